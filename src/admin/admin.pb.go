@@ -83,30 +83,128 @@ func (m *ClusterInfo) GetDeploymentID() string {
 	return ""
 }
 
+// StorageInfo summarizes the storage used by the whole cluster.
+type StorageInfo struct {
+	// logical_bytes is the total size of the data in the head commits of all
+	// user repos' branches, as reported by InspectRepo. System repos aren't
+	// counted.
+	LogicalBytes uint64 `protobuf:"varint,1,opt,name=logical_bytes,json=logicalBytes,proto3" json:"logical_bytes,omitempty"`
+	// physical_bytes is the total size of the chunks in object storage, after
+	// deduplication and compression.
+	PhysicalBytes uint64 `protobuf:"varint,2,opt,name=physical_bytes,json=physicalBytes,proto3" json:"physical_bytes,omitempty"`
+	ChunkCount    uint64 `protobuf:"varint,3,opt,name=chunk_count,json=chunkCount,proto3" json:"chunk_count,omitempty"`
+	// temporary_bytes is the size of the chunks referenced by temporary
+	// filesets, which expire unless they are added to a commit.
+	TemporaryBytes uint64 `protobuf:"varint,4,opt,name=temporary_bytes,json=temporaryBytes,proto3" json:"temporary_bytes,omitempty"`
+	// reclaimable_bytes is the size of the chunks that are no longer
+	// referenced and will be deleted by garbage collection.
+	ReclaimableBytes     uint64   `protobuf:"varint,5,opt,name=reclaimable_bytes,json=reclaimableBytes,proto3" json:"reclaimable_bytes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StorageInfo) Reset()         { *m = StorageInfo{} }
+func (m *StorageInfo) String() string { return proto.CompactTextString(m) }
+func (*StorageInfo) ProtoMessage()    {}
+func (*StorageInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8595c8dce2486799, []int{1}
+}
+func (m *StorageInfo) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StorageInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StorageInfo.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StorageInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StorageInfo.Merge(m, src)
+}
+func (m *StorageInfo) XXX_Size() int {
+	return m.Size()
+}
+func (m *StorageInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_StorageInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StorageInfo proto.InternalMessageInfo
+
+func (m *StorageInfo) GetLogicalBytes() uint64 {
+	if m != nil {
+		return m.LogicalBytes
+	}
+	return 0
+}
+
+func (m *StorageInfo) GetPhysicalBytes() uint64 {
+	if m != nil {
+		return m.PhysicalBytes
+	}
+	return 0
+}
+
+func (m *StorageInfo) GetChunkCount() uint64 {
+	if m != nil {
+		return m.ChunkCount
+	}
+	return 0
+}
+
+func (m *StorageInfo) GetTemporaryBytes() uint64 {
+	if m != nil {
+		return m.TemporaryBytes
+	}
+	return 0
+}
+
+func (m *StorageInfo) GetReclaimableBytes() uint64 {
+	if m != nil {
+		return m.ReclaimableBytes
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*ClusterInfo)(nil), "admin_v2.ClusterInfo")
+	proto.RegisterType((*StorageInfo)(nil), "admin_v2.StorageInfo")
 }
 
 func init() { proto.RegisterFile("admin/admin.proto", fileDescriptor_8595c8dce2486799) }
 
 var fileDescriptor_8595c8dce2486799 = []byte{
-	// 252 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x4c, 0x4c, 0xc9, 0xcd,
-	0xcc, 0xd3, 0x07, 0x93, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x1c, 0x60, 0x4e, 0x7c, 0x99,
-	0x91, 0x94, 0x74, 0x7a, 0x7e, 0x7e, 0x7a, 0x4e, 0xaa, 0x3e, 0x58, 0x3c, 0xa9, 0x34, 0x4d, 0x3f,
-	0x35, 0xb7, 0xa0, 0xa4, 0x12, 0xa2, 0x4c, 0x4a, 0x24, 0x3d, 0x3f, 0x3d, 0x1f, 0xcc, 0xd4, 0x07,
-	0xb1, 0x20, 0xa2, 0x4a, 0x31, 0x5c, 0xdc, 0xce, 0x39, 0xa5, 0xc5, 0x25, 0xa9, 0x45, 0x9e, 0x79,
-	0x69, 0xf9, 0x42, 0x62, 0x5c, 0x4c, 0x99, 0x29, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x9c, 0x4e, 0x6c,
-	0x8f, 0xee, 0xc9, 0x33, 0x79, 0xba, 0x04, 0x31, 0x65, 0xa6, 0x08, 0x99, 0x72, 0xf1, 0xa6, 0xa4,
-	0x16, 0xe4, 0xe4, 0x57, 0xe6, 0xa6, 0xe6, 0x95, 0xc4, 0x67, 0xa6, 0x48, 0x30, 0x81, 0x95, 0x08,
-	0x3c, 0xba, 0x27, 0xcf, 0xe3, 0x02, 0x97, 0xf0, 0x74, 0x09, 0xe2, 0x41, 0x28, 0xf3, 0x4c, 0x31,
-	0xf2, 0xe0, 0x62, 0x76, 0x0c, 0xf0, 0x14, 0x72, 0xe4, 0xe2, 0xf3, 0xcc, 0x2b, 0x2e, 0x48, 0x4d,
-	0x2e, 0x81, 0xda, 0x25, 0x24, 0xa6, 0x07, 0x71, 0xaa, 0x1e, 0xcc, 0xa9, 0x7a, 0xae, 0x20, 0xa7,
-	0x4a, 0x89, 0xea, 0xc1, 0x3c, 0xa3, 0x87, 0xe4, 0x2c, 0x25, 0x06, 0x27, 0xcb, 0x13, 0x8f, 0xe4,
-	0x18, 0x2f, 0x3c, 0x92, 0x63, 0x7c, 0xf0, 0x48, 0x8e, 0x31, 0x4a, 0x3b, 0x3d, 0xb3, 0x24, 0xa3,
-	0x34, 0x49, 0x2f, 0x39, 0x3f, 0x57, 0xbf, 0x20, 0x31, 0x39, 0xa3, 0x32, 0x25, 0xb5, 0x08, 0x99,
-	0x55, 0x66, 0xa4, 0x5f, 0x5c, 0x94, 0x0c, 0x09, 0xa5, 0x24, 0x36, 0xb0, 0x1d, 0xc6, 0x80, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x83, 0x02, 0xab, 0x67, 0x3b, 0x01, 0x00, 0x00,
+	// 374 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xcd, 0x6a, 0xab, 0x40,
+	0x14, 0xc7, 0xa3, 0xc9, 0x0d, 0xf7, 0x4e, 0x3e, 0x6e, 0x22, 0xf7, 0x86, 0x90, 0x42, 0x52, 0x2c,
+	0xa5, 0x85, 0x80, 0x42, 0x4a, 0x17, 0x5d, 0xe6, 0xa3, 0x0b, 0x77, 0x25, 0xdd, 0x95, 0x82, 0xe8,
+	0x38, 0x51, 0xe9, 0xe8, 0x0c, 0xe3, 0x18, 0xf0, 0x19, 0xfa, 0x62, 0x5d, 0xe6, 0x09, 0x42, 0xf1,
+	0x49, 0x8a, 0x47, 0x93, 0xba, 0xec, 0x46, 0x8e, 0xbf, 0xf3, 0xe3, 0x7f, 0xe0, 0xaf, 0x68, 0xe8,
+	0x78, 0x51, 0x18, 0x9b, 0xf0, 0x34, 0xb8, 0x60, 0x92, 0x69, 0xbf, 0xe1, 0xc5, 0xde, 0x2f, 0x26,
+	0x17, 0x3e, 0x63, 0x3e, 0x25, 0x26, 0x70, 0x37, 0xdd, 0x99, 0x24, 0xe2, 0x32, 0x2b, 0xb5, 0xc9,
+	0x3f, 0x9f, 0xf9, 0x0c, 0x46, 0xb3, 0x98, 0x4a, 0xaa, 0xbf, 0xa2, 0xce, 0x9a, 0xa6, 0x89, 0x24,
+	0xc2, 0x8a, 0x77, 0x4c, 0x1b, 0x21, 0x35, 0xf4, 0xc6, 0xca, 0xa5, 0x72, 0xfb, 0x67, 0xd5, 0xce,
+	0x8f, 0x33, 0xd5, 0xda, 0x6c, 0xd5, 0xd0, 0xd3, 0xee, 0x51, 0xcf, 0x23, 0x9c, 0xb2, 0x2c, 0x22,
+	0xb1, 0xb4, 0x43, 0x6f, 0xac, 0x82, 0x32, 0xc8, 0x8f, 0xb3, 0xee, 0xe6, 0xbc, 0xb0, 0x36, 0xdb,
+	0xee, 0xb7, 0x66, 0x79, 0xfa, 0x41, 0x41, 0x9d, 0x67, 0xc9, 0x84, 0xe3, 0x13, 0x88, 0xbf, 0x42,
+	0x3d, 0xca, 0xfc, 0x10, 0x3b, 0xd4, 0x76, 0x33, 0x49, 0x12, 0xb8, 0xd4, 0xda, 0x76, 0x2b, 0xb8,
+	0x2a, 0x98, 0x76, 0x8d, 0xfa, 0x3c, 0xc8, 0x92, 0x9a, 0xa5, 0x82, 0xd5, 0x3b, 0xd1, 0x52, 0x9b,
+	0xa1, 0x0e, 0x0e, 0xd2, 0xf8, 0xcd, 0xc6, 0x2c, 0x8d, 0xe5, 0xb8, 0x09, 0x0e, 0x02, 0xb4, 0x2e,
+	0x88, 0x76, 0x83, 0xfe, 0x4a, 0x12, 0x71, 0x26, 0x1c, 0x91, 0x55, 0x41, 0x2d, 0x90, 0xfa, 0x67,
+	0x5c, 0x26, 0xcd, 0xd1, 0x50, 0x10, 0x4c, 0x9d, 0x30, 0x72, 0x5c, 0x4a, 0x2a, 0xf5, 0x17, 0xa8,
+	0x83, 0xda, 0x02, 0xe4, 0xc5, 0xbb, 0x82, 0x9a, 0xcb, 0x27, 0x4b, 0x5b, 0xa2, 0xbe, 0x15, 0x27,
+	0x9c, 0x60, 0x59, 0xf5, 0xa7, 0x8d, 0x8c, 0xb2, 0x7e, 0xe3, 0x54, 0xbf, 0xf1, 0x58, 0xd4, 0x3f,
+	0xf9, 0x6f, 0x9c, 0x3e, 0x90, 0x51, 0xab, 0x5a, 0x6f, 0xd4, 0x22, 0xaa, 0x8e, 0x7e, 0x12, 0x51,
+	0xab, 0x53, 0x6f, 0xac, 0x1e, 0x3e, 0xf2, 0xa9, 0x72, 0xc8, 0xa7, 0xca, 0x67, 0x3e, 0x55, 0x5e,
+	0xe6, 0x7e, 0x28, 0x83, 0xd4, 0x35, 0x30, 0x8b, 0x4c, 0xee, 0xe0, 0x20, 0xf3, 0x88, 0xa8, 0x4f,
+	0xfb, 0x85, 0x99, 0x08, 0x5c, 0xfe, 0x3c, 0x6e, 0x1b, 0x6e, 0xdc, 0x7d, 0x0d, 0x00, 0x31, 0xa2,
+	0xc7, 0x31, 0x52, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -122,6 +220,7 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type APIClient interface {
 	InspectCluster(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*ClusterInfo, error)
+	InspectStorage(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*StorageInfo, error)
 }
 
 type aPIClient struct {
@@ -141,9 +240,19 @@ func (c *aPIClient) InspectCluster(ctx context.Context, in *types.Empty, opts ..
 	return out, nil
 }
 
+func (c *aPIClient) InspectStorage(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*StorageInfo, error) {
+	out := new(StorageInfo)
+	err := c.cc.Invoke(ctx, "/admin_v2.API/InspectStorage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // APIServer is the server API for API service.
 type APIServer interface {
 	InspectCluster(context.Context, *types.Empty) (*ClusterInfo, error)
+	InspectStorage(context.Context, *types.Empty) (*StorageInfo, error)
 }
 
 // UnimplementedAPIServer can be embedded to have forward compatible implementations.
@@ -153,6 +262,9 @@ type UnimplementedAPIServer struct {
 func (*UnimplementedAPIServer) InspectCluster(ctx context.Context, req *types.Empty) (*ClusterInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method InspectCluster not implemented")
 }
+func (*UnimplementedAPIServer) InspectStorage(ctx context.Context, req *types.Empty) (*StorageInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InspectStorage not implemented")
+}
 
 func RegisterAPIServer(s *grpc.Server, srv APIServer) {
 	s.RegisterService(&_API_serviceDesc, srv)
@@ -176,6 +288,24 @@ func _API_InspectCluster_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_InspectStorage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(types.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).InspectStorage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/admin_v2.API/InspectStorage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).InspectStorage(ctx, req.(*types.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _API_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "admin_v2.API",
 	HandlerType: (*APIServer)(nil),
@@ -184,6 +314,10 @@ var _API_serviceDesc = grpc.ServiceDesc{
 			MethodName: "InspectCluster",
 			Handler:    _API_InspectCluster_Handler,
 		},
+		{
+			MethodName: "InspectStorage",
+			Handler:    _API_InspectStorage_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "admin/admin.proto",
@@ -230,6 +364,58 @@ func (m *ClusterInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *StorageInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StorageInfo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *StorageInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.ReclaimableBytes != 0 {
+		i = encodeVarintAdmin(dAtA, i, uint64(m.ReclaimableBytes))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.TemporaryBytes != 0 {
+		i = encodeVarintAdmin(dAtA, i, uint64(m.TemporaryBytes))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.ChunkCount != 0 {
+		i = encodeVarintAdmin(dAtA, i, uint64(m.ChunkCount))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.PhysicalBytes != 0 {
+		i = encodeVarintAdmin(dAtA, i, uint64(m.PhysicalBytes))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.LogicalBytes != 0 {
+		i = encodeVarintAdmin(dAtA, i, uint64(m.LogicalBytes))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintAdmin(dAtA []byte, offset int, v uint64) int {
 	offset -= sovAdmin(v)
 	base := offset
@@ -261,6 +447,33 @@ func (m *ClusterInfo) Size() (n int) {
 	return n
 }
 
+func (m *StorageInfo) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LogicalBytes != 0 {
+		n += 1 + sovAdmin(uint64(m.LogicalBytes))
+	}
+	if m.PhysicalBytes != 0 {
+		n += 1 + sovAdmin(uint64(m.PhysicalBytes))
+	}
+	if m.ChunkCount != 0 {
+		n += 1 + sovAdmin(uint64(m.ChunkCount))
+	}
+	if m.TemporaryBytes != 0 {
+		n += 1 + sovAdmin(uint64(m.TemporaryBytes))
+	}
+	if m.ReclaimableBytes != 0 {
+		n += 1 + sovAdmin(uint64(m.ReclaimableBytes))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovAdmin(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -382,6 +595,152 @@ func (m *ClusterInfo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *StorageInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAdmin
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StorageInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StorageInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogicalBytes", wireType)
+			}
+			m.LogicalBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAdmin
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LogicalBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PhysicalBytes", wireType)
+			}
+			m.PhysicalBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAdmin
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PhysicalBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkCount", wireType)
+			}
+			m.ChunkCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAdmin
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunkCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TemporaryBytes", wireType)
+			}
+			m.TemporaryBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAdmin
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TemporaryBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReclaimableBytes", wireType)
+			}
+			m.ReclaimableBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAdmin
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReclaimableBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAdmin(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAdmin
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipAdmin(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
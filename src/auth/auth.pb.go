@@ -70,6 +70,9 @@ const (
 	Permission_CLUSTER_IDENTITY_GET_OIDC_CLIENT           Permission = 128
 	Permission_CLUSTER_IDENTITY_DELETE_OIDC_CLIENT        Permission = 129
 	Permission_CLUSTER_DEBUG_DUMP                         Permission = 131
+	Permission_CLUSTER_INSPECT_STORAGE                    Permission = 149
+	Permission_CLUSTER_GARBAGE_COLLECT                    Permission = 150
+	Permission_CLUSTER_BREAK_REPO_LOCK                    Permission = 151
 	Permission_CLUSTER_LICENSE_ACTIVATE                   Permission = 132
 	Permission_CLUSTER_LICENSE_GET_CODE                   Permission = 133
 	Permission_CLUSTER_LICENSE_ADD_CLUSTER                Permission = 134
@@ -136,6 +139,9 @@ var Permission_name = map[int32]string{
 	128: "CLUSTER_IDENTITY_GET_OIDC_CLIENT",
 	129: "CLUSTER_IDENTITY_DELETE_OIDC_CLIENT",
 	131: "CLUSTER_DEBUG_DUMP",
+	149: "CLUSTER_INSPECT_STORAGE",
+	150: "CLUSTER_GARBAGE_COLLECT",
+	151: "CLUSTER_BREAK_REPO_LOCK",
 	132: "CLUSTER_LICENSE_ACTIVATE",
 	133: "CLUSTER_LICENSE_GET_CODE",
 	134: "CLUSTER_LICENSE_ADD_CLUSTER",
@@ -201,6 +207,9 @@ var Permission_value = map[string]int32{
 	"CLUSTER_IDENTITY_GET_OIDC_CLIENT":           128,
 	"CLUSTER_IDENTITY_DELETE_OIDC_CLIENT":        129,
 	"CLUSTER_DEBUG_DUMP":                         131,
+	"CLUSTER_INSPECT_STORAGE":                    149,
+	"CLUSTER_GARBAGE_COLLECT":                    150,
+	"CLUSTER_BREAK_REPO_LOCK":                    151,
 	"CLUSTER_LICENSE_ACTIVATE":                   132,
 	"CLUSTER_LICENSE_GET_CODE":                   133,
 	"CLUSTER_LICENSE_ADD_CLUSTER":                134,
@@ -3089,177 +3098,179 @@ func init() {
 func init() { proto.RegisterFile("auth/auth.proto", fileDescriptor_712ec48c1eaf43a2) }
 
 var fileDescriptor_712ec48c1eaf43a2 = []byte{
-	// 2715 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x5a, 0x5b, 0x77, 0xdb, 0xc6,
-	0xb5, 0x36, 0x24, 0x4b, 0x22, 0x37, 0x75, 0x81, 0x47, 0x94, 0x44, 0xc1, 0xb6, 0x28, 0x21, 0xf1,
-	0xb1, 0xec, 0x73, 0x22, 0x25, 0xca, 0xc9, 0x39, 0x4e, 0xe2, 0x87, 0xf2, 0x02, 0xd1, 0x48, 0x28,
-	0x92, 0x6b, 0x00, 0xda, 0x71, 0x57, 0x57, 0x51, 0x8a, 0x1c, 0x4b, 0xa8, 0x25, 0x82, 0x01, 0x40,
-	0xd5, 0x4a, 0x9b, 0xb6, 0xe9, 0xfd, 0x92, 0x36, 0xe9, 0x65, 0xf5, 0xbd, 0x3f, 0xa0, 0x2f, 0xed,
-	0x9f, 0x48, 0xef, 0xe9, 0xf5, 0xd1, 0xed, 0xd2, 0x5b, 0x5f, 0xfb, 0x0b, 0xba, 0x30, 0x18, 0x00,
-	0x03, 0x10, 0x54, 0x9c, 0x64, 0xe5, 0x45, 0xc6, 0xec, 0xfd, 0xed, 0xcb, 0xec, 0xd9, 0x33, 0xb3,
-	0xf7, 0xd0, 0xb0, 0xd0, 0x19, 0xba, 0x87, 0xdb, 0xde, 0x9f, 0xad, 0x81, 0x6d, 0xb9, 0x16, 0x9a,
-	0xf1, 0xbe, 0x8d, 0x93, 0x1d, 0x29, 0x7f, 0x60, 0x1d, 0x58, 0x94, 0xb6, 0xed, 0x7d, 0xf9, 0x6c,
-	0xa9, 0x78, 0x60, 0x59, 0x07, 0x47, 0x64, 0x9b, 0x8e, 0xf6, 0x87, 0x0f, 0xb6, 0x5d, 0xf3, 0x98,
-	0x38, 0x6e, 0xe7, 0x78, 0xe0, 0x03, 0xe4, 0x67, 0x61, 0xa1, 0xd4, 0x75, 0xcd, 0x93, 0x8e, 0x4b,
-	0x30, 0x79, 0x7d, 0x48, 0x1c, 0x17, 0x5d, 0x05, 0xb0, 0x2d, 0xcb, 0x35, 0x5c, 0xeb, 0x21, 0xe9,
-	0x17, 0x84, 0x75, 0x61, 0x33, 0x8b, 0xb3, 0x1e, 0x45, 0xf7, 0x08, 0xf2, 0x73, 0x20, 0x46, 0x12,
-	0xce, 0xc0, 0xea, 0x3b, 0xc4, 0x13, 0x19, 0x74, 0xba, 0x87, 0x71, 0x11, 0x8f, 0xe2, 0x8b, 0x2c,
-	0xc2, 0xa5, 0x2a, 0xe9, 0xc4, 0xcd, 0xc8, 0x79, 0x40, 0x3c, 0xd1, 0xd7, 0x24, 0xff, 0x3f, 0x2c,
-	0x63, 0xcb, 0xf5, 0x28, 0x81, 0xc1, 0x27, 0x74, 0xeb, 0x16, 0xac, 0x8c, 0x08, 0x46, 0xde, 0x9d,
-	0x27, 0xf9, 0xf3, 0x09, 0x80, 0xa6, 0x5a, 0xad, 0x54, 0xac, 0xfe, 0x03, 0xf3, 0x00, 0x2d, 0xc3,
-	0xb4, 0xe9, 0x38, 0x43, 0x62, 0x33, 0x24, 0x1b, 0xa1, 0x1b, 0x90, 0xed, 0x1e, 0x99, 0xa4, 0xef,
-	0x1a, 0x66, 0xaf, 0x30, 0xe1, 0xb1, 0xca, 0xb3, 0x67, 0x8f, 0x8b, 0x99, 0x0a, 0x25, 0xaa, 0x55,
-	0x9c, 0xf1, 0xd9, 0x6a, 0x0f, 0x3d, 0x05, 0x73, 0x0c, 0xea, 0x90, 0xae, 0x4d, 0xdc, 0xc2, 0x24,
-	0xd5, 0x34, 0xeb, 0x13, 0x35, 0x4a, 0x43, 0x3b, 0x30, 0x6b, 0x93, 0x9e, 0x69, 0x93, 0xae, 0x6b,
-	0x0c, 0x6d, 0xb3, 0x70, 0x91, 0xaa, 0x5c, 0x38, 0x7b, 0x5c, 0xcc, 0x61, 0x46, 0x6f, 0x63, 0x15,
-	0xe7, 0x02, 0x50, 0xdb, 0x36, 0x3d, 0xdf, 0x9c, 0xae, 0x35, 0x20, 0x4e, 0x61, 0x6a, 0x7d, 0xd2,
-	0xf3, 0xcd, 0x1f, 0xa1, 0xff, 0x85, 0x65, 0x9b, 0xbc, 0x3e, 0x34, 0x6d, 0x62, 0x90, 0xe3, 0x8e,
-	0x79, 0x64, 0x9c, 0x10, 0xdb, 0x7c, 0x60, 0x92, 0x5e, 0x61, 0x7a, 0x5d, 0xd8, 0xcc, 0xe0, 0x3c,
-	0xe3, 0x2a, 0x1e, 0xf3, 0x2e, 0xe3, 0xa1, 0x1b, 0x20, 0x1e, 0x59, 0xdd, 0xce, 0xd1, 0xa1, 0xe5,
-	0xb8, 0x06, 0x9b, 0xf3, 0x0c, 0xc5, 0x2f, 0x84, 0x74, 0x95, 0x92, 0xe5, 0x55, 0x58, 0xa9, 0x11,
-	0xd7, 0x8f, 0xd0, 0xd0, 0xee, 0xb8, 0xa6, 0x15, 0xac, 0x8b, 0xdc, 0x86, 0xc2, 0x28, 0x8b, 0x45,
-	0xfe, 0x45, 0x98, 0xeb, 0xf2, 0x0c, 0x1a, 0xd2, 0xdc, 0xce, 0xe2, 0x16, 0xcb, 0xda, 0xad, 0x28,
-	0xee, 0x38, 0x8e, 0x94, 0x75, 0x58, 0xd1, 0xd2, 0x2d, 0x7e, 0x1c, 0xad, 0x12, 0x14, 0xb4, 0x31,
-	0xce, 0xca, 0xbf, 0x14, 0x20, 0x4b, 0x33, 0x42, 0xed, 0x3f, 0xb0, 0x50, 0x01, 0x66, 0x9c, 0xe1,
-	0xfe, 0xe7, 0x49, 0xd7, 0x65, 0x79, 0x10, 0x0c, 0x91, 0x06, 0x40, 0x1e, 0x0d, 0x4c, 0x66, 0x7b,
-	0x82, 0xda, 0x96, 0xb6, 0xfc, 0x8d, 0xb6, 0x15, 0x6c, 0xb4, 0x2d, 0x3d, 0xd8, 0x68, 0xe5, 0x95,
-	0x7f, 0x3f, 0x2e, 0x2e, 0xf4, 0xf6, 0x5f, 0x92, 0x23, 0x29, 0xf9, 0xdd, 0x7f, 0x14, 0x05, 0xcc,
-	0xa9, 0x41, 0xff, 0x07, 0xb3, 0x87, 0x1d, 0xe7, 0x90, 0xf4, 0x58, 0x96, 0xd2, 0x8c, 0x29, 0x2f,
-	0x06, 0xa2, 0x94, 0x68, 0x78, 0x08, 0x19, 0xe7, 0x7c, 0xa0, 0x9f, 0xbc, 0x9f, 0x85, 0xc5, 0xd2,
-	0xd0, 0x3d, 0x24, 0x7d, 0xd7, 0xec, 0x72, 0x7b, 0xf8, 0x7f, 0x00, 0x2c, 0xb3, 0xd7, 0x35, 0x1c,
-	0x6f, 0x47, 0xf8, 0x13, 0x28, 0xcf, 0x9d, 0x3d, 0x2e, 0x66, 0xbd, 0xd0, 0x68, 0x74, 0x9b, 0x64,
-	0x3d, 0x00, 0xfd, 0x44, 0xab, 0x90, 0x31, 0x03, 0xc3, 0x13, 0xfe, 0x64, 0x4d, 0xa6, 0xff, 0x05,
-	0xc8, 0xc7, 0xf5, 0x3f, 0xd9, 0x8e, 0x5f, 0x80, 0xb9, 0x7b, 0x87, 0x56, 0xe9, 0x58, 0x0d, 0xb2,
-	0xe4, 0x2d, 0x01, 0xe6, 0x03, 0x0a, 0x53, 0x21, 0x41, 0x66, 0xe8, 0x10, 0xbb, 0xdf, 0x39, 0x66,
-	0x1e, 0xe2, 0x70, 0xfc, 0x89, 0xc4, 0x58, 0xb6, 0x61, 0x0a, 0x5b, 0x47, 0xc4, 0x41, 0xdb, 0x30,
-	0x65, 0x7b, 0x1f, 0x05, 0x61, 0x7d, 0x72, 0x33, 0xb7, 0xb3, 0x1a, 0x26, 0x0e, 0x65, 0xfb, 0x7f,
-	0x95, 0xbe, 0x6b, 0x9f, 0x62, 0x1f, 0x27, 0xdd, 0x02, 0x88, 0x88, 0x48, 0x84, 0xc9, 0x87, 0xe4,
-	0x94, 0xf9, 0xec, 0x7d, 0xa2, 0x3c, 0x4c, 0x9d, 0x74, 0x8e, 0x86, 0x84, 0x7a, 0x9a, 0xc1, 0xfe,
-	0xe0, 0xa5, 0x89, 0x5b, 0x82, 0xfc, 0x33, 0x01, 0x72, 0x9e, 0x68, 0xd9, 0xec, 0xf7, 0xcc, 0xfe,
-	0x01, 0x7a, 0x19, 0x66, 0x48, 0xdf, 0xb5, 0xcd, 0xd0, 0xf8, 0x46, 0xcc, 0x38, 0x83, 0x6d, 0x29,
-	0x3e, 0xc6, 0x77, 0x22, 0x90, 0x90, 0x5e, 0x81, 0x59, 0x9e, 0x91, 0xe2, 0xc8, 0xd3, 0xbc, 0x23,
-	0xb9, 0x9d, 0xf9, 0xf8, 0xcc, 0x78, 0xc7, 0x54, 0xc8, 0x60, 0xe2, 0x58, 0x43, 0xbb, 0x4b, 0xd0,
-	0x0d, 0xb8, 0xe8, 0x9e, 0x0e, 0xfc, 0x55, 0x98, 0xdf, 0x59, 0x8a, 0x84, 0x18, 0x40, 0x3f, 0x1d,
-	0x10, 0x4c, 0x21, 0x08, 0xc1, 0x45, 0xba, 0x60, 0x7e, 0x9a, 0xd0, 0x6f, 0xf9, 0x6b, 0x02, 0x4c,
-	0xb5, 0x1d, 0x62, 0x3b, 0xe8, 0x65, 0xc8, 0x06, 0x4b, 0x18, 0xcc, 0xef, 0x6a, 0xa8, 0x8d, 0x42,
-	0xe8, 0x5f, 0xca, 0xf7, 0xe7, 0x16, 0xe1, 0xa5, 0xdb, 0x30, 0x1f, 0x67, 0x7e, 0xa8, 0x40, 0x3f,
-	0x82, 0xe9, 0x9a, 0x6d, 0x0d, 0x07, 0x0e, 0x7a, 0x1e, 0xa6, 0x0f, 0xe8, 0x17, 0xf3, 0xe0, 0x72,
-	0xe8, 0x81, 0x0f, 0x60, 0xff, 0xf8, 0xf6, 0x19, 0x54, 0x7a, 0x11, 0x72, 0x1c, 0xf9, 0x43, 0x59,
-	0x7e, 0x47, 0x80, 0x8b, 0x5e, 0x78, 0xc3, 0xd8, 0x08, 0x51, 0x6c, 0xd0, 0x0b, 0x90, 0x1b, 0x10,
-	0xfb, 0xd8, 0x74, 0x1c, 0xd3, 0xea, 0x3b, 0x85, 0x89, 0xf5, 0xc9, 0xcd, 0x79, 0xee, 0xa4, 0x6a,
-	0x85, 0x3c, 0xcc, 0xe3, 0xd0, 0x6d, 0x98, 0xb7, 0x59, 0xf0, 0x0d, 0x2f, 0xee, 0x4e, 0x61, 0x92,
-	0x4a, 0x8e, 0x59, 0x9b, 0x39, 0x9b, 0x1b, 0x39, 0xf2, 0x23, 0x10, 0xbd, 0x4d, 0x6b, 0xd9, 0xe6,
-	0x1b, 0xe1, 0x89, 0xf0, 0x0c, 0x64, 0x02, 0x10, 0x3b, 0x2f, 0x2f, 0x8d, 0xe8, 0xc2, 0x21, 0xe4,
-	0x23, 0xfa, 0x2d, 0xff, 0x4a, 0x80, 0x4b, 0x9c, 0x69, 0xb6, 0xd3, 0xd7, 0x00, 0x3a, 0x01, 0xb1,
-	0x47, 0xad, 0x67, 0x30, 0x47, 0x41, 0xcf, 0x41, 0xd6, 0xe9, 0xb8, 0xa6, 0x43, 0x6f, 0xac, 0x73,
-	0x4c, 0x45, 0x28, 0xf4, 0x0c, 0xcc, 0x50, 0x6a, 0xff, 0x80, 0x45, 0x26, 0x55, 0x20, 0xc0, 0xa0,
-	0x2b, 0x90, 0x1d, 0xd8, 0x66, 0xbf, 0x6b, 0x0e, 0x3a, 0x47, 0xfe, 0x4d, 0x8b, 0x23, 0x82, 0xbc,
-	0x0b, 0x4b, 0x35, 0xe2, 0x46, 0x72, 0xce, 0x47, 0x0b, 0x9a, 0x3c, 0x80, 0x8d, 0xb8, 0x9e, 0x5d,
-	0xcb, 0x6e, 0x05, 0x56, 0x3e, 0xe2, 0x42, 0xc4, 0x3c, 0x9f, 0x48, 0x7a, 0x4e, 0x60, 0x39, 0xe9,
-	0x39, 0x8b, 0x79, 0x62, 0x01, 0x85, 0x27, 0x4c, 0xbc, 0x7c, 0x70, 0x34, 0x4e, 0xd0, 0x02, 0xc3,
-	0x1f, 0xc8, 0x6f, 0x42, 0x61, 0xcf, 0xea, 0x99, 0x0f, 0x4e, 0xb9, 0x33, 0xea, 0x93, 0x98, 0x4f,
-	0x64, 0x7e, 0x92, 0x37, 0x7f, 0x19, 0x56, 0x53, 0xcc, 0xb3, 0x6b, 0xdb, 0x5f, 0xbc, 0x8f, 0xed,
-	0x98, 0x7c, 0x87, 0x86, 0x32, 0xc5, 0x02, 0xda, 0x82, 0x99, 0x7d, 0x9f, 0xc4, 0xf4, 0xe4, 0xd3,
-	0xce, 0x6c, 0x1c, 0x80, 0xe4, 0xcf, 0x41, 0x4e, 0x23, 0x34, 0x9e, 0xb4, 0x92, 0xc8, 0xc3, 0x54,
-	0xdf, 0xea, 0x77, 0x83, 0x73, 0xc1, 0x1f, 0x78, 0x54, 0x5a, 0xaa, 0xb1, 0x18, 0xf8, 0x03, 0x74,
-	0x0d, 0xe6, 0xbb, 0x56, 0xff, 0x84, 0xd8, 0x9e, 0xb4, 0x41, 0x6c, 0x9b, 0x16, 0x02, 0x19, 0x5a,
-	0xc6, 0x30, 0xaa, 0x62, 0xdb, 0xf2, 0x12, 0x2c, 0xd6, 0x88, 0xeb, 0xdd, 0xe5, 0x75, 0xeb, 0xc0,
-	0x0c, 0x4b, 0xb1, 0x7b, 0x90, 0x8f, 0x93, 0xd9, 0x04, 0x6e, 0x40, 0xf6, 0xc8, 0x23, 0x18, 0x43,
-	0xfb, 0x88, 0x15, 0x03, 0xb4, 0x74, 0xa5, 0xa8, 0x36, 0xae, 0xe3, 0x0c, 0x65, 0xb7, 0x6d, 0xba,
-	0x00, 0x7e, 0xcd, 0xc0, 0xdc, 0xa2, 0x03, 0xb9, 0x46, 0x15, 0x63, 0x6b, 0x3f, 0x51, 0x93, 0xd3,
-	0xe5, 0xda, 0xb7, 0x82, 0x12, 0xc9, 0x1f, 0xa0, 0x55, 0x98, 0x74, 0x5d, 0x7f, 0x62, 0x93, 0xe5,
-	0x99, 0xb3, 0xc7, 0xc5, 0x49, 0x5d, 0xaf, 0x63, 0x8f, 0x26, 0x3f, 0xc3, 0x16, 0x6b, 0x3f, 0x59,
-	0xa3, 0xe7, 0x61, 0x8a, 0x2f, 0x25, 0xfc, 0x81, 0xbc, 0x05, 0xcb, 0x98, 0x9c, 0x58, 0x0f, 0x89,
-	0x77, 0xa6, 0x24, 0x2d, 0xa7, 0xe0, 0x57, 0x61, 0x65, 0x04, 0xcf, 0xd2, 0x64, 0x8f, 0xd6, 0x93,
-	0xfe, 0x19, 0xbf, 0x6b, 0xd9, 0xde, 0x4d, 0x13, 0xe8, 0x3a, 0xaf, 0x10, 0x59, 0x0e, 0x2f, 0x13,
-	0x7f, 0x43, 0xb0, 0x11, 0x2b, 0x24, 0x13, 0xea, 0x98, 0xa9, 0xbb, 0x90, 0xf7, 0xd3, 0x75, 0x8f,
-	0x1c, 0xef, 0x13, 0xdb, 0xe1, 0x7c, 0xa6, 0xd2, 0x81, 0xcf, 0x74, 0xe0, 0x5d, 0x35, 0x9d, 0x5e,
-	0x8f, 0xa9, 0xf7, 0x3e, 0x3d, 0x9b, 0x36, 0x39, 0xb6, 0x4e, 0x08, 0xdb, 0x05, 0x6c, 0x24, 0xaf,
-	0xc0, 0x52, 0x42, 0x2f, 0x33, 0x88, 0x40, 0xac, 0x05, 0xce, 0x04, 0xb9, 0x70, 0x1b, 0xae, 0xd4,
-	0x38, 0x07, 0x47, 0x8e, 0xa1, 0xd8, 0x3e, 0x14, 0x92, 0xe7, 0xca, 0x7f, 0xc3, 0x25, 0x4e, 0x23,
-	0x5b, 0xa3, 0xe5, 0xd8, 0xc5, 0x1a, 0xc5, 0xe2, 0x3a, 0x2c, 0xd4, 0x88, 0x4b, 0xaf, 0xf7, 0x73,
-	0xa7, 0x2a, 0x3f, 0x4b, 0xfd, 0x64, 0x40, 0xa6, 0xf4, 0x4a, 0xb2, 0x64, 0xc8, 0x72, 0x35, 0x81,
-	0x17, 0x66, 0xe5, 0x91, 0x6b, 0x77, 0xba, 0x6e, 0xb8, 0xa2, 0xe1, 0x0c, 0x6b, 0xb0, 0x9a, 0xc2,
-	0x63, 0x6a, 0x6f, 0xc2, 0x34, 0x4d, 0x89, 0xa0, 0x08, 0x40, 0xe1, 0x96, 0x0d, 0x4b, 0x7c, 0xcc,
-	0x10, 0x72, 0xc5, 0xcb, 0x1a, 0xc7, 0xb5, 0xec, 0xd1, 0x34, 0xdb, 0xe4, 0xd3, 0x2c, 0x5d, 0x0b,
-	0x4b, 0x3d, 0x09, 0x0a, 0xa3, 0x4a, 0xd8, 0xfa, 0xdc, 0x86, 0xb5, 0x44, 0x5a, 0x7e, 0x88, 0x14,
-	0x94, 0x37, 0xa0, 0x38, 0x56, 0x9a, 0x19, 0x58, 0x87, 0xb5, 0x2a, 0x39, 0x22, 0x2e, 0x51, 0xbc,
-	0x6a, 0x97, 0xf4, 0x46, 0x83, 0xb5, 0x01, 0xc5, 0xb1, 0x08, 0x5f, 0xc9, 0xcd, 0x7f, 0xcd, 0x03,
-	0x44, 0xd7, 0x02, 0xca, 0xc1, 0x4c, 0xbb, 0xf1, 0x6a, 0xa3, 0x79, 0xaf, 0x21, 0x5e, 0x40, 0x97,
-	0x61, 0xa5, 0x52, 0x6f, 0x6b, 0xba, 0x82, 0x8d, 0xbd, 0x66, 0x55, 0xdd, 0xbd, 0x6f, 0x94, 0xd5,
-	0x46, 0x55, 0x6d, 0xd4, 0x34, 0xb1, 0x87, 0x0a, 0x90, 0x0f, 0x98, 0x35, 0x45, 0x8f, 0x38, 0x04,
-	0x5d, 0x86, 0x65, 0x9e, 0xd3, 0x2a, 0x55, 0xee, 0x54, 0x8d, 0x7a, 0xb3, 0xa6, 0x89, 0x3f, 0x15,
-	0xd0, 0x2a, 0x2c, 0x05, 0xcc, 0x52, 0x5b, 0xbf, 0x63, 0x94, 0x2a, 0xba, 0x7a, 0xb7, 0xa4, 0x2b,
-	0xe2, 0x03, 0xde, 0x1c, 0x65, 0x55, 0x95, 0x90, 0x79, 0x30, 0xc2, 0xf4, 0x34, 0x57, 0x9a, 0x8d,
-	0x5d, 0xb5, 0x26, 0x1e, 0x8e, 0x30, 0xb5, 0x88, 0x69, 0xa2, 0x0d, 0xb8, 0x32, 0x22, 0x89, 0x9b,
-	0xe5, 0xa6, 0x6e, 0xe8, 0xcd, 0x57, 0x95, 0x86, 0xf8, 0x7d, 0x01, 0x5d, 0x83, 0x8d, 0x18, 0x84,
-	0xcd, 0xb6, 0x86, 0x9b, 0xed, 0x96, 0xb1, 0xa7, 0xec, 0x95, 0x15, 0xac, 0x89, 0xc7, 0xa9, 0x3e,
-	0x50, 0x8c, 0x26, 0xf6, 0xd1, 0x7a, 0x8a, 0x19, 0x5f, 0x41, 0x5b, 0xf3, 0xc4, 0x2d, 0x54, 0x84,
-	0xcb, 0x31, 0x84, 0xf2, 0x9a, 0x8e, 0x4b, 0x15, 0xe6, 0x86, 0x26, 0x0e, 0xd0, 0x1a, 0x48, 0x31,
-	0x00, 0x56, 0x34, 0xbd, 0x89, 0x15, 0xe6, 0xe7, 0xeb, 0x68, 0x1b, 0x6e, 0x8e, 0x98, 0x68, 0x29,
-	0x78, 0x4f, 0xd5, 0x34, 0xb5, 0xd9, 0xd0, 0x8c, 0xdd, 0x26, 0x36, 0x5a, 0x58, 0x6d, 0x54, 0xd4,
-	0x56, 0xa9, 0x2e, 0xfe, 0x40, 0x40, 0xd7, 0x41, 0x4e, 0x44, 0xb4, 0xae, 0xe8, 0x8a, 0xa1, 0xbc,
-	0xd6, 0x52, 0xb1, 0x52, 0x0d, 0x0c, 0xbf, 0x2d, 0xa0, 0xa7, 0xa1, 0x98, 0xb0, 0x7c, 0xb7, 0xf9,
-	0xaa, 0x42, 0x3d, 0x0f, 0x50, 0x3f, 0x14, 0xd0, 0x53, 0xb0, 0x16, 0x47, 0x35, 0xf5, 0x92, 0xae,
-	0x18, 0xb8, 0x19, 0xc6, 0xf2, 0x27, 0x02, 0x3f, 0x4b, 0xa5, 0xa1, 0x2b, 0xb8, 0x85, 0x55, 0x4d,
-	0x89, 0x96, 0xd9, 0xe6, 0x03, 0xc5, 0x01, 0xee, 0x28, 0x25, 0xac, 0x97, 0x95, 0x92, 0x2e, 0x3a,
-	0x63, 0x54, 0xf8, 0x2b, 0x5e, 0x55, 0x44, 0x17, 0x6d, 0xc0, 0xd5, 0x14, 0x00, 0x97, 0x2f, 0x43,
-	0x5e, 0x87, 0x5a, 0x55, 0x1a, 0xba, 0xaa, 0xdf, 0xe7, 0xd3, 0xe2, 0x24, 0x15, 0xc0, 0x25, 0xd5,
-	0x17, 0x52, 0x01, 0x15, 0xac, 0x78, 0x33, 0x56, 0xab, 0x2d, 0xf1, 0x51, 0x2a, 0xa0, 0xdd, 0xaa,
-	0x06, 0x80, 0x53, 0x7e, 0x3d, 0x43, 0x40, 0x5d, 0xd5, 0x74, 0x8f, 0xad, 0x89, 0x6f, 0xa0, 0x2b,
-	0x50, 0x48, 0x75, 0xc1, 0x93, 0xfe, 0x62, 0xaa, 0x7a, 0xb6, 0x80, 0x1e, 0xe0, 0x4b, 0xe8, 0x3a,
-	0x3c, 0x35, 0xce, 0x41, 0xaf, 0x1a, 0x30, 0x2a, 0x75, 0x55, 0x69, 0xe8, 0xe2, 0x9b, 0xa9, 0x40,
-	0xe6, 0x28, 0x0f, 0xfc, 0x32, 0xfa, 0xaf, 0x28, 0x5f, 0xe2, 0x0e, 0x73, 0x30, 0x4d, 0xfc, 0x0a,
-	0xba, 0x06, 0xeb, 0xa9, 0x8e, 0xf3, 0xda, 0xbe, 0x2a, 0xa0, 0xcd, 0x14, 0xbb, 0x6c, 0x06, 0x3c,
-	0xf2, 0x2d, 0x01, 0xad, 0x00, 0x0a, 0x90, 0x55, 0xa5, 0xdc, 0xae, 0x19, 0xd5, 0xf6, 0x5e, 0x4b,
-	0xfc, 0xba, 0x80, 0xae, 0x46, 0x21, 0xaa, 0xab, 0x15, 0xa5, 0xc1, 0xa7, 0xd2, 0x37, 0x52, 0xd9,
-	0x61, 0x9a, 0x7c, 0x53, 0x40, 0xeb, 0x51, 0x08, 0x43, 0xe9, 0x6a, 0xd5, 0x60, 0x34, 0xf1, 0x5b,
-	0xb1, 0x94, 0x0e, 0x10, 0x2c, 0x32, 0x01, 0xe8, 0xdb, 0xa9, 0x20, 0x36, 0x8d, 0x00, 0xf4, 0x1d,
-	0x01, 0xc9, 0x51, 0x4e, 0x06, 0x20, 0x1a, 0x3a, 0x46, 0xd4, 0xc4, 0xef, 0x0a, 0x48, 0x8a, 0x0e,
-	0x3f, 0xb6, 0x50, 0x9a, 0x52, 0xc1, 0x8a, 0x2e, 0xbe, 0xe3, 0x1d, 0x8c, 0xf9, 0x48, 0x5e, 0xd3,
-	0x19, 0x47, 0x13, 0xdf, 0x15, 0x10, 0x82, 0x39, 0x7f, 0xc4, 0xcc, 0x8a, 0x3f, 0x12, 0xd0, 0x22,
-	0xcc, 0x33, 0x9a, 0xda, 0xd0, 0x5a, 0x4a, 0x45, 0x17, 0x7f, 0x9c, 0x08, 0x23, 0x75, 0xb0, 0x54,
-	0xaf, 0x8b, 0xdf, 0x13, 0xd0, 0x3c, 0x64, 0xb1, 0xd2, 0x6a, 0x1a, 0x58, 0x29, 0x55, 0xc5, 0xf7,
-	0x04, 0xb4, 0x00, 0x40, 0xc7, 0xf7, 0xb0, 0xaa, 0x2b, 0xe2, 0xaf, 0xa9, 0x75, 0x4a, 0x48, 0x9e,
-	0xf3, 0xbf, 0x11, 0x90, 0x08, 0x39, 0xca, 0x62, 0xb6, 0x7f, 0x2b, 0xa0, 0x02, 0x2c, 0x52, 0x0a,
-	0xb3, 0x6c, 0x54, 0x9a, 0x7b, 0x7b, 0xaa, 0x2e, 0xfe, 0x4e, 0x40, 0x4b, 0x20, 0x52, 0x8e, 0x3f,
-	0x73, 0x9f, 0xfc, 0x7b, 0xea, 0x17, 0xa7, 0x22, 0x60, 0xfc, 0x21, 0x62, 0xb0, 0x68, 0x94, 0x71,
-	0xa9, 0x51, 0xb9, 0x23, 0xfe, 0x31, 0xa1, 0x88, 0x91, 0xdf, 0x1f, 0x51, 0xc4, 0x18, 0x7f, 0x12,
-	0xd0, 0x32, 0x5c, 0x8a, 0xb9, 0xb4, 0xab, 0xd6, 0x15, 0xf1, 0xcf, 0x34, 0x4c, 0x91, 0x1e, 0x4a,
-	0xfc, 0x0b, 0xcd, 0x1a, 0x4a, 0xf4, 0x72, 0xa1, 0xa5, 0xb6, 0x94, 0xba, 0xda, 0x50, 0x68, 0x68,
-	0x14, 0x2c, 0xfe, 0x95, 0x66, 0x0d, 0x0b, 0xd6, 0x5e, 0xf3, 0xae, 0x32, 0x82, 0xf8, 0xdb, 0x18,
-	0x05, 0x34, 0x96, 0x58, 0xfc, 0x3b, 0x75, 0x26, 0xa4, 0x52, 0xc3, 0xaf, 0x34, 0xcb, 0xe2, 0x2f,
-	0x26, 0x6e, 0x7e, 0x0a, 0x66, 0xf9, 0x06, 0xde, 0xbb, 0x0b, 0xb1, 0xa2, 0x35, 0xdb, 0xb8, 0xa2,
-	0x18, 0xfa, 0xfd, 0x96, 0x62, 0x44, 0x57, 0x6f, 0x0e, 0x66, 0x82, 0xdc, 0x12, 0x50, 0x06, 0x2e,
-	0x7a, 0xe6, 0xc4, 0x89, 0x9d, 0xb7, 0x45, 0x98, 0x2c, 0xb5, 0x54, 0x54, 0x82, 0x4c, 0xf0, 0x1c,
-	0x8f, 0x0a, 0x61, 0x79, 0x92, 0x78, 0xd3, 0x97, 0x56, 0x53, 0x38, 0xac, 0x76, 0xb8, 0x80, 0x6a,
-	0x00, 0xd1, 0x4b, 0x3c, 0x92, 0x42, 0xe8, 0xc8, 0x9b, 0xbd, 0x74, 0x39, 0x95, 0x17, 0x2a, 0xba,
-	0x4f, 0xeb, 0xbb, 0xd8, 0xeb, 0x2a, 0x5a, 0x8f, 0x5e, 0x5f, 0xd2, 0x9f, 0x73, 0xa5, 0x8d, 0x73,
-	0x10, 0xbc, 0x6a, 0x6d, 0xbc, 0x6a, 0xed, 0x03, 0x55, 0x6b, 0xe3, 0x55, 0xef, 0xc1, 0x2c, 0xff,
-	0xc4, 0x89, 0xae, 0x44, 0xb1, 0x1a, 0x7d, 0x59, 0x95, 0xae, 0x8e, 0xe1, 0x86, 0xea, 0xaa, 0x90,
-	0x0d, 0x5f, 0x40, 0xd0, 0x6a, 0x0c, 0xcd, 0x3f, 0xc8, 0x48, 0x52, 0x1a, 0x2b, 0xd4, 0xa2, 0xc1,
-	0x7c, 0xbc, 0xb1, 0x47, 0x6b, 0x7c, 0x98, 0x46, 0xdf, 0x2a, 0xa4, 0xe2, 0x58, 0x7e, 0xa8, 0xf4,
-	0x21, 0x48, 0xe3, 0xdf, 0x27, 0xd0, 0xcd, 0x31, 0x0a, 0x52, 0xba, 0x87, 0x27, 0x31, 0xf6, 0x32,
-	0x4c, 0xfb, 0x0f, 0xbe, 0x68, 0x39, 0x04, 0xc7, 0xde, 0x84, 0xa5, 0x95, 0x11, 0x7a, 0x28, 0xfc,
-	0x19, 0xb8, 0x34, 0xd2, 0xf1, 0xa3, 0x68, 0x35, 0xc7, 0x3d, 0x46, 0x48, 0xf2, 0x79, 0x90, 0x44,
-	0x70, 0x79, 0xd5, 0xb1, 0xe0, 0xa6, 0xe8, 0x2d, 0x8e, 0xe5, 0xf3, 0x69, 0xc4, 0x37, 0xdf, 0x5c,
-	0x1a, 0xa5, 0xb4, 0xea, 0x5c, 0x1a, 0xa5, 0x75, 0xec, 0xf2, 0x05, 0xd4, 0x82, 0xb9, 0x58, 0xa7,
-	0x8c, 0xae, 0xc6, 0x5d, 0x48, 0xb4, 0xe2, 0xd2, 0xda, 0x38, 0x76, 0xa8, 0xf1, 0x2e, 0x2c, 0x24,
-	0xfa, 0x08, 0x54, 0xe4, 0x1e, 0x44, 0xd2, 0xda, 0x6c, 0x69, 0x7d, 0x3c, 0x20, 0xd4, 0xdb, 0x1f,
-	0x69, 0xba, 0x83, 0xfe, 0x04, 0x5d, 0x1f, 0x27, 0x9e, 0xe8, 0x7f, 0xa4, 0xcd, 0x0f, 0x06, 0x26,
-	0x8e, 0x82, 0x58, 0xeb, 0x1d, 0x3f, 0x0a, 0xd2, 0x9a, 0xfc, 0xf8, 0x51, 0x90, 0xde, 0xb7, 0xd3,
-	0xa0, 0xc7, 0x3a, 0x6c, 0x2e, 0xe8, 0x69, 0x1d, 0x3d, 0x17, 0xf4, 0xf4, 0xc6, 0x9c, 0x9e, 0x06,
-	0x61, 0x23, 0xcd, 0x9d, 0x06, 0xc9, 0x76, 0x9d, 0x3b, 0x0d, 0x46, 0xfa, 0x6e, 0xba, 0x1d, 0x96,
-	0x52, 0x9b, 0x79, 0x74, 0x6d, 0x54, 0x2c, 0x6d, 0xbb, 0x9e, 0xaf, 0xbd, 0x04, 0x99, 0xa0, 0x2d,
-	0xe7, 0xae, 0x90, 0x44, 0x4b, 0x2f, 0xad, 0xa6, 0x70, 0xf8, 0xfd, 0x3a, 0xd2, 0x8b, 0x73, 0xfb,
-	0x75, 0x5c, 0x0f, 0xcf, 0xed, 0xd7, 0xb1, 0xad, 0xbc, 0xbf, 0xe2, 0xc9, 0xde, 0x1a, 0xf1, 0x99,
-	0x99, 0xda, 0xbb, 0x73, 0x2b, 0x3e, 0xb6, 0x31, 0xa7, 0xc9, 0x3b, 0xa6, 0x2f, 0xe6, 0x92, 0xf7,
-	0xfc, 0xde, 0x9a, 0x4b, 0xde, 0x0f, 0x68, 0xb1, 0xd9, 0x26, 0x8c, 0xff, 0x4c, 0xcd, 0x6f, 0xc2,
-	0xd4, 0x5f, 0xbe, 0xf9, 0x4d, 0x98, 0xfe, 0x0b, 0xb7, 0x7c, 0xa1, 0x7c, 0xeb, 0xbd, 0xb3, 0x35,
-	0xe1, 0xfd, 0xb3, 0x35, 0xe1, 0x9f, 0x67, 0x6b, 0xc2, 0xa7, 0x6f, 0x1e, 0x98, 0xee, 0xe1, 0x70,
-	0x7f, 0xab, 0x6b, 0x1d, 0x6f, 0x0f, 0x3a, 0xdd, 0xc3, 0xd3, 0x1e, 0xb1, 0xf9, 0xaf, 0x93, 0x9d,
-	0x6d, 0xc7, 0xee, 0xd2, 0xff, 0x47, 0xb0, 0x3f, 0x4d, 0x7f, 0x4e, 0x7b, 0xfe, 0x3f, 0x01, 0x00,
-	0x00, 0xff, 0xff, 0xe0, 0x77, 0x39, 0x67, 0x5b, 0x20, 0x00, 0x00,
+	// 2742 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x5a, 0x59, 0x77, 0xdb, 0xc6,
+	0x15, 0x0e, 0x24, 0x5b, 0xa2, 0xae, 0x36, 0x78, 0xb4, 0x51, 0xd0, 0x42, 0x0a, 0x8e, 0x6b, 0xd9,
+	0x6d, 0xa4, 0x44, 0x69, 0x5a, 0x27, 0xf1, 0x43, 0xb9, 0x40, 0x34, 0x62, 0x8a, 0xe4, 0x19, 0x80,
+	0x76, 0xdc, 0xd3, 0x53, 0x94, 0x22, 0xc7, 0x12, 0x6a, 0x89, 0x60, 0x00, 0x50, 0xb5, 0xd2, 0xa6,
+	0x6d, 0xba, 0x2f, 0x69, 0x93, 0x6e, 0xe9, 0x73, 0x7f, 0x40, 0x5f, 0xda, 0x9f, 0xd0, 0x97, 0x74,
+	0x4f, 0xd7, 0x47, 0xb7, 0x47, 0x3f, 0xa1, 0xbf, 0xa0, 0x07, 0x83, 0x01, 0x30, 0x00, 0x41, 0xc5,
+	0x49, 0x4e, 0x5e, 0x64, 0xcc, 0xbd, 0xdf, 0x7c, 0xf7, 0xce, 0x9d, 0x3b, 0xdb, 0xa5, 0x61, 0xb6,
+	0xd5, 0x77, 0x0f, 0xb7, 0xbd, 0x3f, 0x5b, 0x3d, 0xdb, 0x72, 0x2d, 0x34, 0xee, 0x7d, 0x1b, 0x27,
+	0x3b, 0xd2, 0xfc, 0x81, 0x75, 0x60, 0x51, 0xd9, 0xb6, 0xf7, 0xe5, 0xab, 0xa5, 0xdc, 0x81, 0x65,
+	0x1d, 0x1c, 0x91, 0x6d, 0xda, 0xda, 0xef, 0xdf, 0xdf, 0x76, 0xcd, 0x63, 0xe2, 0xb8, 0xad, 0xe3,
+	0x9e, 0x0f, 0x90, 0x9f, 0x86, 0xd9, 0x42, 0xdb, 0x35, 0x4f, 0x5a, 0x2e, 0xc1, 0xe4, 0x95, 0x3e,
+	0x71, 0x5c, 0xb4, 0x06, 0x60, 0x5b, 0x96, 0x6b, 0xb8, 0xd6, 0x03, 0xd2, 0xcd, 0x0a, 0x79, 0x61,
+	0x73, 0x02, 0x4f, 0x78, 0x12, 0xdd, 0x13, 0xc8, 0xcf, 0x80, 0x18, 0xf5, 0x70, 0x7a, 0x56, 0xd7,
+	0x21, 0x5e, 0x97, 0x5e, 0xab, 0x7d, 0x18, 0xef, 0xe2, 0x49, 0xfc, 0x2e, 0x73, 0x70, 0xa9, 0x4c,
+	0x5a, 0x71, 0x33, 0xf2, 0x3c, 0x20, 0x5e, 0xe8, 0x33, 0xc9, 0x9f, 0x86, 0x45, 0x6c, 0xb9, 0x9e,
+	0x24, 0x30, 0xf8, 0x98, 0x6e, 0xdd, 0x80, 0xa5, 0x81, 0x8e, 0x91, 0x77, 0xe7, 0xf5, 0xfc, 0xd5,
+	0x08, 0x40, 0x5d, 0x2d, 0x97, 0x4a, 0x56, 0xf7, 0xbe, 0x79, 0x80, 0x16, 0x61, 0xcc, 0x74, 0x9c,
+	0x3e, 0xb1, 0x19, 0x92, 0xb5, 0xd0, 0x35, 0x98, 0x68, 0x1f, 0x99, 0xa4, 0xeb, 0x1a, 0x66, 0x27,
+	0x3b, 0xe2, 0xa9, 0x8a, 0x53, 0x67, 0x8f, 0x72, 0x99, 0x12, 0x15, 0xaa, 0x65, 0x9c, 0xf1, 0xd5,
+	0x6a, 0x07, 0x5d, 0x86, 0x69, 0x06, 0x75, 0x48, 0xdb, 0x26, 0x6e, 0x76, 0x94, 0x32, 0x4d, 0xf9,
+	0x42, 0x8d, 0xca, 0xd0, 0x0e, 0x4c, 0xd9, 0xa4, 0x63, 0xda, 0xa4, 0xed, 0x1a, 0x7d, 0xdb, 0xcc,
+	0x5e, 0xa0, 0x94, 0xb3, 0x67, 0x8f, 0x72, 0x93, 0x98, 0xc9, 0x9b, 0x58, 0xc5, 0x93, 0x01, 0xa8,
+	0x69, 0x9b, 0x9e, 0x6f, 0x4e, 0xdb, 0xea, 0x11, 0x27, 0x7b, 0x31, 0x3f, 0xea, 0xf9, 0xe6, 0xb7,
+	0xd0, 0x27, 0x61, 0xd1, 0x26, 0xaf, 0xf4, 0x4d, 0x9b, 0x18, 0xe4, 0xb8, 0x65, 0x1e, 0x19, 0x27,
+	0xc4, 0x36, 0xef, 0x9b, 0xa4, 0x93, 0x1d, 0xcb, 0x0b, 0x9b, 0x19, 0x3c, 0xcf, 0xb4, 0x8a, 0xa7,
+	0xbc, 0xc3, 0x74, 0xe8, 0x1a, 0x88, 0x47, 0x56, 0xbb, 0x75, 0x74, 0x68, 0x39, 0xae, 0xc1, 0xc6,
+	0x3c, 0x4e, 0xf1, 0xb3, 0xa1, 0x5c, 0xa5, 0x62, 0x79, 0x19, 0x96, 0x2a, 0xc4, 0xf5, 0x23, 0xd4,
+	0xb7, 0x5b, 0xae, 0x69, 0x05, 0xf3, 0x22, 0x37, 0x21, 0x3b, 0xa8, 0x62, 0x91, 0x7f, 0x1e, 0xa6,
+	0xdb, 0xbc, 0x82, 0x86, 0x74, 0x72, 0x67, 0x6e, 0x8b, 0x65, 0xed, 0x56, 0x14, 0x77, 0x1c, 0x47,
+	0xca, 0x3a, 0x2c, 0x69, 0xe9, 0x16, 0x3f, 0x0c, 0xab, 0x04, 0x59, 0x6d, 0x88, 0xb3, 0xf2, 0x6f,
+	0x04, 0x98, 0xa0, 0x19, 0xa1, 0x76, 0xef, 0x5b, 0x28, 0x0b, 0xe3, 0x4e, 0x7f, 0xff, 0x8b, 0xa4,
+	0xed, 0xb2, 0x3c, 0x08, 0x9a, 0x48, 0x03, 0x20, 0x0f, 0x7b, 0x26, 0xb3, 0x3d, 0x42, 0x6d, 0x4b,
+	0x5b, 0xfe, 0x42, 0xdb, 0x0a, 0x16, 0xda, 0x96, 0x1e, 0x2c, 0xb4, 0xe2, 0xd2, 0xff, 0x1e, 0xe5,
+	0x66, 0x3b, 0xfb, 0x2f, 0xc8, 0x51, 0x2f, 0xf9, 0xad, 0xff, 0xe4, 0x04, 0xcc, 0xd1, 0xa0, 0x4f,
+	0xc1, 0xd4, 0x61, 0xcb, 0x39, 0x24, 0x1d, 0x96, 0xa5, 0x34, 0x63, 0x8a, 0x73, 0x41, 0x57, 0x2a,
+	0x34, 0x3c, 0x84, 0x8c, 0x27, 0x7d, 0xa0, 0x9f, 0xbc, 0x9f, 0x87, 0xb9, 0x42, 0xdf, 0x3d, 0x24,
+	0x5d, 0xd7, 0x6c, 0x73, 0x6b, 0xf8, 0x13, 0x00, 0x96, 0xd9, 0x69, 0x1b, 0x8e, 0xb7, 0x22, 0xfc,
+	0x01, 0x14, 0xa7, 0xcf, 0x1e, 0xe5, 0x26, 0xbc, 0xd0, 0x68, 0x9e, 0x10, 0x4f, 0x78, 0x00, 0xfa,
+	0x89, 0x96, 0x21, 0x63, 0x06, 0x86, 0x47, 0xfc, 0xc1, 0x9a, 0x8c, 0xff, 0x39, 0x98, 0x8f, 0xf3,
+	0x3f, 0xde, 0x8a, 0x9f, 0x85, 0xe9, 0xbb, 0x87, 0x56, 0xe1, 0x58, 0x0d, 0xb2, 0xe4, 0x75, 0x01,
+	0x66, 0x02, 0x09, 0xa3, 0x90, 0x20, 0xd3, 0x77, 0x88, 0xdd, 0x6d, 0x1d, 0x33, 0x0f, 0x71, 0xd8,
+	0xfe, 0x48, 0x62, 0x2c, 0xdb, 0x70, 0x11, 0x5b, 0x47, 0xc4, 0x41, 0xdb, 0x70, 0xd1, 0xf6, 0x3e,
+	0xb2, 0x42, 0x7e, 0x74, 0x73, 0x72, 0x67, 0x39, 0x4c, 0x1c, 0xaa, 0xf6, 0xff, 0x2a, 0x5d, 0xd7,
+	0x3e, 0xc5, 0x3e, 0x4e, 0xba, 0x01, 0x10, 0x09, 0x91, 0x08, 0xa3, 0x0f, 0xc8, 0x29, 0xf3, 0xd9,
+	0xfb, 0x44, 0xf3, 0x70, 0xf1, 0xa4, 0x75, 0xd4, 0x27, 0xd4, 0xd3, 0x0c, 0xf6, 0x1b, 0x2f, 0x8c,
+	0xdc, 0x10, 0xe4, 0xb7, 0x05, 0x98, 0xf4, 0xba, 0x16, 0xcd, 0x6e, 0xc7, 0xec, 0x1e, 0xa0, 0x17,
+	0x61, 0x9c, 0x74, 0x5d, 0xdb, 0x0c, 0x8d, 0x6f, 0xc4, 0x8c, 0x33, 0xd8, 0x96, 0xe2, 0x63, 0x7c,
+	0x27, 0x82, 0x1e, 0xd2, 0x4b, 0x30, 0xc5, 0x2b, 0x52, 0x1c, 0x79, 0x92, 0x77, 0x64, 0x72, 0x67,
+	0x26, 0x3e, 0x32, 0xde, 0x31, 0x15, 0x32, 0x98, 0x38, 0x56, 0xdf, 0x6e, 0x13, 0x74, 0x0d, 0x2e,
+	0xb8, 0xa7, 0x3d, 0x7f, 0x16, 0x66, 0x76, 0x16, 0xa2, 0x4e, 0x0c, 0xa0, 0x9f, 0xf6, 0x08, 0xa6,
+	0x10, 0x84, 0xe0, 0x02, 0x9d, 0x30, 0x3f, 0x4d, 0xe8, 0xb7, 0xfc, 0x0d, 0x01, 0x2e, 0x36, 0x1d,
+	0x62, 0x3b, 0xe8, 0x45, 0x98, 0x08, 0xa6, 0x30, 0x18, 0xdf, 0x5a, 0xc8, 0x46, 0x21, 0x5b, 0xcd,
+	0x40, 0xef, 0x8f, 0x2d, 0xc2, 0x4b, 0x37, 0x61, 0x26, 0xae, 0x7c, 0x5f, 0x81, 0x7e, 0x08, 0x63,
+	0x15, 0xdb, 0xea, 0xf7, 0x1c, 0xf4, 0x2c, 0x8c, 0x1d, 0xd0, 0x2f, 0xe6, 0xc1, 0x4a, 0xe8, 0x81,
+	0x0f, 0x60, 0xff, 0xf8, 0xf6, 0x19, 0x54, 0x7a, 0x1e, 0x26, 0x39, 0xf1, 0xfb, 0xb2, 0xfc, 0xa6,
+	0x00, 0x17, 0xbc, 0xf0, 0x86, 0xb1, 0x11, 0xa2, 0xd8, 0xa0, 0xe7, 0x60, 0xb2, 0x47, 0xec, 0x63,
+	0xd3, 0x71, 0x4c, 0xab, 0xeb, 0x64, 0x47, 0xf2, 0xa3, 0x9b, 0x33, 0xdc, 0x4e, 0xd5, 0x08, 0x75,
+	0x98, 0xc7, 0xa1, 0x9b, 0x30, 0x63, 0xb3, 0xe0, 0x1b, 0x5e, 0xdc, 0x9d, 0xec, 0x68, 0x7e, 0x74,
+	0xf8, 0xdc, 0x4c, 0xdb, 0x5c, 0xcb, 0x91, 0x1f, 0x82, 0xe8, 0x2d, 0x5a, 0xcb, 0x36, 0x5f, 0x0d,
+	0x77, 0x84, 0xa7, 0x20, 0x13, 0x80, 0xd8, 0x7e, 0x79, 0x69, 0x80, 0x0b, 0x87, 0x90, 0x0f, 0xe8,
+	0xb7, 0xfc, 0x5b, 0x01, 0x2e, 0x71, 0xa6, 0xd9, 0x4a, 0x5f, 0x07, 0x68, 0x05, 0xc2, 0x0e, 0xb5,
+	0x9e, 0xc1, 0x9c, 0x04, 0x3d, 0x03, 0x13, 0x4e, 0xcb, 0x35, 0x1d, 0x7a, 0x62, 0x9d, 0x63, 0x2a,
+	0x42, 0xa1, 0xa7, 0x60, 0x9c, 0x4a, 0xbb, 0x07, 0xd9, 0xd1, 0xe1, 0x1d, 0x02, 0x0c, 0x5a, 0x85,
+	0x89, 0x9e, 0x6d, 0x76, 0xdb, 0x66, 0xaf, 0x75, 0xe4, 0x9f, 0xb4, 0x38, 0x12, 0xc8, 0xbb, 0xb0,
+	0x50, 0x21, 0x6e, 0xd4, 0xcf, 0xf9, 0x60, 0x41, 0x93, 0x7b, 0xb0, 0x11, 0xe7, 0xd9, 0xb5, 0xec,
+	0x46, 0x60, 0xe5, 0x03, 0x4e, 0x44, 0xcc, 0xf3, 0x91, 0xa4, 0xe7, 0x04, 0x16, 0x93, 0x9e, 0xb3,
+	0x98, 0x27, 0x26, 0x50, 0x78, 0xcc, 0xc4, 0x9b, 0x0f, 0xb6, 0xc6, 0x11, 0x7a, 0xc1, 0xf0, 0x1b,
+	0xf2, 0x6b, 0x90, 0xdd, 0xb3, 0x3a, 0xe6, 0xfd, 0x53, 0x6e, 0x8f, 0xfa, 0x28, 0xc6, 0x13, 0x99,
+	0x1f, 0xe5, 0xcd, 0xaf, 0xc0, 0x72, 0x8a, 0x79, 0x76, 0x6c, 0xfb, 0x93, 0xf7, 0xa1, 0x1d, 0x93,
+	0x6f, 0xc1, 0x62, 0x92, 0x87, 0x85, 0x72, 0x0b, 0xc6, 0xf7, 0x7d, 0x11, 0xe3, 0x99, 0x4f, 0xdb,
+	0xb3, 0x71, 0x00, 0x92, 0xbf, 0x00, 0x93, 0x1a, 0xa1, 0xf1, 0xa4, 0x37, 0x89, 0x79, 0xb8, 0xd8,
+	0xb5, 0xba, 0xed, 0x60, 0x5f, 0xf0, 0x1b, 0x9e, 0x94, 0x5e, 0xd5, 0x58, 0x0c, 0xfc, 0x06, 0xba,
+	0x02, 0x33, 0x6d, 0xab, 0x7b, 0x42, 0x6c, 0xaf, 0xb7, 0x41, 0x6c, 0x9b, 0x5e, 0x04, 0x32, 0x78,
+	0x3a, 0x92, 0x2a, 0xb6, 0x2d, 0x2f, 0xc0, 0x5c, 0x85, 0xb8, 0xde, 0x59, 0x5e, 0xb5, 0x0e, 0xcc,
+	0xf0, 0x2a, 0x76, 0x17, 0xe6, 0xe3, 0x62, 0x36, 0x80, 0x6b, 0x30, 0x71, 0xe4, 0x09, 0x8c, 0xbe,
+	0x7d, 0x94, 0x15, 0xa2, 0xab, 0x2b, 0x45, 0x35, 0x71, 0x15, 0x67, 0xa8, 0xba, 0x69, 0xd3, 0x09,
+	0xf0, 0xef, 0x0c, 0xcc, 0x2d, 0xda, 0x90, 0x2b, 0x94, 0x18, 0x5b, 0xfb, 0x89, 0x3b, 0x39, 0x9d,
+	0xae, 0x7d, 0x2b, 0xb8, 0x22, 0xf9, 0x0d, 0xb4, 0x0c, 0xa3, 0xae, 0xeb, 0x0f, 0x6c, 0xb4, 0x38,
+	0x7e, 0xf6, 0x28, 0x37, 0xaa, 0xeb, 0x55, 0xec, 0xc9, 0xe4, 0xa7, 0x60, 0x21, 0x41, 0xc4, 0x5c,
+	0x9c, 0x87, 0x8b, 0xfc, 0x55, 0xc2, 0x6f, 0xc8, 0x5b, 0xb0, 0x88, 0xc9, 0x89, 0xf5, 0x80, 0x78,
+	0x7b, 0x4a, 0xd2, 0x72, 0x0a, 0x7e, 0x19, 0x96, 0x06, 0xf0, 0x2c, 0x4d, 0xf6, 0xe8, 0x7d, 0xd2,
+	0xdf, 0xe3, 0x77, 0x2d, 0xdb, 0x3b, 0x69, 0x02, 0xae, 0xf3, 0x2e, 0x22, 0x8b, 0xe1, 0x61, 0xe2,
+	0x2f, 0x08, 0xd6, 0x62, 0x17, 0xc9, 0x04, 0x1d, 0x33, 0x75, 0x07, 0xe6, 0xfd, 0x74, 0xdd, 0x23,
+	0xc7, 0xfb, 0xc4, 0x76, 0x38, 0x9f, 0x69, 0xef, 0xc0, 0x67, 0xda, 0xf0, 0x8e, 0x9a, 0x56, 0xa7,
+	0xc3, 0xe8, 0xbd, 0x4f, 0xcf, 0xa6, 0x4d, 0x8e, 0xad, 0x13, 0xc2, 0x56, 0x01, 0x6b, 0xc9, 0x4b,
+	0xb0, 0x90, 0xe0, 0x65, 0x06, 0x11, 0x88, 0x95, 0xc0, 0x99, 0x20, 0x17, 0x6e, 0xc2, 0x6a, 0x85,
+	0x73, 0x70, 0x60, 0x1b, 0x8a, 0xad, 0x43, 0x21, 0xb9, 0xaf, 0x7c, 0x1c, 0x2e, 0x71, 0x8c, 0x6c,
+	0x8e, 0x16, 0x63, 0x07, 0x6b, 0x14, 0x8b, 0xab, 0x30, 0x5b, 0x21, 0x2e, 0x3d, 0xde, 0xcf, 0x1d,
+	0xaa, 0xfc, 0x34, 0x88, 0x11, 0x90, 0x91, 0xae, 0x26, 0xaf, 0x0c, 0x13, 0xdc, 0x9d, 0xc0, 0x0b,
+	0xb3, 0xf2, 0xd0, 0xb5, 0x5b, 0x6d, 0x37, 0x9c, 0xd1, 0x70, 0x84, 0x15, 0x58, 0x4e, 0xd1, 0x31,
+	0xda, 0xeb, 0x30, 0x46, 0x53, 0x22, 0xb8, 0x04, 0xa0, 0x70, 0xc9, 0x86, 0x57, 0x7c, 0xcc, 0x10,
+	0x72, 0xc9, 0xcb, 0x1a, 0xc7, 0xb5, 0xec, 0xc1, 0x34, 0xdb, 0xe4, 0xd3, 0x2c, 0x9d, 0x85, 0xa5,
+	0x9e, 0x04, 0xd9, 0x41, 0x12, 0x36, 0x3f, 0x37, 0x61, 0x3d, 0x91, 0x96, 0xef, 0x23, 0x05, 0xe5,
+	0x0d, 0xc8, 0x0d, 0xed, 0xcd, 0x0c, 0xe4, 0x61, 0xbd, 0x4c, 0x8e, 0x88, 0x4b, 0x14, 0xef, 0xb6,
+	0x4b, 0x3a, 0x83, 0xc1, 0xda, 0x80, 0xdc, 0x50, 0x84, 0x4f, 0x72, 0xfd, 0x77, 0xb3, 0x00, 0xd1,
+	0xb1, 0x80, 0x26, 0x61, 0xbc, 0x59, 0xbb, 0x5d, 0xab, 0xdf, 0xad, 0x89, 0x4f, 0xa0, 0x15, 0x58,
+	0x2a, 0x55, 0x9b, 0x9a, 0xae, 0x60, 0x63, 0xaf, 0x5e, 0x56, 0x77, 0xef, 0x19, 0x45, 0xb5, 0x56,
+	0x56, 0x6b, 0x15, 0x4d, 0xec, 0xa0, 0x2c, 0xcc, 0x07, 0xca, 0x8a, 0xa2, 0x47, 0x1a, 0x82, 0x56,
+	0x60, 0x91, 0xd7, 0x34, 0x0a, 0xa5, 0x5b, 0x65, 0xa3, 0x5a, 0xaf, 0x68, 0xe2, 0xcf, 0x05, 0xb4,
+	0x0c, 0x0b, 0x81, 0xb2, 0xd0, 0xd4, 0x6f, 0x19, 0x85, 0x92, 0xae, 0xde, 0x29, 0xe8, 0x8a, 0x78,
+	0x9f, 0x37, 0x47, 0x55, 0x65, 0x25, 0x54, 0x1e, 0x0c, 0x28, 0x3d, 0xe6, 0x52, 0xbd, 0xb6, 0xab,
+	0x56, 0xc4, 0xc3, 0x01, 0xa5, 0x16, 0x29, 0x4d, 0xb4, 0x01, 0xab, 0x03, 0x3d, 0x71, 0xbd, 0x58,
+	0xd7, 0x0d, 0xbd, 0x7e, 0x5b, 0xa9, 0x89, 0x3f, 0x14, 0xd0, 0x15, 0xd8, 0x88, 0x41, 0xd8, 0x68,
+	0x2b, 0xb8, 0xde, 0x6c, 0x18, 0x7b, 0xca, 0x5e, 0x51, 0xc1, 0x9a, 0x78, 0x9c, 0xea, 0x03, 0xc5,
+	0x68, 0x62, 0x17, 0xe5, 0x61, 0x35, 0x5d, 0x69, 0x34, 0x35, 0xaf, 0xbb, 0x85, 0x72, 0xb0, 0x12,
+	0x43, 0x28, 0x2f, 0xeb, 0xb8, 0x50, 0x62, 0x6e, 0x68, 0x62, 0x0f, 0xad, 0x83, 0x14, 0x03, 0x60,
+	0x45, 0xd3, 0xeb, 0x58, 0x61, 0x7e, 0xbe, 0x82, 0xb6, 0xe1, 0xfa, 0x80, 0x89, 0x86, 0x82, 0xf7,
+	0x54, 0x4d, 0x53, 0xeb, 0x35, 0xcd, 0xd8, 0xad, 0x63, 0xa3, 0x81, 0xd5, 0x5a, 0x49, 0x6d, 0x14,
+	0xaa, 0xe2, 0x8f, 0x04, 0x74, 0x15, 0xe4, 0x44, 0x44, 0xab, 0x8a, 0xae, 0x18, 0xca, 0xcb, 0x0d,
+	0x15, 0x2b, 0xe5, 0xc0, 0xf0, 0x1b, 0x02, 0x7a, 0x12, 0x72, 0x09, 0xcb, 0x77, 0xea, 0xb7, 0x15,
+	0xea, 0x79, 0x80, 0xfa, 0xb1, 0x80, 0x2e, 0xc3, 0x7a, 0x1c, 0x55, 0xd7, 0x0b, 0xba, 0x62, 0xe0,
+	0x7a, 0x18, 0xcb, 0x9f, 0x09, 0xfc, 0x28, 0x95, 0x9a, 0xae, 0xe0, 0x06, 0x56, 0x35, 0x25, 0x9a,
+	0x66, 0x9b, 0x0f, 0x14, 0x07, 0xb8, 0xa5, 0x14, 0xb0, 0x5e, 0x54, 0x0a, 0xba, 0xe8, 0x0c, 0xa1,
+	0xf0, 0x67, 0xbc, 0xac, 0x88, 0x2e, 0xda, 0x80, 0xb5, 0x14, 0x00, 0x97, 0x2f, 0x7d, 0x9e, 0x43,
+	0x2d, 0x2b, 0x35, 0x5d, 0xd5, 0xef, 0xf1, 0x69, 0x71, 0x92, 0x0a, 0xe0, 0x92, 0xea, 0x4b, 0xa9,
+	0x80, 0x12, 0x56, 0xbc, 0x11, 0xab, 0xe5, 0x86, 0xf8, 0x30, 0x15, 0xd0, 0x6c, 0x94, 0x03, 0xc0,
+	0x29, 0x3f, 0x9f, 0x21, 0xa0, 0xaa, 0x6a, 0xba, 0xa7, 0xd6, 0xc4, 0x57, 0xd1, 0x2a, 0x64, 0x53,
+	0x5d, 0xf0, 0x7a, 0x7f, 0x39, 0x95, 0x9e, 0x4d, 0xa0, 0x07, 0xf8, 0x0a, 0xba, 0x0a, 0x97, 0x87,
+	0x39, 0xe8, 0xdd, 0x06, 0x8c, 0x52, 0x55, 0x55, 0x6a, 0xba, 0xf8, 0x5a, 0x2a, 0x90, 0x39, 0xca,
+	0x03, 0xbf, 0x8a, 0x3e, 0x06, 0xf2, 0x00, 0x90, 0x3a, 0xcc, 0xc1, 0x34, 0xf1, 0x6b, 0xe8, 0x0a,
+	0xe4, 0x53, 0x1d, 0xe7, 0xd9, 0xbe, 0x2e, 0xa0, 0x4d, 0xb8, 0x3c, 0x6c, 0x04, 0x3c, 0xf2, 0x75,
+	0x01, 0x2d, 0x01, 0x0a, 0x90, 0x65, 0xa5, 0xd8, 0xac, 0x18, 0xe5, 0xe6, 0x5e, 0x43, 0xfc, 0xa6,
+	0x80, 0x56, 0xa3, 0x25, 0xa7, 0xd6, 0xb4, 0x86, 0x52, 0xd2, 0x0d, 0x6f, 0x4d, 0x14, 0x2a, 0x8a,
+	0xf8, 0x8b, 0x98, 0xb6, 0x52, 0xc0, 0xc5, 0x42, 0x45, 0x31, 0x4a, 0xf5, 0x6a, 0x55, 0x29, 0xe9,
+	0xe2, 0xdb, 0x31, 0x6d, 0x11, 0x2b, 0x85, 0xdb, 0x06, 0x56, 0x1a, 0x75, 0xa3, 0x5a, 0x2f, 0xdd,
+	0x16, 0x7f, 0x29, 0xa0, 0xb5, 0x28, 0xf8, 0x55, 0xb5, 0xa4, 0xd4, 0xf8, 0x24, 0xfd, 0x56, 0xaa,
+	0x3a, 0x4c, 0xc0, 0x6f, 0x0b, 0x28, 0x0f, 0x2b, 0x49, 0x75, 0xa1, 0x5c, 0x36, 0x98, 0x4c, 0xfc,
+	0x4e, 0x6c, 0xb1, 0x04, 0x08, 0x16, 0xf3, 0x00, 0xf4, 0xdd, 0x54, 0x10, 0x0b, 0x50, 0x00, 0xfa,
+	0x9e, 0x80, 0x64, 0x58, 0x4b, 0x82, 0xe8, 0xa4, 0x30, 0xa1, 0x26, 0x7e, 0x5f, 0x40, 0x52, 0xb4,
+	0xad, 0xb2, 0x14, 0xd0, 0x94, 0x12, 0x56, 0x74, 0xf1, 0x4d, 0x6f, 0xcb, 0x9d, 0x8f, 0xfa, 0x6b,
+	0x3a, 0xd3, 0x68, 0xe2, 0x5b, 0x02, 0x42, 0x30, 0xed, 0xb7, 0x98, 0x59, 0xf1, 0x27, 0x02, 0x9a,
+	0x83, 0x19, 0x26, 0x63, 0x11, 0x17, 0x7f, 0x9a, 0x98, 0x20, 0xea, 0x60, 0xa1, 0x5a, 0x15, 0x7f,
+	0x20, 0xa0, 0x19, 0x98, 0xa0, 0x61, 0xc5, 0x4a, 0xa1, 0x2c, 0xbe, 0x23, 0xa0, 0x59, 0x00, 0xda,
+	0xbe, 0x8b, 0x55, 0x5d, 0x11, 0x7f, 0x4f, 0xad, 0x53, 0x41, 0xf2, 0x04, 0xf9, 0x83, 0x80, 0x44,
+	0x98, 0xa4, 0x2a, 0x66, 0xfb, 0x8f, 0x02, 0xca, 0xc2, 0x1c, 0x95, 0x04, 0x73, 0x5d, 0xaa, 0xef,
+	0xed, 0xa9, 0xba, 0xf8, 0x27, 0x01, 0x2d, 0x80, 0xe8, 0x4f, 0x9f, 0xaa, 0x85, 0xe2, 0x3f, 0x53,
+	0xbf, 0x38, 0x8a, 0x40, 0xf1, 0x97, 0x48, 0xc1, 0xa2, 0x51, 0xc4, 0x85, 0x5a, 0xe9, 0x96, 0xf8,
+	0xd7, 0x04, 0x11, 0x13, 0xbf, 0x3b, 0x40, 0xc4, 0x14, 0x7f, 0x13, 0xd0, 0x22, 0x5c, 0x8a, 0xb9,
+	0xb4, 0xab, 0x56, 0x15, 0xf1, 0xef, 0x34, 0x4c, 0x11, 0x0f, 0x15, 0xfe, 0x83, 0x66, 0x0d, 0x15,
+	0x7a, 0xb9, 0xd0, 0x50, 0x1b, 0x4a, 0x55, 0xad, 0x29, 0x34, 0x34, 0x0a, 0x16, 0xff, 0x49, 0xb3,
+	0x86, 0x05, 0x6b, 0xaf, 0x7e, 0x47, 0x19, 0x40, 0xfc, 0x6b, 0x08, 0x01, 0x8d, 0x25, 0x16, 0xff,
+	0x4d, 0x9d, 0x09, 0xa5, 0xd4, 0xf0, 0x4b, 0xf5, 0xa2, 0xf8, 0xeb, 0x91, 0xeb, 0x9f, 0x81, 0x29,
+	0xbe, 0x34, 0xe0, 0x9d, 0xb2, 0x58, 0xd1, 0xea, 0x4d, 0x5c, 0x52, 0x0c, 0xfd, 0x5e, 0x43, 0x31,
+	0xa2, 0x43, 0x7d, 0x12, 0xc6, 0x83, 0xdc, 0x12, 0x50, 0x06, 0x2e, 0x78, 0xe6, 0xc4, 0x91, 0x9d,
+	0x37, 0x44, 0x18, 0x2d, 0x34, 0x54, 0x54, 0x80, 0x4c, 0x50, 0xe8, 0x47, 0xd9, 0xf0, 0xe2, 0x93,
+	0xf8, 0xb5, 0x40, 0x5a, 0x4e, 0xd1, 0xb0, 0x5b, 0xc9, 0x13, 0xa8, 0x02, 0x10, 0xd5, 0xf8, 0x91,
+	0x14, 0x42, 0x07, 0x7e, 0x0d, 0x90, 0x56, 0x52, 0x75, 0x21, 0xd1, 0x3d, 0x7a, 0x73, 0x8c, 0xd5,
+	0x6d, 0x51, 0x3e, 0xec, 0x32, 0xa4, 0x34, 0x2d, 0x6d, 0x9c, 0x83, 0xe0, 0xa9, 0xb5, 0xe1, 0xd4,
+	0xda, 0x7b, 0x52, 0x6b, 0xc3, 0xa9, 0xf7, 0x60, 0x8a, 0x2f, 0x9e, 0xa2, 0xd5, 0x28, 0x56, 0x83,
+	0x35, 0x5b, 0x69, 0x6d, 0x88, 0x36, 0xa4, 0x2b, 0xc3, 0x44, 0x58, 0x5b, 0x41, 0xcb, 0x31, 0x34,
+	0x5f, 0xea, 0x91, 0xa4, 0x34, 0x55, 0xc8, 0xa2, 0xc1, 0x4c, 0xbc, 0x64, 0x80, 0xd6, 0xf9, 0x30,
+	0x0d, 0x56, 0x41, 0xa4, 0xdc, 0x50, 0x7d, 0x48, 0xfa, 0x00, 0xa4, 0xe1, 0x95, 0x0f, 0x74, 0x7d,
+	0x08, 0x41, 0xca, 0xbb, 0xe4, 0x71, 0x8c, 0xbd, 0x08, 0x63, 0x7e, 0x29, 0x19, 0x2d, 0x86, 0xe0,
+	0x58, 0xb5, 0x59, 0x5a, 0x1a, 0x90, 0x87, 0x9d, 0x3f, 0x07, 0x97, 0x06, 0x6a, 0x09, 0x28, 0x9a,
+	0xcd, 0x61, 0x65, 0x0e, 0x49, 0x3e, 0x0f, 0x92, 0x08, 0x2e, 0x4f, 0x1d, 0x0b, 0x6e, 0x0a, 0x6f,
+	0x6e, 0xa8, 0x9e, 0x4f, 0x23, 0xfe, 0x59, 0xcf, 0xa5, 0x51, 0x4a, 0x11, 0x40, 0x5a, 0x1b, 0xa2,
+	0x0d, 0xe9, 0x1a, 0x30, 0x1d, 0x7b, 0x83, 0xa3, 0xb5, 0xb8, 0x0b, 0x89, 0x47, 0xbe, 0xb4, 0x3e,
+	0x4c, 0x1d, 0x32, 0xde, 0x81, 0xd9, 0xc4, 0x0b, 0x05, 0xe5, 0xb8, 0x52, 0x4b, 0xda, 0x03, 0x5e,
+	0xca, 0x0f, 0x07, 0x84, 0xbc, 0xdd, 0x81, 0xe7, 0x7c, 0xf0, 0xf2, 0x41, 0x57, 0x87, 0x75, 0x4f,
+	0xbc, 0xac, 0xa4, 0xcd, 0xf7, 0x06, 0x26, 0xb6, 0x82, 0xd8, 0xa3, 0x3e, 0xbe, 0x15, 0xa4, 0x95,
+	0x0f, 0xa4, 0x8d, 0x73, 0x10, 0x7c, 0xd0, 0x63, 0x6f, 0x77, 0x2e, 0xe8, 0x69, 0xb5, 0x02, 0x69,
+	0x7d, 0x98, 0x9a, 0xdf, 0x0d, 0xc2, 0x27, 0x3a, 0xb7, 0x1b, 0x24, 0x0b, 0x01, 0x92, 0x94, 0xa6,
+	0xe2, 0x96, 0xc3, 0x42, 0x6a, 0x99, 0x00, 0x5d, 0x19, 0xec, 0x96, 0xb6, 0x5c, 0xcf, 0x67, 0x2f,
+	0x40, 0x26, 0x78, 0xf0, 0x73, 0x47, 0x48, 0xa2, 0x58, 0x20, 0x2d, 0xa7, 0x68, 0xf8, 0xf5, 0x3a,
+	0xf0, 0xca, 0xe7, 0xd6, 0xeb, 0xb0, 0xea, 0x80, 0x24, 0x9f, 0x07, 0xe1, 0x67, 0x3c, 0xf9, 0x6a,
+	0x47, 0x7c, 0x66, 0xa6, 0x56, 0x05, 0xa4, 0x8d, 0x73, 0x10, 0x7c, 0xf2, 0x0e, 0x79, 0x71, 0x73,
+	0xc9, 0x7b, 0xfe, 0xab, 0x5d, 0xda, 0x7c, 0x6f, 0x60, 0x6c, 0x11, 0xc6, 0x7f, 0x00, 0xe7, 0x17,
+	0x61, 0xea, 0x6f, 0xea, 0x52, 0x7e, 0x38, 0x20, 0xe0, 0x2d, 0xde, 0x78, 0xe7, 0x6c, 0x5d, 0x78,
+	0xf7, 0x6c, 0x5d, 0xf8, 0xef, 0xd9, 0xba, 0xf0, 0xd9, 0xeb, 0x07, 0xa6, 0x7b, 0xd8, 0xdf, 0xdf,
+	0x6a, 0x5b, 0xc7, 0xdb, 0xde, 0xcf, 0x7d, 0xa7, 0x1d, 0x62, 0xf3, 0x5f, 0x27, 0x3b, 0xdb, 0x8e,
+	0xdd, 0xa6, 0xff, 0x43, 0x61, 0x7f, 0x8c, 0xfe, 0x50, 0xf7, 0xec, 0xff, 0x07, 0x00, 0xc2, 0xd9,
+	0x84, 0x98, 0xb5, 0x20, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
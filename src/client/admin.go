@@ -14,3 +14,12 @@ func (c APIClient) InspectCluster() (*admin.ClusterInfo, error) {
 	}
 	return clusterInfo, nil
 }
+
+// InspectStorage retrieves a summary of the storage used by the cluster.
+func (c APIClient) InspectStorage() (*admin.StorageInfo, error) {
+	storageInfo, err := c.AdminAPIClient.InspectStorage(c.Ctx(), &types.Empty{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return storageInfo, nil
+}
@@ -523,6 +523,21 @@ func NewOnUserMachine(prefix string, options ...Option) (*APIClient, error) {
 	return newOnUserMachine(cfg, context, name, prefix, options...)
 }
 
+// NewOnUserMachineWithContext is like NewOnUserMachine, except that it
+// connects to the cluster of the named context instead of the active one.
+// This is intended to be used to talk to a remote cluster from pachctl.
+func NewOnUserMachineWithContext(contextName, prefix string, options ...Option) (*APIClient, error) {
+	cfg, err := config.Read(false, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read config")
+	}
+	context, ok := cfg.V2.Contexts[contextName]
+	if !ok {
+		return nil, errors.Errorf("pachctl config error: no context named %q has been configured", contextName)
+	}
+	return newOnUserMachine(cfg, context, contextName, prefix, options...)
+}
+
 // NewEnterpriseClientOnUserMachine constructs a new APIClient using $HOME/.pachyderm/config
 // if it exists. This is intended to be used in the pachctl binary to communicate with the
 // enterprise server.
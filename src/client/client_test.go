@@ -1,13 +1,25 @@
 package client
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/transaction"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type countingInterceptor struct {
@@ -74,3 +86,478 @@ func TestInterceptors(t *testing.T) {
 		t.Errorf("stream call count:\n  got: %v\n want: %v", got, want)
 	}
 }
+
+func TestMultiClusterRouting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	clusters := make(map[string]*APIClient)
+	interceptors := make(map[string]*countingInterceptor)
+	for _, name := range []string{"a", "b"} {
+		server, err := grpcutil.NewServer(ctx, false)
+		if err != nil {
+			t.Fatalf("server: %v", err)
+		}
+		defer server.Wait()
+		listener, err := server.ListenTCP("localhost", 0)
+		if err != nil {
+			t.Fatalf("listener: %v", err)
+		}
+		defer listener.Close()
+		pfs.RegisterAPIServer(server.Server, new(pfs.UnimplementedAPIServer))
+		interceptors[name] = new(countingInterceptor)
+		c, err := NewFromURI(listener.Addr().String(), WithAdditionalUnaryClientInterceptors(interceptors[name].unary()), WithAdditionalStreamClientInterceptors(interceptors[name].stream()))
+		if err != nil {
+			t.Fatalf("create client: %v", err)
+		}
+		clusters[name] = c
+	}
+	mc, err := NewMultiClusterClient(clusters, "a")
+	if err != nil {
+		t.Fatalf("create multi-cluster client: %v", err)
+	}
+	defer mc.Close()
+	if err := mc.AddRoute("b-*", "b"); err != nil {
+		t.Fatalf("add route: %v", err)
+	}
+	pfsClient, err := mc.PfsAPIClient()
+	if err != nil {
+		t.Fatalf("create pfs client: %v", err)
+	}
+
+	// Unary calls go to the cluster of the repo in the request.
+	if _, err := pfsClient.CreateRepo(ctx, &pfs.CreateRepoRequest{Repo: NewRepo("b-repo")}); err == nil {
+		t.Fatal("create repo: expected error")
+	}
+	if _, err := pfsClient.CreateRepo(ctx, &pfs.CreateRepoRequest{Repo: NewRepo("repo")}); err == nil {
+		t.Fatal("create repo: expected error")
+	}
+	if got, want := interceptors["a"].u, 1; got != want {
+		t.Errorf("cluster a unary call count:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := interceptors["b"].u, 1; got != want {
+		t.Errorf("cluster b unary call count:\n  got: %v\n want: %v", got, want)
+	}
+
+	// Streams are routed by their first message.
+	stream, err := pfsClient.ListCommit(ctx, &pfs.ListCommitRequest{Repo: NewRepo("b-repo")})
+	if err != nil {
+		t.Fatalf("list commit: %v", err)
+	}
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("list commit: expected error")
+	}
+	if got, want := interceptors["a"].s, 0; got != want {
+		t.Errorf("cluster a stream call count:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := interceptors["b"].s, 1; got != want {
+		t.Errorf("cluster b stream call count:\n  got: %v\n want: %v", got, want)
+	}
+}
+
+// flakySubscribeServer fails every other SubscribeCommit stream with a
+// transient error after sending one commit.
+type flakySubscribeServer struct {
+	pfs.UnimplementedAPIServer
+	calls   int
+	cursors []string
+}
+
+func (s *flakySubscribeServer) SubscribeCommit(req *pfs.SubscribeCommitRequest, stream pfs.API_SubscribeCommitServer) error {
+	s.calls++
+	s.cursors = append(s.cursors, req.Cursor)
+	if req.Repo.Name == "missing" {
+		return status.Error(codes.NotFound, "repo not found")
+	}
+	id := fmt.Sprintf("%d", s.calls)
+	if err := stream.Send(&pfs.CommitInfo{Commit: req.Repo.NewCommit("master", id), Cursor: "cursor-" + id}); err != nil {
+		return err
+	}
+	if s.calls%2 == 1 {
+		return status.Error(codes.Unavailable, "pachd restarting")
+	}
+	return nil
+}
+
+func TestResubscribeCommit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server, err := grpcutil.NewServer(ctx, false)
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+	defer server.Wait()
+	listener, err := server.ListenTCP("localhost", 0)
+	if err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	defer listener.Close()
+	fake := new(flakySubscribeServer)
+	pfs.RegisterAPIServer(server.Server, fake)
+	c, err := NewFromURI(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	defer c.Close()
+
+	// The first stream fails after one commit and is resumed from its cursor.
+	var ids []string
+	if err := c.ResubscribeCommit(NewRepo("repo"), "master", "", pfs.CommitState_STARTED, func(ci *pfs.CommitInfo) error {
+		ids = append(ids, ci.Commit.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("resubscribe commit: %v", err)
+	}
+	if got, want := ids, []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("commits:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := fake.cursors, []string{"", "cursor-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cursors:\n  got: %v\n want: %v", got, want)
+	}
+
+	// Errors from the callback are returned as is, without retrying.
+	errCallback := errors.New("callback failed")
+	if err := c.ResubscribeCommit(NewRepo("repo"), "master", "", pfs.CommitState_STARTED, func(ci *pfs.CommitInfo) error {
+		return errCallback
+	}); !errors.Is(err, errCallback) {
+		t.Errorf("callback error:\n  got: %v\n want: %v", err, errCallback)
+	}
+
+	// Fatal errors are returned without retrying.
+	calls := fake.calls
+	if err := c.ResubscribeCommit(NewRepo("missing"), "master", "", pfs.CommitState_STARTED, func(ci *pfs.CommitInfo) error {
+		return nil
+	}); err == nil {
+		t.Error("resubscribe commit: expected error")
+	}
+	if got, want := fake.calls, calls+1; got != want {
+		t.Errorf("calls:\n  got: %v\n want: %v", got, want)
+	}
+}
+
+// flakyGetFileServer serves a tar stream of files, failing every other
+// stream with a transient error halfway through.
+type flakyGetFileServer struct {
+	pfs.UnimplementedAPIServer
+	mu    sync.Mutex
+	calls int
+	files map[string]string
+}
+
+func (s *flakyGetFileServer) setFile(path, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = content
+}
+
+func (s *flakyGetFileServer) GetFileTAR(req *pfs.GetFileRequest, server pfs.API_GetFileTARServer) error {
+	s.mu.Lock()
+	s.calls++
+	calls, content := s.calls, s.files[req.File.Path]
+	s.mu.Unlock()
+	if req.File.Path == "missing" {
+		return status.Error(codes.NotFound, "file not found")
+	}
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: req.File.Path, Size: int64(len(content)), Mode: 0600}); err != nil {
+		return err
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if calls%2 == 1 {
+		// Send the header and some of the content, but not all of it.
+		if err := server.Send(&types.BytesValue{Value: data[:512+len(content)/2]}); err != nil {
+			return err
+		}
+		return status.Error(codes.Unavailable, "connection reset")
+	}
+	return server.Send(&types.BytesValue{Value: data})
+}
+
+func TestGetFileResume(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server, err := grpcutil.NewServer(ctx, false)
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+	defer server.Wait()
+	listener, err := server.ListenTCP("localhost", 0)
+	if err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	defer listener.Close()
+	fake := &flakyGetFileServer{files: map[string]string{"file": "0123456789"}}
+	pfs.RegisterAPIServer(server.Server, fake)
+	c, err := NewFromURI(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	defer c.Close()
+	commit := NewCommit("repo", "master", "")
+
+	// The first stream fails halfway, and the second resumes after the bytes
+	// that were already written.
+	buf := &bytes.Buffer{}
+	if err := c.GetFile(commit, "file", buf); err != nil {
+		t.Fatalf("get file: %v", err)
+	}
+	if got, want := buf.String(), "0123456789"; got != want {
+		t.Errorf("content:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := fake.calls, 2; got != want {
+		t.Errorf("calls:\n  got: %v\n want: %v", got, want)
+	}
+
+	// A file that changes between streams isn't spliced together.
+	buf.Reset()
+	if err := c.GetFile(commit, "file", &changingWriter{w: buf, fake: fake}); err == nil {
+		t.Error("get file: expected error for a changed file")
+	}
+
+	// Errors before anything is received are returned without retrying.
+	calls := fake.calls
+	if err := c.GetFile(commit, "missing", buf); err == nil {
+		t.Error("get file: expected error")
+	}
+	if got, want := fake.calls, calls+1; got != want {
+		t.Errorf("calls:\n  got: %v\n want: %v", got, want)
+	}
+}
+
+// changingWriter changes the file served by fake once it's first written to.
+type changingWriter struct {
+	w    io.Writer
+	fake *flakyGetFileServer
+	done bool
+}
+
+func (cw *changingWriter) Write(p []byte) (int, error) {
+	if !cw.done {
+		cw.fake.setFile("file", "0123456789abc")
+		cw.done = true
+	}
+	return cw.w.Write(p)
+}
+
+// conflictingTransactionServer fails the first conflicts batch transactions
+// with a transaction conflict.
+type conflictingTransactionServer struct {
+	transaction.UnimplementedAPIServer
+	conflicts int
+	calls     int
+}
+
+func (s *conflictingTransactionServer) BatchTransaction(ctx context.Context, req *transaction.BatchTransactionRequest) (*transaction.TransactionInfo, error) {
+	s.calls++
+	if s.calls <= s.conflicts {
+		return nil, status.Error(codes.Unknown, "transaction conflict, will be reattempted")
+	}
+	if len(req.Requests) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty transaction")
+	}
+	return &transaction.TransactionInfo{Requests: req.Requests}, nil
+}
+
+func TestWithTransactionRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server, err := grpcutil.NewServer(ctx, false)
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+	defer server.Wait()
+	listener, err := server.ListenTCP("localhost", 0)
+	if err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	defer listener.Close()
+	fake := &conflictingTransactionServer{conflicts: 2}
+	transaction.RegisterAPIServer(server.Server, fake)
+	c, err := NewFromURI(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	defer c.Close()
+
+	// The transaction is rebuilt and rerun until it doesn't conflict.
+	var builds int
+	info, err := c.WithTransactionRetry(func(builder *TransactionBuilder) error {
+		builds++
+		return builder.CreateBranch("repo", "master", "", "", nil)
+	})
+	if err != nil {
+		t.Fatalf("with transaction retry: %v", err)
+	}
+	if got, want := len(info.Requests), 1; got != want {
+		t.Errorf("requests:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := builds, 3; got != want {
+		t.Errorf("builds:\n  got: %v\n want: %v", got, want)
+	}
+
+	// Other errors are returned without retrying.
+	calls := fake.calls
+	if _, err := c.WithTransactionRetry(func(builder *TransactionBuilder) error {
+		return nil
+	}); err == nil {
+		t.Error("with transaction retry: expected error")
+	}
+	if got, want := fake.calls, calls+1; got != want {
+		t.Errorf("calls:\n  got: %v\n want: %v", got, want)
+	}
+}
+
+func TestPager(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	var fetches int
+	var page []string
+	p := &pager{pageSize: 2}
+	p.fetch = func(token string) (int, string, error) {
+		fetches++
+		page = nil
+		for _, item := range items {
+			if item > token && len(page) < 2 {
+				page = append(page, item)
+			}
+		}
+		if len(page) == 0 {
+			return 0, token, nil
+		}
+		return len(page), page[len(page)-1], nil
+	}
+	var got []string
+	for p.next() {
+		got = append(got, page[p.index(len(page))])
+	}
+	if err := p.Err(); err != nil {
+		t.Fatalf("pager: %v", err)
+	}
+	if want := items; !reflect.DeepEqual(got, want) {
+		t.Errorf("items:\n  got: %v\n want: %v", got, want)
+	}
+	// The last page is short, so it isn't followed by another fetch.
+	if got, want := fetches, 3; got != want {
+		t.Errorf("fetches:\n  got: %v\n want: %v", got, want)
+	}
+}
+
+// partUploadServer keeps the files that ModifyFile writes in memory, and
+// fails the first upload of each file set whose content starts with fail.
+type partUploadServer struct {
+	pfs.UnimplementedAPIServer
+	fail     string
+	mu       sync.Mutex
+	uploads  int
+	failed   map[string]bool
+	fileSets map[string]string
+	files    map[string]string
+}
+
+func (s *partUploadServer) CreateFileSet(server pfs.API_CreateFileSetServer) error {
+	var content string
+	for {
+		req, err := server.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		content += string(req.GetAddFile().GetRaw().GetValue())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uploads++
+	if s.fail != "" && strings.HasPrefix(content, s.fail) && !s.failed[content] {
+		s.failed[content] = true
+		return status.Error(codes.Unavailable, "connection reset")
+	}
+	id := fmt.Sprintf("fileset-%d", s.uploads)
+	s.fileSets[id] = content
+	return server.SendAndClose(&pfs.CreateFileSetResponse{FileSetId: id})
+}
+
+func (s *partUploadServer) ModifyFile(server pfs.API_ModifyFileServer) error {
+	for {
+		req, err := server.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		switch body := req.Body.(type) {
+		case *pfs.ModifyFileRequest_DeleteFile:
+			delete(s.files, body.DeleteFile.Path)
+		case *pfs.ModifyFileRequest_AddFile:
+			s.files[body.AddFile.Path] += string(body.AddFile.GetRaw().GetValue())
+		case *pfs.ModifyFileRequest_AddFileSet:
+			s.files["file"] += s.fileSets[body.AddFileSet]
+		}
+		s.mu.Unlock()
+	}
+	return server.SendAndClose(&types.Empty{})
+}
+
+func (s *partUploadServer) RenewFileSet(context.Context, *pfs.RenewFileSetRequest) (*types.Empty, error) {
+	return &types.Empty{}, nil
+}
+
+func TestParallelPutFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server, err := grpcutil.NewServer(ctx, false)
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+	defer server.Wait()
+	listener, err := server.ListenTCP("localhost", 0)
+	if err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	defer listener.Close()
+	fake := &partUploadServer{
+		fail:     "bbbb",
+		failed:   make(map[string]bool),
+		fileSets: make(map[string]string),
+		files:    make(map[string]string),
+	}
+	pfs.RegisterAPIServer(server.Server, fake)
+	c, err := NewFromURI(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	defer c.Close()
+	commit := NewCommit("repo", "master", "")
+
+	// The parts are put back together in order, even though the second one
+	// has to be uploaded again.
+	content := "aaaabbbbccccdd"
+	if err := c.PutFile(commit, "file", bytes.NewReader([]byte(content)), WithParallelPutFile(2, 4)); err != nil {
+		t.Fatalf("put file: %v", err)
+	}
+	if got, want := fake.files["file"], content; got != want {
+		t.Errorf("content:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := fake.uploads, 5; got != want {
+		t.Errorf("uploads:\n  got: %v\n want: %v", got, want)
+	}
+
+	// Content that fits in a single part isn't uploaded separately.
+	if err := c.PutFile(commit, "file", bytes.NewReader([]byte("abc")), WithParallelPutFile(2, 4)); err != nil {
+		t.Fatalf("put file: %v", err)
+	}
+	if got, want := fake.files["file"], "abc"; got != want {
+		t.Errorf("content:\n  got: %v\n want: %v", got, want)
+	}
+	if got, want := fake.uploads, 5; got != want {
+		t.Errorf("uploads:\n  got: %v\n want: %v", got, want)
+	}
+}
@@ -0,0 +1,359 @@
+package client
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MultiClusterClient is a client for a fleet of Pachyderm clusters. Each repo
+// is mapped to the cluster that holds it, and calls that refer to a repo are
+// routed to that cluster, using that cluster's credentials.
+type MultiClusterClient struct {
+	clusters       map[string]*APIClient
+	routes         []clusterRoute
+	defaultCluster string
+
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+}
+
+type clusterRoute struct {
+	pattern, cluster string
+}
+
+// NewMultiClusterClient creates a MultiClusterClient for clusters, keyed by
+// cluster name. Calls that don't refer to a repo, or refer to a repo that
+// doesn't match any route, go to defaultCluster.
+func NewMultiClusterClient(clusters map[string]*APIClient, defaultCluster string) (*MultiClusterClient, error) {
+	if _, ok := clusters[defaultCluster]; !ok {
+		return nil, errors.Errorf("default cluster %q is not one of the clusters", defaultCluster)
+	}
+	return &MultiClusterClient{
+		clusters:       clusters,
+		defaultCluster: defaultCluster,
+	}, nil
+}
+
+// NewMultiClusterOnUserMachine creates a MultiClusterClient from the contexts
+// in the user's config, using each context's address and credentials. routes
+// maps repo patterns to context names, see AddRoute.
+func NewMultiClusterOnUserMachine(prefix string, routes map[string]string, defaultContext string, options ...Option) (_ *MultiClusterClient, retErr error) {
+	clusters := make(map[string]*APIClient)
+	defer func() {
+		if retErr != nil {
+			for _, c := range clusters {
+				c.Close()
+			}
+		}
+	}()
+	contexts := []string{defaultContext}
+	for _, contextName := range routes {
+		contexts = append(contexts, contextName)
+	}
+	for _, contextName := range contexts {
+		if _, ok := clusters[contextName]; ok {
+			continue
+		}
+		c, err := NewOnUserMachineWithContext(contextName, prefix, options...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not connect to context %q", contextName)
+		}
+		clusters[contextName] = c
+	}
+	mc, err := NewMultiClusterClient(clusters, defaultContext)
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]string, 0, len(routes))
+	for pattern := range routes {
+		patterns = append(patterns, pattern)
+	}
+	// Route exact names before patterns, and otherwise go in a stable order.
+	sort.Slice(patterns, func(i, j int) bool {
+		if isPattern(patterns[i]) != isPattern(patterns[j]) {
+			return !isPattern(patterns[i])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if err := mc.AddRoute(pattern, routes[pattern]); err != nil {
+			return nil, err
+		}
+	}
+	return mc, nil
+}
+
+// AddRoute routes the repos whose qualified names (see pfs.Repo.QualifiedName)
+// match pattern to cluster. Patterns use path.Match syntax, so "project/*"
+// matches all the repos in a project. Routes are matched in the order in which
+// they are added.
+func (mc *MultiClusterClient) AddRoute(pattern, cluster string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.Wrapf(err, "invalid repo pattern %q", pattern)
+	}
+	if _, ok := mc.clusters[cluster]; !ok {
+		return errors.Errorf("unknown cluster %q", cluster)
+	}
+	mc.routes = append(mc.routes, clusterRoute{pattern: pattern, cluster: cluster})
+	return nil
+}
+
+// Cluster returns the client for the named cluster.
+func (mc *MultiClusterClient) Cluster(name string) (*APIClient, error) {
+	c, ok := mc.clusters[name]
+	if !ok {
+		return nil, errors.Errorf("unknown cluster %q", name)
+	}
+	return c, nil
+}
+
+// ClusterFor returns the name of the cluster that repo is routed to.
+func (mc *MultiClusterClient) ClusterFor(repo *pfs.Repo) string {
+	if repo == nil {
+		return mc.defaultCluster
+	}
+	name := repo.QualifiedName()
+	for _, r := range mc.routes {
+		if ok, _ := path.Match(r.pattern, name); ok {
+			return r.cluster
+		}
+	}
+	return mc.defaultCluster
+}
+
+// ForRepo returns the client for the cluster that repo is routed to.
+func (mc *MultiClusterClient) ForRepo(repo *pfs.Repo) *APIClient {
+	return mc.clusters[mc.ClusterFor(repo)]
+}
+
+// PfsAPIClient returns a PFS client that routes each call to the cluster of
+// the repo that the request refers to.
+func (mc *MultiClusterClient) PfsAPIClient() (pfs.APIClient, error) {
+	conn, err := mc.routingConn()
+	if err != nil {
+		return nil, err
+	}
+	return pfs.NewAPIClient(conn), nil
+}
+
+// routingConn returns a grpc connection whose interceptors send each call to
+// the cluster of the repo that the call's request refers to. The connection's
+// own target is never dialed.
+func (mc *MultiClusterClient) routingConn() (*grpc.ClientConn, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.conn == nil {
+		rc := &router{mc: mc}
+		conn, err := grpc.Dial("passthrough:///pachyderm-multi-cluster",
+			grpc.WithInsecure(),
+			grpc.WithUnaryInterceptor(rc.invoke),
+			grpc.WithStreamInterceptor(rc.newStream),
+		)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		mc.conn = conn
+	}
+	return mc.conn, nil
+}
+
+// Close closes the clients of all of the clusters.
+func (mc *MultiClusterClient) Close() error {
+	var retErr error
+	mc.mu.Lock()
+	if mc.conn != nil {
+		retErr = errors.EnsureStack(mc.conn.Close())
+	}
+	mc.mu.Unlock()
+	for _, c := range mc.clusters {
+		if err := c.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}
+	return retErr
+}
+
+// router implements the interceptors of a MultiClusterClient's routing
+// connection.
+type router struct {
+	mc *MultiClusterClient
+}
+
+func (rc *router) invoke(ctx context.Context, method string, req, reply interface{}, _ *grpc.ClientConn, _ grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	c := rc.mc.ForRepo(findRepo(req))
+	return errors.EnsureStack(c.clientConn.Invoke(withClusterMetadata(ctx, c), method, req, reply, opts...))
+}
+
+// newStream returns a stream that is bound to a cluster when its first message
+// is sent, since that is when the repo it refers to is known.
+func (rc *router) newStream(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, _ grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return &routingStream{
+		rc:     rc,
+		ctx:    ctx,
+		desc:   desc,
+		method: method,
+		opts:   opts,
+	}, nil
+}
+
+type routingStream struct {
+	rc     *router
+	ctx    context.Context
+	desc   *grpc.StreamDesc
+	method string
+	opts   []grpc.CallOption
+
+	mu     sync.Mutex
+	stream grpc.ClientStream
+}
+
+func (rs *routingStream) bind(m interface{}) (grpc.ClientStream, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.stream == nil {
+		c := rs.rc.mc.ForRepo(findRepo(m))
+		stream, err := c.clientConn.NewStream(withClusterMetadata(rs.ctx, c), rs.desc, rs.method, rs.opts...)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		rs.stream = stream
+	}
+	return rs.stream, nil
+}
+
+func (rs *routingStream) bound() (grpc.ClientStream, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.stream == nil {
+		return nil, errors.Errorf("no message has been sent on the stream")
+	}
+	return rs.stream, nil
+}
+
+func (rs *routingStream) SendMsg(m interface{}) error {
+	stream, err := rs.bind(m)
+	if err != nil {
+		return err
+	}
+	return errors.EnsureStack(stream.SendMsg(m))
+}
+
+func (rs *routingStream) RecvMsg(m interface{}) error {
+	stream, err := rs.bound()
+	if err != nil {
+		return err
+	}
+	return errors.EnsureStack(stream.RecvMsg(m))
+}
+
+func (rs *routingStream) Header() (metadata.MD, error) {
+	stream, err := rs.bound()
+	if err != nil {
+		return nil, err
+	}
+	md, err := stream.Header()
+	return md, errors.EnsureStack(err)
+}
+
+func (rs *routingStream) Trailer() metadata.MD {
+	stream, err := rs.bound()
+	if err != nil {
+		return nil
+	}
+	return stream.Trailer()
+}
+
+func (rs *routingStream) CloseSend() error {
+	stream, err := rs.bind(nil)
+	if err != nil {
+		return err
+	}
+	return errors.EnsureStack(stream.CloseSend())
+}
+
+func (rs *routingStream) Context() context.Context {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.stream == nil {
+		return rs.ctx
+	}
+	return rs.stream.Context()
+}
+
+// withClusterMetadata replaces the credentials in ctx with c's, so that the
+// credentials of one cluster are never sent to another.
+func withClusterMetadata(ctx context.Context, c *APIClient) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		md = md.Copy()
+		delete(md, auth.ContextTokenKey)
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	return c.AddMetadata(ctx)
+}
+
+var repoType = reflect.TypeOf(&pfs.Repo{})
+
+// findRepo returns the first repo referred to by a request, searching its
+// fields depth-first, or nil if it doesn't refer to one.
+func findRepo(m interface{}) *pfs.Repo {
+	if m == nil {
+		return nil
+	}
+	return findRepoValue(reflect.ValueOf(m), 0)
+}
+
+func findRepoValue(v reflect.Value, depth int) *pfs.Repo {
+	// Requests are shallow, this just guards against cycles.
+	if depth > 8 {
+		return nil
+	}
+	if v.Type() == repoType {
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(*pfs.Repo)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return findRepoValue(v.Elem(), depth+1)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if repo := findRepoValue(v.Field(i), depth+1); repo != nil {
+				return repo
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if repo := findRepoValue(v.Index(i), depth+1); repo != nil {
+				return repo
+			}
+		}
+	}
+	return nil
+}
+
+func isPattern(pattern string) bool {
+	for _, r := range pattern {
+		switch r {
+		case '*', '?', '[', '\\':
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,25 @@
 package client
 
-import "github.com/pachyderm/pachyderm/v2/src/pfs"
+import (
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
 
 type putFileConfig struct {
 	tag    string
 	append bool
+	mode   uint32
+	mtime  *types.Timestamp
+	split  *pfs.AddFile_Split
+	// linkTarget makes the file a symlink, if set.
+	linkTarget string
+	metadata   map[string]string
+	// parallelism is the number of parts of the content that are uploaded
+	// at a time, of partSize bytes each, if it's more than 1.
+	parallelism int
+	partSize    int
 }
 
 // PutFileOption configures a PutFile call.
@@ -24,6 +39,74 @@ func WithTagPutFile(tag string) PutFileOption {
 	}
 }
 
+// WithModePutFile configures the PutFile call to set the permission bits of
+// the file, for example 0755 to make it executable. PutFileTAR uses the modes
+// in the tar headers instead.
+func WithModePutFile(mode uint32) PutFileOption {
+	return func(pf *putFileConfig) {
+		pf.mode = mode
+	}
+}
+
+// WithModTimePutFile configures the PutFile call to set the modification time
+// of the file, instead of the time it's written. PutFileTAR uses the
+// modification times in the tar headers instead.
+func WithModTimePutFile(mtime time.Time) PutFileOption {
+	return func(pf *putFileConfig) {
+		// Only fails for times outside of the range of a timestamp.
+		pf.mtime, _ = types.TimestampProto(mtime)
+	}
+}
+
+// WithSymlinkPutFile configures the PutFile call to write the file as a
+// symlink to target, in which case the content must be empty. PutFileTAR
+// writes the symlinks in the archive as symlinks instead.
+func WithSymlinkPutFile(target string) PutFileOption {
+	return func(pf *putFileConfig) {
+		pf.linkTarget = target
+	}
+}
+
+// WithMetadataPutFile configures the PutFile call to add metadata to the file,
+// overwriting values with the same keys. The metadata is returned in the
+// file's FileInfo.
+func WithMetadataPutFile(metadata map[string]string) PutFileOption {
+	return func(pf *putFileConfig) {
+		pf.metadata = metadata
+	}
+}
+
+// WithSplitPutFile configures the PutFile call to split the content into
+// records separated by delimiter, and write them to numbered files in the
+// directory at the path, rather than to a single file. Each file gets
+// targetFileDatums records, or is filled to targetFileBytes, or gets one
+// record if neither is set. The first headerRecords records are written at
+// the start of every file. Splitting continues the numbering of the files in
+// the directory when appending.
+func WithSplitPutFile(delimiter pfs.Delimiter, targetFileDatums, targetFileBytes, headerRecords int64) PutFileOption {
+	return func(pf *putFileConfig) {
+		pf.split = &pfs.AddFile_Split{
+			Delimiter:        delimiter,
+			TargetFileDatums: targetFileDatums,
+			TargetFileBytes:  targetFileBytes,
+			HeaderRecords:    headerRecords,
+		}
+	}
+}
+
+// WithParallelPutFile configures the PutFile call to upload the content in
+// parts of partSize bytes (DefaultUploadPartSize if it's 0), parallelism of
+// them at a time, which are put back together in order by pachd. A part
+// whose upload fails with a transient error is uploaded again on its own.
+// Up to parallelism+1 parts are held in memory. Content that fits in a single
+// part, split files and symlinks are uploaded as usual.
+func WithParallelPutFile(parallelism, partSize int) PutFileOption {
+	return func(pf *putFileConfig) {
+		pf.parallelism = parallelism
+		pf.partSize = partSize
+	}
+}
+
 // DeleteFileOption configures a DeleteFile call.
 type DeleteFileOption func(*pfs.DeleteFile)
 
@@ -50,3 +133,298 @@ func WithTagCopyFile(tag string) CopyFileOption {
 		cf.Tag = tag
 	}
 }
+
+// WithRangeCopyFile configures the CopyFile call to copy at most length bytes
+// of a single file, starting at offset, or everything from offset if length is
+// 0. Only the content is copied, not the file's mode or metadata.
+func WithRangeCopyFile(offset, length int64) CopyFileOption {
+	return func(cf *pfs.CopyFile) {
+		cf.OffsetBytes = offset
+		cf.SizeBytes = length
+	}
+}
+
+// GetFileOption configures a GetFile call.
+type GetFileOption func(*pfs.GetFileRequest)
+
+// WithArchiveFormatGetFile configures the format of the archive returned by
+// GetFileTar, or written by GetFileURL.
+func WithArchiveFormatGetFile(format pfs.ArchiveFormat) GetFileOption {
+	return func(gf *pfs.GetFileRequest) {
+		gf.Format = format
+	}
+}
+
+// WithCaseInsensitiveGetFile configures the GetFile call to resolve the path
+// ignoring case.
+func WithCaseInsensitiveGetFile() GetFileOption {
+	return func(gf *pfs.GetFileRequest) {
+		gf.CaseInsensitive = true
+	}
+}
+
+// WithRangeGetFile configures the GetFile call to read at most length bytes of
+// a single file, starting at offset, or everything from offset if length is 0.
+func WithRangeGetFile(offset, length int64) GetFileOption {
+	return func(gf *pfs.GetFileRequest) {
+		gf.OffsetBytes = offset
+		gf.SizeBytes = length
+	}
+}
+
+// InspectFileOption configures an InspectFile call.
+type InspectFileOption func(*pfs.InspectFileRequest)
+
+// WithCaseInsensitiveInspectFile configures the InspectFile call to resolve
+// the path ignoring case.
+func WithCaseInsensitiveInspectFile() InspectFileOption {
+	return func(inf *pfs.InspectFileRequest) {
+		inf.CaseInsensitive = true
+	}
+}
+
+// ListFileOption configures a ListFile call.
+type ListFileOption func(*pfs.ListFileRequest)
+
+// WithExcludeHiddenListFile configures the ListFile call to leave out hidden
+// files, which are files whose names start with "." or with one of prefixes.
+func WithExcludeHiddenListFile(prefixes ...string) ListFileOption {
+	return func(lf *pfs.ListFileRequest) {
+		lf.ExcludeHidden = true
+		lf.HiddenPrefixes = prefixes
+	}
+}
+
+// WithTagListFile configures the ListFile call to return only the files
+// written with tag.
+func WithTagListFile(tag string) ListFileOption {
+	return func(lf *pfs.ListFileRequest) {
+		lf.File.Tag = tag
+	}
+}
+
+// WithSizeListFile configures the ListFile call to leave out the files and
+// directories smaller than min bytes, or larger than max bytes if it's set.
+func WithSizeListFile(min, max int64) ListFileOption {
+	return func(lf *pfs.ListFileRequest) {
+		lf.MinSizeBytes = min
+		lf.MaxSizeBytes = max
+	}
+}
+
+// WithTypeListFile configures the ListFile call to return only the files of
+// fileType.
+func WithTypeListFile(fileType pfs.FileType) ListFileOption {
+	return func(lf *pfs.ListFileRequest) {
+		lf.FileType = fileType
+	}
+}
+
+// WithChangedListFile configures the ListFile call to return only the files
+// that were changed in the commit, and the directories that contain them.
+func WithChangedListFile() ListFileOption {
+	return func(lf *pfs.ListFileRequest) {
+		lf.ChangedOnly = true
+	}
+}
+
+// WithSortListFile configures the ListFile call to return files in the order
+// of sortBy, reversed if reverse is set.
+func WithSortListFile(sortBy pfs.FileSortBy, reverse bool) ListFileOption {
+	return func(lf *pfs.ListFileRequest) {
+		lf.SortBy = sortBy
+		lf.Reverse = reverse
+	}
+}
+
+// GlobFileOption configures a GlobFile call.
+type GlobFileOption func(*pfs.GlobFileRequest)
+
+// WithExcludeHiddenGlobFile configures the GlobFile call to leave out hidden
+// files, which are files whose names start with "." or with one of prefixes,
+// and files in hidden directories.
+func WithExcludeHiddenGlobFile(prefixes ...string) GlobFileOption {
+	return func(gf *pfs.GlobFileRequest) {
+		gf.ExcludeHidden = true
+		gf.HiddenPrefixes = prefixes
+	}
+}
+
+// WithTagGlobFile configures the GlobFile call to return only the files
+// written with tag.
+func WithTagGlobFile(tag string) GlobFileOption {
+	return func(gf *pfs.GlobFileRequest) {
+		gf.Tag = tag
+	}
+}
+
+// StartCommitOption configures a StartCommit call.
+type StartCommitOption func(*pfs.StartCommitRequest)
+
+// WithDescriptionStartCommit configures the StartCommit call to describe the
+// commit with description.
+func WithDescriptionStartCommit(description string) StartCommitOption {
+	return func(sc *pfs.StartCommitRequest) {
+		sc.Description = description
+	}
+}
+
+// WithLabelsStartCommit configures the StartCommit call to annotate the commit
+// with labels.
+func WithLabelsStartCommit(labels map[string]string) StartCommitOption {
+	return func(sc *pfs.StartCommitRequest) {
+		sc.Labels = labels
+	}
+}
+
+// FinishCommitOption configures a FinishCommit call.
+type FinishCommitOption func(*pfs.FinishCommitRequest)
+
+// WithDescriptionFinishCommit configures the FinishCommit call to overwrite
+// the commit's description.
+func WithDescriptionFinishCommit(description string) FinishCommitOption {
+	return func(fc *pfs.FinishCommitRequest) {
+		fc.Description = description
+	}
+}
+
+// WithLabelsFinishCommit configures the FinishCommit call to add labels to the
+// commit. Labels with empty values are removed from it.
+func WithLabelsFinishCommit(labels map[string]string) FinishCommitOption {
+	return func(fc *pfs.FinishCommitRequest) {
+		fc.Labels = labels
+	}
+}
+
+// ListRepoOption configures a ListRepo call.
+type ListRepoOption func(*pfs.ListRepoRequest)
+
+// WithNameGlobListRepo configures the ListRepo call to only return repos
+// whose names match glob.
+func WithNameGlobListRepo(glob string) ListRepoOption {
+	return func(lr *pfs.ListRepoRequest) {
+		lr.NameGlob = glob
+	}
+}
+
+// WithCreatedAfterListRepo configures the ListRepo call to only return repos
+// created after t.
+func WithCreatedAfterListRepo(t time.Time) ListRepoOption {
+	return func(lr *pfs.ListRepoRequest) {
+		lr.CreatedAfter, _ = types.TimestampProto(t)
+	}
+}
+
+// WithLabelsListRepo configures the ListRepo call to only return repos with
+// all of labels. Labels with empty values match any value.
+func WithLabelsListRepo(labels map[string]string) ListRepoOption {
+	return func(lr *pfs.ListRepoRequest) {
+		lr.Labels = labels
+	}
+}
+
+// WithSortListRepo configures the ListRepo call to return repos in the order
+// of sortBy, reversed if reverse is set.
+func WithSortListRepo(sortBy pfs.RepoSortBy, reverse bool) ListRepoOption {
+	return func(lr *pfs.ListRepoRequest) {
+		lr.SortBy = sortBy
+		lr.Reverse = reverse
+	}
+}
+
+// ListCommitOption configures a ListCommit call.
+type ListCommitOption func(*pfs.ListCommitRequest)
+
+// WithArchivedListCommit configures the ListCommit call to also return
+// archived commits.
+func WithArchivedListCommit() ListCommitOption {
+	return func(lc *pfs.ListCommitRequest) {
+		lc.IncludeArchived = true
+	}
+}
+
+// WithLabelsListCommit configures the ListCommit call to only return commits
+// with all of labels. Labels with empty values match any value.
+func WithLabelsListCommit(labels map[string]string) ListCommitOption {
+	return func(lc *pfs.ListCommitRequest) {
+		lc.Labels = labels
+	}
+}
+
+// WithTimeRangeListCommit configures the ListCommit call to only return
+// commits started after startedAfter and finished before finishedBefore.
+// Either may be the zero time, to leave that end of the range open.
+func WithTimeRangeListCommit(startedAfter, finishedBefore time.Time) ListCommitOption {
+	return func(lc *pfs.ListCommitRequest) {
+		if !startedAfter.IsZero() {
+			lc.StartedAfter, _ = types.TimestampProto(startedAfter)
+		}
+		if !finishedBefore.IsZero() {
+			lc.FinishedBefore, _ = types.TimestampProto(finishedBefore)
+		}
+	}
+}
+
+// WithOriginListCommit configures the ListCommit call to only return commits
+// with an origin of kind, such as pfs.OriginKind_USER for commits started by
+// users.
+func WithOriginListCommit(kind pfs.OriginKind) ListCommitOption {
+	return func(lc *pfs.ListCommitRequest) {
+		lc.Origin = &pfs.CommitOrigin{Kind: kind}
+	}
+}
+
+// SubscribeCommitOption configures a SubscribeCommit call.
+type SubscribeCommitOption func(*pfs.SubscribeCommitRequest)
+
+// WithCursorSubscribeCommit configures the SubscribeCommit call to resume an
+// earlier subscription after the commit that cursor was returned with.
+func WithCursorSubscribeCommit(cursor string) SubscribeCommitOption {
+	return func(sc *pfs.SubscribeCommitRequest) {
+		sc.Cursor = cursor
+	}
+}
+
+// WithCursorsSubscribeCommit configures a SubscribeCommits call to resume an
+// earlier subscription after the commits that cursors were returned with, at
+// most one per repo.
+func WithCursorsSubscribeCommit(cursors ...string) SubscribeCommitOption {
+	return func(sc *pfs.SubscribeCommitRequest) {
+		sc.Cursors = cursors
+	}
+}
+
+// WithOriginSubscribeCommit configures the SubscribeCommit call to only
+// return commits with an origin of kind, such as pfs.OriginKind_USER for
+// commits started by users.
+func WithOriginSubscribeCommit(kind pfs.OriginKind) SubscribeCommitOption {
+	return func(sc *pfs.SubscribeCommitRequest) {
+		sc.Origin = &pfs.CommitOrigin{Kind: kind}
+	}
+}
+
+// WithBranchGlobSubscribeCommit configures the SubscribeCommit call to only
+// return commits created on branches whose names match glob. The call's
+// branch must be empty.
+func WithBranchGlobSubscribeCommit(glob string) SubscribeCommitOption {
+	return func(sc *pfs.SubscribeCommitRequest) {
+		sc.BranchGlob = glob
+	}
+}
+
+// WithPathPrefixSubscribeCommit configures the SubscribeCommit call to only
+// return finished commits that changed a file at or under prefix.
+func WithPathPrefixSubscribeCommit(prefix string) SubscribeCommitOption {
+	return func(sc *pfs.SubscribeCommitRequest) {
+		sc.PathPrefix = prefix
+	}
+}
+
+// WithHeartbeatSubscribeCommit configures the SubscribeCommit call to have
+// pachd send a heartbeat every interval. The client fails the subscription if
+// it stops receiving heartbeats, rather than waiting on a dead stream.
+func WithHeartbeatSubscribeCommit(interval time.Duration) SubscribeCommitOption {
+	return func(sc *pfs.SubscribeCommitRequest) {
+		sc.HeartbeatInterval = types.DurationProto(interval)
+	}
+}
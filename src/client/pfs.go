@@ -3,6 +3,12 @@ package client
 import (
 	"context"
 	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
@@ -15,6 +21,21 @@ func NewCommitSet(id string) *pfs.CommitSet {
 	return &pfs.CommitSet{ID: id}
 }
 
+// NewProject creates a pfs.Project.
+func NewProject(projectName string) *pfs.Project {
+	return &pfs.Project{Name: projectName}
+}
+
+// NewProjectRepo creates a pfs.Repo in the given project. An empty project
+// name refers to the default project.
+func NewProjectRepo(projectName string, repoName string) *pfs.Repo {
+	repo := NewRepo(repoName)
+	if projectName != "" {
+		repo.Project = NewProject(projectName)
+	}
+	return repo
+}
+
 // NewRepo creates a pfs.Repo.
 func NewRepo(repoName string) *pfs.Repo {
 	return &pfs.Repo{Name: repoName, Type: pfs.UserRepoType}
@@ -49,6 +70,58 @@ func NewFile(repoName string, branchName string, commitID string, path string) *
 	}
 }
 
+// CreateProject creates a new Project object in pfs with the given name.
+// Projects namespace repos, allowing repos with the same name to coexist in
+// different projects.
+func (c APIClient) CreateProject(projectName string) error {
+	_, err := c.PfsAPIClient.CreateProject(
+		c.Ctx(),
+		&pfs.CreateProjectRequest{
+			Project: NewProject(projectName),
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// InspectProject returns info about a specific Project.
+func (c APIClient) InspectProject(projectName string) (_ *pfs.ProjectInfo, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	return c.PfsAPIClient.InspectProject(
+		c.Ctx(),
+		&pfs.InspectProjectRequest{
+			Project: NewProject(projectName),
+		},
+	)
+}
+
+// ListProject returns info about all Projects.
+func (c APIClient) ListProject() ([]*pfs.ProjectInfo, error) {
+	resp, err := c.PfsAPIClient.ListProject(
+		c.Ctx(),
+		&pfs.ListProjectRequest{},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.ProjectInfo, nil
+}
+
+// DeleteProject deletes a project. If "force" is set to true, the repos in
+// the project are deleted along with it, otherwise deleting a project that
+// still contains repos is an error.
+func (c APIClient) DeleteProject(projectName string, force bool) error {
+	_, err := c.PfsAPIClient.DeleteProject(
+		c.Ctx(),
+		&pfs.DeleteProjectRequest{
+			Project: NewProject(projectName),
+			Force:   force,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // CreateRepo creates a new Repo object in pfs with the given name. Repos are
 // the top level data object in pfs and should be used to store data of a
 // similar type. For example rather than having a single Repo for an entire
@@ -63,6 +136,20 @@ func (c APIClient) CreateRepo(repoName string) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// CreateRepoFromTemplate creates a new repo laid out like an existing repo,
+// with its branches, triggers, settings and description, but none of its
+// data.
+func (c APIClient) CreateRepoFromTemplate(repoName, templateName string) error {
+	_, err := c.PfsAPIClient.CreateRepo(
+		c.Ctx(),
+		&pfs.CreateRepoRequest{
+			Repo:     NewRepo(repoName),
+			Template: NewRepo(templateName),
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // UpdateRepo upserts a repo with the given name.
 func (c APIClient) UpdateRepo(repoName string) error {
 	_, err := c.PfsAPIClient.CreateRepo(
@@ -95,8 +182,11 @@ func (c APIClient) ListRepo() ([]*pfs.RepoInfo, error) {
 
 // ListRepoByType returns info about Repos of the given type
 // The if repoType is empty, all Repos will be included
-func (c APIClient) ListRepoByType(repoType string) ([]*pfs.RepoInfo, error) {
+func (c APIClient) ListRepoByType(repoType string, opts ...ListRepoOption) ([]*pfs.RepoInfo, error) {
 	request := &pfs.ListRepoRequest{Type: repoType}
+	for _, opt := range opts {
+		opt(request)
+	}
 	repoInfos, err := c.PfsAPIClient.ListRepo(
 		c.Ctx(),
 		request,
@@ -126,6 +216,77 @@ func (c APIClient) DeleteRepo(repoName string, force bool) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// BreakRepoLock ends repoName's lock before it expires, recording reason in
+// the lock along with the caller.
+func (c APIClient) BreakRepoLock(repoName string, reason string) error {
+	_, err := c.PfsAPIClient.BreakRepoLock(
+		c.Ctx(),
+		&pfs.BreakRepoLockRequest{
+			Repo:   NewRepo(repoName),
+			Reason: reason,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// DeleteReposByLabels deletes the repos with all of labels, along with their
+// system repos. Labels with empty values match any value.
+func (c APIClient) DeleteReposByLabels(labels map[string]string, force bool) error {
+	_, err := c.PfsAPIClient.DeleteRepo(
+		c.Ctx(),
+		&pfs.DeleteRepoRequest{
+			Labels: labels,
+			Force:  force,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// DeleteRepos deletes repos, and the repos with all of labels if it's set, in
+// one transaction, downstream repos first. It returns the repos in the order
+// they were deleted, or would be with dryRun.
+func (c APIClient) DeleteRepos(repos []*pfs.Repo, labels map[string]string, force, dryRun bool) ([]*pfs.Repo, error) {
+	resp, err := c.PfsAPIClient.DeleteRepos(
+		c.Ctx(),
+		&pfs.DeleteReposRequest{
+			Repos:  repos,
+			Labels: labels,
+			Force:  force,
+			DryRun: dryRun,
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.Repos, nil
+}
+
+// ReservePath reserves prefix in repoName for owner, so that only owner can
+// modify the files under it. An empty owner reserves it for the caller.
+func (c APIClient) ReservePath(repoName, prefix, owner string) error {
+	_, err := c.PfsAPIClient.ReservePath(
+		c.Ctx(),
+		&pfs.ReservePathRequest{
+			Repo:   NewRepo(repoName),
+			Prefix: prefix,
+			Owner:  owner,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ReleasePath releases a prefix reserved with ReservePath.
+func (c APIClient) ReleasePath(repoName, prefix string) error {
+	_, err := c.PfsAPIClient.ReleasePath(
+		c.Ctx(),
+		&pfs.ReleasePathRequest{
+			Repo:   NewRepo(repoName),
+			Prefix: prefix,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // StartCommit begins the process of committing data to a Repo. Once started
 // you can write to the Commit with PutFile and when all the data has been
 // written you must finish the Commit with FinishCommit. NOTE, data is not
@@ -135,16 +296,17 @@ func (c APIClient) DeleteRepo(repoName string, force bool) error {
 // alias for the created Commit. This enables a more intuitive access pattern.
 // When the commit is started on a branch the previous head of the branch is
 // used as the parent of the commit.
-func (c APIClient) StartCommit(repoName string, branchName string) (_ *pfs.Commit, retErr error) {
+func (c APIClient) StartCommit(repoName string, branchName string, opts ...StartCommitOption) (_ *pfs.Commit, retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
-	return c.PfsAPIClient.StartCommit(
-		c.Ctx(),
-		&pfs.StartCommitRequest{
-			Branch: NewBranch(repoName, branchName),
-		},
-	)
+	req := &pfs.StartCommitRequest{
+		Branch: NewBranch(repoName, branchName),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return c.PfsAPIClient.StartCommit(c.Ctx(), req)
 }
 
 // StartCommitParent begins the process of committing data to a Repo. Once started
@@ -178,13 +340,14 @@ func (c APIClient) StartCommitParent(repoName string, branchName string, parentB
 // FinishCommit ends the process of committing data to a Repo and persists the
 // Commit. Once a Commit is finished the data becomes immutable and future
 // attempts to write to it with PutFile will error.
-func (c APIClient) FinishCommit(repoName string, branchName string, commitID string) error {
-	_, err := c.PfsAPIClient.FinishCommit(
-		c.Ctx(),
-		&pfs.FinishCommitRequest{
-			Commit: NewCommit(repoName, branchName, commitID),
-		},
-	)
+func (c APIClient) FinishCommit(repoName string, branchName string, commitID string, opts ...FinishCommitOption) error {
+	req := &pfs.FinishCommitRequest{
+		Commit: NewCommit(repoName, branchName, commitID),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	_, err := c.PfsAPIClient.FinishCommit(c.Ctx(), req)
 	return grpcutil.ScrubGRPC(err)
 }
 
@@ -194,6 +357,20 @@ func (c APIClient) InspectCommit(repoName string, branchName string, commitID st
 	return c.inspectCommit(repoName, branchName, commitID, pfs.CommitState_STARTED)
 }
 
+// InspectCommitDetails is like InspectCommit, but also returns details about
+// how the commit's data is stored, such as the IDs of its filesets.
+func (c APIClient) InspectCommitDetails(repoName string, branchName string, commitID string) (_ *pfs.CommitInfo, retErr error) {
+	defer func() { retErr = grpcutil.ScrubGRPC(retErr) }()
+	return c.PfsAPIClient.InspectCommit(
+		c.Ctx(),
+		&pfs.InspectCommitRequest{
+			Commit:  NewCommit(repoName, branchName, commitID),
+			Wait:    pfs.CommitState_STARTED,
+			Details: true,
+		},
+	)
+}
+
 // WaitCommit returns info about a specific Commit, but blocks until that
 // commit has been finished.
 func (c APIClient) WaitCommit(repoName string, branchName string, commitID string) (_ *pfs.CommitInfo, retErr error) {
@@ -201,6 +378,42 @@ func (c APIClient) WaitCommit(repoName string, branchName string, commitID strin
 	return c.inspectCommit(repoName, branchName, commitID, pfs.CommitState_FINISHED)
 }
 
+// WaitCommitTimeout is like WaitCommit, but the server gives up waiting once
+// 'timeout' elapses. In that case it returns the commit's current CommitInfo
+// along with an error for which pfsserver.IsCommitWaitTimeoutErr is true, so
+// callers can long-poll without tearing down their own context.
+func (c APIClient) WaitCommitTimeout(repoName string, branchName string, commitID string, timeout time.Duration) (_ *pfs.CommitInfo, retErr error) {
+	defer func() { retErr = grpcutil.ScrubGRPC(retErr) }()
+	commitInfo, err := c.PfsAPIClient.InspectCommit(
+		c.Ctx(),
+		&pfs.InspectCommitRequest{
+			Commit:      NewCommit(repoName, branchName, commitID),
+			Wait:        pfs.CommitState_FINISHED,
+			WaitTimeout: types.DurationProto(timeout),
+		},
+	)
+	if err != nil {
+		return commitInfoFromStatus(err), err
+	}
+	return commitInfo, nil
+}
+
+// commitInfoFromStatus returns the CommitInfo attached to a gRPC error's
+// status details, or nil if there isn't one.
+func commitInfoFromStatus(err error) *pfs.CommitInfo {
+	s, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	for _, detail := range s.Proto().Details {
+		commitInfo := &pfs.CommitInfo{}
+		if err := types.UnmarshalAny(&types.Any{TypeUrl: detail.TypeUrl, Value: detail.Value}, commitInfo); err == nil {
+			return commitInfo
+		}
+	}
+	return nil
+}
+
 func (c APIClient) inspectCommit(repoName string, branchName string, commitID string, wait pfs.CommitState) (*pfs.CommitInfo, error) {
 	commitInfo, err := c.PfsAPIClient.InspectCommit(
 		c.Ctx(),
@@ -224,12 +437,12 @@ func (c APIClient) inspectCommit(repoName string, branchName string, commitID st
 // If `to` and `from` are the same commit, no commits will be returned.
 // `number` determines how many commits are returned.  If `number` is 0,
 // all commits that match the aforementioned criteria are returned.
-func (c APIClient) ListCommit(repo *pfs.Repo, to, from *pfs.Commit, number uint64) ([]*pfs.CommitInfo, error) {
+func (c APIClient) ListCommit(repo *pfs.Repo, to, from *pfs.Commit, number uint64, opts ...ListCommitOption) ([]*pfs.CommitInfo, error) {
 	var result []*pfs.CommitInfo
 	if err := c.ListCommitF(repo, to, from, number, false, func(ci *pfs.CommitInfo) error {
 		result = append(result, ci)
 		return nil
-	}); err != nil {
+	}, opts...); err != nil {
 		return nil, err
 	}
 	return result, nil
@@ -245,7 +458,8 @@ func (c APIClient) ListCommit(repo *pfs.Repo, to, from *pfs.Commit, number uint6
 // `number` determines how many commits are returned.  If `number` is 0,
 // `reverse` lists the commits from oldest to newest, rather than newest to oldest
 // all commits that match the aforementioned criteria are passed to f.
-func (c APIClient) ListCommitF(repo *pfs.Repo, to, from *pfs.Commit, number uint64, reverse bool, f func(*pfs.CommitInfo) error) error {
+// Archived commits are left out unless WithArchivedListCommit is passed.
+func (c APIClient) ListCommitF(repo *pfs.Repo, to, from *pfs.Commit, number uint64, reverse bool, f func(*pfs.CommitInfo) error, opts ...ListCommitOption) error {
 	req := &pfs.ListCommitRequest{
 		Repo:    repo,
 		Number:  number,
@@ -253,6 +467,9 @@ func (c APIClient) ListCommitF(repo *pfs.Repo, to, from *pfs.Commit, number uint
 		To:      to,
 		From:    from,
 	}
+	for _, opt := range opts {
+		opt(req)
+	}
 	stream, err := c.PfsAPIClient.ListCommit(c.Ctx(), req)
 	if err != nil {
 		return grpcutil.ScrubGRPC(err)
@@ -315,6 +532,35 @@ func (c APIClient) CreateBranchTrigger(repoName string, branchName string, commi
 	return grpcutil.ScrubGRPC(err)
 }
 
+// CreateBranchStoragePolicy sets the storage policy of a branch, creating it if
+// it doesn't exist. The policy controls whether the data of the branch's
+// commits is kept in cold storage, and whether it's garbage collected as soon
+// as they're squashed.
+func (c APIClient) CreateBranchStoragePolicy(repoName string, branchName string, policy *pfs.BranchStoragePolicy) error {
+	_, err := c.PfsAPIClient.CreateBranch(
+		c.Ctx(),
+		&pfs.CreateBranchRequest{
+			Branch:        NewBranch(repoName, branchName),
+			StoragePolicy: policy,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// CreateBranchRetention sets the retention policy of a branch, creating it if
+// it doesn't exist. Commits on the branch beyond the policy's limits are
+// squashed in the background, except for branch heads.
+func (c APIClient) CreateBranchRetention(repoName string, branchName string, retention *pfs.BranchRetention) error {
+	_, err := c.PfsAPIClient.CreateBranch(
+		c.Ctx(),
+		&pfs.CreateBranchRequest{
+			Branch:    NewBranch(repoName, branchName),
+			Retention: retention,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
 // InspectBranch returns information on a specific PFS branch
 func (c APIClient) InspectBranch(repoName string, branchName string) (*pfs.BranchInfo, error) {
 	branchInfo, err := c.PfsAPIClient.InspectBranch(
@@ -354,6 +600,99 @@ func (c APIClient) DeleteBranch(repoName string, branchName string, force bool)
 	return grpcutil.ScrubGRPC(err)
 }
 
+// MergeBranch merges the changes made on branch from into branch into, in a
+// commit with both branches' heads as its parents. No commit is made if
+// there are conflicts, which are returned in the response instead.
+func (c APIClient) MergeBranch(repoName string, from, into string, description string) (*pfs.MergeBranchResponse, error) {
+	resp, err := c.PfsAPIClient.MergeBranch(
+		c.Ctx(),
+		&pfs.MergeBranchRequest{
+			From:        NewBranch(repoName, from),
+			Into:        NewBranch(repoName, into),
+			Description: description,
+		},
+	)
+	return resp, grpcutil.ScrubGRPC(err)
+}
+
+// InspectTrigger returns the state of the trigger on a branch, which shows how
+// far it is from moving the branch's head.
+func (c APIClient) InspectTrigger(repoName string, branchName string) (*pfs.TriggerInfo, error) {
+	info, err := c.PfsAPIClient.InspectTrigger(
+		c.Ctx(),
+		&pfs.InspectTriggerRequest{
+			Branch: NewBranch(repoName, branchName),
+		},
+	)
+	return info, grpcutil.ScrubGRPC(err)
+}
+
+// RunTrigger evaluates the trigger on a branch now, and moves the branch's
+// head if the trigger's conditions are met, or regardless of them if force is
+// true.
+func (c APIClient) RunTrigger(repoName string, branchName string, force bool) (*pfs.RunTriggerResponse, error) {
+	resp, err := c.PfsAPIClient.RunTrigger(
+		c.Ctx(),
+		&pfs.RunTriggerRequest{
+			Branch: NewBranch(repoName, branchName),
+			Force:  force,
+		},
+	)
+	return resp, grpcutil.ScrubGRPC(err)
+}
+
+// PromoteBranch moves the head of the branch toBranch to the head of the
+// branch fromBranch in the same repo. If expectedHeadID isn't empty, the
+// promotion fails unless it's the ID of toBranch's current head.
+func (c APIClient) PromoteBranch(repoName string, fromBranch string, toBranch string, expectedHeadID string) error {
+	var expectedHead *pfs.Commit
+	if expectedHeadID != "" {
+		expectedHead = NewCommit(repoName, toBranch, expectedHeadID)
+	}
+	_, err := c.PfsAPIClient.PromoteBranch(
+		c.Ctx(),
+		&pfs.PromoteBranchRequest{
+			From:         NewBranch(repoName, fromBranch),
+			To:           NewBranch(repoName, toBranch),
+			ExpectedHead: expectedHead,
+		},
+	)
+	return grpcutil.ScrubGRPC(err)
+}
+
+// WatchBranch calls cb with each change to the head of a branch from now on,
+// or with each change to the heads of all of the branches in the repo if
+// branchName is empty.
+func (c APIClient) WatchBranch(repoName string, branchName string, cb func(*pfs.BranchHeadChange) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	client, err := c.PfsAPIClient.WatchBranch(
+		c.Ctx(),
+		&pfs.WatchBranchRequest{
+			Branch: NewBranch(repoName, branchName),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	for {
+		change, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(change); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
 func (c APIClient) inspectCommitSet(id string, wait bool, cb func(*pfs.CommitInfo) error) error {
 	req := &pfs.InspectCommitSetRequest{
 		CommitSet: NewCommitSet(id),
@@ -430,8 +769,9 @@ func (c APIClient) SquashCommitSet(id string) error {
 }
 
 // SubscribeCommit is like ListCommit but it keeps listening for commits as
-// they come in.
-func (c APIClient) SubscribeCommit(repo *pfs.Repo, branchName string, from string, state pfs.CommitState, cb func(*pfs.CommitInfo) error) (retErr error) {
+// they come in. Each CommitInfo has a Cursor, which can be passed back with
+// WithCursorSubscribeCommit to resume the subscription after that commit.
+func (c APIClient) SubscribeCommit(repo *pfs.Repo, branchName string, from string, state pfs.CommitState, cb func(*pfs.CommitInfo) error, opts ...SubscribeCommitOption) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
@@ -443,19 +783,57 @@ func (c APIClient) SubscribeCommit(repo *pfs.Repo, branchName string, from strin
 	if from != "" {
 		req.From = repo.NewCommit(branchName, from)
 	}
-	client, err := c.PfsAPIClient.SubscribeCommit(c.Ctx(), req)
+	for _, opt := range opts {
+		opt(req)
+	}
+	return c.subscribeCommit(req, cb)
+}
+
+// SubscribeCommits is like SubscribeCommit, but subscribes to commits in all
+// of 'repos' over a single stream. Each CommitInfo's Cursor can be passed back
+// with WithCursorsSubscribeCommit to resume the subscription.
+func (c APIClient) SubscribeCommits(repos []*pfs.Repo, branchName string, state pfs.CommitState, cb func(*pfs.CommitInfo) error, opts ...SubscribeCommitOption) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	req := &pfs.SubscribeCommitRequest{
+		Repos:  repos,
+		Branch: branchName,
+		State:  state,
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return c.subscribeCommit(req, cb)
+}
+
+// Watch calls cb with the changes made to repos, branches and commits, in the
+// order they're made, until cb returns an error. Each event's Token can be
+// passed back to resume after it; if token is empty, only the changes made
+// from now on are returned. If repos are given, only their changes are
+// returned.
+func (c APIClient) Watch(token string, cb func(*pfs.WatchEvent) error, repos ...*pfs.Repo) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	client, err := c.PfsAPIClient.Watch(ctx, &pfs.WatchRequest{
+		Token: token,
+		Repos: repos,
+	})
 	if err != nil {
 		return err
 	}
 	for {
-		ci, err := client.Recv()
+		ev, err := client.Recv()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				return nil
 			}
 			return err
 		}
-		if err := cb(ci); err != nil {
+		if err := cb(ev); err != nil {
 			if errors.Is(err, errutil.ErrBreak) {
 				return nil
 			}
@@ -464,8 +842,67 @@ func (c APIClient) SubscribeCommit(repo *pfs.Repo, branchName string, from strin
 	}
 }
 
-// ClearCommit clears the state of an open commit.
-func (c APIClient) ClearCommit(repoName string, branchName string, commitID string) (retErr error) {
+// heartbeatMisses is the number of consecutive heartbeats a subscription may
+// miss before the client gives up on the stream.
+const heartbeatMisses = 3
+
+func (c APIClient) subscribeCommit(req *pfs.SubscribeCommitRequest, cb func(*pfs.CommitInfo) error) error {
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	// If heartbeats were requested, treat the stream as dead once several
+	// of them are missed while waiting for a message.
+	var timeout time.Duration
+	var timer *time.Timer
+	var dead int32
+	if req.HeartbeatInterval != nil {
+		interval, err := types.DurationFromProto(req.HeartbeatInterval)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		timeout = heartbeatMisses * interval
+		timer = time.AfterFunc(timeout, func() {
+			atomic.StoreInt32(&dead, 1)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+	client, err := c.PfsAPIClient.SubscribeCommit(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		ci, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if atomic.LoadInt32(&dead) == 1 {
+				return status.Errorf(codes.Unavailable, "no heartbeat received from pachd in %v", timeout)
+			}
+			return err
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		// Heartbeats carry no commit and aren't passed to cb.
+		if ci.Commit != nil {
+			if err := cb(ci); err != nil {
+				if errors.Is(err, errutil.ErrBreak) {
+					return nil
+				}
+				return err
+			}
+		}
+		if timer != nil {
+			timer.Reset(timeout)
+		}
+	}
+}
+
+// ClearCommit clears the state of an open commit. If paths (which may be
+// globs) are given, only the changes to the files that match them are
+// cleared.
+func (c APIClient) ClearCommit(repoName string, branchName string, commitID string, paths ...string) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
@@ -473,6 +910,85 @@ func (c APIClient) ClearCommit(repoName string, branchName string, commitID stri
 		c.Ctx(),
 		&pfs.ClearCommitRequest{
 			Commit: NewCommit(repoName, branchName, commitID),
+			Paths:  paths,
+		},
+	)
+	return err
+}
+
+// CherryPickCommit applies the changes that a commit made to its parent to a
+// new commit on targetBranch, in the same repo, which it returns.
+func (c APIClient) CherryPickCommit(repoName string, branchName string, commitID string, targetBranch string, description string) (_ *pfs.Commit, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	return c.PfsAPIClient.CherryPickCommit(
+		c.Ctx(),
+		&pfs.CherryPickCommitRequest{
+			Commit:      NewCommit(repoName, branchName, commitID),
+			Branch:      NewBranch(repoName, targetBranch),
+			Description: description,
+		},
+	)
+}
+
+// CommitLineage returns the commits upstream and downstream of a commit, up
+// to upstreamDepth and downstreamDepth levels of provenance away. A depth of
+// zero is unlimited, and a negative depth leaves that direction out.
+func (c APIClient) CommitLineage(repoName string, branchName string, commitID string, upstreamDepth, downstreamDepth int64) (_ *pfs.CommitLineageResponse, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	return c.PfsAPIClient.CommitLineage(
+		c.Ctx(),
+		&pfs.CommitLineageRequest{
+			Commit:          NewCommit(repoName, branchName, commitID),
+			UpstreamDepth:   upstreamDepth,
+			DownstreamDepth: downstreamDepth,
+		},
+	)
+}
+
+// RecallCommit moves the data of a commit from cold storage back to hot
+// storage.
+func (c APIClient) RecallCommit(repoName string, branchName string, commitID string) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	_, err := c.PfsAPIClient.RecallCommit(
+		c.Ctx(),
+		&pfs.RecallCommitRequest{
+			Commit: NewCommit(repoName, branchName, commitID),
+		},
+	)
+	return err
+}
+
+// ArchiveCommit archives a finished commit, which leaves it out of ListCommit
+// and moves its data to cold storage.
+func (c APIClient) ArchiveCommit(repoName string, branchName string, commitID string) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	_, err := c.PfsAPIClient.ArchiveCommit(
+		c.Ctx(),
+		&pfs.ArchiveCommitRequest{
+			Commit: NewCommit(repoName, branchName, commitID),
+		},
+	)
+	return err
+}
+
+// UnarchiveCommit makes an archived commit active again.
+func (c APIClient) UnarchiveCommit(repoName string, branchName string, commitID string) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	_, err := c.PfsAPIClient.ArchiveCommit(
+		c.Ctx(),
+		&pfs.ArchiveCommitRequest{
+			Commit:    NewCommit(repoName, branchName, commitID),
+			Unarchive: true,
 		},
 	)
 	return err
@@ -483,7 +999,17 @@ func (c APIClient) ClearCommit(repoName string, branchName string, commitID stri
 // prevent the completion of fsck. Errors that do prevent completion will be
 // returned from the function.
 func (c APIClient) Fsck(fix bool, cb func(*pfs.FsckResponse) error) error {
-	fsckClient, err := c.PfsAPIClient.Fsck(c.Ctx(), &pfs.FsckRequest{Fix: fix})
+	fixLevel := pfs.FsckFixLevel_REPORT_ONLY
+	if fix {
+		fixLevel = pfs.FsckFixLevel_SAFE_FIX
+	}
+	return c.FsckWithFixLevel(fixLevel, cb)
+}
+
+// FsckWithFixLevel is identical to Fsck, except that the issues found are
+// fixed if fixLevel allows it. Each FsckResponse's issue says what was done.
+func (c APIClient) FsckWithFixLevel(fixLevel pfs.FsckFixLevel, cb func(*pfs.FsckResponse) error) error {
+	fsckClient, err := c.PfsAPIClient.Fsck(c.Ctx(), &pfs.FsckRequest{FixLevel: fixLevel})
 	if err != nil {
 		return grpcutil.ScrubGRPC(err)
 	}
@@ -528,6 +1054,42 @@ func (c APIClient) FsckFastExit() error {
 	}
 }
 
+// GarbageCollect deletes the storage that nothing references, and returns
+// what the run did. It waits for any run that's already in progress.
+func (c APIClient) GarbageCollect() (*pfs.GarbageCollectionRun, error) {
+	resp, err := c.PfsAPIClient.GarbageCollect(c.Ctx(), &pfs.GarbageCollectRequest{})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return resp.Run, nil
+}
+
+// StorageUsage returns the logical and physical size of the branches in
+// repoName, or of every branch if it's empty.
+func (c APIClient) StorageUsage(repoName string) (*pfs.StorageUsageResponse, error) {
+	request := &pfs.StorageUsageRequest{}
+	if repoName != "" {
+		request.Repo = NewRepo(repoName)
+	}
+	resp, err := c.PfsAPIClient.StorageUsage(c.Ctx(), request)
+	return resp, grpcutil.ScrubGRPC(err)
+}
+
+// DedupReport compares the chunks that the files in commits a and b
+// reference, and returns how many of them, and how many bytes, the commits
+// share and each have on their own.
+func (c APIClient) DedupReport(a, b *pfs.Commit) (*pfs.CommitDedupReport, error) {
+	resp, err := c.PfsAPIClient.DedupReport(c.Ctx(), &pfs.DedupReportRequest{A: a, B: b})
+	return resp, grpcutil.ScrubGRPC(err)
+}
+
+// InspectGarbageCollection returns the storage that the next garbage
+// collection run will reclaim, and what the recent runs did.
+func (c APIClient) InspectGarbageCollection() (*pfs.GarbageCollectionStats, error) {
+	stats, err := c.PfsAPIClient.InspectGarbageCollection(c.Ctx(), &pfs.InspectGarbageCollectionRequest{})
+	return stats, grpcutil.ScrubGRPC(err)
+}
+
 // RunPFSLoadTest runs a PFS load test.
 func (c APIClient) RunPFSLoadTest(spec []byte, seed ...int64) (_ *pfs.RunLoadTestResponse, retErr error) {
 	defer func() {
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultDownloadPartSize is the size of the parts that GetFileParallel
+// downloads by default.
+const DefaultDownloadPartSize = 64 * 1024 * 1024
+
+// GetFileParallel writes the file at path to w, like GetFile, but downloads
+// it in parts of partSize bytes (DefaultDownloadPartSize if it's 0),
+// parallelism of them at a time, with GetFileRange. Each part is written to w
+// at its offset, and writes to w are serialized, so w doesn't need to be safe
+// for concurrent use. A range set in opts with WithRangeGetFile is downloaded
+// in parts too, and written to w from offset 0. A part whose download fails
+// with a transient error is resumed on its own.
+//
+// path must name a single file. A file in a commit that isn't finished could
+// change between the parts' downloads, so it's downloaded with GetFile
+// instead, as is a file that fits in a single part.
+func (c APIClient) GetFileParallel(commit *pfs.Commit, path string, w io.WriterAt, parallelism, partSize int, opts ...GetFileOption) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	if partSize <= 0 {
+		partSize = DefaultDownloadPartSize
+	}
+	commitInfo, err := c.PfsAPIClient.InspectCommit(c.Ctx(), &pfs.InspectCommitRequest{Commit: commit})
+	if err != nil {
+		return err
+	}
+	if commitInfo.Finished == nil || parallelism <= 1 {
+		return c.GetFile(commit, path, &offsetWriter{w: w}, opts...)
+	}
+	// The parts are all read from the same commit, even if commit is a branch
+	// whose head moves in the meantime.
+	commit = commitInfo.Commit
+	req := &pfs.GetFileRequest{}
+	for _, opt := range opts {
+		opt(req)
+	}
+	var inspectOpts []InspectFileOption
+	if req.CaseInsensitive {
+		inspectOpts = append(inspectOpts, WithCaseInsensitiveInspectFile())
+	}
+	fi, err := c.InspectFile(commit, path, inspectOpts...)
+	if err != nil {
+		return err
+	}
+	if fi.FileType != pfs.FileType_FILE {
+		return errors.Errorf("%s is not a file", path)
+	}
+	path = fi.File.Path
+	start, end := req.OffsetBytes, int64(fi.SizeBytes)
+	if req.SizeBytes > 0 && start+req.SizeBytes < end {
+		end = start + req.SizeBytes
+	}
+	if end-start <= int64(partSize) {
+		return c.GetFile(commit, path, &offsetWriter{w: w}, opts...)
+	}
+	eg, ctx := errgroup.WithContext(c.Ctx())
+	pachClient := c.WithCtx(ctx)
+	limit := make(chan struct{}, parallelism)
+	var mu sync.Mutex
+	// Parts are started as earlier ones finish, until one of them fails.
+	for offset := start; offset < end && ctx.Err() == nil; offset += int64(partSize) {
+		limit <- struct{}{}
+		offset, length := offset, int64(partSize)
+		if offset+length > end {
+			length = end - offset
+		}
+		eg.Go(func() error {
+			defer func() { <-limit }()
+			ow := &offsetWriter{w: w, offset: offset - start, mu: &mu}
+			if err := pachClient.GetFileRange(commit, path, offset, length, ow, opts...); err != nil {
+				return err
+			}
+			if ow.offset != offset-start+length {
+				return errors.Errorf("%s changed while it was being downloaded", path)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// offsetWriter writes to w sequentially, starting at offset. If mu is set,
+// it's held for each write.
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+	mu     *sync.Mutex
+}
+
+func (ow *offsetWriter) Write(data []byte) (int, error) {
+	if ow.mu != nil {
+		ow.mu.Lock()
+		defer ow.mu.Unlock()
+	}
+	n, err := ow.w.WriteAt(data, ow.offset)
+	ow.offset += int64(n)
+	return n, errors.EnsureStack(err)
+}
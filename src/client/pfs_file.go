@@ -5,9 +5,11 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"os"
 	"time"
 
 	"github.com/gogo/protobuf/types"
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
@@ -23,6 +25,13 @@ func (c APIClient) PutFile(commit *pfs.Commit, path string, r io.Reader, opts ..
 	})
 }
 
+// PutFileSplit splits the content of r into records separated by delimiter,
+// and puts them into numbered files in the directory at path. See
+// WithSplitPutFile for what the other arguments do.
+func (c APIClient) PutFileSplit(commit *pfs.Commit, path string, delimiter pfs.Delimiter, targetFileDatums, targetFileBytes, headerRecords int64, r io.Reader, opts ...PutFileOption) error {
+	return c.PutFile(commit, path, r, append(opts, WithSplitPutFile(delimiter, targetFileDatums, targetFileBytes, headerRecords))...)
+}
+
 // PutFileTAR puts a set of files into PFS from a tar stream.
 func (c APIClient) PutFileTAR(commit *pfs.Commit, r io.Reader, opts ...PutFileOption) error {
 	return c.WithModifyFileClient(commit, func(mf ModifyFile) error {
@@ -39,18 +48,47 @@ func (c APIClient) PutFileURL(commit *pfs.Commit, path, url string, recursive bo
 	})
 }
 
-// DeleteFile deletes a file from PFS.
+// DeleteFile deletes a file from PFS. If path is a glob pattern, every file
+// and directory in the commit that matches it is deleted.
 func (c APIClient) DeleteFile(commit *pfs.Commit, path string, opts ...DeleteFileOption) error {
 	return c.WithModifyFileClient(commit, func(mf ModifyFile) error {
 		return mf.DeleteFile(path, opts...)
 	})
 }
 
-// CopyFile copies a file from one PFS location to another.
-// It can be used on directories or regular files.
+// DeleteTag deletes the files at or under path that were written with tag,
+// leaving the files written with other tags in place.
+func (c APIClient) DeleteTag(commit *pfs.Commit, path, tag string) error {
+	return c.WithModifyFileClient(commit, func(mf ModifyFile) error {
+		return mf.DeleteTag(path, tag)
+	})
+}
+
+// CopyFile copies a file from one PFS location to another, which can be in
+// another repo. It can be used on directories or regular files. The copies
+// refer to the source's data rather than rewriting it, and record the file
+// they were copied from in their FileInfos' CopiedFrom. If srcPath is a glob
+// pattern, everything that matches it is copied into the directory dstPath.
 func (c APIClient) CopyFile(dstCommit *pfs.Commit, dstPath string, srcCommit *pfs.Commit, srcPath string, opts ...CopyFileOption) error {
-	return c.WithModifyFileClient(dstCommit, func(mf ModifyFile) error {
-		return mf.CopyFile(dstPath, srcCommit.NewFile(srcPath), opts...)
+	cf := &pfs.CopyFile{
+		Dst: dstPath,
+		Src: srcCommit.NewFile(srcPath),
+	}
+	for _, opt := range opts {
+		opt(cf)
+	}
+	_, err := c.PfsAPIClient.CopyFile(c.Ctx(), &pfs.CopyFileRequest{
+		Commit:   dstCommit,
+		CopyFile: cf,
+	})
+	return grpcutil.ScrubGRPC(err)
+}
+
+// MoveFile moves a file or directory from src to dst in commit, without
+// copying its data.
+func (c APIClient) MoveFile(commit *pfs.Commit, src, dst string) error {
+	return c.WithModifyFileClient(commit, func(mf ModifyFile) error {
+		return mf.MoveFile(src, dst)
 	})
 }
 
@@ -68,8 +106,13 @@ type ModifyFile interface {
 	PutFileURL(path, url string, recursive bool, opts ...PutFileOption) error
 	// DeleteFile deletes a file from PFS.
 	DeleteFile(path string, opts ...DeleteFileOption) error
+	// DeleteTag deletes the files at or under path that were written with
+	// tag.
+	DeleteTag(path, tag string) error
 	// CopyFile copies a file from src to dst.
 	CopyFile(dst string, src *pfs.File, opts ...CopyFileOption) error
+	// MoveFile moves a file or directory from src to dst in the same commit.
+	MoveFile(src, dst string) error
 }
 
 // WithModifyFileClient creates a new ModifyFileClient that is scoped to the passed in callback.
@@ -89,7 +132,11 @@ func (c APIClient) WithModifyFileClient(commit *pfs.Commit, cb func(ModifyFile)
 	return cb(mfc)
 }
 
-// NewModifyFileClient creates a new ModifyFileClient.
+// NewModifyFileClient creates a new ModifyFileClient. If commit is a branch
+// whose head is finished (or that doesn't exist yet), the modifications are
+// written to a file set first, and a commit with them is then started and
+// finished on the branch in a single transaction when the client is closed, so
+// any number of clients can modify the same branch concurrently.
 func (c APIClient) NewModifyFileClient(commit *pfs.Commit) (_ *ModifyFileClient, retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
@@ -106,7 +153,8 @@ func (c APIClient) NewModifyFileClient(commit *pfs.Commit) (_ *ModifyFileClient,
 	return &ModifyFileClient{
 		client: client,
 		modifyFileCore: modifyFileCore{
-			client: client,
+			client:     client,
+			pachClient: c,
 		},
 	}, nil
 }
@@ -120,7 +168,11 @@ type modifyFileCore struct {
 	client interface {
 		Send(*pfs.ModifyFileRequest) error
 	}
-	err error
+	// pachClient uploads the parts of parallel uploads, whose file sets
+	// renewer keeps alive until the client is closed.
+	pachClient APIClient
+	renewer    *renew.StringSet
+	err        error
 }
 
 func (mfc *modifyFileCore) PutFile(path string, r io.Reader, opts ...PutFileOption) error {
@@ -137,29 +189,47 @@ func (mfc *modifyFileCore) PutFile(path string, r io.Reader, opts ...PutFileOpti
 				return err
 			}
 		}
-		emptyFile := true
-		if _, err := grpcutil.ChunkReader(r, func(data []byte) error {
-			emptyFile = false
-			return mfc.sendPutFile(&pfs.AddFile{
-				Path: path,
-				Tag:  config.tag,
-				Source: &pfs.AddFile_Raw{
-					Raw: &types.BytesValue{Value: data},
-				},
-			})
-		}); err != nil {
-			return err
+		if config.parallelism > 1 && config.split == nil && config.linkTarget == "" {
+			return mfc.putFileParallel(path, config, r)
 		}
-		if emptyFile {
-			return mfc.sendPutFile(&pfs.AddFile{
-				Path: path,
-				Tag:  config.tag,
-			})
-		}
-		return nil
+		return mfc.sendPutFileReader(path, config, r)
 	})
 }
 
+// sendPutFileReader sends the content of r in chunks.
+func (mfc *modifyFileCore) sendPutFileReader(path string, config *putFileConfig, r io.Reader) error {
+	emptyFile := true
+	if _, err := grpcutil.ChunkReader(r, func(data []byte) error {
+		emptyFile = false
+		return mfc.sendPutFile(&pfs.AddFile{
+			Path: path,
+			Tag:  config.tag,
+			Source: &pfs.AddFile_Raw{
+				Raw: &types.BytesValue{Value: data},
+			},
+			Mode:       config.mode,
+			Mtime:      config.mtime,
+			Split:      config.split,
+			LinkTarget: config.linkTarget,
+			Metadata:   config.metadata,
+		})
+	}); err != nil {
+		return err
+	}
+	if emptyFile {
+		return mfc.sendPutFile(&pfs.AddFile{
+			Path:       path,
+			Tag:        config.tag,
+			Mode:       config.mode,
+			Mtime:      config.mtime,
+			Split:      config.split,
+			LinkTarget: config.linkTarget,
+			Metadata:   config.metadata,
+		})
+	}
+	return nil
+}
+
 func (mfc *modifyFileCore) maybeError(f func() error) (retErr error) {
 	if mfc.err != nil {
 		return mfc.err
@@ -204,26 +274,23 @@ func (mfc *modifyFileCore) PutFileTAR(r io.Reader, opts ...PutFileOption) error
 					return err
 				}
 			}
-			if hdr.Size == 0 {
-				if err := mfc.sendPutFile(&pfs.AddFile{
-					Path: p,
-					Tag:  config.tag,
-				}); err != nil {
-					return err
-				}
-			} else {
-				if _, err := grpcutil.ChunkReader(tr, func(data []byte) error {
-					return mfc.sendPutFile(&pfs.AddFile{
-						Path: p,
-						Tag:  config.tag,
-						Source: &pfs.AddFile_Raw{
-							Raw: &types.BytesValue{Value: data},
-						},
-					})
-				}); err != nil {
-					return err
+			fileConfig := &putFileConfig{
+				tag:      config.tag,
+				mode:     uint32(os.FileMode(hdr.Mode).Perm()),
+				metadata: config.metadata,
+			}
+			if hdr.Typeflag == tar.TypeSymlink {
+				fileConfig.linkTarget = hdr.Linkname
+			}
+			if !hdr.ModTime.IsZero() {
+				fileConfig.mtime, err = types.TimestampProto(hdr.ModTime)
+				if err != nil {
+					return errors.EnsureStack(err)
 				}
 			}
+			if err := mfc.sendPutFileReader(p, fileConfig, tr); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
@@ -244,8 +311,11 @@ func (mfc *modifyFileCore) PutFileURL(path, url string, recursive bool, opts ...
 			}
 		}
 		pf := &pfs.AddFile{
-			Path: path,
-			Tag:  config.tag,
+			Path:     path,
+			Tag:      config.tag,
+			Mode:     config.mode,
+			Mtime:    config.mtime,
+			Metadata: config.metadata,
 			Source: &pfs.AddFile_Url{
 				Url: &pfs.AddFile_URLSource{
 					URL:       url,
@@ -275,6 +345,16 @@ func (mfc *modifyFileCore) sendDeleteFile(req *pfs.DeleteFile) error {
 	})
 }
 
+func (mfc *modifyFileCore) DeleteTag(path, tag string) error {
+	return mfc.maybeError(func() error {
+		return mfc.client.Send(&pfs.ModifyFileRequest{
+			Body: &pfs.ModifyFileRequest_DeleteTag{
+				DeleteTag: &pfs.DeleteTag{Path: path, Tag: tag},
+			},
+		})
+	})
+}
+
 func (mfc *modifyFileCore) CopyFile(dst string, src *pfs.File, opts ...CopyFileOption) error {
 	return mfc.maybeError(func() error {
 		cf := &pfs.CopyFile{
@@ -296,21 +376,45 @@ func (mfc *modifyFileCore) sendCopyFile(req *pfs.CopyFile) error {
 	})
 }
 
+func (mfc *modifyFileCore) MoveFile(src, dst string) error {
+	return mfc.maybeError(func() error {
+		return mfc.client.Send(&pfs.ModifyFileRequest{
+			Body: &pfs.ModifyFileRequest_MoveFile{
+				MoveFile: &pfs.MoveFile{Src: src, Dst: dst},
+			},
+		})
+	})
+}
+
 // Close closes the ModifyFileClient.
 func (mfc *ModifyFileClient) Close() error {
 	return mfc.maybeError(func() error {
 		_, err := mfc.client.CloseAndRecv()
+		mfc.closeRenewer()
 		return err
 	})
 }
 
+// File sets are collections of files that are staged in pachd without a
+// commit. A file set is created with CreateFileSet, and is deleted once its
+// time-to-live runs out, unless it's renewed, or added to a commit with
+// AddFileSet. The files in a file set can be read like those of a commit,
+// through the commit returned by NewFileSetCommit.
+
 // FileSetsRepoName is the repo name used to access filesets as virtual commits.
 const FileSetsRepoName = "__filesets__"
 
 // DefaultTTL is the default time-to-live for a temporary fileset.
 const DefaultTTL = 10 * time.Minute
 
-// WithRenewer provides a scoped fileset renewer.
+// NewFileSetCommit returns the virtual commit through which the files in the
+// file set ID can be read.
+func NewFileSetCommit(ID string) *pfs.Commit {
+	return NewRepo(FileSetsRepoName).NewCommit("", ID)
+}
+
+// WithRenewer provides a scoped fileset renewer, which renews the file sets
+// added to it until cb returns.
 func (c APIClient) WithRenewer(cb func(context.Context, *renew.StringSet) error) error {
 	rf := func(ctx context.Context, p string, ttl time.Duration) error {
 		return c.WithCtx(ctx).RenewFileSet(p, ttl)
@@ -318,7 +422,9 @@ func (c APIClient) WithRenewer(cb func(context.Context, *renew.StringSet) error)
 	return renew.WithStringSet(c.Ctx(), DefaultTTL, rf, cb)
 }
 
-// WithCreateFileSetClient provides a scoped fileset client.
+// WithCreateFileSetClient provides a scoped fileset client. The file set that
+// cb writes is created when it returns, and expires after DefaultTTL unless
+// it's renewed.
 func (c APIClient) WithCreateFileSetClient(cb func(ModifyFile) error) (resp *pfs.CreateFileSetResponse, retErr error) {
 	cancelCtx, cancel := context.WithCancel(c.Ctx())
 	defer cancel()
@@ -352,7 +458,8 @@ func (c APIClient) NewCreateFileSetClient() (_ *CreateFileSetClient, retErr erro
 	return &CreateFileSetClient{
 		client: client,
 		modifyFileCore: modifyFileCore{
-			client: client,
+			client:     client,
+			pachClient: c,
 		},
 	}, nil
 }
@@ -362,6 +469,7 @@ func (ctfsc *CreateFileSetClient) Close() (*pfs.CreateFileSetResponse, error) {
 	var ret *pfs.CreateFileSetResponse
 	if err := ctfsc.maybeError(func() error {
 		resp, err := ctfsc.client.CloseAndRecv()
+		ctfsc.closeRenewer()
 		if err != nil {
 			return err
 		}
@@ -373,7 +481,121 @@ func (ctfsc *CreateFileSetClient) Close() (*pfs.CreateFileSetResponse, error) {
 	return ret, nil
 }
 
-// AddFileSet adds a fileset to a commit.
+// CreateFileSet creates a file set with the files that cb writes, and returns
+// its ID. The file set expires after DefaultTTL unless it's renewed.
+func (c APIClient) CreateFileSet(cb func(ModifyFile) error) (string, error) {
+	resp, err := c.WithCreateFileSetClient(cb)
+	if err != nil {
+		return "", err
+	}
+	return resp.FileSetId, nil
+}
+
+// GetFileSet returns the ID of a file set with the files in a commit, which
+// expires after DefaultTTL unless it's renewed.
+func (c APIClient) GetFileSet(repo, branch, commit string) (_ string, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	resp, err := c.PfsAPIClient.GetFileSet(
+		c.Ctx(),
+		&pfs.GetFileSetRequest{
+			Commit: NewCommit(repo, branch, commit),
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return resp.FileSetId, nil
+}
+
+// ComposeFileSet composes the file sets IDs into a new file set, and returns
+// its ID. The files in later file sets are layered on top of those in earlier
+// ones, as if they were written to a commit in order, which makes it their
+// union. The new file set expires after ttl, or DefaultTTL if it's 0, unless
+// it's renewed.
+func (c APIClient) ComposeFileSet(IDs []string, ttl time.Duration) (_ string, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	resp, err := c.PfsAPIClient.ComposeFileSet(
+		c.Ctx(),
+		&pfs.ComposeFileSetRequest{
+			FileSetIds: IDs,
+			TtlSeconds: int64(ttl.Seconds()),
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return resp.FileSetId, nil
+}
+
+// FilterFileSet makes a new file set with the files in the file set ID whose
+// paths start with prefix and match glob, either of which can be empty, and
+// returns its ID. A file matches glob if its path, or the path of one of its
+// parent directories, does. If exclude is true, the new file set has the
+// files that don't match instead. The files' data is referenced rather than
+// copied. The new file set expires after ttl, or DefaultTTL if it's 0, unless
+// it's renewed.
+func (c APIClient) FilterFileSet(ID, prefix, glob string, exclude bool, ttl time.Duration) (_ string, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	resp, err := c.PfsAPIClient.FilterFileSet(
+		c.Ctx(),
+		&pfs.FilterFileSetRequest{
+			FileSetId:  ID,
+			PathPrefix: prefix,
+			Glob:       glob,
+			Exclude:    exclude,
+			TtlSeconds: int64(ttl.Seconds()),
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return resp.FileSetId, nil
+}
+
+// SubtractFileSet makes a new file set with the files in the file set ID
+// whose paths aren't in the file set subtrahendID, and returns its ID. The
+// files' data is referenced rather than copied. The new file set expires
+// after ttl, or DefaultTTL if it's 0, unless it's renewed.
+func (c APIClient) SubtractFileSet(ID, subtrahendID string, ttl time.Duration) (_ string, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	resp, err := c.PfsAPIClient.SubtractFileSet(
+		c.Ctx(),
+		&pfs.SubtractFileSetRequest{
+			FileSetId:    ID,
+			SubtrahendId: subtrahendID,
+			TtlSeconds:   int64(ttl.Seconds()),
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return resp.FileSetId, nil
+}
+
+// InspectFileSet returns the size of the file set ID, and the number of file
+// sets it's composed of.
+func (c APIClient) InspectFileSet(ID string) (_ *pfs.FileSetInfo, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	return c.PfsAPIClient.InspectFileSet(
+		c.Ctx(),
+		&pfs.InspectFileSetRequest{
+			FileSetId: ID,
+		},
+	)
+}
+
+// AddFileSet adds a fileset to a commit, which must be open. The files in it
+// are written to the commit as if they were written by ModifyFile.
 func (c APIClient) AddFileSet(repo, branch, commit, ID string) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
@@ -388,7 +610,8 @@ func (c APIClient) AddFileSet(repo, branch, commit, ID string) (retErr error) {
 	return err
 }
 
-// RenewFileSet renews a fileset.
+// RenewFileSet renews a fileset, so that it expires after ttl, which can be
+// at most 30 minutes.
 func (c APIClient) RenewFileSet(ID string, ttl time.Duration) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
@@ -409,23 +632,101 @@ func (c APIClient) RenewFileSet(ID string, ttl time.Duration) (retErr error) {
 // than size if you pass a value larger than the size of the file.
 // If size is set to 0 then all of the data will be returned.
 // TODO: Should we error if multiple files are matched?
-func (c APIClient) GetFile(commit *pfs.Commit, path string, w io.Writer) error {
-	r, err := c.getFileTar(commit, path)
+//
+// If the stream fails with a transient error after data has started to
+// arrive, GetFile requests the file again and resumes after the last byte
+// written to w. A file that changed in the meantime, as seen by its name,
+// size or modification time, fails the download rather than being spliced.
+func (c APIClient) GetFile(commit *pfs.Commit, path string, w io.Writer, opts ...GetFileOption) error {
+	// seen holds the headers of the files received so far, and written the
+	// number of bytes of their content written to w.
+	var seen []*tar.Header
+	var written int64
+	return c.retryStream(backoff.NewExponentialBackOff(), func(progress func()) error {
+		r, err := c.getFileTar(commit, path, opts...)
+		if err != nil {
+			return c.getFileErr(err, seen)
+		}
+		var i int
+		var offset int64
+		if err := tarutil.Iterate(r, func(f tarutil.File) error {
+			hdr, err := f.Header()
+			if err != nil {
+				return callbackError{err}
+			}
+			if i < len(seen) {
+				if prev := seen[i]; prev.Name != hdr.Name || prev.Size != hdr.Size || !prev.ModTime.Equal(hdr.ModTime) {
+					return callbackError{errors.Errorf("%s changed while it was being downloaded", hdr.Name)}
+				}
+			} else {
+				seen = append(seen, hdr)
+			}
+			i++
+			rw := &resumeWriter{w: w, skip: written - offset, written: &written, progress: progress}
+			offset += hdr.Size
+			return f.Content(rw)
+		}, true); err != nil {
+			return c.getFileErr(err, seen)
+		}
+		if i < len(seen) {
+			return callbackError{errors.Errorf("%s changed while it was being downloaded", path)}
+		}
+		return nil
+	})
+}
+
+// GetFileRange writes at most length bytes of the file at path to w, starting
+// at offset, or everything from offset if length is 0. Only the parts of the
+// file in the range are read.
+func (c APIClient) GetFileRange(commit *pfs.Commit, path string, offset, length int64, w io.Writer, opts ...GetFileOption) error {
+	return c.GetFile(commit, path, w, append(opts, WithRangeGetFile(offset, length))...)
+}
+
+// getFileErr makes a GetFile stream error fatal if nothing has been received
+// yet, so that GetFile only retries downloads that are underway.
+func (c APIClient) getFileErr(err error, seen []*tar.Header) error {
+	if len(seen) == 0 && !errors.As(err, &callbackError{}) {
+		return callbackError{grpcutil.ScrubGRPC(err)}
+	}
+	return err
+}
+
+// resumeWriter writes to w, discarding the first skip bytes, which were
+// written to w before a GetFile stream was resumed.
+type resumeWriter struct {
+	w        io.Writer
+	skip     int64
+	written  *int64
+	progress func()
+}
+
+func (rw *resumeWriter) Write(data []byte) (int, error) {
+	n := len(data)
+	if rw.skip >= int64(n) {
+		rw.skip -= int64(n)
+		return n, nil
+	}
+	data = data[rw.skip:]
+	rw.skip = 0
+	m, err := rw.w.Write(data)
+	*rw.written += int64(m)
 	if err != nil {
-		return err
+		return n - len(data) + m, callbackError{err}
 	}
-	return tarutil.Iterate(r, func(f tarutil.File) error {
-		return f.Content(w)
-	}, true)
+	rw.progress()
+	return n, nil
 }
 
-func (c APIClient) getFileTar(commit *pfs.Commit, path string) (_ io.Reader, retErr error) {
+func (c APIClient) getFileTar(commit *pfs.Commit, path string, opts ...GetFileOption) (_ io.Reader, retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
 	req := &pfs.GetFileRequest{
 		File: commit.NewFile(path),
 	}
+	for _, opt := range opts {
+		opt(req)
+	}
 	client, err := c.PfsAPIClient.GetFileTAR(c.Ctx(), req)
 	if err != nil {
 		return nil, err
@@ -433,15 +734,24 @@ func (c APIClient) getFileTar(commit *pfs.Commit, path string) (_ io.Reader, ret
 	return grpcutil.NewStreamingBytesReader(client, nil), nil
 }
 
-// GetFileTar gets a tar file from PFS.
-func (c APIClient) GetFileTar(commit *pfs.Commit, path string) (io.Reader, error) {
-	return c.getFileTar(commit, path)
+// GetFileTar gets the files that match path, which may be a glob, as a tar
+// archive. Directories are included with their descendants, so this can be
+// used to download a directory. Entries have absolute paths, and keep the mode
+// and modification time of each file.
+func (c APIClient) GetFileTar(commit *pfs.Commit, path string, opts ...GetFileOption) (io.Reader, error) {
+	return c.getFileTar(commit, path, opts...)
+}
+
+// GetFileZip gets the files that match path as a zip archive, which is
+// streamed rather than built in memory.
+func (c APIClient) GetFileZip(commit *pfs.Commit, path string, opts ...GetFileOption) (io.Reader, error) {
+	return c.getFileTar(commit, path, append(opts, WithArchiveFormatGetFile(pfs.ArchiveFormat_ZIP))...)
 }
 
 // GetFileReader gets a reader for the specified path
 // TODO: This should probably be an io.ReadCloser so we can close the rpc if the full file isn't read.
-func (c APIClient) GetFileReader(commit *pfs.Commit, path string) (io.Reader, error) {
-	r, err := c.getFileTar(commit, path)
+func (c APIClient) GetFileReader(commit *pfs.Commit, path string, opts ...GetFileOption) (io.Reader, error) {
+	r, err := c.getFileTar(commit, path, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -518,7 +828,7 @@ func (gfrs *getFileReadSeeker) Seek(offset int64, whence int) (int64, error) {
 }
 
 // GetFileURL gets the file at the specified URL
-func (c APIClient) GetFileURL(commit *pfs.Commit, path, URL string) (retErr error) {
+func (c APIClient) GetFileURL(commit *pfs.Commit, path, URL string, opts ...GetFileOption) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
@@ -526,6 +836,9 @@ func (c APIClient) GetFileURL(commit *pfs.Commit, path, URL string) (retErr erro
 		File: commit.NewFile(path),
 		URL:  URL,
 	}
+	for _, opt := range opts {
+		opt(req)
+	}
 	client, err := c.PfsAPIClient.GetFileTAR(c.Ctx(), req)
 	if err != nil {
 		return err
@@ -535,30 +848,32 @@ func (c APIClient) GetFileURL(commit *pfs.Commit, path, URL string) (retErr erro
 }
 
 // InspectFile returns metadata about the specified file
-func (c APIClient) InspectFile(commit *pfs.Commit, path string) (_ *pfs.FileInfo, retErr error) {
+func (c APIClient) InspectFile(commit *pfs.Commit, path string, opts ...InspectFileOption) (_ *pfs.FileInfo, retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
-	fi, err := c.PfsAPIClient.InspectFile(
-		c.Ctx(),
-		&pfs.InspectFileRequest{
-			File: commit.NewFile(path),
-		},
-	)
+	req := &pfs.InspectFileRequest{
+		File: commit.NewFile(path),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	fi, err := c.PfsAPIClient.InspectFile(c.Ctx(), req)
 	return fi, err
 }
 
 // ListFile returns info about all files in a Commit under path, calling cb with each FileInfo.
-func (c APIClient) ListFile(commit *pfs.Commit, path string, cb func(fi *pfs.FileInfo) error) (retErr error) {
+func (c APIClient) ListFile(commit *pfs.Commit, path string, cb func(fi *pfs.FileInfo) error, opts ...ListFileOption) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
-	client, err := c.PfsAPIClient.ListFile(
-		c.Ctx(),
-		&pfs.ListFileRequest{
-			File: commit.NewFile(path),
-		},
-	)
+	req := &pfs.ListFileRequest{
+		File: commit.NewFile(path),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	client, err := c.PfsAPIClient.ListFile(c.Ctx(), req)
 	if err != nil {
 		return err
 	}
@@ -580,7 +895,7 @@ func (c APIClient) ListFile(commit *pfs.Commit, path string, cb func(fi *pfs.Fil
 }
 
 // ListFileAll returns info about all files in a Commit under path.
-func (c APIClient) ListFileAll(commit *pfs.Commit, path string) (_ []*pfs.FileInfo, retErr error) {
+func (c APIClient) ListFileAll(commit *pfs.Commit, path string, opts ...ListFileOption) (_ []*pfs.FileInfo, retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
@@ -588,7 +903,7 @@ func (c APIClient) ListFileAll(commit *pfs.Commit, path string) (_ []*pfs.FileIn
 	if err := c.ListFile(commit, path, func(fi *pfs.FileInfo) error {
 		fis = append(fis, fi)
 		return nil
-	}); err != nil {
+	}, opts...); err != nil {
 		return nil, err
 	}
 	return fis, nil
@@ -597,17 +912,18 @@ func (c APIClient) ListFileAll(commit *pfs.Commit, path string) (_ []*pfs.FileIn
 // GlobFile returns files that match a given glob pattern in a given commit,
 // calling cb with each FileInfo. The pattern is documented here:
 // https://golang.org/pkg/path/filepath/#Match
-func (c APIClient) GlobFile(commit *pfs.Commit, pattern string, cb func(fi *pfs.FileInfo) error) (retErr error) {
+func (c APIClient) GlobFile(commit *pfs.Commit, pattern string, cb func(fi *pfs.FileInfo) error, opts ...GlobFileOption) (retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
-	client, err := c.PfsAPIClient.GlobFile(
-		c.Ctx(),
-		&pfs.GlobFileRequest{
-			Commit:  commit,
-			Pattern: pattern,
-		},
-	)
+	req := &pfs.GlobFileRequest{
+		Commit:  commit,
+		Pattern: pattern,
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	client, err := c.PfsAPIClient.GlobFile(c.Ctx(), req)
 	if err != nil {
 		return err
 	}
@@ -630,7 +946,7 @@ func (c APIClient) GlobFile(commit *pfs.Commit, pattern string, cb func(fi *pfs.
 
 // GlobFileAll returns files that match a given glob pattern in a given commit.
 // The pattern is documented here: https://golang.org/pkg/path/filepath/#Match
-func (c APIClient) GlobFileAll(commit *pfs.Commit, pattern string) (_ []*pfs.FileInfo, retErr error) {
+func (c APIClient) GlobFileAll(commit *pfs.Commit, pattern string, opts ...GlobFileOption) (_ []*pfs.FileInfo, retErr error) {
 	defer func() {
 		retErr = grpcutil.ScrubGRPC(retErr)
 	}()
@@ -638,7 +954,7 @@ func (c APIClient) GlobFileAll(commit *pfs.Commit, pattern string) (_ []*pfs.Fil
 	if err := c.GlobFile(commit, pattern, func(fi *pfs.FileInfo) error {
 		fis = append(fis, fi)
 		return nil
-	}); err != nil {
+	}, opts...); err != nil {
 		return nil, err
 	}
 	return fis, nil
@@ -699,6 +1015,126 @@ func (c APIClient) DiffFileAll(newCommit *pfs.Commit, newPath string, oldCommit
 	return newFis, oldFis, nil
 }
 
+// DiffFileEntries returns a DiffEntry for each file that differs between 2
+// paths at 2 commits, detecting files that were renamed by their content.
+func (c APIClient) DiffFileEntries(newCommit *pfs.Commit, newPath string, oldCommit *pfs.Commit, oldPath string) (_ []*pfs.DiffEntry, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	var oldFile *pfs.File
+	if oldCommit != nil {
+		oldFile = oldCommit.NewFile(oldPath)
+	}
+	client, err := c.PfsAPIClient.DiffFile(ctx, &pfs.DiffFileRequest{
+		NewFile:       newCommit.NewFile(newPath),
+		OldFile:       oldFile,
+		DetectRenames: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var entries []*pfs.DiffEntry
+	for {
+		resp, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, err
+		}
+		entries = append(entries, resp.Entry)
+	}
+}
+
+// DiffFileContent writes a unified diff of the content of a file at 2 commits
+// to w, which pachd computes, so neither version of the file is downloaded.
+func (c APIClient) DiffFileContent(newCommit *pfs.Commit, newPath string, oldCommit *pfs.Commit, oldPath string, contextLines int, w io.Writer) error {
+	return c.diffFileContent(newCommit, newPath, oldCommit, oldPath, contextLines, false, func(resp *pfs.DiffFileContentResponse) error {
+		_, err := w.Write(resp.Unified)
+		return errors.EnsureStack(err)
+	})
+}
+
+// DiffFileContentRanges calls cb with the ranges of bytes that differ between
+// the content of a file at 2 commits. Only the data of the new ranges is
+// downloaded.
+func (c APIClient) DiffFileContentRanges(newCommit *pfs.Commit, newPath string, oldCommit *pfs.Commit, oldPath string, cb func(*pfs.ByteRangeDelta) error) error {
+	return c.diffFileContent(newCommit, newPath, oldCommit, oldPath, 0, true, func(resp *pfs.DiffFileContentResponse) error {
+		return cb(resp.Delta)
+	})
+}
+
+func (c APIClient) diffFileContent(newCommit *pfs.Commit, newPath string, oldCommit *pfs.Commit, oldPath string, contextLines int, byteRanges bool, cb func(*pfs.DiffFileContentResponse) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	var oldFile *pfs.File
+	if oldCommit != nil {
+		oldFile = oldCommit.NewFile(oldPath)
+	}
+	client, err := c.PfsAPIClient.DiffFileContent(ctx, &pfs.DiffFileContentRequest{
+		NewFile:      newCommit.NewFile(newPath),
+		OldFile:      oldFile,
+		ContextLines: int64(contextLines),
+		ByteRanges:   byteRanges,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// ChangeFeed calls cb with the files changed by each commit on a branch, as
+// the commits are finished. The last FileChange for each commit has no path
+// and a cursor, which can be passed back to resume the feed after that commit.
+// Every commit on the branch is included if cursor is empty.
+func (c APIClient) ChangeFeed(repoName string, branchName string, cursor string, cb func(*pfs.FileChange) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	return c.changeFeed(&pfs.ChangeFeedRequest{
+		Branch: NewBranch(repoName, branchName),
+		Cursor: cursor,
+	}, cb)
+}
+
+func (c APIClient) changeFeed(req *pfs.ChangeFeedRequest, cb func(*pfs.FileChange) error) error {
+	client, err := c.PfsAPIClient.ChangeFeed(c.Ctx(), req)
+	if err != nil {
+		return err
+	}
+	for {
+		change, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(change); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
 // WalkFile walks the files under path.
 func (c APIClient) WalkFile(commit *pfs.Commit, path string, cb func(*pfs.FileInfo) error) (retErr error) {
 	defer func() {
@@ -728,3 +1164,150 @@ func (c APIClient) WalkFile(commit *pfs.Commit, path string, cb func(*pfs.FileIn
 		}
 	}
 }
+
+// ListFileChunks calls cb with the chunks of the content of the files at or
+// under path, in order of path and then of offset in the file.
+func (c APIClient) ListFileChunks(commit *pfs.Commit, path string, cb func(*pfs.FileChunk) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	client, err := c.PfsAPIClient.ListFileChunks(
+		c.Ctx(),
+		&pfs.ListFileChunksRequest{
+			File: commit.NewFile(path),
+		})
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(chunk); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// FindFilesByHash calls cb with the info of each file whose content has hash,
+// as in FileInfo.Hash, in the finished commits of repo, or of every repo that
+// the caller can read if repo is nil. A file is found once for each commit
+// that it's in.
+func (c APIClient) FindFilesByHash(repo *pfs.Repo, hash []byte, cb func(*pfs.FileInfo) error) error {
+	return c.findFilesByContent(&pfs.FindFilesByContentRequest{Hash: hash, Repo: repo}, cb)
+}
+
+// FindFilesByChunk calls cb with the info of each file whose data is in the
+// chunk chunkID, in the finished commits of repo, or of every repo that the
+// caller can read if repo is nil.
+func (c APIClient) FindFilesByChunk(repo *pfs.Repo, chunkID []byte, cb func(*pfs.FileInfo) error) error {
+	return c.findFilesByContent(&pfs.FindFilesByContentRequest{ChunkId: chunkID, Repo: repo}, cb)
+}
+
+func (c APIClient) findFilesByContent(req *pfs.FindFilesByContentRequest, cb func(*pfs.FileInfo) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	client, err := c.PfsAPIClient.FindFilesByContent(c.Ctx(), req)
+	if err != nil {
+		return err
+	}
+	for {
+		fi, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(fi); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ListFileHistory returns the versions of the file at path, newest first: its
+// info at each commit, going back from commit through its ancestors, where it
+// was created or changed. At most limit versions are returned, or all of them
+// if limit isn't positive.
+func (c APIClient) ListFileHistory(commit *pfs.Commit, path string, limit int64) (_ []*pfs.FileInfo, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	client, err := c.PfsAPIClient.ListFileHistory(
+		c.Ctx(),
+		&pfs.ListFileHistoryRequest{
+			File:  commit.NewFile(path),
+			Limit: limit,
+		})
+	if err != nil {
+		return nil, err
+	}
+	var fileInfos []*pfs.FileInfo
+	for {
+		fi, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fileInfos, nil
+			}
+			return nil, err
+		}
+		fileInfos = append(fileInfos, fi)
+	}
+}
+
+// ListTags returns the tags of the files at or under path, with the number and
+// size of the files written with each of them.
+func (c APIClient) ListTags(commit *pfs.Commit, path string) (_ []*pfs.TagInfo, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	resp, err := c.PfsAPIClient.ListTags(c.Ctx(), &pfs.ListTagsRequest{File: commit.NewFile(path)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+// DirectorySizes calls cb with the aggregate size of the directory at path,
+// and of each of its subdirectories down to depth, like du --max-depth. A
+// negative depth reports every subdirectory.
+func (c APIClient) DirectorySizes(commit *pfs.Commit, path string, depth int64, cb func(*pfs.FileInfo) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	client, err := c.PfsAPIClient.DirectorySizes(
+		c.Ctx(),
+		&pfs.DirectorySizesRequest{
+			File:  commit.NewFile(path),
+			Depth: depth,
+		})
+	if err != nil {
+		return err
+	}
+	for {
+		fi, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(fi); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// pager fetches a listing from pachd a page at a time.
+type pager struct {
+	// fetch fetches the page after the item whose token is given, returning
+	// the number of items on it and the token of its last item.
+	fetch    func(token string) (int, string, error)
+	pageSize int64
+	token    string
+	// left is the number of items on the current page after the current one.
+	left int
+	last bool
+	err  error
+}
+
+// next advances to the next item, fetching the next page if needed. It
+// returns false at the end of the listing, or if there was an error.
+func (p *pager) next() bool {
+	if p.err != nil {
+		return false
+	}
+	if p.left == 0 {
+		if p.last {
+			return false
+		}
+		var n int
+		n, p.token, p.err = p.fetch(p.token)
+		if p.err != nil {
+			return false
+		}
+		p.last = p.pageSize <= 0 || int64(n) < p.pageSize
+		if n == 0 {
+			return false
+		}
+		p.left = n
+	}
+	p.left--
+	return true
+}
+
+// index returns the index of the current item in a page of n items.
+func (p *pager) index(n int) int {
+	return n - p.left - 1
+}
+
+// Err returns the error that ended the listing, if any.
+func (p *pager) Err() error {
+	return grpcutil.ScrubGRPC(p.err)
+}
+
+// FileInfoIter iterates over the files in a directory, fetching them from
+// pachd a page at a time, so that only one page is held in memory. Files can
+// be fetched with 'Next()' and examined with 'FileInfo()', and any errors can
+// be examined with 'Err()'.
+type FileInfoIter struct {
+	pager
+	page []*pfs.FileInfo
+}
+
+// Next advances to the next file, returning false after the last one.
+func (it *FileInfoIter) Next() bool {
+	return it.next()
+}
+
+// FileInfo returns the current file.
+func (it *FileInfoIter) FileInfo() *pfs.FileInfo {
+	return it.page[it.index(len(it.page))]
+}
+
+// ListFilePaged returns an iterator over the files in the directory at path,
+// which fetches pageSize of them at a time.
+func (c APIClient) ListFilePaged(commit *pfs.Commit, path string, pageSize int64, opts ...ListFileOption) *FileInfoIter {
+	it := &FileInfoIter{}
+	it.pageSize = pageSize
+	it.fetch = func(token string) (int, string, error) {
+		it.page = nil
+		if err := c.ListFile(commit, path, func(fi *pfs.FileInfo) error {
+			it.page = append(it.page, fi)
+			return nil
+		}, append(opts, func(req *pfs.ListFileRequest) {
+			req.PageSize = pageSize
+			req.PageToken = token
+		})...); err != nil {
+			return 0, "", err
+		}
+		if len(it.page) == 0 {
+			return 0, token, nil
+		}
+		return len(it.page), it.page[len(it.page)-1].File.Path, nil
+	}
+	return it
+}
+
+// CommitInfoIter iterates over commits like FileInfoIter does over files.
+type CommitInfoIter struct {
+	pager
+	page []*pfs.CommitInfo
+}
+
+// Next advances to the next commit, returning false after the last one.
+func (it *CommitInfoIter) Next() bool {
+	return it.next()
+}
+
+// CommitInfo returns the current commit.
+func (it *CommitInfoIter) CommitInfo() *pfs.CommitInfo {
+	return it.page[it.index(len(it.page))]
+}
+
+// ListCommitPaged returns an iterator over the commits in repo, newest first,
+// which fetches pageSize of them at a time.
+func (c APIClient) ListCommitPaged(repo *pfs.Repo, pageSize int64, opts ...ListCommitOption) *CommitInfoIter {
+	it := &CommitInfoIter{}
+	it.pageSize = pageSize
+	it.fetch = func(token string) (int, string, error) {
+		it.page = nil
+		if err := c.ListCommitF(repo, nil, nil, 0, false, func(ci *pfs.CommitInfo) error {
+			it.page = append(it.page, ci)
+			return nil
+		}, append(opts, func(req *pfs.ListCommitRequest) {
+			req.PageSize = pageSize
+			req.PageToken = token
+		})...); err != nil {
+			return 0, "", err
+		}
+		if len(it.page) == 0 {
+			return 0, token, nil
+		}
+		return len(it.page), it.page[len(it.page)-1].Commit.String(), nil
+	}
+	return it
+}
+
+// BranchInfoIter iterates over branches like FileInfoIter does over files.
+type BranchInfoIter struct {
+	pager
+	page []*pfs.BranchInfo
+}
+
+// Next advances to the next branch, returning false after the last one.
+func (it *BranchInfoIter) Next() bool {
+	return it.next()
+}
+
+// BranchInfo returns the current branch.
+func (it *BranchInfoIter) BranchInfo() *pfs.BranchInfo {
+	return it.page[it.index(len(it.page))]
+}
+
+// ListBranchPaged returns an iterator over the branches in a repo, which
+// fetches pageSize of them at a time.
+func (c APIClient) ListBranchPaged(repoName string, pageSize int64) *BranchInfoIter {
+	it := &BranchInfoIter{}
+	it.pageSize = pageSize
+	it.fetch = func(token string) (int, string, error) {
+		branchInfos, err := c.PfsAPIClient.ListBranch(
+			c.Ctx(),
+			&pfs.ListBranchRequest{
+				Repo:      NewRepo(repoName),
+				PageSize:  pageSize,
+				PageToken: token,
+			},
+		)
+		if err != nil {
+			return 0, "", err
+		}
+		it.page = branchInfos.BranchInfo
+		if len(it.page) == 0 {
+			return 0, token, nil
+		}
+		return len(it.page), it.page[len(it.page)-1].Branch.String(), nil
+	}
+	return it
+}
@@ -0,0 +1,283 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// PushCommit pushes the contents of commit to remoteBranch in the cluster
+// that remote is connected to, creating the remote repo if it does not exist.
+// The remote side is negotiated before any data is sent: the chunks of the
+// files in commit are compared with the chunks of the files at the head of
+// remoteBranch, and only the data in chunks that the remote doesn't have is
+// transferred. The rest is copied on the remote by reference to the chunks it
+// already has. Files that no longer exist in commit are deleted. The new
+// remote commit is returned. If the push fails once the remote commit is
+// started, the remote commit is squashed, so that remoteBranch isn't left
+// with an open commit.
+func (c APIClient) PushCommit(commit *pfs.Commit, remote *APIClient, remoteBranch *pfs.Branch) (_ *pfs.Commit, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	if _, err := remote.PfsAPIClient.CreateRepo(remote.Ctx(), &pfs.CreateRepoRequest{
+		Repo:   remoteBranch.Repo,
+		Update: true,
+	}); err != nil {
+		return nil, err
+	}
+	// Both sides are read at fixed commits, so that they don't change under
+	// the push.
+	commitInfo, err := c.PfsAPIClient.InspectCommit(c.Ctx(), &pfs.InspectCommitRequest{
+		Commit: commit,
+		Wait:   pfs.CommitState_FINISHED,
+	})
+	if err != nil {
+		return nil, err
+	}
+	commit = commitInfo.Commit
+	remoteHead, err := remoteBranchHead(remote, remoteBranch)
+	if err != nil {
+		return nil, err
+	}
+	local, err := listCommitChunks(&c, commit)
+	if err != nil {
+		return nil, err
+	}
+	remoteFiles := &commitChunks{files: make(map[string]*pfs.FileInfo), chunks: make(map[string][]*pfs.FileChunk)}
+	if remoteHead != nil {
+		if remoteFiles, err = listCommitChunks(remote, remoteHead); err != nil {
+			return nil, err
+		}
+	}
+	remoteChunks := make(map[string]*pfs.FileChunk)
+	for _, chunks := range remoteFiles.chunks {
+		for _, chunk := range chunks {
+			if len(chunk.Hash) > 0 {
+				remoteChunks[hex.EncodeToString(chunk.Hash)] = chunk
+			}
+		}
+	}
+	remoteCommit, err := remote.PfsAPIClient.StartCommit(remote.Ctx(), &pfs.StartCommitRequest{
+		Branch:      remoteBranch,
+		Description: "pushed from " + commit.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		if _, err := remote.PfsAPIClient.SquashCommitSet(remote.Ctx(), &pfs.SquashCommitSetRequest{
+			CommitSet: NewCommitSet(remoteCommit.ID),
+		}); err != nil {
+			// The commit is finished instead, so that later pushes to the
+			// branch aren't blocked by it.
+			if _, err := remote.PfsAPIClient.FinishCommit(remote.Ctx(), &pfs.FinishCommitRequest{Commit: remoteCommit}); err != nil {
+				retErr = errors.Wrapf(retErr, "error finishing remote commit %v after failed push: %v", remoteCommit, err)
+			}
+		}
+	}()
+	if err := remote.WithModifyFileClient(remoteCommit, func(mf ModifyFile) error {
+		for path := range remoteFiles.files {
+			if _, ok := local.files[path]; !ok {
+				if err := mf.DeleteFile(path); err != nil {
+					return err
+				}
+			}
+		}
+		for _, path := range local.paths {
+			fi := local.files[path]
+			chunks := local.chunks[path]
+			if remoteFi, ok := remoteFiles.files[path]; ok {
+				same, err := c.sameFile(commit, remote, remoteHead, fi, remoteFi, chunks, remoteFiles.chunks[path])
+				if err != nil {
+					return err
+				}
+				if same {
+					continue
+				}
+			}
+			if err := mf.DeleteFile(path); err != nil {
+				return err
+			}
+			// Runs of chunks that the remote doesn't have are sent in one
+			// request each.
+			var offset, size int64
+			for _, chunk := range chunks {
+				remoteChunk, ok := remoteChunks[hex.EncodeToString(chunk.Hash)]
+				if !ok || len(chunk.Hash) == 0 {
+					if size == 0 {
+						offset = chunk.OffsetBytes
+					}
+					size += chunk.SizeBytes
+					continue
+				}
+				if size > 0 {
+					if err := c.putFileRange(mf, commit, path, offset, size); err != nil {
+						return err
+					}
+					size = 0
+				}
+				if err := mf.CopyFile(path, remoteHead.NewFile(remoteChunk.Path), WithAppendCopyFile(), WithRangeCopyFile(remoteChunk.OffsetBytes, remoteChunk.SizeBytes)); err != nil {
+					return err
+				}
+			}
+			if size > 0 {
+				if err := c.putFileRange(mf, commit, path, offset, size); err != nil {
+					return err
+				}
+			}
+			// The file's mode and metadata are set last, since the most
+			// recently written are the ones that the file ends up with.
+			opts := []PutFileOption{WithAppendPutFile(), WithModePutFile(fi.Mode), WithMetadataPutFile(fi.Metadata)}
+			if fi.Mtime != nil {
+				mtime, err := types.TimestampFromProto(fi.Mtime)
+				if err != nil {
+					return errors.EnsureStack(err)
+				}
+				opts = append(opts, WithModTimePutFile(mtime))
+			}
+			if fi.LinkTarget != "" {
+				opts = append(opts, WithSymlinkPutFile(fi.LinkTarget))
+			}
+			if err := mf.PutFile(path, &bytes.Buffer{}, opts...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := remote.PfsAPIClient.FinishCommit(remote.Ctx(), &pfs.FinishCommitRequest{Commit: remoteCommit}); err != nil {
+		return nil, err
+	}
+	return remoteCommit, nil
+}
+
+// PullCommit pulls the contents of remoteCommit, in the cluster that remote is
+// connected to, into branch. It is the inverse of PushCommit.
+func (c APIClient) PullCommit(remote *APIClient, remoteCommit *pfs.Commit, branch *pfs.Branch) (*pfs.Commit, error) {
+	return remote.PushCommit(remoteCommit, &c, branch)
+}
+
+// remoteBranchHead returns the head of branch, or nil if branch doesn't exist.
+func remoteBranchHead(c *APIClient, branch *pfs.Branch) (*pfs.Commit, error) {
+	branchInfos, err := c.PfsAPIClient.ListBranch(c.Ctx(), &pfs.ListBranchRequest{Repo: branch.Repo})
+	if err != nil {
+		return nil, err
+	}
+	for _, bi := range branchInfos.BranchInfo {
+		if bi.Branch.Name == branch.Name {
+			return bi.Head, nil
+		}
+	}
+	return nil, nil
+}
+
+// commitChunks are the files in a commit, and the chunks of their content.
+type commitChunks struct {
+	paths  []string
+	files  map[string]*pfs.FileInfo
+	chunks map[string][]*pfs.FileChunk
+}
+
+func listCommitChunks(c *APIClient, commit *pfs.Commit) (*commitChunks, error) {
+	cc := &commitChunks{
+		files:  make(map[string]*pfs.FileInfo),
+		chunks: make(map[string][]*pfs.FileChunk),
+	}
+	if err := c.WalkFile(commit, "/", func(fi *pfs.FileInfo) error {
+		if fi.FileType == pfs.FileType_FILE {
+			cc.paths = append(cc.paths, fi.File.Path)
+			cc.files[fi.File.Path] = fi
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := c.ListFileChunks(commit, "/", func(chunk *pfs.FileChunk) error {
+		cc.chunks[chunk.Path] = append(cc.chunks[chunk.Path], chunk)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+// sameFile returns true if a, in commit, and b, in remoteCommit, have the
+// same content, mode, modification time and metadata, so that a doesn't need
+// to be pushed over b. Files with the same chunks have the same content, but
+// the clusters may chunk content differently, so files whose chunks differ
+// are compared by the hashes of their content.
+func (c APIClient) sameFile(commit *pfs.Commit, remote *APIClient, remoteCommit *pfs.Commit, a, b *pfs.FileInfo, aChunks, bChunks []*pfs.FileChunk) (bool, error) {
+	if a.Mode != b.Mode || a.LinkTarget != b.LinkTarget || a.SizeBytes != b.SizeBytes || !a.Mtime.Equal(b.Mtime) || !sameMetadata(a.Metadata, b.Metadata) {
+		return false, nil
+	}
+	if sameChunks(aChunks, bChunks) {
+		return true, nil
+	}
+	aHash, err := c.hashFile(a.File.Path, commit)
+	if err != nil {
+		return false, err
+	}
+	bHash, err := remote.hashFile(b.File.Path, remoteCommit)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aHash, bHash), nil
+}
+
+func sameChunks(a, b []*pfs.FileChunk) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i].Hash) == 0 || !bytes.Equal(a[i].Hash, b[i].Hash) || a[i].SizeBytes != b[i].SizeBytes {
+			return false
+		}
+	}
+	return true
+}
+
+func sameMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// hashFile returns the SHA-256 hash of the content of the file at path in
+// commit.
+func (c APIClient) hashFile(path string, commit *pfs.Commit) ([]byte, error) {
+	h := sha256.New()
+	if err := c.GetFile(commit, path, h); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// putFileRange appends size bytes of the file at path in commit, starting at
+// offset, to the file at the same path in mf.
+func (c APIClient) putFileRange(mf ModifyFile, commit *pfs.Commit, path string, offset, size int64) error {
+	pr, pw := io.Pipe()
+	// Closing the reader stops the download if the upload fails.
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(c.GetFileRange(commit, path, offset, size, pw))
+	}()
+	return mf.PutFile(path, pr, WithAppendPutFile())
+}
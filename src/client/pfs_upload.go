@@ -0,0 +1,258 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/renew"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultUploadPartSize is the size of the parts that WithParallelPutFile
+// uploads by default.
+const DefaultUploadPartSize = 64 * 1024 * 1024
+
+// putFileParallel uploads the content of r to path in parts, each to a file
+// set of its own, and then appends the file sets to the file in order.
+func (mfc *modifyFileCore) putFileParallel(path string, config *putFileConfig, r io.Reader) error {
+	partSize := config.partSize
+	if partSize <= 0 {
+		partSize = DefaultUploadPartSize
+	}
+	part, err := readPart(r, partSize)
+	if err != nil {
+		return err
+	}
+	if len(part) < partSize {
+		return mfc.sendPutFileReader(path, config, bytes.NewReader(part))
+	}
+	if mfc.renewer == nil {
+		pachClient := mfc.pachClient
+		mfc.renewer = renew.NewStringSet(pachClient.Ctx(), DefaultTTL, func(ctx context.Context, id string, ttl time.Duration) error {
+			return pachClient.WithCtx(ctx).RenewFileSet(id, ttl)
+		})
+	}
+	eg, ctx := errgroup.WithContext(mfc.pachClient.Ctx())
+	limit := make(chan struct{}, config.parallelism)
+	var ids []*string
+	err = func() error {
+		for len(part) > 0 {
+			select {
+			case limit <- struct{}{}:
+			case <-ctx.Done():
+				return nil
+			}
+			id, data := new(string), part
+			ids = append(ids, id)
+			eg.Go(func() error {
+				defer func() { <-limit }()
+				var err error
+				*id, err = mfc.uploadPart(ctx, path, config, data)
+				return err
+			})
+			if len(part) < partSize {
+				return nil
+			}
+			if part, err = readPart(r, partSize); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := mfc.client.Send(&pfs.ModifyFileRequest{
+			Body: &pfs.ModifyFileRequest_AddFileSet{AddFileSet: *id},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadPart uploads data to path in a new file set, and returns its ID. The
+// upload is retried with backoff if it fails with a transient error.
+func (mfc *modifyFileCore) uploadPart(ctx context.Context, path string, config *putFileConfig, data []byte) (string, error) {
+	c := mfc.pachClient.WithCtx(ctx)
+	var id string
+	if err := c.retryStream(backoff.NewExponentialBackOff(), func(func()) error {
+		client, err := c.PfsAPIClient.CreateFileSet(c.Ctx())
+		if err != nil {
+			return err
+		}
+		core := &modifyFileCore{client: client}
+		// A stream that fails returns io.EOF from Send, and the error from
+		// CloseAndRecv.
+		if err := core.sendPutFileReader(path, config, bytes.NewReader(data)); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		resp, err := client.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		id = resp.FileSetId
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	mfc.renewer.Add(id)
+	return id, nil
+}
+
+// readPart reads up to partSize bytes from r, fewer only if r runs out.
+func readPart(r io.Reader, partSize int) ([]byte, error) {
+	part := make([]byte, partSize)
+	n, err := io.ReadFull(r, part)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, errors.EnsureStack(err)
+	}
+	return part[:n], nil
+}
+
+// closeRenewer stops renewing the file sets of parallel uploads, once pachd
+// has put them back together. If renewing them failed, pachd fails to find
+// them, so the renewer's error isn't returned.
+func (mfc *modifyFileCore) closeRenewer() {
+	if mfc.renewer != nil {
+		mfc.renewer.Close()
+		mfc.renewer = nil
+	}
+}
+
+// Upload is a resumable upload of files into a commit. Each part of it is
+// uploaded to a file set of its own, and the parts are appended to the commit
+// in order of their numbers when it's closed. An upload that's interrupted
+// can be resumed by starting it again with the same ID, from the same or
+// another client, and putting the parts that it's missing.
+type Upload struct {
+	c    APIClient
+	info *pfs.UploadInfo
+}
+
+// StartUpload starts the upload id into commit, which must be open, or
+// resumes it if it has already been started. id is chosen by the caller, and
+// must be unique among the uploads that haven't been closed or aborted.
+func (c APIClient) StartUpload(commit *pfs.Commit, id string) (_ *Upload, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	info, err := c.PfsAPIClient.StartUpload(c.Ctx(), &pfs.StartUploadRequest{
+		UploadId: id,
+		Commit:   commit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Upload{c: c, info: info}, nil
+}
+
+// ID returns the upload's ID.
+func (u *Upload) ID() string {
+	return u.info.UploadId
+}
+
+// HasPart returns true if part number has been added to the upload, as of
+// when it was started or a part was last put by u.
+func (u *Upload) HasPart(number int64) bool {
+	for _, part := range u.info.Parts {
+		if part.Number == number {
+			return true
+		}
+	}
+	return false
+}
+
+// PutPart uploads the files that cb writes to a new file set, and adds it to
+// the upload as part number, replacing the part with that number if there is
+// one. The part is retried with backoff if it fails with a transient error,
+// so cb may be called more than once, and must write the same files each
+// time.
+func (u *Upload) PutPart(number int64, cb func(ModifyFile) error) error {
+	var id string
+	if err := u.c.retryStream(backoff.NewExponentialBackOff(), func(func()) error {
+		ctx, cancel := context.WithCancel(u.c.Ctx())
+		defer cancel()
+		client, err := u.c.PfsAPIClient.CreateFileSet(ctx)
+		if err != nil {
+			return err
+		}
+		// As in uploadPart, a stream that fails returns io.EOF to cb, and the
+		// error from CloseAndRecv.
+		core := &modifyFileCore{client: client, pachClient: u.c}
+		defer core.closeRenewer()
+		if err := cb(core); err != nil && !errors.Is(err, io.EOF) {
+			return callbackError{err}
+		}
+		resp, err := client.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		id = resp.FileSetId
+		return nil
+	}); err != nil {
+		return err
+	}
+	if _, err := u.c.PfsAPIClient.AddUploadPart(u.c.Ctx(), &pfs.AddUploadPartRequest{
+		UploadId:  u.info.UploadId,
+		Number:    number,
+		FileSetId: id,
+	}); err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	info, err := u.c.InspectUpload(u.info.UploadId)
+	if err != nil {
+		return err
+	}
+	u.info = info
+	return nil
+}
+
+// Close appends the parts of the upload to its commit, in order of their
+// numbers, and ends the upload.
+func (u *Upload) Close() error {
+	return u.c.FinishUpload(u.info.UploadId)
+}
+
+// Abort ends the upload without adding its parts to its commit.
+func (u *Upload) Abort() error {
+	return u.c.AbortUpload(u.info.UploadId)
+}
+
+// InspectUpload returns the upload id and the parts that have been added to
+// it.
+func (c APIClient) InspectUpload(id string) (_ *pfs.UploadInfo, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	return c.PfsAPIClient.InspectUpload(c.Ctx(), &pfs.InspectUploadRequest{UploadId: id})
+}
+
+// FinishUpload appends the parts of the upload id to its commit, in order of
+// their numbers, and ends the upload.
+func (c APIClient) FinishUpload(id string) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	_, err := c.PfsAPIClient.FinishUpload(c.Ctx(), &pfs.FinishUploadRequest{UploadId: id})
+	return err
+}
+
+// AbortUpload ends the upload id without adding its parts to its commit.
+func (c APIClient) AbortUpload(id string) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	_, err := c.PfsAPIClient.AbortUpload(c.Ctx(), &pfs.AbortUploadRequest{UploadId: id})
+	return err
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// callbackError marks an error returned by a subscriber's callback, which
+// is never retried.
+type callbackError struct {
+	error
+}
+
+func (e callbackError) Unwrap() error {
+	return e.error
+}
+
+// isTransientStreamErr returns true if a stream failed in a way that a new
+// stream may not, e.g. because pachd restarted or the connection was dropped.
+func isTransientStreamErr(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// resubscribe runs subscribe until it returns nil or a fatal error, retrying
+// with backoff when it fails with a transient error. Callback errors are
+// returned unwrapped. The backoff is reset whenever progress is made, which
+// subscribe reports by calling its argument.
+func (c APIClient) resubscribe(subscribe func(progress func()) error) error {
+	return c.retryStream(backoff.NewInfiniteBackOff(), subscribe)
+}
+
+// retryStream is like resubscribe, but gives up once b does.
+func (c APIClient) retryStream(b backoff.BackOff, subscribe func(progress func()) error) error {
+	err := backoff.RetryUntilCancel(c.Ctx(), func() error {
+		return subscribe(b.Reset)
+	}, b, func(err error, _ time.Duration) error {
+		if errors.As(err, &callbackError{}) || !isTransientStreamErr(err) {
+			return err
+		}
+		return nil
+	})
+	var cbErr callbackError
+	if errors.As(err, &cbErr) {
+		return cbErr.error
+	}
+	return grpcutil.ScrubGRPC(err)
+}
+
+// ResubscribeCommit is like SubscribeCommit, but transparently reconnects
+// with backoff when the stream fails with a transient error, resuming after
+// the last commit passed to cb. Only fatal errors, and errors returned by cb,
+// are returned.
+func (c APIClient) ResubscribeCommit(repo *pfs.Repo, branchName string, from string, state pfs.CommitState, cb func(*pfs.CommitInfo) error, opts ...SubscribeCommitOption) error {
+	req := &pfs.SubscribeCommitRequest{
+		Repo:   repo,
+		Branch: branchName,
+		State:  state,
+	}
+	if from != "" {
+		req.From = repo.NewCommit(branchName, from)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return c.resubscribe(func(progress func()) error {
+		return c.subscribeCommit(req, func(ci *pfs.CommitInfo) error {
+			if err := cb(ci); err != nil {
+				return callbackError{err}
+			}
+			req.Cursor = ci.Cursor
+			req.From = nil
+			progress()
+			return nil
+		})
+	})
+}
+
+// ResubscribeChangeFeed is like ChangeFeed, but transparently reconnects with
+// backoff when the stream fails with a transient error, resuming after the
+// last commit whose changes were all passed to cb. Changes from a commit that
+// was only partly delivered when the stream failed are delivered again. Only
+// fatal errors, and errors returned by cb, are returned.
+func (c APIClient) ResubscribeChangeFeed(repoName string, branchName string, cursor string, cb func(*pfs.FileChange) error) error {
+	req := &pfs.ChangeFeedRequest{
+		Branch: NewBranch(repoName, branchName),
+		Cursor: cursor,
+	}
+	return c.resubscribe(func(progress func()) error {
+		return c.changeFeed(req, func(change *pfs.FileChange) error {
+			if err := cb(change); err != nil {
+				return callbackError{err}
+			}
+			if change.Cursor != "" {
+				req.Cursor = change.Cursor
+			}
+			progress()
+			return nil
+		})
+	})
+}
@@ -2,11 +2,14 @@ package client
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/pachyderm/pachyderm/v2/src/admin"
 	"github.com/pachyderm/pachyderm/v2/src/auth"
 	"github.com/pachyderm/pachyderm/v2/src/debug"
 	"github.com/pachyderm/pachyderm/v2/src/enterprise"
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	"github.com/pachyderm/pachyderm/v2/src/pps"
@@ -16,7 +19,9 @@ import (
 	types "github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const transactionMetadataKey = "pach-transaction"
@@ -283,6 +288,56 @@ func (c APIClient) RunBatchInTransaction(cb func(builder *TransactionBuilder) er
 	return c.BatchTransaction(c.Ctx(), &transaction.BatchTransactionRequest{Requests: tb.requests})
 }
 
+// transactionConflictMessages are the messages of the errors pachd returns
+// when a transaction fails because of a concurrent transaction.
+var transactionConflictMessages = []string{
+	"transaction conflict",
+	"could not serialize access",
+	"deadlock detected",
+	"due to concurrent modifications",
+}
+
+// isTransactionConflictErr returns true if err means that a transaction
+// failed because it raced with a concurrent transaction, so it may succeed if
+// it's rerun.
+func isTransactionConflictErr(err error) bool {
+	if status.Code(err) == codes.Aborted {
+		return true
+	}
+	msg := err.Error()
+	for _, conflict := range transactionConflictMessages {
+		if strings.Contains(msg, conflict) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTransactionRetry is like RunBatchInTransaction, but if the transaction
+// fails because it conflicts with a concurrent transaction (e.g. one that
+// moved the same branch), it calls cb again to rebuild the transaction and
+// reruns it, with backoff. cb may be called several times, so it should
+// re-read any state the transaction depends on rather than capture it.
+func (c APIClient) WithTransactionRetry(cb func(builder *TransactionBuilder) error) (*transaction.TransactionInfo, error) {
+	var info *transaction.TransactionInfo
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = time.Minute
+	err := backoff.RetryUntilCancel(c.Ctx(), func() error {
+		var err error
+		info, err = c.RunBatchInTransaction(cb)
+		return err
+	}, b, func(err error, d time.Duration) error {
+		if !isTransactionConflictErr(err) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return info, nil
+}
+
 func (c *pfsBuilderClient) CreateRepo(ctx context.Context, req *pfs.CreateRepoRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	c.tb.requests = append(c.tb.requests, &transaction.TransactionRequest{CreateRepo: req})
 	return nil, nil
@@ -291,6 +346,9 @@ func (c *pfsBuilderClient) DeleteRepo(ctx context.Context, req *pfs.DeleteRepoRe
 	c.tb.requests = append(c.tb.requests, &transaction.TransactionRequest{DeleteRepo: req})
 	return nil, nil
 }
+func (c *pfsBuilderClient) DeleteRepos(ctx context.Context, req *pfs.DeleteReposRequest, opts ...grpc.CallOption) (*pfs.DeleteReposResponse, error) {
+	return nil, unsupportedError("DeleteRepos")
+}
 func (c *pfsBuilderClient) StartCommit(ctx context.Context, req *pfs.StartCommitRequest, opts ...grpc.CallOption) (*pfs.Commit, error) {
 	// Note that since we are batching requests (no extra round-trips), we do not
 	// have the commit id to return here. If you need an operation that relies
@@ -314,6 +372,10 @@ func (c *pfsBuilderClient) DeleteBranch(ctx context.Context, req *pfs.DeleteBran
 	c.tb.requests = append(c.tb.requests, &transaction.TransactionRequest{DeleteBranch: req})
 	return nil, nil
 }
+func (c *pfsBuilderClient) PromoteBranch(ctx context.Context, req *pfs.PromoteBranchRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	c.tb.requests = append(c.tb.requests, &transaction.TransactionRequest{PromoteBranch: req})
+	return nil, nil
+}
 func (c *ppsBuilderClient) StopJob(ctx context.Context, req *pps.StopJobRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	c.tb.requests = append(c.tb.requests, &transaction.TransactionRequest{StopJob: req})
 	return nil, nil
@@ -332,9 +394,45 @@ func unsupportedError(name string) error {
 	return errors.Errorf("the '%s' API call is not supported in transactions", name)
 }
 
+func (c *pfsBuilderClient) WatchBranch(ctx context.Context, req *pfs.WatchBranchRequest, opts ...grpc.CallOption) (pfs.API_WatchBranchClient, error) {
+	return nil, unsupportedError("WatchBranch")
+}
+func (c *pfsBuilderClient) MergeBranch(ctx context.Context, req *pfs.MergeBranchRequest, opts ...grpc.CallOption) (*pfs.MergeBranchResponse, error) {
+	return nil, unsupportedError("MergeBranch")
+}
+func (c *pfsBuilderClient) InspectTrigger(ctx context.Context, req *pfs.InspectTriggerRequest, opts ...grpc.CallOption) (*pfs.TriggerInfo, error) {
+	return nil, unsupportedError("InspectTrigger")
+}
+func (c *pfsBuilderClient) RunTrigger(ctx context.Context, req *pfs.RunTriggerRequest, opts ...grpc.CallOption) (*pfs.RunTriggerResponse, error) {
+	return nil, unsupportedError("RunTrigger")
+}
+func (c *pfsBuilderClient) ChangeFeed(ctx context.Context, req *pfs.ChangeFeedRequest, opts ...grpc.CallOption) (pfs.API_ChangeFeedClient, error) {
+	return nil, unsupportedError("ChangeFeed")
+}
+func (c *pfsBuilderClient) Watch(ctx context.Context, req *pfs.WatchRequest, opts ...grpc.CallOption) (pfs.API_WatchClient, error) {
+	return nil, unsupportedError("Watch")
+}
+func (c *pfsBuilderClient) ReservePath(ctx context.Context, req *pfs.ReservePathRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("ReservePath")
+}
+func (c *pfsBuilderClient) ReleasePath(ctx context.Context, req *pfs.ReleasePathRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("ReleasePath")
+}
 func (c *pfsBuilderClient) ActivateAuth(ctx context.Context, req *pfs.ActivateAuthRequest, opts ...grpc.CallOption) (*pfs.ActivateAuthResponse, error) {
 	return nil, unsupportedError("ActivateAuth")
 }
+func (c *pfsBuilderClient) CreateProject(ctx context.Context, req *pfs.CreateProjectRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("CreateProject")
+}
+func (c *pfsBuilderClient) InspectProject(ctx context.Context, req *pfs.InspectProjectRequest, opts ...grpc.CallOption) (*pfs.ProjectInfo, error) {
+	return nil, unsupportedError("InspectProject")
+}
+func (c *pfsBuilderClient) ListProject(ctx context.Context, req *pfs.ListProjectRequest, opts ...grpc.CallOption) (*pfs.ListProjectResponse, error) {
+	return nil, unsupportedError("ListProject")
+}
+func (c *pfsBuilderClient) DeleteProject(ctx context.Context, req *pfs.DeleteProjectRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("DeleteProject")
+}
 func (c *pfsBuilderClient) InspectRepo(ctx context.Context, req *pfs.InspectRepoRequest, opts ...grpc.CallOption) (*pfs.RepoInfo, error) {
 	return nil, unsupportedError("InspectRepo")
 }
@@ -350,12 +448,24 @@ func (c *pfsBuilderClient) ListCommit(ctx context.Context, req *pfs.ListCommitRe
 func (c *pfsBuilderClient) InspectCommitSet(ctx context.Context, req *pfs.InspectCommitSetRequest, opts ...grpc.CallOption) (pfs.API_InspectCommitSetClient, error) {
 	return nil, unsupportedError("InspectCommitSet")
 }
+func (c *pfsBuilderClient) CommitLineage(ctx context.Context, req *pfs.CommitLineageRequest, opts ...grpc.CallOption) (*pfs.CommitLineageResponse, error) {
+	return nil, unsupportedError("CommitLineage")
+}
 func (c *pfsBuilderClient) SubscribeCommit(ctx context.Context, req *pfs.SubscribeCommitRequest, opts ...grpc.CallOption) (pfs.API_SubscribeCommitClient, error) {
 	return nil, unsupportedError("SubscribeCommit")
 }
 func (c *pfsBuilderClient) ClearCommit(ctx context.Context, req *pfs.ClearCommitRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("ClearCommit")
 }
+func (c *pfsBuilderClient) CherryPickCommit(ctx context.Context, req *pfs.CherryPickCommitRequest, opts ...grpc.CallOption) (*pfs.Commit, error) {
+	return nil, unsupportedError("CherryPickCommit")
+}
+func (c *pfsBuilderClient) RecallCommit(ctx context.Context, req *pfs.RecallCommitRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("RecallCommit")
+}
+func (c *pfsBuilderClient) ArchiveCommit(ctx context.Context, req *pfs.ArchiveCommitRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("ArchiveCommit")
+}
 func (c *pfsBuilderClient) InspectBranch(ctx context.Context, req *pfs.InspectBranchRequest, opts ...grpc.CallOption) (*pfs.BranchInfo, error) {
 	return nil, unsupportedError("InspectBranch")
 }
@@ -365,6 +475,9 @@ func (c *pfsBuilderClient) ListBranch(ctx context.Context, req *pfs.ListBranchRe
 func (c *pfsBuilderClient) ModifyFile(ctx context.Context, opts ...grpc.CallOption) (pfs.API_ModifyFileClient, error) {
 	return nil, unsupportedError("ModifyFile")
 }
+func (c *pfsBuilderClient) CopyFile(ctx context.Context, req *pfs.CopyFileRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("CopyFile")
+}
 func (c *pfsBuilderClient) GetFileTAR(ctx context.Context, req *pfs.GetFileRequest, opts ...grpc.CallOption) (pfs.API_GetFileTARClient, error) {
 	return nil, unsupportedError("GetFileTAR")
 }
@@ -377,26 +490,87 @@ func (c *pfsBuilderClient) ListFile(ctx context.Context, req *pfs.ListFileReques
 func (c *pfsBuilderClient) WalkFile(ctx context.Context, req *pfs.WalkFileRequest, opts ...grpc.CallOption) (pfs.API_WalkFileClient, error) {
 	return nil, unsupportedError("WalkFile")
 }
+func (c *pfsBuilderClient) ListFileHistory(ctx context.Context, req *pfs.ListFileHistoryRequest, opts ...grpc.CallOption) (pfs.API_ListFileHistoryClient, error) {
+	return nil, unsupportedError("ListFileHistory")
+}
+func (c *pfsBuilderClient) DirectorySizes(ctx context.Context, req *pfs.DirectorySizesRequest, opts ...grpc.CallOption) (pfs.API_DirectorySizesClient, error) {
+	return nil, unsupportedError("DirectorySizes")
+}
+func (c *pfsBuilderClient) ListTags(ctx context.Context, req *pfs.ListTagsRequest, opts ...grpc.CallOption) (*pfs.ListTagsResponse, error) {
+	return nil, unsupportedError("ListTags")
+}
 func (c *pfsBuilderClient) GlobFile(ctx context.Context, req *pfs.GlobFileRequest, opts ...grpc.CallOption) (pfs.API_GlobFileClient, error) {
 	return nil, unsupportedError("GlobFile")
 }
 func (c *pfsBuilderClient) DiffFile(ctx context.Context, req *pfs.DiffFileRequest, opts ...grpc.CallOption) (pfs.API_DiffFileClient, error) {
 	return nil, unsupportedError("DiffFile")
 }
+func (c *pfsBuilderClient) DiffFileContent(ctx context.Context, req *pfs.DiffFileContentRequest, opts ...grpc.CallOption) (pfs.API_DiffFileContentClient, error) {
+	return nil, unsupportedError("DiffFileContent")
+}
+func (c *pfsBuilderClient) ListFileChunks(ctx context.Context, req *pfs.ListFileChunksRequest, opts ...grpc.CallOption) (pfs.API_ListFileChunksClient, error) {
+	return nil, unsupportedError("ListFileChunks")
+}
 func (c *pfsBuilderClient) DeleteAll(ctx context.Context, req *types.Empty, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("DeleteAll")
 }
 func (c *pfsBuilderClient) Fsck(ctx context.Context, req *pfs.FsckRequest, opts ...grpc.CallOption) (pfs.API_FsckClient, error) {
 	return nil, unsupportedError("Fsck")
 }
+func (c *pfsBuilderClient) GarbageCollect(ctx context.Context, req *pfs.GarbageCollectRequest, opts ...grpc.CallOption) (*pfs.GarbageCollectResponse, error) {
+	return nil, unsupportedError("GarbageCollect")
+}
+func (c *pfsBuilderClient) InspectGarbageCollection(ctx context.Context, req *pfs.InspectGarbageCollectionRequest, opts ...grpc.CallOption) (*pfs.GarbageCollectionStats, error) {
+	return nil, unsupportedError("InspectGarbageCollection")
+}
+func (c *pfsBuilderClient) BreakRepoLock(ctx context.Context, req *pfs.BreakRepoLockRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("BreakRepoLock")
+}
+func (c *pfsBuilderClient) StorageUsage(ctx context.Context, req *pfs.StorageUsageRequest, opts ...grpc.CallOption) (*pfs.StorageUsageResponse, error) {
+	return nil, unsupportedError("StorageUsage")
+}
+func (c *pfsBuilderClient) DedupReport(ctx context.Context, req *pfs.DedupReportRequest, opts ...grpc.CallOption) (*pfs.CommitDedupReport, error) {
+	return nil, unsupportedError("DedupReport")
+}
+func (c *pfsBuilderClient) FindFilesByContent(ctx context.Context, req *pfs.FindFilesByContentRequest, opts ...grpc.CallOption) (pfs.API_FindFilesByContentClient, error) {
+	return nil, unsupportedError("FindFilesByContent")
+}
 func (c *pfsBuilderClient) CreateFileSet(ctx context.Context, opts ...grpc.CallOption) (pfs.API_CreateFileSetClient, error) {
 	return nil, unsupportedError("CreateFileSet")
 }
 func (c *pfsBuilderClient) RenewFileSet(ctx context.Context, req *pfs.RenewFileSetRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("RenewFileSet")
 }
+func (c *pfsBuilderClient) ComposeFileSet(ctx context.Context, req *pfs.ComposeFileSetRequest, opts ...grpc.CallOption) (*pfs.CreateFileSetResponse, error) {
+	return nil, unsupportedError("ComposeFileSet")
+}
+func (c *pfsBuilderClient) FilterFileSet(ctx context.Context, req *pfs.FilterFileSetRequest, opts ...grpc.CallOption) (*pfs.CreateFileSetResponse, error) {
+	return nil, unsupportedError("FilterFileSet")
+}
+func (c *pfsBuilderClient) SubtractFileSet(ctx context.Context, req *pfs.SubtractFileSetRequest, opts ...grpc.CallOption) (*pfs.CreateFileSetResponse, error) {
+	return nil, unsupportedError("SubtractFileSet")
+}
+func (c *pfsBuilderClient) InspectFileSet(ctx context.Context, req *pfs.InspectFileSetRequest, opts ...grpc.CallOption) (*pfs.FileSetInfo, error) {
+	return nil, unsupportedError("InspectFileSet")
+}
+func (c *pfsBuilderClient) StartUpload(ctx context.Context, req *pfs.StartUploadRequest, opts ...grpc.CallOption) (*pfs.UploadInfo, error) {
+	return nil, unsupportedError("StartUpload")
+}
+func (c *pfsBuilderClient) AddUploadPart(ctx context.Context, req *pfs.AddUploadPartRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("AddUploadPart")
+}
+func (c *pfsBuilderClient) InspectUpload(ctx context.Context, req *pfs.InspectUploadRequest, opts ...grpc.CallOption) (*pfs.UploadInfo, error) {
+	return nil, unsupportedError("InspectUpload")
+}
+func (c *pfsBuilderClient) FinishUpload(ctx context.Context, req *pfs.FinishUploadRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("FinishUpload")
+}
+func (c *pfsBuilderClient) AbortUpload(ctx context.Context, req *pfs.AbortUploadRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	return nil, unsupportedError("AbortUpload")
+}
 func (c *pfsBuilderClient) AddFileSet(ctx context.Context, req *pfs.AddFileSetRequest, opts ...grpc.CallOption) (*types.Empty, error) {
-	return nil, unsupportedError("AddFileSet")
+	c.tb.requests = append(c.tb.requests, &transaction.TransactionRequest{AddFileSet: req})
+	return nil, nil
 }
 func (c *pfsBuilderClient) GetFileSet(ctx context.Context, req *pfs.GetFileSetRequest, opts ...grpc.CallOption) (*pfs.CreateFileSetResponse, error) {
 	return nil, unsupportedError("GetFileSet")
@@ -566,6 +740,10 @@ func (c *adminBuilderClient) InspectCluster(ctx context.Context, req *types.Empt
 	return nil, unsupportedError("InspectCluster")
 }
 
+func (c *adminBuilderClient) InspectStorage(ctx context.Context, req *types.Empty, opts ...grpc.CallOption) (*admin.StorageInfo, error) {
+	return nil, unsupportedError("InspectStorage")
+}
+
 func (c *transactionBuilderClient) BatchTransaction(ctx context.Context, req *transaction.BatchTransactionRequest, opts ...grpc.CallOption) (*transaction.TransactionInfo, error) {
 	return nil, unsupportedError("BatchTransaction")
 }
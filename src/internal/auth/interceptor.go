@@ -23,6 +23,7 @@ var authHandlers = map[string]authHandler{
 
 	// Allow InspectCluster to succeed before a user logs in
 	"/admin_v2.API/InspectCluster": unauthenticated,
+	"/admin_v2.API/InspectStorage": authDisabledOr(clusterPermissions(auth.Permission_CLUSTER_INSPECT_STORAGE)),
 
 	//
 	// Auth API
@@ -120,37 +121,76 @@ var authHandlers = map[string]authHandler{
 	//
 
 	// TODO: Add methods to handle repo permissions
-	"/pfs_v2.API/ActivateAuth":     clusterPermissions(auth.Permission_CLUSTER_AUTH_ACTIVATE),
-	"/pfs_v2.API/CreateRepo":       authDisabledOr(authenticated),
-	"/pfs_v2.API/InspectRepo":      authDisabledOr(authenticated),
-	"/pfs_v2.API/ListRepo":         authDisabledOr(authenticated),
-	"/pfs_v2.API/DeleteRepo":       authDisabledOr(authenticated),
-	"/pfs_v2.API/StartCommit":      authDisabledOr(authenticated),
-	"/pfs_v2.API/FinishCommit":     authDisabledOr(authenticated),
-	"/pfs_v2.API/InspectCommit":    authDisabledOr(authenticated),
-	"/pfs_v2.API/ListCommit":       authDisabledOr(authenticated),
-	"/pfs_v2.API/SubscribeCommit":  authDisabledOr(authenticated),
-	"/pfs_v2.API/ClearCommit":      authDisabledOr(authenticated),
-	"/pfs_v2.API/InspectCommitSet": authDisabledOr(authenticated),
-	"/pfs_v2.API/SquashCommitSet":  authDisabledOr(authenticated),
-	"/pfs_v2.API/CreateBranch":     authDisabledOr(authenticated),
-	"/pfs_v2.API/InspectBranch":    authDisabledOr(authenticated),
-	"/pfs_v2.API/ListBranch":       authDisabledOr(authenticated),
-	"/pfs_v2.API/DeleteBranch":     authDisabledOr(authenticated),
-	"/pfs_v2.API/ModifyFile":       authDisabledOr(authenticated),
-	"/pfs_v2.API/GetFileTAR":       authDisabledOr(authenticated),
-	"/pfs_v2.API/InspectFile":      authDisabledOr(authenticated),
-	"/pfs_v2.API/ListFile":         authDisabledOr(authenticated),
-	"/pfs_v2.API/WalkFile":         authDisabledOr(authenticated),
-	"/pfs_v2.API/GlobFile":         authDisabledOr(authenticated),
-	"/pfs_v2.API/DiffFile":         authDisabledOr(authenticated),
-	"/pfs_v2.API/DeleteAll":        authDisabledOr(authenticated),
-	"/pfs_v2.API/Fsck":             authDisabledOr(authenticated),
-	"/pfs_v2.API/CreateFileSet":    authDisabledOr(authenticated),
-	"/pfs_v2.API/GetFileSet":       authDisabledOr(authenticated),
-	"/pfs_v2.API/AddFileSet":       authDisabledOr(authenticated),
-	"/pfs_v2.API/RenewFileSet":     authDisabledOr(authenticated),
-	"/pfs_v2.API/RunLoadTest":      authDisabledOr(authenticated),
+	"/pfs_v2.API/ActivateAuth":             clusterPermissions(auth.Permission_CLUSTER_AUTH_ACTIVATE),
+	"/pfs_v2.API/CreateRepo":               authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectRepo":              authDisabledOr(authenticated),
+	"/pfs_v2.API/ListRepo":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/DeleteRepo":               authDisabledOr(authenticated),
+	"/pfs_v2.API/DeleteRepos":              authDisabledOr(authenticated),
+	"/pfs_v2.API/BreakRepoLock":            authDisabledOr(clusterPermissions(auth.Permission_CLUSTER_BREAK_REPO_LOCK)),
+	"/pfs_v2.API/StartCommit":              authDisabledOr(authenticated),
+	"/pfs_v2.API/FinishCommit":             authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectCommit":            authDisabledOr(authenticated),
+	"/pfs_v2.API/ListCommit":               authDisabledOr(authenticated),
+	"/pfs_v2.API/SubscribeCommit":          authDisabledOr(authenticated),
+	"/pfs_v2.API/ClearCommit":              authDisabledOr(authenticated),
+	"/pfs_v2.API/ArchiveCommit":            authDisabledOr(authenticated),
+	"/pfs_v2.API/RecallCommit":             authDisabledOr(authenticated),
+	"/pfs_v2.API/CherryPickCommit":         authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectCommitSet":         authDisabledOr(authenticated),
+	"/pfs_v2.API/CommitLineage":            authDisabledOr(authenticated),
+	"/pfs_v2.API/SquashCommitSet":          authDisabledOr(authenticated),
+	"/pfs_v2.API/CreateBranch":             authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectBranch":            authDisabledOr(authenticated),
+	"/pfs_v2.API/ListBranch":               authDisabledOr(authenticated),
+	"/pfs_v2.API/DeleteBranch":             authDisabledOr(authenticated),
+	"/pfs_v2.API/WatchBranch":              authDisabledOr(authenticated),
+	"/pfs_v2.API/MergeBranch":              authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectTrigger":           authDisabledOr(authenticated),
+	"/pfs_v2.API/RunTrigger":               authDisabledOr(authenticated),
+	"/pfs_v2.API/PromoteBranch":            authDisabledOr(authenticated),
+	"/pfs_v2.API/ModifyFile":               authDisabledOr(authenticated),
+	"/pfs_v2.API/CopyFile":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/GetFileTAR":               authDisabledOr(authenticated),
+	"/pfs_v2.API/ListTags":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectFile":              authDisabledOr(authenticated),
+	"/pfs_v2.API/ListFile":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/WalkFile":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/ListFileHistory":          authDisabledOr(authenticated),
+	"/pfs_v2.API/DirectorySizes":           authDisabledOr(authenticated),
+	"/pfs_v2.API/ReservePath":              authDisabledOr(authenticated),
+	"/pfs_v2.API/ReleasePath":              authDisabledOr(authenticated),
+	"/pfs_v2.API/GlobFile":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/DiffFile":                 authDisabledOr(authenticated),
+	"/pfs_v2.API/DiffFileContent":          authDisabledOr(authenticated),
+	"/pfs_v2.API/ListFileChunks":           authDisabledOr(authenticated),
+	"/pfs_v2.API/ChangeFeed":               authDisabledOr(authenticated),
+	"/pfs_v2.API/Watch":                    authDisabledOr(authenticated),
+	"/pfs_v2.API/DeleteAll":                authDisabledOr(authenticated),
+	"/pfs_v2.API/Fsck":                     authDisabledOr(authenticated),
+	"/pfs_v2.API/GarbageCollect":           authDisabledOr(clusterPermissions(auth.Permission_CLUSTER_GARBAGE_COLLECT)),
+	"/pfs_v2.API/InspectGarbageCollection": authDisabledOr(clusterPermissions(auth.Permission_CLUSTER_INSPECT_STORAGE)),
+	"/pfs_v2.API/StorageUsage":             authDisabledOr(authenticated),
+	"/pfs_v2.API/DedupReport":              authDisabledOr(authenticated),
+	"/pfs_v2.API/FindFilesByContent":       authDisabledOr(authenticated),
+	"/pfs_v2.API/CreateFileSet":            authDisabledOr(authenticated),
+	"/pfs_v2.API/GetFileSet":               authDisabledOr(authenticated),
+	"/pfs_v2.API/AddFileSet":               authDisabledOr(authenticated),
+	"/pfs_v2.API/RenewFileSet":             authDisabledOr(authenticated),
+	"/pfs_v2.API/ComposeFileSet":           authDisabledOr(authenticated),
+	"/pfs_v2.API/FilterFileSet":            authDisabledOr(authenticated),
+	"/pfs_v2.API/SubtractFileSet":          authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectFileSet":           authDisabledOr(authenticated),
+	"/pfs_v2.API/StartUpload":              authDisabledOr(authenticated),
+	"/pfs_v2.API/AddUploadPart":            authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectUpload":            authDisabledOr(authenticated),
+	"/pfs_v2.API/FinishUpload":             authDisabledOr(authenticated),
+	"/pfs_v2.API/AbortUpload":              authDisabledOr(authenticated),
+	"/pfs_v2.API/RunLoadTest":              authDisabledOr(authenticated),
+	"/pfs_v2.API/CreateProject":            authDisabledOr(authenticated),
+	"/pfs_v2.API/InspectProject":           authDisabledOr(authenticated),
+	"/pfs_v2.API/ListProject":              authDisabledOr(authenticated),
+	"/pfs_v2.API/DeleteProject":            authDisabledOr(authenticated),
 
 	//
 	// PPS API
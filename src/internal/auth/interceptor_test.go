@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// TestPFSHandlers checks that every PFS RPC has an auth handler, as the
+// interceptor rejects RPCs that don't.
+func TestPFSHandlers(t *testing.T) {
+	api := reflect.TypeOf((*pfs.APIServer)(nil)).Elem()
+	require.True(t, api.NumMethod() > 0)
+	for i := 0; i < api.NumMethod(); i++ {
+		method := "/pfs_v2.API/" + api.Method(i).Name
+		_, ok := authHandlers[method]
+		require.True(t, ok, "no auth handler for %s", method)
+	}
+}
@@ -88,4 +88,25 @@ var DesiredClusterState migrations.State = migrations.InitialState().
 	}).
 	Apply("identity config token lifetime", func(ctx context.Context, env migrations.Env) error {
 		return identity.AddTokenExpiryConfig(ctx, env.Tx)
+	}).
+	Apply("create pfs projects collection", func(ctx context.Context, env migrations.Env) error {
+		return col.SetupPostgresCollections(ctx, env.Tx, pfsdb.ProjectsCollections()...)
+	}).
+	Apply("pfs events v0", func(ctx context.Context, env migrations.Env) error {
+		return pfsserver.SetupPostgresEventsV0(ctx, env.Tx)
+	}).
+	Apply("pfs branch stats v0", func(ctx context.Context, env migrations.Env) error {
+		return pfsdb.SetupPostgresBranchStatsV0(ctx, env.Tx)
+	}).
+	Apply("pfs gc runs v0", func(ctx context.Context, env migrations.Env) error {
+		return pfsdb.SetupPostgresGCRunsV0(ctx, env.Tx)
+	}).
+	Apply("pfs uploads v0", func(ctx context.Context, env migrations.Env) error {
+		return pfsdb.SetupPostgresUploadsV0(ctx, env.Tx)
+	}).
+	Apply("pfs chunk refs v0", func(ctx context.Context, env migrations.Env) error {
+		return pfsdb.SetupPostgresChunkRefsV0(ctx, env.Tx)
+	}).
+	Apply("pfs events v1", func(ctx context.Context, env migrations.Env) error {
+		return pfsserver.SetupPostgresEventsV1(ctx, env.Tx)
 	})
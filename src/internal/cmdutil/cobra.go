@@ -112,8 +112,15 @@ func isValidBranch(name string) bool {
 	return err == nil
 }
 
+// ParseRepo takes an argument of the form "[project/]repo[.type]" and returns
+// the corresponding *pfs.Repo.
 func ParseRepo(name string) *pfs.Repo {
 	var repo pfs.Repo
+	if strings.Contains(name, "/") {
+		projectParts := strings.SplitN(name, "/", 2)
+		repo.Project = client.NewProject(projectParts[0])
+		name = projectParts[1]
+	}
 	if strings.Contains(name, ".") {
 		repoParts := strings.SplitN(name, ".", 2)
 		repo.Name = repoParts[0]
@@ -128,6 +135,8 @@ func ParseRepo(name string) *pfs.Repo {
 // Parses the following formats, any unspecified fields will be left as empty
 // strings in the pfs.File structure.  The second return value is the number of fields parsed -
 // (1: repo only, 2: repo and branch-or-commit, 3: repo, branch, and file).
+// Any of the formats may be prefixed with "project/" to address a repo outside
+// of the default project.
 //   repo
 //   repo@branch
 //   repo@branch:path
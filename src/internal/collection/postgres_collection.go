@@ -179,6 +179,15 @@ func (c *postgresReadWriteCollection) GetByIndex(index *Index, indexVal string,
 	return c.getByIndex(context.Background(), c.tx, index, indexVal, val, opts, true, f)
 }
 
+func (c *postgresReadWriteCollection) List(val proto.Message, opts *Options, f func(string) error) error {
+	return c.list(context.Background(), nil, opts, true, c.tx, func(m *model) error {
+		if err := proto.Unmarshal(m.Proto, val); err != nil {
+			return errors.EnsureStack(err)
+		}
+		return f(m.Key)
+	})
+}
+
 func (c *postgresCollection) getUniqueByIndex(ctx context.Context, q sqlx.ExtContext, index *Index, indexVal string, val proto.Message) error {
 	found := false
 	if err := c.getByIndex(ctx, q, index, indexVal, val, DefaultOptions(), false, func(string) error {
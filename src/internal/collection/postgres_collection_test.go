@@ -169,6 +169,31 @@ func TestPostgresCollections(suite *testing.T) {
 		})
 	})
 
+	// Like GetByIndex, List in a transaction loads the entire result set into
+	// memory, so that the rows can be read inside its callback.
+	suite.Run("ReadWriteList", func(subsuite *testing.T) {
+		subsuite.Parallel()
+		defaultRead, defaultWriter := initCollection(subsuite, newCollection)
+
+		subsuite.Run("Nested", func(t *testing.T) {
+			t.Parallel()
+			keys := []string{}
+			innerID := makeID(3)
+			err := defaultWriter(context.Background(), func(rw col.ReadWriteCollection) error {
+				testProto := &col.TestItem{}
+				pgrw := rw.(col.PostgresReadWriteCollection)
+				return pgrw.List(testProto, col.DefaultOptions(), func(key string) error {
+					require.Equal(t, testProto.ID, key)
+					keys = append(keys, key)
+					return pgrw.Get(innerID, &col.TestItem{})
+				})
+			})
+			require.NoError(t, err)
+			require.ElementsEqual(t, idRange(0, defaultCollectionSize), keys)
+			checkDefaultCollection(t, defaultRead, RowDiff{})
+		})
+	})
+
 	// TODO: postgres-specific collection tests:
 	// GetRevByIndex(index *Index, indexVal string, val proto.Message, opts *Options, f func(int64) error) error
 	// DeleteByIndex(index *Index, indexVal string) error
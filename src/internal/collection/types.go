@@ -98,6 +98,10 @@ type PostgresReadWriteCollection interface {
 	// TODO: decide if we should merge this with GetByIndex and use an `Options`.
 	GetUniqueByIndex(index *Index, indexVal string, val proto.Message) error
 
+	// List is like ReadOnlyCollection.List, but reads the collection in the
+	// transaction. Like GetByIndex, it reads every row before calling f.
+	List(val proto.Message, opts *Options, f func(string) error) error
+
 	// Unsupported operations - only here during migration so we can compile
 	// TODO: remove these before merging into master
 	TTL(key string) (int64, error)
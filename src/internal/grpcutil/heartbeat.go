@@ -0,0 +1,48 @@
+package grpcutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Heartbeat runs 'f' and calls 'beat' every 'interval' until 'f' returns, so
+// that a streaming RPC keeps sending messages while it has nothing else to
+// send. 'beat' is called while holding the Locker passed to 'f', so 'f' must
+// hold it while sending on the same stream. If 'beat' fails, the context
+// passed to 'f' is canceled and the error from 'beat' is returned. An interval
+// of 0 disables heartbeats.
+func Heartbeat(ctx context.Context, interval time.Duration, beat func() error, f func(context.Context, sync.Locker) error) error {
+	mu := &sync.Mutex{}
+	if interval <= 0 {
+		return f(ctx, mu)
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	done := make(chan struct{})
+	eg.Go(func() error {
+		defer close(done)
+		return f(ctx, mu)
+	})
+	eg.Go(func() error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				mu.Lock()
+				err := beat()
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	})
+	return eg.Wait()
+}
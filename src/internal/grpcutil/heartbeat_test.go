@@ -0,0 +1,35 @@
+package grpcutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var beats int
+	require.NoError(t, Heartbeat(context.Background(), 10*time.Millisecond, func() error {
+		beats++
+		return nil
+	}, func(ctx context.Context, mu sync.Locker) error {
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		require.True(t, beats > 0)
+		return nil
+	}))
+
+	// A failed heartbeat cancels f and is returned.
+	errBeat := errors.New("beat failed")
+	err := Heartbeat(context.Background(), 10*time.Millisecond, func() error {
+		return errBeat
+	}, func(ctx context.Context, _ sync.Locker) error {
+		<-ctx.Done()
+		return errors.EnsureStack(ctx.Err())
+	})
+	require.True(t, errors.Is(err, errBeat))
+}
@@ -0,0 +1,87 @@
+package grpcutil
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
+)
+
+var (
+	// panicCounter counts the RPCs that panicked, by method.
+	panicCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pachyderm",
+			Subsystem: "grpc",
+			Name:      "panics_total",
+			Help:      "Number of RPCs that panicked and were recovered, by method",
+		},
+		[]string{"method"},
+	)
+	registerPanicCounter sync.Once
+)
+
+// RecoveryInterceptor recovers from panics in RPC handlers (and in the
+// interceptors after it), so that a bug in one RPC fails that RPC with an
+// Internal error instead of crashing pachd and closing every connection.
+//
+// The panic and its stack are logged under a random ID, and only the ID is
+// returned to the client, so that the error can be matched with the logs
+// without leaking server internals.
+type RecoveryInterceptor struct{}
+
+// NewRecoveryInterceptor returns a RecoveryInterceptor. It should be the first
+// interceptor in the chain, so that it also recovers from panics in the other
+// interceptors.
+func NewRecoveryInterceptor() *RecoveryInterceptor {
+	registerPanicCounter.Do(func() {
+		if err := prometheus.Register(panicCounter); err != nil {
+			// metrics may be redundantly registered; ignore these errors
+			if !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+				log.Errorf("error registering prometheus metric: %v", err)
+			}
+		}
+	})
+	return &RecoveryInterceptor{}
+}
+
+// InterceptUnary recovers from panics in unary RPCs.
+func (i *RecoveryInterceptor) InterceptUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = panicError(info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// InterceptStream recovers from panics in streaming RPCs.
+func (i *RecoveryInterceptor) InterceptStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (retErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retErr = panicError(info.FullMethod, r)
+		}
+	}()
+	return handler(srv, stream)
+}
+
+// panicError logs a panic recovered from method and returns the error to send
+// to the client in its place.
+func panicError(method string, r interface{}) error {
+	id := uuid.NewWithoutDashes()
+	log.WithFields(log.Fields{
+		"method":   method,
+		"panic_id": id,
+		"panic":    r,
+	}).Errorf("recovered from panic in %s: %v\n%s", method, r, debug.Stack())
+	panicCounter.WithLabelValues(method).Inc()
+	return status.Errorf(codes.Internal, "internal error in %s (panic ID %s)", method, id)
+}
@@ -0,0 +1,42 @@
+package grpcutil
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	i := NewRecoveryInterceptor()
+	_, err := i.InterceptUnary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Unary"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			var m map[string]int
+			m["secret"] = 1 // panics
+			return nil, nil
+		})
+	require.YesError(t, err)
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.True(t, strings.Contains(err.Error(), "panic ID"), err.Error())
+	require.False(t, strings.Contains(err.Error(), "nil map"), err.Error())
+
+	err = i.InterceptStream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			panic("boom")
+		})
+	require.YesError(t, err)
+	require.Equal(t, codes.Internal, status.Code(err))
+
+	// RPCs that don't panic are unaffected
+	resp, err := i.InterceptUnary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Unary"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+}
@@ -0,0 +1,255 @@
+package obj
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pacherr"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ Client = &erasureClient{}
+
+// erasureHeaderSize is the size of the header at the start of each shard,
+// which holds the length of the object the shard belongs to.
+const erasureHeaderSize = 8
+
+type erasureClient struct {
+	shards []Client
+	rs     *reedSolomon
+}
+
+// NewErasureClient returns a client that splits each object into data shards
+// and adds parityShards parity shards computed with a Reed-Solomon code, then
+// writes each shard to one of shards (typically different buckets or
+// prefixes). Objects can be read as long as any len(shards) - parityShards of
+// their shards can be, so the layout tolerates the loss of parityShards
+// shards while storing only len(shards) / (len(shards) - parityShards) times
+// the data.
+func NewErasureClient(shards []Client, parityShards int) (Client, error) {
+	rs, err := newReedSolomon(len(shards)-parityShards, parityShards)
+	if err != nil {
+		return nil, err
+	}
+	return &erasureClient{
+		shards: shards,
+		rs:     rs,
+	}, nil
+}
+
+// NewErasureClientFromConfig wraps primary in an erasure client with
+// dataShards + parityShards shards. The shards are written to the object
+// stores at the comma-separated urls if there are any, or otherwise to
+// separate prefixes in primary. primary is returned unchanged if dataShards is
+// 0.
+func NewErasureClientFromConfig(primary Client, dataShards, parityShards int, urls string) (Client, error) {
+	if dataShards == 0 {
+		return primary, nil
+	}
+	var shards []Client
+	for _, urlStr := range strings.Split(urls, ",") {
+		urlStr = strings.TrimSpace(urlStr)
+		if urlStr == "" {
+			continue
+		}
+		url, err := ParseURL(urlStr)
+		if err != nil {
+			return nil, err
+		}
+		shard, err := NewClientFromURLAndSecret(url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating client for erasure shard %v", urlStr)
+		}
+		shards = append(shards, shard)
+	}
+	if len(shards) == 0 {
+		for i := 0; i < dataShards+parityShards; i++ {
+			shards = append(shards, &prefixClient{c: primary, prefix: fmt.Sprintf("shard-%d/", i)})
+		}
+	}
+	if len(shards) != dataShards+parityShards {
+		return nil, errors.Errorf("erasure code with %d data shards and %d parity shards needs %d shard urls, got %d", dataShards, parityShards, dataShards+parityShards, len(shards))
+	}
+	return NewErasureClient(shards, parityShards)
+}
+
+func (c *erasureClient) Put(ctx context.Context, p string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	header := make([]byte, erasureHeaderSize)
+	binary.BigEndian.PutUint64(header, uint64(len(data)))
+	eg, ctx := errgroup.WithContext(ctx)
+	for i, shard := range c.rs.split(data) {
+		i, shard := i, shard
+		eg.Go(func() error {
+			return c.shards[i].Put(ctx, p, io.MultiReader(bytes.NewReader(header), bytes.NewReader(shard)))
+		})
+	}
+	return errors.EnsureStack(eg.Wait())
+}
+
+func (c *erasureClient) Get(ctx context.Context, p string, w io.Writer) error {
+	shards := make([][]byte, len(c.shards))
+	var size uint64
+	var notExist int
+	var firstErr error
+	// Read the data shards first, which don't need decoding, and then as many
+	// parity shards as are needed to make up for the data shards that
+	// couldn't be read.
+	available := 0
+	for i := range c.shards {
+		if available == c.rs.dataShards {
+			break
+		}
+		buf := &bytes.Buffer{}
+		if err := c.shards[i].Get(ctx, p, buf); err != nil {
+			if ctx.Err() != nil {
+				return errors.EnsureStack(ctx.Err())
+			}
+			if pacherr.IsNotExist(err) {
+				notExist++
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			log.Debugf("could not read shard %d of %v, reconstructing: %v", i, p, err)
+			continue
+		}
+		if buf.Len() < erasureHeaderSize {
+			if firstErr == nil {
+				firstErr = errors.Errorf("shard %d of %v is truncated", i, p)
+			}
+			log.Debugf("shard %d of %v is truncated, reconstructing", i, p)
+			continue
+		}
+		size = binary.BigEndian.Uint64(buf.Bytes()[:erasureHeaderSize])
+		shards[i] = buf.Bytes()[erasureHeaderSize:]
+		available++
+	}
+	if available < c.rs.dataShards {
+		if notExist > c.rs.parityShards {
+			return firstErr
+		}
+		return errors.Wrapf(firstErr, "could not read enough shards of %v", p)
+	}
+	if err := c.rs.reconstruct(shards); err != nil {
+		return err
+	}
+	for _, shard := range shards[:c.rs.dataShards] {
+		n := uint64(len(shard))
+		if n > size {
+			n = size
+		}
+		if _, err := w.Write(shard[:n]); err != nil {
+			return errors.EnsureStack(err)
+		}
+		size -= n
+	}
+	return nil
+}
+
+func (c *erasureClient) Delete(ctx context.Context, p string) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, shard := range c.shards {
+		shard := shard
+		eg.Go(func() error {
+			if err := shard.Delete(ctx, p); err != nil && !pacherr.IsNotExist(err) {
+				return err
+			}
+			return nil
+		})
+	}
+	return errors.EnsureStack(eg.Wait())
+}
+
+// Exists returns true if enough of the object's shards exist to read it.
+func (c *erasureClient) Exists(ctx context.Context, p string) (bool, error) {
+	var count int
+	for _, shard := range c.shards {
+		exists, err := shard.Exists(ctx, p)
+		if err != nil {
+			log.Debugf("could not check whether shard of %v exists: %v", p, err)
+			continue
+		}
+		if exists {
+			count++
+		}
+		if count == c.rs.dataShards {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Walk calls cb with the objects that any shard has a piece of, since the
+// shard that holds the first piece of an object may be the one that's lost.
+func (c *erasureClient) Walk(ctx context.Context, p string, cb func(p string) error) error {
+	names := make(map[string]struct{})
+	var walked int
+	var firstErr error
+	for _, shard := range c.shards {
+		if err := shard.Walk(ctx, p, func(name string) error {
+			names[name] = struct{}{}
+			return nil
+		}); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		walked++
+	}
+	if walked < c.rs.dataShards {
+		return errors.Wrapf(firstErr, "could not list enough shards under %v", p)
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if err := cb(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixClient stores its objects under prefix in c.
+type prefixClient struct {
+	c      Client
+	prefix string
+}
+
+func (c *prefixClient) Put(ctx context.Context, p string, r io.Reader) error {
+	return c.c.Put(ctx, c.prefix+p, r)
+}
+
+func (c *prefixClient) Get(ctx context.Context, p string, w io.Writer) error {
+	return c.c.Get(ctx, c.prefix+p, w)
+}
+
+func (c *prefixClient) Delete(ctx context.Context, p string) error {
+	return c.c.Delete(ctx, c.prefix+p)
+}
+
+func (c *prefixClient) Exists(ctx context.Context, p string) (bool, error) {
+	return c.c.Exists(ctx, c.prefix+p)
+}
+
+func (c *prefixClient) Walk(ctx context.Context, p string, cb func(p string) error) error {
+	return c.c.Walk(ctx, c.prefix+p, func(name string) error {
+		return cb(strings.TrimPrefix(name, c.prefix))
+	})
+}
@@ -0,0 +1,71 @@
+package obj
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/pacherr"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func newTestErasureClient(t testing.TB, dataShards, parityShards int) (Client, []Client) {
+	var shards []Client
+	for i := 0; i < dataShards+parityShards; i++ {
+		shards = append(shards, newTestLocalClient(t))
+	}
+	c, err := NewErasureClient(shards, parityShards)
+	require.NoError(t, err)
+	return c, shards
+}
+
+func TestErasureClient(t *testing.T) {
+	t.Parallel()
+	TestSuite(t, func(t testing.TB) Client {
+		c, _ := newTestErasureClient(t, 4, 2)
+		return c
+	})
+}
+
+func TestErasureClientPrefixes(t *testing.T) {
+	t.Parallel()
+	TestSuite(t, func(t testing.TB) Client {
+		c, err := NewErasureClientFromConfig(newTestLocalClient(t), 3, 1, "")
+		require.NoError(t, err)
+		return c
+	})
+}
+
+func TestErasureClientReconstruct(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	c, shards := newTestErasureClient(t, 4, 2)
+	data, err := ioutil.ReadAll(io.LimitReader(rand.Reader, 1<<20+3))
+	require.NoError(t, err)
+	require.NoError(t, c.Put(ctx, "a", bytes.NewReader(data)))
+
+	// Any two shards can be lost.
+	for _, lost := range [][]int{{0, 1}, {1, 4}, {3, 5}} {
+		for _, i := range lost {
+			require.NoError(t, shards[i].Delete(ctx, "a"))
+		}
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Get(ctx, "a", buf))
+		require.Equal(t, data, buf.Bytes())
+		exists, err := c.Exists(ctx, "a")
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.NoError(t, c.Put(ctx, "a", bytes.NewReader(data)))
+	}
+
+	// Losing a third shard loses the object.
+	for _, i := range []int{0, 2, 5} {
+		require.NoError(t, shards[i].Delete(ctx, "a"))
+	}
+	err = c.Get(ctx, "a", &bytes.Buffer{})
+	require.YesError(t, err)
+	require.True(t, pacherr.IsNotExist(err))
+}
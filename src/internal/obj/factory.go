@@ -479,6 +479,60 @@ func NewClientFromURLAndSecret(url *ObjectStoreURL, reverse ...bool) (c Client,
 	}
 }
 
+// NewClientFromURLAndSecretData constructs a client for url with the
+// credentials in data, the contents of a kubernetes secret with the same keys
+// as pachd's storage secret. Unlike NewClientFromURLAndSecret, it never falls
+// back to pachd's own credentials, or to its instance's role, so the secret
+// must hold static credentials, and local URLs aren't allowed.
+func NewClientFromURLAndSecretData(url *ObjectStoreURL, data map[string][]byte) (c Client, err error) {
+	get := func(key string) (string, error) {
+		value := string(data[key])
+		if value == "" {
+			return "", errors.Errorf("%s not found", key)
+		}
+		return value, nil
+	}
+	switch url.Store {
+	case "s3":
+		var region string
+		var creds AmazonCreds
+		if region, err = get("amazon-region"); err != nil {
+			return nil, err
+		}
+		if creds.ID, err = get("amazon-id"); err != nil {
+			return nil, err
+		}
+		if creds.Secret, err = get("amazon-secret"); err != nil {
+			return nil, err
+		}
+		creds.Token = string(data["amazon-token"])
+		c, err = NewAmazonClient(region, url.Bucket, &creds, "", string(data["custom-endpoint"]))
+	case "gcs", "gs":
+		var cred string
+		if cred, err = get("google-cred"); err != nil {
+			return nil, err
+		}
+		c, err = NewGoogleClient(url.Bucket, []option.ClientOption{option.WithCredentialsJSON([]byte(cred))})
+	case "as", "wasb":
+		var id, secret string
+		if id, err = get("microsoft-id"); err != nil {
+			return nil, err
+		}
+		if secret, err = get("microsoft-secret"); err != nil {
+			return nil, err
+		}
+		c, err = NewMicrosoftClient(url.Bucket, id, secret)
+	}
+	switch {
+	case err != nil:
+		return nil, err
+	case c != nil:
+		return TracingObjClient(url.Store, c), nil
+	default:
+		return nil, errors.Errorf("unsupported object store: %s", url.Store)
+	}
+}
+
 // ObjectStoreURL represents a parsed URL to an object in an object store.
 type ObjectStoreURL struct {
 	// The object store, e.g. s3, gcs, as...
@@ -0,0 +1,173 @@
+package obj
+
+import (
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// The Reed-Solomon code used by the erasure client works in GF(2^8) with the
+// generator polynomial x^8 + x^4 + x^3 + x^2 + 1.
+var gfExp, gfLog = func() (exp [510]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = byte(x), byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// reedSolomon is a systematic Reed-Solomon code with dataShards data shards
+// and parityShards parity shards, any dataShards of which are enough to
+// reconstruct the data.
+type reedSolomon struct {
+	dataShards, parityShards int
+	// parity holds the rows of the encoding matrix below the identity, which
+	// form a Cauchy matrix so that every square submatrix of the encoding
+	// matrix is invertible.
+	parity [][]byte
+}
+
+func newReedSolomon(dataShards, parityShards int) (*reedSolomon, error) {
+	if dataShards < 1 || parityShards < 0 || dataShards+parityShards > 256 {
+		return nil, errors.Errorf("invalid erasure code with %d data shards and %d parity shards", dataShards, parityShards)
+	}
+	rs := &reedSolomon{dataShards: dataShards, parityShards: parityShards}
+	for i := 0; i < parityShards; i++ {
+		row := make([]byte, dataShards)
+		for j := range row {
+			row[j] = gfInv(byte(dataShards+i) ^ byte(j))
+		}
+		rs.parity = append(rs.parity, row)
+	}
+	return rs, nil
+}
+
+// row returns row i of the encoding matrix.
+func (rs *reedSolomon) row(i int) []byte {
+	if i >= rs.dataShards {
+		return rs.parity[i-rs.dataShards]
+	}
+	row := make([]byte, rs.dataShards)
+	row[i] = 1
+	return row
+}
+
+// split pads data and splits it into equally sized data shards, followed by
+// the parity shards computed from them.
+func (rs *reedSolomon) split(data []byte) [][]byte {
+	size := (len(data) + rs.dataShards - 1) / rs.dataShards
+	padded := make([]byte, size*(rs.dataShards+rs.parityShards))
+	copy(padded, data)
+	shards := make([][]byte, rs.dataShards+rs.parityShards)
+	for i := range shards {
+		shards[i] = padded[i*size : (i+1)*size]
+	}
+	for i, row := range rs.parity {
+		mulAdd(shards[rs.dataShards+i], row, shards[:rs.dataShards])
+	}
+	return shards
+}
+
+// reconstruct fills in the missing (nil) data shards from the shards that are
+// present, at least dataShards of which must be.
+func (rs *reedSolomon) reconstruct(shards [][]byte) error {
+	var present []int
+	missing := false
+	for i, shard := range shards {
+		if shard != nil {
+			present = append(present, i)
+		} else if i < rs.dataShards {
+			missing = true
+		}
+	}
+	if !missing {
+		return nil
+	}
+	if len(present) < rs.dataShards {
+		return errors.Errorf("only %d of the %d shards needed to reconstruct the object are available", len(present), rs.dataShards)
+	}
+	present = present[:rs.dataShards]
+	m := make([][]byte, rs.dataShards)
+	inputs := make([][]byte, rs.dataShards)
+	for i, shard := range present {
+		m[i] = append([]byte(nil), rs.row(shard)...)
+		inputs[i] = shards[shard]
+	}
+	inverse, err := gfInvert(m)
+	if err != nil {
+		return err
+	}
+	size := len(inputs[0])
+	for i := 0; i < rs.dataShards; i++ {
+		if shards[i] != nil {
+			continue
+		}
+		shards[i] = make([]byte, size)
+		mulAdd(shards[i], inverse[i], inputs)
+	}
+	return nil
+}
+
+// mulAdd sets out to the sum of inputs weighted by coefficients.
+func mulAdd(out []byte, coefficients []byte, inputs [][]byte) {
+	for j, c := range coefficients {
+		if c == 0 {
+			continue
+		}
+		for k, b := range inputs[j] {
+			out[k] ^= gfMul(c, b)
+		}
+	}
+}
+
+// gfInvert inverts the square matrix m in place using Gauss-Jordan
+// elimination and returns the inverse.
+func gfInvert(m [][]byte) ([][]byte, error) {
+	n := len(m)
+	inverse := make([][]byte, n)
+	for i := range inverse {
+		inverse[i] = make([]byte, n)
+		inverse[i][i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for pivot < n && m[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, errors.New("erasure code matrix is singular")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+		scale := gfInv(m[col][col])
+		for j := 0; j < n; j++ {
+			m[col][j] = gfMul(m[col][j], scale)
+			inverse[col][j] = gfMul(inverse[col][j], scale)
+		}
+		for row := 0; row < n; row++ {
+			if row == col || m[row][col] == 0 {
+				continue
+			}
+			factor := m[row][col]
+			for j := 0; j < n; j++ {
+				m[row][j] ^= gfMul(factor, m[col][j])
+				inverse[row][j] ^= gfMul(factor, inverse[col][j])
+			}
+		}
+	}
+	return inverse, nil
+}
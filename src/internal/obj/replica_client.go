@@ -0,0 +1,89 @@
+package obj
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ Client = &replicaClient{}
+
+type replicaClient struct {
+	primary  Client
+	replicas []Client
+}
+
+// NewReplicaClient returns a client that writes to primary and reads from
+// replicas, which are copies of primary (typically buckets replicated to other
+// regions). Reads try the replicas in order of preference and fall back to
+// primary if none of them can serve the object, for example because
+// replication hasn't caught up yet.
+func NewReplicaClient(primary Client, replicas ...Client) Client {
+	if len(replicas) == 0 {
+		return primary
+	}
+	return &replicaClient{
+		primary:  primary,
+		replicas: replicas,
+	}
+}
+
+// NewReplicaClientFromURLs wraps primary in a replica client that reads from
+// the object stores at the comma-separated urls, in order of preference.
+func NewReplicaClientFromURLs(primary Client, urls string) (Client, error) {
+	var replicas []Client
+	for _, urlStr := range strings.Split(urls, ",") {
+		urlStr = strings.TrimSpace(urlStr)
+		if urlStr == "" {
+			continue
+		}
+		url, err := ParseURL(urlStr)
+		if err != nil {
+			return nil, err
+		}
+		replica, err := NewClientFromURLAndSecret(url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating client for replica %v", urlStr)
+		}
+		replicas = append(replicas, replica)
+	}
+	return NewReplicaClient(primary, replicas...), nil
+}
+
+func (c *replicaClient) Put(ctx context.Context, p string, r io.Reader) error {
+	return c.primary.Put(ctx, p, r)
+}
+
+func (c *replicaClient) Get(ctx context.Context, p string, w io.Writer) error {
+	for i, replica := range c.replicas {
+		// Buffer the object so that a replica that fails partway through
+		// doesn't leave partial data in w.
+		buf := &bytes.Buffer{}
+		if err := replica.Get(ctx, p, buf); err != nil {
+			if ctx.Err() != nil {
+				return errors.EnsureStack(ctx.Err())
+			}
+			log.Debugf("could not read %v from object store replica %d, falling back: %v", p, i, err)
+			continue
+		}
+		_, err := io.Copy(w, buf)
+		return errors.EnsureStack(err)
+	}
+	return c.primary.Get(ctx, p, w)
+}
+
+func (c *replicaClient) Delete(ctx context.Context, p string) error {
+	return c.primary.Delete(ctx, p)
+}
+
+func (c *replicaClient) Exists(ctx context.Context, p string) (bool, error) {
+	return c.primary.Exists(ctx, p)
+}
+
+func (c *replicaClient) Walk(ctx context.Context, p string, cb func(p string) error) error {
+	return c.primary.Walk(ctx, p, cb)
+}
@@ -0,0 +1,39 @@
+package obj
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestReplicaClient(t *testing.T) {
+	t.Parallel()
+	TestSuite(t, func(t testing.TB) Client {
+		return NewReplicaClient(newTestLocalClient(t), newTestLocalClient(t))
+	})
+}
+
+func TestReplicaClientPrefersReplica(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	primary, replica := newTestLocalClient(t), newTestLocalClient(t)
+	c := NewReplicaClient(primary, replica)
+	require.NoError(t, c.Put(ctx, "a", strings.NewReader("primary")))
+	require.NoError(t, replica.Put(ctx, "a", strings.NewReader("replica")))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, c.Get(ctx, "a", buf))
+	require.Equal(t, "replica", buf.String())
+
+	// Objects that haven't been replicated yet are read from the primary.
+	require.NoError(t, c.Put(ctx, "b", strings.NewReader("primary")))
+	buf.Reset()
+	require.NoError(t, c.Get(ctx, "b", buf))
+	require.Equal(t, "primary", buf.String())
+	exists, err := replica.Exists(ctx, "b")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
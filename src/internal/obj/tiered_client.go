@@ -0,0 +1,106 @@
+package obj
+
+import (
+	"context"
+	"io"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pacherr"
+)
+
+var _ Client = &TieredClient{}
+
+// TieredClient is a Client that writes objects to a hot tier and can move
+// them to a cold tier (typically an archival storage class) and back. Reads
+// are served from whichever tier holds the object, so moving an object only
+// changes how quickly it can be read.
+type TieredClient struct {
+	hot, cold Client
+}
+
+// NewTieredClient returns a TieredClient that writes to hot and moves objects
+// to cold on request.
+func NewTieredClient(hot, cold Client) *TieredClient {
+	return &TieredClient{
+		hot:  hot,
+		cold: cold,
+	}
+}
+
+// NewTieredClientFromURL returns a TieredClient with hot as its hot tier and
+// the object store at url as its cold tier.
+func NewTieredClientFromURL(hot Client, urlStr string) (*TieredClient, error) {
+	url, err := ParseURL(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := NewClientFromURLAndSecret(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating client for cold storage %v", urlStr)
+	}
+	return NewTieredClient(hot, cold), nil
+}
+
+func (c *TieredClient) Put(ctx context.Context, p string, r io.Reader) error {
+	return c.hot.Put(ctx, p, r)
+}
+
+func (c *TieredClient) Get(ctx context.Context, p string, w io.Writer) error {
+	err := c.hot.Get(ctx, p, w)
+	if !pacherr.IsNotExist(err) {
+		return err
+	}
+	return c.cold.Get(ctx, p, w)
+}
+
+func (c *TieredClient) Delete(ctx context.Context, p string) error {
+	if err := c.cold.Delete(ctx, p); err != nil && !pacherr.IsNotExist(err) {
+		return err
+	}
+	return c.hot.Delete(ctx, p)
+}
+
+func (c *TieredClient) Exists(ctx context.Context, p string) (bool, error) {
+	exists, err := c.hot.Exists(ctx, p)
+	if err != nil || exists {
+		return exists, err
+	}
+	return c.cold.Exists(ctx, p)
+}
+
+// Walk calls cb with the objects in either tier. An object that is being
+// moved between tiers may be passed to cb twice.
+func (c *TieredClient) Walk(ctx context.Context, p string, cb func(p string) error) error {
+	if err := c.hot.Walk(ctx, p, cb); err != nil {
+		return err
+	}
+	return c.cold.Walk(ctx, p, cb)
+}
+
+// MoveToCold moves the object at p to the cold tier. It's a no-op if the
+// object is already there.
+func (c *TieredClient) MoveToCold(ctx context.Context, p string) error {
+	return move(ctx, c.hot, c.cold, p)
+}
+
+// MoveToHot moves the object at p back to the hot tier. It's a no-op if the
+// object is already there.
+func (c *TieredClient) MoveToHot(ctx context.Context, p string) error {
+	return move(ctx, c.cold, c.hot, p)
+}
+
+func move(ctx context.Context, src, dst Client, p string) error {
+	exists, err := src.Exists(ctx, p)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	// The object is written to dst before it's deleted from src, so that it
+	// can be read throughout.
+	if err := Copy(ctx, src, dst, p, p); err != nil {
+		return err
+	}
+	return src.Delete(ctx, p)
+}
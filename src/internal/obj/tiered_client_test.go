@@ -0,0 +1,51 @@
+package obj
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestTieredClient(t *testing.T) {
+	t.Parallel()
+	TestSuite(t, func(t testing.TB) Client {
+		return NewTieredClient(newTestLocalClient(t), newTestLocalClient(t))
+	})
+}
+
+func TestTieredClientMove(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	hot, cold := newTestLocalClient(t), newTestLocalClient(t)
+	c := NewTieredClient(hot, cold)
+	require.NoError(t, c.Put(ctx, "a", strings.NewReader("foo")))
+
+	requireTier := func(inHot, inCold bool) {
+		exists, err := hot.Exists(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, inHot, exists)
+		exists, err = cold.Exists(ctx, "a")
+		require.NoError(t, err)
+		require.Equal(t, inCold, exists)
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Get(ctx, "a", buf))
+		require.Equal(t, "foo", buf.String())
+	}
+	requireTier(true, false)
+	require.NoError(t, c.MoveToCold(ctx, "a"))
+	requireTier(false, true)
+	// Moving an object to the tier it's in is a no-op.
+	require.NoError(t, c.MoveToCold(ctx, "a"))
+	requireTier(false, true)
+	require.NoError(t, c.MoveToHot(ctx, "a"))
+	requireTier(true, false)
+
+	require.NoError(t, c.MoveToCold(ctx, "a"))
+	require.NoError(t, c.Delete(ctx, "a"))
+	exists, err := c.Exists(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -0,0 +1,106 @@
+package pfsdb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// SetupPostgresBranchStatsV0 runs SQL to setup the table of branch statistics,
+// which triggers on the commit and branch collections keep up to date, and
+// fills it in from the commits that already exist.
+func SetupPostgresBranchStatsV0(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE pfs.branch_stats (
+			branch TEXT PRIMARY KEY,
+			commits BIGINT NOT NULL DEFAULT 0,
+			last_commit TIMESTAMP WITH TIME ZONE,
+			last_triggered TIMESTAMP WITH TIME ZONE
+		);
+
+		INSERT INTO pfs.branch_stats (branch, commits, last_commit)
+			SELECT split_part(key, '=', 1), count(*), max(createdat)
+			FROM collections.commits GROUP BY 1;
+
+		CREATE FUNCTION pfs.branch_stats_commits_trigger_fn() RETURNS TRIGGER AS $$
+		BEGIN
+			IF tg_op = 'INSERT' THEN
+				INSERT INTO pfs.branch_stats (branch, commits, last_commit)
+					VALUES (split_part(new.key, '=', 1), 1, new.createdat)
+				ON CONFLICT (branch) DO UPDATE SET
+					commits = pfs.branch_stats.commits + 1,
+					last_commit = greatest(pfs.branch_stats.last_commit, excluded.last_commit);
+				RETURN new;
+			END IF;
+			UPDATE pfs.branch_stats SET commits = commits - 1 WHERE branch = split_part(old.key, '=', 1);
+			-- The most recent commit was deleted, so find the one before it.
+			UPDATE pfs.branch_stats SET last_commit = (
+				SELECT max(createdat) FROM collections.commits
+				WHERE idx_repo = old.idx_repo AND split_part(key, '=', 1) = split_part(old.key, '=', 1)
+			) WHERE branch = split_part(old.key, '=', 1) AND last_commit <= old.createdat;
+			RETURN old;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE FUNCTION pfs.branch_stats_branches_trigger_fn() RETURNS TRIGGER AS $$
+		BEGIN
+			DELETE FROM pfs.branch_stats WHERE branch = old.key;
+			RETURN old;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER branch_stats AFTER INSERT OR DELETE ON collections.commits
+			FOR EACH ROW EXECUTE PROCEDURE pfs.branch_stats_commits_trigger_fn();
+		CREATE TRIGGER branch_stats AFTER DELETE ON collections.branches
+			FOR EACH ROW EXECUTE PROCEDURE pfs.branch_stats_branches_trigger_fn();
+	`)
+	return errors.EnsureStack(err)
+}
+
+// GetBranchStatsTx returns the statistics kept for branch, which are zero if
+// it has no commits.
+func GetBranchStatsTx(tx *sqlx.Tx, branch *pfs.Branch) (*pfs.BranchStats, error) {
+	var row struct {
+		Commits       int64        `db:"commits"`
+		LastCommit    sql.NullTime `db:"last_commit"`
+		LastTriggered sql.NullTime `db:"last_triggered"`
+	}
+	stats := &pfs.BranchStats{}
+	if err := tx.Get(&row, `
+		SELECT commits, last_commit, last_triggered FROM pfs.branch_stats WHERE branch = $1
+	`, BranchKey(branch)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return stats, nil
+		}
+		return nil, errors.EnsureStack(err)
+	}
+	stats.Commits = row.Commits
+	var err error
+	if row.LastCommit.Valid {
+		if stats.LastCommit, err = types.TimestampProto(row.LastCommit.Time); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+	}
+	if row.LastTriggered.Valid {
+		stats.Trigger = &pfs.BranchTriggerStatus{}
+		if stats.Trigger.LastTriggered, err = types.TimestampProto(row.LastTriggered.Time); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+	}
+	return stats, nil
+}
+
+// SetBranchTriggeredTx records that branch's trigger moved its head at t.
+func SetBranchTriggeredTx(tx *sqlx.Tx, branch *pfs.Branch, t time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO pfs.branch_stats (branch, last_triggered) VALUES ($1, $2)
+		ON CONFLICT (branch) DO UPDATE SET last_triggered = excluded.last_triggered
+	`, BranchKey(branch), t)
+	return errors.EnsureStack(err)
+}
@@ -0,0 +1,99 @@
+package pfsdb
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// SetupPostgresChunkRefsV0 runs SQL to setup the table of chunk reference
+// counts, which triggers on the storage tracker's tables keep up to date, and
+// fills it in from the references that already exist.
+//
+// A chunk's count is the number of tracker objects that reference it, other
+// than the temporary objects that writers hold their chunks with. A chunk is
+// released when its count drops to zero, and stays released until its
+// tracker object's TTL is changed, which is how a writer that deduplicates
+// against it claims it again. Released chunks are expired by
+// ExpireReleasedChunksTx, so that they're garbage collected without waiting
+// out their TTL.
+func SetupPostgresChunkRefsV0(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE pfs.chunk_refs (
+			int_id BIGINT PRIMARY KEY,
+			refs BIGINT NOT NULL DEFAULT 0,
+			released_at TIMESTAMP WITH TIME ZONE
+		);
+
+		CREATE INDEX ON pfs.chunk_refs (released_at) WHERE released_at IS NOT NULL;
+
+		INSERT INTO pfs.chunk_refs (int_id, refs)
+			SELECT r.to_id, count(*)
+			FROM storage.tracker_refs r
+			JOIN storage.tracker_objects t ON t.int_id = r.to_id
+			JOIN storage.tracker_objects f ON f.int_id = r.from_id
+			WHERE t.str_id LIKE 'chunk/%' AND f.str_id NOT LIKE 'tmp/%'
+			GROUP BY 1;
+
+		CREATE FUNCTION pfs.chunk_refs_refs_trigger_fn() RETURNS TRIGGER AS $$
+		DECLARE
+			ref storage.tracker_refs;
+		BEGIN
+			IF tg_op = 'INSERT' THEN
+				ref := new;
+			ELSE
+				ref := old;
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM storage.tracker_objects WHERE int_id = ref.to_id AND str_id LIKE 'chunk/%')
+				OR EXISTS (SELECT 1 FROM storage.tracker_objects WHERE int_id = ref.from_id AND str_id LIKE 'tmp/%') THEN
+				RETURN ref;
+			END IF;
+			IF tg_op = 'INSERT' THEN
+				INSERT INTO pfs.chunk_refs (int_id, refs) VALUES (ref.to_id, 1)
+				ON CONFLICT (int_id) DO UPDATE SET refs = pfs.chunk_refs.refs + 1, released_at = NULL;
+			ELSE
+				UPDATE pfs.chunk_refs SET
+					refs = refs - 1,
+					released_at = CASE WHEN refs = 1 THEN clock_timestamp() END
+				WHERE int_id = ref.to_id;
+			END IF;
+			RETURN ref;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE FUNCTION pfs.chunk_refs_objects_trigger_fn() RETURNS TRIGGER AS $$
+		BEGIN
+			IF tg_op = 'DELETE' THEN
+				DELETE FROM pfs.chunk_refs WHERE int_id = old.int_id;
+				RETURN old;
+			END IF;
+			UPDATE pfs.chunk_refs SET released_at = NULL WHERE int_id = new.int_id AND released_at IS NOT NULL;
+			RETURN new;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER chunk_refs AFTER INSERT OR DELETE ON storage.tracker_refs
+			FOR EACH ROW EXECUTE PROCEDURE pfs.chunk_refs_refs_trigger_fn();
+		CREATE TRIGGER chunk_refs AFTER UPDATE OF expires_at OR DELETE ON storage.tracker_objects
+			FOR EACH ROW WHEN (old.str_id LIKE 'chunk/%')
+			EXECUTE PROCEDURE pfs.chunk_refs_objects_trigger_fn();
+	`)
+	return errors.EnsureStack(err)
+}
+
+// ExpireReleasedChunksTx expires the tracker objects of the chunks that have
+// been released, so that the storage garbage collector deletes them on its
+// next pass. It returns the number of chunks expired.
+func ExpireReleasedChunksTx(tx *sqlx.Tx) (int64, error) {
+	res, err := tx.Exec(`
+		UPDATE storage.tracker_objects SET expires_at = CURRENT_TIMESTAMP
+		WHERE int_id IN (SELECT int_id FROM pfs.chunk_refs WHERE refs = 0 AND released_at IS NOT NULL)
+	`)
+	if err != nil {
+		return 0, errors.EnsureStack(err)
+	}
+	n, err := res.RowsAffected()
+	return n, errors.EnsureStack(err)
+}
@@ -0,0 +1,99 @@
+package pfsdb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// SetupPostgresGCRunsV0 runs SQL to setup the table of storage garbage
+// collection runs. Runs are kept for a day.
+func SetupPostgresGCRunsV0(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE pfs.gc_runs (
+			id BIGSERIAL PRIMARY KEY,
+			started TIMESTAMP WITH TIME ZONE NOT NULL,
+			finished TIMESTAMP WITH TIME ZONE NOT NULL,
+			objects_deleted BIGINT NOT NULL,
+			chunks_deleted BIGINT NOT NULL,
+			bytes_reclaimed BIGINT NOT NULL,
+			manual BOOLEAN NOT NULL
+		);
+
+		CREATE INDEX ON pfs.gc_runs (finished);
+	`)
+	return errors.EnsureStack(err)
+}
+
+type gcRunRow struct {
+	Started        time.Time `db:"started"`
+	Finished       time.Time `db:"finished"`
+	ObjectsDeleted int64     `db:"objects_deleted"`
+	ChunksDeleted  int64     `db:"chunks_deleted"`
+	BytesReclaimed int64     `db:"bytes_reclaimed"`
+	Manual         bool      `db:"manual"`
+}
+
+// AddGCRunTx records run, and removes the runs that finished more than a day
+// ago.
+func AddGCRunTx(tx *sqlx.Tx, run *pfs.GarbageCollectionRun) error {
+	started, err := types.TimestampFromProto(run.Started)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	finished, err := types.TimestampFromProto(run.Finished)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO pfs.gc_runs (started, finished, objects_deleted, chunks_deleted, bytes_reclaimed, manual)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, started, finished, run.ObjectsDeleted, run.ChunksDeleted, run.BytesReclaimed, run.Manual); err != nil {
+		return errors.EnsureStack(err)
+	}
+	_, err = tx.Exec(`DELETE FROM pfs.gc_runs WHERE finished < now() - interval '1 day'`)
+	return errors.EnsureStack(err)
+}
+
+// GetGCStatsTx returns the most recent garbage collection run, which is nil
+// if there hasn't been one in the last day, and the bytes reclaimed by the
+// runs in the last day.
+func GetGCStatsTx(tx *sqlx.Tx) (*pfs.GarbageCollectionRun, int64, error) {
+	var reclaimed int64
+	if err := tx.Get(&reclaimed, `
+		SELECT COALESCE(sum(bytes_reclaimed), 0) FROM pfs.gc_runs
+		WHERE finished >= now() - interval '1 day'
+	`); err != nil {
+		return nil, 0, errors.EnsureStack(err)
+	}
+	var row gcRunRow
+	if err := tx.Get(&row, `
+		SELECT started, finished, objects_deleted, chunks_deleted, bytes_reclaimed, manual
+		FROM pfs.gc_runs ORDER BY finished DESC LIMIT 1
+	`); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, reclaimed, nil
+		}
+		return nil, 0, errors.EnsureStack(err)
+	}
+	run := &pfs.GarbageCollectionRun{
+		ObjectsDeleted: row.ObjectsDeleted,
+		ChunksDeleted:  row.ChunksDeleted,
+		BytesReclaimed: row.BytesReclaimed,
+		Manual:         row.Manual,
+	}
+	var err error
+	if run.Started, err = types.TimestampProto(row.Started); err != nil {
+		return nil, 0, errors.EnsureStack(err)
+	}
+	if run.Finished, err = types.TimestampProto(row.Finished); err != nil {
+		return nil, 0, errors.EnsureStack(err)
+	}
+	return run, reclaimed, nil
+}
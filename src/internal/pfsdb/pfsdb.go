@@ -14,11 +14,38 @@ import (
 )
 
 const (
+	projectsCollectionName = "projects"
 	reposCollectionName    = "repos"
 	branchesCollectionName = "branches"
 	commitsCollectionName  = "commits"
 )
 
+var projectsIndexes = []*col.Index{}
+
+func ProjectKey(project *pfs.Project) string {
+	return project.Name
+}
+
+// Projects returns a collection of projects
+func Projects(db *sqlx.DB, listener *col.PostgresListener) col.PostgresCollection {
+	return col.NewPostgresCollection(
+		projectsCollectionName,
+		db,
+		listener,
+		&pfs.ProjectInfo{},
+		projectsIndexes,
+		nil,
+	)
+}
+
+// ProjectsCollections returns the collections that were added to PFS to
+// support projects, for postgres-initialization purposes.
+func ProjectsCollections() []col.PostgresCollection {
+	return []col.PostgresCollection{
+		col.NewPostgresCollection(projectsCollectionName, nil, nil, nil, projectsIndexes, nil),
+	}
+}
+
 var ReposTypeIndex = &col.Index{
 	Name: "type",
 	Extract: func(val proto.Message) string {
@@ -35,8 +62,11 @@ var ReposNameIndex = &col.Index{
 
 var reposIndexes = []*col.Index{ReposNameIndex, ReposTypeIndex}
 
+// RepoKey returns the key of a repo. Repos in a project other than the
+// default one are prefixed with the project name, so that repos with the same
+// name can exist in different projects.
 func RepoKey(repo *pfs.Repo) string {
-	return repo.Name + "." + repo.Type
+	return repo.QualifiedName() + "." + repo.Type
 }
 
 func repoKeyCheck(key string) error {
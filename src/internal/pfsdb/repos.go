@@ -0,0 +1,94 @@
+package pfsdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// RepoListFilter selects the rows of the repos collection that ListRepos
+// reads. Its zero value selects every repo.
+type RepoListFilter struct {
+	// Type is the type of the repos, or blank for all types.
+	Type string
+	// Project is the project of the repos, or nil for all projects. A blank
+	// project name selects the repos in the default project.
+	Project *pfs.Project
+	// Name is the name of the repos, or blank for all names.
+	Name string
+	// NamePrefix is a prefix of the names of the repos.
+	NamePrefix string
+}
+
+// ListRepos calls f with each repo selected by filter, read into repoInfo,
+// newest first, or oldest first if ascending is set. The repos are filtered
+// and ordered by postgres, so the repos that filter doesn't select are never
+// read.
+func ListRepos(ctx context.Context, db *sqlx.DB, filter RepoListFilter, ascending bool, repoInfo *pfs.RepoInfo, f func() error) error {
+	var conds []string
+	var args []interface{}
+	cond := func(format string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(format, len(args)))
+	}
+	if filter.Type != "" {
+		cond("idx_type = $%d", filter.Type)
+	}
+	if filter.Name != "" {
+		cond("idx_name = $%d", filter.Name)
+	}
+	if filter.NamePrefix != "" {
+		cond("idx_name LIKE $%d", escapeLike(filter.NamePrefix)+"%")
+	}
+	if filter.Project != nil {
+		// Keys are qualified with the project's name, unless it's the
+		// default project, see RepoKey.
+		if filter.Project.Name == "" {
+			conds = append(conds, "key NOT LIKE '%/%'")
+		} else {
+			cond("key LIKE $%d", escapeLike(filter.Project.Name)+"/%")
+		}
+	}
+	query := "SELECT proto FROM collections.repos"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	if ascending {
+		query += " ORDER BY createdat ASC"
+	} else {
+		query += " ORDER BY createdat DESC"
+	}
+	rows, err := db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer rows.Close()
+	var data []byte
+	for rows.Next() {
+		if err := rows.Scan(&data); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if err := proto.Unmarshal(data, repoInfo); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if err := f(); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+	return errors.EnsureStack(rows.Err())
+}
+
+// escapeLike escapes the characters of s that LIKE treats as wildcards.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
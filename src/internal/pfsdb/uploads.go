@@ -0,0 +1,128 @@
+package pfsdb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// SetupPostgresUploadsV0 runs SQL to setup the tables of resumable uploads
+// and their parts.
+func SetupPostgresUploadsV0(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE pfs.uploads (
+			id TEXT PRIMARY KEY,
+			commit BYTEA NOT NULL,
+			created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires TIMESTAMP WITH TIME ZONE NOT NULL
+		);
+
+		CREATE INDEX ON pfs.uploads (expires);
+
+		CREATE TABLE pfs.upload_parts (
+			upload_id TEXT NOT NULL REFERENCES pfs.uploads (id) ON DELETE CASCADE,
+			number BIGINT NOT NULL,
+			fileset TEXT NOT NULL,
+			size_bytes BIGINT NOT NULL,
+			PRIMARY KEY (upload_id, number)
+		);
+	`)
+	return errors.EnsureStack(err)
+}
+
+type uploadRow struct {
+	Commit  []byte    `db:"commit"`
+	Created time.Time `db:"created"`
+	Expires time.Time `db:"expires"`
+}
+
+type uploadPartRow struct {
+	Number    int64  `db:"number"`
+	FileSet   string `db:"fileset"`
+	SizeBytes int64  `db:"size_bytes"`
+}
+
+// CreateUploadTx records a new upload id into commit, which expires at
+// expires. Expired uploads are removed first, so id can be reused once the
+// upload that had it has expired.
+func CreateUploadTx(tx *sqlx.Tx, id string, commit *pfs.Commit, expires time.Time) error {
+	if _, err := tx.Exec(`DELETE FROM pfs.uploads WHERE expires < now()`); err != nil {
+		return errors.EnsureStack(err)
+	}
+	data, err := proto.Marshal(commit)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO pfs.uploads (id, commit, expires) VALUES ($1, $2, $3)
+	`, id, data, expires)
+	return errors.EnsureStack(err)
+}
+
+// GetUploadTx returns the upload id, with its parts ordered by number, or nil
+// if there's no such upload or it has expired.
+func GetUploadTx(tx *sqlx.Tx, id string) (*pfs.UploadInfo, error) {
+	var row uploadRow
+	if err := tx.Get(&row, `
+		SELECT commit, created, expires FROM pfs.uploads WHERE id = $1 AND expires >= now()
+	`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.EnsureStack(err)
+	}
+	info := &pfs.UploadInfo{
+		UploadId: id,
+		Commit:   &pfs.Commit{},
+	}
+	if err := proto.Unmarshal(row.Commit, info.Commit); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	var err error
+	if info.Created, err = types.TimestampProto(row.Created); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if info.Expires, err = types.TimestampProto(row.Expires); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	var parts []uploadPartRow
+	if err := tx.Select(&parts, `
+		SELECT number, fileset, size_bytes FROM pfs.upload_parts WHERE upload_id = $1 ORDER BY number
+	`, id); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	for _, part := range parts {
+		info.Parts = append(info.Parts, &pfs.UploadPart{
+			Number:    part.Number,
+			FileSetId: part.FileSet,
+			SizeBytes: part.SizeBytes,
+		})
+	}
+	return info, nil
+}
+
+// PutUploadPartTx records part of upload id, replacing the part with the same
+// number if there is one, and extends the upload to expire at expires.
+func PutUploadPartTx(tx *sqlx.Tx, id string, part *pfs.UploadPart, expires time.Time) error {
+	if _, err := tx.Exec(`
+		INSERT INTO pfs.upload_parts (upload_id, number, fileset, size_bytes) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (upload_id, number) DO UPDATE SET fileset = excluded.fileset, size_bytes = excluded.size_bytes
+	`, id, part.Number, part.FileSetId, part.SizeBytes); err != nil {
+		return errors.EnsureStack(err)
+	}
+	_, err := tx.Exec(`UPDATE pfs.uploads SET expires = $2 WHERE id = $1`, id, expires)
+	return errors.EnsureStack(err)
+}
+
+// DeleteUploadTx removes upload id and its parts.
+func DeleteUploadTx(tx *sqlx.Tx, id string) error {
+	_, err := tx.Exec(`DELETE FROM pfs.uploads WHERE id = $1`, id)
+	return errors.EnsureStack(err)
+}
@@ -0,0 +1,622 @@
+// Package pfsimport imports repos from a Pachyderm 1.x cluster into a 2.x
+// cluster.
+//
+// REQUIREMENT: the source cluster is read with a Pachyderm 1.x pachctl
+// binary, which must be installed alongside this one, see NewPachctlSource.
+// The 1.x API can't be called with the 2.x client, and 1.x's object storage
+// layout (hashtrees and its own object format) has no reader in 2.x, so it
+// can't be read directly either.
+//
+// The importer reads the branches and commits of every repo in the source
+// cluster and recreates them in the destination, with the same parents and
+// provenance. The 1.x storage format (hashtrees) can't be read by 2.x, so
+// data is copied through the source cluster's API rather than reinterpreted
+// in place, and only the files that a commit changed are copied into it.
+//
+// Branches are created with their provenance before any commits are
+// imported, so each branch starts with the empty commit that creating it
+// makes. Commits that aren't provenant on other commits are then imported in
+// order, each one on top of its parent. In 2.x, starting one of them starts a
+// commit in every branch downstream of its branch, in the same commit set;
+// each of those is filled in from the 1.x commit in that branch whose
+// provenance includes the commit being imported, if there is one, and is
+// finished. A 1.x commit that's provenant on several commits is imported in
+// the commit set of the latest of them.
+package pfsimport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+const defaultBatchSize = 1000
+
+// commitState records the progress of importing a source commit.
+type commitState struct {
+	// Commit is the ID of the commit set that the commit is imported in.
+	Commit string `json:"commit"`
+	// Files is the number of the commit's changed files that have been
+	// uploaded.
+	Files int  `json:"files"`
+	Done  bool `json:"done"`
+}
+
+// Importer imports repos from a Source into a Pachyderm cluster. Progress is
+// recorded in a state file so that an interrupted import can be resumed.
+type Importer struct {
+	src        Source
+	pachClient *client.APIClient
+	statePath  string
+	// state is keyed by the source commits' repo and ID, as in "repo@id".
+	state map[string]*commitState
+	// BatchSize is the number of files uploaded between checkpoints.
+	BatchSize int
+	// Log is called with progress messages, if set.
+	Log func(format string, args ...interface{})
+}
+
+// NewImporter creates an Importer that imports from src using pachClient,
+// recording progress in the file at statePath.
+func NewImporter(src Source, pachClient *client.APIClient, statePath string) (*Importer, error) {
+	im := &Importer{
+		src:        src,
+		pachClient: pachClient,
+		statePath:  statePath,
+		state:      make(map[string]*commitState),
+		BatchSize:  defaultBatchSize,
+	}
+	data, err := ioutil.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.EnsureStack(err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &im.state); err != nil {
+			return nil, errors.Wrapf(err, "error parsing import state %v", statePath)
+		}
+	}
+	return im, nil
+}
+
+// history is the branches and commits of the source.
+type history struct {
+	// branches are sorted so that each branch comes after the branches it's
+	// provenant on.
+	branches []*SourceBranch
+	// commits are keyed by commitKey, and sorted by it in keys.
+	commits map[string]*SourceCommit
+	keys    []string
+	// roots are the commits that aren't provenant on other commits, sorted so
+	// that each commit comes after its parent.
+	roots []*SourceCommit
+	// downstream holds the commits that are provenant on other commits, keyed
+	// by their branch and the root commit whose commit set they're imported
+	// in, as in "repo@branch/repo@id".
+	downstream map[string]*SourceCommit
+}
+
+func commitKey(repo, id string) string {
+	return repo + "@" + id
+}
+
+func downstreamKey(repo, branch string, root *SourceCommit) string {
+	return repo + "@" + branch + "/" + commitKey(root.Repo, root.ID)
+}
+
+// Import imports every commit in the source, skipping the ones that a
+// previous run has already imported.
+func (im *Importer) Import(ctx context.Context) error {
+	h, err := im.loadHistory(ctx)
+	if err != nil {
+		return err
+	}
+	if err := im.createBranches(h); err != nil {
+		return err
+	}
+	for _, c := range h.roots {
+		if err := im.importCommitSet(ctx, h, c); err != nil {
+			return errors.Wrapf(err, "error importing %v", commitKey(c.Repo, c.ID))
+		}
+	}
+	for _, key := range h.keys {
+		if state, ok := im.state[key]; !ok || !state.Done {
+			im.logf("%v was not imported, because there's no commit set to import it in", key)
+		}
+	}
+	return nil
+}
+
+func (im *Importer) loadHistory(ctx context.Context) (*history, error) {
+	branches, err := im.src.Branches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h := &history{
+		branches:   sortBranches(branches),
+		commits:    make(map[string]*SourceCommit),
+		downstream: make(map[string]*SourceCommit),
+	}
+	repos := make(map[string]bool)
+	for _, branch := range h.branches {
+		if repos[branch.Repo] {
+			continue
+		}
+		repos[branch.Repo] = true
+		commits, err := im.src.Commits(ctx, branch.Repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commits {
+			h.commits[commitKey(c.Repo, c.ID)] = c
+		}
+	}
+	// Commits from old 1.x versions don't record their branch, so they're
+	// imported on the first branch they're an ancestor of the head of.
+	for _, branch := range h.branches {
+		for id := branch.Head; id != ""; {
+			c, ok := h.commits[commitKey(branch.Repo, id)]
+			if !ok {
+				break
+			}
+			if c.Branch == "" {
+				c.Branch = branch.Name
+			}
+			id = c.Parent
+		}
+	}
+	for key, c := range h.commits {
+		if c.Branch == "" {
+			im.logf("skipping %v, it isn't on any branch", key)
+			delete(h.commits, key)
+			continue
+		}
+		h.keys = append(h.keys, key)
+	}
+	sort.Strings(h.keys)
+	// Roots are imported in the order they were started in, but always after
+	// their parent.
+	var started []*SourceCommit
+	for _, key := range h.keys {
+		if c := h.commits[key]; len(c.Provenance) == 0 {
+			started = append(started, c)
+		}
+	}
+	sort.SliceStable(started, func(i, j int) bool { return started[i].Started.Before(started[j].Started) })
+	added := make(map[string]bool)
+	var addRoot func(c *SourceCommit)
+	addRoot = func(c *SourceCommit) {
+		key := commitKey(c.Repo, c.ID)
+		if added[key] {
+			return
+		}
+		added[key] = true
+		if parent, ok := h.commits[commitKey(c.Repo, c.Parent)]; ok && len(parent.Provenance) == 0 {
+			addRoot(parent)
+		}
+		h.roots = append(h.roots, c)
+	}
+	for _, c := range started {
+		addRoot(c)
+	}
+	for _, key := range h.keys {
+		c := h.commits[key]
+		if len(c.Provenance) == 0 {
+			continue
+		}
+		var root *SourceCommit
+		for _, prov := range c.Provenance {
+			p, ok := h.commits[commitKey(prov.Repo, prov.ID)]
+			if ok && len(p.Provenance) == 0 && (root == nil || p.Started.After(root.Started)) {
+				root = p
+			}
+		}
+		if root == nil {
+			continue
+		}
+		dkey := downstreamKey(c.Repo, c.Branch, root)
+		if other, ok := h.downstream[dkey]; ok {
+			// A branch only has one commit in a commit set, so only the latest
+			// of the commits that would share one is imported.
+			if other.Started.After(c.Started) {
+				continue
+			}
+		}
+		h.downstream[dkey] = c
+	}
+	return h, nil
+}
+
+// sortBranches sorts branches so that each branch comes after the branches
+// it's provenant on.
+func sortBranches(branches []*SourceBranch) []*SourceBranch {
+	byKey := make(map[string]*SourceBranch)
+	var keys []string
+	for _, branch := range branches {
+		key := branch.Repo + "@" + branch.Name
+		byKey[key] = branch
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	added := make(map[string]bool)
+	var result []*SourceBranch
+	var add func(key string)
+	add = func(key string) {
+		branch, ok := byKey[key]
+		if !ok || added[key] {
+			return
+		}
+		added[key] = true
+		for _, prov := range branch.Provenance {
+			add(prov.Repo + "@" + prov.Name)
+		}
+		result = append(result, branch)
+	}
+	for _, key := range keys {
+		add(key)
+	}
+	return result
+}
+
+// createBranches creates the repos and branches of h that don't exist yet,
+// with their provenance, and finishes the commits that creating them starts.
+func (im *Importer) createBranches(h *history) error {
+	existing := make(map[string]bool)
+	for _, branch := range h.branches {
+		if _, ok := existing[branch.Repo]; !ok {
+			if err := im.pachClient.UpdateRepo(branch.Repo); err != nil {
+				return err
+			}
+			branchInfos, err := im.pachClient.ListBranch(branch.Repo)
+			if err != nil {
+				return err
+			}
+			existing[branch.Repo] = true
+			for _, bi := range branchInfos {
+				existing[branch.Repo+"@"+bi.Branch.Name] = true
+			}
+		}
+		if existing[branch.Repo+"@"+branch.Name] {
+			continue
+		}
+		var provenance []*pfs.Branch
+		for _, prov := range branch.Provenance {
+			provenance = append(provenance, client.NewBranch(prov.Repo, prov.Name))
+		}
+		if err := im.pachClient.CreateBranch(branch.Repo, branch.Name, "", "", provenance); err != nil {
+			return err
+		}
+		bi, err := im.pachClient.InspectBranch(branch.Repo, branch.Name)
+		if err != nil {
+			return err
+		}
+		if err := im.finishCommitSet(bi.Head.ID); err != nil {
+			return err
+		}
+		im.logf("created %v@%v", branch.Repo, branch.Name)
+	}
+	return nil
+}
+
+// finishCommitSet finishes the open commits in a commit set.
+func (im *Importer) finishCommitSet(id string) error {
+	commitInfos, err := im.pachClient.InspectCommitSet(id)
+	if err != nil {
+		return err
+	}
+	for _, ci := range commitInfos {
+		if ci.Finished == nil {
+			if err := im.pachClient.FinishCommit(ci.Commit.Branch.Repo.Name, ci.Commit.Branch.Name, ci.Commit.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importCommitSet imports root, which isn't provenant on other commits, and
+// the commits that are imported in its commit set.
+func (im *Importer) importCommitSet(ctx context.Context, h *history, root *SourceCommit) error {
+	key := commitKey(root.Repo, root.ID)
+	state, ok := im.state[key]
+	if ok && state.Done {
+		im.logf("skipping %v, already imported", key)
+		return nil
+	}
+	if ok {
+		im.logf("resuming %v in commit %v", key, state.Commit)
+	} else {
+		req := &pfs.StartCommitRequest{
+			Branch:      client.NewBranch(root.Repo, root.Branch),
+			Description: root.Description,
+		}
+		if root.Parent != "" {
+			parent, err := im.importedCommit(h, root.Repo, root.Parent)
+			if err != nil {
+				return err
+			}
+			req.Parent = parent
+		}
+		commit, err := im.pachClient.PfsAPIClient.StartCommit(im.pachClient.Ctx(), req)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		state = &commitState{Commit: commit.ID}
+		im.state[key] = state
+		if err := im.saveState(); err != nil {
+			return err
+		}
+		im.logf("importing %v in commit %v", key, commit.ID)
+	}
+	// The commit set's commits are listed with the upstream ones first.
+	commitInfos, err := im.pachClient.InspectCommitSet(state.Commit)
+	if err != nil {
+		return err
+	}
+	for _, ci := range commitInfos {
+		if ci.Finished != nil {
+			continue
+		}
+		branch := ci.Commit.Branch
+		c, cState := root, state
+		if branch.Repo.Name != root.Repo || branch.Name != root.Branch {
+			c = h.downstream[downstreamKey(branch.Repo.Name, branch.Name, root)]
+			if c != nil {
+				ckey := commitKey(c.Repo, c.ID)
+				if cState = im.state[ckey]; cState == nil {
+					cState = &commitState{Commit: state.Commit}
+					im.state[ckey] = cState
+				}
+			}
+		}
+		if c != nil {
+			if err := im.importCommit(ctx, h, c, ci, cState); err != nil {
+				return errors.Wrapf(err, "error importing %v", commitKey(c.Repo, c.ID))
+			}
+		}
+		if err := im.pachClient.FinishCommit(branch.Repo.Name, branch.Name, ci.Commit.ID); err != nil {
+			return err
+		}
+		if c != nil {
+			cState.Done = true
+			if err := im.saveState(); err != nil {
+				return err
+			}
+		}
+	}
+	state.Done = true
+	return im.saveState()
+}
+
+// importedCommit returns the commit that the source commit id in repo was
+// imported as.
+func (im *Importer) importedCommit(h *history, repo, id string) (*pfs.Commit, error) {
+	key := commitKey(repo, id)
+	c, ok := h.commits[key]
+	state, imported := im.state[key]
+	if !ok || !imported || !state.Done {
+		return nil, errors.Errorf("%v has not been imported", key)
+	}
+	return client.NewCommit(repo, c.Branch, state.Commit), nil
+}
+
+// importCommit writes the files of c into the open commit ci. Only the files
+// that changed since c's parent are written, if ci's parent is the commit
+// that c's parent was imported as. Otherwise ci is cleared, and all of c's
+// files are written.
+func (im *Importer) importCommit(ctx context.Context, h *history, c *SourceCommit, ci *pfs.CommitInfo, state *commitState) error {
+	base := make(map[string]*SourceFile)
+	reset := true
+	if parent, ok := h.commits[commitKey(c.Repo, c.Parent)]; ok {
+		if parentState, ok := im.state[commitKey(c.Repo, c.Parent)]; ok && parentState.Done && ci.ParentCommit != nil && ci.ParentCommit.ID == parentState.Commit {
+			reset = false
+			if err := im.src.WalkFiles(ctx, parent, func(f *SourceFile) error {
+				base[f.Path] = f
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	files := make(map[string]bool)
+	var changed []*SourceFile
+	if err := im.src.WalkFiles(ctx, c, func(f *SourceFile) error {
+		files[f.Path] = true
+		if b, ok := base[f.Path]; !ok || !sameSourceFile(b, f) {
+			changed = append(changed, f)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Path < changed[j].Path })
+	// Deleting is idempotent, so it's redone until the first batch of files
+	// is uploaded.
+	if state.Files == 0 {
+		if err := im.pachClient.WithModifyFileClient(ci.Commit, func(mf client.ModifyFile) error {
+			if reset {
+				return mf.DeleteFile("/")
+			}
+			for path := range base {
+				if !files[path] {
+					if err := mf.DeleteFile(path); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if state.Files > len(changed) {
+		return errors.Errorf("the import state records %d uploaded files, but only %d changed", state.Files, len(changed))
+	}
+	for changed = changed[state.Files:]; len(changed) > 0; {
+		n := im.BatchSize
+		if n > len(changed) {
+			n = len(changed)
+		}
+		if err := im.putFiles(ctx, c, ci.Commit, changed[:n]); err != nil {
+			return err
+		}
+		changed = changed[n:]
+		state.Files += n
+		if err := im.saveState(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameSourceFile returns true if a and b have the same content, according to
+// the source.
+func sameSourceFile(a, b *SourceFile) bool {
+	return a.Size == b.Size && len(a.Hash) > 0 && bytes.Equal(a.Hash, b.Hash)
+}
+
+func (im *Importer) putFiles(ctx context.Context, c *SourceCommit, commit *pfs.Commit, files []*SourceFile) error {
+	if err := im.pachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+		for _, f := range files {
+			if err := func() (retErr error) {
+				r, err := im.src.GetFile(ctx, c, f.Path)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := r.Close(); retErr == nil {
+						retErr = errors.EnsureStack(err)
+					}
+				}()
+				return mf.PutFile(f.Path, r)
+			}(); err != nil {
+				return errors.Wrapf(err, "error importing %v", f.Path)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	im.logf("imported %d files into %v", len(files), commit)
+	return nil
+}
+
+// Verify compares every imported commit with the source, and returns an error
+// describing the files that are missing or that differ in size or content.
+// Contents are compared by their SHA-256 hash, which is computed on both
+// sides once for each pair of source and destination hashes, since a file
+// that's unchanged between commits has the same hashes in both.
+func (im *Importer) Verify(ctx context.Context) error {
+	h, err := im.loadHistory(ctx)
+	if err != nil {
+		return err
+	}
+	verified := make(map[string]bool)
+	var mismatches []string
+	for _, key := range h.keys {
+		c := h.commits[key]
+		state, ok := im.state[key]
+		if !ok || !state.Done {
+			mismatches = append(mismatches, fmt.Sprintf("%v has not been imported", key))
+			continue
+		}
+		commit := client.NewCommit(c.Repo, c.Branch, state.Commit)
+		imported := make(map[string]*pfs.FileInfo)
+		if err := im.pachClient.WalkFile(commit, "/", func(fi *pfs.FileInfo) error {
+			if fi.FileType == pfs.FileType_FILE {
+				imported[fi.File.Path] = fi
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := im.src.WalkFiles(ctx, c, func(f *SourceFile) error {
+			fi, ok := imported[f.Path]
+			delete(imported, f.Path)
+			switch {
+			case !ok:
+				mismatches = append(mismatches, fmt.Sprintf("%v:%v is missing", key, f.Path))
+				return nil
+			case int64(fi.SizeBytes) != f.Size:
+				mismatches = append(mismatches, fmt.Sprintf("%v:%v has size %d, expected %d", key, f.Path, fi.SizeBytes, f.Size))
+				return nil
+			}
+			pair := hex.EncodeToString(f.Hash) + "/" + hex.EncodeToString(fi.Hash)
+			if len(f.Hash) > 0 && len(fi.Hash) > 0 && verified[pair] {
+				return nil
+			}
+			same, err := im.sameContent(ctx, c, commit, f.Path)
+			if err != nil {
+				return err
+			}
+			if !same {
+				mismatches = append(mismatches, fmt.Sprintf("%v:%v differs from the source", key, f.Path))
+			} else if len(f.Hash) > 0 && len(fi.Hash) > 0 {
+				verified[pair] = true
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for path := range imported {
+			mismatches = append(mismatches, fmt.Sprintf("%v:%v does not exist in the source", key, path))
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return errors.Errorf("verification found %d problem(s):\n%v", len(mismatches), "  "+strings.Join(mismatches, "\n  "))
+	}
+	return nil
+}
+
+// sameContent returns true if the file at path has the same SHA-256 hash in
+// the source commit c and in commit.
+func (im *Importer) sameContent(ctx context.Context, c *SourceCommit, commit *pfs.Commit, path string) (_ bool, retErr error) {
+	r, err := im.src.GetFile(ctx, c, path)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := r.Close(); retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	srcHash := sha256.New()
+	if _, err := io.Copy(srcHash, r); err != nil {
+		return false, errors.EnsureStack(err)
+	}
+	destHash := sha256.New()
+	if err := im.pachClient.GetFile(commit, path, destHash); err != nil {
+		return false, err
+	}
+	return bytes.Equal(srcHash.Sum(nil), destHash.Sum(nil)), nil
+}
+
+func (im *Importer) saveState() error {
+	data, err := json.MarshalIndent(im.state, "", "  ")
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	tmp := im.statePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.EnsureStack(err)
+	}
+	return errors.EnsureStack(os.Rename(tmp, im.statePath))
+}
+
+func (im *Importer) logf(format string, args ...interface{}) {
+	if im.Log != nil {
+		im.Log(format, args...)
+	}
+}
@@ -0,0 +1,211 @@
+package pfsimport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/internal/testpachd"
+	tu "github.com/pachyderm/pachyderm/v2/src/internal/testutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// testSource is a Source that holds its files in memory.
+type testSource struct {
+	branches []*SourceBranch
+	commits  []*SourceCommit
+	// files are keyed by commit key and then path.
+	files map[string]map[string]string
+	// failing is the paths whose GetFile fails.
+	failing map[string]bool
+}
+
+func (s *testSource) Branches(ctx context.Context) ([]*SourceBranch, error) {
+	return s.branches, nil
+}
+
+func (s *testSource) Commits(ctx context.Context, repo string) ([]*SourceCommit, error) {
+	var result []*SourceCommit
+	for _, c := range s.commits {
+		if c.Repo == repo {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (s *testSource) WalkFiles(ctx context.Context, commit *SourceCommit, cb func(*SourceFile) error) error {
+	for path, content := range s.files[commitKey(commit.Repo, commit.ID)] {
+		hash := sha256.Sum256([]byte(content))
+		if err := cb(&SourceFile{Path: path, Size: int64(len(content)), Hash: hash[:]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testSource) GetFile(ctx context.Context, commit *SourceCommit, path string) (io.ReadCloser, error) {
+	if s.failing[path] {
+		return nil, errors.Errorf("error reading %v", path)
+	}
+	return ioutil.NopCloser(strings.NewReader(s.files[commitKey(commit.Repo, commit.ID)][path])), nil
+}
+
+// newTestSource returns a source with a repo "in", whose master branch has
+// two commits and whose dev branch forks from the first of them, and a repo
+// "out" whose master branch is provenant on in@master.
+func newTestSource() *testSource {
+	started := time.Now().Add(-time.Hour)
+	at := func(minutes int) time.Time { return started.Add(time.Duration(minutes) * time.Minute) }
+	return &testSource{
+		branches: []*SourceBranch{
+			{Repo: "out", Name: "master", Head: "d2", Provenance: []*SourceBranch{{Repo: "in", Name: "master"}}},
+			{Repo: "in", Name: "master", Head: "c2"},
+			{Repo: "in", Name: "dev", Head: "c3"},
+		},
+		commits: []*SourceCommit{
+			{Repo: "in", Branch: "master", ID: "c1", Description: "first", Started: at(0)},
+			{Repo: "in", Branch: "master", ID: "c2", Parent: "c1", Started: at(2)},
+			{Repo: "in", Branch: "dev", ID: "c3", Parent: "c1", Started: at(4)},
+			{Repo: "out", Branch: "master", ID: "d1", Started: at(1), Provenance: []*SourceCommit{{Repo: "in", ID: "c1"}}},
+			{Repo: "out", Branch: "master", ID: "d2", Parent: "d1", Started: at(3), Provenance: []*SourceCommit{{Repo: "in", ID: "c2"}}},
+		},
+		files: map[string]map[string]string{
+			"in@c1":  {"/a": "foo", "/b": "bar"},
+			"in@c2":  {"/a": "foo2", "/c": "baz"},
+			"in@c3":  {"/a": "foo", "/b": "bar", "/d": "qux"},
+			"out@d1": {"/x": "1"},
+			"out@d2": {"/x": "22"},
+		},
+		failing: make(map[string]bool),
+	}
+}
+
+func requireFiles(t *testing.T, c *client.APIClient, commit *pfs.Commit, expected map[string]string) {
+	t.Helper()
+	actual := make(map[string]string)
+	require.NoError(t, c.WalkFile(commit, "/", func(fi *pfs.FileInfo) error {
+		if fi.FileType != pfs.FileType_FILE {
+			return nil
+		}
+		buf := &bytes.Buffer{}
+		if err := c.GetFile(commit, fi.File.Path, buf); err != nil {
+			return err
+		}
+		actual[fi.File.Path] = buf.String()
+		return nil
+	}))
+	require.Equal(t, expected, actual)
+}
+
+func TestImport(t *testing.T) {
+	env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+	src := newTestSource()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	im, err := NewImporter(src, env.PachClient, statePath)
+	require.NoError(t, err)
+	require.NoError(t, im.Import(context.Background()))
+	require.NoError(t, im.Verify(context.Background()))
+
+	// Commits are imported on top of their parents.
+	master, err := env.PachClient.InspectCommit("in", "master", "")
+	require.NoError(t, err)
+	requireFiles(t, env.PachClient, master.Commit, map[string]string{"/a": "foo2", "/c": "baz"})
+	first, err := env.PachClient.InspectCommit("in", "master", master.ParentCommit.ID)
+	require.NoError(t, err)
+	require.Equal(t, "first", first.Description)
+	requireFiles(t, env.PachClient, first.Commit, map[string]string{"/a": "foo", "/b": "bar"})
+	dev, err := env.PachClient.InspectCommit("in", "dev", "")
+	require.NoError(t, err)
+	require.Equal(t, first.Commit.ID, dev.ParentCommit.ID)
+	requireFiles(t, env.PachClient, dev.Commit, map[string]string{"/a": "foo", "/b": "bar", "/d": "qux"})
+
+	// Downstream commits are imported in the commit sets of the commits
+	// they're provenant on.
+	out, err := env.PachClient.InspectCommit("out", "master", "")
+	require.NoError(t, err)
+	require.Equal(t, master.Commit.ID, out.Commit.ID)
+	requireFiles(t, env.PachClient, out.Commit, map[string]string{"/x": "22"})
+	out, err = env.PachClient.InspectCommit("out", "master", first.Commit.ID)
+	require.NoError(t, err)
+	requireFiles(t, env.PachClient, out.Commit, map[string]string{"/x": "1"})
+
+	// Importing again does nothing.
+	im, err = NewImporter(src, env.PachClient, statePath)
+	require.NoError(t, err)
+	require.NoError(t, im.Import(context.Background()))
+	again, err := env.PachClient.InspectCommit("in", "master", "")
+	require.NoError(t, err)
+	require.Equal(t, master.Commit.ID, again.Commit.ID)
+}
+
+func TestImportResume(t *testing.T) {
+	env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+	src := newTestSource()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	// The import fails partway through the second commit.
+	src.failing["/c"] = true
+	im, err := NewImporter(src, env.PachClient, statePath)
+	require.NoError(t, err)
+	im.BatchSize = 1
+	require.YesError(t, im.Import(context.Background()))
+	require.YesError(t, im.Verify(context.Background()))
+	open, err := env.PachClient.InspectCommit("in", "master", "")
+	require.NoError(t, err)
+	require.Nil(t, open.Finished)
+
+	// It's resumed in the same commit.
+	delete(src.failing, "/c")
+	im, err = NewImporter(src, env.PachClient, statePath)
+	require.NoError(t, err)
+	require.NoError(t, im.Import(context.Background()))
+	require.NoError(t, im.Verify(context.Background()))
+	master, err := env.PachClient.InspectCommit("in", "master", "")
+	require.NoError(t, err)
+	require.Equal(t, open.Commit.ID, master.Commit.ID)
+	requireFiles(t, env.PachClient, master.Commit, map[string]string{"/a": "foo2", "/c": "baz"})
+}
+
+func TestVerifyContent(t *testing.T) {
+	env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+	src := newTestSource()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	im, err := NewImporter(src, env.PachClient, statePath)
+	require.NoError(t, err)
+	require.NoError(t, im.Import(context.Background()))
+
+	// A file whose content differs from the source, but whose size doesn't,
+	// fails verification.
+	src.files["in@c2"]["/a"] = "foo3"
+	err = im.Verify(context.Background())
+	require.YesError(t, err)
+	require.Matches(t, "in@c2:/a differs from the source", err.Error())
+}
+
+func TestPachctlSourceVersion(t *testing.T) {
+	dir := t.TempDir()
+	fakePachctl := func(name, version string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\necho "+version+"\n"), 0755))
+		return path
+	}
+	_, err := NewPachctlSource(context.Background(), fakePachctl("pachctl-1x", "1.13.4"), "")
+	require.NoError(t, err)
+	_, err = NewPachctlSource(context.Background(), fakePachctl("pachctl-2x", "2.1.0"), "")
+	require.YesError(t, err)
+	require.Matches(t, "1.x pachctl is required", err.Error())
+	_, err = NewPachctlSource(context.Background(), filepath.Join(dir, "missing"), "")
+	require.YesError(t, err)
+}
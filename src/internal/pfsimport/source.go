@@ -0,0 +1,280 @@
+package pfsimport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// SourceBranch is a branch in the source cluster.
+type SourceBranch struct {
+	Repo string
+	Name string
+	// Head is the ID of the branch's head commit, or empty if it has none.
+	Head string
+	// Provenance is the branches, in other repos, that the branch is directly
+	// provenant on.
+	Provenance []*SourceBranch
+}
+
+// SourceCommit is a commit in the source cluster.
+type SourceCommit struct {
+	Repo   string
+	Branch string
+	ID     string
+	// Parent is the ID of the commit's parent, in the same repo, or empty if
+	// it has none.
+	Parent      string
+	Description string
+	Started     time.Time
+	// Provenance is the commits, in other repos, that the commit is
+	// provenant on. Only their Repo and ID are set.
+	Provenance []*SourceCommit
+}
+
+// SourceFile is a file in the source cluster.
+type SourceFile struct {
+	Path string
+	Size int64
+	// Hash is the source's hash of the file's content. It's only compared
+	// with the hashes of other files in the source.
+	Hash []byte
+}
+
+// Source is a cluster that repos are imported from.
+type Source interface {
+	// Branches returns every branch in the source.
+	Branches(ctx context.Context) ([]*SourceBranch, error)
+	// Commits returns every commit in a repo.
+	Commits(ctx context.Context, repo string) ([]*SourceCommit, error)
+	// WalkFiles calls cb with every file in a commit.
+	WalkFiles(ctx context.Context, commit *SourceCommit, cb func(*SourceFile) error) error
+	// GetFile returns the contents of a file in a commit.
+	GetFile(ctx context.Context, commit *SourceCommit, path string) (io.ReadCloser, error)
+}
+
+type pachctlSource struct {
+	pachctl string
+	config  string
+}
+
+// NewPachctlSource returns a Source that reads a Pachyderm 1.x cluster with
+// the 1.x pachctl binary at pachctl, since the 1.x API can't be called with
+// this version's client. If config is set, it's the 1.x pachctl config file
+// to use, otherwise the binary uses its default one. It returns an error if
+// pachctl can't be run, or isn't a 1.x pachctl, whose output the source
+// can't parse.
+func NewPachctlSource(ctx context.Context, pachctl, config string) (Source, error) {
+	s := &pachctlSource{pachctl: pachctl, config: config}
+	cmd := s.command(ctx, "version", "--client-only")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error running %s, a Pachyderm 1.x pachctl is required to import from 1.x: %s", pachctl, stderr)
+	}
+	version := strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+	if major := strings.SplitN(version, ".", 2)[0]; major != "1" {
+		return nil, errors.Errorf("%s is pachctl %q, but a Pachyderm 1.x pachctl is required to import from 1.x", pachctl, version)
+	}
+	return s, nil
+}
+
+// The types below are the parts of 1.x's PFS messages, as pachctl prints them
+// with --raw, that imports need.
+
+type rawRepo struct {
+	Name string `json:"name"`
+}
+
+type rawBranch struct {
+	Repo rawRepo `json:"repo"`
+	Name string  `json:"name"`
+}
+
+type rawCommit struct {
+	Repo rawRepo `json:"repo"`
+	ID   string  `json:"id"`
+}
+
+type rawRepoInfo struct {
+	Repo rawRepo `json:"repo"`
+}
+
+type rawBranchInfo struct {
+	Branch           rawBranch   `json:"branch"`
+	Head             *rawCommit  `json:"head"`
+	DirectProvenance []rawBranch `json:"directProvenance"`
+}
+
+type rawCommitInfo struct {
+	Commit       rawCommit  `json:"commit"`
+	Branch       *rawBranch `json:"branch"`
+	ParentCommit *rawCommit `json:"parentCommit"`
+	Description  string     `json:"description"`
+	Started      time.Time  `json:"started"`
+	Provenance   []struct {
+		Commit rawCommit `json:"commit"`
+	} `json:"provenance"`
+}
+
+type rawFileInfo struct {
+	File struct {
+		Path string `json:"path"`
+	} `json:"file"`
+	FileType  string `json:"fileType"`
+	SizeBytes int64  `json:"sizeBytes,string"`
+	Hash      []byte `json:"hash"`
+}
+
+func (s *pachctlSource) Branches(ctx context.Context) ([]*SourceBranch, error) {
+	var repos []*rawRepoInfo
+	if err := s.list(ctx, func() interface{} {
+		ri := &rawRepoInfo{}
+		repos = append(repos, ri)
+		return ri
+	}, "list", "repo", "--raw"); err != nil {
+		return nil, err
+	}
+	var result []*SourceBranch
+	for _, ri := range repos {
+		var branches []*rawBranchInfo
+		if err := s.list(ctx, func() interface{} {
+			bi := &rawBranchInfo{}
+			branches = append(branches, bi)
+			return bi
+		}, "list", "branch", ri.Repo.Name, "--raw"); err != nil {
+			return nil, err
+		}
+		for _, bi := range branches {
+			branch := &SourceBranch{Repo: bi.Branch.Repo.Name, Name: bi.Branch.Name}
+			if bi.Head != nil {
+				branch.Head = bi.Head.ID
+			}
+			for _, prov := range bi.DirectProvenance {
+				branch.Provenance = append(branch.Provenance, &SourceBranch{Repo: prov.Repo.Name, Name: prov.Name})
+			}
+			result = append(result, branch)
+		}
+	}
+	return result, nil
+}
+
+func (s *pachctlSource) Commits(ctx context.Context, repo string) ([]*SourceCommit, error) {
+	var commits []*rawCommitInfo
+	if err := s.list(ctx, func() interface{} {
+		ci := &rawCommitInfo{}
+		commits = append(commits, ci)
+		return ci
+	}, "list", "commit", repo, "--raw"); err != nil {
+		return nil, err
+	}
+	var result []*SourceCommit
+	for _, ci := range commits {
+		commit := &SourceCommit{
+			Repo:        ci.Commit.Repo.Name,
+			ID:          ci.Commit.ID,
+			Description: ci.Description,
+			Started:     ci.Started,
+		}
+		if ci.Branch != nil {
+			commit.Branch = ci.Branch.Name
+		}
+		if ci.ParentCommit != nil {
+			commit.Parent = ci.ParentCommit.ID
+		}
+		for _, prov := range ci.Provenance {
+			// Commits list themselves in their provenance in some 1.x
+			// versions.
+			if prov.Commit.Repo.Name != repo {
+				commit.Provenance = append(commit.Provenance, &SourceCommit{Repo: prov.Commit.Repo.Name, ID: prov.Commit.ID})
+			}
+		}
+		result = append(result, commit)
+	}
+	return result, nil
+}
+
+func (s *pachctlSource) WalkFiles(ctx context.Context, commit *SourceCommit, cb func(*SourceFile) error) error {
+	var files []*rawFileInfo
+	if err := s.list(ctx, func() interface{} {
+		fi := &rawFileInfo{}
+		files = append(files, fi)
+		return fi
+	}, "glob", "file", commit.Repo+"@"+commit.ID+":/**", "--raw"); err != nil {
+		return err
+	}
+	for _, fi := range files {
+		if fi.FileType != "FILE" {
+			continue
+		}
+		if err := cb(&SourceFile{Path: fi.File.Path, Size: fi.SizeBytes, Hash: fi.Hash}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *pachctlSource) GetFile(ctx context.Context, commit *SourceCommit, path string) (io.ReadCloser, error) {
+	cmd := s.command(ctx, "get", "file", commit.Repo+"@"+commit.ID+":"+path)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	return &commandReader{ReadCloser: stdout, cmd: cmd, stderr: stderr}, nil
+}
+
+// list runs pachctl with args, and decodes each message that it prints into
+// the value that next returns.
+func (s *pachctlSource) list(ctx context.Context, next func() interface{}, args ...string) error {
+	cmd := s.command(ctx, args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "error running pachctl %v: %s", args, stderr)
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		if err := dec.Decode(next()); err != nil {
+			return errors.Wrapf(err, "error parsing the output of pachctl %v", args)
+		}
+	}
+	return nil
+}
+
+func (s *pachctlSource) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, s.pachctl, args...)
+	if s.config != "" {
+		cmd.Env = append(os.Environ(), "PACH_CONFIG="+s.config)
+	}
+	return cmd
+}
+
+// commandReader reads the output of a command, and waits for it to exit when
+// it's closed.
+type commandReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func (r *commandReader) Close() error {
+	r.ReadCloser.Close()
+	if err := r.cmd.Wait(); err != nil {
+		return errors.Wrapf(err, "error running pachctl %v: %s", r.cmd.Args[1:], r.stderr)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package pfsload
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/randutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// The operations that a benchmark can run.
+const (
+	BenchPutFile  = "putFile"
+	BenchGetFile  = "getFile"
+	BenchListFile = "listFile"
+)
+
+const BenchSpecification string = `
+Specification:
+
+-- BenchSpec --
+
+count: int
+concurrency: int
+operations: [ BenchOperationSpec ]
+size: [ SizeSpec ]
+
+-- BenchOperationSpec --
+
+type: string (putFile | getFile | listFile)
+prob: int [0, 100]
+
+-- SizeSpec --
+
+min: int
+max: int
+prob: int [0, 100]
+
+Example:
+
+count: 1000
+concurrency: 8
+operations:
+  - type: putFile
+    prob: 50
+  - type: getFile
+    prob: 40
+  - type: listFile
+    prob: 10
+size:
+  - min: 1000
+    max: 10000
+    prob: 90
+  - min: 1000000
+    max: 10000000
+    prob: 10
+`
+
+// BenchSpec specifies a benchmark: count operations, drawn from a mix of
+// operations, run by concurrency workers. The sizes of the files written
+// are drawn from SizeSpecs.
+type BenchSpec struct {
+	Count               int                   `yaml:"count,omitempty"`
+	Concurrency         int                   `yaml:"concurrency,omitempty"`
+	BenchOperationSpecs []*BenchOperationSpec `yaml:"operations,omitempty"`
+	SizeSpecs           []*SizeSpec           `yaml:"size,omitempty"`
+}
+
+// BenchOperationSpec is an operation in a benchmark's mix, chosen with
+// probability Prob.
+type BenchOperationSpec struct {
+	Type string `yaml:"type,omitempty"`
+	Prob int    `yaml:"prob,omitempty"`
+}
+
+// DefaultBenchSpec returns the spec used when none is given.
+func DefaultBenchSpec() *BenchSpec {
+	return &BenchSpec{
+		Count:       1000,
+		Concurrency: 8,
+		BenchOperationSpecs: []*BenchOperationSpec{
+			{Type: BenchPutFile, Prob: 50},
+			{Type: BenchGetFile, Prob: 40},
+			{Type: BenchListFile, Prob: 10},
+		},
+		SizeSpecs: []*SizeSpec{
+			{Min: 1000, Max: 10000, Prob: 90},
+			{Min: 1000000, Max: 10000000, Prob: 10},
+		},
+	}
+}
+
+// BenchResult is the outcome of a benchmark.
+type BenchResult struct {
+	Duration   time.Duration
+	Operations []*BenchOperationResult
+}
+
+// BenchOperationResult summarizes the runs of one type of operation. The
+// throughputs are averaged over the whole benchmark.
+type BenchOperationResult struct {
+	Type           string
+	Count          int
+	Bytes          int64
+	P50, P90, P99  time.Duration
+	Max            time.Duration
+	OpsPerSecond   float64
+	BytesPerSecond float64
+	latencies      []time.Duration
+}
+
+// Bench runs the benchmark in spec against repo. Each worker writes to its
+// own branch, so that workers don't contend for the same open commit, and
+// reads back the files that it wrote.
+func Bench(pachClient *client.APIClient, repo string, spec *BenchSpec, seed int64) (*BenchResult, error) {
+	if spec.Concurrency <= 0 {
+		return nil, errors.Errorf("concurrency must be positive")
+	}
+	if err := validateBenchSpec(spec); err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	results := make(map[string]*BenchOperationResult)
+	record := func(op string, latency time.Duration, n int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		r, ok := results[op]
+		if !ok {
+			r = &BenchOperationResult{Type: op}
+			results[op] = r
+		}
+		r.Count++
+		r.Bytes += n
+		r.latencies = append(r.latencies, latency)
+	}
+	start := time.Now()
+	var eg errgroup.Group
+	for i := 0; i < spec.Concurrency; i++ {
+		w := &benchWorker{
+			client: pachClient,
+			branch: client.NewBranch(repo, fmt.Sprintf("bench-%d", i)),
+			random: rand.New(rand.NewSource(seed + int64(i))),
+			spec:   spec,
+			record: record,
+		}
+		count := spec.Count / spec.Concurrency
+		if i < spec.Count%spec.Concurrency {
+			count++
+		}
+		eg.Go(func() error {
+			return w.run(count)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	result := &BenchResult{Duration: time.Since(start)}
+	for _, opSpec := range spec.BenchOperationSpecs {
+		r, ok := results[opSpec.Type]
+		if !ok {
+			continue
+		}
+		sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+		r.P50 = percentile(r.latencies, 50)
+		r.P90 = percentile(r.latencies, 90)
+		r.P99 = percentile(r.latencies, 99)
+		r.Max = r.latencies[len(r.latencies)-1]
+		r.OpsPerSecond = float64(r.Count) / result.Duration.Seconds()
+		r.BytesPerSecond = float64(r.Bytes) / result.Duration.Seconds()
+		result.Operations = append(result.Operations, r)
+	}
+	return result, nil
+}
+
+func validateBenchSpec(spec *BenchSpec) error {
+	var totalProb int
+	for _, opSpec := range spec.BenchOperationSpecs {
+		switch opSpec.Type {
+		case BenchPutFile, BenchGetFile, BenchListFile:
+		default:
+			return errors.Errorf("unknown benchmark operation %q", opSpec.Type)
+		}
+		if err := validateProb(opSpec.Prob); err != nil {
+			return err
+		}
+		totalProb += opSpec.Prob
+	}
+	if totalProb != 100 {
+		return errors.Errorf("operation probabilities must add up to 100")
+	}
+	return nil
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+type benchWorker struct {
+	client *client.APIClient
+	branch *pfs.Branch
+	random *rand.Rand
+	spec   *BenchSpec
+	record func(op string, latency time.Duration, n int64)
+	files  []string
+}
+
+func (w *benchWorker) run(count int) error {
+	if err := w.client.CreateBranch(w.branch.Repo.Name, w.branch.Name, "", "", nil); err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		op := w.nextOperation()
+		// Reads need a file to read, so the first operation is always a write.
+		if len(w.files) == 0 {
+			op = BenchPutFile
+		}
+		if err := w.runOperation(op); err != nil {
+			return errors.Wrapf(err, "%s", op)
+		}
+	}
+	return nil
+}
+
+func (w *benchWorker) nextOperation() string {
+	prob := w.random.Intn(100)
+	var totalProb int
+	for _, opSpec := range w.spec.BenchOperationSpecs {
+		totalProb += opSpec.Prob
+		if prob < totalProb {
+			return opSpec.Type
+		}
+	}
+	panic("should not be able to reach here")
+}
+
+func (w *benchWorker) runOperation(op string) error {
+	commit := w.branch.NewCommit("")
+	var n int64
+	start := time.Now()
+	switch op {
+	case BenchPutFile:
+		sizeSpec, err := FuzzSize(w.spec.SizeSpecs, w.random)
+		if err != nil {
+			return err
+		}
+		size := sizeSpec.Min
+		if sizeSpec.Max > sizeSpec.Min {
+			size += w.random.Intn(sizeSpec.Max - sizeSpec.Min)
+		}
+		data := randutil.Bytes(w.random, size)
+		p := fmt.Sprintf("/%016d", len(w.files))
+		start = time.Now()
+		if err := w.client.PutFile(commit, p, bytes.NewReader(data)); err != nil {
+			return err
+		}
+		w.files = append(w.files, p)
+		n = int64(size)
+	case BenchGetFile:
+		p := w.files[w.random.Intn(len(w.files))]
+		counter := &countingWriter{}
+		if err := w.client.GetFile(commit, p, counter); err != nil {
+			return err
+		}
+		n = counter.n
+	case BenchListFile:
+		if err := w.client.ListFile(commit, "/", func(*pfs.FileInfo) error {
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	w.record(op, time.Since(start), n)
+	return nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
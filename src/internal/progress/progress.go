@@ -190,9 +190,7 @@ func (f *File) Write(p []byte) (int, error) {
 // bytes written to the progress bar
 func (f *File) WriteAt(b []byte, offset int64) (int, error) {
 	n, err := f.File.WriteAt(b, offset)
-	if err == nil {
-		f.setProgress(int(offset) + n)
-	}
+	f.add(n)
 	return n, err
 }
 
@@ -1,5 +1,11 @@
 package serviceenv
 
+import (
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
 // Configuration is the generic configuration structure used to access configuration fields.
 type Configuration struct {
 	*GlobalConfiguration
@@ -61,6 +67,7 @@ type PachdFullConfiguration struct {
 // PachdSpecificConfiguration contains the pachd specific configuration.
 type PachdSpecificConfiguration struct {
 	StorageConfiguration
+	PathValidationConfiguration
 	StorageBackend             string `env:"STORAGE_BACKEND,required"`
 	StorageHostPath            string `env:"STORAGE_HOST_PATH,default="`
 	PFSEtcdPrefix              string `env:"PFS_ETCD_PREFIX,default=pachyderm_pfs"`
@@ -75,6 +82,45 @@ type PachdSpecificConfiguration struct {
 	MemoryRequest              string `env:"PACHD_MEMORY_REQUEST,default=1T"`
 	WorkerUsesRoot             bool   `env:"WORKER_USES_ROOT,default=true"`
 	RequireCriticalServersOnly bool   `env:"REQUIRE_CRITICAL_SERVERS_ONLY,default=false"`
+	// SnapshotInterval is how often a snapshot of the cluster's metadata is
+	// written to object storage (e.g. "10m"). Snapshots are disabled if empty.
+	SnapshotInterval string `env:"SNAPSHOT_INTERVAL,default="`
+	// RestoreSnapshot restores the latest metadata snapshot at startup, if
+	// the database doesn't contain any repos.
+	RestoreSnapshot bool `env:"RESTORE_SNAPSHOT,default=false"`
+	// SnapshotRetention is how many full metadata snapshots are kept, along
+	// with the incremental snapshots that follow them. All snapshots are
+	// kept if 0.
+	SnapshotRetention int `env:"SNAPSHOT_RETENTION,default=7"`
+	// FsckInterval is how often the pfs master runs fsck in the background
+	// (e.g. "1h"), reporting what it finds in the logs and as Prometheus
+	// metrics. Background fsck is disabled if empty.
+	FsckInterval string `env:"FSCK_INTERVAL,default="`
+	// ColdTieringInterval is how often the pfs master moves the data of
+	// commits older than their repo's cold_storage_after setting to
+	// StorageColdURL (e.g. "24h"). Tiering is disabled if empty.
+	ColdTieringInterval string `env:"COLD_TIERING_INTERVAL,default="`
+	// CommitRetentionInterval is how often the pfs master squashes the
+	// commits that are beyond their branch's retention policy (e.g. "10m").
+	// Retention isn't enforced if empty.
+	CommitRetentionInterval string `env:"COMMIT_RETENTION_INTERVAL,default=10m"`
+	// FileInfoStreamBuffer is the maximum number of FileInfos that ListFile
+	// and WalkFile queue for a stream ahead of a slow client. Iteration over
+	// the index pauses once the queue is full. Sends are synchronous if 0.
+	FileInfoStreamBuffer int `env:"PFS_FILE_INFO_STREAM_BUFFER,default=100"`
+	// UploadMaxStreams is the maximum number of ModifyFile and CreateFileSet
+	// streams that pachd serves at once. Streams over the limit fail with
+	// ResourceExhausted. Unlimited if 0.
+	UploadMaxStreams int `env:"PFS_UPLOAD_MAX_STREAMS,default=0"`
+	// UploadMaxStreamsPerUser is the maximum number of ModifyFile and
+	// CreateFileSet streams that each user may have open at once, when auth
+	// is active. Unlimited if 0.
+	UploadMaxStreamsPerUser int `env:"PFS_UPLOAD_MAX_STREAMS_PER_USER,default=0"`
+	// UploadMaxInFlightBytes is the maximum number of bytes of uploaded file
+	// content that pachd holds in memory at once, across all streams, whether
+	// it was sent in the stream or read from a URL, until it's written out.
+	// Uploads over the limit fail with ResourceExhausted. Unlimited if 0.
+	UploadMaxInFlightBytes int64 `env:"PFS_UPLOAD_MAX_IN_FLIGHT_BYTES,default=0"`
 	// TODO: Merge this with the worker specific pod name (PPS_POD_NAME) into a global configuration pod name.
 	PachdPodName string `env:"PACHD_POD_NAME,required"`
 }
@@ -92,6 +138,57 @@ type StorageConfiguration struct {
 	StorageFileSetsMaxOpen         int    `env:"STORAGE_FILESETS_MAX_OPEN,default=50"`
 	StorageDiskCacheSize           int    `env:"STORAGE_DISK_CACHE_SIZE,default=100"`
 	StorageMemoryCacheSize         int    `env:"STORAGE_MEMORY_CACHE_SIZE,default=100"`
+	// StorageReadReplicas is a comma-separated list of object store URLs
+	// (e.g. s3://bucket-us-west) that replicate the primary bucket. Chunks are
+	// read from them in order of preference, falling back to the primary.
+	StorageReadReplicas string `env:"STORAGE_READ_REPLICAS,default="`
+	// StorageErasureDataShards enables erasure coding of objects if it's
+	// non-zero. Each object is split into this many data shards, and
+	// StorageErasureParityShards parity shards are added so that the object
+	// can be reconstructed when that many shards are lost.
+	StorageErasureDataShards   int `env:"STORAGE_ERASURE_DATA_SHARDS,default=0"`
+	StorageErasureParityShards int `env:"STORAGE_ERASURE_PARITY_SHARDS,default=0"`
+	// StorageErasureShardURLs is a comma-separated list of object store URLs,
+	// one for each data and parity shard. The shards are stored under
+	// separate prefixes of the primary bucket if it's empty.
+	StorageErasureShardURLs string `env:"STORAGE_ERASURE_SHARD_URLS,default="`
+	// StorageColdURL is the URL of an object store (typically one with an
+	// archival storage class) that chunks of old commits are moved to, see
+	// ColdTieringInterval. Chunks can't be moved to cold storage if empty.
+	StorageColdURL string `env:"STORAGE_COLD_URL,default="`
+	// StorageCompression is how chunks are compressed, one of none, gzip,
+	// zstd or lz4, unless their repo's settings say otherwise.
+	// StorageCompressionLevel is the level they're compressed at, 0 means
+	// the codec's default.
+	StorageCompression      string `env:"STORAGE_COMPRESSION,default=zstd"`
+	StorageCompressionLevel int    `env:"STORAGE_COMPRESSION_LEVEL,default=0"`
+	// StorageEncryptionKMSKeyID is the AWS KMS key (an ID, ARN or alias) that
+	// the keys chunks are encrypted with are wrapped with, in the region
+	// StorageEncryptionKMSRegion, or the default one if it's empty.
+	StorageEncryptionKMSKeyID  string `env:"STORAGE_ENCRYPTION_KMS_KEY_ID,default="`
+	StorageEncryptionKMSRegion string `env:"STORAGE_ENCRYPTION_KMS_REGION,default="`
+	// StorageEncryptionKeys is a comma-separated list of <id>=<base64 key>
+	// master keys, which are 32 bytes, to wrap chunk keys with instead of
+	// KMS. The first key wraps new chunk keys, the rest are only used to
+	// unwrap the keys of chunks written before it was rotated in.
+	StorageEncryptionKeys string `env:"STORAGE_ENCRYPTION_KEYS,default="`
+}
+
+// PathValidationConfiguration contains the policy for the paths of files
+// written to PFS.
+type PathValidationConfiguration struct {
+	// PathAllowedRanges is a comma-separated list of the ranges of unicode
+	// code points allowed in paths (e.g. "0x20-0x7e,0xa0-0x10ffff"). Only
+	// printable ASCII is allowed if empty.
+	PathAllowedRanges string `env:"PFS_PATH_ALLOWED_RANGES,default="`
+	// PathForbiddenCharacters are characters that aren't allowed in paths,
+	// in addition to glob characters, which are never allowed.
+	PathForbiddenCharacters string `env:"PFS_PATH_FORBIDDEN_CHARACTERS,default="`
+	// PathMaxComponentLength is the maximum length in bytes of each element
+	// of a path, unlimited if 0.
+	PathMaxComponentLength int `env:"PFS_PATH_MAX_COMPONENT_LENGTH,default=0"`
+	// PathMaxDepth is the maximum number of elements in a path, unlimited if 0.
+	PathMaxDepth int `env:"PFS_PATH_MAX_DEPTH,default=0"`
 }
 
 // WorkerFullConfiguration contains the full worker configuration.
@@ -138,3 +235,23 @@ func NewConfiguration(config interface{}) *Configuration {
 		return nil
 	}
 }
+
+// ValidateIntervals checks that the intervals of pachd's background loops
+// parse, so that a bad value fails pachd's startup rather than restarting the
+// loop forever.
+func (c *PachdSpecificConfiguration) ValidateIntervals() error {
+	for _, interval := range []struct {
+		name, value string
+	}{
+		{"SNAPSHOT_INTERVAL", c.SnapshotInterval},
+		{"STORAGE_GC_POLLING", c.StorageGCPolling},
+	} {
+		if interval.value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(interval.value); err != nil {
+			return errors.Wrapf(err, "invalid %s %q", interval.name, interval.value)
+		}
+	}
+	return nil
+}
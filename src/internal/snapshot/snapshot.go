@@ -0,0 +1,407 @@
+// Package snapshot writes snapshots of the cluster's metadata to object
+// storage, and restores a database from them.
+//
+// A snapshot is either full, containing every row of every snapshotted table,
+// or incremental, containing only the rows of the collections and fileset
+// manifests that changed since the previous snapshot, along with the keys of
+// all rows so that deletions can be replayed. The tables that track chunks
+// are small relative to the collections and are always included in full.
+// Data itself lives in object storage already, so a database can be
+// recovered to the time of the latest snapshot by replaying the last full
+// snapshot and the incremental snapshots that follow it. Only the latest few
+// full snapshots, and the incremental snapshots that follow them, are kept.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	prefix = "snapshots"
+	// fullEvery is how many snapshots are taken between full snapshots.
+	fullEvery = 24
+	// overlap is subtracted from the time of the previous snapshot when taking
+	// an incremental snapshot, to catch rows written by transactions that were
+	// still in flight when the previous snapshot was taken.
+	overlap = time.Minute
+	// fullSuffix marks the object names of full snapshots, so that the
+	// snapshots to keep can be found without reading them.
+	fullSuffix = ".full"
+)
+
+// table describes how a table is snapshotted.
+type table struct {
+	name string
+	// key is an expression that uniquely identifies a row.
+	key string
+	// changed is a column holding the time a row last changed, or empty if
+	// the table is always snapshotted in full.
+	changed string
+	// serial is a serial column whose sequence must be reset after a restore.
+	serial string
+}
+
+var storageTables = []*table{
+	{name: "storage.filesets", key: "id::text", changed: "created_at"},
+	{name: "storage.chunk_objects", key: "encode(chunk_id, 'hex') || '.' || gen", serial: "gen"},
+	{name: "storage.tracker_objects", key: "int_id::text", serial: "int_id"},
+	{name: "storage.tracker_refs", key: "from_id || '/' || to_id"},
+	{name: "storage.keys", key: "name"},
+}
+
+// Snapshot is the metadata in a snapshot.
+type Snapshot struct {
+	Time   time.Time         `json:"time"`
+	Full   bool              `json:"full"`
+	Tables map[string]*Table `json:"tables"`
+}
+
+// Table is the contents of a table in a snapshot.
+type Table struct {
+	// Rows are the rows that changed since the previous snapshot (all rows,
+	// for a full snapshot), as JSON, keyed by row key.
+	Rows map[string]json.RawMessage `json:"rows"`
+	// Keys are the keys of all of the rows in the table, for incremental
+	// snapshots.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Snapshotter takes snapshots of a database.
+type Snapshotter struct {
+	db        *sqlx.DB
+	objClient obj.Client
+	retain    int
+	resumed   bool
+	last      time.Time
+	count     int
+}
+
+// NewSnapshotter creates a Snapshotter that writes snapshots of db to
+// objClient. It keeps the latest retain full snapshots, and the incremental
+// snapshots that follow them, or all snapshots if retain is 0.
+func NewSnapshotter(db *sqlx.DB, objClient obj.Client, retain int) *Snapshotter {
+	return &Snapshotter{db: db, objClient: objClient, retain: retain}
+}
+
+// Run takes a snapshot every interval until ctx is cancelled.
+func (s *Snapshotter) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		}
+		if err := s.Take(ctx); err != nil {
+			log.Errorf("error taking metadata snapshot: %v", err)
+		}
+	}
+}
+
+// Take takes a snapshot and writes it to object storage. Every fullEvery'th
+// snapshot is full. A new Snapshotter continues the chain of snapshots
+// already in object storage, so that restarting one doesn't force a full
+// snapshot.
+func (s *Snapshotter) Take(ctx context.Context) (retErr error) {
+	if !s.resumed {
+		if err := s.resume(ctx); err != nil {
+			return err
+		}
+		s.resumed = true
+	}
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := tx.Rollback(); retErr == nil && err != sql.ErrTxDone {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	full := s.count%fullEvery == 0
+	snapshot := &Snapshot{Full: full, Tables: make(map[string]*Table)}
+	if err := tx.GetContext(ctx, &snapshot.Time, `SELECT now()`); err != nil {
+		return errors.EnsureStack(err)
+	}
+	tables, err := listTables(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		st := &Table{Rows: make(map[string]json.RawMessage)}
+		query := fmt.Sprintf(`SELECT %s AS key, row_to_json(t) AS row FROM %s t`, t.key, t.name)
+		var args []interface{}
+		if !full && t.changed != "" {
+			query += fmt.Sprintf(` WHERE %s > $1`, t.changed)
+			args = append(args, s.last.Add(-overlap))
+			st.Keys = []string{}
+			if err := tx.SelectContext(ctx, &st.Keys, fmt.Sprintf(`SELECT %s FROM %s`, t.key, t.name)); err != nil {
+				return errors.Wrapf(err, "error listing keys of %v", t.name)
+			}
+		}
+		rows, err := tx.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return errors.Wrapf(err, "error reading %v", t.name)
+		}
+		for rows.Next() {
+			var key string
+			var row []byte
+			if err := rows.Scan(&key, &row); err != nil {
+				rows.Close()
+				return errors.EnsureStack(err)
+			}
+			st.Rows[key] = row
+		}
+		if err := rows.Close(); err != nil {
+			return errors.EnsureStack(err)
+		}
+		snapshot.Tables[t.name] = st
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := s.objClient.Put(ctx, objectName(snapshot.Time, full), bytes.NewReader(data)); err != nil {
+		return err
+	}
+	s.last = snapshot.Time
+	s.count++
+	if full {
+		return s.prune(ctx)
+	}
+	return nil
+}
+
+// resume picks up the chain of snapshots in object storage, so that the next
+// snapshot is incremental to the latest one unless a full one is due.
+func (s *Snapshotter) resume(ctx context.Context) error {
+	names, err := listSnapshots(ctx, s.objClient)
+	if err != nil {
+		return err
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		if isFull(names[i]) {
+			last, err := timeOf(names[len(names)-1])
+			if err != nil {
+				return err
+			}
+			s.last = last
+			s.count = len(names) - i
+			return nil
+		}
+	}
+	return nil
+}
+
+// prune deletes the snapshots that precede the latest retain full snapshots.
+func (s *Snapshotter) prune(ctx context.Context) error {
+	if s.retain <= 0 {
+		return nil
+	}
+	names, err := listSnapshots(ctx, s.objClient)
+	if err != nil {
+		return err
+	}
+	fulls := 0
+	for i := len(names) - 1; i >= 0; i-- {
+		if !isFull(names[i]) {
+			continue
+		}
+		if fulls++; fulls < s.retain {
+			continue
+		}
+		for _, name := range names[:i] {
+			if err := s.objClient.Delete(ctx, name); err != nil {
+				return errors.Wrapf(err, "error deleting snapshot %v", name)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// ImportSnapshot restores the latest snapshot in objClient into db. The
+// database must have been migrated, and must not contain any repos yet;
+// ImportSnapshot does nothing if it does, so it is safe to call from every
+// pachd at startup. It returns the time of the restored snapshot, or the zero
+// time if nothing was restored.
+func ImportSnapshot(ctx context.Context, db *sqlx.DB, objClient obj.Client) (_ time.Time, retErr error) {
+	snapshots, err := latestChain(ctx, objClient)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(snapshots) == 0 {
+		return time.Time{}, nil
+	}
+	// Replay the chain of snapshots to get the final contents of each table.
+	contents := make(map[string]map[string]json.RawMessage)
+	for _, snapshot := range snapshots {
+		for name, st := range snapshot.Tables {
+			rows, ok := contents[name]
+			if !ok || snapshot.Full {
+				rows = make(map[string]json.RawMessage)
+				contents[name] = rows
+			}
+			for key, row := range st.Rows {
+				rows[key] = row
+			}
+			if st.Keys != nil {
+				keep := make(map[string]bool)
+				for _, key := range st.Keys {
+					keep[key] = true
+				}
+				for key := range rows {
+					if !keep[key] {
+						delete(rows, key)
+					}
+				}
+			}
+		}
+	}
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return time.Time{}, errors.EnsureStack(err)
+	}
+	defer func() {
+		if retErr != nil {
+			tx.Rollback()
+		}
+	}()
+	// Locking the repos collection serializes pachds that restore concurrently.
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE collections.repos IN EXCLUSIVE MODE`); err != nil {
+		return time.Time{}, errors.EnsureStack(err)
+	}
+	var repos int
+	if err := tx.GetContext(ctx, &repos, `SELECT count(*) FROM collections.repos`); err != nil {
+		return time.Time{}, errors.EnsureStack(err)
+	}
+	if repos > 0 {
+		return time.Time{}, errors.EnsureStack(tx.Rollback())
+	}
+	tables, err := listTables(ctx, tx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, t := range tables {
+		rows, ok := contents[t.name]
+		if !ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, t.name)); err != nil {
+			return time.Time{}, errors.Wrapf(err, "error clearing %v", t.name)
+		}
+		insert := fmt.Sprintf(`INSERT INTO %s SELECT * FROM json_populate_record(NULL::%s, $1)`, t.name, t.name)
+		for _, row := range rows {
+			if _, err := tx.ExecContext(ctx, insert, string(row)); err != nil {
+				return time.Time{}, errors.Wrapf(err, "error restoring %v", t.name)
+			}
+		}
+		if t.serial != "" {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+				`SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(max(%s), 0) + 1, false) FROM %s`,
+				t.name, t.serial, t.serial, t.name)); err != nil {
+				return time.Time{}, errors.Wrapf(err, "error resetting sequence of %v", t.name)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, errors.EnsureStack(err)
+	}
+	return snapshots[len(snapshots)-1].Time, nil
+}
+
+// latestChain returns the latest full snapshot and the incremental snapshots
+// taken after it, in order.
+func latestChain(ctx context.Context, objClient obj.Client) ([]*Snapshot, error) {
+	names, err := listSnapshots(ctx, objClient)
+	if err != nil {
+		return nil, err
+	}
+	var chain []*Snapshot
+	for i := len(names) - 1; i >= 0; i-- {
+		buf := &bytes.Buffer{}
+		if err := objClient.Get(ctx, names[i], buf); err != nil {
+			return nil, err
+		}
+		snapshot := &Snapshot{}
+		if err := json.Unmarshal(buf.Bytes(), snapshot); err != nil {
+			return nil, errors.Wrapf(err, "error parsing snapshot %v", names[i])
+		}
+		chain = append([]*Snapshot{snapshot}, chain...)
+		if snapshot.Full {
+			return chain, nil
+		}
+	}
+	if len(chain) > 0 {
+		return nil, errors.Errorf("no full snapshot found in %v", prefix)
+	}
+	return nil, nil
+}
+
+// listTables returns the tables to snapshot: every collection, and the
+// storage layer's tables.
+func listTables(ctx context.Context, tx *sqlx.Tx) ([]*table, error) {
+	var names []string
+	if err := tx.SelectContext(ctx, &names, `
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'collections' AND table_type = 'BASE TABLE' AND table_name != 'large_notifications'
+		ORDER BY table_name
+	`); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	var tables []*table
+	for _, name := range names {
+		tables = append(tables, &table{name: "collections." + name, key: "key", changed: "updatedat"})
+	}
+	return append(tables, storageTables...), nil
+}
+
+// listSnapshots returns the object names of the snapshots in objClient, in
+// chronological order.
+func listSnapshots(ctx context.Context, objClient obj.Client) ([]string, error) {
+	var names []string
+	if err := objClient.Walk(ctx, prefix, func(name string) error {
+		names = append(names, name)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func objectName(t time.Time, full bool) string {
+	// Zero-padding makes lexical order match chronological order.
+	name := path.Join(prefix, fmt.Sprintf("%020d", t.UnixNano()))
+	if full {
+		name += fullSuffix
+	}
+	return name
+}
+
+func isFull(name string) bool {
+	return strings.HasSuffix(name, fullSuffix)
+}
+
+func timeOf(name string) (time.Time, error) {
+	nanos, err := strconv.ParseInt(strings.TrimSuffix(path.Base(name), fullSuffix), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid snapshot name %v", name)
+	}
+	return time.Unix(0, nanos), nil
+}
@@ -0,0 +1,107 @@
+package snapshot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/clusterstate"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/migrations"
+	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/internal/snapshot"
+	"github.com/pachyderm/pachyderm/v2/src/internal/testutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	db := testutil.NewTestDB(t)
+	require.NoError(t, migrations.ApplyMigrations(context.Background(), db, migrations.Env{}, clusterstate.DesiredClusterState))
+	return db
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	src, dst := newTestDB(t), newTestDB(t)
+	objClient, _ := obj.NewTestClient(t)
+	repos := pfsdb.Repos(src, nil)
+	putRepo := func(name string) {
+		repo := client.NewRepo(name)
+		require.NoError(t, dbutil.WithTx(ctx, src, func(tx *sqlx.Tx) error {
+			return repos.ReadWrite(tx).Put(pfsdb.RepoKey(repo), &pfs.RepoInfo{Repo: repo})
+		}))
+	}
+	s := snapshot.NewSnapshotter(src, objClient, 0)
+
+	putRepo("a")
+	require.NoError(t, s.Take(ctx))
+	putRepo("b")
+	require.NoError(t, s.Take(ctx))
+	require.NoError(t, dbutil.WithTx(ctx, src, func(tx *sqlx.Tx) error {
+		return repos.ReadWrite(tx).Delete(pfsdb.RepoKey(client.NewRepo("a")))
+	}))
+	require.NoError(t, s.Take(ctx))
+
+	restored, err := snapshot.ImportSnapshot(ctx, dst, objClient)
+	require.NoError(t, err)
+	require.False(t, restored.IsZero())
+	var names []string
+	repoInfo := &pfs.RepoInfo{}
+	require.NoError(t, pfsdb.Repos(dst, nil).ReadOnly(ctx).List(repoInfo, col.DefaultOptions(), func(string) error {
+		names = append(names, repoInfo.Repo.Name)
+		return nil
+	}))
+	require.ElementsEqual(t, []string{"b"}, names)
+
+	// Restoring into a database that already has repos does nothing.
+	restored, err = snapshot.ImportSnapshot(ctx, dst, objClient)
+	require.NoError(t, err)
+	require.True(t, restored.IsZero())
+}
+
+func TestSnapshotChain(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	objClient, _ := obj.NewTestClient(t)
+	countSnapshots := func() (full, incremental int) {
+		require.NoError(t, objClient.Walk(ctx, "snapshots", func(name string) error {
+			if strings.HasSuffix(name, ".full") {
+				full++
+			} else {
+				incremental++
+			}
+			return nil
+		}))
+		return full, incremental
+	}
+
+	// A restarted Snapshotter continues the chain rather than starting with
+	// a full snapshot.
+	require.NoError(t, snapshot.NewSnapshotter(db, objClient, 1).Take(ctx))
+	s := snapshot.NewSnapshotter(db, objClient, 1)
+	require.NoError(t, s.Take(ctx))
+	full, incremental := countSnapshots()
+	require.Equal(t, 1, full)
+	require.Equal(t, 1, incremental)
+
+	// Only the latest full snapshot, and the snapshots after it, are kept
+	// once a new full snapshot is taken.
+	for incremental > 0 {
+		require.NoError(t, s.Take(ctx))
+		full, incremental = countSnapshots()
+		require.Equal(t, 1, full)
+	}
+	require.NoError(t, s.Take(ctx))
+	full, incremental = countSnapshots()
+	require.Equal(t, 1, full)
+	require.Equal(t, 1, incremental)
+
+	restored, err := snapshot.ImportSnapshot(ctx, newTestDB(t), objClient)
+	require.NoError(t, err)
+	require.False(t, restored.IsZero())
+}
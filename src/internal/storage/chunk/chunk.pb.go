@@ -27,16 +27,22 @@ type CompressionAlgo int32
 const (
 	CompressionAlgo_NONE            CompressionAlgo = 0
 	CompressionAlgo_GZIP_BEST_SPEED CompressionAlgo = 1
+	CompressionAlgo_ZSTD            CompressionAlgo = 2
+	CompressionAlgo_LZ4             CompressionAlgo = 3
 )
 
 var CompressionAlgo_name = map[int32]string{
 	0: "NONE",
 	1: "GZIP_BEST_SPEED",
+	2: "ZSTD",
+	3: "LZ4",
 }
 
 var CompressionAlgo_value = map[string]int32{
 	"NONE":            0,
 	"GZIP_BEST_SPEED": 1,
+	"ZSTD":            2,
+	"LZ4":             3,
 }
 
 func (x CompressionAlgo) String() string {
@@ -145,15 +151,18 @@ func (m *DataRef) GetSizeBytes() int64 {
 }
 
 type Ref struct {
-	Id                   []byte          `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	SizeBytes            int64           `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
-	Edge                 bool            `protobuf:"varint,3,opt,name=edge,proto3" json:"edge,omitempty"`
-	Dek                  []byte          `protobuf:"bytes,4,opt,name=dek,proto3" json:"dek,omitempty"`
-	EncryptionAlgo       EncryptionAlgo  `protobuf:"varint,5,opt,name=encryption_algo,json=encryptionAlgo,proto3,enum=chunk.EncryptionAlgo" json:"encryption_algo,omitempty"`
-	CompressionAlgo      CompressionAlgo `protobuf:"varint,6,opt,name=compression_algo,json=compressionAlgo,proto3,enum=chunk.CompressionAlgo" json:"compression_algo,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	Id              []byte          `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SizeBytes       int64           `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Edge            bool            `protobuf:"varint,3,opt,name=edge,proto3" json:"edge,omitempty"`
+	Dek             []byte          `protobuf:"bytes,4,opt,name=dek,proto3" json:"dek,omitempty"`
+	EncryptionAlgo  EncryptionAlgo  `protobuf:"varint,5,opt,name=encryption_algo,json=encryptionAlgo,proto3,enum=chunk.EncryptionAlgo" json:"encryption_algo,omitempty"`
+	CompressionAlgo CompressionAlgo `protobuf:"varint,6,opt,name=compression_algo,json=compressionAlgo,proto3,enum=chunk.CompressionAlgo" json:"compression_algo,omitempty"`
+	// key_id is the master key that dek is wrapped with, dek isn't wrapped if
+	// it's empty.
+	KeyId                string   `protobuf:"bytes,7,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Ref) Reset()         { *m = Ref{} }
@@ -231,6 +240,13 @@ func (m *Ref) GetCompressionAlgo() CompressionAlgo {
 	return CompressionAlgo_NONE
 }
 
+func (m *Ref) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("chunk.CompressionAlgo", CompressionAlgo_name, CompressionAlgo_value)
 	proto.RegisterEnum("chunk.EncryptionAlgo", EncryptionAlgo_name, EncryptionAlgo_value)
@@ -243,31 +259,33 @@ func init() {
 }
 
 var fileDescriptor_4b743b4a788792d7 = []byte{
-	// 376 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x52, 0x4d, 0xab, 0xd3, 0x40,
-	0x14, 0x7d, 0x93, 0xf4, 0x3d, 0xeb, 0x7d, 0x21, 0x09, 0x23, 0x4a, 0x16, 0x1a, 0x62, 0x57, 0xe1,
-	0x2d, 0x9a, 0x47, 0xdc, 0x8a, 0x90, 0xb6, 0xc1, 0xea, 0xa2, 0x96, 0xa9, 0xab, 0x6e, 0x42, 0x9a,
-	0x4c, 0x3e, 0x68, 0x9b, 0x09, 0x33, 0x53, 0xa1, 0x82, 0xff, 0xcf, 0xa5, 0x7f, 0x40, 0x90, 0xfe,
-	0x12, 0xc9, 0xb4, 0x54, 0x5b, 0xde, 0x66, 0x38, 0xf7, 0xdc, 0x73, 0xcf, 0xb9, 0x03, 0x17, 0x06,
-	0x75, 0x23, 0x29, 0x6f, 0xd2, 0x4d, 0x20, 0x24, 0xe3, 0x69, 0x49, 0x83, 0xac, 0xda, 0x35, 0xeb,
-	0xe3, 0x3b, 0x6c, 0x39, 0x93, 0x0c, 0xdf, 0xaa, 0x62, 0xf0, 0x03, 0x9e, 0x4d, 0x52, 0x99, 0x12,
-	0x5a, 0xe0, 0xd7, 0xa0, 0x73, 0x5a, 0x38, 0xc8, 0x43, 0xfe, 0x7d, 0x08, 0xc3, 0xa3, 0x98, 0xd0,
-	0x82, 0x74, 0x34, 0xc6, 0xd0, 0xab, 0x52, 0x51, 0x39, 0x9a, 0x87, 0x7c, 0x83, 0x28, 0x8c, 0xdf,
-	0x82, 0xc1, 0x8a, 0x42, 0x50, 0x99, 0xac, 0xf6, 0x92, 0x0a, 0x47, 0xf7, 0x90, 0xaf, 0x93, 0xfb,
-	0x23, 0x37, 0xea, 0x28, 0xfc, 0x06, 0x40, 0xd4, 0xdf, 0xe9, 0x49, 0xd0, 0x53, 0x82, 0xe7, 0x1d,
-	0xa3, 0xda, 0x83, 0xdf, 0x08, 0xf4, 0x2e, 0xdb, 0x04, 0xad, 0xce, 0x55, 0xb4, 0x41, 0xb4, 0x3a,
-	0xbf, 0x1a, 0xd3, 0xae, 0xc6, 0xba, 0x65, 0x68, 0x5e, 0x52, 0x15, 0xd8, 0x27, 0x0a, 0x63, 0x1b,
-	0xf4, 0x9c, 0xae, 0x55, 0x84, 0x41, 0x3a, 0x88, 0x3f, 0x80, 0x45, 0x9b, 0x8c, 0xef, 0x5b, 0x59,
-	0xb3, 0x26, 0x49, 0x37, 0x25, 0x73, 0x6e, 0x3d, 0xe4, 0x9b, 0xe1, 0xcb, 0xd3, 0xe7, 0xe2, 0x73,
-	0x37, 0xda, 0x94, 0x8c, 0x98, 0xf4, 0xa2, 0xc6, 0x11, 0xd8, 0x19, 0xdb, 0xb6, 0x9c, 0x0a, 0x71,
-	0x36, 0xb8, 0x53, 0x06, 0xaf, 0x4e, 0x06, 0xe3, 0x7f, 0x6d, 0xe5, 0x60, 0x65, 0x97, 0xc4, 0xc3,
-	0x23, 0x58, 0x57, 0x1a, 0xdc, 0x87, 0xde, 0xec, 0xcb, 0x2c, 0xb6, 0x6f, 0xf0, 0x0b, 0xb0, 0x3e,
-	0x2e, 0x3f, 0xcd, 0x93, 0x51, 0xbc, 0xf8, 0x9a, 0x2c, 0xe6, 0x71, 0x3c, 0xb1, 0xd1, 0x83, 0x0b,
-	0xe6, 0xe5, 0x5a, 0xd8, 0x80, 0xfe, 0x78, 0x1a, 0x8d, 0xa7, 0x51, 0xf8, 0x68, 0xdf, 0x8c, 0x3e,
-	0xff, 0x3c, 0xb8, 0xe8, 0xd7, 0xc1, 0x45, 0x7f, 0x0e, 0x2e, 0x5a, 0xbe, 0x2f, 0x6b, 0x59, 0xed,
-	0x56, 0xc3, 0x8c, 0x6d, 0x83, 0x36, 0xcd, 0xaa, 0x7d, 0x4e, 0xf9, 0xff, 0xe8, 0x5b, 0x18, 0x08,
-	0x9e, 0x05, 0x4f, 0xdf, 0xc3, 0xea, 0x4e, 0x9d, 0xc2, 0xbb, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x1a, 0x9e, 0x8a, 0xd6, 0x30, 0x02, 0x00, 0x00,
+	// 406 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x52, 0x4d, 0x6b, 0xdb, 0x40,
+	0x10, 0xcd, 0x4a, 0xfe, 0xca, 0x44, 0xd8, 0x62, 0x4b, 0x8a, 0x0e, 0xad, 0x51, 0x7d, 0x12, 0x39,
+	0x58, 0xc5, 0xed, 0xb1, 0x14, 0xfc, 0x21, 0x9a, 0x94, 0x92, 0x86, 0x75, 0x4e, 0xbe, 0x08, 0x59,
+	0x1a, 0x7d, 0x60, 0x47, 0x2b, 0x76, 0x37, 0x05, 0x15, 0xfa, 0xff, 0x7a, 0xec, 0x4f, 0x28, 0xfe,
+	0x0d, 0xfd, 0x01, 0x45, 0x6b, 0x93, 0xd6, 0x26, 0x97, 0xe5, 0xcd, 0x7b, 0x33, 0xef, 0xed, 0xc0,
+	0xc0, 0xa8, 0x28, 0x15, 0x8a, 0x32, 0xda, 0xfa, 0x52, 0x71, 0x11, 0x65, 0xe8, 0xc7, 0xf9, 0x63,
+	0xb9, 0xd9, 0xbf, 0xe3, 0x4a, 0x70, 0xc5, 0x69, 0x5b, 0x17, 0xa3, 0x1f, 0xd0, 0x5d, 0x44, 0x2a,
+	0x62, 0x98, 0xd2, 0x57, 0x60, 0x0a, 0x4c, 0x1d, 0xe2, 0x12, 0xef, 0x62, 0x02, 0xe3, 0x7d, 0x33,
+	0xc3, 0x94, 0x35, 0x34, 0xa5, 0xd0, 0xca, 0x23, 0x99, 0x3b, 0x86, 0x4b, 0x3c, 0x8b, 0x69, 0x4c,
+	0xdf, 0x80, 0xc5, 0xd3, 0x54, 0xa2, 0x0a, 0xd7, 0xb5, 0x42, 0xe9, 0x98, 0x2e, 0xf1, 0x4c, 0x76,
+	0xb1, 0xe7, 0x66, 0x0d, 0x45, 0x5f, 0x03, 0xc8, 0xe2, 0x3b, 0x1e, 0x1a, 0x5a, 0xba, 0xe1, 0xbc,
+	0x61, 0xb4, 0x3c, 0xfa, 0x43, 0xc0, 0x6c, 0xb2, 0xfb, 0x60, 0x14, 0x89, 0x8e, 0xb6, 0x98, 0x51,
+	0x24, 0x27, 0x63, 0xc6, 0xc9, 0x58, 0xf3, 0x19, 0x4c, 0x32, 0xd4, 0x81, 0x3d, 0xa6, 0x31, 0xb5,
+	0xc1, 0x4c, 0x70, 0xa3, 0x23, 0x2c, 0xd6, 0x40, 0xfa, 0x11, 0x06, 0x58, 0xc6, 0xa2, 0xae, 0x54,
+	0xc1, 0xcb, 0x30, 0xda, 0x66, 0xdc, 0x69, 0xbb, 0xc4, 0xeb, 0x4f, 0x2e, 0x0f, 0xcb, 0x05, 0x4f,
+	0xea, 0x74, 0x9b, 0x71, 0xd6, 0xc7, 0xa3, 0x9a, 0x4e, 0xc1, 0x8e, 0xf9, 0x43, 0x25, 0x50, 0xca,
+	0x27, 0x83, 0x8e, 0x36, 0x78, 0x79, 0x30, 0x98, 0xff, 0x93, 0xb5, 0xc3, 0x20, 0x3e, 0x26, 0xe8,
+	0x25, 0x74, 0x36, 0x58, 0x87, 0x45, 0xe2, 0x74, 0x5d, 0xe2, 0x9d, 0xb3, 0xf6, 0x06, 0xeb, 0x9b,
+	0xe4, 0x6a, 0x0e, 0x83, 0x93, 0x51, 0xda, 0x83, 0xd6, 0xed, 0xd7, 0xdb, 0xc0, 0x3e, 0xa3, 0x2f,
+	0x60, 0xf0, 0x69, 0x75, 0x73, 0x17, 0xce, 0x82, 0xe5, 0x7d, 0xb8, 0xbc, 0x0b, 0x82, 0x85, 0x4d,
+	0x1a, 0x79, 0xb5, 0xbc, 0x5f, 0xd8, 0x06, 0xed, 0x82, 0xf9, 0x65, 0xf5, 0xde, 0x36, 0xaf, 0x86,
+	0xd0, 0x3f, 0x5e, 0x80, 0x5a, 0xd0, 0x9b, 0x5f, 0x4f, 0xe7, 0xd7, 0xd3, 0xc9, 0x5b, 0xfb, 0x6c,
+	0xf6, 0xf9, 0xe7, 0x6e, 0x48, 0x7e, 0xed, 0x86, 0xe4, 0xf7, 0x6e, 0x48, 0x56, 0x1f, 0xb2, 0x42,
+	0xe5, 0x8f, 0xeb, 0x71, 0xcc, 0x1f, 0xfc, 0x2a, 0x8a, 0xf3, 0x3a, 0x41, 0xf1, 0x3f, 0xfa, 0x36,
+	0xf1, 0xa5, 0x88, 0xfd, 0xe7, 0x2f, 0x67, 0xdd, 0xd1, 0x47, 0xf3, 0xee, 0xef, 0x00, 0x51, 0x3e,
+	0xa9, 0xb5, 0x5a, 0x02, 0x00, 0x00,
 }
 
 func (m *DataRef) Marshal() (dAtA []byte, err error) {
@@ -350,6 +368,13 @@ func (m *Ref) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.KeyId) > 0 {
+		i -= len(m.KeyId)
+		copy(dAtA[i:], m.KeyId)
+		i = encodeVarintChunk(dAtA, i, uint64(len(m.KeyId)))
+		i--
+		dAtA[i] = 0x3a
+	}
 	if m.CompressionAlgo != 0 {
 		i = encodeVarintChunk(dAtA, i, uint64(m.CompressionAlgo))
 		i--
@@ -455,6 +480,10 @@ func (m *Ref) Size() (n int) {
 	if m.CompressionAlgo != 0 {
 		n += 1 + sovChunk(uint64(m.CompressionAlgo))
 	}
+	l = len(m.KeyId)
+	if l > 0 {
+		n += 1 + l + sovChunk(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -800,6 +829,38 @@ func (m *Ref) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KeyId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChunk
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChunk
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChunk
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.KeyId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipChunk(dAtA[iNdEx:])
@@ -3,9 +3,12 @@ package chunk
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
 	"github.com/pachyderm/pachyderm/v2/src/internal/randutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/kv"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/track"
 	"github.com/pachyderm/pachyderm/v2/src/internal/testutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
@@ -210,3 +214,126 @@ func newTestStorage(t testing.TB) (obj.Client, *Storage) {
 	tr := track.NewTestTracker(t, db)
 	return NewTestStorage(t, db, tr)
 }
+
+func TestCompression(t *testing.T) {
+	text := []byte(strings.Repeat("compressible text ", 10000))
+	random := make([]byte, 10000)
+	rand.New(rand.NewSource(0)).Read(random)
+	for _, algo := range []CompressionAlgo{CompressionAlgo_NONE, CompressionAlgo_GZIP_BEST_SPEED, CompressionAlgo_ZSTD, CompressionAlgo_LZ4} {
+		for _, level := range []int{0, 1} {
+			if algo == CompressionAlgo_NONE && level > 0 {
+				continue
+			}
+			t.Run(fmt.Sprintf("%v/%d", algo, level), func(t *testing.T) {
+				require.NoError(t, ValidateCompression(algo, level))
+				buf := make([]byte, len(text))
+				usedAlgo, n, err := compress(algo, level, buf, text)
+				require.NoError(t, err)
+				require.Equal(t, algo, usedAlgo)
+				if algo != CompressionAlgo_NONE {
+					require.True(t, n < len(text))
+				}
+				r, err := decompress(usedAlgo, bytes.NewReader(buf[:n]))
+				require.NoError(t, err)
+				data, err := ioutil.ReadAll(r)
+				require.NoError(t, err)
+				require.Equal(t, text, data)
+				// Data that doesn't compress is stored uncompressed.
+				buf = make([]byte, len(random))
+				usedAlgo, n, err = compress(algo, level, buf, random)
+				require.NoError(t, err)
+				require.Equal(t, CompressionAlgo_NONE, usedAlgo)
+				require.Equal(t, random, buf[:n])
+			})
+		}
+	}
+	require.YesError(t, ValidateCompression(CompressionAlgo_ZSTD, 23))
+	require.YesError(t, ValidateCompression(CompressionAlgo_LZ4, -1))
+}
+
+type mapClient map[string][]byte
+
+func (c mapClient) Create(_ context.Context, _ Metadata, chunkData []byte) (ID, error) {
+	id := Hash(chunkData)
+	c[string(id)] = append([]byte{}, chunkData...)
+	return id, nil
+}
+
+func (c mapClient) Get(_ context.Context, chunkID ID, cb kv.ValueCallback) error {
+	return cb(c[string(chunkID)])
+}
+
+func (c mapClient) Close() error { return nil }
+
+func TestKeyWrapper(t *testing.T) {
+	ctx := context.Background()
+	oldKeys, current, err := ParseAESKeys("old=" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	require.Equal(t, "old", current)
+	oldWrapper, err := NewAESKeyWrapper(oldKeys, current)
+	require.NoError(t, err)
+	newKey := make([]byte, 32)
+	rand.New(rand.NewSource(0)).Read(newKey)
+	keys, current, err := ParseAESKeys("new=" + base64.StdEncoding.EncodeToString(newKey) + ",old=" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	require.Equal(t, "new", current)
+	wrapper, err := NewAESKeyWrapper(keys, current)
+	require.NoError(t, err)
+
+	client := mapClient{}
+	data := []byte(strings.Repeat("secret data ", 1000))
+	create := func(w KeyWrapper) *Ref {
+		ref, err := Create(ctx, CreateOptions{Secret: []byte("secret"), KeyWrapper: w}, data, func(ctx context.Context, data []byte) (ID, error) {
+			return client.Create(ctx, Metadata{}, data)
+		})
+		require.NoError(t, err)
+		return ref
+	}
+	get := func(w KeyWrapper, ref *Ref) ([]byte, error) {
+		var out []byte
+		err := Get(ctx, client, kv.NewMemCache(10), w, ref, func(data []byte) error {
+			out = append([]byte{}, data...)
+			return nil
+		})
+		return out, err
+	}
+	// Chunks written with the old key can still be read after it's rotated.
+	oldRef := create(oldWrapper)
+	require.Equal(t, "old", oldRef.KeyId)
+	out, err := get(wrapper, oldRef)
+	require.NoError(t, err)
+	require.Equal(t, data, out)
+
+	ref := create(wrapper)
+	require.Equal(t, "new", ref.KeyId)
+	require.Equal(t, oldRef.Id, ref.Id)
+	out, err = get(wrapper, ref)
+	require.NoError(t, err)
+	require.Equal(t, data, out)
+	_, err = get(oldWrapper, ref)
+	require.YesError(t, err)
+	_, err = get(nil, ref)
+	require.YesError(t, err)
+
+	// Chunks written without a key wrapper can be read with one.
+	ref = create(nil)
+	require.Equal(t, "", ref.KeyId)
+	out, err = get(wrapper, ref)
+	require.NoError(t, err)
+	require.Equal(t, data, out)
+
+	_, _, err = ParseAESKeys("nokey")
+	require.YesError(t, err)
+	_, err = NewAESKeyWrapper(map[string][]byte{"short": make([]byte, 16)}, "short")
+	require.YesError(t, err)
+}
+
+func TestValidateChunking(t *testing.T) {
+	require.NoError(t, ValidateChunking(0, 0, 0, 0))
+	require.NoError(t, ValidateChunking(12, 1024, 16*1024, 32))
+	// The default minimum is larger than 2^12.
+	require.YesError(t, ValidateChunking(12, 0, 0, 0))
+	require.YesError(t, ValidateChunking(maxAverageBits+1, 0, 0, 0))
+	require.YesError(t, ValidateChunking(12, 1024, 2048, 0))
+	require.YesError(t, ValidateChunking(12, 1024, 16*1024, 2048))
+}
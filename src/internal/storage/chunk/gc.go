@@ -40,13 +40,20 @@ func (gc *GarbageCollector) RunForever(ctx context.Context) error {
 }
 
 // RunOnce runs 1 cycle of garbage collection.
-func (gc *GarbageCollector) RunOnce(ctx context.Context) (retErr error) {
+func (gc *GarbageCollector) RunOnce(ctx context.Context) error {
+	_, _, err := gc.Collect(ctx)
+	return err
+}
+
+// Collect runs 1 cycle of garbage collection, and returns the number of
+// chunks it deleted and their size.
+func (gc *GarbageCollector) Collect(ctx context.Context) (chunks int64, size int64, retErr error) {
 	rows, err := gc.s.db.QueryxContext(ctx, `
-	SELECT chunk_id, gen, uploaded FROM storage.chunk_objects
+	SELECT chunk_id, gen, uploaded, size FROM storage.chunk_objects
 	WHERE tombstone = true
 	`)
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 	defer func() {
 		if err := rows.Close(); retErr == nil {
@@ -56,20 +63,22 @@ func (gc *GarbageCollector) RunOnce(ctx context.Context) (retErr error) {
 	for rows.Next() {
 		var ent Entry
 		if err := rows.StructScan(&ent); err != nil {
-			return err
+			return chunks, size, err
 		}
 		if !ent.Uploaded {
 			gc.log.Warnf("possibility for untracked chunk %s", chunkPath(ent.ChunkID, ent.Gen))
 		}
 		if err := gc.deleteOne(ctx, ent); err != nil {
-			return err
+			return chunks, size, err
 		}
+		chunks++
+		size += ent.Size
 		gc.log.WithFields(logrus.Fields{
 			"chunk_id": ent.ChunkID,
 			"gen":      ent.Gen,
 		}).Infof("deleting object for chunk entry")
 	}
-	return rows.Err()
+	return chunks, size, rows.Err()
 }
 
 func (gc *GarbageCollector) deleteOne(ctx context.Context, ent Entry) error {
@@ -0,0 +1,217 @@
+package chunk
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// keyCacheSize is the number of data encryption keys that a caching key
+// wrapper keeps in each direction.
+const keyCacheSize = 10000
+
+// KeyWrapper wraps the data encryption keys of chunks with a master key, so
+// that the chunks can't be decrypted by anyone who can read the object store
+// and the indexes in it, without also having access to the master key.
+type KeyWrapper interface {
+	// Wrap wraps dek with the current master key, and returns the ID of that
+	// key along with the wrapped dek.
+	Wrap(ctx context.Context, dek []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap unwraps a dek that was wrapped with the master key keyID.
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+type aesKeyWrapper struct {
+	keys    map[string]cipher.AEAD
+	current string
+}
+
+// NewAESKeyWrapper returns a KeyWrapper that wraps keys with AES-GCM, using
+// the master keys in keys, which are 32 bytes long, by their IDs. New keys
+// are wrapped with the key current, the others are only kept to unwrap keys
+// that were wrapped before current was rotated in.
+func NewAESKeyWrapper(keys map[string][]byte, current string) (KeyWrapper, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, errors.Errorf("master key %q isn't one of the keys", current)
+	}
+	w := &aesKeyWrapper{
+		keys:    make(map[string]cipher.AEAD),
+		current: current,
+	}
+	for id, key := range keys {
+		if id == "" {
+			return nil, errors.Errorf("master keys must have an ID")
+		}
+		if len(key) != 32 {
+			return nil, errors.Errorf("master key %q is %d bytes, it must be 32", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		w.keys[id] = gcm
+	}
+	return w, nil
+}
+
+func (w *aesKeyWrapper) Wrap(_ context.Context, dek []byte) (string, []byte, error) {
+	gcm := w.keys[w.current]
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, errors.EnsureStack(err)
+	}
+	return w.current, gcm.Seal(nonce, nonce, dek, []byte(w.current)), nil
+}
+
+func (w *aesKeyWrapper) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	gcm, ok := w.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown master key %q", keyID)
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, errors.Errorf("wrapped data encryption key is too short")
+	}
+	nonce, ctext := wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():]
+	dek, err := gcm.Open(nil, nonce, ctext, []byte(keyID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not unwrap data encryption key with master key %q", keyID)
+	}
+	return dek, nil
+}
+
+// ParseAESKeys parses a comma-separated list of master keys, each of which is
+// an ID and a base64 encoded key separated by "=". It returns the keys by ID,
+// and the ID of the first one, which is the current one.
+func ParseAESKeys(s string) (map[string][]byte, string, error) {
+	keys := make(map[string][]byte)
+	var current string
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(parts) != 2 {
+			return nil, "", errors.Errorf("master key must be <id>=<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "could not decode master key %q", parts[0])
+		}
+		if _, ok := keys[parts[0]]; ok {
+			return nil, "", errors.Errorf("master key %q is listed more than once", parts[0])
+		}
+		keys[parts[0]] = key
+		if current == "" {
+			current = parts[0]
+		}
+	}
+	return keys, current, nil
+}
+
+type kmsKeyWrapper struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+// NewKMSKeyWrapper returns a KeyWrapper that wraps keys with the AWS KMS key
+// keyID, which can be a key ID, ARN or alias. Keys are recorded by the ARN
+// of the key, which KMS rotates without it changing. Wrapped and unwrapped
+// keys are cached, so chunks that are read or written often don't each make
+// a request to KMS.
+func NewKMSKeyWrapper(client kmsiface.KMSAPI, keyID string) (KeyWrapper, error) {
+	return newCachingKeyWrapper(&kmsKeyWrapper{
+		client: client,
+		keyID:  keyID,
+	})
+}
+
+func (w *kmsKeyWrapper) Wrap(ctx context.Context, dek []byte) (string, []byte, error) {
+	out, err := w.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(w.keyID),
+		Plaintext: dek,
+	})
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "could not wrap data encryption key with KMS key %q", w.keyID)
+	}
+	return aws.StringValue(out.KeyId), out.CiphertextBlob, nil
+}
+
+func (w *kmsKeyWrapper) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	out, err := w.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(keyID),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not unwrap data encryption key with KMS key %q", keyID)
+	}
+	return out.Plaintext, nil
+}
+
+// cachingKeyWrapper caches the keys that a KeyWrapper wraps and unwraps.
+// Data encryption keys are derived from the content of chunks, so the same
+// chunk written twice is wrapped once.
+type cachingKeyWrapper struct {
+	KeyWrapper
+	wrapped   *lru.Cache
+	unwrapped *lru.Cache
+}
+
+type wrappedKey struct {
+	keyID   string
+	wrapped []byte
+}
+
+func newCachingKeyWrapper(w KeyWrapper) (KeyWrapper, error) {
+	wrapped, err := lru.New(keyCacheSize)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	unwrapped, err := lru.New(keyCacheSize)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	return &cachingKeyWrapper{
+		KeyWrapper: w,
+		wrapped:    wrapped,
+		unwrapped:  unwrapped,
+	}, nil
+}
+
+func (w *cachingKeyWrapper) Wrap(ctx context.Context, dek []byte) (string, []byte, error) {
+	if v, ok := w.wrapped.Get(string(dek)); ok {
+		k := v.(wrappedKey)
+		return k.keyID, k.wrapped, nil
+	}
+	keyID, wrapped, err := w.KeyWrapper.Wrap(ctx, dek)
+	if err != nil {
+		return "", nil, err
+	}
+	w.wrapped.Add(string(dek), wrappedKey{keyID: keyID, wrapped: wrapped})
+	w.unwrapped.Add(keyID+"/"+string(wrapped), dek)
+	return keyID, wrapped, nil
+}
+
+func (w *cachingKeyWrapper) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key := keyID + "/" + string(wrapped)
+	if v, ok := w.unwrapped.Get(key); ok {
+		return v.([]byte), nil
+	}
+	dek, err := w.KeyWrapper.Unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	w.unwrapped.Add(key, dek)
+	return dek, nil
+}
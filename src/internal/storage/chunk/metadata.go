@@ -63,6 +63,7 @@ type Entry struct {
 	Gen       uint64 `db:"gen"`
 	Uploaded  bool   `db:"uploaded"`
 	Tombstone bool   `db:"tombstone"`
+	Size      int64  `db:"size"`
 }
 
 // SetupPostgresStoreV0 sets up tables in db
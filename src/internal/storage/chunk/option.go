@@ -5,7 +5,11 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/chmduquesne/rollinghash/buzhash64"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
 	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
@@ -29,6 +33,15 @@ func WithObjectCache(fastLayer obj.Client, size int) StorageOption {
 	}
 }
 
+// WithColdStorage adds a cold tier that chunks can be moved to with
+// MoveToCold. It should be set before the options that wrap the object client.
+func WithColdStorage(cold obj.Client) StorageOption {
+	return func(s *Storage) {
+		s.tiered = obj.NewTieredClient(s.objClient, cold)
+		s.objClient = s.tiered
+	}
+}
+
 // WithSecret sets the secret used to generate chunk encryption keys
 func WithSecret(secret []byte) StorageOption {
 	return func(s *Storage) {
@@ -36,10 +49,21 @@ func WithSecret(secret []byte) StorageOption {
 	}
 }
 
-// WithCompression sets the compression algorithm used to compress chunks
-func WithCompression(algo CompressionAlgo) StorageOption {
+// WithCompression sets the compression algorithm and level used to compress
+// chunks, a level of 0 is the algorithm's default.
+func WithCompression(algo CompressionAlgo, level int) StorageOption {
 	return func(s *Storage) {
 		s.createOpts.Compression = algo
+		s.createOpts.CompressionLevel = level
+	}
+}
+
+// WithKeyWrapper wraps the keys that chunks are encrypted with using keys,
+// which must be able to unwrap the keys of the chunks already written with
+// it.
+func WithKeyWrapper(keys KeyWrapper) StorageOption {
+	return func(s *Storage) {
+		s.createOpts.KeyWrapper = keys
 	}
 }
 
@@ -55,11 +79,40 @@ func WithRollingHashConfig(averageBits int, seed int64) WriterOption {
 	}
 }
 
-// WithMinMax sets the minimum and maximum chunk size.
+// WithAverageBits sets the average chunk size to 2^averageBits, keeping the
+// rolling hash's seed.
+func WithAverageBits(averageBits int) WriterOption {
+	return func(w *Writer) {
+		w.chunkSize.avg = int(math.Pow(2, float64(averageBits)))
+		w.splitMask = (1 << uint64(averageBits)) - 1
+	}
+}
+
+// WithWindowSize sets the size of the rolling hash window.
+func WithWindowSize(size int) WriterOption {
+	return func(w *Writer) {
+		w.window = make([]byte, size)
+	}
+}
+
+// WithMinMax sets the minimum and maximum chunk size, 0 keeps the default.
 func WithMinMax(min, max int) WriterOption {
 	return func(w *Writer) {
-		w.chunkSize.min = min
-		w.chunkSize.max = max
+		if min > 0 {
+			w.chunkSize.min = min
+		}
+		if max > 0 {
+			w.chunkSize.max = max
+		}
+	}
+}
+
+// WithCompressionOverride compresses the chunks that the writer creates with
+// algo at level, rather than the storage's compression.
+func WithCompressionOverride(algo CompressionAlgo, level int) WriterOption {
+	return func(w *Writer) {
+		w.createOpts.Compression = algo
+		w.createOpts.CompressionLevel = level
 	}
 }
 
@@ -73,6 +126,34 @@ func WithNoUpload() WriterOption {
 // StorageOptions returns the chunk storage options for the config.
 func StorageOptions(conf *serviceenv.Configuration) ([]StorageOption, error) {
 	var opts []StorageOption
+	if conf.StorageCompression != "" {
+		algo, err := ParseCompressionAlgo(conf.StorageCompression)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateCompression(algo, conf.StorageCompressionLevel); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCompression(algo, conf.StorageCompressionLevel))
+	}
+	keys, err := keyWrapper(conf)
+	if err != nil {
+		return nil, err
+	}
+	if keys != nil {
+		opts = append(opts, WithKeyWrapper(keys))
+	}
+	if conf.StorageColdURL != "" {
+		url, err := obj.ParseURL(conf.StorageColdURL)
+		if err != nil {
+			return nil, err
+		}
+		cold, err := obj.NewClientFromURLAndSecret(url)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithColdStorage(cold))
+	}
 	if conf.StorageUploadConcurrencyLimit > 0 {
 		opts = append(opts, WithMaxConcurrentObjects(0, conf.StorageUploadConcurrencyLimit))
 	}
@@ -85,3 +166,30 @@ func StorageOptions(conf *serviceenv.Configuration) ([]StorageOption, error) {
 	}
 	return opts, nil
 }
+
+// keyWrapper returns the key wrapper for the config's master keys, or nil if
+// it doesn't have any.
+func keyWrapper(conf *serviceenv.Configuration) (KeyWrapper, error) {
+	switch {
+	case conf.StorageEncryptionKMSKeyID != "" && conf.StorageEncryptionKeys != "":
+		return nil, errors.Errorf("only one of STORAGE_ENCRYPTION_KMS_KEY_ID and STORAGE_ENCRYPTION_KEYS can be set")
+	case conf.StorageEncryptionKMSKeyID != "":
+		awsConfig := aws.NewConfig()
+		if conf.StorageEncryptionKMSRegion != "" {
+			awsConfig = awsConfig.WithRegion(conf.StorageEncryptionKMSRegion)
+		}
+		sess, err := session.NewSession(awsConfig)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		return NewKMSKeyWrapper(kms.New(sess), conf.StorageEncryptionKMSKeyID)
+	case conf.StorageEncryptionKeys != "":
+		keys, current, err := ParseAESKeys(conf.StorageEncryptionKeys)
+		if err != nil {
+			return nil, err
+		}
+		return NewAESKeyWrapper(keys, current)
+	default:
+		return nil, nil
+	}
+}
@@ -20,6 +20,7 @@ const (
 // Storage is the abstraction that manages chunk storage.
 type Storage struct {
 	objClient obj.Client
+	tiered    *obj.TieredClient
 	store     kv.Store
 	memCache  kv.GetPut
 	tracker   track.Tracker
@@ -36,7 +37,7 @@ func NewStorage(objC obj.Client, memCache kv.GetPut, db *sqlx.DB, tracker track.
 		db:        db,
 		tracker:   tracker,
 		createOpts: CreateOptions{
-			Compression: CompressionAlgo_GZIP_BEST_SPEED,
+			Compression: CompressionAlgo_ZSTD,
 		},
 	}
 	for _, opt := range opts {
@@ -51,7 +52,7 @@ func NewStorage(objC obj.Client, memCache kv.GetPut, db *sqlx.DB, tracker track.
 func (s *Storage) NewReader(ctx context.Context, dataRefs []*DataRef) *Reader {
 	// using the empty string for the tmp id to disable the renewer
 	client := NewClient(s.store, s.db, s.tracker, "")
-	return newReader(ctx, client, s.memCache, dataRefs)
+	return newReader(ctx, client, s.memCache, s.createOpts.KeyWrapper, dataRefs)
 }
 
 // NewWriter creates a new Writer for a stream of bytes to be chunked.
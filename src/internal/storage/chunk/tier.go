@@ -0,0 +1,48 @@
+package chunk
+
+import (
+	"context"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// HasColdStorage returns true if the storage has a cold tier that chunks can
+// be moved to.
+func (s *Storage) HasColdStorage() bool {
+	return s.tiered != nil
+}
+
+// MoveToCold moves the objects that hold the chunk to cold storage. The chunk
+// can still be read, but reads are slower.
+func (s *Storage) MoveToCold(ctx context.Context, id ID) error {
+	return s.moveChunk(ctx, id, func(p string) error {
+		return s.tiered.MoveToCold(ctx, p)
+	})
+}
+
+// MoveToHot moves the objects that hold the chunk back to hot storage.
+func (s *Storage) MoveToHot(ctx context.Context, id ID) error {
+	return s.moveChunk(ctx, id, func(p string) error {
+		return s.tiered.MoveToHot(ctx, p)
+	})
+}
+
+func (s *Storage) moveChunk(ctx context.Context, id ID, move func(p string) error) error {
+	if s.tiered == nil {
+		return errors.Errorf("no cold storage is configured")
+	}
+	var gens []uint64
+	if err := s.db.SelectContext(ctx, &gens, `
+	SELECT gen
+	FROM storage.chunk_objects
+	WHERE uploaded = TRUE AND tombstone = FALSE AND chunk_id = $1
+	`, id); err != nil {
+		return errors.EnsureStack(err)
+	}
+	for _, gen := range gens {
+		if err := move(chunkPath(id, gen)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,6 +7,11 @@ import (
 	"crypto/cipher"
 	io "io"
 	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pachhash"
@@ -19,19 +24,30 @@ import (
 type CreateOptions struct {
 	Secret      []byte
 	Compression CompressionAlgo
+	// CompressionLevel is the level that Compression compresses at, 0 means
+	// the algorithm's default.
+	CompressionLevel int
+	// KeyWrapper, if it's set, wraps the keys that chunks are encrypted with.
+	KeyWrapper KeyWrapper
 }
 
 // Create calls createFunc to create a new chunk, but first compresses, and encrypts ptext.
 // ptext will not be modified.
 func Create(ctx context.Context, opts CreateOptions, ptext []byte, createFunc func(ctx context.Context, data []byte) (ID, error)) (*Ref, error) {
 	buf := make([]byte, len(ptext))
-	compressAlgo, n, err := compress(opts.Compression, buf, ptext)
+	compressAlgo, n, err := compress(opts.Compression, opts.CompressionLevel, buf, ptext)
 	if err != nil {
 		return nil, err
 	}
 	buf = buf[:n]
 	// encrypt in place; compress will always make a copy of the data.
 	dek := encrypt(opts.Secret, buf, buf)
+	var keyID string
+	if opts.KeyWrapper != nil {
+		if keyID, dek, err = opts.KeyWrapper.Wrap(ctx, dek); err != nil {
+			return nil, err
+		}
+	}
 	id, err := createFunc(ctx, buf)
 	if err != nil {
 		return nil, err
@@ -42,25 +58,37 @@ func Create(ctx context.Context, opts CreateOptions, ptext []byte, createFunc fu
 		Dek:             dek,
 		CompressionAlgo: compressAlgo,
 		EncryptionAlgo:  EncryptionAlgo_CHACHA20,
+		KeyId:           keyID,
 	}, nil
 }
 
 // Get calls getFunc to retrieve a chunk, then verifies, decrypts, and decompresses the data.
-// Uncompressed plaintext is written to w.
-func Get(ctx context.Context, client Client, cache kv.GetPut, ref *Ref, cb kv.ValueCallback) error {
+// Uncompressed plaintext is written to w. keys unwraps the chunk's key if it
+// was wrapped when the chunk was created.
+func Get(ctx context.Context, client Client, cache kv.GetPut, keys KeyWrapper, ref *Ref, cb kv.ValueCallback) error {
 	if err := getFromCache(ctx, cache, ref, cb); err == nil {
 		return nil
 	}
 	if ref.EncryptionAlgo != EncryptionAlgo_CHACHA20 {
 		return errors.Errorf("unknown encryption algorithm %d", ref.EncryptionAlgo)
 	}
+	dek := ref.Dek
+	if ref.KeyId != "" {
+		if keys == nil {
+			return errors.Errorf("chunk's key is wrapped with master key %q, but no master keys are configured", ref.KeyId)
+		}
+		var err error
+		if dek, err = keys.Unwrap(ctx, ref.KeyId, ref.Dek); err != nil {
+			return err
+		}
+	}
 	return client.Get(ctx, ref.Id, func(ctext []byte) error {
 		if err := verifyData(ref.Id, ctext); err != nil {
 			return err
 		}
 		var r io.Reader = bytes.NewReader(ctext)
 		var err error
-		if r, err = decrypt(ref.Dek, r); err != nil {
+		if r, err = decrypt(dek, r); err != nil {
 			return err
 		}
 		if r, err = decompress(ref.CompressionAlgo, r); err != nil {
@@ -77,19 +105,22 @@ func Get(ctx context.Context, client Client, cache kv.GetPut, ref *Ref, cb kv.Va
 	})
 }
 
-// compress attempts to compress src using algo. If the compressed data is bigger
+// compress attempts to compress src using algo at level. If the compressed data is bigger
 // then no compression is used.
 // compress returns the compression algorithm used (algo or NONE), the number of bytes written to dst
 // or an error
-func compress(algo CompressionAlgo, dst, src []byte) (CompressionAlgo, int, error) {
+func compress(algo CompressionAlgo, level int, dst, src []byte) (CompressionAlgo, int, error) {
 	switch algo {
 	case CompressionAlgo_NONE:
 		copy(dst, src)
 		return CompressionAlgo_NONE, len(src), nil
 	case CompressionAlgo_GZIP_BEST_SPEED:
+		if level == 0 {
+			level = gzip.BestSpeed
+		}
 		lw := newLimitWriter(dst)
 		err := func() (retErr error) {
-			gw, err := gzip.NewWriterLevel(lw, gzip.BestSpeed)
+			gw, err := gzip.NewWriterLevel(lw, level)
 			if err != nil {
 				return err
 			}
@@ -105,14 +136,45 @@ func compress(algo CompressionAlgo, dst, src []byte) (CompressionAlgo, int, erro
 			return gw.Close()
 		}()
 		if err == io.ErrShortWrite {
-			return compress(CompressionAlgo_NONE, dst, src)
+			return compress(CompressionAlgo_NONE, 0, dst, src)
 		}
 		return CompressionAlgo_GZIP_BEST_SPEED, lw.pos, err
+	case CompressionAlgo_ZSTD:
+		enc, err := zstdEncoder(level)
+		if err != nil {
+			return 0, 0, err
+		}
+		return compressed(algo, dst, src, enc.EncodeAll(src, nil))
+	case CompressionAlgo_LZ4:
+		if level < 0 || level >= len(lz4Levels) {
+			return 0, 0, errors.Errorf("invalid lz4 compression level %d", level)
+		}
+		var buf bytes.Buffer
+		lw := lz4.NewWriter(&buf)
+		if err := lw.Apply(lz4.CompressionLevelOption(lz4Levels[level])); err != nil {
+			return 0, 0, errors.EnsureStack(err)
+		}
+		if _, err := lw.Write(src); err != nil {
+			return 0, 0, errors.EnsureStack(err)
+		}
+		if err := lw.Close(); err != nil {
+			return 0, 0, errors.EnsureStack(err)
+		}
+		return compressed(algo, dst, src, buf.Bytes())
 	default:
 		return 0, 0, errors.Errorf("unrecognized compression: %v", algo)
 	}
 }
 
+// compressed copies data, which is src compressed with algo, to dst, unless
+// it's no smaller than src, in which case src is copied uncompressed.
+func compressed(algo CompressionAlgo, dst, src, data []byte) (CompressionAlgo, int, error) {
+	if len(data) >= len(src) {
+		return compress(CompressionAlgo_NONE, 0, dst, src)
+	}
+	return algo, copy(dst, data), nil
+}
+
 func decompress(algo CompressionAlgo, r io.Reader) (io.Reader, error) {
 	switch algo {
 	case CompressionAlgo_NONE:
@@ -123,11 +185,101 @@ func decompress(algo CompressionAlgo, r io.Reader) (io.Reader, error) {
 			return nil, err
 		}
 		return gr, nil
+	case CompressionAlgo_ZSTD:
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		data, err = zstdDecoder().DecodeAll(data, nil)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		return bytes.NewReader(data), nil
+	case CompressionAlgo_LZ4:
+		return lz4.NewReader(r), nil
 	default:
 		return nil, errors.Errorf("unrecognized compression: %v", algo)
 	}
 }
 
+// lz4Levels maps compression levels to lz4's, 0 being the fastest.
+var lz4Levels = []lz4.CompressionLevel{lz4.Fast, lz4.Level1, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5, lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9}
+
+var (
+	// zstdEncoders holds an encoder for each compression level that's been
+	// used. Encoders are safe to use concurrently with EncodeAll.
+	zstdEncoders   sync.Map
+	zstdDecoderVal *zstd.Decoder
+	zstdDecoderErr error
+	zstdDecoderOne sync.Once
+)
+
+func zstdEncoder(level int) (*zstd.Encoder, error) {
+	if enc, ok := zstdEncoders.Load(level); ok {
+		return enc.(*zstd.Encoder), nil
+	}
+	encLevel := zstd.SpeedDefault
+	if level > 0 {
+		encLevel = zstd.EncoderLevelFromZstd(level)
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encLevel), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	actual, _ := zstdEncoders.LoadOrStore(level, enc)
+	return actual.(*zstd.Encoder), nil
+}
+
+func zstdDecoder() *zstd.Decoder {
+	zstdDecoderOne.Do(func() {
+		zstdDecoderVal, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	if zstdDecoderErr != nil {
+		// NewReader only fails on invalid options, and none are passed.
+		panic(zstdDecoderErr)
+	}
+	return zstdDecoderVal
+}
+
+// ValidateCompression returns an error if level isn't a valid compression
+// level for algo.
+func ValidateCompression(algo CompressionAlgo, level int) error {
+	var max int
+	switch algo {
+	case CompressionAlgo_NONE:
+		max = 0
+	case CompressionAlgo_GZIP_BEST_SPEED:
+		max = gzip.BestCompression
+	case CompressionAlgo_ZSTD:
+		max = 22
+	case CompressionAlgo_LZ4:
+		max = len(lz4Levels) - 1
+	default:
+		return errors.Errorf("unrecognized compression: %v", algo)
+	}
+	if level < 0 || level > max {
+		return errors.Errorf("invalid %v compression level %d, it must be between 0 and %d", algo, level, max)
+	}
+	return nil
+}
+
+// ParseCompressionAlgo returns the compression algorithm called name, which
+// is one of none, gzip, zstd or lz4.
+func ParseCompressionAlgo(name string) (CompressionAlgo, error) {
+	switch strings.ToLower(name) {
+	case "none":
+		return CompressionAlgo_NONE, nil
+	case "gzip":
+		return CompressionAlgo_GZIP_BEST_SPEED, nil
+	case "zstd":
+		return CompressionAlgo_ZSTD, nil
+	case "lz4":
+		return CompressionAlgo_LZ4, nil
+	default:
+		return 0, errors.Errorf("unrecognized compression %q, it must be one of none, gzip, zstd or lz4", name)
+	}
+}
+
 type limitWriter struct {
 	buf []byte
 	pos int
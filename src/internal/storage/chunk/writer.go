@@ -6,6 +6,7 @@ import (
 
 	"github.com/chmduquesne/rollinghash/buzhash64"
 	units "github.com/docker/go-units"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/kv"
 )
 
@@ -47,6 +48,42 @@ type chunkSize struct {
 	avg, min, max int
 }
 
+// maxAverageBits is the largest average chunk size that can be configured,
+// 2^30 (1GB).
+const maxAverageBits = 30
+
+// ValidateChunking returns an error if chunks can't be split with an average
+// size of 2^averageBits, bounded by min and max, using a rolling hash window
+// of window bytes. Zero values are the defaults.
+func ValidateChunking(averageBits, min, max, window int) error {
+	if averageBits == 0 {
+		averageBits = defaultAverageBits
+	}
+	if min == 0 {
+		min = defaultMinChunkSize
+	}
+	if max == 0 {
+		max = defaultMaxChunkSize
+	}
+	if window == 0 {
+		window = WindowSize
+	}
+	if averageBits < 0 || averageBits > maxAverageBits {
+		return errors.Errorf("invalid average chunk size 2^%d, it must be at most 2^%d", averageBits, maxAverageBits)
+	}
+	if min < 0 || max < 0 || window < 0 {
+		return errors.Errorf("chunk sizes and the window size can't be negative")
+	}
+	avg := 1 << uint(averageBits)
+	if min > avg || avg > max {
+		return errors.Errorf("the average chunk size (%d) must be between the minimum (%d) and the maximum (%d)", avg, min, max)
+	}
+	if window > min {
+		return errors.Errorf("the rolling hash window (%d) can't be larger than the minimum chunk size (%d)", window, min)
+	}
+	return nil
+}
+
 // Writer splits a byte stream into content defined chunks that are hashed and deduplicated/uploaded to object storage.
 // Chunk split points are determined by a bit pattern in a rolling hash function (buzhash64 at https://github.com/chmduquesne/rollinghash).
 type Writer struct {
@@ -55,6 +92,7 @@ type Writer struct {
 	cb         WriterCallback
 	chunkSize  *chunkSize
 	splitMask  uint64
+	window     []byte
 	noUpload   bool
 	createOpts CreateOptions
 
@@ -84,10 +122,11 @@ func newWriter(ctx context.Context, client Client, memCache kv.GetPut, createOpt
 			min: defaultMinChunkSize,
 			max: defaultMaxChunkSize,
 		},
-		buf:   &bytes.Buffer{},
-		stats: &stats{},
-		chain: NewTaskChain(cancelCtx),
-		first: true,
+		window: initialWindow,
+		buf:    &bytes.Buffer{},
+		stats:  &stats{},
+		chain:  NewTaskChain(cancelCtx),
+		first:  true,
 	}
 	WithRollingHashConfig(defaultAverageBits, defaultSeed)(w)
 	for _, opt := range opts {
@@ -99,7 +138,7 @@ func newWriter(ctx context.Context, client Client, memCache kv.GetPut, createOpt
 
 func (w *Writer) resetHash() {
 	w.hash.Reset()
-	w.hash.Write(initialWindow)
+	w.hash.Write(w.window)
 }
 
 // AnnotationCount returns a count of the number of annotations created/referenced by
@@ -268,7 +307,7 @@ func (w *Writer) maybeUpload(ctx context.Context, chunkBytes []byte, pointsTo []
 			return w.client.Create(ctx, md, data)
 		}
 	}
-	return Create(ctx, CreateOptions{}, chunkBytes, createFunc)
+	return Create(ctx, w.createOpts, chunkBytes, createFunc)
 }
 
 func (w *Writer) getPointsTo(annotations []*Annotation) (pointsTo []ID) {
@@ -393,7 +432,7 @@ func (w *Writer) flushBuffer() error {
 
 func (w *Writer) flushDataRef(dataRef *DataRef) error {
 	buf := &bytes.Buffer{}
-	r := newDataReader(w.ctx, w.client, w.memCache, dataRef)
+	r := newDataReader(w.ctx, w.client, w.memCache, w.createOpts.KeyWrapper, dataRef)
 	if err := r.Get(buf); err != nil {
 		return err
 	}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"sort"
 	"strings"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
 )
 
 type Buffer struct {
@@ -16,6 +18,8 @@ type file struct {
 	path string
 	tag  string
 	buf  *bytes.Buffer
+	// meta is the metadata of the file, its data refs are unused.
+	meta index.File
 }
 
 func NewBuffer() *Buffer {
@@ -25,7 +29,7 @@ func NewBuffer() *Buffer {
 	}
 }
 
-func (b *Buffer) Add(path, tag string) io.Writer {
+func (b *Buffer) Add(path, tag string, opts ...FileOption) io.Writer {
 	path = Clean(path, false)
 	if _, ok := b.additive[path]; !ok {
 		b.additive[path] = make(map[string]*file)
@@ -39,6 +43,9 @@ func (b *Buffer) Add(path, tag string) io.Writer {
 		}
 	}
 	f := taggedFiles[tag]
+	for _, opt := range opts {
+		opt(&f.meta)
+	}
 	return f.buf
 }
 
@@ -47,9 +54,12 @@ func (b *Buffer) Delete(path, tag string) {
 	if IsDir(path) {
 		// TODO: Linear scan for directory delete is less than ideal.
 		// Fine for now since this should be rare and is an in-memory operation.
-		for file := range b.additive {
+		for file, taggedFiles := range b.additive {
 			if strings.HasPrefix(file, path) {
-				delete(b.additive, file)
+				delete(taggedFiles, tag)
+				if len(taggedFiles) == 0 {
+					delete(b.additive, file)
+				}
 			}
 		}
 		return
@@ -68,14 +78,31 @@ func (b *Buffer) Delete(path, tag string) {
 }
 
 func (b *Buffer) WalkAdditive(cb func(path, tag string, r io.Reader) error) error {
+	return b.walkAdditive(func(path, tag string, r io.Reader, _ ...FileOption) error {
+		return cb(path, tag, r)
+	})
+}
+
+func (b *Buffer) walkAdditive(cb func(path, tag string, r io.Reader, opts ...FileOption) error) error {
 	for _, file := range sortFiles(b.additive) {
-		if err := cb(file.path, file.tag, bytes.NewReader(file.buf.Bytes())); err != nil {
+		if err := cb(file.path, file.tag, bytes.NewReader(file.buf.Bytes()), withMetadata(&file.meta)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// withMetadata copies the metadata of src, other than the tag and data refs.
+func withMetadata(src *index.File) FileOption {
+	return func(f *index.File) {
+		f.Mode = src.Mode
+		f.Mtime = src.Mtime
+		f.LinkTarget = src.LinkTarget
+		f.Metadata = src.Metadata
+		f.CopiedFrom = src.CopiedFrom
+	}
+}
+
 func sortFiles(files map[string]map[string]*file) []*file {
 	var result []*file
 	for _, taggedFiles := range files {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -259,3 +260,105 @@ func TestStableHash(t *testing.T) {
 	}
 	require.True(t, bytes.Equal(stableHash, getHash()), msg)
 }
+
+func TestMode(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t)
+	var ids []ID
+	write := func(data string, opts ...FileOption) {
+		w := storage.NewWriter(ctx)
+		require.NoError(t, w.Add("/test", DefaultFileTag, strings.NewReader(data), opts...))
+		id, err := w.Close()
+		require.NoError(t, err)
+		ids = append(ids, *id)
+	}
+	getMode := func() uint32 {
+		fs, err := storage.Open(ctx, ids)
+		require.NoError(t, err)
+		var mode uint32
+		require.NoError(t, fs.Iterate(ctx, func(f File) error {
+			mode = f.Index().File.Mode
+			return nil
+		}))
+		return mode
+	}
+	write("#!/bin/sh\n", WithMode(0755))
+	require.Equal(t, uint32(0755), getMode())
+	// Appending without a mode keeps the existing mode.
+	write("echo foo\n")
+	require.Equal(t, uint32(0755), getMode())
+	write("echo bar\n", WithMode(0644))
+	require.Equal(t, uint32(0644), getMode())
+	// Compaction preserves the mode.
+	id, err := storage.Compact(ctx, ids, time.Minute)
+	require.NoError(t, err)
+	ids = []ID{*id}
+	require.Equal(t, uint32(0644), getMode())
+}
+
+func TestLinkTarget(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t)
+	var ids []ID
+	write := func(data string, opts ...FileOption) {
+		w := storage.NewWriter(ctx)
+		require.NoError(t, w.Add("/test", DefaultFileTag, strings.NewReader(data), opts...))
+		id, err := w.Close()
+		require.NoError(t, err)
+		ids = append(ids, *id)
+	}
+	getLinkTarget := func() string {
+		fs, err := storage.Open(ctx, ids)
+		require.NoError(t, err)
+		var target string
+		require.NoError(t, fs.Iterate(ctx, func(f File) error {
+			target = f.Index().File.LinkTarget
+			return nil
+		}))
+		return target
+	}
+	write("", WithLinkTarget("/a"))
+	require.Equal(t, "/a", getLinkTarget())
+	write("", WithLinkTarget("/b"))
+	require.Equal(t, "/b", getLinkTarget())
+	// Compaction preserves the link target.
+	id, err := storage.Compact(ctx, ids, time.Minute)
+	require.NoError(t, err)
+	ids = []ID{*id}
+	require.Equal(t, "/b", getLinkTarget())
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestStorage(t)
+	var ids []ID
+	write := func(data string, opts ...FileOption) {
+		w := storage.NewWriter(ctx)
+		require.NoError(t, w.Add("/test", DefaultFileTag, strings.NewReader(data), opts...))
+		id, err := w.Close()
+		require.NoError(t, err)
+		ids = append(ids, *id)
+	}
+	getMetadata := func() map[string]string {
+		fs, err := storage.Open(ctx, ids)
+		require.NoError(t, err)
+		var metadata map[string]string
+		require.NoError(t, fs.Iterate(ctx, func(f File) error {
+			metadata = f.Index().File.Metadata
+			return nil
+		}))
+		return metadata
+	}
+	write("a,b\n", WithMetadata(map[string]string{"schema": "v1", "type": "text/csv"}))
+	require.Equal(t, map[string]string{"schema": "v1", "type": "text/csv"}, getMetadata())
+	// Appending merges the metadata.
+	write("1,2\n", WithMetadata(map[string]string{"schema": "v2"}))
+	require.Equal(t, map[string]string{"schema": "v2", "type": "text/csv"}, getMetadata())
+	write("3,4\n")
+	require.Equal(t, map[string]string{"schema": "v2", "type": "text/csv"}, getMetadata())
+	// Compaction preserves the metadata.
+	id, err := storage.Compact(ctx, ids, time.Minute)
+	require.NoError(t, err)
+	ids = []ID{*id}
+	require.Equal(t, map[string]string{"schema": "v2", "type": "text/csv"}, getMetadata())
+}
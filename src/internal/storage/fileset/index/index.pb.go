@@ -6,7 +6,9 @@ package index
 import (
 	fmt "fmt"
 	proto "github.com/gogo/protobuf/proto"
+	types "github.com/gogo/protobuf/types"
 	chunk "github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	pfs "github.com/pachyderm/pachyderm/v2/src/pfs"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -151,11 +153,22 @@ func (m *Range) GetChunkRef() *chunk.DataRef {
 }
 
 type File struct {
-	Tag                  string           `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
-	DataRefs             []*chunk.DataRef `protobuf:"bytes,2,rep,name=data_refs,json=dataRefs,proto3" json:"data_refs,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	Tag      string           `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	DataRefs []*chunk.DataRef `protobuf:"bytes,2,rep,name=data_refs,json=dataRefs,proto3" json:"data_refs,omitempty"`
+	// mode is the file's permission bits, or 0 if they weren't set.
+	Mode uint32 `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	// mtime is the time the file was last modified.
+	Mtime *types.Timestamp `protobuf:"bytes,4,opt,name=mtime,proto3" json:"mtime,omitempty"`
+	// link_target is the path that the file links to, if it's a symlink.
+	LinkTarget string `protobuf:"bytes,5,opt,name=link_target,json=linkTarget,proto3" json:"link_target,omitempty"`
+	// metadata is user-provided key/value pairs describing the file.
+	Metadata map[string]string `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// copied_from is the file that the file was copied from, if it was copied
+	// by CopyFile.
+	CopiedFrom           *pfs.File `protobuf:"bytes,7,opt,name=copied_from,json=copiedFrom,proto3" json:"copied_from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *File) Reset()         { *m = File{} }
@@ -205,10 +218,46 @@ func (m *File) GetDataRefs() []*chunk.DataRef {
 	return nil
 }
 
+func (m *File) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *File) GetMtime() *types.Timestamp {
+	if m != nil {
+		return m.Mtime
+	}
+	return nil
+}
+
+func (m *File) GetLinkTarget() string {
+	if m != nil {
+		return m.LinkTarget
+	}
+	return ""
+}
+
+func (m *File) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *File) GetCopiedFrom() *pfs.File {
+	if m != nil {
+		return m.CopiedFrom
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Index)(nil), "index.Index")
 	proto.RegisterType((*Range)(nil), "index.Range")
 	proto.RegisterType((*File)(nil), "index.File")
+	proto.RegisterMapType((map[string]string)(nil), "index.File.MetadataEntry")
 }
 
 func init() {
@@ -216,26 +265,36 @@ func init() {
 }
 
 var fileDescriptor_dfa1b84c403551af = []byte{
-	// 297 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x51, 0xcd, 0x4a, 0xc4, 0x30,
-	0x10, 0x26, 0xfd, 0x59, 0xb6, 0x59, 0x11, 0xc9, 0x41, 0x8a, 0x42, 0x2d, 0x3d, 0x2d, 0x0a, 0x0d,
-	0xac, 0x6f, 0x20, 0xab, 0xe0, 0x4d, 0x72, 0xf4, 0xb2, 0x66, 0xdb, 0x49, 0x1b, 0xac, 0x6d, 0x49,
-	0xb2, 0xa2, 0x6f, 0xe8, 0xd1, 0x47, 0x90, 0x3e, 0x89, 0x24, 0xe9, 0x41, 0x50, 0xbc, 0x0c, 0xdf,
-	0xcc, 0x7c, 0x33, 0xdf, 0x37, 0x09, 0xbe, 0x94, 0xbd, 0x01, 0xd5, 0xf3, 0x8e, 0x6a, 0x33, 0x28,
-	0xde, 0x00, 0x15, 0xb2, 0x03, 0x0d, 0x86, 0xca, 0xbe, 0x86, 0x37, 0x1f, 0xcb, 0x51, 0x0d, 0x66,
-	0x20, 0xb1, 0x4b, 0xce, 0x8a, 0x5f, 0x23, 0x55, 0x7b, 0xe8, 0x9f, 0x7d, 0xf4, 0xd4, 0xe2, 0x09,
-	0xc7, 0xf7, 0x96, 0x4c, 0x08, 0x8e, 0x46, 0x6e, 0xda, 0x14, 0xe5, 0x68, 0x9d, 0x30, 0x87, 0x49,
-	0x81, 0x63, 0xc5, 0xfb, 0x06, 0xd2, 0x20, 0x47, 0xeb, 0xd5, 0xe6, 0xa8, 0xf4, 0x22, 0xcc, 0xd6,
-	0x98, 0x6f, 0x91, 0x0b, 0x1c, 0x59, 0x23, 0x69, 0xe8, 0x28, 0xab, 0x99, 0x72, 0x27, 0x3b, 0x60,
-	0xae, 0x51, 0x48, 0x1c, 0xbb, 0x01, 0x72, 0x8a, 0x17, 0x83, 0x10, 0x1a, 0x8c, 0xd3, 0x08, 0xd9,
-	0x9c, 0x91, 0x73, 0x9c, 0x74, 0x5c, 0x9b, 0x9d, 0x93, 0x0f, 0x9c, 0xfc, 0xd2, 0x16, 0x1e, 0xac,
-	0x85, 0x2b, 0x9c, 0x38, 0xbb, 0x3b, 0x05, 0x62, 0xd6, 0x38, 0x2e, 0xfd, 0x01, 0x5b, 0x6e, 0x38,
-	0x03, 0xc1, 0x96, 0x2e, 0x65, 0x20, 0x8a, 0x5b, 0x1c, 0x59, 0x61, 0x72, 0x82, 0x43, 0xc3, 0x9b,
-	0xf9, 0x14, 0x0b, 0xed, 0x9a, 0x9a, 0x1b, 0x6e, 0xb7, 0xe8, 0x34, 0xc8, 0xc3, 0xbf, 0xd6, 0xd4,
-	0x1e, 0xe8, 0x1b, 0xf6, 0x31, 0x65, 0xe8, 0x73, 0xca, 0xd0, 0xd7, 0x94, 0xa1, 0xc7, 0x6d, 0x23,
-	0x4d, 0x7b, 0xd8, 0x97, 0xd5, 0xf0, 0x42, 0x47, 0x5e, 0xb5, 0xef, 0x35, 0xa8, 0x9f, 0xe8, 0x75,
-	0x43, 0xb5, 0xaa, 0xe8, 0xff, 0xdf, 0xb3, 0x5f, 0xb8, 0xe7, 0xbe, 0xfe, 0x0e, 0x00, 0x00, 0xff,
-	0xff, 0xc3, 0x95, 0x66, 0xf1, 0xc7, 0x01, 0x00, 0x00,
+	// 460 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0x51, 0xab, 0xd3, 0x30,
+	0x14, 0xa6, 0xdd, 0x3a, 0xb7, 0x33, 0x27, 0x12, 0x44, 0xea, 0x84, 0x6d, 0xf4, 0xe9, 0xa2, 0xd8,
+	0xca, 0x44, 0x10, 0x7d, 0x93, 0xeb, 0x05, 0x1f, 0x04, 0x09, 0xf7, 0xc9, 0x97, 0x9a, 0xb5, 0x27,
+	0x5d, 0x58, 0xdb, 0x94, 0x24, 0x1b, 0xee, 0x1f, 0xfa, 0xa6, 0x3f, 0x41, 0xf6, 0x4b, 0x24, 0x49,
+	0x27, 0x13, 0xe5, 0xbe, 0x84, 0x73, 0x4e, 0xbe, 0x7e, 0xdf, 0x97, 0xef, 0x14, 0x9e, 0x89, 0xd6,
+	0xa0, 0x6a, 0x59, 0x9d, 0x69, 0x23, 0x15, 0xab, 0x30, 0xe3, 0xa2, 0x46, 0x8d, 0x26, 0x13, 0x6d,
+	0x89, 0xdf, 0xfc, 0x99, 0x76, 0x4a, 0x1a, 0x49, 0x22, 0xd7, 0xcc, 0x97, 0x95, 0x94, 0x55, 0x8d,
+	0x99, 0x1b, 0x6e, 0xf6, 0x3c, 0x33, 0xa2, 0x41, 0x6d, 0x58, 0xd3, 0x79, 0xdc, 0x3c, 0xf9, 0x87,
+	0xb3, 0xd8, 0xee, 0xdb, 0x9d, 0x3f, 0x7b, 0xcc, 0xac, 0xe3, 0x3a, 0xeb, 0xb8, 0xf6, 0x6d, 0xf2,
+	0x15, 0xa2, 0x8f, 0x96, 0x9c, 0x10, 0x18, 0x76, 0xcc, 0x6c, 0xe3, 0x60, 0x15, 0x5c, 0x4d, 0xa8,
+	0xab, 0x49, 0x02, 0x91, 0x62, 0x6d, 0x85, 0x71, 0xb8, 0x0a, 0xae, 0xa6, 0xeb, 0xfb, 0xa9, 0x37,
+	0x45, 0xed, 0x8c, 0xfa, 0x2b, 0xb2, 0x84, 0xa1, 0x35, 0x1e, 0x0f, 0x1c, 0x64, 0xda, 0x43, 0x6e,
+	0x44, 0x8d, 0xd4, 0x5d, 0x24, 0x02, 0x22, 0xf7, 0x01, 0x79, 0x0c, 0x23, 0xc9, 0xb9, 0x46, 0xe3,
+	0x34, 0x06, 0xb4, 0xef, 0xc8, 0x53, 0x98, 0xd4, 0x4c, 0x9b, 0xdc, 0xc9, 0x87, 0x4e, 0x7e, 0x6c,
+	0x07, 0x9f, 0xad, 0x85, 0xe7, 0x30, 0x71, 0xee, 0x73, 0x85, 0xbc, 0xd7, 0x78, 0x90, 0xfa, 0xf7,
+	0x5c, 0x33, 0xc3, 0x28, 0x72, 0x3a, 0x76, 0x2d, 0x45, 0x9e, 0xfc, 0x08, 0x61, 0x68, 0x95, 0xc9,
+	0x43, 0x18, 0x18, 0x56, 0xf5, 0x6f, 0xb1, 0xa5, 0xe5, 0x29, 0x99, 0x61, 0x96, 0x46, 0xc7, 0xe1,
+	0x6a, 0xf0, 0x3f, 0x9e, 0xd2, 0x17, 0xda, 0x66, 0xd1, 0xc8, 0xd2, 0xbf, 0x69, 0x46, 0x5d, 0x4d,
+	0x5e, 0x42, 0xd4, 0xd8, 0xbc, 0xe3, 0xa1, 0x33, 0x31, 0x4f, 0xfd, 0x32, 0xd2, 0xf3, 0x32, 0xd2,
+	0xdb, 0xf3, 0x32, 0xa8, 0x07, 0x92, 0x25, 0x4c, 0x6b, 0xd1, 0xee, 0x72, 0xc3, 0x54, 0x85, 0x26,
+	0x8e, 0x9c, 0x19, 0xb0, 0xa3, 0x5b, 0x37, 0x21, 0xaf, 0x61, 0xdc, 0xa0, 0x61, 0x56, 0x36, 0x1e,
+	0x39, 0x4b, 0x4f, 0x2e, 0xe2, 0x4b, 0x3f, 0xf5, 0x77, 0x1f, 0x5a, 0xa3, 0x8e, 0xf4, 0x0f, 0x94,
+	0xbc, 0x80, 0x69, 0x21, 0x3b, 0x81, 0x65, 0xce, 0x95, 0x6c, 0xe2, 0x7b, 0xfd, 0x6e, 0x3a, 0xae,
+	0xf3, 0xc3, 0xda, 0x27, 0x0f, 0x1e, 0x70, 0xa3, 0x64, 0x33, 0x7f, 0x07, 0xb3, 0xbf, 0x98, 0x6c,
+	0x38, 0x3b, 0x3c, 0x9e, 0xc3, 0xd9, 0xe1, 0x91, 0x3c, 0x82, 0xe8, 0xc0, 0xea, 0x3d, 0xf6, 0xe9,
+	0xfb, 0xe6, 0x6d, 0xf8, 0x26, 0x78, 0x4f, 0xbf, 0x9f, 0x16, 0xc1, 0xcf, 0xd3, 0x22, 0xf8, 0x75,
+	0x5a, 0x04, 0x5f, 0xae, 0x2b, 0x61, 0xb6, 0xfb, 0x4d, 0x5a, 0xc8, 0x26, 0xeb, 0x58, 0xb1, 0x3d,
+	0x96, 0xa8, 0x2e, 0xab, 0xc3, 0x3a, 0xd3, 0xaa, 0xc8, 0xee, 0xfe, 0xb3, 0x37, 0x23, 0x17, 0xd9,
+	0xab, 0xdf, 0x03, 0x00, 0x37, 0x42, 0x45, 0x3c, 0x02, 0x03, 0x00, 0x00,
 }
 
 func (m *Index) Marshal() (dAtA []byte, err error) {
@@ -371,6 +430,61 @@ func (m *File) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.CopiedFrom != nil {
+		{
+			size, err := m.CopiedFrom.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Metadata) > 0 {
+		for k := range m.Metadata {
+			v := m.Metadata[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintIndex(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintIndex(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintIndex(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if len(m.LinkTarget) > 0 {
+		i -= len(m.LinkTarget)
+		copy(dAtA[i:], m.LinkTarget)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.LinkTarget)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Mtime != nil {
+		{
+			size, err := m.Mtime.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Mode != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Mode))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.DataRefs) > 0 {
 		for iNdEx := len(m.DataRefs) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -469,6 +583,29 @@ func (m *File) Size() (n int) {
 			n += 1 + l + sovIndex(uint64(l))
 		}
 	}
+	if m.Mode != 0 {
+		n += 1 + sovIndex(uint64(m.Mode))
+	}
+	if m.Mtime != nil {
+		l = m.Mtime.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	l = len(m.LinkTarget)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if len(m.Metadata) > 0 {
+		for k, v := range m.Metadata {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovIndex(uint64(len(k))) + 1 + len(v) + sovIndex(uint64(len(v)))
+			n += mapEntrySize + 1 + sovIndex(uint64(mapEntrySize))
+		}
+	}
+	if m.CopiedFrom != nil {
+		l = m.CopiedFrom.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -869,6 +1006,256 @@ func (m *File) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+			}
+			m.Mode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mode |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mtime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Mtime == nil {
+				m.Mtime = &types.Timestamp{}
+			}
+			if err := m.Mtime.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LinkTarget", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LinkTarget = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthIndex
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthIndex
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthIndex
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthIndex
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipIndex(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthIndex
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Metadata[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CopiedFrom", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.CopiedFrom == nil {
+				m.CopiedFrom = &pfs.File{}
+			}
+			if err := m.CopiedFrom.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIndex(dAtA[iNdEx:])
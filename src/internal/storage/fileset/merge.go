@@ -5,9 +5,11 @@ import (
 	"io"
 	"strings"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
 	"github.com/pachyderm/pachyderm/v2/src/internal/stream"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
 )
 
 // MergeReader is an abstraction for reading merged filesets.
@@ -58,19 +60,48 @@ func (mr *MergeReader) iterate(ctx context.Context, cb func(File) error) error {
 			return cb(newFileReader(ctx, mr.chunks, fss[0].file.Index()))
 		}
 		var dataRefs []*chunk.DataRef
+		var mode uint32
+		var mtime *types.Timestamp
+		var linkTarget string
+		var metadata map[string]string
+		var copiedFrom *pfs.File
 		for i, fs := range fss {
 			if fs.deletive {
 				if i == len(fss)-1 {
 					return nil
 				}
 				dataRefs = nil
+				mode = 0
+				mtime = nil
+				linkTarget = ""
+				metadata = nil
+				copiedFrom = nil
 				continue
 			}
 			idx := fs.file.Index()
 			dataRefs = append(dataRefs, idx.File.DataRefs...)
+			// The most recently set metadata wins.
+			if idx.File.Mode != 0 {
+				mode = idx.File.Mode
+			}
+			if idx.File.Mtime != nil {
+				mtime = idx.File.Mtime
+			}
+			if idx.File.LinkTarget != "" {
+				linkTarget = idx.File.LinkTarget
+			}
+			metadata = mergeMetadata(metadata, idx.File.Metadata)
+			if idx.File.CopiedFrom != nil {
+				copiedFrom = idx.File.CopiedFrom
+			}
 		}
 		mergeIdx := fss[0].file.Index()
 		mergeIdx.File.DataRefs = dataRefs
+		mergeIdx.File.Mode = mode
+		mergeIdx.File.Mtime = mtime
+		mergeIdx.File.LinkTarget = linkTarget
+		mergeIdx.File.Metadata = metadata
+		mergeIdx.File.CopiedFrom = copiedFrom
 		return cb(newMergeFileReader(ctx, mr.chunks, mergeIdx))
 
 	})
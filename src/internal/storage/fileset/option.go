@@ -3,7 +3,9 @@ package fileset
 import (
 	"time"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/renew"
 	"golang.org/x/sync/semaphore"
@@ -71,6 +73,74 @@ func WithValidator(validator func(string) error) UnorderedWriterOption {
 	}
 }
 
+// WithWriterOptions sets options for the file set writers that the
+// UnorderedWriter serializes its buffered files with.
+func WithWriterOptions(opts ...WriterOption) UnorderedWriterOption {
+	return func(uw *UnorderedWriter) {
+		uw.writerOpts = append(uw.writerOpts, opts...)
+	}
+}
+
+// WithBufferReservation makes the UnorderedWriter reserve the memory that it
+// buffers content in, size bytes at a time, with reserve, before buffering
+// it. The reserved bytes are given back with release once the buffered
+// content is written out, or the writer is closed. If reserve fails, the
+// buffer is written out to give back what it holds, and reserve is tried
+// again before the error is returned.
+func WithBufferReservation(size int64, reserve func(int64) error, release func(int64)) UnorderedWriterOption {
+	return func(uw *UnorderedWriter) {
+		uw.reservationSize = size
+		uw.reserve = reserve
+		uw.release = release
+	}
+}
+
+// FileOption configures the metadata of a file written to a file set.
+type FileOption func(*index.File)
+
+// WithMode sets the permission bits of a file.
+func WithMode(mode uint32) FileOption {
+	return func(f *index.File) {
+		f.Mode = mode
+	}
+}
+
+// WithModTime sets the modification time of a file.
+func WithModTime(mtime *types.Timestamp) FileOption {
+	return func(f *index.File) {
+		f.Mtime = mtime
+	}
+}
+
+// WithLinkTarget makes a file a symlink to target.
+func WithLinkTarget(target string) FileOption {
+	return func(f *index.File) {
+		f.LinkTarget = target
+	}
+}
+
+// WithMetadata adds metadata to a file, overwriting values with the same keys.
+func WithMetadata(metadata map[string]string) FileOption {
+	return func(f *index.File) {
+		f.Metadata = mergeMetadata(f.Metadata, metadata)
+	}
+}
+
+// mergeMetadata returns a copy of dst with the values in src set.
+func mergeMetadata(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	merged := make(map[string]string, len(dst)+len(src))
+	for k, v := range dst {
+		merged[k] = v
+	}
+	for k, v := range src {
+		merged[k] = v
+	}
+	return merged
+}
+
 // WriterOption configures a file set writer.
 type WriterOption func(w *Writer)
 
@@ -89,6 +159,33 @@ func WithTTL(ttl time.Duration) WriterOption {
 	}
 }
 
+// WithCompression compresses the content of the files written with algo at
+// level, rather than with the chunk storage's compression. Indexes still use
+// the chunk storage's compression.
+func WithCompression(algo chunk.CompressionAlgo, level int) WriterOption {
+	return func(w *Writer) {
+		w.chunkWriterOpts = append(w.chunkWriterOpts, chunk.WithCompressionOverride(algo, level))
+	}
+}
+
+// WithChunking splits the content of the files written into chunks with an
+// average size of 2^averageBits, bounded by min and max, using a rolling hash
+// window of window bytes. Zero values keep the chunk storage's defaults, and
+// indexes are always chunked with them.
+func WithChunking(averageBits, min, max, window int) WriterOption {
+	return func(w *Writer) {
+		if averageBits > 0 {
+			w.chunkWriterOpts = append(w.chunkWriterOpts, chunk.WithAverageBits(averageBits))
+		}
+		if min > 0 || max > 0 {
+			w.chunkWriterOpts = append(w.chunkWriterOpts, chunk.WithMinMax(min, max))
+		}
+		if window > 0 {
+			w.chunkWriterOpts = append(w.chunkWriterOpts, chunk.WithWindowSize(window))
+		}
+	}
+}
+
 // StorageOptions returns the fileset storage options for the config.
 func StorageOptions(conf *serviceenv.Configuration) []StorageOption {
 	var opts []StorageOption
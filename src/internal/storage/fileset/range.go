@@ -0,0 +1,87 @@
+package fileset
+
+import (
+	"context"
+	"io"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
+)
+
+// RangeDataRefs returns data refs to at most length bytes of the data that
+// dataRefs refer to, starting at offset. The data from offset to the end is
+// referred to if length is 0.
+func RangeDataRefs(dataRefs []*chunk.DataRef, offset, length int64) []*chunk.DataRef {
+	var ranged []*chunk.DataRef
+	for _, dataRef := range dataRefs {
+		if offset >= dataRef.SizeBytes {
+			offset -= dataRef.SizeBytes
+			continue
+		}
+		ref := proto.Clone(dataRef).(*chunk.DataRef)
+		if offset > 0 || (length > 0 && length < ref.SizeBytes-offset) {
+			// The hash is of the whole data ref, so it doesn't apply to a
+			// part of it.
+			ref.Hash = nil
+		}
+		ref.OffsetBytes += offset
+		ref.SizeBytes -= offset
+		offset = 0
+		if length > 0 {
+			if ref.SizeBytes >= length {
+				ref.SizeBytes = length
+				ranged = append(ranged, ref)
+				break
+			}
+			length -= ref.SizeBytes
+		}
+		ranged = append(ranged, ref)
+	}
+	return ranged
+}
+
+var _ File = &rangeFile{}
+
+// rangeFile is part of the content of a file.
+type rangeFile struct {
+	ctx    context.Context
+	chunks *chunk.Storage
+	idx    *index.Index
+}
+
+// NewRangeFile returns a File with at most length bytes of the content of f,
+// starting at offset, or all of its content from offset if length is 0. Only
+// the chunks that hold that content are read.
+func NewRangeFile(ctx context.Context, chunks *chunk.Storage, f File, offset, length int64) File {
+	idx := proto.Clone(f.Index()).(*index.Index)
+	if idx.File != nil {
+		idx.File.DataRefs = RangeDataRefs(idx.File.DataRefs, offset, length)
+	}
+	return &rangeFile{
+		ctx:    ctx,
+		chunks: chunks,
+		idx:    idx,
+	}
+}
+
+// Index returns the index for the file, which refers only to the data in
+// the range.
+func (rf *rangeFile) Index() *index.Index {
+	return rf.idx
+}
+
+// Content writes the content of the file in the range.
+func (rf *rangeFile) Content(w io.Writer) error {
+	if rf.idx.File == nil {
+		return nil
+	}
+	return rf.chunks.NewReader(rf.ctx, rf.idx.File.DataRefs).Get(w)
+}
+
+// Hash returns an error, because only whole files are hashed.
+func (rf *rangeFile) Hash() ([]byte, error) {
+	return nil, errors.Errorf("cannot hash part of a file")
+}
@@ -0,0 +1,34 @@
+package fileset
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+)
+
+func TestRangeDataRefs(t *testing.T) {
+	dataRefs := []*chunk.DataRef{
+		{Hash: []byte("a"), OffsetBytes: 0, SizeBytes: 10},
+		{Hash: []byte("b"), OffsetBytes: 5, SizeBytes: 10},
+		{Hash: []byte("c"), OffsetBytes: 0, SizeBytes: 10},
+	}
+	type span struct{ offset, size int64 }
+	spans := func(refs []*chunk.DataRef) []span {
+		var ss []span
+		for _, ref := range refs {
+			ss = append(ss, span{ref.OffsetBytes, ref.SizeBytes})
+		}
+		return ss
+	}
+	require.Equal(t, spans(dataRefs), spans(RangeDataRefs(dataRefs, 0, 0)))
+	require.Equal(t, []span{{7, 8}, {0, 2}}, spans(RangeDataRefs(dataRefs, 12, 10)))
+	require.Equal(t, []span{{5, 10}}, spans(RangeDataRefs(dataRefs, 10, 10)))
+	require.Equal(t, 0, len(RangeDataRefs(dataRefs, 30, 0)))
+	// Whole data refs keep their hash, parts of them don't.
+	ranged := RangeDataRefs(dataRefs, 10, 15)
+	require.Equal(t, []byte("b"), ranged[0].Hash)
+	require.Equal(t, 0, len(ranged[1].Hash))
+	// The original data refs are unchanged.
+	require.Equal(t, int64(10), dataRefs[1].SizeBytes)
+}
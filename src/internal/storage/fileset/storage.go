@@ -32,6 +32,9 @@ const (
 
 	// TrackerPrefix is used for creating tracker objects for filesets
 	TrackerPrefix = "fileset/"
+	// HoldTrackerPrefix is used for creating tracker objects for holds on
+	// filesets.
+	HoldTrackerPrefix = "hold/"
 
 	// DefaultFileTag is the default file tag.
 	DefaultFileTag = "default"
@@ -241,6 +244,26 @@ func (s *Storage) Drop(ctx context.Context, id ID) error {
 	return err
 }
 
+// DropTx is identical to Drop except it runs in the provided transaction.
+func (s *Storage) DropTx(tx *sqlx.Tx, id ID) error {
+	return s.tracker.SetTTLTx(tx, id.TrackerID(), track.ExpireNow)
+}
+
+// HoldTx keeps the fileset at id alive on behalf of holder for ttl, or until
+// the hold is released, whichever is first.
+func (s *Storage) HoldTx(tx *sqlx.Tx, holder string, id ID, ttl time.Duration) error {
+	return s.tracker.CreateTx(tx, holdTrackerID(holder, id), []string{id.TrackerID()}, ttl)
+}
+
+// ReleaseTx releases holder's hold on the fileset at id, if it has one.
+func (s *Storage) ReleaseTx(tx *sqlx.Tx, holder string, id ID) error {
+	return s.tracker.DeleteTx(tx, holdTrackerID(holder, id))
+}
+
+func holdTrackerID(holder string, id ID) string {
+	return HoldTrackerPrefix + holder + "/" + id.HexString()
+}
+
 // SetTTL sets the time-to-live for the fileset at id
 func (s *Storage) SetTTL(ctx context.Context, id ID, ttl time.Duration) (time.Time, error) {
 	oid := id.TrackerID()
@@ -260,6 +283,39 @@ func (s *Storage) SizeOf(ctx context.Context, id ID) (int64, error) {
 	return total, nil
 }
 
+// Chunks calls cb with the ID of each chunk that the fileset at id references,
+// including the chunks that hold its index.
+func (s *Storage) Chunks(ctx context.Context, id ID, cb func(chunk.ID) error) error {
+	seen := make(map[string]struct{})
+	var walk func(oid string) error
+	walk = func(oid string) error {
+		if _, ok := seen[oid]; ok {
+			return nil
+		}
+		seen[oid] = struct{}{}
+		if strings.HasPrefix(oid, chunk.TrackerPrefix) {
+			chunkID, err := chunk.ParseTrackerID(oid)
+			if err != nil {
+				return err
+			}
+			if err := cb(chunkID); err != nil {
+				return err
+			}
+		}
+		downstream, err := s.tracker.GetDownstream(ctx, oid)
+		if err != nil {
+			return err
+		}
+		for _, oid := range downstream {
+			if err := walk(oid); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(id.TrackerID())
+}
+
 // WithRenewer calls cb with a Renewer, and a context which will be canceled if the renewer is unable to renew a path.
 func (s *Storage) WithRenewer(ctx context.Context, ttl time.Duration, cb func(context.Context, *renew.StringSet) error) error {
 	rf := func(ctx context.Context, idHexStr string, ttl time.Duration) error {
@@ -278,6 +334,21 @@ func (s *Storage) GC(ctx context.Context) error {
 	return s.newGC().RunForever(ctx)
 }
 
+// CollectGarbage deletes the filesets and chunks that are no longer
+// referenced until there are none left, and returns the number of objects it
+// deleted.
+func (s *Storage) CollectGarbage(ctx context.Context) (int, error) {
+	gc := s.newGC()
+	var total int
+	for {
+		n, err := gc.RunOnce(ctx)
+		total += n
+		if err != nil || n == 0 {
+			return total, err
+		}
+	}
+}
+
 func (s *Storage) newGC() *track.GarbageCollector {
 	const period = 10 * time.Second
 	tmpDeleter := track.NewTmpDeleter()
@@ -300,6 +371,32 @@ func (s *Storage) newGC() *track.GarbageCollector {
 	return track.NewGarbageCollector(s.tracker, period, mux)
 }
 
+// Exists returns false if the fileset with id, or one of the filesets it's
+// composed of, doesn't exist.
+func (s *Storage) Exists(ctx context.Context, id ID) (bool, error) {
+	md, err := s.store.Get(ctx, id)
+	if err != nil {
+		if err == ErrFileSetNotExists {
+			return false, nil
+		}
+		return false, err
+	}
+	composite, ok := md.Value.(*Metadata_Composite)
+	if !ok {
+		return true, nil
+	}
+	ids, err := composite.Composite.PointsTo()
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if exists, err := s.Exists(ctx, id); err != nil || !exists {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s *Storage) exists(ctx context.Context, id ID) (bool, error) {
 	_, err := s.store.Get(ctx, id)
 	if err != nil {
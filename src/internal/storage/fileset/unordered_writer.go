@@ -3,6 +3,7 @@ package fileset
 import (
 	"context"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
@@ -23,6 +24,14 @@ type UnorderedWriter struct {
 	ids                        []ID
 	parentID                   *ID
 	validator                  func(string) error
+	writerOpts                 []WriterOption
+	// reserve and release, if set, account for the buffered content, see
+	// WithBufferReservation. reserved is the number of bytes reserved for
+	// the current buffer.
+	reservationSize int64
+	reserve         func(int64) error
+	release         func(int64)
+	reserved        int64
 }
 
 func newUnorderedWriter(ctx context.Context, storage *Storage, memThreshold int64, opts ...UnorderedWriterOption) (*UnorderedWriter, error) {
@@ -42,7 +51,8 @@ func newUnorderedWriter(ctx context.Context, storage *Storage, memThreshold int6
 	return uw, nil
 }
 
-func (uw *UnorderedWriter) Put(p, tag string, appendFile bool, r io.Reader) (retErr error) {
+// Put writes the content of r to the file at p. opts set the metadata of the file.
+func (uw *UnorderedWriter) Put(p, tag string, appendFile bool, r io.Reader, opts ...FileOption) (retErr error) {
 	if err := uw.validate(p); err != nil {
 		return err
 	}
@@ -52,9 +62,22 @@ func (uw *UnorderedWriter) Put(p, tag string, appendFile bool, r io.Reader) (ret
 	if !appendFile {
 		uw.buffer.Delete(p, tag)
 	}
-	w := uw.buffer.Add(p, tag)
+	w := uw.buffer.Add(p, tag, opts...)
 	for {
-		n, err := io.CopyN(w, r, uw.memAvailable)
+		n := uw.memAvailable
+		if uw.reserve != nil {
+			if uw.reserved == uw.buffered() {
+				flushed, err := uw.reserveBuffer()
+				if err != nil {
+					return err
+				}
+				if flushed {
+					w = uw.buffer.Add(p, tag, opts...)
+				}
+			}
+			n = uw.reserved - uw.buffered()
+		}
+		n, err := io.CopyN(w, r, n)
 		uw.memAvailable -= n
 		if err != nil {
 			if errors.Is(err, io.EOF) {
@@ -66,11 +89,52 @@ func (uw *UnorderedWriter) Put(p, tag string, appendFile bool, r io.Reader) (ret
 			if err := uw.serialize(); err != nil {
 				return err
 			}
-			w = uw.buffer.Add(p, tag)
+			w = uw.buffer.Add(p, tag, opts...)
 		}
 	}
 }
 
+// buffered returns the number of bytes of content in the buffer.
+func (uw *UnorderedWriter) buffered() int64 {
+	return uw.memThreshold - uw.memAvailable
+}
+
+// reserveBuffer reserves room for more content in the buffer. If the
+// reservation fails, and the buffer holds content, the buffer is serialized
+// to give back its reservation, and flushed is true.
+func (uw *UnorderedWriter) reserveBuffer() (flushed bool, _ error) {
+	size := func() int64 {
+		if uw.reservationSize > 0 && uw.reservationSize < uw.memAvailable {
+			return uw.reservationSize
+		}
+		return uw.memAvailable
+	}
+	n := size()
+	if err := uw.reserve(n); err != nil {
+		if uw.buffered() == 0 {
+			return false, err
+		}
+		if err := uw.serialize(); err != nil {
+			return false, err
+		}
+		n = size()
+		if err := uw.reserve(n); err != nil {
+			return true, err
+		}
+		flushed = true
+	}
+	uw.reserved += n
+	return flushed, nil
+}
+
+// releaseBuffer gives back the reservation of the buffer.
+func (uw *UnorderedWriter) releaseBuffer() {
+	if uw.release != nil && uw.reserved > 0 {
+		uw.release(uw.reserved)
+		uw.reserved = 0
+	}
+}
+
 func (uw *UnorderedWriter) validate(p string) error {
 	if uw.validator != nil {
 		return uw.validator(p)
@@ -85,8 +149,8 @@ func (uw *UnorderedWriter) serialize() error {
 		return nil
 	}
 	return uw.withWriter(func(w *Writer) error {
-		if err := uw.buffer.WalkAdditive(func(path, tag string, r io.Reader) error {
-			return w.Add(path, tag, r)
+		if err := uw.buffer.walkAdditive(func(path, tag string, r io.Reader, opts ...FileOption) error {
+			return w.Add(path, tag, r, opts...)
 		}); err != nil {
 			return err
 		}
@@ -98,7 +162,7 @@ func (uw *UnorderedWriter) serialize() error {
 
 func (uw *UnorderedWriter) withWriter(cb func(*Writer) error) error {
 	// Serialize file set.
-	var writerOpts []WriterOption
+	writerOpts := append([]WriterOption{}, uw.writerOpts...)
 	if uw.ttl > 0 {
 		writerOpts = append(writerOpts, WithTTL(uw.ttl))
 	}
@@ -117,6 +181,7 @@ func (uw *UnorderedWriter) withWriter(cb func(*Writer) error) error {
 	// Reset fileset buffer.
 	uw.buffer = NewBuffer()
 	uw.memAvailable = uw.memThreshold
+	uw.releaseBuffer()
 	uw.subFileSet++
 	return nil
 }
@@ -157,6 +222,9 @@ func (uw *UnorderedWriter) Copy(ctx context.Context, fs FileSet, tag string, app
 	}
 	return uw.withWriter(func(w *Writer) error {
 		return fs.Iterate(ctx, func(f File) error {
+			if err := uw.validate(f.Index().Path); err != nil {
+				return err
+			}
 			if !appendFile {
 				if err := w.Delete(f.Index().Path, tag); err != nil {
 					return err
@@ -167,9 +235,72 @@ func (uw *UnorderedWriter) Copy(ctx context.Context, fs FileSet, tag string, app
 	})
 }
 
+// Move moves the files under src in fs to dst, so that the file at src/x ends
+// up at dst/x, keeping their tags. Files already at the new paths with the
+// same tags are replaced. The moved files refer to the same data as the
+// originals, only their index entries are written again.
+func (uw *UnorderedWriter) Move(ctx context.Context, fs FileSet, src, dst string) error {
+	type movedFile struct{ path, tag string }
+	var moved []movedFile
+	if err := uw.copyTagged(ctx, fs, false, func(idx *index.Index) string {
+		moved = append(moved, movedFile{path: idx.Path, tag: idx.File.Tag})
+		return dst + strings.TrimPrefix(idx.Path, src)
+	}); err != nil {
+		return err
+	}
+	for _, f := range moved {
+		if err := uw.Delete(f.path, f.tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyTagged copies the files in fs, keeping their tags, unlike Copy. Files
+// already at the same paths with the same tags are replaced.
+func (uw *UnorderedWriter) CopyTagged(ctx context.Context, fs FileSet) error {
+	return uw.copyTagged(ctx, fs, false, func(idx *index.Index) string {
+		return idx.Path
+	})
+}
+
+// AppendTagged appends the files in fs to the files with the same paths and
+// tags, by reference to their data.
+func (uw *UnorderedWriter) AppendTagged(ctx context.Context, fs FileSet) error {
+	return uw.copyTagged(ctx, fs, true, func(idx *index.Index) string {
+		return idx.Path
+	})
+}
+
+// copyTagged copies the files in fs to the paths that pathFunc returns for
+// them, keeping their tags. The files already at those paths with the same
+// tags are replaced, unless appendFile is set.
+func (uw *UnorderedWriter) copyTagged(ctx context.Context, fs FileSet, appendFile bool, pathFunc func(*index.Index) string) error {
+	if err := uw.serialize(); err != nil {
+		return err
+	}
+	return uw.withWriter(func(w *Writer) error {
+		return fs.Iterate(ctx, func(f File) error {
+			idx := f.Index()
+			dstIdx := *idx
+			dstIdx.Path = pathFunc(idx)
+			if err := uw.validate(dstIdx.Path); err != nil {
+				return err
+			}
+			if !appendFile {
+				if err := w.Delete(dstIdx.Path, idx.File.Tag); err != nil {
+					return err
+				}
+			}
+			return w.Copy(newFileReader(ctx, uw.storage.ChunkStorage(), &dstIdx), idx.File.Tag)
+		})
+	})
+}
+
 // Close closes the writer.
 func (uw *UnorderedWriter) Close() (*ID, error) {
 	defer uw.storage.filesetSem.Release(1)
+	defer uw.releaseBuffer()
 	if err := uw.serialize(); err != nil {
 		return nil, err
 	}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/jmoiron/sqlx"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pachhash"
@@ -43,7 +44,21 @@ func CopyFiles(ctx context.Context, w *Writer, fs FileSet, deletive ...bool) err
 func WriteTarEntry(w io.Writer, f File) error {
 	idx := f.Index()
 	tw := tar.NewWriter(w)
-	if err := tw.WriteHeader(tarutil.NewHeader(idx.Path, index.SizeBytes(idx))); err != nil {
+	hdr := tarutil.NewHeader(idx.Path, index.SizeBytes(idx))
+	hdr.Mode = int64(idx.File.Mode)
+	if idx.File.LinkTarget != "" {
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = idx.File.LinkTarget
+		hdr.Size = 0
+	}
+	if idx.File.Mtime != nil {
+		mtime, err := types.TimestampFromProto(idx.File.Mtime)
+		if err != nil {
+			return err
+		}
+		hdr.ModTime = mtime
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
 		return err
 	}
 	if err := f.Content(tw); err != nil {
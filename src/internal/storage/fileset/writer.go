@@ -31,6 +31,7 @@ type Writer struct {
 	lastIdx            *index.Index
 	indexFunc          func(*index.Index) error
 	ttl                time.Duration
+	chunkWriterOpts    []chunk.WriterOption
 }
 
 func newWriter(ctx context.Context, storage *Storage, tracker track.Tracker, chunks *chunk.Storage, opts ...WriterOption) *Writer {
@@ -42,20 +43,23 @@ func newWriter(ctx context.Context, storage *Storage, tracker track.Tracker, chu
 	for _, opt := range opts {
 		opt(w)
 	}
-	var chunkWriterOpts []chunk.WriterOption
 	w.additive = index.NewWriter(ctx, chunks, "additive-index-writer")
 	w.deletive = index.NewWriter(ctx, chunks, "deletive-index-writer")
-	w.cw = chunks.NewWriter(ctx, "chunk-writer", w.callback, chunkWriterOpts...)
+	w.cw = chunks.NewWriter(ctx, "chunk-writer", w.callback, w.chunkWriterOpts...)
 	return w
 }
 
-func (w *Writer) Add(path, tag string, r io.Reader) error {
+// Add adds a file to the file set.
+func (w *Writer) Add(path, tag string, r io.Reader, opts ...FileOption) error {
 	idx := &index.Index{
 		Path: path,
 		File: &index.File{
 			Tag: tag,
 		},
 	}
+	for _, opt := range opts {
+		opt(idx.File)
+	}
 	if err := w.nextIdx(idx); err != nil {
 		return err
 	}
@@ -108,7 +112,12 @@ func (w *Writer) Copy(file File, tag string) error {
 	copyIdx := &index.Index{
 		Path: idx.Path,
 		File: &index.File{
-			Tag: tag,
+			Tag:        tag,
+			Mode:       idx.File.Mode,
+			Mtime:      idx.File.Mtime,
+			LinkTarget: idx.File.LinkTarget,
+			Metadata:   idx.File.Metadata,
+			CopiedFrom: idx.File.CopiedFrom,
 		},
 	}
 	if err := w.nextIdx(copyIdx); err != nil {
@@ -120,6 +120,14 @@ func (t *postgresTracker) SetTTLPrefix(ctx context.Context, prefix string, ttl t
 	return expiresAt, nil
 }
 
+func (t *postgresTracker) SetTTLTx(tx *sqlx.Tx, id string, ttl time.Duration) error {
+	_, err := tx.Exec(
+		`UPDATE storage.tracker_objects
+		SET expires_at = CURRENT_TIMESTAMP + $2 * interval '1 microsecond'
+		WHERE str_id = $1`, id, ttl.Microseconds())
+	return err
+}
+
 func (t *postgresTracker) GetDownstream(ctx context.Context, id string) ([]string, error) {
 	var dwn []string
 	if err := t.db.SelectContext(ctx, &dwn, `
@@ -41,6 +41,10 @@ type Tracker interface {
 	// SetTTLPrefix sets the expiration time to current_time + ttl for all objects with ids starting with prefix
 	SetTTLPrefix(ctx context.Context, prefix string, ttl time.Duration) (time.Time, error)
 
+	// SetTTLTx sets the expiration time to current_time + ttl for the object with id.
+	// If the id doesn't exist, no error is returned
+	SetTTLTx(tx *sqlx.Tx, id string, ttl time.Duration) error
+
 	// GetDownstream gets all objects immediately downstream of (pointed to by) object with id
 	GetDownstream(ctx context.Context, id string) ([]string, error)
 
@@ -181,9 +181,28 @@ func Import(storageRoot string, r io.Reader, cb ...func(*tar.Header) error) erro
 			}
 			continue
 		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			if err := os.MkdirAll(path.Dir(fullPath), 0777); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, fullPath); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := writeFile(fullPath, tr); err != nil {
 			return err
 		}
+		if perm := os.FileMode(hdr.Mode).Perm(); perm != 0 {
+			if err := os.Chmod(fullPath, perm); err != nil {
+				return err
+			}
+		}
+		if !hdr.ModTime.IsZero() {
+			if err := os.Chtimes(fullPath, hdr.ModTime, hdr.ModTime); err != nil {
+				return err
+			}
+		}
 	}
 }
 
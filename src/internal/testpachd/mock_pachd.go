@@ -6,12 +6,14 @@ import (
 	"reflect"
 
 	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
 
 	"github.com/pachyderm/pachyderm/v2/src/admin"
 	"github.com/pachyderm/pachyderm/v2/src/auth"
 	"github.com/pachyderm/pachyderm/v2/src/enterprise"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/validation"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	"github.com/pachyderm/pachyderm/v2/src/pps"
 	"github.com/pachyderm/pachyderm/v2/src/transaction"
@@ -45,10 +47,13 @@ func linkServers(mockServerPtr interface{}, realServer interface{}) {
 /* Admin Server Mocks */
 
 type inspectClusterFunc func(context.Context, *types.Empty) (*admin.ClusterInfo, error)
+type inspectStorageFunc func(context.Context, *types.Empty) (*admin.StorageInfo, error)
 
 type mockInspectCluster struct{ handler inspectClusterFunc }
+type mockInspectStorage struct{ handler inspectStorageFunc }
 
 func (mock *mockInspectCluster) Use(cb inspectClusterFunc) { mock.handler = cb }
+func (mock *mockInspectStorage) Use(cb inspectStorageFunc) { mock.handler = cb }
 
 type adminServerAPI struct {
 	mock *mockAdminServer
@@ -57,6 +62,7 @@ type adminServerAPI struct {
 type mockAdminServer struct {
 	api            adminServerAPI
 	InspectCluster mockInspectCluster
+	InspectStorage mockInspectStorage
 }
 
 func (api *adminServerAPI) InspectCluster(ctx context.Context, req *types.Empty) (*admin.ClusterInfo, error) {
@@ -65,6 +71,12 @@ func (api *adminServerAPI) InspectCluster(ctx context.Context, req *types.Empty)
 	}
 	return nil, errors.Errorf("unhandled pachd mock admin.InspectCluster")
 }
+func (api *adminServerAPI) InspectStorage(ctx context.Context, req *types.Empty) (*admin.StorageInfo, error) {
+	if api.mock.InspectStorage.handler != nil {
+		return api.mock.InspectStorage.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock admin.InspectStorage")
+}
 
 /* Auth Server Mocks */
 
@@ -400,31 +412,70 @@ type createRepoFunc func(context.Context, *pfs.CreateRepoRequest) (*types.Empty,
 type inspectRepoFunc func(context.Context, *pfs.InspectRepoRequest) (*pfs.RepoInfo, error)
 type listRepoFunc func(context.Context, *pfs.ListRepoRequest) (*pfs.ListRepoResponse, error)
 type deleteRepoFunc func(context.Context, *pfs.DeleteRepoRequest) (*types.Empty, error)
+type deleteReposFunc func(context.Context, *pfs.DeleteReposRequest) (*pfs.DeleteReposResponse, error)
+type breakRepoLockFunc func(context.Context, *pfs.BreakRepoLockRequest) (*types.Empty, error)
+type createProjectFunc func(context.Context, *pfs.CreateProjectRequest) (*types.Empty, error)
+type inspectProjectFunc func(context.Context, *pfs.InspectProjectRequest) (*pfs.ProjectInfo, error)
+type listProjectFunc func(context.Context, *pfs.ListProjectRequest) (*pfs.ListProjectResponse, error)
+type deleteProjectFunc func(context.Context, *pfs.DeleteProjectRequest) (*types.Empty, error)
 type startCommitFunc func(context.Context, *pfs.StartCommitRequest) (*pfs.Commit, error)
 type finishCommitFunc func(context.Context, *pfs.FinishCommitRequest) (*types.Empty, error)
 type inspectCommitFunc func(context.Context, *pfs.InspectCommitRequest) (*pfs.CommitInfo, error)
 type listCommitFunc func(*pfs.ListCommitRequest, pfs.API_ListCommitServer) error
 type squashCommitSetFunc func(context.Context, *pfs.SquashCommitSetRequest) (*types.Empty, error)
 type inspectCommitSetFunc func(*pfs.InspectCommitSetRequest, pfs.API_InspectCommitSetServer) error
+type commitLineageFunc func(context.Context, *pfs.CommitLineageRequest) (*pfs.CommitLineageResponse, error)
 type subscribeCommitFunc func(*pfs.SubscribeCommitRequest, pfs.API_SubscribeCommitServer) error
 type clearCommitFunc func(context.Context, *pfs.ClearCommitRequest) (*types.Empty, error)
+type cherryPickCommitFunc func(context.Context, *pfs.CherryPickCommitRequest) (*pfs.Commit, error)
+type recallCommitFunc func(context.Context, *pfs.RecallCommitRequest) (*types.Empty, error)
+type archiveCommitFunc func(context.Context, *pfs.ArchiveCommitRequest) (*types.Empty, error)
 type createBranchFunc func(context.Context, *pfs.CreateBranchRequest) (*types.Empty, error)
 type inspectBranchFunc func(context.Context, *pfs.InspectBranchRequest) (*pfs.BranchInfo, error)
 type listBranchFunc func(context.Context, *pfs.ListBranchRequest) (*pfs.BranchInfos, error)
 type deleteBranchFunc func(context.Context, *pfs.DeleteBranchRequest) (*types.Empty, error)
+type watchBranchFunc func(*pfs.WatchBranchRequest, pfs.API_WatchBranchServer) error
+type mergeBranchFunc func(context.Context, *pfs.MergeBranchRequest) (*pfs.MergeBranchResponse, error)
+type inspectTriggerFunc func(context.Context, *pfs.InspectTriggerRequest) (*pfs.TriggerInfo, error)
+type runTriggerFunc func(context.Context, *pfs.RunTriggerRequest) (*pfs.RunTriggerResponse, error)
+type promoteBranchFunc func(context.Context, *pfs.PromoteBranchRequest) (*types.Empty, error)
 type modifyFileFunc func(pfs.API_ModifyFileServer) error
+type copyFileFunc func(context.Context, *pfs.CopyFileRequest) (*types.Empty, error)
 type getFileTARFunc func(*pfs.GetFileRequest, pfs.API_GetFileTARServer) error
 type inspectFileFunc func(context.Context, *pfs.InspectFileRequest) (*pfs.FileInfo, error)
 type listFileFunc func(*pfs.ListFileRequest, pfs.API_ListFileServer) error
 type walkFileFunc func(*pfs.WalkFileRequest, pfs.API_WalkFileServer) error
+type listFileHistoryFunc func(*pfs.ListFileHistoryRequest, pfs.API_ListFileHistoryServer) error
+type directorySizesFunc func(*pfs.DirectorySizesRequest, pfs.API_DirectorySizesServer) error
+type listTagsFunc func(context.Context, *pfs.ListTagsRequest) (*pfs.ListTagsResponse, error)
+type reservePathFunc func(context.Context, *pfs.ReservePathRequest) (*types.Empty, error)
+type releasePathFunc func(context.Context, *pfs.ReleasePathRequest) (*types.Empty, error)
 type globFileFunc func(*pfs.GlobFileRequest, pfs.API_GlobFileServer) error
 type diffFileFunc func(*pfs.DiffFileRequest, pfs.API_DiffFileServer) error
+type diffFileContentFunc func(*pfs.DiffFileContentRequest, pfs.API_DiffFileContentServer) error
+type listFileChunksFunc func(*pfs.ListFileChunksRequest, pfs.API_ListFileChunksServer) error
+type changeFeedFunc func(*pfs.ChangeFeedRequest, pfs.API_ChangeFeedServer) error
+type watchFunc func(*pfs.WatchRequest, pfs.API_WatchServer) error
 type deleteAllPFSFunc func(context.Context, *types.Empty) (*types.Empty, error)
 type fsckFunc func(*pfs.FsckRequest, pfs.API_FsckServer) error
+type garbageCollectFunc func(context.Context, *pfs.GarbageCollectRequest) (*pfs.GarbageCollectResponse, error)
+type inspectGarbageCollectionFunc func(context.Context, *pfs.InspectGarbageCollectionRequest) (*pfs.GarbageCollectionStats, error)
+type storageUsageFunc func(context.Context, *pfs.StorageUsageRequest) (*pfs.StorageUsageResponse, error)
+type dedupReportFunc func(context.Context, *pfs.DedupReportRequest) (*pfs.CommitDedupReport, error)
+type findFilesByContentFunc func(*pfs.FindFilesByContentRequest, pfs.API_FindFilesByContentServer) error
 type createFileSetFunc func(pfs.API_CreateFileSetServer) error
 type addFileSetFunc func(context.Context, *pfs.AddFileSetRequest) (*types.Empty, error)
 type getFileSetFunc func(context.Context, *pfs.GetFileSetRequest) (*pfs.CreateFileSetResponse, error)
 type renewFileSetFunc func(context.Context, *pfs.RenewFileSetRequest) (*types.Empty, error)
+type composeFileSetFunc func(context.Context, *pfs.ComposeFileSetRequest) (*pfs.CreateFileSetResponse, error)
+type filterFileSetFunc func(context.Context, *pfs.FilterFileSetRequest) (*pfs.CreateFileSetResponse, error)
+type subtractFileSetFunc func(context.Context, *pfs.SubtractFileSetRequest) (*pfs.CreateFileSetResponse, error)
+type inspectFileSetFunc func(context.Context, *pfs.InspectFileSetRequest) (*pfs.FileSetInfo, error)
+type startUploadFunc func(context.Context, *pfs.StartUploadRequest) (*pfs.UploadInfo, error)
+type addUploadPartFunc func(context.Context, *pfs.AddUploadPartRequest) (*types.Empty, error)
+type inspectUploadFunc func(context.Context, *pfs.InspectUploadRequest) (*pfs.UploadInfo, error)
+type finishUploadFunc func(context.Context, *pfs.FinishUploadRequest) (*types.Empty, error)
+type abortUploadFunc func(context.Context, *pfs.AbortUploadRequest) (*types.Empty, error)
 type runLoadTestFunc func(context.Context, *pfs.RunLoadTestRequest) (*pfs.RunLoadTestResponse, error)
 
 type mockActivateAuthPFS struct{ handler activateAuthPFSFunc }
@@ -432,102 +483,219 @@ type mockCreateRepo struct{ handler createRepoFunc }
 type mockInspectRepo struct{ handler inspectRepoFunc }
 type mockListRepo struct{ handler listRepoFunc }
 type mockDeleteRepo struct{ handler deleteRepoFunc }
+type mockDeleteRepos struct{ handler deleteReposFunc }
+type mockBreakRepoLock struct{ handler breakRepoLockFunc }
+type mockCreateProject struct{ handler createProjectFunc }
+type mockInspectProject struct{ handler inspectProjectFunc }
+type mockListProject struct{ handler listProjectFunc }
+type mockDeleteProject struct{ handler deleteProjectFunc }
 type mockStartCommit struct{ handler startCommitFunc }
 type mockFinishCommit struct{ handler finishCommitFunc }
 type mockInspectCommit struct{ handler inspectCommitFunc }
 type mockListCommit struct{ handler listCommitFunc }
 type mockSquashCommitSet struct{ handler squashCommitSetFunc }
 type mockInspectCommitSet struct{ handler inspectCommitSetFunc }
+type mockCommitLineage struct{ handler commitLineageFunc }
 type mockSubscribeCommit struct{ handler subscribeCommitFunc }
 type mockClearCommit struct{ handler clearCommitFunc }
+type mockCherryPickCommit struct{ handler cherryPickCommitFunc }
+type mockRecallCommit struct{ handler recallCommitFunc }
+type mockArchiveCommit struct{ handler archiveCommitFunc }
 type mockCreateBranch struct{ handler createBranchFunc }
 type mockInspectBranch struct{ handler inspectBranchFunc }
 type mockListBranch struct{ handler listBranchFunc }
 type mockDeleteBranch struct{ handler deleteBranchFunc }
+type mockWatchBranch struct{ handler watchBranchFunc }
+type mockMergeBranch struct{ handler mergeBranchFunc }
+type mockInspectTrigger struct{ handler inspectTriggerFunc }
+type mockRunTrigger struct{ handler runTriggerFunc }
+type mockPromoteBranch struct{ handler promoteBranchFunc }
 type mockModifyFile struct{ handler modifyFileFunc }
+type mockCopyFile struct{ handler copyFileFunc }
 type mockGetFileTAR struct{ handler getFileTARFunc }
 type mockInspectFile struct{ handler inspectFileFunc }
 type mockListFile struct{ handler listFileFunc }
 type mockWalkFile struct{ handler walkFileFunc }
+type mockListFileHistory struct{ handler listFileHistoryFunc }
+type mockDirectorySizes struct{ handler directorySizesFunc }
+type mockListTags struct{ handler listTagsFunc }
+type mockReservePath struct{ handler reservePathFunc }
+type mockReleasePath struct{ handler releasePathFunc }
 type mockGlobFile struct{ handler globFileFunc }
 type mockDiffFile struct{ handler diffFileFunc }
+type mockDiffFileContent struct{ handler diffFileContentFunc }
+type mockListFileChunks struct{ handler listFileChunksFunc }
+type mockChangeFeed struct{ handler changeFeedFunc }
+type mockWatch struct{ handler watchFunc }
 type mockDeleteAllPFS struct{ handler deleteAllPFSFunc }
 type mockFsck struct{ handler fsckFunc }
+type mockGarbageCollect struct{ handler garbageCollectFunc }
+type mockInspectGarbageCollection struct{ handler inspectGarbageCollectionFunc }
+type mockStorageUsage struct{ handler storageUsageFunc }
+type mockDedupReport struct{ handler dedupReportFunc }
+type mockFindFilesByContent struct{ handler findFilesByContentFunc }
 type mockCreateFileSet struct{ handler createFileSetFunc }
 type mockAddFileSet struct{ handler addFileSetFunc }
 type mockGetFileSet struct{ handler getFileSetFunc }
 type mockRenewFileSet struct{ handler renewFileSetFunc }
+type mockComposeFileSet struct{ handler composeFileSetFunc }
+type mockFilterFileSet struct{ handler filterFileSetFunc }
+type mockSubtractFileSet struct{ handler subtractFileSetFunc }
+type mockInspectFileSet struct{ handler inspectFileSetFunc }
+type mockStartUpload struct{ handler startUploadFunc }
+type mockAddUploadPart struct{ handler addUploadPartFunc }
+type mockInspectUpload struct{ handler inspectUploadFunc }
+type mockFinishUpload struct{ handler finishUploadFunc }
+type mockAbortUpload struct{ handler abortUploadFunc }
 type mockRunLoadTest struct{ handler runLoadTestFunc }
 
-func (mock *mockActivateAuthPFS) Use(cb activateAuthPFSFunc)   { mock.handler = cb }
-func (mock *mockCreateRepo) Use(cb createRepoFunc)             { mock.handler = cb }
-func (mock *mockInspectRepo) Use(cb inspectRepoFunc)           { mock.handler = cb }
-func (mock *mockListRepo) Use(cb listRepoFunc)                 { mock.handler = cb }
-func (mock *mockDeleteRepo) Use(cb deleteRepoFunc)             { mock.handler = cb }
-func (mock *mockStartCommit) Use(cb startCommitFunc)           { mock.handler = cb }
-func (mock *mockFinishCommit) Use(cb finishCommitFunc)         { mock.handler = cb }
-func (mock *mockInspectCommit) Use(cb inspectCommitFunc)       { mock.handler = cb }
-func (mock *mockListCommit) Use(cb listCommitFunc)             { mock.handler = cb }
-func (mock *mockSubscribeCommit) Use(cb subscribeCommitFunc)   { mock.handler = cb }
-func (mock *mockClearCommit) Use(cb clearCommitFunc)           { mock.handler = cb }
-func (mock *mockSquashCommitSet) Use(cb squashCommitSetFunc)   { mock.handler = cb }
-func (mock *mockInspectCommitSet) Use(cb inspectCommitSetFunc) { mock.handler = cb }
-func (mock *mockCreateBranch) Use(cb createBranchFunc)         { mock.handler = cb }
-func (mock *mockInspectBranch) Use(cb inspectBranchFunc)       { mock.handler = cb }
-func (mock *mockListBranch) Use(cb listBranchFunc)             { mock.handler = cb }
-func (mock *mockDeleteBranch) Use(cb deleteBranchFunc)         { mock.handler = cb }
-func (mock *mockModifyFile) Use(cb modifyFileFunc)             { mock.handler = cb }
-func (mock *mockGetFileTAR) Use(cb getFileTARFunc)             { mock.handler = cb }
-func (mock *mockInspectFile) Use(cb inspectFileFunc)           { mock.handler = cb }
-func (mock *mockListFile) Use(cb listFileFunc)                 { mock.handler = cb }
-func (mock *mockWalkFile) Use(cb walkFileFunc)                 { mock.handler = cb }
-func (mock *mockGlobFile) Use(cb globFileFunc)                 { mock.handler = cb }
-func (mock *mockDiffFile) Use(cb diffFileFunc)                 { mock.handler = cb }
-func (mock *mockDeleteAllPFS) Use(cb deleteAllPFSFunc)         { mock.handler = cb }
-func (mock *mockFsck) Use(cb fsckFunc)                         { mock.handler = cb }
-func (mock *mockCreateFileSet) Use(cb createFileSetFunc)       { mock.handler = cb }
-func (mock *mockAddFileSet) Use(cb addFileSetFunc)             { mock.handler = cb }
-func (mock *mockGetFileSet) Use(cb getFileSetFunc)             { mock.handler = cb }
-func (mock *mockRenewFileSet) Use(cb renewFileSetFunc)         { mock.handler = cb }
-func (mock *mockRunLoadTest) Use(cb runLoadTestFunc)           { mock.handler = cb }
+func (mock *mockActivateAuthPFS) Use(cb activateAuthPFSFunc)                   { mock.handler = cb }
+func (mock *mockCreateRepo) Use(cb createRepoFunc)                             { mock.handler = cb }
+func (mock *mockInspectRepo) Use(cb inspectRepoFunc)                           { mock.handler = cb }
+func (mock *mockListRepo) Use(cb listRepoFunc)                                 { mock.handler = cb }
+func (mock *mockDeleteRepo) Use(cb deleteRepoFunc)                             { mock.handler = cb }
+func (mock *mockDeleteRepos) Use(cb deleteReposFunc)                           { mock.handler = cb }
+func (mock *mockBreakRepoLock) Use(cb breakRepoLockFunc)                       { mock.handler = cb }
+func (mock *mockCreateProject) Use(cb createProjectFunc)                       { mock.handler = cb }
+func (mock *mockInspectProject) Use(cb inspectProjectFunc)                     { mock.handler = cb }
+func (mock *mockListProject) Use(cb listProjectFunc)                           { mock.handler = cb }
+func (mock *mockDeleteProject) Use(cb deleteProjectFunc)                       { mock.handler = cb }
+func (mock *mockStartCommit) Use(cb startCommitFunc)                           { mock.handler = cb }
+func (mock *mockFinishCommit) Use(cb finishCommitFunc)                         { mock.handler = cb }
+func (mock *mockInspectCommit) Use(cb inspectCommitFunc)                       { mock.handler = cb }
+func (mock *mockListCommit) Use(cb listCommitFunc)                             { mock.handler = cb }
+func (mock *mockSubscribeCommit) Use(cb subscribeCommitFunc)                   { mock.handler = cb }
+func (mock *mockClearCommit) Use(cb clearCommitFunc)                           { mock.handler = cb }
+func (mock *mockCherryPickCommit) Use(cb cherryPickCommitFunc)                 { mock.handler = cb }
+func (mock *mockRecallCommit) Use(cb recallCommitFunc)                         { mock.handler = cb }
+func (mock *mockArchiveCommit) Use(cb archiveCommitFunc)                       { mock.handler = cb }
+func (mock *mockSquashCommitSet) Use(cb squashCommitSetFunc)                   { mock.handler = cb }
+func (mock *mockInspectCommitSet) Use(cb inspectCommitSetFunc)                 { mock.handler = cb }
+func (mock *mockCommitLineage) Use(cb commitLineageFunc)                       { mock.handler = cb }
+func (mock *mockCreateBranch) Use(cb createBranchFunc)                         { mock.handler = cb }
+func (mock *mockInspectBranch) Use(cb inspectBranchFunc)                       { mock.handler = cb }
+func (mock *mockListBranch) Use(cb listBranchFunc)                             { mock.handler = cb }
+func (mock *mockDeleteBranch) Use(cb deleteBranchFunc)                         { mock.handler = cb }
+func (mock *mockWatchBranch) Use(cb watchBranchFunc)                           { mock.handler = cb }
+func (mock *mockMergeBranch) Use(cb mergeBranchFunc)                           { mock.handler = cb }
+func (mock *mockInspectTrigger) Use(cb inspectTriggerFunc)                     { mock.handler = cb }
+func (mock *mockRunTrigger) Use(cb runTriggerFunc)                             { mock.handler = cb }
+func (mock *mockPromoteBranch) Use(cb promoteBranchFunc)                       { mock.handler = cb }
+func (mock *mockModifyFile) Use(cb modifyFileFunc)                             { mock.handler = cb }
+func (mock *mockCopyFile) Use(cb copyFileFunc)                                 { mock.handler = cb }
+func (mock *mockGetFileTAR) Use(cb getFileTARFunc)                             { mock.handler = cb }
+func (mock *mockInspectFile) Use(cb inspectFileFunc)                           { mock.handler = cb }
+func (mock *mockListFile) Use(cb listFileFunc)                                 { mock.handler = cb }
+func (mock *mockWalkFile) Use(cb walkFileFunc)                                 { mock.handler = cb }
+func (mock *mockListFileHistory) Use(cb listFileHistoryFunc)                   { mock.handler = cb }
+func (mock *mockDirectorySizes) Use(cb directorySizesFunc)                     { mock.handler = cb }
+func (mock *mockListTags) Use(cb listTagsFunc)                                 { mock.handler = cb }
+func (mock *mockReservePath) Use(cb reservePathFunc)                           { mock.handler = cb }
+func (mock *mockReleasePath) Use(cb releasePathFunc)                           { mock.handler = cb }
+func (mock *mockGlobFile) Use(cb globFileFunc)                                 { mock.handler = cb }
+func (mock *mockDiffFile) Use(cb diffFileFunc)                                 { mock.handler = cb }
+func (mock *mockDiffFileContent) Use(cb diffFileContentFunc)                   { mock.handler = cb }
+func (mock *mockListFileChunks) Use(cb listFileChunksFunc)                     { mock.handler = cb }
+func (mock *mockChangeFeed) Use(cb changeFeedFunc)                             { mock.handler = cb }
+func (mock *mockWatch) Use(cb watchFunc)                                       { mock.handler = cb }
+func (mock *mockDeleteAllPFS) Use(cb deleteAllPFSFunc)                         { mock.handler = cb }
+func (mock *mockFsck) Use(cb fsckFunc)                                         { mock.handler = cb }
+func (mock *mockGarbageCollect) Use(cb garbageCollectFunc)                     { mock.handler = cb }
+func (mock *mockInspectGarbageCollection) Use(cb inspectGarbageCollectionFunc) { mock.handler = cb }
+func (mock *mockStorageUsage) Use(cb storageUsageFunc)                         { mock.handler = cb }
+func (mock *mockDedupReport) Use(cb dedupReportFunc)                           { mock.handler = cb }
+func (mock *mockFindFilesByContent) Use(cb findFilesByContentFunc)             { mock.handler = cb }
+func (mock *mockCreateFileSet) Use(cb createFileSetFunc)                       { mock.handler = cb }
+func (mock *mockAddFileSet) Use(cb addFileSetFunc)                             { mock.handler = cb }
+func (mock *mockGetFileSet) Use(cb getFileSetFunc)                             { mock.handler = cb }
+func (mock *mockRenewFileSet) Use(cb renewFileSetFunc)                         { mock.handler = cb }
+func (mock *mockComposeFileSet) Use(cb composeFileSetFunc)                     { mock.handler = cb }
+func (mock *mockFilterFileSet) Use(cb filterFileSetFunc)                       { mock.handler = cb }
+func (mock *mockSubtractFileSet) Use(cb subtractFileSetFunc)                   { mock.handler = cb }
+func (mock *mockInspectFileSet) Use(cb inspectFileSetFunc)                     { mock.handler = cb }
+func (mock *mockStartUpload) Use(cb startUploadFunc)                           { mock.handler = cb }
+func (mock *mockAddUploadPart) Use(cb addUploadPartFunc)                       { mock.handler = cb }
+func (mock *mockInspectUpload) Use(cb inspectUploadFunc)                       { mock.handler = cb }
+func (mock *mockFinishUpload) Use(cb finishUploadFunc)                         { mock.handler = cb }
+func (mock *mockAbortUpload) Use(cb abortUploadFunc)                           { mock.handler = cb }
+func (mock *mockRunLoadTest) Use(cb runLoadTestFunc)                           { mock.handler = cb }
 
 type pfsServerAPI struct {
 	mock *mockPFSServer
 }
 
 type mockPFSServer struct {
-	api              pfsServerAPI
-	ActivateAuth     mockActivateAuthPFS
-	CreateRepo       mockCreateRepo
-	InspectRepo      mockInspectRepo
-	ListRepo         mockListRepo
-	DeleteRepo       mockDeleteRepo
-	StartCommit      mockStartCommit
-	FinishCommit     mockFinishCommit
-	InspectCommit    mockInspectCommit
-	ListCommit       mockListCommit
-	SubscribeCommit  mockSubscribeCommit
-	ClearCommit      mockClearCommit
-	SquashCommitSet  mockSquashCommitSet
-	InspectCommitSet mockInspectCommitSet
-	CreateBranch     mockCreateBranch
-	InspectBranch    mockInspectBranch
-	ListBranch       mockListBranch
-	DeleteBranch     mockDeleteBranch
-	ModifyFile       mockModifyFile
-	GetFileTAR       mockGetFileTAR
-	InspectFile      mockInspectFile
-	ListFile         mockListFile
-	WalkFile         mockWalkFile
-	GlobFile         mockGlobFile
-	DiffFile         mockDiffFile
-	DeleteAll        mockDeleteAllPFS
-	Fsck             mockFsck
-	CreateFileSet    mockCreateFileSet
-	AddFileSet       mockAddFileSet
-	GetFileSet       mockGetFileSet
-	RenewFileSet     mockRenewFileSet
-	RunLoadTest      mockRunLoadTest
+	api                      pfsServerAPI
+	ActivateAuth             mockActivateAuthPFS
+	CreateRepo               mockCreateRepo
+	InspectRepo              mockInspectRepo
+	ListRepo                 mockListRepo
+	DeleteRepo               mockDeleteRepo
+	DeleteRepos              mockDeleteRepos
+	BreakRepoLock            mockBreakRepoLock
+	CreateProject            mockCreateProject
+	InspectProject           mockInspectProject
+	ListProject              mockListProject
+	DeleteProject            mockDeleteProject
+	StartCommit              mockStartCommit
+	FinishCommit             mockFinishCommit
+	InspectCommit            mockInspectCommit
+	ListCommit               mockListCommit
+	SubscribeCommit          mockSubscribeCommit
+	ClearCommit              mockClearCommit
+	CherryPickCommit         mockCherryPickCommit
+	RecallCommit             mockRecallCommit
+	ArchiveCommit            mockArchiveCommit
+	SquashCommitSet          mockSquashCommitSet
+	InspectCommitSet         mockInspectCommitSet
+	CommitLineage            mockCommitLineage
+	CreateBranch             mockCreateBranch
+	InspectBranch            mockInspectBranch
+	ListBranch               mockListBranch
+	DeleteBranch             mockDeleteBranch
+	WatchBranch              mockWatchBranch
+	MergeBranch              mockMergeBranch
+	InspectTrigger           mockInspectTrigger
+	RunTrigger               mockRunTrigger
+	PromoteBranch            mockPromoteBranch
+	ModifyFile               mockModifyFile
+	CopyFile                 mockCopyFile
+	GetFileTAR               mockGetFileTAR
+	InspectFile              mockInspectFile
+	ListFile                 mockListFile
+	WalkFile                 mockWalkFile
+	ListFileHistory          mockListFileHistory
+	DirectorySizes           mockDirectorySizes
+	ListTags                 mockListTags
+	ReservePath              mockReservePath
+	ReleasePath              mockReleasePath
+	GlobFile                 mockGlobFile
+	DiffFile                 mockDiffFile
+	DiffFileContent          mockDiffFileContent
+	ListFileChunks           mockListFileChunks
+	ChangeFeed               mockChangeFeed
+	Watch                    mockWatch
+	DeleteAll                mockDeleteAllPFS
+	Fsck                     mockFsck
+	GarbageCollect           mockGarbageCollect
+	InspectGarbageCollection mockInspectGarbageCollection
+	StorageUsage             mockStorageUsage
+	DedupReport              mockDedupReport
+	FindFilesByContent       mockFindFilesByContent
+	CreateFileSet            mockCreateFileSet
+	AddFileSet               mockAddFileSet
+	GetFileSet               mockGetFileSet
+	RenewFileSet             mockRenewFileSet
+	ComposeFileSet           mockComposeFileSet
+	FilterFileSet            mockFilterFileSet
+	SubtractFileSet          mockSubtractFileSet
+	InspectFileSet           mockInspectFileSet
+	StartUpload              mockStartUpload
+	AddUploadPart            mockAddUploadPart
+	InspectUpload            mockInspectUpload
+	FinishUpload             mockFinishUpload
+	AbortUpload              mockAbortUpload
+	RunLoadTest              mockRunLoadTest
 }
 
 func (api *pfsServerAPI) ActivateAuth(ctx context.Context, req *pfs.ActivateAuthRequest) (*pfs.ActivateAuthResponse, error) {
@@ -560,6 +728,42 @@ func (api *pfsServerAPI) DeleteRepo(ctx context.Context, req *pfs.DeleteRepoRequ
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteRepo")
 }
+func (api *pfsServerAPI) DeleteRepos(ctx context.Context, req *pfs.DeleteReposRequest) (*pfs.DeleteReposResponse, error) {
+	if api.mock.DeleteRepos.handler != nil {
+		return api.mock.DeleteRepos.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteRepos")
+}
+func (api *pfsServerAPI) BreakRepoLock(ctx context.Context, req *pfs.BreakRepoLockRequest) (*types.Empty, error) {
+	if api.mock.BreakRepoLock.handler != nil {
+		return api.mock.BreakRepoLock.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.BreakRepoLock")
+}
+func (api *pfsServerAPI) CreateProject(ctx context.Context, req *pfs.CreateProjectRequest) (*types.Empty, error) {
+	if api.mock.CreateProject.handler != nil {
+		return api.mock.CreateProject.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.CreateProject")
+}
+func (api *pfsServerAPI) InspectProject(ctx context.Context, req *pfs.InspectProjectRequest) (*pfs.ProjectInfo, error) {
+	if api.mock.InspectProject.handler != nil {
+		return api.mock.InspectProject.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.InspectProject")
+}
+func (api *pfsServerAPI) ListProject(ctx context.Context, req *pfs.ListProjectRequest) (*pfs.ListProjectResponse, error) {
+	if api.mock.ListProject.handler != nil {
+		return api.mock.ListProject.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.ListProject")
+}
+func (api *pfsServerAPI) DeleteProject(ctx context.Context, req *pfs.DeleteProjectRequest) (*types.Empty, error) {
+	if api.mock.DeleteProject.handler != nil {
+		return api.mock.DeleteProject.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteProject")
+}
 func (api *pfsServerAPI) StartCommit(ctx context.Context, req *pfs.StartCommitRequest) (*pfs.Commit, error) {
 	if api.mock.StartCommit.handler != nil {
 		return api.mock.StartCommit.handler(ctx, req)
@@ -596,6 +800,12 @@ func (api *pfsServerAPI) InspectCommitSet(req *pfs.InspectCommitSetRequest, serv
 	}
 	return errors.Errorf("unhandled pachd mock pfs.InspectCommitSet")
 }
+func (api *pfsServerAPI) CommitLineage(ctx context.Context, req *pfs.CommitLineageRequest) (*pfs.CommitLineageResponse, error) {
+	if api.mock.CommitLineage.handler != nil {
+		return api.mock.CommitLineage.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.CommitLineage")
+}
 func (api *pfsServerAPI) SubscribeCommit(req *pfs.SubscribeCommitRequest, serv pfs.API_SubscribeCommitServer) error {
 	if api.mock.SubscribeCommit.handler != nil {
 		return api.mock.SubscribeCommit.handler(req, serv)
@@ -608,6 +818,24 @@ func (api *pfsServerAPI) ClearCommit(ctx context.Context, req *pfs.ClearCommitRe
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.ClearCommit")
 }
+func (api *pfsServerAPI) CherryPickCommit(ctx context.Context, req *pfs.CherryPickCommitRequest) (*pfs.Commit, error) {
+	if api.mock.CherryPickCommit.handler != nil {
+		return api.mock.CherryPickCommit.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.CherryPickCommit")
+}
+func (api *pfsServerAPI) RecallCommit(ctx context.Context, req *pfs.RecallCommitRequest) (*types.Empty, error) {
+	if api.mock.RecallCommit.handler != nil {
+		return api.mock.RecallCommit.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.RecallCommit")
+}
+func (api *pfsServerAPI) ArchiveCommit(ctx context.Context, req *pfs.ArchiveCommitRequest) (*types.Empty, error) {
+	if api.mock.ArchiveCommit.handler != nil {
+		return api.mock.ArchiveCommit.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.ArchiveCommit")
+}
 func (api *pfsServerAPI) CreateBranch(ctx context.Context, req *pfs.CreateBranchRequest) (*types.Empty, error) {
 	if api.mock.CreateBranch.handler != nil {
 		return api.mock.CreateBranch.handler(ctx, req)
@@ -632,12 +860,48 @@ func (api *pfsServerAPI) DeleteBranch(ctx context.Context, req *pfs.DeleteBranch
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.DeleteBranch")
 }
+func (api *pfsServerAPI) WatchBranch(req *pfs.WatchBranchRequest, serv pfs.API_WatchBranchServer) error {
+	if api.mock.WatchBranch.handler != nil {
+		return api.mock.WatchBranch.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.WatchBranch")
+}
+func (api *pfsServerAPI) MergeBranch(ctx context.Context, req *pfs.MergeBranchRequest) (*pfs.MergeBranchResponse, error) {
+	if api.mock.MergeBranch.handler != nil {
+		return api.mock.MergeBranch.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.MergeBranch")
+}
+func (api *pfsServerAPI) InspectTrigger(ctx context.Context, req *pfs.InspectTriggerRequest) (*pfs.TriggerInfo, error) {
+	if api.mock.InspectTrigger.handler != nil {
+		return api.mock.InspectTrigger.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.InspectTrigger")
+}
+func (api *pfsServerAPI) RunTrigger(ctx context.Context, req *pfs.RunTriggerRequest) (*pfs.RunTriggerResponse, error) {
+	if api.mock.RunTrigger.handler != nil {
+		return api.mock.RunTrigger.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.RunTrigger")
+}
+func (api *pfsServerAPI) PromoteBranch(ctx context.Context, req *pfs.PromoteBranchRequest) (*types.Empty, error) {
+	if api.mock.PromoteBranch.handler != nil {
+		return api.mock.PromoteBranch.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.PromoteBranch")
+}
 func (api *pfsServerAPI) ModifyFile(serv pfs.API_ModifyFileServer) error {
 	if api.mock.ModifyFile.handler != nil {
 		return api.mock.ModifyFile.handler(serv)
 	}
 	return errors.Errorf("unhandled pachd mock pfs.ModifyFile")
 }
+func (api *pfsServerAPI) CopyFile(ctx context.Context, req *pfs.CopyFileRequest) (*types.Empty, error) {
+	if api.mock.CopyFile.handler != nil {
+		return api.mock.CopyFile.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.CopyFile")
+}
 func (api *pfsServerAPI) GetFileTAR(req *pfs.GetFileRequest, serv pfs.API_GetFileTARServer) error {
 	if api.mock.GetFileTAR.handler != nil {
 		return api.mock.GetFileTAR.handler(req, serv)
@@ -662,6 +926,36 @@ func (api *pfsServerAPI) WalkFile(req *pfs.WalkFileRequest, serv pfs.API_WalkFil
 	}
 	return errors.Errorf("unhandled pachd mock pfs.WalkFile")
 }
+func (api *pfsServerAPI) ListFileHistory(req *pfs.ListFileHistoryRequest, serv pfs.API_ListFileHistoryServer) error {
+	if api.mock.ListFileHistory.handler != nil {
+		return api.mock.ListFileHistory.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.ListFileHistory")
+}
+func (api *pfsServerAPI) DirectorySizes(req *pfs.DirectorySizesRequest, serv pfs.API_DirectorySizesServer) error {
+	if api.mock.DirectorySizes.handler != nil {
+		return api.mock.DirectorySizes.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.DirectorySizes")
+}
+func (api *pfsServerAPI) ListTags(ctx context.Context, req *pfs.ListTagsRequest) (*pfs.ListTagsResponse, error) {
+	if api.mock.ListTags.handler != nil {
+		return api.mock.ListTags.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.ListTags")
+}
+func (api *pfsServerAPI) ReservePath(ctx context.Context, req *pfs.ReservePathRequest) (*types.Empty, error) {
+	if api.mock.ReservePath.handler != nil {
+		return api.mock.ReservePath.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.ReservePath")
+}
+func (api *pfsServerAPI) ReleasePath(ctx context.Context, req *pfs.ReleasePathRequest) (*types.Empty, error) {
+	if api.mock.ReleasePath.handler != nil {
+		return api.mock.ReleasePath.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.ReleasePath")
+}
 func (api *pfsServerAPI) GlobFile(req *pfs.GlobFileRequest, serv pfs.API_GlobFileServer) error {
 	if api.mock.GlobFile.handler != nil {
 		return api.mock.GlobFile.handler(req, serv)
@@ -674,6 +968,30 @@ func (api *pfsServerAPI) DiffFile(req *pfs.DiffFileRequest, serv pfs.API_DiffFil
 	}
 	return errors.Errorf("unhandled pachd mock pfs.DiffFile")
 }
+func (api *pfsServerAPI) DiffFileContent(req *pfs.DiffFileContentRequest, serv pfs.API_DiffFileContentServer) error {
+	if api.mock.DiffFileContent.handler != nil {
+		return api.mock.DiffFileContent.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.DiffFileContent")
+}
+func (api *pfsServerAPI) ListFileChunks(req *pfs.ListFileChunksRequest, serv pfs.API_ListFileChunksServer) error {
+	if api.mock.ListFileChunks.handler != nil {
+		return api.mock.ListFileChunks.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.ListFileChunks")
+}
+func (api *pfsServerAPI) ChangeFeed(req *pfs.ChangeFeedRequest, serv pfs.API_ChangeFeedServer) error {
+	if api.mock.ChangeFeed.handler != nil {
+		return api.mock.ChangeFeed.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.ChangeFeed")
+}
+func (api *pfsServerAPI) Watch(req *pfs.WatchRequest, serv pfs.API_WatchServer) error {
+	if api.mock.Watch.handler != nil {
+		return api.mock.Watch.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.Watch")
+}
 func (api *pfsServerAPI) DeleteAll(ctx context.Context, req *types.Empty) (*types.Empty, error) {
 	if api.mock.DeleteAll.handler != nil {
 		return api.mock.DeleteAll.handler(ctx, req)
@@ -686,6 +1004,36 @@ func (api *pfsServerAPI) Fsck(req *pfs.FsckRequest, serv pfs.API_FsckServer) err
 	}
 	return errors.Errorf("unhandled pachd mock pfs.Fsck")
 }
+func (api *pfsServerAPI) GarbageCollect(ctx context.Context, req *pfs.GarbageCollectRequest) (*pfs.GarbageCollectResponse, error) {
+	if api.mock.GarbageCollect.handler != nil {
+		return api.mock.GarbageCollect.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.GarbageCollect")
+}
+func (api *pfsServerAPI) InspectGarbageCollection(ctx context.Context, req *pfs.InspectGarbageCollectionRequest) (*pfs.GarbageCollectionStats, error) {
+	if api.mock.InspectGarbageCollection.handler != nil {
+		return api.mock.InspectGarbageCollection.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.InspectGarbageCollection")
+}
+func (api *pfsServerAPI) StorageUsage(ctx context.Context, req *pfs.StorageUsageRequest) (*pfs.StorageUsageResponse, error) {
+	if api.mock.StorageUsage.handler != nil {
+		return api.mock.StorageUsage.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.StorageUsage")
+}
+func (api *pfsServerAPI) DedupReport(ctx context.Context, req *pfs.DedupReportRequest) (*pfs.CommitDedupReport, error) {
+	if api.mock.DedupReport.handler != nil {
+		return api.mock.DedupReport.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.DedupReport")
+}
+func (api *pfsServerAPI) FindFilesByContent(req *pfs.FindFilesByContentRequest, serv pfs.API_FindFilesByContentServer) error {
+	if api.mock.FindFilesByContent.handler != nil {
+		return api.mock.FindFilesByContent.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pfs.FindFilesByContent")
+}
 func (api *pfsServerAPI) CreateFileSet(srv pfs.API_CreateFileSetServer) error {
 	if api.mock.CreateFileSet.handler != nil {
 		return api.mock.CreateFileSet.handler(srv)
@@ -710,6 +1058,60 @@ func (api *pfsServerAPI) RenewFileSet(ctx context.Context, req *pfs.RenewFileSet
 	}
 	return nil, errors.Errorf("unhandled pachd mock pfs.RenewFileSet")
 }
+func (api *pfsServerAPI) ComposeFileSet(ctx context.Context, req *pfs.ComposeFileSetRequest) (*pfs.CreateFileSetResponse, error) {
+	if api.mock.ComposeFileSet.handler != nil {
+		return api.mock.ComposeFileSet.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.ComposeFileSet")
+}
+func (api *pfsServerAPI) FilterFileSet(ctx context.Context, req *pfs.FilterFileSetRequest) (*pfs.CreateFileSetResponse, error) {
+	if api.mock.FilterFileSet.handler != nil {
+		return api.mock.FilterFileSet.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.FilterFileSet")
+}
+func (api *pfsServerAPI) SubtractFileSet(ctx context.Context, req *pfs.SubtractFileSetRequest) (*pfs.CreateFileSetResponse, error) {
+	if api.mock.SubtractFileSet.handler != nil {
+		return api.mock.SubtractFileSet.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.SubtractFileSet")
+}
+func (api *pfsServerAPI) InspectFileSet(ctx context.Context, req *pfs.InspectFileSetRequest) (*pfs.FileSetInfo, error) {
+	if api.mock.InspectFileSet.handler != nil {
+		return api.mock.InspectFileSet.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.InspectFileSet")
+}
+func (api *pfsServerAPI) StartUpload(ctx context.Context, req *pfs.StartUploadRequest) (*pfs.UploadInfo, error) {
+	if api.mock.StartUpload.handler != nil {
+		return api.mock.StartUpload.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.StartUpload")
+}
+func (api *pfsServerAPI) AddUploadPart(ctx context.Context, req *pfs.AddUploadPartRequest) (*types.Empty, error) {
+	if api.mock.AddUploadPart.handler != nil {
+		return api.mock.AddUploadPart.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.AddUploadPart")
+}
+func (api *pfsServerAPI) InspectUpload(ctx context.Context, req *pfs.InspectUploadRequest) (*pfs.UploadInfo, error) {
+	if api.mock.InspectUpload.handler != nil {
+		return api.mock.InspectUpload.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.InspectUpload")
+}
+func (api *pfsServerAPI) FinishUpload(ctx context.Context, req *pfs.FinishUploadRequest) (*types.Empty, error) {
+	if api.mock.FinishUpload.handler != nil {
+		return api.mock.FinishUpload.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.FinishUpload")
+}
+func (api *pfsServerAPI) AbortUpload(ctx context.Context, req *pfs.AbortUploadRequest) (*types.Empty, error) {
+	if api.mock.AbortUpload.handler != nil {
+		return api.mock.AbortUpload.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pfs.AbortUpload")
+}
 func (api *pfsServerAPI) RunLoadTest(ctx context.Context, req *pfs.RunLoadTestRequest) (*pfs.RunLoadTestResponse, error) {
 	if api.mock.RunLoadTest.handler != nil {
 		return api.mock.RunLoadTest.handler(ctx, req)
@@ -1126,7 +1528,15 @@ func NewMockPachd(ctx context.Context) (*MockPachd, error) {
 	mock.Version.api.mock = &mock.Version
 	mock.Admin.api.mock = &mock.Admin
 
-	server, err := grpcutil.NewServer(ctx, false)
+	// Requests are validated (and panics recovered) as they are by pachd, so
+	// that the API servers linked to the mock by RealEnv see the same requests
+	// they would in pachd.
+	validationInterceptor := validation.NewInterceptor(validation.PFSRules, validation.PPSRules)
+	recoveryInterceptor := grpcutil.NewRecoveryInterceptor()
+	server, err := grpcutil.NewServer(ctx, false,
+		grpc.ChainUnaryInterceptor(recoveryInterceptor.InterceptUnary, validationInterceptor.InterceptUnary),
+		grpc.ChainStreamInterceptor(recoveryInterceptor.InterceptStream, validationInterceptor.InterceptStream),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -29,9 +29,11 @@ type PfsWrites interface {
 	StartCommit(*pfs.StartCommitRequest) (*pfs.Commit, error)
 	FinishCommit(*pfs.FinishCommitRequest) error
 	SquashCommitSet(*pfs.SquashCommitSetRequest) error
+	AddFileSet(*pfs.AddFileSetRequest) error
 
 	CreateBranch(*pfs.CreateBranchRequest) error
 	DeleteBranch(*pfs.DeleteBranchRequest) error
+	PromoteBranch(*pfs.PromoteBranchRequest) error
 }
 
 // PpsWrites is an interface providing a wrapper for each operation that
@@ -139,6 +141,11 @@ func (t *directTransaction) SquashCommitSet(original *pfs.SquashCommitSetRequest
 	return t.txnEnv.serviceEnv.PfsServer().SquashCommitSetInTransaction(t.txnCtx, req)
 }
 
+func (t *directTransaction) AddFileSet(original *pfs.AddFileSetRequest) error {
+	req := proto.Clone(original).(*pfs.AddFileSetRequest)
+	return t.txnEnv.serviceEnv.PfsServer().AddFileSetInTransaction(t.txnCtx, req)
+}
+
 func (t *directTransaction) CreateBranch(original *pfs.CreateBranchRequest) error {
 	req := proto.Clone(original).(*pfs.CreateBranchRequest)
 	return t.txnEnv.serviceEnv.PfsServer().CreateBranchInTransaction(t.txnCtx, req)
@@ -149,6 +156,11 @@ func (t *directTransaction) DeleteBranch(original *pfs.DeleteBranchRequest) erro
 	return t.txnEnv.serviceEnv.PfsServer().DeleteBranchInTransaction(t.txnCtx, req)
 }
 
+func (t *directTransaction) PromoteBranch(original *pfs.PromoteBranchRequest) error {
+	req := proto.Clone(original).(*pfs.PromoteBranchRequest)
+	return t.txnEnv.serviceEnv.PfsServer().PromoteBranchInTransaction(t.txnCtx, req)
+}
+
 func (t *directTransaction) StopJob(original *pps.StopJobRequest) error {
 	req := proto.Clone(original).(*pps.StopJobRequest)
 	return t.txnEnv.serviceEnv.PpsServer().StopJobInTransaction(t.txnCtx, req)
@@ -216,6 +228,11 @@ func (t *appendTransaction) SquashCommitSet(req *pfs.SquashCommitSetRequest) err
 	return err
 }
 
+func (t *appendTransaction) AddFileSet(req *pfs.AddFileSetRequest) error {
+	_, err := t.txnEnv.txnServer.AppendRequest(t.ctx, t.activeTxn, &transaction.TransactionRequest{AddFileSet: req})
+	return err
+}
+
 func (t *appendTransaction) CreateBranch(req *pfs.CreateBranchRequest) error {
 	_, err := t.txnEnv.txnServer.AppendRequest(t.ctx, t.activeTxn, &transaction.TransactionRequest{CreateBranch: req})
 	return err
@@ -226,6 +243,11 @@ func (t *appendTransaction) DeleteBranch(req *pfs.DeleteBranchRequest) error {
 	return err
 }
 
+func (t *appendTransaction) PromoteBranch(req *pfs.PromoteBranchRequest) error {
+	_, err := t.txnEnv.txnServer.AppendRequest(t.ctx, t.activeTxn, &transaction.TransactionRequest{PromoteBranch: req})
+	return err
+}
+
 func (t *appendTransaction) StopJob(req *pps.StopJobRequest) error {
 	_, err := t.txnEnv.txnServer.AppendRequest(t.ctx, t.activeTxn, &transaction.TransactionRequest{StopJob: req})
 	return err
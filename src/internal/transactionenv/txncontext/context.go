@@ -50,6 +50,16 @@ func (t *TransactionContext) PropagateBranch(branch *pfs.Branch) error {
 	return t.PfsPropagater.PropagateBranch(branch)
 }
 
+// ReleaseFileSets releases the file sets that PFS holds for the stored
+// transaction txnID at the end of the transaction (if all operations complete
+// successfully).  This is used when the transaction is finished, after which
+// its commits reference the file sets, or deleted.
+func (t *TransactionContext) ReleaseFileSets(txnID string, fileSetIDs []string) {
+	if t.PfsPropagater != nil {
+		t.PfsPropagater.ReleaseFileSets(txnID, fileSetIDs)
+	}
+}
+
 // Finish applies the deferred logic in the pfsPropagator and ppsPropagator to
 // the transaction
 func (t *TransactionContext) Finish() error {
@@ -66,10 +76,11 @@ func (t *TransactionContext) Finish() error {
 	return nil
 }
 
-// PfsPropagater is the interface that PFS implements to propagate commits at
-// the end of a transaction.  It is defined here to avoid a circular dependency.
+// PfsPropagater is the interface that PFS implements to propagate commits and
+// release file sets at the end of a transaction.  It is defined here to avoid a circular dependency.
 type PfsPropagater interface {
 	PropagateBranch(branch *pfs.Branch) error
+	ReleaseFileSets(txnID string, fileSetIDs []string)
 	Run() error
 }
 
@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Interceptor validates the requests of RPCs against a set of Rules.
+type Interceptor struct {
+	rules Rules
+}
+
+// NewInterceptor returns an Interceptor that validates requests against
+// rules.
+func NewInterceptor(rules ...Rules) *Interceptor {
+	return &Interceptor{
+		rules: Merge(rules...),
+	}
+}
+
+// InterceptUnary validates the requests of unary RPCs.
+func (i *Interceptor) InterceptUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := i.rules.Validate(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// InterceptStream validates each message received by streaming RPCs.
+func (i *Interceptor) InterceptStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingStream{ServerStream: stream, rules: i.rules})
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	rules Rules
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.rules.Validate(m)
+}
@@ -0,0 +1,57 @@
+package validation
+
+// PFSRules are the fields that PFS requests must set. They're applied to
+// incoming RPCs by an Interceptor, so the PFS API server can assume them.
+var PFSRules = Rules{
+	"pfs_v2.CreateRepoRequest":    {Required("repo.name")},
+	"pfs_v2.InspectRepoRequest":   {Required("repo.name")},
+	"pfs_v2.DeleteRepoRequest":    {OneOf("repo.name", "labels")},
+	"pfs_v2.DeleteReposRequest":   {OneOf("repos", "labels")},
+	"pfs_v2.BreakRepoLockRequest": {Required("repo.name"), Required("reason")},
+
+	"pfs_v2.CreateProjectRequest":  {Required("project.name")},
+	"pfs_v2.InspectProjectRequest": {Required("project.name")},
+	"pfs_v2.DeleteProjectRequest":  {Required("project.name")},
+
+	"pfs_v2.StartCommitRequest":      {Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.FinishCommitRequest":     {Required("commit.branch.repo.name")},
+	"pfs_v2.ClearCommitRequest":      {Required("commit.branch.repo.name")},
+	"pfs_v2.CherryPickCommitRequest": {Required("commit.branch.repo.name"), Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.RecallCommitRequest":     {Required("commit.branch.repo.name")},
+	"pfs_v2.ArchiveCommitRequest":    {Required("commit.branch.repo.name")},
+	"pfs_v2.InspectCommitRequest":    {Required("commit.branch.repo.name")},
+	"pfs_v2.ListCommitRequest":       {Required("repo.name")},
+	"pfs_v2.SubscribeCommitRequest":  {OneOf("repo.name", "repos")},
+	"pfs_v2.InspectCommitSetRequest": {Required("commit_set.id")},
+	"pfs_v2.CommitLineageRequest":    {Required("commit.branch.repo.name")},
+	"pfs_v2.SquashCommitSetRequest":  {Required("commit_set.id")},
+
+	"pfs_v2.CreateBranchRequest":  {Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.InspectBranchRequest": {Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.ListBranchRequest":    {Required("repo")},
+	"pfs_v2.DeleteBranchRequest":  {Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.WatchBranchRequest":   {Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.MergeBranchRequest":   {Required("from.repo.name"), Required("from.name"), Required("into.repo.name"), Required("into.name")},
+	"pfs_v2.ChangeFeedRequest":    {Required("branch.repo.name"), Required("branch.name")},
+	"pfs_v2.PromoteBranchRequest": {Required("from.repo.name"), Required("from.name"), Required("to.repo.name"), Required("to.name")},
+
+	"pfs_v2.GetFileRequest":         {Required("file.commit.branch.repo.name")},
+	"pfs_v2.InspectFileRequest":     {Required("file.commit.branch.repo.name")},
+	"pfs_v2.ListFileRequest":        {Required("file.commit.branch.repo.name")},
+	"pfs_v2.WalkFileRequest":        {Required("file.commit.branch.repo.name")},
+	"pfs_v2.ListFileHistoryRequest": {Required("file.commit.branch.repo.name")},
+	"pfs_v2.DirectorySizesRequest":  {Required("file.commit.branch.repo.name")},
+	"pfs_v2.ListTagsRequest":        {Required("file.commit.branch.repo.name")},
+	"pfs_v2.GlobFileRequest":        {Required("commit.branch.repo.name")},
+	"pfs_v2.DiffFileRequest":        {Required("new_file.commit.branch.repo.name")},
+	"pfs_v2.DiffFileContentRequest": {Required("new_file.commit.branch.repo.name"), Required("new_file.path")},
+	"pfs_v2.ListFileChunksRequest":  {Required("file.commit.branch.repo.name")},
+	"pfs_v2.CopyFileRequest":        {Required("commit.branch.repo.name"), Required("copy_file.src.commit.branch.repo.name")},
+
+	"pfs_v2.ReservePathRequest": {Required("repo.name"), Required("prefix")},
+	"pfs_v2.ReleasePathRequest": {Required("repo.name"), Required("prefix")},
+
+	"pfs_v2.GetFileSetRequest":   {Required("commit.branch.repo.name")},
+	"pfs_v2.AddFileSetRequest":   {Required("commit.branch.repo.name"), Required("file_set_id")},
+	"pfs_v2.RenewFileSetRequest": {Required("file_set_id")},
+}
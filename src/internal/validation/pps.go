@@ -0,0 +1,20 @@
+package validation
+
+// PPSRules are the fields that PPS requests must set. They're applied to
+// incoming RPCs by an Interceptor, so the PPS API server can assume them.
+var PPSRules = Rules{
+	"pps_v2.InspectJobRequest":   {Required("job.pipeline.name"), Required("job.id")},
+	"pps_v2.DeleteJobRequest":    {Required("job.pipeline.name"), Required("job.id")},
+	"pps_v2.StopJobRequest":      {Required("job.pipeline.name"), Required("job.id")},
+	"pps_v2.InspectDatumRequest": {Required("datum.job.pipeline.name"), Required("datum.job.id"), Required("datum.id")},
+	"pps_v2.ListDatumRequest":    {OneOf("job.id", "input")},
+	"pps_v2.RestartDatumRequest": {Required("job.pipeline.name"), Required("job.id")},
+
+	"pps_v2.CreatePipelineRequest":  {Required("pipeline.name")},
+	"pps_v2.InspectPipelineRequest": {Required("pipeline.name")},
+	"pps_v2.DeletePipelineRequest":  {OneOf("pipeline.name", "all")},
+	"pps_v2.StartPipelineRequest":   {Required("pipeline.name")},
+	"pps_v2.StopPipelineRequest":    {Required("pipeline.name")},
+	"pps_v2.RunPipelineRequest":     {Required("pipeline.name")},
+	"pps_v2.RunCronRequest":         {Required("pipeline.name")},
+}
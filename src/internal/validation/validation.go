@@ -0,0 +1,159 @@
+// Package validation checks that API requests have the fields they need
+// before they reach the API servers, so that servers don't have to repeat
+// nil checks for every RPC, and malformed requests get a consistent error
+// naming the offending field instead of a panic or a vague error.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// FieldError is returned for a request with a missing or invalid field.
+type FieldError struct {
+	// Request is the name of the request message, e.g.
+	// "pfs_v2.InspectFileRequest".
+	Request string
+	// Field is the path to the field, e.g. "file.commit.branch.repo".
+	Field string
+	// Reason describes what's wrong with the field, e.g. "is required".
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %s %s", e.Request, e.Field, e.Reason)
+}
+
+// GRPCStatus returns an InvalidArgument status, so that clients can tell
+// invalid requests apart from other errors.
+func (e *FieldError) GRPCStatus() *status.Status {
+	return status.New(codes.InvalidArgument, e.Error())
+}
+
+// IsFieldError returns true if err is a *FieldError.
+func IsFieldError(err error) bool {
+	var fieldErr *FieldError
+	return errors.As(err, &fieldErr)
+}
+
+// A Rule checks a request, returning a *FieldError if the request breaks it.
+type Rule func(req proto.Message) *FieldError
+
+// Required returns a Rule that requires the field at path (a dot-separated
+// list of proto field names) to be set, along with each message on the way to
+// it. Strings must be non-empty, repeated fields must have an element, and
+// messages must be non-nil.
+func Required(path string) Rule {
+	fields := strings.Split(path, ".")
+	return func(req proto.Message) *FieldError {
+		v := reflect.ValueOf(req)
+		for i, field := range fields {
+			var ok bool
+			if v, ok = getField(v, field); !ok {
+				return &FieldError{
+					Request: proto.MessageName(req),
+					Field:   strings.Join(fields[:i+1], "."),
+					Reason:  "is required",
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// OneOf returns a Rule that requires at least one of the fields at paths to be
+// set, as defined by Required.
+func OneOf(paths ...string) Rule {
+	required := make([]Rule, len(paths))
+	for i, path := range paths {
+		required[i] = Required(path)
+	}
+	return func(req proto.Message) *FieldError {
+		for _, rule := range required {
+			if rule(req) == nil {
+				return nil
+			}
+		}
+		return &FieldError{
+			Request: proto.MessageName(req),
+			Field:   strings.Join(paths, " or "),
+			Reason:  "is required",
+		}
+	}
+}
+
+// getField returns the field of the message v with the proto name, and
+// whether it's set.
+func getField(v reflect.Value, name string) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !hasProtoName(t.Field(i).Tag.Get("protobuf"), name) {
+			continue
+		}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			return f, !f.IsNil()
+		case reflect.Slice, reflect.Map, reflect.String:
+			return f, f.Len() > 0
+		default:
+			return f, !f.IsZero()
+		}
+	}
+	return v, false
+}
+
+func hasProtoName(tag, name string) bool {
+	for _, part := range strings.Split(tag, ",") {
+		if part == "name="+name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules maps the names of request messages to the rules requests of that type
+// must follow.
+type Rules map[string][]Rule
+
+// Validate returns the error of the first rule for req's type that req
+// breaks, or nil if it follows them all. Requests with no rules are valid.
+func (r Rules) Validate(req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	for _, rule := range r[proto.MessageName(msg)] {
+		if err := rule(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge returns a Rules with the rules in each of rules.
+func Merge(rules ...Rules) Rules {
+	merged := make(Rules)
+	for _, r := range rules {
+		for name, rs := range r {
+			merged[name] = append(merged[name], rs...)
+		}
+	}
+	return merged
+}
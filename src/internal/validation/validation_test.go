@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+var testRules = Rules{
+	"pfs_v2.InspectFileRequest": {Required("file.commit.branch.repo.name")},
+	"pfs_v2.GlobFileRequest":    {Required("commit"), Required("pattern")},
+	"pfs_v2.SubscribeCommitRequest": {
+		OneOf("repo.name", "branch"),
+	},
+}
+
+func TestRequired(t *testing.T) {
+	err := testRules.Validate(&pfs.InspectFileRequest{})
+	require.YesError(t, err)
+	require.True(t, IsFieldError(err))
+	var fieldErr *FieldError
+	require.True(t, errors.As(err, &fieldErr))
+	require.Equal(t, "pfs_v2.InspectFileRequest", fieldErr.Request)
+	require.Equal(t, "file", fieldErr.Field)
+
+	err = testRules.Validate(&pfs.InspectFileRequest{File: &pfs.File{Commit: &pfs.Commit{Branch: &pfs.Branch{Repo: &pfs.Repo{}}}}})
+	require.True(t, errors.As(err, &fieldErr))
+	require.Equal(t, "file.commit.branch.repo.name", fieldErr.Field)
+
+	require.NoError(t, testRules.Validate(&pfs.InspectFileRequest{File: &pfs.File{Commit: &pfs.Commit{Branch: &pfs.Branch{Repo: &pfs.Repo{Name: "repo"}}}}}))
+
+	err = testRules.Validate(&pfs.GlobFileRequest{Commit: &pfs.Commit{}})
+	require.True(t, errors.As(err, &fieldErr))
+	require.Equal(t, "pattern", fieldErr.Field)
+}
+
+func TestOneOf(t *testing.T) {
+	err := testRules.Validate(&pfs.SubscribeCommitRequest{})
+	require.YesError(t, err)
+	require.True(t, IsFieldError(err))
+	require.NoError(t, testRules.Validate(&pfs.SubscribeCommitRequest{Repo: &pfs.Repo{Name: "repo"}}))
+	require.NoError(t, testRules.Validate(&pfs.SubscribeCommitRequest{Branch: "master"}))
+}
+
+func TestNoRules(t *testing.T) {
+	require.NoError(t, testRules.Validate(&pfs.ListRepoRequest{}))
+	require.NoError(t, testRules.Validate("not a message"))
+}
+
+func TestInterceptUnary(t *testing.T) {
+	i := NewInterceptor(testRules)
+	var called bool
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+	_, err := i.InterceptUnary(context.Background(), &pfs.InspectFileRequest{}, &grpc.UnaryServerInfo{}, handler)
+	require.YesError(t, err)
+	require.False(t, called)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	req := &pfs.InspectFileRequest{File: &pfs.File{Commit: &pfs.Commit{Branch: &pfs.Branch{Repo: &pfs.Repo{Name: "repo"}}}}}
+	_, err = i.InterceptUnary(context.Background(), req, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	require.True(t, called)
+}
@@ -36,11 +36,25 @@ func DecodeHash(hash string) ([]byte, error) {
 	return hex.DecodeString(hash)
 }
 
+func (p *Project) String() string {
+	return p.GetName()
+}
+
+// QualifiedName returns the name of the repo prefixed by its project, if it
+// belongs to one. Repos in the default project are identified by their name
+// alone.
+func (r *Repo) QualifiedName() string {
+	if r.GetProject().GetName() == "" {
+		return r.Name
+	}
+	return r.Project.Name + "/" + r.Name
+}
+
 func (r *Repo) String() string {
 	if r.Type == UserRepoType {
-		return r.Name
+		return r.QualifiedName()
 	}
-	return r.Name + "." + r.Type
+	return r.QualifiedName() + "." + r.Type
 }
 
 func (r *Repo) NewBranch(name string) *Branch {
@@ -2,9 +2,12 @@ package cmds
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/cmdutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsimport"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pretty"
 
 	"github.com/spf13/cobra"
 )
@@ -32,5 +35,85 @@ func Cmds() []*cobra.Command {
 	}
 	commands = append(commands, cmdutil.CreateAlias(inspectCluster, "inspect cluster"))
 
+	inspectStorage := &cobra.Command{
+		Short: "Returns a summary of the storage used by the pachyderm cluster",
+		Long:  "Returns a summary of the storage used by the pachyderm cluster. Logical size is the size of the data in all repos, physical size is the size of the (deduplicated, compressed) chunks in object storage.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			si, err := c.InspectStorage()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Logical size: %s\n", pretty.Size(si.LogicalBytes))
+			fmt.Printf("Physical size: %s\n", pretty.Size(si.PhysicalBytes))
+			fmt.Printf("Chunks: %d\n", si.ChunkCount)
+			fmt.Printf("Temporary filesets: %s\n", pretty.Size(si.TemporaryBytes))
+			fmt.Printf("Reclaimable: %s\n", pretty.Size(si.ReclaimableBytes))
+			return nil
+		}),
+	}
+	commands = append(commands, cmdutil.CreateAlias(inspectStorage, "inspect storage"))
+
+	var pachctl1x, config1x, stateFile string
+	var verifyOnly bool
+	migrate1x := &cobra.Command{
+		Use:   "{{alias}}",
+		Short: "Import the repos of a Pachyderm 1.x cluster.",
+		Long: `Import the repos of a Pachyderm 1.x cluster, reading them with a 1.x pachctl binary.
+
+REQUIREMENT: a Pachyderm 1.x pachctl must be installed, and configured for the
+1.x cluster, alongside this pachctl, and passed with --pachctl-1x. The 1.x
+cluster can't be read without it. Its version is checked before the import
+starts.
+
+Every branch and commit in the 1.x cluster is recreated in this cluster, with
+the same parents and provenance, and only the files that a commit changed are
+copied into it. A commit that's provenant on other commits is imported in the
+same commit set as the latest of them. Progress is recorded in the state file,
+so an interrupted import can be resumed by running the command again. After
+importing, the imported commits are verified against the source, by comparing
+the SHA-256 hashes of their files.`,
+		Example: `
+# import from the 1.x cluster that a 1.x pachctl is configured for
+$ {{alias}} --pachctl-1x /usr/local/bin/pachctl-1.13 --config-1x ~/.pachyderm-1x/config.json --state-file import.json`,
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			src, err := pfsimport.NewPachctlSource(c.Ctx(), pachctl1x, config1x)
+			if err != nil {
+				return err
+			}
+			im, err := pfsimport.NewImporter(src, c, stateFile)
+			if err != nil {
+				return err
+			}
+			im.Log = func(format string, args ...interface{}) {
+				fmt.Fprintf(os.Stderr, format+"\n", args...)
+			}
+			if !verifyOnly {
+				if err := im.Import(c.Ctx()); err != nil {
+					return err
+				}
+			}
+			if err := im.Verify(c.Ctx()); err != nil {
+				return err
+			}
+			fmt.Println("import verified")
+			return nil
+		}),
+	}
+	migrate1x.Flags().StringVar(&pachctl1x, "pachctl-1x", "pachctl-1x", "The 1.x pachctl binary to read the 1.x cluster with.")
+	migrate1x.Flags().StringVar(&config1x, "config-1x", "", "The config file of the 1.x pachctl, if it isn't the default one. The 1.x and 2.x config formats differ, so the two can't share one.")
+	migrate1x.Flags().StringVar(&stateFile, "state-file", "pachyderm-1x-import.json", "The file that import progress is recorded in.")
+	migrate1x.Flags().BoolVar(&verifyOnly, "verify-only", false, "Only verify a previous import, don't import anything.")
+	commands = append(commands, cmdutil.CreateAlias(migrate1x, "migrate 1x"))
+
 	return commands
 }
@@ -1,18 +1,84 @@
 package server
 
 import (
+	"time"
+
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/admin"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/log"
+	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
 
 	"golang.org/x/net/context"
 )
 
 type apiServer struct {
 	log.Logger
+	env         serviceenv.ServiceEnv
 	clusterInfo *admin.ClusterInfo
 }
 
 func (a *apiServer) InspectCluster(ctx context.Context, request *types.Empty) (*admin.ClusterInfo, error) {
 	return a.clusterInfo, nil
 }
+
+// chunksQuery selects the latest generation of every chunk that has been
+// uploaded to object storage, along with its tracker object.
+const chunksQuery = `
+	SELECT c.size, c.tombstone, t.int_id, t.expires_at
+	FROM (
+		SELECT DISTINCT ON (chunk_id) chunk_id, size, tombstone
+		FROM storage.chunk_objects
+		WHERE uploaded
+		ORDER BY chunk_id, gen DESC
+	) c
+	LEFT JOIN storage.tracker_objects t ON t.str_id = $1 || encode(c.chunk_id, 'hex')
+`
+
+func (a *apiServer) InspectStorage(ctx context.Context, request *types.Empty) (response *admin.StorageInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	response = &admin.StorageInfo{}
+	pachClient := a.env.GetPachClient(ctx)
+	// Only user repos are counted, as system repos (such as spec and meta
+	// repos) hold pachyderm's own records rather than user data.
+	repoInfos, err := pachClient.PfsAPIClient.ListRepo(pachClient.Ctx(), &pfs.ListRepoRequest{Type: pfs.UserRepoType})
+	if err != nil {
+		return nil, err
+	}
+	for _, repoInfo := range repoInfos.RepoInfo {
+		response.LogicalBytes += repoInfo.SizeBytes
+	}
+	db := a.env.GetDBClient()
+	// Chunks are garbage once nothing refers to them and their tracker object
+	// has expired (or they have already been tombstoned by the GC).
+	if err := db.QueryRowxContext(ctx, `
+		SELECT
+			count(*) FILTER (WHERE NOT c.tombstone),
+			COALESCE(sum(c.size) FILTER (WHERE NOT c.tombstone), 0),
+			COALESCE(sum(c.size) FILTER (WHERE c.tombstone OR (
+				(c.expires_at IS NULL OR c.expires_at < CURRENT_TIMESTAMP)
+				AND NOT EXISTS (SELECT 1 FROM storage.tracker_refs r WHERE r.to_id = c.int_id)
+			)), 0)
+		FROM (`+chunksQuery+`) c
+	`, chunk.TrackerPrefix).Scan(&response.ChunkCount, &response.PhysicalBytes, &response.ReclaimableBytes); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	// Temporary filesets are the ones whose tracker objects still expire.
+	if err := db.GetContext(ctx, &response.TemporaryBytes, `
+		SELECT COALESCE(sum(c.size), 0)
+		FROM (`+chunksQuery+`) c
+		WHERE NOT c.tombstone AND c.int_id IN (
+			SELECT r.to_id
+			FROM storage.tracker_refs r
+			JOIN storage.tracker_objects f ON f.int_id = r.from_id
+			WHERE f.str_id LIKE $2 || '%' AND f.expires_at IS NOT NULL
+		)
+	`, chunk.TrackerPrefix, fileset.TrackerPrefix); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	return response, nil
+}
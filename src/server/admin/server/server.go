@@ -15,6 +15,7 @@ type APIServer interface {
 func NewAPIServer(env serviceenv.ServiceEnv) APIServer {
 	return &apiServer{
 		Logger: log.NewLogger("admin.API", env.Logger()),
+		env:    env,
 		clusterInfo: &admin.ClusterInfo{
 			ID:           env.ClusterID(),
 			DeploymentID: env.Config().DeploymentID,
@@ -0,0 +1,46 @@
+package testing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/internal/testpachd"
+	"github.com/pachyderm/pachyderm/v2/src/internal/testutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	adminserver "github.com/pachyderm/pachyderm/v2/src/server/admin/server"
+)
+
+func TestInspectStorage(t *testing.T) {
+	env := testpachd.NewRealEnv(t, testutil.NewTestDBConfig(t))
+	admin := adminserver.NewAPIServer(env.ServiceEnv)
+
+	data := strings.Repeat("foo", 1000)
+	require.NoError(t, env.PachClient.CreateRepo("repo"))
+	require.NoError(t, env.PachClient.PutFile(client.NewCommit("repo", "master", ""), "file", strings.NewReader(data)))
+	// System repos aren't counted in the logical size.
+	meta := client.NewSystemRepo("repo", pfs.MetaRepoType)
+	_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{Repo: meta})
+	require.NoError(t, err)
+	require.NoError(t, env.PachClient.PutFile(meta.NewCommit("master", ""), "file", strings.NewReader(data+data)))
+
+	info, err := admin.InspectStorage(env.PachClient.Ctx(), &types.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(data)), info.LogicalBytes)
+	require.True(t, info.ChunkCount > 0)
+	require.True(t, info.PhysicalBytes > 0)
+	require.Equal(t, uint64(0), info.TemporaryBytes)
+
+	// A temporary fileset's chunks are counted until it expires.
+	_, err = env.PachClient.WithCreateFileSetClient(func(mf client.ModifyFile) error {
+		return mf.PutFile("temp", strings.NewReader(strings.Repeat("bar", 1000)))
+	})
+	require.NoError(t, err)
+	info2, err := admin.InspectStorage(env.PachClient.Ctx(), &types.Empty{})
+	require.NoError(t, err)
+	require.True(t, info2.TemporaryBytes > 0)
+	require.True(t, info2.PhysicalBytes > info.PhysicalBytes)
+}
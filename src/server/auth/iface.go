@@ -15,6 +15,7 @@ type APIServer interface {
 
 	CheckRepoIsAuthorized(context.Context, string, ...auth_client.Permission) error
 	CheckClusterIsAuthorized(ctx context.Context, p ...auth_client.Permission) error
+	CheckRoleBindingIsAuthorized(ctx context.Context, binding *auth_client.RoleBinding, r *auth_client.Resource, p ...auth_client.Permission) error
 	CheckClusterIsAuthorizedInTransaction(*txncontext.TransactionContext, ...auth_client.Permission) error
 	CheckRepoIsAuthorizedInTransaction(*txncontext.TransactionContext, string, ...auth_client.Permission) error
 
@@ -174,6 +174,9 @@ func init() {
 			[]auth.Permission{
 				auth.Permission_CLUSTER_MODIFY_BINDINGS,
 				auth.Permission_CLUSTER_GET_BINDINGS,
+				auth.Permission_CLUSTER_INSPECT_STORAGE,
+				auth.Permission_CLUSTER_GARBAGE_COLLECT,
+				auth.Permission_CLUSTER_BREAK_REPO_LOCK,
 				auth.Permission_CLUSTER_AUTH_ACTIVATE,
 				auth.Permission_CLUSTER_AUTH_DEACTIVATE,
 				auth.Permission_CLUSTER_AUTH_GET_CONFIG,
@@ -701,6 +701,39 @@ func TestGetRobotTokenErrorNonAdminUser(t *testing.T) {
 	require.Matches(t, "needs permissions \\[CLUSTER_AUTH_GET_ROBOT_TOKEN\\] on CLUSTER", err.Error())
 }
 
+// TestBreakRepoLock tests that only cluster admins can break a repo's lock,
+// even if they own the repo, and that the lock records who broke it.
+func TestBreakRepoLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	tu.DeleteAll(t)
+	defer tu.DeleteAll(t)
+
+	alice := robot(tu.UniqueString("alice"))
+	aliceClient, rootClient := tu.GetAuthenticatedPachClient(t, alice), tu.GetAuthenticatedPachClient(t, auth.RootUser)
+	repo := tu.UniqueString("TestBreakRepoLock")
+	_, err := aliceClient.PfsAPIClient.CreateRepo(aliceClient.Ctx(), &pfs.CreateRepoRequest{
+		Repo: client.NewRepo(repo),
+		Settings: &pfs.RepoSettings{Lock: &pfs.RepoLock{
+			Until:  TSProtoOrDie(t, time.Now().Add(time.Hour)),
+			Reason: "litigation",
+		}},
+	})
+	require.NoError(t, err)
+
+	err = aliceClient.BreakRepoLock(repo, "settled")
+	require.YesError(t, err)
+	require.Matches(t, "needs permissions \\[CLUSTER_BREAK_REPO_LOCK\\] on CLUSTER", err.Error())
+	require.NoError(t, rootClient.BreakRepoLock(repo, "settled"))
+
+	repoInfo, err := aliceClient.InspectRepo(repo)
+	require.NoError(t, err)
+	require.Equal(t, auth.RootUser, repoInfo.Settings.Lock.BrokenBy)
+	require.Equal(t, "settled", repoInfo.Settings.Lock.BreakReason)
+	require.NoError(t, aliceClient.DeleteRepo(repo, false))
+}
+
 // TestRobotUserWhoAmI tests that robot users can call WhoAmI and get a response
 // with the right prefix
 func TestRobotUserWhoAmI(t *testing.T) {
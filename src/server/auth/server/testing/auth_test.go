@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -1290,6 +1291,62 @@ func TestListRepoNoAuthInfoIfDeactivated(t *testing.T) {
 	}
 }
 
+// TestWatchDeletedRepo tests that a user who can read a repo sees its removal
+// in Watch, even though the repo's role binding is deleted along with it.
+func TestWatchDeletedRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	tu.DeleteAll(t)
+	defer tu.DeleteAll(t)
+	alice, bob := robot(tu.UniqueString("alice")), robot(tu.UniqueString("bob"))
+	aliceClient, bobClient := tu.GetAuthenticatedPachClient(t, alice), tu.GetAuthenticatedPachClient(t, bob)
+
+	// alice creates a repo, and makes bob a reader
+	repo := tu.UniqueString(t.Name())
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	require.NoError(t, aliceClient.ModifyRepoRoleBinding(repo, bob, []string{auth.RepoReaderRole}))
+
+	ctx, cancel := context.WithCancel(bobClient.Ctx())
+	defer cancel()
+	events := make(chan *pfs.WatchEvent)
+	go func() {
+		bobClient.WithCtx(ctx).Watch("", func(ev *pfs.WatchEvent) error {
+			select {
+			case events <- ev:
+				return nil
+			case <-ctx.Done():
+				return errors.EnsureStack(ctx.Err())
+			}
+		}, client.NewRepo(repo))
+	}()
+	// bob's watch only returns the events from after it starts, so alice
+	// makes commits until it returns one.
+	for started := false; !started; {
+		_, err := aliceClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, aliceClient.FinishCommit(repo, "master", ""))
+		select {
+		case <-events:
+			started = true
+		case <-time.After(time.Second):
+		}
+	}
+
+	require.NoError(t, aliceClient.DeleteRepo(repo, false))
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Repo != nil && ev.Type == pfs.WatchEventType_REMOVED {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the repo's removal")
+		}
+	}
+}
+
 // TestCreateRepoAlreadyExistsError tests that creating a repo that already
 // exists gives you an error to that effect, even when auth is already
 // activated (rather than "access denied")
@@ -82,3 +82,39 @@ func (a *apiServer) CheckClusterIsAuthorized(ctx context.Context, p ...auth.Perm
 	}
 	return nil
 }
+
+// CheckRoleBindingIsAuthorized returns an error if the current user doesn't
+// have the permissions in `p` on the resource `r` under `binding`, rather
+// than under r's stored role binding. It's used to authorize access to
+// records of resources that have since been deleted, along with their role
+// bindings. The cluster role binding applies as usual.
+func (a *apiServer) CheckRoleBindingIsAuthorized(ctx context.Context, binding *auth.RoleBinding, r *auth.Resource, p ...auth.Permission) error {
+	me, err := a.WhoAmI(ctx, &auth.WhoAmIRequest{})
+	if auth.IsErrNotActivated(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	clusterBinding, err := a.getClusterRoleBinding(ctx)
+	if err != nil {
+		return err
+	}
+	permissions := make(map[auth.Permission]bool)
+	for _, permission := range p {
+		permissions[permission] = true
+	}
+	request := newAuthorizeRequest(me.Username, permissions, a.getGroups)
+	if err := request.evaluateRoleBinding(ctx, clusterBinding); err != nil {
+		return err
+	}
+	if !request.isSatisfied() && binding != nil {
+		if err := request.evaluateRoleBinding(ctx, binding); err != nil {
+			return err
+		}
+	}
+	if !request.isSatisfied() {
+		return &auth.ErrNotAuthorized{Subject: me.Username, Resource: *r, Required: p}
+	}
+	return nil
+}
@@ -194,6 +194,11 @@ func (a *InactiveAPIServer) CheckClusterIsAuthorized(ctx context.Context, p ...a
 	return nil
 }
 
+// CheckRoleBindingIsAuthorized returns nil when auth is not activated
+func (a *InactiveAPIServer) CheckRoleBindingIsAuthorized(context.Context, *auth.RoleBinding, *auth.Resource, ...auth.Permission) error {
+	return nil
+}
+
 // CheckClusterIsAuthorizedInTransaction returns nil when auth is not activated
 func (a *InactiveAPIServer) CheckClusterIsAuthorizedInTransaction(*txncontext.TransactionContext, ...auth.Permission) error {
 	return nil
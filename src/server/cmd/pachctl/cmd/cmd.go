@@ -768,6 +768,30 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(copyDocs, "copy"))
 
+	moveDocs := &cobra.Command{
+		Short: "Move a Pachyderm resource.",
+		Long:  "Move a Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(moveDocs, "move"))
+
+	cherryPickDocs := &cobra.Command{
+		Short: "Apply the changes in a Pachyderm resource to another.",
+		Long:  "Apply the changes in a Pachyderm resource to another.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(cherryPickDocs, "cherry-pick"))
+
+	lineageDocs := &cobra.Command{
+		Short: "Return the resources upstream and downstream of a Pachyderm resource.",
+		Long:  "Return the resources upstream and downstream of a Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(lineageDocs, "lineage"))
+
+	mergeDocs := &cobra.Command{
+		Short: "Merge Pachyderm resources.",
+		Long:  "Merge Pachyderm resources.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(mergeDocs, "merge"))
+
 	getDocs := &cobra.Command{
 		Short: "Get the raw data represented by a Pachyderm resource.",
 		Long:  "Get the raw data represented by a Pachyderm resource.",
@@ -780,6 +804,24 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(globDocs, "glob"))
 
+	findDocs := &cobra.Command{
+		Short: "Find Pachyderm resources by their content.",
+		Long:  "Find Pachyderm resources by their content.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(findDocs, "find"))
+
+	promoteDocs := &cobra.Command{
+		Short: "Move a Pachyderm resource to the state of another.",
+		Long:  "Move a Pachyderm resource to the state of another.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(promoteDocs, "promote"))
+
+	breakDocs := &cobra.Command{
+		Short: "End a hold on a Pachyderm resource early.",
+		Long:  "End a hold on a Pachyderm resource early.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(breakDocs, "break"))
+
 	diffDocs := &cobra.Command{
 		Short: "Show the differences between two Pachyderm resources.",
 		Long:  "Show the differences between two Pachyderm resources.",
@@ -810,12 +852,48 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(runDocs, "run"))
 
+	benchDocs := &cobra.Command{
+		Short: "Measure the performance of a Pachyderm subsystem.",
+		Long:  "Measure the performance of a Pachyderm subsystem.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(benchDocs, "bench"))
+
+	watchDocs := &cobra.Command{
+		Short: "Print changes to Pachyderm resources as they happen.",
+		Long:  "Print changes to Pachyderm resources as they happen.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(watchDocs, "watch"))
+
+	exportDocs := &cobra.Command{
+		Short: "Export Pachyderm data to local files.",
+		Long:  "Export Pachyderm data to local files.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(exportDocs, "export"))
+
 	editDocs := &cobra.Command{
 		Short: "Edit the value of an existing Pachyderm resource.",
 		Long:  "Edit the value of an existing Pachyderm resource.",
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(editDocs, "edit"))
 
+	recallDocs := &cobra.Command{
+		Short: "Move Pachyderm data back from cold storage.",
+		Long:  "Move Pachyderm data back from cold storage.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(recallDocs, "recall"))
+
+	archiveDocs := &cobra.Command{
+		Short: "Archive an existing Pachyderm resource.",
+		Long:  "Archive an existing Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(archiveDocs, "archive"))
+
+	unarchiveDocs := &cobra.Command{
+		Short: "Unarchive an archived Pachyderm resource.",
+		Long:  "Unarchive an archived Pachyderm resource.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(unarchiveDocs, "unarchive"))
+
 	subcommands = append(subcommands, pfscmds.Cmds()...)
 	subcommands = append(subcommands, ppscmds.Cmds()...)
 	subcommands = append(subcommands, deploycmds.Cmds()...)
@@ -863,17 +941,26 @@ func applyRootUsageFunc(rootCmd *cobra.Command) {
 			"tag":
 			// These are ignored - they will show up in the help topics section
 		case
+			"cherry-pick",
 			"copy",
 			"create",
 			"delete",
 			"diff",
 			"edit",
+			"archive",
+			"unarchive",
+			"recall",
 			"finish",
+			"find",
 			"wait",
 			"get",
 			"glob",
 			"inspect",
+			"lineage",
 			"list",
+			"merge",
+			"move",
+			"promote",
 			"put",
 			"restart",
 			"squash",
@@ -887,6 +974,7 @@ func applyRootUsageFunc(rootCmd *cobra.Command) {
 			"undeploy",
 			"extract",
 			"restore",
+			"break",
 			"garbage-collect",
 			"auth",
 			"enterprise",
@@ -25,10 +25,13 @@ import (
 	logutil "github.com/pachyderm/pachyderm/v2/src/internal/log"
 	"github.com/pachyderm/pachyderm/v2/src/internal/metrics"
 	"github.com/pachyderm/pachyderm/v2/src/internal/migrations"
+	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/snapshot"
 	"github.com/pachyderm/pachyderm/v2/src/internal/tls"
 	"github.com/pachyderm/pachyderm/v2/src/internal/tracing"
 	txnenv "github.com/pachyderm/pachyderm/v2/src/internal/transactionenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/validation"
 	licenseclient "github.com/pachyderm/pachyderm/v2/src/license"
 	pfsclient "github.com/pachyderm/pachyderm/v2/src/pfs"
 	ppsclient "github.com/pachyderm/pachyderm/v2/src/pps"
@@ -133,16 +136,22 @@ func doEnterpriseMode(config interface{}) (retErr error) {
 
 	// Setup External Pachd GRPC Server.
 	authInterceptor := auth.NewInterceptor(env)
+	validationInterceptor := validation.NewInterceptor(validation.PFSRules, validation.PPSRules)
+	recoveryInterceptor := grpcutil.NewRecoveryInterceptor()
 	externalServer, err := grpcutil.NewServer(
 		context.Background(),
 		true,
 		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor.InterceptUnary,
 			tracing.UnaryServerInterceptor(),
 			authInterceptor.InterceptUnary,
+			validationInterceptor.InterceptUnary,
 		),
 		grpc.ChainStreamInterceptor(
+			recoveryInterceptor.InterceptStream,
 			tracing.StreamServerInterceptor(),
 			authInterceptor.InterceptStream,
+			validationInterceptor.InterceptStream,
 		),
 	)
 	if err != nil {
@@ -238,7 +247,7 @@ func doEnterpriseMode(config interface{}) (retErr error) {
 	}
 
 	// Setup Internal Pachd GRPC Server.
-	internalServer, err := grpcutil.NewServer(context.Background(), false, grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), authInterceptor.InterceptUnary), grpc.StreamInterceptor(authInterceptor.InterceptStream))
+	internalServer, err := grpcutil.NewServer(context.Background(), false, grpc.ChainUnaryInterceptor(recoveryInterceptor.InterceptUnary, tracing.UnaryServerInterceptor(), authInterceptor.InterceptUnary, validationInterceptor.InterceptUnary), grpc.ChainStreamInterceptor(recoveryInterceptor.InterceptStream, authInterceptor.InterceptStream, validationInterceptor.InterceptStream))
 	if err != nil {
 		return err
 	}
@@ -379,16 +388,22 @@ func doSidecarMode(config interface{}) (retErr error) {
 		reporter = metrics.NewReporter(env)
 	}
 	authInterceptor := auth.NewInterceptor(env)
+	validationInterceptor := validation.NewInterceptor(validation.PFSRules, validation.PPSRules)
+	recoveryInterceptor := grpcutil.NewRecoveryInterceptor()
 	server, err := grpcutil.NewServer(
 		context.Background(),
 		false,
 		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor.InterceptUnary,
 			tracing.UnaryServerInterceptor(),
 			authInterceptor.InterceptUnary,
+			validationInterceptor.InterceptUnary,
 		),
 		grpc.ChainStreamInterceptor(
+			recoveryInterceptor.InterceptStream,
 			tracing.StreamServerInterceptor(),
 			authInterceptor.InterceptStream,
+			validationInterceptor.InterceptStream,
 		),
 	)
 	if err != nil {
@@ -525,6 +540,9 @@ func doFullMode(config interface{}) (retErr error) {
 		log.Printf("no Jaeger collector found (JAEGER_COLLECTOR_SERVICE_HOST not set)")
 	}
 	env := serviceenv.InitWithKube(serviceenv.NewConfiguration(config))
+	if err := env.Config().ValidateIntervals(); err != nil {
+		return err
+	}
 	debug.SetGCPercent(env.Config().GCPercent)
 	env.InitDexDB()
 	if env.Config().EtcdPrefix == "" {
@@ -538,6 +556,19 @@ func doFullMode(config interface{}) (retErr error) {
 	if err := migrations.BlockUntil(context.Background(), env.GetDBClient(), clusterstate.DesiredClusterState); err != nil {
 		return err
 	}
+	if env.Config().RestoreSnapshot {
+		objClient, err := obj.NewClient(env.Config().StorageBackend, env.Config().StorageRoot)
+		if err != nil {
+			return err
+		}
+		restored, err := snapshot.ImportSnapshot(context.Background(), env.GetDBClient(), objClient)
+		if err != nil {
+			return errors.Wrapf(err, "error restoring metadata snapshot")
+		}
+		if !restored.IsZero() {
+			env.Logger().Infof("restored metadata snapshot taken at %v", restored)
+		}
+	}
 
 	var reporter *metrics.Reporter
 	if env.Config().Metrics {
@@ -547,16 +578,22 @@ func doFullMode(config interface{}) (retErr error) {
 
 	// Setup External Pachd GRPC Server.
 	authInterceptor := auth.NewInterceptor(env)
+	validationInterceptor := validation.NewInterceptor(validation.PFSRules, validation.PPSRules)
+	recoveryInterceptor := grpcutil.NewRecoveryInterceptor()
 	externalServer, err := grpcutil.NewServer(
 		context.Background(),
 		true,
 		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor.InterceptUnary,
 			tracing.UnaryServerInterceptor(),
 			authInterceptor.InterceptUnary,
+			validationInterceptor.InterceptUnary,
 		),
 		grpc.ChainStreamInterceptor(
+			recoveryInterceptor.InterceptStream,
 			tracing.StreamServerInterceptor(),
 			authInterceptor.InterceptStream,
+			validationInterceptor.InterceptStream,
 		),
 	)
 
@@ -693,7 +730,7 @@ func doFullMode(config interface{}) (retErr error) {
 		return err
 	}
 	// Setup Internal Pachd GRPC Server.
-	internalServer, err := grpcutil.NewServer(context.Background(), false, grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), authInterceptor.InterceptUnary), grpc.StreamInterceptor(authInterceptor.InterceptStream))
+	internalServer, err := grpcutil.NewServer(context.Background(), false, grpc.ChainUnaryInterceptor(recoveryInterceptor.InterceptUnary, tracing.UnaryServerInterceptor(), authInterceptor.InterceptUnary, validationInterceptor.InterceptUnary), grpc.ChainStreamInterceptor(recoveryInterceptor.InterceptStream, authInterceptor.InterceptStream, validationInterceptor.InterceptStream))
 	if err != nil {
 		return err
 	}
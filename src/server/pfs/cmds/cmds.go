@@ -2,6 +2,8 @@ package cmds
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,20 +14,28 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	prompt "github.com/c-bata/go-prompt"
+	units "github.com/docker/go-units"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/mattn/go-isatty"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v2"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/cmdutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pachhash"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pager"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pfsload"
 	"github.com/pachyderm/pachyderm/v2/src/internal/progress"
@@ -70,10 +80,20 @@ or type (e.g. csv, binary, images, etc).`,
 	commands = append(commands, cmdutil.CreateDocsAlias(repoDocs, "repo", " repo$"))
 
 	var description string
+	var repoLabels map[string]string
+	repoLimits := &repoLimitFlags{}
+	var mirrorAddress, mirrorSource, mirrorSnapshot, mirrorSecret string
+	var mirrorBranches []string
+	var mirrorInterval time.Duration
+	var template string
 	createRepo := &cobra.Command{
 		Use:   "{{alias}} <repo>",
 		Short: "Create a new repo.",
-		Long:  "Create a new repo.",
+		Long: "Create a new repo. With --template, the repo is laid out like an existing repo: it gets the template's branches " +
+			"(with their triggers, but not their data), path reservations, and its description and settings unless they're given.",
+		Example: `
+# create repo "bar" with the same branches and settings as repo "foo"
+$ {{alias}} bar --template foo`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
@@ -81,12 +101,49 @@ or type (e.g. csv, binary, images, etc).`,
 			}
 			defer c.Close()
 
+			var mirror *pfs.RepoMirror
+			switch {
+			case mirrorSnapshot != "":
+				mirror = &pfs.RepoMirror{
+					SnapshotURL:     mirrorSnapshot,
+					Branches:        mirrorBranches,
+					AuthTokenSecret: mirrorSecret,
+				}
+			case mirrorAddress != "":
+				source := args[0]
+				if mirrorSource != "" {
+					source = mirrorSource
+				}
+				mirror = &pfs.RepoMirror{
+					Address:         mirrorAddress,
+					Source:          cmdutil.ParseRepo(source),
+					Branches:        mirrorBranches,
+					AuthTokenSecret: mirrorSecret,
+				}
+			}
+			if mirror != nil {
+				if mirrorInterval != 0 {
+					mirror.Interval = types.DurationProto(mirrorInterval)
+				}
+			}
+			var templateRepo *pfs.Repo
+			if template != "" {
+				templateRepo = cmdutil.ParseRepo(template)
+			}
+			settings, err := repoLimits.settings(nil)
+			if err != nil {
+				return err
+			}
 			err = txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
 				_, err = c.PfsAPIClient.CreateRepo(
 					c.Ctx(),
 					&pfs.CreateRepoRequest{
 						Repo:        client.NewRepo(args[0]),
 						Description: description,
+						Mirror:      mirror,
+						Settings:    settings,
+						Template:    templateRepo,
+						Labels:      repoLabels,
 					},
 				)
 				return err
@@ -95,6 +152,15 @@ or type (e.g. csv, binary, images, etc).`,
 		}),
 	}
 	createRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	createRepo.Flags().StringVar(&mirrorAddress, "mirror", "", "Make the repo a read-only mirror of a repo in the cluster at this address (e.g. grpc://pachd.example.com:30650).")
+	createRepo.Flags().StringVar(&mirrorSource, "mirror-repo", "", "The repo to mirror, defaults to a repo with the same name.")
+	createRepo.Flags().StringVar(&mirrorSecret, "mirror-secret", "", "A kubernetes secret holding the pachd_address and auth_token to authenticate to the mirror's source cluster with, or, for a snapshot, its snapshot_url and the object storage credentials to read it with (required).")
+	createRepo.Flags().StringVar(&mirrorSnapshot, "mirror-snapshot", "", "Make the repo a read-only mirror of an exported snapshot at this object storage URL (a .tar, .tar.gz or .tgz).")
+	createRepo.Flags().StringSliceVar(&mirrorBranches, "mirror-branch", nil, "A branch to mirror, may be specified multiple times. Defaults to all branches of the source repo, or master for a snapshot.")
+	createRepo.Flags().DurationVar(&mirrorInterval, "mirror-interval", 0, "How often to sync the mirror from its source, defaults to every minute.")
+	createRepo.Flags().StringVar(&template, "template", "", "Lay the repo out like this existing repo.")
+	createRepo.Flags().StringToStringVarP(&repoLabels, "label", "l", nil, "A key=value label to attach to the repo (can be repeated).")
+	repoLimits.addFlags(createRepo)
 	commands = append(commands, cmdutil.CreateAlias(createRepo, "create repo"))
 
 	updateRepo := &cobra.Command{
@@ -108,13 +174,26 @@ or type (e.g. csv, binary, images, etc).`,
 			}
 			defer c.Close()
 
+			repo := cmdutil.ParseRepo(args[0])
+			var settings *pfs.RepoSettings
+			if repoLimits.changed() {
+				repoInfo, err := c.PfsAPIClient.InspectRepo(c.Ctx(), &pfs.InspectRepoRequest{Repo: repo})
+				if err != nil {
+					return grpcutil.ScrubGRPC(err)
+				}
+				if settings, err = repoLimits.settings(repoInfo.Settings); err != nil {
+					return err
+				}
+			}
 			err = txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
 				_, err = c.PfsAPIClient.CreateRepo(
 					c.Ctx(),
 					&pfs.CreateRepoRequest{
-						Repo:        cmdutil.ParseRepo(args[0]),
+						Repo:        repo,
 						Description: description,
 						Update:      true,
+						Settings:    settings,
+						Labels:      repoLabels,
 					},
 				)
 				return err
@@ -123,6 +202,8 @@ or type (e.g. csv, binary, images, etc).`,
 		}),
 	}
 	updateRepo.Flags().StringVarP(&description, "description", "d", "", "A description of the repo.")
+	updateRepo.Flags().StringToStringVarP(&repoLabels, "label", "l", nil, "A key=value label to add to the repo (can be repeated); an empty value removes the label.")
+	repoLimits.addFlags(updateRepo)
 	shell.RegisterCompletionFunc(updateRepo, shell.RepoCompletion)
 	commands = append(commands, cmdutil.CreateAlias(updateRepo, "update repo"))
 
@@ -160,9 +241,18 @@ or type (e.g. csv, binary, images, etc).`,
 
 	var all bool
 	var repoType string
+	var project string
+	var repoNameGlob, repoCreatedAfter, repoSortBy string
+	var repoReverse bool
 	listRepo := &cobra.Command{
 		Short: "Return a list of repos.",
-		Long:  "Return a list of repos. By default, only show user repos",
+		Long:  "Return a list of repos. By default, only show user repos, newest first.",
+		Example: `
+# list the repos whose names start with "images-", largest first
+$ {{alias}} --name 'images-*' --sort size
+
+# list the repos created in the last day, oldest first
+$ {{alias}} --created-after 24h --reverse`,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
 			if all && repoType != "" {
 				return errors.Errorf("cannot set a repo type with --all")
@@ -176,10 +266,34 @@ or type (e.g. csv, binary, images, etc).`,
 			if repoType == "" && !all {
 				repoType = pfs.UserRepoType // default to user
 			}
-			repoInfos, err := c.ListRepoByType(repoType)
+			request := &pfs.ListRepoRequest{
+				Type:     repoType,
+				NameGlob: repoNameGlob,
+				Reverse:  repoReverse,
+				Labels:   repoLabels,
+			}
+			if project != "" {
+				request.Project = client.NewProject(project)
+			}
+			if repoCreatedAfter != "" {
+				after, err := parseTime(repoCreatedAfter)
+				if err != nil {
+					return err
+				}
+				if request.CreatedAfter, err = types.TimestampProto(after); err != nil {
+					return errors.EnsureStack(err)
+				}
+			}
+			sortBy, ok := pfs.RepoSortBy_value["BY_"+strings.ToUpper(repoSortBy)]
+			if !ok {
+				return errors.Errorf("unrecognized sort order %q, expected one of creation, name or size", repoSortBy)
+			}
+			request.SortBy = pfs.RepoSortBy(sortBy)
+			resp, err := c.PfsAPIClient.ListRepo(c.Ctx(), request)
 			if err != nil {
-				return err
+				return grpcutil.ScrubGRPC(err)
 			}
+			repoInfos := resp.RepoInfo
 			if raw {
 				for _, repoInfo := range repoInfos {
 					if err := marshaller.Marshal(os.Stdout, repoInfo); err != nil {
@@ -204,32 +318,70 @@ or type (e.g. csv, binary, images, etc).`,
 	listRepo.Flags().AddFlagSet(fullTimestampsFlags)
 	listRepo.Flags().BoolVar(&all, "all", false, "include system repos of all types")
 	listRepo.Flags().StringVar(&repoType, "type", "", "only include repos of the given type")
+	listRepo.Flags().StringVar(&project, "project", "", "only include repos in the given project")
+	listRepo.Flags().StringVar(&repoNameGlob, "name", "", "only include repos whose names match this glob")
+	listRepo.Flags().StringVar(&repoCreatedAfter, "created-after", "", "only include repos created after this time, an RFC 3339 timestamp or a duration ago")
+	listRepo.Flags().StringVar(&repoSortBy, "sort", "creation", "sort repos by creation (newest first), name or size (largest first)")
+	listRepo.Flags().BoolVar(&repoReverse, "reverse", false, "reverse the sort order")
+	listRepo.Flags().StringToStringVarP(&repoLabels, "label", "l", nil, "only include repos with this key=value label (can be repeated); an empty value matches any value")
 	commands = append(commands, cmdutil.CreateAlias(listRepo, "list repo"))
 
-	var force bool
+	var force, dryRun bool
 	deleteRepo := &cobra.Command{
-		Use:   "{{alias}} <repo>",
+		Use:   "{{alias}} <repo>...",
 		Short: "Delete a repo.",
-		Long:  "Delete a repo.",
-		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+		Long:  "Delete a repo, all repos with --all, or the repos with the given labels with --label. Several repos are deleted in one transaction, downstream repos first, so --force is only needed for repos downstream of ones that aren't being deleted.",
+		Example: `
+# delete every repo labeled team=ml-experiments
+$ {{alias}} --label team=ml-experiments
+
+# print the order that repos "raw", "clean" and "model" would be deleted in
+$ {{alias}} raw clean model --dry-run`,
+		Run: cmdutil.Run(func(args []string) error {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer c.Close()
 
+			if len(repoLabels) > 0 && all {
+				return errors.Errorf("cannot use the --label flag with --all")
+			}
+			if all && len(args) > 0 {
+				return errors.Errorf("cannot use the --all flag with an argument")
+			}
+			if all && dryRun {
+				return errors.Errorf("cannot use the --dry-run flag with --all")
+			}
+			if !all && len(args) == 0 && len(repoLabels) == 0 {
+				return errors.Errorf("either a repo name, the --all flag or the --label flag needs to be provided")
+			}
+			if len(args) > 1 || (len(args) > 0 && len(repoLabels) > 0) || dryRun {
+				var repos []*pfs.Repo
+				for _, arg := range args {
+					repos = append(repos, cmdutil.ParseRepo(arg))
+				}
+				deleted, err := c.DeleteRepos(repos, repoLabels, force, dryRun)
+				if err != nil {
+					return err
+				}
+				for _, repo := range deleted {
+					if dryRun {
+						fmt.Printf("would delete %s\n", repo)
+					} else {
+						fmt.Printf("deleted %s\n", repo)
+					}
+				}
+				return nil
+			}
+
 			request := &pfs.DeleteRepoRequest{
-				Force: force,
+				Force:  force,
+				Labels: repoLabels,
 			}
 			if len(args) > 0 {
-				if all {
-					return errors.Errorf("cannot use the --all flag with an argument")
-				}
 				request.Repo = cmdutil.ParseRepo(args[0])
-			} else if !all {
-				return errors.Errorf("either a repo name or the --all flag needs to be provided")
 			}
-
 			err = txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
 				if all {
 					_, err = c.PfsAPIClient.DeleteAll(c.Ctx(), &types.Empty{})
@@ -243,9 +395,117 @@ or type (e.g. csv, binary, images, etc).`,
 	}
 	deleteRepo.Flags().BoolVarP(&force, "force", "f", false, "remove the repo regardless of errors; use with care")
 	deleteRepo.Flags().BoolVar(&all, "all", false, "remove all repos")
+	deleteRepo.Flags().StringToStringVarP(&repoLabels, "label", "l", nil, "remove the repos with this key=value label (can be repeated); an empty value matches any value")
+	deleteRepo.Flags().BoolVar(&dryRun, "dry-run", false, "print the repos that would be deleted, in order, without deleting them")
 	shell.RegisterCompletionFunc(deleteRepo, shell.RepoCompletion)
 	commands = append(commands, cmdutil.CreateAlias(deleteRepo, "delete repo"))
 
+	var breakReason string
+	breakRepoLock := &cobra.Command{
+		Use:   "{{alias}} <repo>",
+		Short: "Break a repo's lock before it expires.",
+		Long:  "Break a repo's lock before it expires, such as when a legal hold is lifted early, so that its commits and branches can be deleted again. This requires the CLUSTER_BREAK_REPO_LOCK permission, and the caller and reason are recorded in the lock.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			if breakReason == "" {
+				return errors.Errorf("--reason must be given")
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			_, err = c.PfsAPIClient.BreakRepoLock(c.Ctx(), &pfs.BreakRepoLockRequest{
+				Repo:   cmdutil.ParseRepo(args[0]),
+				Reason: breakReason,
+			})
+			return grpcutil.ScrubGRPC(err)
+		}),
+	}
+	breakRepoLock.Flags().StringVar(&breakReason, "reason", "", "Why the lock is being broken, which is recorded in it.")
+	shell.RegisterCompletionFunc(breakRepoLock, shell.RepoCompletion)
+	commands = append(commands, cmdutil.CreateAlias(breakRepoLock, "break repo-lock"))
+
+	projectDocs := &cobra.Command{
+		Short: "Docs for projects.",
+		Long: `Projects are namespaces for repos.
+
+Repos in different projects may share a name. A repo in a project is addressed
+as <project>/<repo>, repos addressed without a project belong to the default
+project.`,
+	}
+	commands = append(commands, cmdutil.CreateDocsAlias(projectDocs, "project", " project$"))
+
+	createProject := &cobra.Command{
+		Use:   "{{alias}} <project>",
+		Short: "Create a new project.",
+		Long:  "Create a new project.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			_, err = c.PfsAPIClient.CreateProject(
+				c.Ctx(),
+				&pfs.CreateProjectRequest{
+					Project:     client.NewProject(args[0]),
+					Description: description,
+				},
+			)
+			return grpcutil.ScrubGRPC(err)
+		}),
+	}
+	createProject.Flags().StringVarP(&description, "description", "d", "", "A description of the project.")
+	commands = append(commands, cmdutil.CreateAlias(createProject, "create project"))
+
+	listProject := &cobra.Command{
+		Short: "Return a list of projects.",
+		Long:  "Return a list of projects.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			projectInfos, err := c.ListProject()
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, projectInfo := range projectInfos {
+					if err := marshaller.Marshal(os.Stdout, projectInfo); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.ProjectHeader)
+			for _, projectInfo := range projectInfos {
+				pretty.PrintProjectInfo(writer, projectInfo, fullTimestamps)
+			}
+			return writer.Flush()
+		}),
+	}
+	listProject.Flags().AddFlagSet(rawFlags)
+	listProject.Flags().AddFlagSet(fullTimestampsFlags)
+	commands = append(commands, cmdutil.CreateAlias(listProject, "list project"))
+
+	deleteProject := &cobra.Command{
+		Use:   "{{alias}} <project>",
+		Short: "Delete a project.",
+		Long:  "Delete a project. The project must be empty unless --force is used, in which case its repos are deleted too.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return c.DeleteProject(args[0], force)
+		}),
+	}
+	deleteProject.Flags().BoolVarP(&force, "force", "f", false, "delete the repos in the project along with it")
+	commands = append(commands, cmdutil.CreateAlias(deleteProject, "delete project"))
+
 	commitDocs := &cobra.Command{
 		Short: "Docs for commits.",
 		Long: `Commits are atomic transactions on the content of a repo.
@@ -263,6 +523,7 @@ Commits can be created with another commit as a parent.`,
 	commands = append(commands, cmdutil.CreateDocsAlias(commitDocs, "commit", " commit$"))
 
 	var parent string
+	var labels map[string]string
 	startCommit := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>",
 		Short: "Start a new commit.",
@@ -308,6 +569,7 @@ $ {{alias}} test -p XXX`,
 						Branch:      branch,
 						Parent:      parentCommit,
 						Description: description,
+						Labels:      labels,
 					},
 				)
 				return err
@@ -322,6 +584,7 @@ $ {{alias}} test -p XXX`,
 	startCommit.MarkFlagCustom("parent", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	startCommit.Flags().StringVarP(&description, "message", "m", "", "A description of this commit's contents")
 	startCommit.Flags().StringVar(&description, "description", "", "A description of this commit's contents (synonym for --message)")
+	startCommit.Flags().StringToStringVarP(&labels, "label", "l", nil, "A key=value label to annotate the commit with (can be repeated).")
 	shell.RegisterCompletionFunc(startCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(startCommit, "start commit"))
 
@@ -346,6 +609,7 @@ $ {{alias}} test -p XXX`,
 					&pfs.FinishCommitRequest{
 						Commit:      commit,
 						Description: description,
+						Labels:      labels,
 					},
 				)
 				return err
@@ -355,9 +619,11 @@ $ {{alias}} test -p XXX`,
 	}
 	finishCommit.Flags().StringVarP(&description, "message", "m", "", "A description of this commit's contents (overwrites any existing commit description)")
 	finishCommit.Flags().StringVar(&description, "description", "", "A description of this commit's contents (synonym for --message)")
+	finishCommit.Flags().StringToStringVarP(&labels, "label", "l", nil, "A key=value label to add to the commit (can be repeated); an empty value removes the label.")
 	shell.RegisterCompletionFunc(finishCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(finishCommit, "finish commit"))
 
+	var details bool
 	inspectCommit := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>",
 		Short: "Return info about a commit.",
@@ -376,8 +642,9 @@ $ {{alias}} test -p XXX`,
 			commitInfo, err := c.PfsAPIClient.InspectCommit(
 				c.Ctx(),
 				&pfs.InspectCommitRequest{
-					Commit: commit,
-					Wait:   pfs.CommitState_STARTED,
+					Commit:  commit,
+					Wait:    pfs.CommitState_STARTED,
+					Details: details,
 				})
 			if err != nil {
 				return grpcutil.ScrubGRPC(err)
@@ -395,13 +662,156 @@ $ {{alias}} test -p XXX`,
 			return pretty.PrintDetailedCommitInfo(os.Stdout, ci)
 		}),
 	}
+	inspectCommit.Flags().BoolVar(&details, "details", false, "Also print how the commit's data is stored (fileset IDs, compaction, chunk count and index size), which can be slow for large commits.")
 	inspectCommit.Flags().AddFlagSet(rawFlags)
 	inspectCommit.Flags().AddFlagSet(fullTimestampsFlags)
 	shell.RegisterCompletionFunc(inspectCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(inspectCommit, "inspect commit"))
 
+	recallCommit := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>",
+		Short: "Move a commit's data back from cold storage.",
+		Long:  "Move a commit's data back from cold storage, so that it can be read quickly. The data is moved to cold storage again once the repo's --cold-storage-after setting elapses.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			return c.RecallCommit(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID)
+		}),
+	}
+	shell.RegisterCompletionFunc(recallCommit, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(recallCommit, "recall commit"))
+
+	archiveCommit := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>",
+		Short: "Archive a commit.",
+		Long:  "Archive a finished commit. Archived commits are left out of list commit (unless --archived is passed) and their data is moved to cold storage, but they can still be read by ID.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			return c.ArchiveCommit(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID)
+		}),
+	}
+	shell.RegisterCompletionFunc(archiveCommit, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(archiveCommit, "archive commit"))
+
+	var cherryPickDescription string
+	cherryPickCommit := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit> <target-branch>",
+		Short: "Apply the changes made by a commit to another branch.",
+		Long:  "Apply the changes that a commit made to its parent to a new commit on another branch of the same repo. The changed files are copied by reference to their data.",
+		Example: `
+# apply the changes made by the head of branch "hotfix" of repo "foo" to branch "master"
+$ {{alias}} foo@hotfix master`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			newCommit, err := c.CherryPickCommit(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID, args[1], cherryPickDescription)
+			if err != nil {
+				return err
+			}
+			fmt.Println(newCommit.ID)
+			return nil
+		}),
+	}
+	cherryPickCommit.Flags().StringVarP(&cherryPickDescription, "message", "m", "", "A description of the new commit, the picked commit's description by default.")
+	shell.RegisterCompletionFunc(cherryPickCommit, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(cherryPickCommit, "cherry-pick commit"))
+
+	unarchiveCommit := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>",
+		Short: "Unarchive a commit.",
+		Long:  "Unarchive a commit, so that it's listed by list commit again. Its data stays in cold storage until it's recalled.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			return c.UnarchiveCommit(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID)
+		}),
+	}
+	shell.RegisterCompletionFunc(unarchiveCommit, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(unarchiveCommit, "unarchive commit"))
+
+	var upstreamDepth, downstreamDepth int64
+	commitLineage := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>",
+		Short: "Return the commits upstream and downstream of a commit.",
+		Long:  "Return the commits that a commit's data was derived from, and the commits derived from its data, following provenance across commit sets.",
+		Example: `
+# return the lineage of the head of branch "master" of repo "foo"
+$ {{alias}} foo@master
+
+# return only the commits that the head of "master" was directly derived from
+$ {{alias}} foo@master --upstream-depth 1 --downstream-depth -1`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			resp, err := c.CommitLineage(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID, upstreamDepth, downstreamDepth)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, resp)
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.LineageHeader)
+			for _, lc := range resp.Upstream {
+				pretty.PrintLineageCommit(writer, "upstream", lc, fullTimestamps)
+			}
+			for _, lc := range resp.Downstream {
+				pretty.PrintLineageCommit(writer, "downstream", lc, fullTimestamps)
+			}
+			return writer.Flush()
+		}),
+	}
+	commitLineage.Flags().Int64Var(&upstreamDepth, "upstream-depth", 0, "follow provenance upstream only this many levels; zero is unlimited, and a negative depth leaves out upstream commits")
+	commitLineage.Flags().Int64Var(&downstreamDepth, "downstream-depth", 0, "follow provenance downstream only this many levels; zero is unlimited, and a negative depth leaves out downstream commits")
+	commitLineage.Flags().AddFlagSet(rawFlags)
+	commitLineage.Flags().AddFlagSet(fullTimestampsFlags)
+	shell.RegisterCompletionFunc(commitLineage, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(commitLineage, "lineage commit"))
+
 	var from string
 	var number int
+	var listArchived bool
+	var startedAfter, finishedBefore, origin string
 	listCommit := &cobra.Command{
 		Use:   "{{alias}} <repo>[@<branch>]",
 		Short: "Return all commits on a repo.",
@@ -417,7 +827,13 @@ $ {{alias}} foo@master
 $ {{alias}} foo@master -n 20
 
 # return commits in repo "foo" since commit XXX
-$ {{alias}} foo@master --from XXX`,
+$ {{alias}} foo@master --from XXX
+
+# return commits in repo "foo" labelled with stage=prod
+$ {{alias}} foo --label stage=prod
+
+# return commits started by users in repo "foo" in the last week
+$ {{alias}} foo --origin user --started-after 168h`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
@@ -440,16 +856,41 @@ $ {{alias}} foo@master --from XXX`,
 				toCommit = branch.NewCommit("")
 			}
 
+			var opts []client.ListCommitOption
+			if listArchived {
+				opts = append(opts, client.WithArchivedListCommit())
+			}
+			if len(labels) > 0 {
+				opts = append(opts, client.WithLabelsListCommit(labels))
+			}
+			if startedAfter != "" || finishedBefore != "" {
+				after, err := parseTime(startedAfter)
+				if err != nil {
+					return err
+				}
+				before, err := parseTime(finishedBefore)
+				if err != nil {
+					return err
+				}
+				opts = append(opts, client.WithTimeRangeListCommit(after, before))
+			}
+			if origin != "" {
+				kind, ok := pfs.OriginKind_value[strings.ToUpper(origin)]
+				if !ok {
+					return errors.Errorf("unrecognized origin %q, expected one of user, auto, fsck or alias", origin)
+				}
+				opts = append(opts, client.WithOriginListCommit(pfs.OriginKind(kind)))
+			}
 			if raw {
 				return c.ListCommitF(branch.Repo, toCommit, fromCommit, uint64(number), false, func(ci *pfs.CommitInfo) error {
 					return marshaller.Marshal(os.Stdout, ci)
-				})
+				}, opts...)
 			}
 			writer := tabwriter.NewWriter(os.Stdout, pretty.CommitHeader)
 			if err := c.ListCommitF(branch.Repo, toCommit, fromCommit, uint64(number), false, func(ci *pfs.CommitInfo) error {
 				pretty.PrintCommitInfo(writer, ci, fullTimestamps)
 				return nil
-			}); err != nil {
+			}, opts...); err != nil {
 				return err
 			}
 			return writer.Flush()
@@ -457,6 +898,11 @@ $ {{alias}} foo@master --from XXX`,
 	}
 	listCommit.Flags().StringVarP(&from, "from", "f", "", "list all commits since this commit")
 	listCommit.Flags().IntVarP(&number, "number", "n", 0, "list only this many commits; if set to zero, list all commits")
+	listCommit.Flags().BoolVar(&listArchived, "archived", false, "include archived commits")
+	listCommit.Flags().StringVar(&startedAfter, "started-after", "", "list only commits started after this time, given as an RFC 3339 timestamp or a duration ago, such as 24h")
+	listCommit.Flags().StringVar(&finishedBefore, "finished-before", "", "list only commits finished before this time, given as an RFC 3339 timestamp or a duration ago, such as 24h")
+	listCommit.Flags().StringVar(&origin, "origin", "", "list only commits with this origin: user, auto, fsck or alias")
+	listCommit.Flags().StringToStringVarP(&labels, "label", "l", nil, "list only commits with this key=value label (can be repeated); an empty value matches any value")
 	listCommit.MarkFlagCustom("from", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	listCommit.Flags().AddFlagSet(rawFlags)
 	listCommit.Flags().AddFlagSet(fullTimestampsFlags)
@@ -574,8 +1020,9 @@ $ {{alias}} XXX -b bar@baz`,
 	commands = append(commands, cmdutil.CreateAlias(waitCommit, "wait commit"))
 
 	var newCommits bool
+	var cursor, subscribeOrigin, branchGlob, pathPrefix string
 	subscribeCommit := &cobra.Command{
-		Use:   "{{alias}} <repo>@<branch>",
+		Use:   "{{alias}} <repo>[@<branch>]",
 		Short: "Print commits as they are created (finished).",
 		Long:  "Print commits as they are created in the specified repo and branch.  By default, all existing commits on the specified branch are returned first.  A commit is only considered 'created' when it's been finished.",
 		Example: `
@@ -586,7 +1033,15 @@ $ {{alias}} test@master
 $ {{alias}} test@master --from XXX
 
 # subscribe to commits in repo "test" on branch "master", but only for new commits created from now on.
-$ {{alias}} test@master --new`,
+$ {{alias}} test@master --new
+
+# resume a subscription to commits in repo "test" on branch "master" after the
+# commit with cursor YYY (from the "cursor" field of --raw output).
+$ {{alias}} test@master --cursor YYY
+
+# subscribe to commits made by users on any branch starting with "release-"
+# in repo "test" that change files under /config.
+$ {{alias}} test --branch-glob 'release-*' --origin user --path /config`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
 			branch, err := cmdutil.ParseBranch(args[0])
 			if err != nil {
@@ -601,6 +1056,24 @@ $ {{alias}} test@master --new`,
 			if newCommits && from != "" {
 				return errors.Errorf("--new and --from cannot be used together")
 			}
+			if cursor != "" && (newCommits || from != "") {
+				return errors.Errorf("--cursor cannot be used with --new or --from")
+			}
+			if branchGlob != "" && branch.Name != "" {
+				return errors.Errorf("--branch-glob cannot be used with a branch")
+			}
+			opts := []client.SubscribeCommitOption{
+				client.WithCursorSubscribeCommit(cursor),
+				client.WithBranchGlobSubscribeCommit(branchGlob),
+				client.WithPathPrefixSubscribeCommit(pathPrefix),
+			}
+			if subscribeOrigin != "" {
+				kind, ok := pfs.OriginKind_value[strings.ToUpper(subscribeOrigin)]
+				if !ok {
+					return errors.Errorf("unrecognized origin %q, expected one of user, auto, fsck or alias", subscribeOrigin)
+				}
+				opts = append(opts, client.WithOriginSubscribeCommit(pfs.OriginKind(kind)))
+			}
 
 			if newCommits {
 				from = branch.Name
@@ -618,17 +1091,95 @@ $ {{alias}} test@master --new`,
 				}
 				pretty.PrintCommitInfo(w, ci, fullTimestamps)
 				return nil
-			})
+			}, opts...)
 		}),
 	}
 	subscribeCommit.Flags().StringVar(&from, "from", "", "subscribe to all commits since this commit")
+	subscribeCommit.Flags().StringVar(&cursor, "cursor", "", "resume a subscription after the commit with this cursor")
 	subscribeCommit.MarkFlagCustom("from", "__pachctl_get_commit $(__parse_repo ${nouns[0]})")
 	subscribeCommit.Flags().BoolVar(&newCommits, "new", false, "subscribe to only new commits created from now on")
+	subscribeCommit.Flags().StringVar(&subscribeOrigin, "origin", "", "subscribe to only commits with this origin: user, auto, fsck or alias")
+	subscribeCommit.Flags().StringVar(&branchGlob, "branch-glob", "", "subscribe to commits on every branch whose name matches this glob, in place of a single branch")
+	subscribeCommit.Flags().StringVar(&pathPrefix, "path", "", "subscribe to only finished commits that change files at or under this path")
 	subscribeCommit.Flags().AddFlagSet(rawFlags)
 	subscribeCommit.Flags().AddFlagSet(fullTimestampsFlags)
 	shell.RegisterCompletionFunc(subscribeCommit, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(subscribeCommit, "subscribe commit"))
 
+	var watchState string
+	var watchNew, watchJSON bool
+	watchCommits := &cobra.Command{
+		Use:   "{{alias}} <repo>[@<branch>]...",
+		Short: "Print commits in one or more repos as they happen, until interrupted.",
+		Long: "Print a line for each commit in the given repos and branches as it's started or finished, until interrupted. " +
+			"If a branch isn't given, commits on every branch of the repo are printed. By default, existing commits are printed first. " +
+			"The subscriptions reconnect by themselves when the connection to pachd is lost, without repeating or missing commits.",
+		Example: `
+# print commits as they're finished on branch "master" of repos "foo" and "bar"
+$ {{alias}} foo@master bar@master
+
+# print new commits on any branch of repo "foo" as they're started, one JSON object per line
+$ {{alias}} foo --new --state started --json`,
+		Run: cmdutil.RunMinimumArgs(1, func(args []string) error {
+			branches, err := cmdutil.ParseBranches(args)
+			if err != nil {
+				return err
+			}
+			var state pfs.CommitState
+			switch watchState {
+			case "started":
+				state = pfs.CommitState_STARTED
+			case "finished":
+				state = pfs.CommitState_FINISHED
+			default:
+				return errors.Errorf("--state must be started or finished, not %q", watchState)
+			}
+			if watchNew {
+				for _, branch := range branches {
+					if branch.Name == "" {
+						return errors.Errorf("--new requires a branch for repo %s", branch.Repo.Name)
+					}
+				}
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			var mu sync.Mutex
+			printCommit := func(ci *pfs.CommitInfo) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if watchJSON {
+					if err := (&jsonpb.Marshaler{}).Marshal(os.Stdout, ci); err != nil {
+						return err
+					}
+					fmt.Println()
+					return nil
+				}
+				pretty.PrintCommitEvent(os.Stdout, ci)
+				return nil
+			}
+			var eg errgroup.Group
+			for _, branch := range branches {
+				branch := branch
+				var from string
+				if watchNew {
+					from = branch.Name
+				}
+				eg.Go(func() error {
+					return c.ResubscribeCommit(branch.Repo, branch.Name, from, state, printCommit)
+				})
+			}
+			return eg.Wait()
+		}),
+	}
+	watchCommits.Flags().StringVar(&watchState, "state", "finished", "Print commits when they're \"started\" or \"finished\".")
+	watchCommits.Flags().BoolVar(&watchNew, "new", false, "Only print commits created from now on.")
+	watchCommits.Flags().BoolVar(&watchJSON, "json", false, "Print each commit as a JSON object on a single line.")
+	shell.RegisterCompletionFunc(watchCommits, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(watchCommits, "watch commits"))
+
 	squashCommitSet := &cobra.Command{
 		Use:   "{{alias}} <commitset>",
 		Short: "Squash the commits of a commitset.",
@@ -648,21 +1199,65 @@ $ {{alias}} test@master --new`,
 	shell.RegisterCompletionFunc(squashCommitSet, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(squashCommitSet, "squash commitset"))
 
-	branchDocs := &cobra.Command{
-		Short: "Docs for branches.",
-		Long: `A branch in Pachyderm is an alias for a Commit ID.
-
-The branch reference will "float" to always refer to the latest commit on the
-branch, known as the HEAD commit. Not all commits must be on a branch and
-multiple branches can refer to the same commit.
+	var squashFrom, squashTo string
+	var squashDryRun, squashYes bool
+	squashCommits := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch>",
+		Short: "Squash a range of commits on a branch.",
+		Long: "Squash the commits on a branch from --from up to, but not including, --to, so that their data remains only in --to. " +
+			"Each commit is squashed with the rest of its commitset, so commits in other repos that belong to the same commitsets are squashed too. " +
+			"All of the commitsets are squashed in one transaction.",
+		Example: `
+# squash the commits on branch "master" of repo "foo" from X up to the head
+$ {{alias}} foo@master --from X
 
-Any pachctl command that can take a Commit ID, can take a branch name instead.`,
+# show what squashing the commits from X up to Y would do
+$ {{alias}} foo@master --from X --to Y --dry-run`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			if squashFrom == "" {
+				return errors.Errorf("the first commit to squash must be set with --from")
+			}
+			if squashDryRun && squashYes {
+				return errors.Errorf("cannot set both --dry-run and --yes")
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return squashCommitRange(c, branch, squashFrom, squashTo, squashDryRun, squashYes)
+		}),
+	}
+	squashCommits.Flags().StringVar(&squashFrom, "from", "", "The oldest commit to squash.")
+	squashCommits.Flags().StringVar(&squashTo, "to", "", "The commit to squash the range into, the head of the branch if unset.")
+	squashCommits.Flags().BoolVar(&squashDryRun, "dry-run", false, "Only show what would be squashed.")
+	squashCommits.Flags().BoolVarP(&squashYes, "yes", "y", false, "Squash without asking for confirmation.")
+	shell.RegisterCompletionFunc(squashCommits, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(squashCommits, "squash commits"))
+
+	branchDocs := &cobra.Command{
+		Short: "Docs for branches.",
+		Long: `A branch in Pachyderm is an alias for a Commit ID.
+
+The branch reference will "float" to always refer to the latest commit on the
+branch, known as the HEAD commit. Not all commits must be on a branch and
+multiple branches can refer to the same commit.
+
+Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 	}
 	commands = append(commands, cmdutil.CreateDocsAlias(branchDocs, "branch", " branch$"))
 
 	var branchProvenance cmdutil.RepeatedStringArg
 	var head string
 	trigger := &pfs.Trigger{}
+	var storageClass string
+	var eagerGC bool
+	var retainCommits int64
+	var retainFor time.Duration
 	createBranch := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>",
 		Short: "Create a new branch, or update an existing branch, on a repo.",
@@ -679,12 +1274,30 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 			if len(provenance) != 0 && trigger.Branch != "" {
 				return errors.Errorf("cannot use provenance and triggers on the same branch")
 			}
-			if (trigger.CronSpec != "" || trigger.Size_ != "" || trigger.Commits != 0) && trigger.Branch == "" {
+			if (trigger.CronSpec != "" || trigger.Size_ != "" || trigger.Commits != 0 || trigger.Files != 0 || trigger.PathPattern != "") && trigger.Branch == "" {
 				return errors.Errorf("trigger condition specified without a branch to trigger on, specify a branch with --trigger")
 			}
 			if proto.Equal(trigger, &pfs.Trigger{}) {
 				trigger = nil
 			}
+			var storagePolicy *pfs.BranchStoragePolicy
+			if storageClass != "" || eagerGC {
+				class, ok := pfs.StorageClass_value[strings.ToUpper(storageClass)]
+				if !ok && storageClass != "" {
+					return errors.Errorf("unrecognized storage class %q, expected \"standard\" or \"cold\"", storageClass)
+				}
+				storagePolicy = &pfs.BranchStoragePolicy{
+					StorageClass: pfs.StorageClass(class),
+					EagerGc:      eagerGC,
+				}
+			}
+			var retention *pfs.BranchRetention
+			if retainCommits != 0 || retainFor != 0 {
+				retention = &pfs.BranchRetention{MaxCommits: retainCommits}
+				if retainFor > 0 {
+					retention.MaxAge = types.DurationProto(retainFor)
+				}
+			}
 			var headCommit *pfs.Commit
 			if head != "" {
 				if strings.Contains(head, "@") {
@@ -708,10 +1321,12 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 				_, err := c.PfsAPIClient.CreateBranch(
 					c.Ctx(),
 					&pfs.CreateBranchRequest{
-						Head:       headCommit,
-						Branch:     branch,
-						Provenance: provenance,
-						Trigger:    trigger,
+						Head:          headCommit,
+						Branch:        branch,
+						Provenance:    provenance,
+						Trigger:       trigger,
+						StoragePolicy: storagePolicy,
+						Retention:     retention,
 					})
 				return grpcutil.ScrubGRPC(err)
 			})
@@ -725,7 +1340,13 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 	createBranch.Flags().StringVar(&trigger.CronSpec, "trigger-cron", "", "The cron spec to use in triggering.")
 	createBranch.Flags().StringVar(&trigger.Size_, "trigger-size", "", "The data size to use in triggering.")
 	createBranch.Flags().Int64Var(&trigger.Commits, "trigger-commits", 0, "The number of commits to use in triggering.")
+	createBranch.Flags().Int64Var(&trigger.Files, "trigger-files", 0, "The number of changed files to use in triggering.")
+	createBranch.Flags().StringVar(&trigger.PathPattern, "trigger-path", "", "A glob pattern that a changed file's path must match to trigger, such as \"/**/_SUCCESS\".")
 	createBranch.Flags().BoolVar(&trigger.All, "trigger-all", false, "Only trigger when all conditions are met, rather than when any are met.")
+	createBranch.Flags().StringVar(&storageClass, "storage-class", "", "Where to store the data of the branch's commits, \"standard\" or \"cold\" (moved to cold storage as soon as each commit is finished). Setting it (or --eager-gc) replaces the branch's storage policy.")
+	createBranch.Flags().BoolVar(&eagerGC, "eager-gc", false, "Garbage collect the data of the branch's commits as soon as they're squashed.")
+	createBranch.Flags().Int64Var(&retainCommits, "retain-commits", 0, "The number of commits to keep on the branch, including its head, older commits are squashed in the background.")
+	createBranch.Flags().DurationVar(&retainFor, "retain-for", 0, "How long to keep the branch's commits after they're finished, older commits (other than the head) are squashed in the background.")
 	commands = append(commands, cmdutil.CreateAlias(createBranch, "create branch"))
 
 	inspectBranch := &cobra.Command{
@@ -821,6 +1442,150 @@ Any pachctl command that can take a Commit ID, can take a branch name instead.`,
 	shell.RegisterCompletionFunc(deleteBranch, shell.BranchCompletion)
 	commands = append(commands, cmdutil.CreateAlias(deleteBranch, "delete branch"))
 
+	var expectedHead string
+	var promoteNewCommitSet bool
+	promoteBranch := &cobra.Command{
+		Use:   "{{alias}} <repo>@<from-branch> <to-branch>",
+		Short: "Move a branch's head to another branch's head.",
+		Long:  "Move a branch's head to the head of another branch in the same repo, such as from a staging branch to master. The head being promoted must be finished.",
+		Example: `
+# move the head of branch "master" of repo "foo" to the head of branch "staging"
+$ {{alias}} foo@staging master
+
+# only move it if master's head hasn't changed since it was inspected
+$ {{alias}} foo@staging master --expected-head 2b0f3a4c5d6e4f7a8b9c0d1e2f3a4b5c`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			from, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			request := &pfs.PromoteBranchRequest{
+				From:         from,
+				To:           client.NewBranch(from.Repo.Name, args[1]),
+				NewCommitSet: promoteNewCommitSet,
+			}
+			if expectedHead != "" {
+				request.ExpectedHead = request.To.NewCommit(expectedHead)
+			}
+			return txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
+				_, err := c.PfsAPIClient.PromoteBranch(c.Ctx(), request)
+				return err
+			})
+		}),
+	}
+	promoteBranch.Flags().StringVar(&expectedHead, "expected-head", "", "Only move the branch if this is the ID of its current head.")
+	promoteBranch.Flags().BoolVar(&promoteNewCommitSet, "new-commit-set", false, "Put the moved head in a new commit set, rather than the one of the head it's moved to.")
+	shell.RegisterCompletionFunc(promoteBranch, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(promoteBranch, "promote branch"))
+
+	var mergeDescription string
+	mergeBranch := &cobra.Command{
+		Use:   "{{alias}} <repo>@<from-branch> <into-branch>",
+		Short: "Merge the changes on one branch into another.",
+		Long:  "Merge the changes on one branch into another, in a commit with the heads of both branches as its parents. Files changed on both branches since they last had a commit in common are conflicts, and nothing is merged if there are any.",
+		Example: `
+# merge the changes on branch "feature" of repo "foo" into branch "master"
+$ {{alias}} foo@feature master`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			from, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			resp, err := c.MergeBranch(from.Repo.Name, from.Name, args[1], mergeDescription)
+			if err != nil {
+				return err
+			}
+			if len(resp.Conflicts) > 0 {
+				for _, conflict := range resp.Conflicts {
+					fmt.Fprintf(os.Stderr, "conflict: %s\n", conflict.Path)
+				}
+				return errors.Errorf("%d files were changed on both branches, nothing was merged", len(resp.Conflicts))
+			}
+			fmt.Println(resp.Commit.ID)
+			return nil
+		}),
+	}
+	mergeBranch.Flags().StringVarP(&mergeDescription, "message", "m", "", "A description of the merge commit.")
+	shell.RegisterCompletionFunc(mergeBranch, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(mergeBranch, "merge branch"))
+
+	inspectTrigger := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch>",
+		Short: "Return the state of a branch's trigger.",
+		Long:  "Return the state of a branch's trigger: whether it's pending, and how far the branch that it's on has come since it last fired towards each of its conditions.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			info, err := c.InspectTrigger(branch.Repo.Name, branch.Name)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, info)
+			}
+			pretty.PrintDetailedTriggerInfo(os.Stdout, info)
+			return nil
+		}),
+	}
+	inspectTrigger.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(inspectTrigger, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(inspectTrigger, "inspect trigger"))
+
+	var forceTrigger bool
+	runTrigger := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch>",
+		Short: "Evaluate a branch's trigger now.",
+		Long:  "Evaluate a branch's trigger now, rather than when the next commit is finished, and move the branch's head if its conditions are met. A cron spec is evaluated against the current time.",
+		Example: `
+# move branch "master" of repo "foo" if its trigger's conditions are met
+$ {{alias}} foo@master
+
+# move branch "master" of repo "foo" whether its trigger's conditions are met or not
+$ {{alias}} foo@master --force`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			branch, err := cmdutil.ParseBranch(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			resp, err := c.RunTrigger(branch.Repo.Name, branch.Name, forceTrigger)
+			if err != nil {
+				return err
+			}
+			if resp.Fired {
+				fmt.Printf("moved %s to %s\n", branch, resp.Trigger.Status.Head.ID)
+			} else {
+				fmt.Printf("%s wasn't moved\n", branch)
+			}
+			return nil
+		}),
+	}
+	runTrigger.Flags().BoolVarP(&forceTrigger, "force", "f", false, "Move the branch even if the trigger's conditions aren't met.")
+	shell.RegisterCompletionFunc(runTrigger, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(runTrigger, "run trigger"))
+
 	fileDocs := &cobra.Command{
 		Short: "Docs for files.",
 		Long: `Files are the lowest level data objects in Pachyderm.
@@ -835,9 +1600,14 @@ from commits with 'get file'.`,
 	var inputFile string
 	var recursive bool
 	var parallelism int
+	var parallelParts int
 	var appendFile bool
 	var compress bool
 	var enableProgress bool
+	var skipUnchanged bool
+	var split string
+	var targetFileDatums, targetFileBytes, headerRecords int64
+	var fileMetadata map[string]string
 	putFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>[:<path/to/file>]",
 		Short: "Put a file into the filesystem.",
@@ -846,6 +1616,10 @@ from commits with 'get file'.`,
 # Put data from stdin as repo/branch/path:
 $ echo "data" | {{alias}} repo@branch:/path
 
+# Put the contents of a directory, skipping files whose content already
+# matches the file in PFS:
+$ {{alias}} -r --skip-unchanged repo@branch:/path -f dir
+
 # Put data from stdin as repo/branch/path and start / finish a new commit on the branch.
 $ echo "data" | {{alias}} -c repo@branch:/path
 
@@ -877,6 +1651,13 @@ $ {{alias}} repo@branch -r -f s3://my_bucket
 # Files and URLs should be newline delimited.
 $ {{alias}} repo@branch -i file
 
+# Put each line of file as a separate file in the directory repo/branch/path:
+$ {{alias}} repo@branch:/path -f file --split line
+
+# Put the rows of a CSV file into files of 100 rows each, with the column
+# names in the first row at the start of every file:
+$ {{alias}} repo@branch:/path -f file.csv --split csv --target-file-datums 100 --header-records 1
+
 # Put several files or URLs that are listed at URL.
 # NOTE this URL can reference local files, so it could cause you to put sensitive
 # files into your Pachyderm cluster.
@@ -898,6 +1679,28 @@ $ {{alias}} repo@branch -i http://host/path`,
 				return err
 			}
 			defer c.Close()
+			if appendFile && skipUnchanged {
+				return errors.Errorf("cannot set both --append and --skip-unchanged")
+			}
+			var splitOpt client.PutFileOption
+			if split != "" {
+				delimiter, ok := pfs.Delimiter_value[strings.ToUpper(split)]
+				if !ok || pfs.Delimiter(delimiter) == pfs.Delimiter_NONE {
+					return errors.Errorf("unrecognized delimiter %q, must be one of line, json, sql or csv", split)
+				}
+				if skipUnchanged {
+					return errors.Errorf("cannot set both --split and --skip-unchanged")
+				}
+				splitOpt = client.WithSplitPutFile(pfs.Delimiter(delimiter), targetFileDatums, targetFileBytes, headerRecords)
+			}
+			var pf *putFiler
+			// Registered before progress.Wait, so that the summary is printed
+			// after the last progress bar is drawn.
+			defer func() {
+				if pf != nil {
+					fmt.Fprintf(os.Stderr, "%d uploaded, %d skipped, %d failed\n", pf.uploaded, pf.skipped, pf.failed)
+				}
+			}()
 			defer progress.Wait()
 
 			// TODO: Rethink put file parallelism for 2.0.
@@ -944,33 +1747,54 @@ $ {{alias}} repo@branch -i http://host/path`,
 				sources = filePaths
 			}
 
-			return c.WithModifyFileClient(file.Commit, func(mf client.ModifyFile) error {
-				for _, source := range sources {
-					source := source
-					if file.Path == "" {
-						// The user has not specified a path so we use source as path.
-						if source == "-" {
-							return errors.Errorf("must specify filename when reading data from stdin")
-						}
-						if err := putFileHelper(mf, joinPaths("", source), source, recursive, appendFile); err != nil {
-							return err
-						}
-					} else if len(sources) == 1 {
-						// We have a single source and the user has specified a path,
-						// we use the path and ignore source (in terms of naming the file).
-						if err := putFileHelper(mf, file.Path, source, recursive, appendFile); err != nil {
-							return err
-						}
-					} else {
-						// We have multiple sources and the user has specified a path,
-						// we use that path as a prefix for the filepaths.
-						if err := putFileHelper(mf, joinPaths(file.Path, source), source, recursive, appendFile); err != nil {
-							return err
+			pf = &putFiler{
+				client:        c,
+				commit:        file.Commit,
+				appendFile:    appendFile,
+				skipUnchanged: skipUnchanged,
+				split:         splitOpt,
+				metadata:      fileMetadata,
+			}
+			if parallelParts > 1 {
+				pf.parallel = client.WithParallelPutFile(parallelParts, 0)
+			}
+			if err := txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
+				pf.client = c
+				return c.WithModifyFileClient(file.Commit, func(mf client.ModifyFile) error {
+					pf.mf = mf
+					for _, source := range sources {
+						source := source
+						if file.Path == "" {
+							// The user has not specified a path so we use source as path.
+							if source == "-" {
+								return errors.Errorf("must specify filename when reading data from stdin")
+							}
+							if err := pf.put(joinPaths("", source), source, recursive); err != nil {
+								return err
+							}
+						} else if len(sources) == 1 {
+							// We have a single source and the user has specified a path,
+							// we use the path and ignore source (in terms of naming the file).
+							if err := pf.put(file.Path, source, recursive); err != nil {
+								return err
+							}
+						} else {
+							// We have multiple sources and the user has specified a path,
+							// we use that path as a prefix for the filepaths.
+							if err := pf.put(joinPaths(file.Path, source), source, recursive); err != nil {
+								return err
+							}
 						}
 					}
-				}
-				return nil
-			})
+					return nil
+				})
+			}); err != nil {
+				return err
+			}
+			if pf.failed > 0 {
+				return errors.Errorf("%d file(s) failed to upload", pf.failed)
+			}
+			return nil
 		}),
 	}
 	putFile.Flags().StringSliceVarP(&filePaths, "file", "f", []string{"-"}, "The file to be put, it can be a local file or a URL.")
@@ -978,8 +1802,15 @@ $ {{alias}} repo@branch -i http://host/path`,
 	putFile.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively put the files in a directory.")
 	putFile.Flags().BoolVarP(&compress, "compress", "", false, "Compress data during upload. This parameter might help you upload your uncompressed data, such as CSV files, to Pachyderm faster. Use 'compress' with caution, because if your data is already compressed, this parameter might slow down the upload speed instead of increasing.")
 	putFile.Flags().IntVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of files that can be uploaded in parallel.")
+	putFile.Flags().IntVar(&parallelParts, "parallel-parts", 0, "Upload files larger than 64MB in 64MB parts, this many at a time, rather than in a single stream. A part that fails to upload is retried on its own.")
 	putFile.Flags().BoolVarP(&appendFile, "append", "a", false, "Append to the existing content of the file, either from previous commits or previous calls to 'put file' within this commit.")
 	putFile.Flags().BoolVar(&enableProgress, "progress", isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()), "Print progress bars.")
+	putFile.Flags().BoolVar(&skipUnchanged, "skip-unchanged", false, "Skip local files whose content matches the file already at the destination path.")
+	putFile.Flags().StringVar(&split, "split", "", "Split the content into records (one of line, json, sql or csv), and put them into numbered files in the directory at the path.")
+	putFile.Flags().Int64Var(&targetFileDatums, "target-file-datums", 0, "With --split, the number of records to put in each file.")
+	putFile.Flags().Int64Var(&targetFileBytes, "target-file-bytes", 0, "With --split, the size to fill each file to before putting records in the next one.")
+	putFile.Flags().Int64Var(&headerRecords, "header-records", 0, "With --split, the number of records at the start of the content to put at the start of every file, such as the column names of a CSV file.")
+	putFile.Flags().StringToStringVar(&fileMetadata, "metadata", nil, "A key=value pair of metadata to attach to each file put (can be repeated).")
 	shell.RegisterCompletionFunc(putFile,
 		func(flag, text string, maxCompletions int64) ([]prompt.Suggest, shell.CacheFunc) {
 			if flag == "-f" || flag == "--file" || flag == "-i" || flag == "input-file" {
@@ -996,7 +1827,10 @@ $ {{alias}} repo@branch -i http://host/path`,
 	copyFile := &cobra.Command{
 		Use:   "{{alias}} <src-repo>@<src-branch-or-commit>:<src-path> <dst-repo>@<dst-branch-or-commit>:<dst-path>",
 		Short: "Copy files between pfs paths.",
-		Long:  "Copy files between pfs paths.",
+		Long:  "Copy files between pfs paths. If the source path is a glob pattern, everything that matches it is copied into the destination directory, keeping its path relative to the directory the pattern starts in.",
+		Example: `
+# copy all of the csv files under "data" in repo "foo" into "out", so "data/a/b.csv" is copied to "out/a/b.csv"
+$ {{alias}} 'foo@master:/data/**/*.csv' foo@master:/out`,
 		Run: cmdutil.RunFixedArgs(2, func(args []string) (retErr error) {
 			srcFile, err := cmdutil.ParseFile(args[0])
 			if err != nil {
@@ -1016,18 +1850,45 @@ $ {{alias}} repo@branch -i http://host/path`,
 			if appendFile {
 				opts = append(opts, client.WithAppendCopyFile())
 			}
-			return c.CopyFile(
-				destFile.Commit, destFile.Path,
-				srcFile.Commit, srcFile.Path,
-				opts...,
-			)
+			return txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
+				return c.CopyFile(
+					destFile.Commit, destFile.Path,
+					srcFile.Commit, srcFile.Path,
+					opts...,
+				)
+			})
 		}),
 	}
 	copyFile.Flags().BoolVarP(&appendFile, "append", "a", false, "Append to the existing content of the file, either from previous commits or previous calls to 'put file' within this commit.")
 	shell.RegisterCompletionFunc(copyFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(copyFile, "copy file"))
 
+	moveFile := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>:<src-path> <dst-path>",
+		Short: "Move a file or directory within a commit.",
+		Long:  "Move a file or directory within a commit. Only the paths of the files change, their data isn't copied.",
+		Example: `
+# move the directory "raw" in repo "foo" on branch "master" to "archive/raw"
+$ {{alias}} foo@master:/raw /archive/raw`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			file, err := cmdutil.ParseFile(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return c.MoveFile(file.Commit, file.Path, args[1])
+		}),
+	}
+	shell.RegisterCompletionFunc(moveFile, shell.FileCompletion)
+	commands = append(commands, cmdutil.CreateAlias(moveFile, "move file"))
+
 	var outputPath string
+	var ignoreCase bool
+	var offsetBytes, lengthBytes int64
 	getFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>:<path/in/pfs>",
 		Short: "Return the contents of a file.",
@@ -1046,7 +1907,14 @@ $ {{alias}} foo@master^2:XXX
 
 # get file "test[].txt" on branch "master" in repo "foo"
 # the path is interpreted as a glob pattern: quote and protect regex characters
-$ {{alias}} 'foo@master:/test\[\].txt'`,
+$ {{alias}} 'foo@master:/test\[\].txt'
+
+# get 1KB of file "XXX" on branch "master" in repo "foo", starting 1MB in
+$ {{alias}} foo@master:XXX --offset 1048576 --length 1024
+
+# download file "XXX" on branch "master" in repo "foo" to a local file, 20
+# parts at a time
+$ {{alias}} foo@master:XXX -o XXX --parallelism 20`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			if !enableProgress {
 				progress.Disable()
@@ -1055,39 +1923,102 @@ $ {{alias}} 'foo@master:/test\[\].txt'`,
 			if err != nil {
 				return err
 			}
-			c, err := newClient("user")
+			c, err := newClient("user", client.WithMaxConcurrentStreams(parallelism))
 			if err != nil {
 				return err
 			}
 			defer c.Close()
 			defer progress.Wait()
+			var opts []client.GetFileOption
+			var inspectOpts []client.InspectFileOption
+			if ignoreCase {
+				opts = append(opts, client.WithCaseInsensitiveGetFile())
+				inspectOpts = append(inspectOpts, client.WithCaseInsensitiveInspectFile())
+			}
+			if offsetBytes != 0 || lengthBytes != 0 {
+				opts = append(opts, client.WithRangeGetFile(offsetBytes, lengthBytes))
+			}
 			var w io.Writer
 			// If an output path is given, print the output to stdout
 			if outputPath == "" {
 				w = os.Stdout
 			} else {
 				if url, err := url.Parse(outputPath); err == nil && url.Scheme != "" {
-					return c.GetFileURL(file.Commit, file.Path, url.String())
+					return c.GetFileURL(file.Commit, file.Path, url.String(), opts...)
 				}
-				fi, err := c.InspectFile(file.Commit, file.Path)
+				fi, err := c.InspectFile(file.Commit, file.Path, inspectOpts...)
 				if err != nil {
 					return err
 				}
-				f, err := progress.Create(outputPath, int64(fi.SizeBytes))
+				size := int64(fi.SizeBytes) - offsetBytes
+				if size < 0 {
+					size = 0
+				}
+				if lengthBytes > 0 && lengthBytes < size {
+					size = lengthBytes
+				}
+				f, err := progress.Create(outputPath, size)
 				if err != nil {
 					return err
 				}
 				defer f.Close()
-				w = f
+				// A local file can be written in parts, as they're
+				// downloaded.
+				return c.GetFileParallel(file.Commit, file.Path, f, parallelism, 0, opts...)
 			}
-			return c.GetFile(file.Commit, file.Path, w)
+			return c.GetFile(file.Commit, file.Path, w, opts...)
 		}),
 	}
 	getFile.Flags().StringVarP(&outputPath, "output", "o", "", "The path where data will be downloaded.")
+	getFile.Flags().BoolVar(&ignoreCase, "ignore-case", false, "Resolve the path ignoring case, failing if it matches more than one file.")
+	getFile.Flags().Int64Var(&offsetBytes, "offset", 0, "The number of bytes at the start of the file to skip.")
+	getFile.Flags().Int64Var(&lengthBytes, "length", 0, "The maximum number of bytes of the file to get, all of them (after --offset) if 0.")
+	getFile.Flags().IntVarP(&parallelism, "parallelism", "p", DefaultParallelism, "The maximum number of parts of the file that can be downloaded in parallel, when downloading to a local file with --output.")
 	getFile.Flags().BoolVar(&enableProgress, "progress", isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()), "{true|false} Whether or not to print the progress bars.")
 	shell.RegisterCompletionFunc(getFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(getFile, "get file"))
 
+	var exportOutput string
+	exportRepo := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>[:<glob>]",
+		Short: "Export the files in a commit to a local tar archive.",
+		Long: "Export the files in a commit, or those that match a glob pattern, to a local tar archive that can be read without access to Pachyderm. " +
+			"The archive is gzipped if its name ends in .tar.gz or .tgz, and is a zip archive if it ends in .zip. It's written next to the output as <output>.part, " +
+			"and moved into place once every file has been checked against the sizes reported by pachd. Running the same export of a tar archive again after " +
+			"an interruption resumes it.",
+		Example: `
+# export the head of branch "master" in repo "foo"
+$ {{alias}} foo@master -o foo.tar
+
+# export the csv files under /data, gzipped
+$ {{alias}} 'foo@master:/data/*.csv' -o data.tar.gz
+
+# export the head of branch "master" in repo "foo" as a zip archive
+$ {{alias}} foo@master -o foo.zip`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			file, err := cmdutil.ParseFile(args[0])
+			if err != nil {
+				return err
+			}
+			if exportOutput == "" {
+				return errors.Errorf("an output archive must be set with --output")
+			}
+			glob := file.Path
+			if glob == "" {
+				glob = "/"
+			}
+			c, err := newClient("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			return exportCommit(c, file.Commit, glob, exportOutput)
+		}),
+	}
+	exportRepo.Flags().StringVarP(&exportOutput, "output", "o", "", "The archive to write, ending in .tar, .tar.gz, .tgz or .zip.")
+	shell.RegisterCompletionFunc(exportRepo, shell.FileCompletion)
+	commands = append(commands, cmdutil.CreateAlias(exportRepo, "export repo"))
+
 	inspectFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>:<path/in/pfs>",
 		Short: "Return info about a file.",
@@ -1102,7 +2033,11 @@ $ {{alias}} 'foo@master:/test\[\].txt'`,
 				return err
 			}
 			defer c.Close()
-			fileInfo, err := c.InspectFile(file.Commit, file.Path)
+			var opts []client.InspectFileOption
+			if ignoreCase {
+				opts = append(opts, client.WithCaseInsensitiveInspectFile())
+			}
+			fileInfo, err := c.InspectFile(file.Commit, file.Path, opts...)
 			if err != nil {
 				return err
 			}
@@ -1116,10 +2051,16 @@ $ {{alias}} 'foo@master:/test\[\].txt'`,
 		}),
 	}
 	inspectFile.Flags().AddFlagSet(rawFlags)
+	inspectFile.Flags().BoolVar(&ignoreCase, "ignore-case", false, "Resolve the path ignoring case, failing if it matches more than one file.")
 	shell.RegisterCompletionFunc(inspectFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(inspectFile, "inspect file"))
 
 	var history string
+	var excludeHidden bool
+	var hiddenPrefixes []string
+	var fileTag string
+	var minFileSize, maxFileSize, listFileType, fileSortBy string
+	var changedOnly, fileReverse bool
 	listFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>[:<path/in/pfs>]",
 		Short: "Return the files in a directory.",
@@ -1145,6 +2086,15 @@ $ {{alias}} foo@master --history n
 # list all versions of top-level files on branch "master" in repo "foo"
 $ {{alias}} foo@master --history all
 
+# list the files under "dir" written with tag "datum-1"
+$ {{alias}} foo@master:dir --tag datum-1
+
+# list the files over 1GB under "dir", largest first
+$ {{alias}} foo@master:dir --type file --min-size 1GB --sort size
+
+# list the files and directories changed in the head commit of "master"
+$ {{alias}} foo@master --changed
+
 # list file under directory "dir[1]" on branch "master" in repo "foo"
 # the path is interpreted as a glob pattern: quote and protect regex characters
 $ {{alias}} 'foo@master:dir\[1\]'`,
@@ -1162,8 +2112,63 @@ $ {{alias}} 'foo@master:dir\[1\]'`,
 				return err
 			}
 			defer c.Close()
-			if raw {
+			var opts []client.ListFileOption
+			if excludeHidden {
+				opts = append(opts, client.WithExcludeHiddenListFile(hiddenPrefixes...))
+			}
+			if fileTag != "" {
+				opts = append(opts, client.WithTagListFile(fileTag))
+			}
+			var minSize, maxSize int64
+			if minFileSize != "" {
+				if minSize, err = units.FromHumanSize(minFileSize); err != nil {
+					return errors.Wrapf(err, "invalid --min-size")
+				}
+			}
+			if maxFileSize != "" {
+				if maxSize, err = units.FromHumanSize(maxFileSize); err != nil {
+					return errors.Wrapf(err, "invalid --max-size")
+				}
+			}
+			if minSize > 0 || maxSize > 0 {
+				opts = append(opts, client.WithSizeListFile(minSize, maxSize))
+			}
+			if listFileType != "" {
+				fileType, ok := pfs.FileType_value[strings.ToUpper(listFileType)]
+				if !ok || fileType == int32(pfs.FileType_RESERVED) {
+					return errors.Errorf("unrecognized file type %q, expected file or dir", listFileType)
+				}
+				opts = append(opts, client.WithTypeListFile(pfs.FileType(fileType)))
+			}
+			if changedOnly {
+				opts = append(opts, client.WithChangedListFile())
+			}
+			sortBy, ok := map[string]pfs.FileSortBy{"path": pfs.FileSortBy_BY_PATH, "size": pfs.FileSortBy_BY_FILE_SIZE}[fileSortBy]
+			if !ok {
+				return errors.Errorf("unrecognized sort order %q, expected path or size", fileSortBy)
+			}
+			opts = append(opts, client.WithSortListFile(sortBy, fileReverse))
+			// listFile calls cb with each file, or with each of its versions
+			// if history was requested.
+			listFile := func(cb func(*pfs.FileInfo) error) error {
 				return c.ListFile(file.Commit, file.Path, func(fi *pfs.FileInfo) error {
+					if history == 0 {
+						return cb(fi)
+					}
+					versions, err := c.ListFileHistory(file.Commit, fi.File.Path, history)
+					if err != nil {
+						return err
+					}
+					for _, version := range versions {
+						if err := cb(version); err != nil {
+							return err
+						}
+					}
+					return nil
+				}, opts...)
+			}
+			if raw {
+				return listFile(func(fi *pfs.FileInfo) error {
 					return marshaller.Marshal(os.Stdout, fi)
 				})
 			}
@@ -1172,7 +2177,7 @@ $ {{alias}} 'foo@master:dir\[1\]'`,
 				header = pretty.FileHeaderWithCommit
 			}
 			writer := tabwriter.NewWriter(os.Stdout, header)
-			if err := c.ListFile(file.Commit, file.Path, func(fi *pfs.FileInfo) error {
+			if err := listFile(func(fi *pfs.FileInfo) error {
 				pretty.PrintFileInfo(writer, fi, fullTimestamps, history != 0)
 				return nil
 			}); err != nil {
@@ -1184,6 +2189,15 @@ $ {{alias}} 'foo@master:dir\[1\]'`,
 	listFile.Flags().AddFlagSet(rawFlags)
 	listFile.Flags().AddFlagSet(fullTimestampsFlags)
 	listFile.Flags().StringVar(&history, "history", "none", "Return revision history for files.")
+	listFile.Flags().BoolVar(&excludeHidden, "exclude-hidden", false, "Leave out files whose names start with \".\" or with a --hidden-prefix.")
+	listFile.Flags().StringSliceVar(&hiddenPrefixes, "hidden-prefix", nil, "A name prefix of hidden files in addition to \".\", such as \"_SUCCESS\" (can be repeated).")
+	listFile.Flags().StringVar(&fileTag, "tag", "", "Only return the files written with this tag.")
+	listFile.Flags().StringVar(&minFileSize, "min-size", "", "Only return files and directories at least this large, such as 100MB.")
+	listFile.Flags().StringVar(&maxFileSize, "max-size", "", "Only return files and directories at most this large, such as 1GB.")
+	listFile.Flags().StringVar(&listFileType, "type", "", "Only return files of this type, file or dir.")
+	listFile.Flags().BoolVar(&changedOnly, "changed", false, "Only return the files changed in the commit, and the directories that contain them.")
+	listFile.Flags().StringVar(&fileSortBy, "sort", "path", "sort files by path or size (largest first)")
+	listFile.Flags().BoolVar(&fileReverse, "reverse", false, "reverse the sort order")
 	shell.RegisterCompletionFunc(listFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(listFile, "list file"))
 
@@ -1209,7 +2223,14 @@ $ {{alias}} "foo@master:data/*"`,
 				return err
 			}
 			defer c.Close()
-			fileInfos, err := c.GlobFileAll(file.Commit, file.Path)
+			var opts []client.GlobFileOption
+			if excludeHidden {
+				opts = append(opts, client.WithExcludeHiddenGlobFile(hiddenPrefixes...))
+			}
+			if fileTag != "" {
+				opts = append(opts, client.WithTagGlobFile(fileTag))
+			}
+			fileInfos, err := c.GlobFileAll(file.Commit, file.Path, opts...)
 			if err != nil {
 				return err
 			}
@@ -1230,62 +2251,245 @@ $ {{alias}} "foo@master:data/*"`,
 	}
 	globFile.Flags().AddFlagSet(rawFlags)
 	globFile.Flags().AddFlagSet(fullTimestampsFlags)
+	globFile.Flags().BoolVar(&excludeHidden, "exclude-hidden", false, "Leave out files whose names start with \".\" or with a --hidden-prefix, and files in hidden directories.")
+	globFile.Flags().StringSliceVar(&hiddenPrefixes, "hidden-prefix", nil, "A name prefix of hidden files in addition to \".\", such as \"_SUCCESS\" (can be repeated).")
+	globFile.Flags().StringVar(&fileTag, "tag", "", "Only return the files written with this tag.")
 	shell.RegisterCompletionFunc(globFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(globFile, "glob file"))
 
-	var shallow bool
-	var nameOnly bool
-	var diffCmdArg string
-	diffFile := &cobra.Command{
-		Use:   "{{alias}} <new-repo>@<new-branch-or-commit>:<new-path> [<old-repo>@<old-branch-or-commit>:<old-path>]",
-		Short: "Return a diff of two file trees in input repo. Diff of file trees in output repo coming soon.",
-		Long:  "Return a diff of two file trees in input repo. Diff of file trees in output repo coming soon.",
+	var findHash, findChunk string
+	findFile := &cobra.Command{
+		Use:   "{{alias}} [<repo>]",
+		Short: "Find the files with some content.",
+		Long: "Find the files, in every finished commit of a repo or of every repo you can read, whose content has a hash (as shown by inspect file) " +
+			"or whose data is in a chunk. A file is listed once for each commit that it's in.",
 		Example: `
-# Return the diff of the file "path" of the input repo "foo" between the head of the
-# "master" branch and its parent.
-$ {{alias}} foo@master:path
+# Find where a file's content came from.
+$ {{alias}} --hash 8a1c2f...
 
-# Return the diff between the master branches of input repos foo and bar at paths
-# path1 and path2, respectively.
-$ {{alias}} foo@master:path1 bar@master:path2`,
-		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
-			newFile, err := cmdutil.ParseFile(args[0])
+# Find the files in repo "foo" that reference a chunk.
+$ {{alias}} foo --chunk 3be0d9...`,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			if (findHash == "") == (findChunk == "") {
+				return errors.Errorf("exactly one of --hash and --chunk must be set")
+			}
+			hash, err := hex.DecodeString(findHash)
 			if err != nil {
-				return err
+				return errors.Wrapf(err, "invalid hash %q", findHash)
 			}
-			oldFile := &pfs.File{}
-			if len(args) == 2 {
-				oldFile, err = cmdutil.ParseFile(args[1])
-				if err != nil {
-					return err
-				}
+			chunkID, err := hex.DecodeString(findChunk)
+			if err != nil {
+				return errors.Wrapf(err, "invalid chunk ID %q", findChunk)
+			}
+			var repo *pfs.Repo
+			if len(args) > 0 {
+				repo = cmdutil.ParseRepo(args[0])
 			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer c.Close()
-
-			return pager.Page(noPager, os.Stdout, func(w io.Writer) (retErr error) {
-				var writer *tabwriter.Writer
-				if nameOnly {
-					writer = tabwriter.NewWriter(w, pretty.DiffFileHeader)
-					defer func() {
-						if err := writer.Flush(); err != nil && retErr == nil {
-							retErr = err
-						}
-					}()
-				}
-
-				newFiles, oldFiles, err := c.DiffFileAll(
-					newFile.Commit, newFile.Path,
-					oldFile.Commit, oldFile.Path,
-					shallow,
-				)
-				if err != nil {
-					return err
+			writer := tabwriter.NewWriter(os.Stdout, "REPO\t"+pretty.FileHeaderWithCommit)
+			cb := func(fi *pfs.FileInfo) error {
+				if raw {
+					return marshaller.Marshal(os.Stdout, fi)
 				}
-				diffCmd := diffCommand(diffCmdArg)
+				fmt.Fprintf(writer, "%s\t", fi.File.Commit.Branch.Repo)
+				pretty.PrintFileInfo(writer, fi, fullTimestamps, true)
+				return nil
+			}
+			if len(hash) > 0 {
+				err = c.FindFilesByHash(repo, hash, cb)
+			} else {
+				err = c.FindFilesByChunk(repo, chunkID, cb)
+			}
+			if err != nil {
+				return err
+			}
+			if raw {
+				return nil
+			}
+			return writer.Flush()
+		}),
+	}
+	findFile.Flags().StringVar(&findHash, "hash", "", "The hash of the files' content, in hex.")
+	findFile.Flags().StringVar(&findChunk, "chunk", "", "The ID of a chunk that the files' data is in, in hex.")
+	findFile.Flags().AddFlagSet(rawFlags)
+	findFile.Flags().AddFlagSet(fullTimestampsFlags)
+	shell.RegisterCompletionFunc(findFile, shell.RepoCompletion)
+	commands = append(commands, cmdutil.CreateAlias(findFile, "find file"))
+
+	var duDepth int64
+	du := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>[:<path/in/pfs>]",
+		Short: "Return the sizes of a directory and its subdirectories.",
+		Long: "Return the total size of the files under a directory, and under each of its subdirectories down to --depth, " +
+			"like du --max-depth. The number of entries counts the files and directories below each directory.",
+		Example: `
+# Return the size of each top-level directory in repo "foo" on branch "master"
+$ {{alias}} foo@master
+
+# Return the sizes of every directory under "data"
+$ {{alias}} foo@master:/data --depth -1`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			file, err := cmdutil.ParseFile(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := newClient("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			if raw {
+				return c.DirectorySizes(file.Commit, file.Path, duDepth, func(fi *pfs.FileInfo) error {
+					return marshaller.Marshal(os.Stdout, fi)
+				})
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.DirectorySizeHeader)
+			if err := c.DirectorySizes(file.Commit, file.Path, duDepth, func(fi *pfs.FileInfo) error {
+				pretty.PrintDirectorySize(writer, fi)
+				return nil
+			}); err != nil {
+				return err
+			}
+			return writer.Flush()
+		}),
+	}
+	du.Flags().Int64VarP(&duDepth, "depth", "d", 1, "How many levels of subdirectories to report, 0 for only the directory itself and -1 for every subdirectory.")
+	du.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(du, shell.FileCompletion)
+	commands = append(commands, cmdutil.CreateAlias(du, "du"))
+
+	tagDocs := &cobra.Command{
+		Short: "Docs for file tags.",
+		Long: `Files can be written with a tag, which keeps them apart from the files
+written to the same path with other tags. A file's content is the content
+written with each of its tags, in tag order.
+
+The tags under a path can be listed with 'list tag', and the files written
+with a tag can be deleted with 'delete file --tag'.`,
+	}
+	commands = append(commands, cmdutil.CreateDocsAlias(tagDocs, "tag", " tag$"))
+
+	listTag := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>[:<path/in/pfs>]",
+		Short: "Return the tags of the files under a path.",
+		Long:  "Return the tags of the files at or under a path, with the number and total size of the files written with each tag.",
+		Example: `
+# Return the tags of the files in repo "foo" on branch "master"
+$ {{alias}} foo@master
+
+# Return the tags of the files under "data"
+$ {{alias}} foo@master:/data`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			file, err := cmdutil.ParseFile(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := newClient("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			tagInfos, err := c.ListTags(file.Commit, file.Path)
+			if err != nil {
+				return err
+			}
+			if raw {
+				for _, ti := range tagInfos {
+					if err := marshaller.Marshal(os.Stdout, ti); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.TagHeader)
+			for _, ti := range tagInfos {
+				pretty.PrintTagInfo(writer, ti)
+			}
+			return writer.Flush()
+		}),
+	}
+	listTag.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(listTag, shell.FileCompletion)
+	commands = append(commands, cmdutil.CreateAlias(listTag, "list tag"))
+
+	var shallow bool
+	var nameOnly bool
+	var detectRenames bool
+	var diffCmdArg string
+	var contextLines int
+	diffFile := &cobra.Command{
+		Use:   "{{alias}} <new-repo>@<new-branch-or-commit>:<new-path> [<old-repo>@<old-branch-or-commit>:<old-path>]",
+		Short: "Return a diff of two file trees in input repo. Diff of file trees in output repo coming soon.",
+		Long:  "Return a diff of two file trees in input repo. Diff of file trees in output repo coming soon.",
+		Example: `
+# Return the diff of the file "path" of the input repo "foo" between the head of the
+# "master" branch and its parent.
+$ {{alias}} foo@master:path
+
+# Return the diff between the master branches of input repos foo and bar at paths
+# path1 and path2, respectively.
+$ {{alias}} foo@master:path1 bar@master:path2
+
+# Return the files that changed under "path", including those that were renamed.
+$ {{alias}} foo@master:path --detect-renames
+
+# Return the diff of the file "path" using git to diff the files.
+$ {{alias}} foo@master:path --diff-command "git --no-pager diff --no-index"`,
+		Run: cmdutil.RunBoundedArgs(1, 2, func(args []string) error {
+			newFile, err := cmdutil.ParseFile(args[0])
+			if err != nil {
+				return err
+			}
+			oldFile := &pfs.File{}
+			if len(args) == 2 {
+				oldFile, err = cmdutil.ParseFile(args[1])
+				if err != nil {
+					return err
+				}
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			if detectRenames {
+				entries, err := c.DiffFileEntries(newFile.Commit, newFile.Path, oldFile.Commit, oldFile.Path)
+				if err != nil {
+					return err
+				}
+				return pager.Page(noPager, os.Stdout, func(w io.Writer) error {
+					writer := tabwriter.NewWriter(w, pretty.DiffEntryHeader)
+					for _, entry := range entries {
+						pretty.PrintDiffEntry(writer, entry)
+					}
+					return writer.Flush()
+				})
+			}
+			return pager.Page(noPager, os.Stdout, func(w io.Writer) (retErr error) {
+				var writer *tabwriter.Writer
+				if nameOnly {
+					writer = tabwriter.NewWriter(w, pretty.DiffFileHeader)
+					defer func() {
+						if err := writer.Flush(); err != nil && retErr == nil {
+							retErr = err
+						}
+					}()
+				}
+
+				newFiles, oldFiles, err := c.DiffFileAll(
+					newFile.Commit, newFile.Path,
+					oldFile.Commit, oldFile.Path,
+					shallow,
+				)
+				if err != nil {
+					return err
+				}
+				diffCmd := strings.Fields(diffCmdArg)
 				return forEachDiffFile(newFiles, oldFiles, func(nFI, oFI *pfs.FileInfo) error {
 					if nameOnly {
 						if nFI != nil {
@@ -1296,6 +2500,9 @@ $ {{alias}} foo@master:path1 bar@master:path2`,
 						}
 						return nil
 					}
+					if diffCmdArg == "" {
+						return printUnifiedDiff(c, w, nFI, oFI, contextLines)
+					}
 					nPath, oPath := "/dev/null", "/dev/null"
 					if nFI != nil {
 						nPath, err = dlFile(c, nFI.File)
@@ -1331,16 +2538,25 @@ $ {{alias}} foo@master:path1 bar@master:path2`,
 	}
 	diffFile.Flags().BoolVarP(&shallow, "shallow", "s", false, "Don't descend into sub directories.")
 	diffFile.Flags().BoolVar(&nameOnly, "name-only", false, "Show only the names of changed files.")
-	diffFile.Flags().StringVar(&diffCmdArg, "diff-command", "", "Use a program other than git to diff files.")
+	diffFile.Flags().BoolVar(&detectRenames, "detect-renames", false, "Show the changed files, detecting renamed files by their content, rather than diffing them.")
+	diffFile.Flags().StringVar(&diffCmdArg, "diff-command", "", "Use an external program to diff files, rather than printing unified diffs.")
+	diffFile.Flags().IntVarP(&contextLines, "unified", "U", 3, "The number of lines of context in unified diffs.")
 	diffFile.Flags().AddFlagSet(fullTimestampsFlags)
 	diffFile.Flags().AddFlagSet(noPagerFlags)
 	shell.RegisterCompletionFunc(diffFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(diffFile, "diff file"))
 
+	var deleteTag string
 	deleteFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>:<path/in/pfs>",
 		Short: "Delete a file.",
-		Long:  "Delete a file.",
+		Long:  "Delete a file. If the path is a glob pattern, every file and directory that matches it is deleted. With --tag, only the files at or under the path that were written with the tag are deleted.",
+		Example: `
+# delete all of the csv files under "data" in repo "foo" on branch "master"
+$ {{alias}} 'foo@master:/data/**/*.csv'
+
+# delete the files written with tag "datum-1" anywhere in repo "foo" on branch "master"
+$ {{alias}} foo@master:/ --tag datum-1`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			file, err := cmdutil.ParseFile(args[0])
 			if err != nil {
@@ -1352,12 +2568,129 @@ $ {{alias}} foo@master:path1 bar@master:path2`,
 			}
 			defer c.Close()
 
-			return c.DeleteFile(file.Commit, file.Path)
+			return txncmds.WithActiveTransaction(c, func(c *client.APIClient) error {
+				if deleteTag != "" {
+					return c.DeleteTag(file.Commit, file.Path, deleteTag)
+				}
+				return c.DeleteFile(file.Commit, file.Path)
+			})
 		}),
 	}
+	deleteFile.Flags().StringVar(&deleteTag, "tag", "", "Only delete the files written with this tag.")
 	shell.RegisterCompletionFunc(deleteFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(deleteFile, "delete file"))
 
+	// parseRemoteRepo parses a <repo>@<remote> argument, where remote is the
+	// name of the pachctl context that connects to the other cluster.
+	parseRemoteRepo := func(arg string) (*pfs.Repo, string, error) {
+		parts := strings.SplitN(arg, "@", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, "", errors.Errorf("invalid remote repo %q, expected <repo>@<remote>", arg)
+		}
+		return cmdutil.ParseRepo(parts[0]), parts[1], nil
+	}
+
+	var pushBranch string
+	push := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit> <remote-repo>@<remote>",
+		Short: "Push a commit to another cluster.",
+		Long: "Push a commit to a repo in another cluster. The remote is the name of the pachctl context that connects to the other cluster. " +
+			"Only the chunks of data that the remote branch's head doesn't already have are transferred.",
+		Example: `
+# Push the head of master in repo foo to master in repo foo of the cluster in context "prod"
+$ {{alias}} foo@master foo@prod
+
+# Push the head of master in repo foo to the staging branch of repo bar in context "prod"
+$ {{alias}} foo@master bar@prod --branch staging`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			remoteRepo, remoteName, err := parseRemoteRepo(args[1])
+			if err != nil {
+				return err
+			}
+			branch := pushBranch
+			if branch == "" {
+				if commit.Branch.Name == "" {
+					return errors.Errorf("--branch must be specified when %s doesn't name a branch", args[0])
+				}
+				branch = commit.Branch.Name
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			remote, err := client.NewOnUserMachineWithContext(remoteName, "user")
+			if err != nil {
+				return err
+			}
+			defer remote.Close()
+			remoteCommit, err := c.PushCommit(commit, remote, remoteRepo.NewBranch(branch))
+			if err != nil {
+				return err
+			}
+			fmt.Println(remoteCommit.ID)
+			return nil
+		}),
+	}
+	push.Flags().StringVar(&pushBranch, "branch", "", "The remote branch to push to, defaults to the branch being pushed.")
+	shell.RegisterCompletionFunc(push, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(push, "push"))
+
+	var pullFrom string
+	pull := &cobra.Command{
+		Use:   "{{alias}} <remote-repo>@<remote> <repo>@<branch>",
+		Short: "Pull a commit from another cluster.",
+		Long: "Pull a commit from a repo in another cluster. The remote is the name of the pachctl context that connects to the other cluster. " +
+			"Only the chunks of data that the local branch's head doesn't already have are transferred.",
+		Example: `
+# Pull the head of master in repo foo of the cluster in context "prod" to master in repo foo
+$ {{alias}} foo@prod foo@master
+
+# Pull the head of the staging branch in repo bar of the cluster in context "prod" to master in repo foo
+$ {{alias}} bar@prod foo@master --from staging`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			remoteRepo, remoteName, err := parseRemoteRepo(args[0])
+			if err != nil {
+				return err
+			}
+			branch, err := cmdutil.ParseBranch(args[1])
+			if err != nil {
+				return err
+			}
+			from := pullFrom
+			if from == "" {
+				from = branch.Name
+			}
+			remoteCommit, err := cmdutil.ParseCommit(remoteRepo.Name + "@" + from)
+			if err != nil {
+				return err
+			}
+			remoteCommit.Branch.Repo = remoteRepo
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			remote, err := client.NewOnUserMachineWithContext(remoteName, "user")
+			if err != nil {
+				return err
+			}
+			defer remote.Close()
+			commit, err := c.PullCommit(remote, remoteCommit, branch)
+			if err != nil {
+				return err
+			}
+			fmt.Println(commit.ID)
+			return nil
+		}),
+	}
+	pull.Flags().StringVar(&pullFrom, "from", "", "The remote branch or commit to pull, defaults to the branch being pulled into.")
+	commands = append(commands, cmdutil.CreateAlias(pull, "pull"))
+
 	objectDocs := &cobra.Command{
 		Short: "Docs for objects.",
 		Long: `Objects are content-addressed blobs of data that are directly stored in the backend object store.
@@ -1366,24 +2699,56 @@ Objects are a low-level resource and should not be accessed directly by most use
 	}
 	commands = append(commands, cmdutil.CreateDocsAlias(objectDocs, "object", " object$"))
 
-	var fix bool
+	var fix, repair, yes, aggressive bool
 	fsck := &cobra.Command{
 		Use:   "{{alias}}",
 		Short: "Run a file system consistency check on pfs.",
-		Long:  "Run a file system consistency check on the pachyderm file system, ensuring the correct provenance relationships are satisfied.",
+		Long:  "Run a file system consistency check on the pachyderm file system, ensuring the correct provenance relationships are satisfied, and that commits belong to repos and reference filesets that exist.",
+		Example: `
+# check pfs for consistency errors
+$ {{alias}}
+
+# print the consistency errors that --repair would fix, as JSON
+$ {{alias}} --repair --dry-run
+
+# repair consistency errors without asking for confirmation
+$ {{alias}} --repair --yes
+
+# also repair errors by deleting orphaned commits and missing data
+$ {{alias}} --repair --aggressive`,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			if (dryRun || yes) && !repair {
+				return errors.Errorf("--dry-run and --yes can only be used with --repair")
+			}
+			if dryRun && yes {
+				return errors.Errorf("--dry-run and --yes cannot be used together")
+			}
+			if aggressive && !fix && !repair {
+				return errors.Errorf("--aggressive can only be used with --fix or --repair")
+			}
+			fixLevel := pfs.FsckFixLevel_SAFE_FIX
+			if aggressive {
+				fixLevel = pfs.FsckFixLevel_AGGRESSIVE_FIX
+			}
 			c, err := client.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer c.Close()
+			if repair {
+				return repairPFS(c, fixLevel, dryRun, yes, marshaller)
+			}
+			if !fix {
+				fixLevel = pfs.FsckFixLevel_REPORT_ONLY
+			}
 			errors := false
-			if err = c.Fsck(fix, func(resp *pfs.FsckResponse) error {
+			if err = c.FsckWithFixLevel(fixLevel, func(resp *pfs.FsckResponse) error {
 				if resp.Error != "" {
 					errors = true
 					fmt.Printf("Error: %s\n", resp.Error)
-				} else {
-					fmt.Printf("Fix applied: %v", resp.Fix)
+				}
+				if resp.Fix != "" {
+					fmt.Printf("Fix applied: %v\n", resp.Fix)
 				}
 				return nil
 			}); err != nil {
@@ -1396,8 +2761,138 @@ Objects are a low-level resource and should not be accessed directly by most use
 		}),
 	}
 	fsck.Flags().BoolVarP(&fix, "fix", "f", false, "Attempt to fix as many issues as possible.")
+	fsck.Flags().BoolVar(&repair, "repair", false, "Check for issues, then confirm before attempting to fix them.")
+	fsck.Flags().BoolVar(&dryRun, "dry-run", false, "With --repair, print the issues found as JSON without fixing them.")
+	fsck.Flags().BoolVarP(&yes, "yes", "y", false, "With --repair, fix issues without asking for confirmation.")
+	fsck.Flags().BoolVar(&aggressive, "aggressive", false, "With --fix or --repair, also fix issues by deleting orphaned commits and data that can't be recovered.")
 	commands = append(commands, cmdutil.CreateAlias(fsck, "fsck"))
 
+	garbageCollect := &cobra.Command{
+		Short: "Garbage collect unused storage.",
+		Long:  "Garbage collect unused storage, deleting the filesets and chunks that nothing references. Storage is also garbage collected in the background, every STORAGE_GC_POLLING.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			run, err := c.GarbageCollect()
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, run)
+			}
+			pretty.PrintGarbageCollectionRun(os.Stdout, run)
+			return nil
+		}),
+	}
+	garbageCollect.Flags().AddFlagSet(rawFlags)
+	commands = append(commands, cmdutil.CreateAlias(garbageCollect, "garbage-collect"))
+
+	inspectGarbageCollection := &cobra.Command{
+		Short: "Return the state of storage garbage collection.",
+		Long:  "Return the size of the unused storage that the next garbage collection run will delete, and what the runs in the last day deleted.",
+		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			stats, err := c.InspectGarbageCollection()
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, stats)
+			}
+			pretty.PrintGarbageCollectionStats(os.Stdout, stats)
+			return nil
+		}),
+	}
+	inspectGarbageCollection.Flags().AddFlagSet(rawFlags)
+	commands = append(commands, cmdutil.CreateAlias(inspectGarbageCollection, "inspect garbage-collection"))
+
+	storageUsage := &cobra.Command{
+		Use:   "{{alias}} [<repo>]",
+		Short: "Return the storage used by branches.",
+		Long:  "Return the logical size of the head of each branch, and the physical size of the chunks it references in object storage. The totals count data that's shared between branches once, so the dedup ratio is how much deduplication saves.",
+		Example: `
+# return the storage used by every branch
+$ {{alias}}
+
+# return the storage used by the branches of repo foo
+$ {{alias}} foo`,
+		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) error {
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			var repo string
+			if len(args) > 0 {
+				repo = args[0]
+			}
+			resp, err := c.StorageUsage(repo)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, resp)
+			}
+			writer := tabwriter.NewWriter(os.Stdout, pretty.StorageUsageHeader)
+			for _, usage := range resp.Branches {
+				pretty.PrintBranchStorageUsage(writer, usage)
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("\nLogical size: %s\n", units.BytesSize(float64(resp.LogicalBytes)))
+			fmt.Printf("Physical size: %s in %d chunks\n", units.BytesSize(float64(resp.PhysicalBytes)), resp.Chunks)
+			fmt.Printf("Dedup ratio: %.2f\n", resp.DedupRatio)
+			return nil
+		}),
+	}
+	storageUsage.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(storageUsage, shell.RepoCompletion)
+	commands = append(commands, cmdutil.CreateAlias(storageUsage, "inspect storage-usage"))
+
+	dedupReport := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit> <repo>@<branch-or-commit>",
+		Short: "Compare the chunks that two commits reference.",
+		Long:  "Compare the chunks that the files in two commits reference, and return how many of them, and their size in object storage, are shared by the commits and unique to each. Commits that share most of their chunks are deduplicated well, so snapshotting them costs little storage.",
+		Example: `
+# compare the head of master with its parent
+$ {{alias}} foo@master^ foo@master`,
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			a, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := cmdutil.ParseCommit(args[1])
+			if err != nil {
+				return err
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			report, err := c.DedupReport(a, b)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return marshaller.Marshal(os.Stdout, report)
+			}
+			pretty.PrintCommitDedupReport(os.Stdout, report)
+			return nil
+		}),
+	}
+	dedupReport.Flags().AddFlagSet(rawFlags)
+	shell.RegisterCompletionFunc(dedupReport, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(dedupReport, "inspect dedup-report"))
+
 	var seed int64
 	runLoadTest := &cobra.Command{
 		Use:     "{{alias}} <spec>",
@@ -1428,6 +2923,82 @@ Objects are a low-level resource and should not be accessed directly by most use
 	runLoadTest.Flags().Int64VarP(&seed, "seed", "s", 0, "The seed to use for generating the load.")
 	commands = append(commands, cmdutil.CreateAlias(runLoadTest, "run pfs-load-test"))
 
+	var benchSpecFile string
+	var benchCount, benchConcurrency int
+	benchPFS := &cobra.Command{
+		Use:   "{{alias}} <repo>",
+		Short: "Benchmark PFS with a mix of file operations.",
+		Long: "Benchmark PFS by running a mix of put, get and list file operations against a repo from several concurrent workers, " +
+			"and print the latency percentiles and throughput of each operation. Each worker writes to its own branch (bench-<n>) of the repo, " +
+			"which is created if it doesn't exist.",
+		Example: `
+# run the default benchmark against repo "bench"
+$ {{alias}} bench
+
+# run 10000 operations with 32 concurrent workers
+$ {{alias}} bench --count 10000 --concurrency 32
+
+# run the benchmark described in spec.yaml
+$ {{alias}} bench --spec spec.yaml
+` + pfsload.BenchSpecification,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) (retErr error) {
+			spec := pfsload.DefaultBenchSpec()
+			if benchSpecFile != "" {
+				specBytes, err := ioutil.ReadFile(benchSpecFile)
+				if err != nil {
+					return err
+				}
+				spec = &pfsload.BenchSpec{}
+				if err := yaml.UnmarshalStrict(specBytes, spec); err != nil {
+					return errors.Wrapf(err, "could not parse benchmark spec")
+				}
+			}
+			if benchCount > 0 {
+				spec.Count = benchCount
+			}
+			if benchConcurrency > 0 {
+				spec.Concurrency = benchConcurrency
+			}
+			c, err := client.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := c.Close(); retErr == nil {
+					retErr = err
+				}
+			}()
+			if _, err := c.InspectRepo(args[0]); err != nil {
+				if !errutil.IsNotFoundError(err) {
+					return err
+				}
+				if err := c.CreateRepo(args[0]); err != nil {
+					return err
+				}
+			}
+			result, err := pfsload.Bench(c, args[0], spec, seed)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, "OPERATION\tCOUNT\tP50\tP90\tP99\tMAX\tOPS/S\tTHROUGHPUT\t\n")
+			for _, op := range result.Operations {
+				fmt.Fprintf(w, "%s\t%d\t%v\t%v\t%v\t%v\t%.1f\t%s/s\t\n", op.Type, op.Count,
+					op.P50.Round(time.Microsecond), op.P90.Round(time.Microsecond), op.P99.Round(time.Microsecond), op.Max.Round(time.Microsecond),
+					op.OpsPerSecond, units.BytesSize(op.BytesPerSecond))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("Ran %d operations in %v.\n", spec.Count, result.Duration.Round(time.Millisecond))
+			return nil
+		}),
+	}
+	benchPFS.Flags().StringVar(&benchSpecFile, "spec", "", "A YAML file describing the benchmark (see below); a mix of 50% puts, 40% gets and 10% lists is used if unset.")
+	benchPFS.Flags().IntVar(&benchCount, "count", 0, "The number of operations to run, overriding the spec.")
+	benchPFS.Flags().IntVar(&benchConcurrency, "concurrency", 0, "The number of concurrent workers, overriding the spec.")
+	benchPFS.Flags().Int64VarP(&seed, "seed", "s", 0, "The seed to use for generating the operations and files.")
+	commands = append(commands, cmdutil.CreateAlias(benchPFS, "bench pfs"))
+
 	// Add the mount commands (which aren't available on Windows, so they're in
 	// their own file)
 	commands = append(commands, mountCmds()...)
@@ -1435,16 +3006,169 @@ Objects are a low-level resource and should not be accessed directly by most use
 	return commands
 }
 
-func putFileHelper(mf client.ModifyFile, path, source string, recursive, appendFile bool) (retErr error) {
+// repoLimitFlags are the flags that set the limits in a repo's settings.
+type repoLimitFlags struct {
+	maxSize, maxFileSize, maxFiles, maxDirEntries uint64
+	finishCommitHook                              string
+	coldStorageAfter, lockFor                     time.Duration
+	lockReason                                    string
+	compression                                   string
+	compressionLevel                              int32
+	chunkAverageBits                              int64
+	chunkMinSize, chunkMaxSize                    uint64
+	chunkWindow                                   uint32
+	flags                                         *pflag.FlagSet
+}
+
+func (f *repoLimitFlags) addFlags(cmd *cobra.Command) {
+	// The flags are shared by several commands, remember the one that runs.
+	cmd.PreRun = func(cmd *cobra.Command, _ []string) {
+		f.flags = cmd.Flags()
+	}
+	cmd.Flags().Uint64Var(&f.maxSize, "max-size", 0, "The maximum size of the repo in bytes, checked when each commit is finished, 0 for no limit.")
+	cmd.Flags().Uint64Var(&f.maxFileSize, "max-file-size", 0, "The maximum size of each file in the repo in bytes, 0 for no limit.")
+	cmd.Flags().Uint64Var(&f.maxFiles, "max-files-per-commit", 0, "The maximum number of files in each commit, 0 for no limit.")
+	cmd.Flags().Uint64Var(&f.maxDirEntries, "max-dir-entries", 0, "The maximum number of entries in each directory, 0 for no limit.")
+	cmd.Flags().StringVar(&f.finishCommitHook, "finish-commit-hook", "", "The URL of a webhook that must accept each commit before it's finished, empty for none.")
+	cmd.Flags().DurationVar(&f.coldStorageAfter, "cold-storage-after", 0, "How long after a commit is finished its data is moved to cold storage, 0 for never.")
+	cmd.Flags().DurationVar(&f.lockFor, "lock-for", 0, "Lock the repo for this long, during which its finished commits can't be squashed and neither it nor its branches can be deleted. A lock can be extended but not shortened.")
+	cmd.Flags().StringVar(&f.lockReason, "lock-reason", "", "Why the repo is locked, used with --lock-for.")
+	cmd.Flags().StringVar(&f.compression, "compression", "", "How the content of files written to the repo is compressed, one of none, gzip, zstd or lz4, or default for the cluster's compression.")
+	cmd.Flags().Int32Var(&f.compressionLevel, "compression-level", 0, "The level that --compression compresses at, 0 for the codec's default.")
+	cmd.Flags().Int64Var(&f.chunkAverageBits, "chunk-average-bits", 0, "Split the content of files written to the repo into chunks of 2^N bytes on average, 0 for the cluster's default of 2^23 (8MB).")
+	cmd.Flags().Uint64Var(&f.chunkMinSize, "chunk-min-size", 0, "The minimum size of the chunks that files are split into in bytes, 0 for the default of 1MB.")
+	cmd.Flags().Uint64Var(&f.chunkMaxSize, "chunk-max-size", 0, "The maximum size of the chunks that files are split into in bytes, 0 for the default of 20MB.")
+	cmd.Flags().Uint32Var(&f.chunkWindow, "chunk-window", 0, "The size of the rolling hash window that decides where files are split into chunks in bytes, 0 for the default of 64.")
+}
+
+func (f *repoLimitFlags) changed() bool {
+	return f.flags != nil && (f.flags.Changed("max-size") || f.flags.Changed("max-file-size") || f.flags.Changed("max-files-per-commit") || f.flags.Changed("max-dir-entries") || f.flags.Changed("finish-commit-hook") || f.flags.Changed("cold-storage-after") || f.flags.Changed("lock-for") || f.flags.Changed("compression") || f.flags.Changed("compression-level") || f.flags.Changed("chunk-average-bits") || f.flags.Changed("chunk-min-size") || f.flags.Changed("chunk-max-size") || f.flags.Changed("chunk-window"))
+}
+
+// settings returns base with the limits that were set on the command line, or
+// nil if none were set.
+func (f *repoLimitFlags) settings(base *pfs.RepoSettings) (*pfs.RepoSettings, error) {
+	if !f.changed() {
+		return nil, nil
+	}
+	settings := &pfs.RepoSettings{}
+	if base != nil {
+		settings = proto.Clone(base).(*pfs.RepoSettings)
+	}
+	if f.flags.Changed("max-size") {
+		settings.MaxSizeBytes = f.maxSize
+	}
+	if f.flags.Changed("max-file-size") {
+		settings.MaxFileSizeBytes = f.maxFileSize
+	}
+	if f.flags.Changed("max-files-per-commit") {
+		settings.MaxFilesPerCommit = f.maxFiles
+	}
+	if f.flags.Changed("max-dir-entries") {
+		settings.MaxDirectoryEntries = f.maxDirEntries
+	}
+	if f.flags.Changed("finish-commit-hook") {
+		settings.FinishCommitHook = f.finishCommitHook
+	}
+	if f.flags.Changed("cold-storage-after") {
+		settings.ColdStorageAfter = nil
+		if f.coldStorageAfter > 0 {
+			settings.ColdStorageAfter = types.DurationProto(f.coldStorageAfter)
+		}
+	}
+	if f.flags.Changed("lock-for") {
+		settings.Lock = nil
+		if f.lockFor > 0 {
+			until, _ := types.TimestampProto(time.Now().Add(f.lockFor))
+			settings.Lock = &pfs.RepoLock{Until: until, Reason: f.lockReason}
+		}
+	}
+	if f.flags.Changed("compression") {
+		settings.Compression = nil
+		if f.compression != "default" {
+			codec, ok := compressionCodecs[strings.ToLower(f.compression)]
+			if !ok {
+				return nil, errors.Errorf("unrecognized compression %q, it must be one of none, gzip, zstd, lz4 or default", f.compression)
+			}
+			settings.Compression = &pfs.ChunkCompression{Codec: codec, Level: f.compressionLevel}
+		}
+	} else if f.flags.Changed("compression-level") {
+		return nil, errors.Errorf("--compression-level can only be used with --compression")
+	}
+	if f.flags.Changed("chunk-average-bits") {
+		settings.ChunkAverageBits = f.chunkAverageBits
+	}
+	if f.flags.Changed("chunk-min-size") || f.flags.Changed("chunk-max-size") || f.flags.Changed("chunk-window") {
+		chunking := &pfs.ChunkingParams{}
+		if settings.Chunking != nil {
+			chunking = proto.Clone(settings.Chunking).(*pfs.ChunkingParams)
+		}
+		if f.flags.Changed("chunk-min-size") {
+			chunking.MinSizeBytes = f.chunkMinSize
+		}
+		if f.flags.Changed("chunk-max-size") {
+			chunking.MaxSizeBytes = f.chunkMaxSize
+		}
+		if f.flags.Changed("chunk-window") {
+			chunking.WindowBytes = f.chunkWindow
+		}
+		settings.Chunking = chunking
+		if chunking.MinSizeBytes == 0 && chunking.MaxSizeBytes == 0 && chunking.WindowBytes == 0 {
+			settings.Chunking = nil
+		}
+	}
+	return settings, nil
+}
+
+var compressionCodecs = map[string]pfs.CompressionCodec{
+	"none": pfs.CompressionCodec_UNCOMPRESSED,
+	"gzip": pfs.CompressionCodec_GZIP,
+	"zstd": pfs.CompressionCodec_ZSTD,
+	"lz4":  pfs.CompressionCodec_LZ4,
+}
+
+// putFiler puts local files, URLs and stdin into a commit, keeping count of
+// the files that were uploaded, skipped and failed.
+type putFiler struct {
+	client        *client.APIClient
+	mf            client.ModifyFile
+	commit        *pfs.Commit
+	appendFile    bool
+	skipUnchanged bool
+	// split, if set, splits the content of each file.
+	split client.PutFileOption
+	// metadata is added to each file.
+	metadata map[string]string
+	// parallel, if set, uploads large files in parts in parallel.
+	parallel client.PutFileOption
+
+	uploaded, skipped, failed int
+}
+
+func (p *putFiler) put(path, source string, recursive bool) error {
 	// Resolve the path and convert to unix path in case we're on windows.
 	path = filepath.ToSlash(filepath.Clean(path))
 	var opts []client.PutFileOption
-	if appendFile {
+	if p.appendFile {
 		opts = append(opts, client.WithAppendPutFile())
 	}
+	if p.split != nil {
+		opts = append(opts, p.split)
+	}
+	if len(p.metadata) > 0 {
+		opts = append(opts, client.WithMetadataPutFile(p.metadata))
+	}
+	if p.parallel != nil {
+		opts = append(opts, p.parallel)
+	}
 	// try parsing the filename as a url, if it is one do a PutFileURL
 	if url, err := url.Parse(source); err == nil && url.Scheme != "" {
-		return mf.PutFileURL(path, url.String(), recursive, opts...)
+		if err := p.mf.PutFileURL(path, url.String(), recursive, opts...); err != nil {
+			p.failed++
+			return err
+		}
+		p.uploaded++
+		return nil
 	}
 	if source == "-" {
 		if recursive {
@@ -1452,7 +3176,12 @@ func putFileHelper(mf client.ModifyFile, path, source string, recursive, appendF
 		}
 		stdin := progress.Stdin()
 		defer stdin.Finish()
-		return mf.PutFile(path, stdin, opts...)
+		if err := p.mf.PutFile(path, stdin, opts...); err != nil {
+			p.failed++
+			return err
+		}
+		p.uploaded++
+		return nil
 	}
 	// Resolve the source and convert to unix path in case we're on windows.
 	source = filepath.ToSlash(filepath.Clean(source))
@@ -1469,19 +3198,86 @@ func putFileHelper(mf client.ModifyFile, path, source string, recursive, appendF
 			// don't do a second recursive 'put file', just put the one file at
 			// filePath into childDest, and then this walk loop will go on to the
 			// next one
-			return putFileHelper(mf, childDest, filePath, false, appendFile)
+			return p.put(childDest, filePath, false)
 		})
 	}
+	if p.skipUnchanged {
+		unchanged, err := p.unchanged(path, source)
+		if err != nil {
+			p.localFailure(source, err)
+			return nil
+		}
+		if unchanged {
+			p.skipped++
+			return nil
+		}
+	}
 	f, err := progress.Open(source)
 	if err != nil {
+		p.localFailure(source, err)
+		return nil
+	}
+	defer f.Close()
+	// Preserve the local file's permissions, so that executables stay
+	// executable.
+	info, err := f.Stat()
+	if err != nil {
+		p.localFailure(source, err)
+		return nil
+	}
+	opts = append(opts, client.WithModePutFile(uint32(info.Mode().Perm())))
+	// Errors from the stream itself mean no more files can be put.
+	if err := p.mf.PutFile(path, f, opts...); err != nil {
+		p.failed++
 		return err
 	}
+	p.uploaded++
+	return nil
+}
+
+// localFailure reports a local file that couldn't be read. It doesn't stop
+// the rest of the upload, it's only counted in the summary.
+func (p *putFiler) localFailure(source string, err error) {
+	fmt.Fprintf(os.Stderr, "failed to put %s: %v\n", source, err)
+	p.failed++
+}
+
+// unchanged returns true if the file at path in the commit has the same
+// content as the local file at source. Sizes are compared first, so the
+// remote file is only downloaded and hashed when they match.
+func (p *putFiler) unchanged(path, source string) (_ bool, retErr error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return false, errors.EnsureStack(err)
+	}
 	defer func() {
-		if err := f.Close(); retErr == nil {
-			retErr = err
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = errors.EnsureStack(err)
 		}
 	}()
-	return mf.PutFile(path, f, opts...)
+	info, err := f.Stat()
+	if err != nil {
+		return false, errors.EnsureStack(err)
+	}
+	fi, err := p.client.InspectFile(p.commit, path)
+	if err != nil {
+		if errutil.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if fi.FileType != pfs.FileType_FILE || int64(fi.SizeBytes) != info.Size() {
+		return false, nil
+	}
+	localHash := pachhash.New()
+	if _, err := io.Copy(localHash, f); err != nil {
+		return false, errors.EnsureStack(err)
+	}
+	remoteHash := pachhash.New()
+	if err := p.client.GetFile(p.commit, path, remoteHash); err != nil {
+		return false, err
+	}
+	return bytes.Equal(localHash.Sum(nil), remoteHash.Sum(nil)), nil
 }
 
 func joinPaths(prefix, filePath string) string {
@@ -1519,15 +3315,70 @@ func dlFile(pachClient *client.APIClient, f *pfs.File) (_ string, retErr error)
 	return file.Name(), nil
 }
 
-func diffCommand(cmdArg string) []string {
-	if cmdArg != "" {
-		return strings.Fields(cmdArg)
+// maxDiffFileSize is the size above which files are summarized rather than
+// diffed line by line.
+const maxDiffFileSize = 16 * units.MiB
+
+// printUnifiedDiff writes a unified diff of the two files to w, either of
+// which may be nil. Binary and very large files are only summarized.
+func printUnifiedDiff(c *client.APIClient, w io.Writer, nFI, oFI *pfs.FileInfo, contextLines int) error {
+	if (nFI != nil && nFI.FileType == pfs.FileType_DIR) || (oFI != nil && oFI.FileType == pfs.FileType_DIR) {
+		return nil
+	}
+	nName, nData, nText, err := readDiffFile(c, nFI)
+	if err != nil {
+		return err
+	}
+	oName, oData, oText, err := readDiffFile(c, oFI)
+	if err != nil {
+		return err
+	}
+	if !nText || !oText {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ (%s -> %s)\n",
+			oName, nName, units.BytesSize(float64(diffFileSize(oFI))), units.BytesSize(float64(diffFileSize(nFI))))
+		return errors.EnsureStack(err)
+	}
+	return errors.EnsureStack(difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(oData)),
+		B:        difflib.SplitLines(string(nData)),
+		FromFile: oName,
+		ToFile:   nName,
+		Context:  contextLines,
+	}))
+}
+
+// readDiffFile returns the name and content of a file, and whether it's text
+// that can be diffed. A nil file is empty text named /dev/null.
+func readDiffFile(c *client.APIClient, fi *pfs.FileInfo) (string, []byte, bool, error) {
+	if fi == nil {
+		return "/dev/null", nil, true, nil
+	}
+	name := fmt.Sprintf("%s@%s:%s", fi.File.Commit.Branch.Repo.Name, fi.File.Commit.ID, fi.File.Path)
+	if fi.SizeBytes > maxDiffFileSize {
+		return name, nil, false, nil
+	}
+	var buf bytes.Buffer
+	if err := c.GetFile(fi.File.Commit, fi.File.Path, &buf); err != nil {
+		return "", nil, false, err
+	}
+	return name, buf.Bytes(), isText(buf.Bytes()), nil
+}
+
+// isText guesses whether data is text, by looking for a NUL byte near the
+// start the way git does, and checking that it's valid UTF-8.
+func isText(data []byte) bool {
+	prefix := data
+	if len(prefix) > 8000 {
+		prefix = prefix[:8000]
 	}
-	_, err := exec.LookPath("git")
-	if err == nil {
-		return []string{"git", "-c", "color.ui=always", "--no-pager", "diff", "--no-index"}
+	return bytes.IndexByte(prefix, 0) < 0 && utf8.Valid(data)
+}
+
+func diffFileSize(fi *pfs.FileInfo) uint64 {
+	if fi == nil {
+		return 0
 	}
-	return []string{"diff"}
+	return fi.SizeBytes
 }
 
 func forEachDiffFile(newFiles, oldFiles []*pfs.FileInfo, f func(newFile, oldFile *pfs.FileInfo) error) error {
@@ -1560,6 +3411,22 @@ func forEachDiffFile(newFiles, oldFiles []*pfs.FileInfo, f func(newFile, oldFile
 	}
 }
 
+// parseTime parses an RFC 3339 timestamp, or a duration before now. An empty
+// string is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid time %q, expected an RFC 3339 timestamp or a duration", s)
+	}
+	return t, nil
+}
+
 func newClient(name string, options ...client.Option) (*client.APIClient, error) {
 	if inWorkerStr, ok := os.LookupEnv("PACH_IN_WORKER"); ok {
 		inWorker, err := strconv.ParseBool(inWorkerStr)
@@ -1572,3 +3439,118 @@ func newClient(name string, options ...client.Option) (*client.APIClient, error)
 	}
 	return client.NewOnUserMachine(name, options...)
 }
+
+// squashCommitRange squashes the commitsets of the commits on branch from
+// fromID up to, but not including, toID, after showing how many commits that
+// removes.
+func squashCommitRange(c *client.APIClient, branch *pfs.Branch, fromID, toID string, dryRun, yes bool) error {
+	to := branch.NewCommit(toID)
+	if toID == "" {
+		bi, err := c.InspectBranch(branch.Repo.Name, branch.Name)
+		if err != nil {
+			return err
+		}
+		to = bi.Head
+	}
+	from := branch.NewCommit(fromID)
+	// ListCommit returns to and its ancestors, stopping before from.
+	var ids []string
+	var last *pfs.CommitInfo
+	if err := c.ListCommitF(branch.Repo, to, from, 0, false, func(ci *pfs.CommitInfo) error {
+		if ci.Commit.ID != to.ID {
+			ids = append(ids, ci.Commit.ID)
+		}
+		last = ci
+		return nil
+	}, client.WithArchivedListCommit()); err != nil {
+		return err
+	}
+	if last == nil || last.ParentCommit == nil || last.ParentCommit.ID != fromID {
+		return errors.Errorf("commit %s is not an ancestor of %s", fromID, to.ID)
+	}
+	ids = append(ids, fromID)
+	var otherCommits int
+	for _, id := range ids {
+		cis, err := c.InspectCommitSet(id)
+		if err != nil {
+			return err
+		}
+		otherCommits += len(cis) - 1
+	}
+	fmt.Printf("Squashing %d commit(s) on %s into %s.\n", len(ids), branch, to.ID)
+	if otherCommits > 0 {
+		fmt.Printf("Their commitsets also squash %d commit(s) in other repos.\n", otherCommits)
+	}
+	if dryRun {
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		return nil
+	}
+	if !yes {
+		if ok, err := cmdutil.InteractiveConfirm(); err != nil {
+			return err
+		} else if !ok {
+			return errors.New("squash aborted")
+		}
+	}
+	_, err := c.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+		for _, id := range ids {
+			if err := builder.SquashCommitSet(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// repairPFS runs a read-only fsck, prints what it finds and, unless dryRun is
+// set, fixes the issues that fixLevel allows after the user confirms (or right
+// away if yes is set).
+func repairPFS(c *client.APIClient, fixLevel pfs.FsckFixLevel, dryRun, yes bool, marshaller *jsonpb.Marshaler) error {
+	var findings []*pfs.FsckResponse
+	if err := c.Fsck(false, func(resp *pfs.FsckResponse) error {
+		findings = append(findings, resp)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if dryRun {
+		for _, resp := range findings {
+			if err := marshaller.Marshal(os.Stdout, resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(findings) == 0 {
+		fmt.Println("No errors found.")
+		return nil
+	}
+	fmt.Printf("Found %d issue(s):\n", len(findings))
+	for _, resp := range findings {
+		switch level := resp.Issue.GetFixLevel(); {
+		case level == pfs.FsckFixLevel_REPORT_ONLY:
+			fmt.Printf("Error (can't be fixed): %s\n", resp.Error)
+		case level > fixLevel:
+			fmt.Printf("Error (fixed only with --aggressive): %s\n", resp.Error)
+		default:
+			fmt.Printf("Error: %s\n", resp.Error)
+		}
+	}
+	if !yes {
+		fmt.Println("pachd will attempt to fix these issues.")
+		if ok, err := cmdutil.InteractiveConfirm(); err != nil {
+			return err
+		} else if !ok {
+			return errors.New("repair aborted")
+		}
+	}
+	return c.FsckWithFixLevel(fixLevel, func(resp *pfs.FsckResponse) error {
+		if resp.Fix != "" {
+			fmt.Printf("Fix applied: %v\n", resp.Fix)
+		}
+		return nil
+	})
+}
@@ -79,6 +79,135 @@ func TestPutFileSplit(t *testing.T) {
 	).Run())
 }
 
+func TestPutFileSkipUnchanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo {{.repo}}
+		dir=$(mktemp -d)
+		echo "foo" >${dir}/foo
+		echo "bar" >${dir}/bar
+
+		pachctl put file -r {{.repo}}@master:/ -f ${dir} --skip-unchanged 2>&1 \
+		  | match "2 uploaded, 0 skipped, 0 failed"
+
+		# Only the modified file is uploaded again
+		echo "baz" >${dir}/bar
+		pachctl put file -r {{.repo}}@master:/ -f ${dir} --skip-unchanged 2>&1 \
+		  | match "1 uploaded, 1 skipped, 0 failed"
+		pachctl get file {{.repo}}@master:/bar \
+		  | match "baz"
+		`,
+		"repo", tu.UniqueString("TestPutFileSkipUnchanged-repo"),
+	).Run())
+}
+
+func TestDiffFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo {{.repo}}
+		printf "foo\nbar\n" | pachctl put file {{.repo}}@master:/text
+		printf "foo\0" | pachctl put file {{.repo}}@master:/binary
+		printf "foo\nbaz\n" | pachctl put file {{.repo}}@master:/text
+		printf "bar\0" | pachctl put file {{.repo}}@master:/binary
+
+		pachctl diff file {{.repo}}@master:/text \
+		  | match -- "-bar" \
+		  | match -- "\\+baz"
+		pachctl diff file {{.repo}}@master:/binary \
+		  | match "Binary files .* differ"
+		`,
+		"repo", tu.UniqueString("TestDiffFile-repo"),
+	).Run())
+}
+
+func TestExportRepo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo {{.repo}}
+		echo "foo" | pachctl put file {{.repo}}@master:/dir/foo.csv
+		echo "bar" | pachctl put file {{.repo}}@master:/dir/bar.txt
+		dir=$(mktemp -d)
+
+		pachctl export repo '{{.repo}}@master:/dir/*.csv' -o ${dir}/out.tar.gz
+		tar -tzf ${dir}/out.tar.gz \
+		  | match "dir/foo.csv" \
+		  | match -v "bar.txt"
+		test ! -e ${dir}/out.tar.gz.part
+		`,
+		"repo", tu.UniqueString("TestExportRepo-repo"),
+	).Run())
+}
+
+func TestDu(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo {{.repo}}
+		echo "foo" | pachctl put file {{.repo}}@master:/a/b/foo
+		echo "bar" | pachctl put file {{.repo}}@master:/c/bar
+
+		pachctl du {{.repo}}@master \
+		  | match "/a/" \
+		  | match "/c/" \
+		  | match -v "/a/b/"
+		pachctl du {{.repo}}@master --depth -1 \
+		  | match "/a/b/"
+		`,
+		"repo", tu.UniqueString("TestDu-repo"),
+	).Run())
+}
+
+func TestSquashCommits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo {{.repo}}
+		from=$(pachctl start commit {{.repo}}@master)
+		echo "1" | pachctl put file {{.repo}}@${from}:/file1
+		pachctl finish commit {{.repo}}@${from}
+		echo "2" | pachctl put file {{.repo}}@master:/file2
+		echo "3" | pachctl put file {{.repo}}@master:/file3
+
+		pachctl squash commits {{.repo}}@master --from ${from} --dry-run \
+		  | match "Squashing 2 commit"
+		pachctl squash commits {{.repo}}@master --from ${from} --yes
+		pachctl list commit {{.repo}}@master \
+		  | match -v ${from}
+		pachctl get file {{.repo}}@master:/file1 \
+		  | match "1"
+		`,
+		"repo", tu.UniqueString("TestSquashCommits-repo"),
+	).Run())
+}
+
+func TestWatchCommits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo {{.repo1}}
+		pachctl create repo {{.repo2}}
+		echo "foo" | pachctl put file {{.repo1}}@master:/foo
+		echo "bar" | pachctl put file {{.repo2}}@master:/bar
+
+		# watch commits runs until it's interrupted
+		(timeout 10 pachctl watch commits {{.repo1}}@master {{.repo2}}@master || true) \
+		  | match "{{.repo1}}@master .* finished" \
+		  | match "{{.repo2}}@master .* finished"
+		`,
+		"repo1", tu.UniqueString("TestWatchCommits-repo1"),
+		"repo2", tu.UniqueString("TestWatchCommits-repo2"),
+	).Run())
+}
+
 func TestMountParsing(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -0,0 +1,364 @@
+package cmds
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// exportCheckpointBytes is how much is written to an export archive between
+// checkpoints, which are the points an interrupted export resumes from.
+const exportCheckpointBytes = 64 * 1024 * 1024
+
+// exportState is the progress of an export, saved next to the partial
+// archive so that an interrupted export can be resumed.
+type exportState struct {
+	// Commit is the ID of the commit being exported, so that a resumed
+	// export reads the same data even if the branch has moved.
+	Commit string `json:"commit"`
+	Glob   string `json:"glob"`
+	// Offset is the size of the archive at the last checkpoint.
+	Offset int64 `json:"offset"`
+	// Files is the number of files in the archive at the last checkpoint,
+	// and LastPath the path of the last of them.
+	Files    int    `json:"files"`
+	LastPath string `json:"last_path"`
+}
+
+func readExportState(path string) (*exportState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.EnsureStack(err)
+	}
+	state := &exportState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", path)
+	}
+	return state, nil
+}
+
+func writeExportState(path string, state *exportState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.EnsureStack(err)
+	}
+	return errors.EnsureStack(os.Rename(tmp, path))
+}
+
+// exportCommit writes the files in commit that match glob to a tar archive
+// at output, gzipped if output ends in .tar.gz or .tgz, or to a zip archive if
+// it ends in .zip. The archive is built at output.part and only moved to
+// output once every file has been written and checked against the sizes that
+// pachd reports. If a previous export of a tar archive to the same output was
+// interrupted, it's resumed from its last checkpoint.
+func exportCommit(c *client.APIClient, commit *pfs.Commit, glob, output string) (retErr error) {
+	compress := strings.HasSuffix(output, ".tar.gz") || strings.HasSuffix(output, ".tgz")
+	isZip := strings.HasSuffix(output, ".zip")
+	if !compress && !isZip && !strings.HasSuffix(output, ".tar") {
+		return errors.Errorf("unsupported archive %q, must end in .tar, .tar.gz, .tgz or .zip", output)
+	}
+	partPath, statePath := output+".part", output+".part.json"
+	if isZip {
+		return exportZip(c, commit, glob, output, partPath)
+	}
+	state, err := readExportState(statePath)
+	if err != nil {
+		return err
+	}
+	if state != nil && state.Glob != glob {
+		return errors.Errorf("%s is an unfinished export of %q, remove it to export %q instead", partPath, state.Glob, glob)
+	}
+	if state == nil {
+		ci, err := c.WaitCommit(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID)
+		if err != nil {
+			return err
+		}
+		state = &exportState{Commit: ci.Commit.ID, Glob: glob}
+	} else {
+		fmt.Fprintf(os.Stderr, "resuming export of commit %s after %d files\n", state.Commit, state.Files)
+	}
+	commit = client.NewCommit(commit.Branch.Repo.Name, commit.Branch.Name, state.Commit)
+	sizes, err := exportSizes(c, commit, glob)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	// Anything after the last checkpoint may be incomplete.
+	if err := f.Truncate(state.Offset); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if _, err := f.Seek(state.Offset, io.SeekStart); err != nil {
+		return errors.EnsureStack(err)
+	}
+	aw := newArchiveWriter(f, state.Offset, compress)
+
+	r, err := c.GetFileTar(commit, glob)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	files := state.Files
+	lastCheckpoint := state.Offset
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return errors.EnsureStack(err)
+		}
+		// Entries up to the last checkpoint are already in the archive.
+		if state.LastPath != "" && hdr.Name <= state.LastPath {
+			continue
+		}
+		if strings.HasSuffix(hdr.Name, "/") {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Size = 0
+		} else {
+			size, ok := sizes[hdr.Name]
+			if !ok {
+				return errors.Errorf("unexpected file %s in commit %s", hdr.Name, state.Commit)
+			}
+			if hdr.Size != size {
+				return errors.Errorf("file %s is %d bytes, expected %d", hdr.Name, hdr.Size, size)
+			}
+			files++
+		}
+		name := hdr.Name
+		// Paths in the archive are relative, so that it extracts into the
+		// current directory.
+		hdr.Name = strings.TrimPrefix(hdr.Name, "/")
+		if hdr.Name == "" {
+			continue
+		}
+		if err := aw.tw.WriteHeader(hdr); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if _, err := io.Copy(aw.tw, tr); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if err := aw.tw.Flush(); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if aw.offset()-lastCheckpoint >= exportCheckpointBytes {
+			if err := aw.checkpoint(); err != nil {
+				return err
+			}
+			if err := f.Sync(); err != nil {
+				return errors.EnsureStack(err)
+			}
+			lastCheckpoint = aw.offset()
+			if err := writeExportState(statePath, &exportState{
+				Commit:   state.Commit,
+				Glob:     glob,
+				Offset:   lastCheckpoint,
+				Files:    files,
+				LastPath: name,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if files != len(sizes) {
+		return errors.Errorf("archive has %d files, expected %d", files, len(sizes))
+	}
+	if err := aw.close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := os.Rename(partPath, output); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := os.Remove(statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.EnsureStack(err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d files to %s\n", files, output)
+	return nil
+}
+
+// exportZip writes the files in commit that match glob to a zip archive,
+// which pachd builds. A zip archive ends in a central directory, so it can't
+// be resumed by appending; an interrupted zip export starts over.
+func exportZip(c *client.APIClient, commit *pfs.Commit, glob, output, partPath string) error {
+	ci, err := c.WaitCommit(commit.Branch.Repo.Name, commit.Branch.Name, commit.ID)
+	if err != nil {
+		return err
+	}
+	commit = ci.Commit
+	sizes, err := exportSizes(c, commit, glob)
+	if err != nil {
+		return err
+	}
+	if err := func() (retErr error) {
+		f, err := os.Create(partPath)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil && retErr == nil {
+				retErr = errors.EnsureStack(err)
+			}
+		}()
+		r, err := c.GetFileZip(commit, glob)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			return errors.EnsureStack(err)
+		}
+		return errors.EnsureStack(f.Sync())
+	}(); err != nil {
+		return err
+	}
+	files, err := verifyZip(partPath, sizes)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(partPath, output); err != nil {
+		return errors.EnsureStack(err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d files to %s\n", files, output)
+	return nil
+}
+
+// verifyZip checks that the zip archive at path holds exactly the files in
+// sizes, with the same sizes, and returns how many files it holds.
+func verifyZip(path string, sizes map[string]int64) (_ int, retErr error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := zr.Close(); err != nil && retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	var files int
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		size, ok := sizes["/"+f.Name]
+		if !ok {
+			return 0, errors.Errorf("unexpected file /%s in archive", f.Name)
+		}
+		if int64(f.UncompressedSize64) != size {
+			return 0, errors.Errorf("file /%s is %d bytes, expected %d", f.Name, f.UncompressedSize64, size)
+		}
+		files++
+	}
+	if files != len(sizes) {
+		return 0, errors.Errorf("archive has %d files, expected %d", files, len(sizes))
+	}
+	return files, nil
+}
+
+// exportSizes returns the size of each file that matches glob, which is what
+// the exported archive is checked against.
+func exportSizes(c *client.APIClient, commit *pfs.Commit, glob string) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	addFile := func(fi *pfs.FileInfo) error {
+		if fi.FileType == pfs.FileType_FILE {
+			sizes[fi.File.Path] = int64(fi.SizeBytes)
+		}
+		return nil
+	}
+	if err := c.GlobFile(commit, glob, func(fi *pfs.FileInfo) error {
+		if fi.FileType == pfs.FileType_DIR {
+			return c.WalkFile(commit, fi.File.Path, addFile)
+		}
+		return addFile(fi)
+	}); err != nil && !errutil.IsNotFoundError(err) {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// archiveWriter writes a tar archive, optionally gzipped. Each checkpoint
+// ends a gzip member, so that the archive can be truncated to the
+// checkpoint and appended to with a new member.
+type archiveWriter struct {
+	w  *countingWriter
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func newArchiveWriter(w io.Writer, offset int64, compress bool) *archiveWriter {
+	aw := &archiveWriter{w: &countingWriter{w: w, n: offset}}
+	aw.reset(compress)
+	return aw
+}
+
+func (aw *archiveWriter) reset(compress bool) {
+	var w io.Writer = aw.w
+	if compress {
+		aw.gw = gzip.NewWriter(aw.w)
+		w = aw.gw
+	}
+	aw.tw = tar.NewWriter(w)
+}
+
+func (aw *archiveWriter) offset() int64 {
+	return aw.w.n
+}
+
+func (aw *archiveWriter) checkpoint() error {
+	if aw.gw == nil {
+		return nil
+	}
+	if err := aw.gw.Close(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	aw.reset(true)
+	return nil
+}
+
+func (aw *archiveWriter) close() error {
+	if err := aw.tw.Close(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if aw.gw != nil {
+		return errors.EnsureStack(aw.gw.Close())
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
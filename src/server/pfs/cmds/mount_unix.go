@@ -9,12 +9,14 @@ import (
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/cmdutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/server/pfs/fuse"
 
+	units "github.com/docker/go-units"
 	"github.com/hanwen/go-fuse/v2/fs"
 	gofuse "github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/spf13/cobra"
@@ -71,6 +73,9 @@ func mountCmds() []*cobra.Command {
 	var write bool
 	var debug bool
 	var repoOpts cmdutil.RepeatedStringArg
+	var cacheDir string
+	var cacheSize string
+	var writeBack time.Duration
 	mount := &cobra.Command{
 		Use:   "{{alias}} <path/to/mount/point>",
 		Short: "Mount pfs locally. This command blocks.",
@@ -86,8 +91,17 @@ func mountCmds() []*cobra.Command {
 			if err != nil {
 				return err
 			}
+			var cacheSizeBytes int64
+			if cacheSize != "" {
+				if cacheSizeBytes, err = units.FromHumanSize(cacheSize); err != nil {
+					return errors.Wrapf(err, "invalid cache size %q", cacheSize)
+				}
+			}
 			opts := &fuse.Options{
-				Write: write,
+				Write:     write,
+				CacheDir:  cacheDir,
+				CacheSize: cacheSizeBytes,
+				WriteBack: writeBack,
 				Fuse: &fs.Options{
 					MountOptions: gofuse.MountOptions{
 						Debug:  debug,
@@ -106,6 +120,9 @@ func mountCmds() []*cobra.Command {
 	mount.Flags().BoolVarP(&debug, "debug", "d", false, "Turn on debug messages.")
 	mount.Flags().VarP(&repoOpts, "repos", "r", "Repos and branches / commits to mount, arguments should be of the form \"repo@branch+w\", where the trailing flag \"+w\" indicates write.")
 	mount.MarkFlagCustom("repos", "__pachctl_get_repo_branch")
+	mount.Flags().StringVar(&cacheDir, "cache-dir", "", "A directory in which to keep the contents of files read through the mount, so that later mounts don't need to fetch them from pachd again.")
+	mount.Flags().StringVar(&cacheSize, "cache-size", "", "The maximum size of --cache-dir (e.g. 10GB), least recently used files are evicted past it. Unlimited if unset.")
+	mount.Flags().DurationVar(&writeBack, "write-back", 0, "Upload writes to pachd at this interval, in one commit per repo, rather than only when unmounting.")
 	commands = append(commands, cmdutil.CreateAlias(mount, "mount"))
 
 	var all bool
@@ -0,0 +1,133 @@
+package fuse
+
+import (
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+const cacheTmpSuffix = ".tmp"
+
+// fileCache is a local cache of file contents, keyed by the hash of the
+// content, that outlives a single mount. It lets repeated mounts of the same
+// data read it from local disk rather than from pachd. When the cache grows
+// past maxSize, the least recently used entries are evicted.
+type fileCache struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+}
+
+func newFileCache(dir string, maxSize int64) (*fileCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &fileCache{dir: dir, maxSize: maxSize}, nil
+}
+
+// get writes the content with the given hash to w, calling fetch to
+// populate the cache if it isn't already there.
+func (c *fileCache) get(hash []byte, w io.Writer, fetch func(io.Writer) error) (retErr error) {
+	if len(hash) == 0 {
+		return fetch(w)
+	}
+	p := filepath.Join(c.dir, hex.EncodeToString(hash))
+	f, err := os.Open(p)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		if err := c.put(p, fetch); err != nil {
+			return err
+		}
+		if f, err = os.Open(p); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	defer func() {
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = errors.WithStack(err)
+		}
+	}()
+	// The modification time of an entry records when it was last used, which
+	// is what eviction goes by.
+	now := time.Now()
+	if err := os.Chtimes(p, now, now); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.Copy(w, f)
+	return errors.WithStack(err)
+}
+
+// put fetches an entry into a temporary file and moves it to p, so that a
+// partial fetch never shows up in the cache.
+func (c *fileCache) put(p string, fetch func(io.Writer) error) (retErr error) {
+	tmp, err := ioutil.TempFile(c.dir, filepath.Base(p)+cacheTmpSuffix)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if retErr != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err := fetch(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return errors.WithStack(err)
+	}
+	return c.evict(p)
+}
+
+// evict removes the least recently used entries until the cache fits in
+// maxSize. The entry at keep, which was just added, is never removed.
+func (c *fileCache) evict(keep string) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var size int64
+	var entries []os.FileInfo
+	for _, info := range infos {
+		if info.IsDir() || strings.Contains(info.Name(), cacheTmpSuffix) {
+			continue
+		}
+		size += info.Size()
+		entries = append(entries, info)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, info := range entries {
+		if size <= c.maxSize {
+			break
+		}
+		p := filepath.Join(c.dir, info.Name())
+		if p == keep {
+			continue
+		}
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		size -= info.Size()
+	}
+	return nil
+}
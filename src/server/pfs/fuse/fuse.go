@@ -7,8 +7,10 @@ import (
 	pathpkg "path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fs"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
@@ -53,13 +55,74 @@ func Mount(c *client.APIClient, target string, opts *Options) (retErr error) {
 		}
 		server.Unmount()
 	}()
+	if writeBack := opts.getWriteBack(); writeBack > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(writeBack)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if err := root.flush(); err != nil {
+						log.Errorf("error writing back to pfs: %v", err)
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
 	server.Serve()
+	return root.flush()
+}
+
+// flush uploads the files that have been written since the last flush, in
+// one commit per repo. Written files stay dirty, since they may still be open
+// for writing, and the modification time that was last uploaded is kept to
+// tell whether they changed since.
+func (r *loopbackRoot) flush() (retErr error) {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	var paths []string
+	func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for path, state := range r.files {
+			if state == dirty {
+				paths = append(paths, path)
+			}
+		}
+	}()
+	flushed := make(map[string]time.Time)
+	for _, path := range paths {
+		// Deleted files are recorded with a zero time.
+		var mtime time.Time
+		info, err := os.Lstat(filepath.Join(r.rootPath, path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+		if err == nil {
+			mtime = info.ModTime()
+		}
+		if prev, ok := r.flushed[path]; ok && prev.Equal(mtime) {
+			continue
+		}
+		flushed[path] = mtime
+	}
+	defer func() {
+		if retErr == nil {
+			for path, mtime := range flushed {
+				r.flushed[path] = mtime
+			}
+		}
+	}()
 	mfcs := make(map[string]*client.ModifyFileClient)
 	mfc := func(repo string) (*client.ModifyFileClient, error) {
 		if mfc, ok := mfcs[repo]; ok {
 			return mfc, nil
 		}
-		mfc, err := c.NewModifyFileClient(client.NewCommit(repo, root.branch(repo), ""))
+		mfc, err := r.c.NewModifyFileClient(client.NewCommit(repo, r.branch(repo), ""))
 		if err != nil {
 			return nil, err
 		}
@@ -73,17 +136,14 @@ func Mount(c *client.APIClient, target string, opts *Options) (retErr error) {
 			}
 		}
 	}()
-	for path, state := range root.files {
-		if state != dirty {
-			continue
-		}
+	for path := range flushed {
 		parts := strings.Split(path, "/")
 		mfc, err := mfc(parts[0])
 		if err != nil {
 			return err
 		}
 		if err := func() (retErr error) {
-			f, err := progress.Open(filepath.Join(root.rootPath, path))
+			f, err := progress.Open(filepath.Join(r.rootPath, path))
 			if err != nil {
 				if errors.Is(err, os.ErrNotExist) {
 					return mfc.DeleteFile(pathpkg.Join(parts[1:]...))
@@ -95,7 +155,11 @@ func Mount(c *client.APIClient, target string, opts *Options) (retErr error) {
 					retErr = errors.WithStack(err)
 				}
 			}()
-			return mfc.PutFile(pathpkg.Join(parts[1:]...), f)
+			info, err := f.Stat()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return mfc.PutFile(pathpkg.Join(parts[1:]...), f, client.WithModePutFile(uint32(info.Mode().Perm())))
 		}(); err != nil {
 			return err
 		}
@@ -3,6 +3,7 @@ package fuse
 import (
 	"bytes"
 	"crypto/sha256"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"github.com/hanwen/go-fuse/v2/fuse"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/require"
 	"github.com/pachyderm/pachyderm/v2/src/internal/testpachd"
 	"github.com/pachyderm/pachyderm/v2/src/internal/testutil"
@@ -364,6 +366,68 @@ func TestOpenCommit(t *testing.T) {
 	})
 }
 
+func TestWriteBack(t *testing.T) {
+	env := testpachd.NewRealEnv(t, testutil.NewTestDBConfig(t))
+	require.NoError(t, env.PachClient.CreateRepo("repo"))
+	commit := client.NewCommit("repo", "master", "")
+	var numCommits int
+	withMount(t, env.PachClient, &Options{
+		Write:     true,
+		WriteBack: time.Second,
+	}, func(mountPoint string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(mountPoint, "repo", "foo"), []byte("foo\n"), 0644))
+		// The file is uploaded while the filesystem is still mounted.
+		require.NoErrorWithinTRetry(t, 30*time.Second, func() error {
+			var b bytes.Buffer
+			if err := env.PachClient.GetFile(commit, "foo", &b); err != nil {
+				return err
+			}
+			if b.String() != "foo\n" {
+				return errors.Errorf("unexpected content %q", b.String())
+			}
+			return nil
+		})
+		cis, err := env.PachClient.ListCommit(client.NewRepo("repo"), nil, nil, 0)
+		require.NoError(t, err)
+		numCommits = len(cis)
+	})
+	// Nothing changed after the last write back, so unmounting doesn't
+	// create another commit.
+	cis, err := env.PachClient.ListCommit(client.NewRepo("repo"), nil, nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, numCommits, len(cis))
+}
+
+func TestFileCache(t *testing.T) {
+	cache, err := newFileCache(t.TempDir(), 10)
+	require.NoError(t, err)
+	var fetches int
+	get := func(hash, content string) string {
+		var b bytes.Buffer
+		require.NoError(t, cache.get([]byte(hash), &b, func(w io.Writer) error {
+			fetches++
+			_, err := w.Write([]byte(content))
+			return err
+		}))
+		return b.String()
+	}
+	require.Equal(t, "foo", get("a", "foo"))
+	require.Equal(t, "foo", get("a", "foo"))
+	require.Equal(t, 1, fetches)
+	require.Equal(t, "barbaz", get("b", "barbaz"))
+	require.Equal(t, 2, fetches)
+	// Make sure that "b" is used more recently than "a", which is evicted
+	// when "c" doesn't fit.
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, "barbaz", get("b", "barbaz"))
+	require.Equal(t, "quux", get("c", "quux"))
+	require.Equal(t, 3, fetches)
+	require.Equal(t, "barbaz", get("b", "barbaz"))
+	require.Equal(t, 3, fetches)
+	require.Equal(t, "foo", get("a", "foo"))
+	require.Equal(t, 4, fetches)
+}
+
 func withMount(tb testing.TB, c *client.APIClient, opts *Options, f func(mountPoint string)) {
 	dir := tb.TempDir()
 	if opts == nil {
@@ -6,13 +6,16 @@ package fuse
 
 import (
 	"context"
+	"io"
 	"os"
 	pathpkg "path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/gogo/protobuf/types"
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
 
@@ -42,13 +45,17 @@ type loopbackRoot struct {
 
 	write bool
 
-	c *client.APIClient
+	c     *client.APIClient
+	cache *fileCache
 
 	repoOpts map[string]*RepoOptions
 	branches map[string]string
 	commits  map[string]string
 	files    map[string]fileState
 	mu       sync.Mutex
+	// flushMu serializes uploads of the written files, and protects flushed.
+	flushMu sync.Mutex
+	flushed map[string]time.Time
 }
 
 type loopbackNode struct {
@@ -504,6 +511,10 @@ func newLoopbackRoot(root, target string, c *client.APIClient, opts *Options) (*
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	cache, err := opts.getCache()
+	if err != nil {
+		return nil, err
+	}
 
 	n := &loopbackRoot{
 		rootPath:   root,
@@ -511,10 +522,12 @@ func newLoopbackRoot(root, target string, c *client.APIClient, opts *Options) (*
 		targetPath: target,
 		write:      opts.getWrite(),
 		c:          c,
+		cache:      cache,
 		repoOpts:   opts.getRepoOpts(),
 		branches:   opts.getBranches(),
 		commits:    make(map[string]string),
 		files:      make(map[string]fileState),
+		flushed:    make(map[string]time.Time),
 	}
 	return n, nil
 }
@@ -597,12 +610,25 @@ func (n *loopbackNode) download(path string, state fileState) (retErr error) {
 				retErr = errors.WithStack(err)
 			}
 		}()
-		if state < full {
-			return f.Truncate(int64(fi.SizeBytes))
+		if fi.Mode != 0 {
+			if err := f.Chmod(os.FileMode(fi.Mode).Perm()); err != nil {
+				return errors.WithStack(err)
+			}
 		}
-		if err := n.c().GetFile(fi.File.Commit, fi.File.Path, f); err != nil {
+		if state < full {
+			if err := f.Truncate(int64(fi.SizeBytes)); err != nil {
+				return errors.WithStack(err)
+			}
+		} else if err := n.root().getFile(fi, f); err != nil {
 			return err
 		}
+		if fi.Mtime != nil {
+			mtime, err := types.TimestampFromProto(fi.Mtime)
+			if err != nil {
+				return errors.EnsureStack(err)
+			}
+			return errors.WithStack(os.Chtimes(p, mtime, mtime))
+		}
 		return nil
 	}); err != nil && !errutil.IsNotFoundError(err) &&
 		!pfsserver.IsOutputCommitNotFinishedErr(err) {
@@ -611,6 +637,18 @@ func (n *loopbackNode) download(path string, state fileState) (retErr error) {
 	return nil
 }
 
+// getFile writes the content of the file to w, from the cache if there is
+// one.
+func (r *loopbackRoot) getFile(fi *pfs.FileInfo, w io.Writer) error {
+	fetch := func(w io.Writer) error {
+		return r.c.GetFile(fi.File.Commit, fi.File.Path, w)
+	}
+	if r.cache == nil {
+		return fetch(w)
+	}
+	return r.cache.get(fi.Hash, w, fetch)
+}
+
 func (n *loopbackNode) trimPath(path string) string {
 	path = strings.TrimPrefix(path, n.root().rootPath)
 	return strings.TrimPrefix(path, "/")
@@ -1,6 +1,8 @@
 package fuse
 
 import (
+	"time"
+
 	"github.com/hanwen/go-fuse/v2/fs"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
@@ -22,6 +24,19 @@ type Options struct {
 	// RepoOptions is a map from repo names to options associated with them.
 	RepoOptions map[string]*RepoOptions
 
+	// CacheDir is a directory in which the contents of downloaded files are
+	// kept across mounts, so that they don't need to be fetched from pachd
+	// again. If it's empty, contents are only kept for the life of the mount.
+	CacheDir string
+
+	// CacheSize is the maximum size in bytes of CacheDir, 0 for no limit.
+	CacheSize int64
+
+	// WriteBack is how often writes are uploaded to pachd, in one commit per
+	// repo. If it's 0, writes are only uploaded when the filesystem is
+	// unmounted.
+	WriteBack time.Duration
+
 	// Unmount is a channel that will be closed when the filesystem has been
 	// unmounted. It can be nil in which case it's ignored.
 	Unmount chan struct{}
@@ -71,6 +86,20 @@ func (o *Options) getWrite() bool {
 	return o.Write
 }
 
+func (o *Options) getCache() (*fileCache, error) {
+	if o == nil || o.CacheDir == "" {
+		return nil, nil
+	}
+	return newFileCache(o.CacheDir, o.CacheSize)
+}
+
+func (o *Options) getWriteBack() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.WriteBack
+}
+
 func (o *Options) getUnmount() chan struct{} {
 	if o == nil {
 		return nil
@@ -3,6 +3,13 @@ package pfs
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
@@ -13,6 +20,16 @@ type ErrFileNotFound struct {
 	File *pfs.File
 }
 
+// ErrProjectNotFound represents a project-not-found error.
+type ErrProjectNotFound struct {
+	Project *pfs.Project
+}
+
+// ErrProjectExists represents a project-exists error.
+type ErrProjectExists struct {
+	Project *pfs.Project
+}
+
 // ErrRepoNotFound represents a repo-not-found error.
 type ErrRepoNotFound struct {
 	Repo *pfs.Repo
@@ -28,6 +45,12 @@ type ErrRepoDeleted struct {
 	Repo *pfs.Repo
 }
 
+// ErrRepoReadOnly represents an error where a write was attempted on a
+// read-only repo, such as a mirror repo.
+type ErrRepoReadOnly struct {
+	Repo *pfs.Repo
+}
+
 // ErrCommitNotFound represents a commit-not-found error.
 type ErrCommitNotFound struct {
 	Commit *pfs.Commit
@@ -93,10 +116,109 @@ type ErrCommitOnOutputBranch struct {
 	Branch *pfs.Branch
 }
 
+// ErrFileTooLarge represents an error where a file exceeds its repo's limit
+// on the size of files.
+type ErrFileTooLarge struct {
+	File      *pfs.File
+	SizeBytes uint64
+	Limit     uint64
+}
+
+// ErrRepoTooLarge represents an error where finishing a commit would make
+// its repo exceed the repo's limit on its size.
+type ErrRepoTooLarge struct {
+	Commit    *pfs.Commit
+	SizeBytes uint64
+	Limit     uint64
+}
+
+// ErrRepoLocked represents an error where a commit, branch or repo would be
+// deleted from a repo under a RepoLock.
+type ErrRepoLocked struct {
+	Repo  *pfs.Repo
+	Until *types.Timestamp
+}
+
+// ErrTooManyFiles represents an error where a commit exceeds its repo's
+// limit on the number of files in a commit.
+type ErrTooManyFiles struct {
+	Commit *pfs.Commit
+	Limit  uint64
+}
+
+// ErrTooManyDirectoryEntries represents an error where a directory exceeds
+// its repo's limit on the number of entries in a directory.
+type ErrTooManyDirectoryEntries struct {
+	Dir   *pfs.File
+	Limit uint64
+}
+
+// ErrAmbiguousPath represents an error where a path matches several files
+// when it is resolved case-insensitively.
+type ErrAmbiguousPath struct {
+	File    *pfs.File
+	Matches []string
+}
+
+// ErrPathReserved represents an error where a path is modified by a writer
+// other than the owner of a reservation that covers it.
+type ErrPathReserved struct {
+	Repo   *pfs.Repo
+	Path   string
+	Prefix string
+	Owner  string
+}
+
+// ErrCommitRejected represents an error where a repo's finish commit hook
+// rejects a commit. Message is the reason that the hook gave.
+type ErrCommitRejected struct {
+	Commit  *pfs.Commit
+	Message string
+}
+
+// ErrCommitWaitTimeout represents an error where a commit did not reach the
+// requested state before an InspectCommit wait timeout elapsed. CommitInfo
+// holds the commit's state at the time the wait gave up.
+type ErrCommitWaitTimeout struct {
+	Commit     *pfs.Commit
+	Wait       pfs.CommitState
+	Timeout    time.Duration
+	CommitInfo *pfs.CommitInfo
+}
+
+// ErrUploadNotFound represents an error where an upload doesn't exist, or
+// has expired.
+type ErrUploadNotFound struct {
+	UploadID string
+}
+
+// ErrUploadExists represents an error where an upload is started with the ID
+// of an upload into a different commit.
+type ErrUploadExists struct {
+	UploadID string
+	Commit   *pfs.Commit
+}
+
+// ErrUnexpectedBranchHead represents an error where a branch is promoted
+// with an expected head that isn't the branch's current head.
+type ErrUnexpectedBranchHead struct {
+	Branch   *pfs.Branch
+	Head     *pfs.Commit
+	Expected *pfs.Commit
+}
+
 func (e ErrFileNotFound) Error() string {
 	return fmt.Sprintf("file %v not found in repo %v at commit %v", e.File.Path, e.File.Commit.Branch.Repo, e.File.Commit.ID)
 }
 
+func (e ErrProjectNotFound) Error() string {
+	return fmt.Sprintf("project %v not found", e.Project)
+}
+
+func (e ErrProjectExists) Error() string {
+	return fmt.Sprintf("project %v already exists", e.Project)
+}
+
 func (e ErrRepoNotFound) Error() string {
 	return fmt.Sprintf("repo %v not found", e.Repo)
 }
@@ -109,6 +231,10 @@ func (e ErrRepoDeleted) Error() string {
 	return fmt.Sprintf("repo %v was deleted", e.Repo)
 }
 
+func (e ErrRepoReadOnly) Error() string {
+	return fmt.Sprintf("repo %v is read-only", e.Repo)
+}
+
 func (e ErrCommitNotFound) Error() string {
 	return fmt.Sprintf("commit %v not found in repo %v", e.Commit.ID, e.Commit.Branch.Repo)
 }
@@ -153,20 +279,102 @@ func (e ErrCommitOnOutputBranch) Error() string {
 	return fmt.Sprintf("cannot start a commit on an output branch: %s", e.Branch)
 }
 
+func (e ErrFileTooLarge) Error() string {
+	return fmt.Sprintf("file %v in repo %v is %d bytes, which exceeds the repo's limit of %d bytes", e.File.Path, e.File.Commit.Branch.Repo, e.SizeBytes, e.Limit)
+}
+
+func (e ErrRepoTooLarge) Error() string {
+	return fmt.Sprintf("commit %v in repo %v is %d bytes, which exceeds the repo's size limit of %d bytes", e.Commit.ID, e.Commit.Branch.Repo, e.SizeBytes, e.Limit)
+}
+
+func (e ErrRepoLocked) Error() string {
+	return fmt.Sprintf("repo %v is locked until %v", e.Repo, types.TimestampString(e.Until))
+}
+
+func (e ErrTooManyFiles) Error() string {
+	return fmt.Sprintf("commit %v in repo %v has more than %d files, which is the repo's limit", e.Commit.ID, e.Commit.Branch.Repo, e.Limit)
+}
+
+func (e ErrTooManyDirectoryEntries) Error() string {
+	return fmt.Sprintf("directory %v in repo %v has more than %d entries, which is the repo's limit", e.Dir.Path, e.Dir.Commit.Branch.Repo, e.Limit)
+}
+
+func (e ErrAmbiguousPath) Error() string {
+	return fmt.Sprintf("path %v is ambiguous in repo %v at commit %v, it matches %v", e.File.Path, e.File.Commit.Branch.Repo, e.File.Commit.ID, strings.Join(e.Matches, ", "))
+}
+
+func (e ErrPathReserved) Error() string {
+	return fmt.Sprintf("path %v in repo %v is reserved for %v (prefix %v)", e.Path, e.Repo, e.Owner, e.Prefix)
+}
+
+func (e ErrCommitRejected) Error() string {
+	return fmt.Sprintf("commit %v in repo %v was rejected by the repo's finish commit hook: %s", e.Commit.ID, e.Commit.Branch.Repo, e.Message)
+}
+
+func (e ErrCommitWaitTimeout) Error() string {
+	return fmt.Sprintf("commit %v in repo %v did not reach state %v within %v", e.Commit.ID, e.Commit.Branch.Repo, e.Wait, e.Timeout)
+}
+
+func (e ErrUploadNotFound) Error() string {
+	return fmt.Sprintf("upload %v not found", e.UploadID)
+}
+
+func (e ErrUploadExists) Error() string {
+	return fmt.Sprintf("upload %v already exists for commit %v in repo %v", e.UploadID, e.Commit.ID, e.Commit.Branch.Repo)
+}
+
+func (e ErrUnexpectedBranchHead) Error() string {
+	return fmt.Sprintf("head of branch %v in repo %v is %v, not the expected %v", e.Branch.Name, e.Branch.Repo, e.Head.ID, e.Expected.ID)
+}
+
+// GRPCStatus returns a DEADLINE_EXCEEDED status carrying the commit's current
+// CommitInfo as a detail, so that callers can tell a server-side wait timeout
+// apart from their own deadline.
+func (e ErrCommitWaitTimeout) GRPCStatus() *status.Status {
+	s := status.New(codes.DeadlineExceeded, e.Error())
+	if e.CommitInfo == nil {
+		return s
+	}
+	// status.WithDetails only knows golang/protobuf's registry, which doesn't
+	// contain our gogo types, so marshal the detail by hand.
+	detail, err := types.MarshalAny(e.CommitInfo)
+	if err != nil {
+		return s
+	}
+	p := s.Proto()
+	p.Details = append(p.Details, &any.Any{TypeUrl: detail.TypeUrl, Value: detail.Value})
+	return status.FromProto(p)
+}
+
 var (
 	commitNotFoundRe          = regexp.MustCompile("commit [^ ]+ not found in repo [^ ]+")
 	commitsetNotFoundRe       = regexp.MustCompile("no commits found for commitset")
 	commitDeletedRe           = regexp.MustCompile("commit [^ ]+ was deleted")
 	commitFinishedRe          = regexp.MustCompile("commit [^ ]+ in repo [^ ]+ has already finished")
-	repoNotFoundRe            = regexp.MustCompile(`repos [a-zA-Z0-9.\-_]{1,255} not found`)
-	repoExistsRe              = regexp.MustCompile(`repo ?[a-zA-Z0-9.\-_]{1,255} already exists`)
-	branchNotFoundRe          = regexp.MustCompile(`branches [a-zA-Z0-9.\-_@]{1,255} not found`)
+	projectNotFoundRe         = regexp.MustCompile(`project [a-zA-Z0-9\-_]{1,255} not found`)
+	projectExistsRe           = regexp.MustCompile(`project [a-zA-Z0-9\-_]{1,255} already exists`)
+	repoNotFoundRe            = regexp.MustCompile(`repos [a-zA-Z0-9.\-_/]{1,255} not found`)
+	repoExistsRe              = regexp.MustCompile(`repo ?[a-zA-Z0-9.\-_/]{1,255} already exists`)
+	repoReadOnlyRe            = regexp.MustCompile(`repo [a-zA-Z0-9.\-_/]{1,255} is read-only`)
+	repoLockedRe              = regexp.MustCompile(`repo [a-zA-Z0-9.\-_/]{1,255} is locked until`)
+	branchNotFoundRe          = regexp.MustCompile(`branches [a-zA-Z0-9.\-_@/]{1,255} not found`)
 	fileNotFoundRe            = regexp.MustCompile(`file .+ not found`)
 	outputCommitNotFinishedRe = regexp.MustCompile("output commit .+ not finished")
 	commitNotFinishedRe       = regexp.MustCompile("commit .+ not finished")
 	ambiguousCommitRe         = regexp.MustCompile("commit .+ is ambiguous")
 	inconsistentCommitRe      = regexp.MustCompile("branch already has a commit in this transaction")
 	commitOnOutputBranchRe    = regexp.MustCompile("cannot start a commit on an output branch")
+	fileTooLargeRe            = regexp.MustCompile(`file .+ exceeds the repo's limit of \d+ bytes`)
+	repoTooLargeRe            = regexp.MustCompile(`commit .+ in repo .+ is \d+ bytes, which exceeds the repo's size limit of \d+ bytes`)
+	tooManyFilesRe            = regexp.MustCompile(`commit .+ has more than \d+ files, which is the repo's limit`)
+	tooManyDirectoryEntriesRe = regexp.MustCompile(`directory .+ has more than \d+ entries, which is the repo's limit`)
+	ambiguousPathRe           = regexp.MustCompile(`path .+ is ambiguous in repo .+ at commit .+, it matches`)
+	pathReservedRe            = regexp.MustCompile(`path .+ in repo .+ is reserved for .+ \(prefix .+\)`)
+	commitWaitTimeoutRe       = regexp.MustCompile(`commit .+ in repo .+ did not reach state \w+ within`)
+	commitRejectedRe          = regexp.MustCompile(`commit .+ in repo .+ was rejected by the repo's finish commit hook`)
+	uploadNotFoundRe          = regexp.MustCompile(`upload .+ not found`)
+	uploadExistsRe            = regexp.MustCompile(`upload .+ already exists for commit`)
+	unexpectedBranchHeadRe    = regexp.MustCompile(`head of branch .+ is .+, not the expected`)
 )
 
 // IsCommitNotFoundErr returns true if 'err' has an error message that matches
@@ -205,6 +413,24 @@ func IsCommitFinishedErr(err error) bool {
 	return commitFinishedRe.MatchString(grpcutil.ScrubGRPC(err).Error())
 }
 
+// IsProjectNotFoundErr returns true if 'err' is an error message about a
+// project not being found
+func IsProjectNotFoundErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return projectNotFoundRe.MatchString(err.Error())
+}
+
+// IsProjectExistsErr returns true if 'err' is an error message about a
+// project existing
+func IsProjectExistsErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return projectExistsRe.MatchString(err.Error())
+}
+
 // IsRepoNotFoundErr returns true if 'err' is an error message about a repo
 // not being found
 func IsRepoNotFoundErr(err error) bool {
@@ -223,6 +449,15 @@ func IsRepoExistsErr(err error) bool {
 	return repoExistsRe.MatchString(err.Error())
 }
 
+// IsRepoReadOnlyErr returns true if 'err' is an error message about a write
+// to a read-only repo
+func IsRepoReadOnlyErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return repoReadOnlyRe.MatchString(err.Error())
+}
+
 // IsBranchNotFoundErr returns true if 'err' is an error message about a
 // branch not being found
 func IsBranchNotFoundErr(err error) bool {
@@ -286,3 +521,111 @@ func IsCommitOnOutputBranchErr(err error) bool {
 	}
 	return commitOnOutputBranchRe.MatchString(err.Error())
 }
+
+// IsFileTooLargeErr returns true if 'err' has an error message that matches
+// ErrFileTooLarge
+func IsFileTooLargeErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return fileTooLargeRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsRepoTooLargeErr returns true if 'err' has an error message that matches
+// ErrRepoTooLarge
+func IsRepoTooLargeErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return repoTooLargeRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsRepoLockedErr returns true if 'err' has an error message that matches
+// ErrRepoLocked
+func IsRepoLockedErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return repoLockedRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsTooManyFilesErr returns true if 'err' has an error message that matches
+// ErrTooManyFiles
+func IsTooManyFilesErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return tooManyFilesRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsTooManyDirectoryEntriesErr returns true if 'err' has an error message
+// that matches ErrTooManyDirectoryEntries
+func IsTooManyDirectoryEntriesErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return tooManyDirectoryEntriesRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsAmbiguousPathErr returns true if 'err' has an error message that matches
+// ErrAmbiguousPath
+func IsAmbiguousPathErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return ambiguousPathRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsPathReservedErr returns true if 'err' has an error message that matches
+// ErrPathReserved
+func IsPathReservedErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return pathReservedRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsCommitWaitTimeoutErr returns true if 'err' has an error message that
+// matches ErrCommitWaitTimeout
+func IsCommitWaitTimeoutErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return commitWaitTimeoutRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsCommitRejectedErr returns true if 'err' has an error message that matches
+// ErrCommitRejected
+func IsCommitRejectedErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return commitRejectedRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsUploadNotFoundErr returns true if 'err' has an error message that matches
+// ErrUploadNotFound
+func IsUploadNotFoundErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return uploadNotFoundRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsUploadExistsErr returns true if 'err' has an error message that matches
+// ErrUploadExists
+func IsUploadExistsErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return uploadExistsRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
+
+// IsUnexpectedBranchHeadErr returns true if 'err' has an error message that
+// matches ErrUnexpectedBranchHead
+func IsUnexpectedBranchHeadErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	return unexpectedBranchHeadRe.MatchString(grpcutil.ScrubGRPC(err).Error())
+}
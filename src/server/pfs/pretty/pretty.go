@@ -6,20 +6,26 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	units "github.com/docker/go-units"
 	"github.com/fatih/color"
+	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pretty"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 )
 
 const (
+	// ProjectHeader is the header for projects.
+	ProjectHeader = "NAME\tCREATED\tDESCRIPTION\t\n"
 	// RepoHeader is the header for repos.
 	RepoHeader = "NAME\tCREATED\tSIZE (MASTER)\tDESCRIPTION\t\n"
 	// RepoAuthHeader is the header for repos with auth information attached.
 	RepoAuthHeader = "NAME\tCREATED\tSIZE (MASTER)\tACCESS LEVEL\t\n"
 	// CommitHeader is the header for commits.
 	CommitHeader = "REPO\tBRANCH\tCOMMIT\tFINISHED\tSIZE\tDESCRIPTION\n"
+	// LineageHeader is the header for the commits in a commit's lineage.
+	LineageHeader = "DIRECTION\tDEPTH\t" + CommitHeader
 	// BranchHeader is the header for branches.
 	BranchHeader = "BRANCH\tHEAD\tTRIGGER\t\n"
 	// FileHeader is the header for files.
@@ -28,8 +34,29 @@ const (
 	FileHeaderWithCommit = "COMMIT\tNAME\tTAG\tTYPE\tCOMMITTED\tSIZE\t\n"
 	// DiffFileHeader is the header for files produced by diff file.
 	DiffFileHeader = "OP\t" + FileHeader
+	// DiffEntryHeader is the header for the changes produced by diff file
+	// --detect-renames.
+	DiffEntryHeader = "OP\tPATH\tSIZE DELTA\t\n"
+	// DirectorySizeHeader is the header for directory sizes produced by du.
+	DirectorySizeHeader = "SIZE\tENTRIES\tPATH\t\n"
+	// TagHeader is the header for tags.
+	TagHeader = "TAG\tFILES\tSIZE\t\n"
+	// StorageUsageHeader is the header for the storage usage of branches.
+	StorageUsageHeader = "REPO\tBRANCH\tLOGICAL SIZE\tPHYSICAL SIZE\tCHUNKS\t\n"
 )
 
+// PrintProjectInfo pretty-prints project info.
+func PrintProjectInfo(w io.Writer, projectInfo *pfs.ProjectInfo, fullTimestamps bool) {
+	fmt.Fprintf(w, "%s\t", projectInfo.Project)
+	if fullTimestamps {
+		fmt.Fprintf(w, "%s\t", projectInfo.Created.String())
+	} else {
+		fmt.Fprintf(w, "%s\t", pretty.Ago(projectInfo.Created))
+	}
+	fmt.Fprintf(w, "%s\t", projectInfo.Description)
+	fmt.Fprintln(w)
+}
+
 // PrintRepoInfo pretty-prints repo info.
 func PrintRepoInfo(w io.Writer, repoInfo *pfs.RepoInfo, fullTimestamps bool) {
 	fmt.Fprintf(w, "%s\t", repoInfo.Repo)
@@ -63,12 +90,25 @@ func NewPrintableRepoInfo(ri *pfs.RepoInfo) *PrintableRepoInfo {
 // PrintDetailedRepoInfo pretty-prints detailed repo info.
 func PrintDetailedRepoInfo(repoInfo *PrintableRepoInfo) error {
 	template, err := template.New("RepoInfo").Funcs(funcMap).Parse(
-		`Name: {{.Repo.Name}}{{if .Description}}
-Description: {{.Description}}{{end}}{{if .FullTimestamps}}
+		`Name: {{.Repo.Name}}{{if .Repo.Project}}
+Project: {{.Repo.Project.Name}}{{end}}{{if .Description}}
+Description: {{.Description}}{{end}}{{if .Labels}}
+Labels:{{range $k, $v := .Labels}} {{$k}}={{$v}}{{end}}{{end}}{{if .Mirror}}
+Mirror of: {{if .Mirror.SnapshotURL}}{{.Mirror.SnapshotURL}}{{else}}{{.Mirror.Source}} at {{.Mirror.Address}}{{end}}{{end}}{{if .FullTimestamps}}
 Created: {{.Created}}{{else}}
 Created: {{prettyAgo .Created}}{{end}}
 Size of HEAD on master: {{prettySize .SizeBytes}}{{if .AuthInfo}}
-Access level: {{ .AuthInfo.AccessLevel.String }}{{end}}
+Access level: {{ .AuthInfo.AccessLevel.String }}{{end}}{{with .Settings}}{{if .MaxSizeBytes}}
+Max size: {{prettySize .MaxSizeBytes}}{{end}}{{if .MaxFileSizeBytes}}
+Max file size: {{prettySize .MaxFileSizeBytes}}{{end}}{{if .MaxFilesPerCommit}}
+Max files per commit: {{.MaxFilesPerCommit}}{{end}}{{if .MaxDirectoryEntries}}
+Max directory entries: {{.MaxDirectoryEntries}}{{end}}{{if .FinishCommitHook}}
+Finish commit hook: {{.FinishCommitHook}}{{end}}{{if .ColdStorageAfter}}
+Cold storage after: {{prettyDuration .ColdStorageAfter}}{{end}}{{with .Compression}}
+Compression: {{printCompression .}}{{end}}{{if or .ChunkAverageBits .Chunking}}
+Chunking: {{printChunking .}}{{end}}{{with .Lock}}
+Locked: {{printLock .}}{{end}}{{end}}{{range .PathReservations}}
+Reserved: {{.Prefix}} for {{.Owner}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -91,6 +131,12 @@ func printTrigger(trigger *pfs.Trigger) string {
 	if trigger.Commits != 0 {
 		conds = append(conds, fmt.Sprintf("Commits(%d)", trigger.Commits))
 	}
+	if trigger.Files != 0 {
+		conds = append(conds, fmt.Sprintf("Files(%d)", trigger.Files))
+	}
+	if trigger.PathPattern != "" {
+		conds = append(conds, fmt.Sprintf("Path(%s)", trigger.PathPattern))
+	}
 	cond := ""
 	if trigger.All {
 		cond = strings.Join(conds, " and ")
@@ -100,6 +146,78 @@ func printTrigger(trigger *pfs.Trigger) string {
 	return fmt.Sprintf("%s on %s", trigger.Branch, cond)
 }
 
+func printStoragePolicy(policy *pfs.BranchStoragePolicy) string {
+	s := strings.ToLower(policy.StorageClass.String())
+	if policy.EagerGc {
+		s += ", eager GC"
+	}
+	return s
+}
+
+func printRetention(retention *pfs.BranchRetention) string {
+	var limits []string
+	if retention.MaxCommits > 0 {
+		limits = append(limits, fmt.Sprintf("%d commits", retention.MaxCommits))
+	}
+	if retention.MaxAge != nil {
+		limits = append(limits, pretty.Duration(retention.MaxAge))
+	}
+	return strings.Join(limits, ", ")
+}
+
+func printCompression(compression *pfs.ChunkCompression) string {
+	s := strings.ToLower(compression.Codec.String())
+	if compression.Level != 0 {
+		s += fmt.Sprintf(" (level %d)", compression.Level)
+	}
+	return s
+}
+
+func printChunking(settings *pfs.RepoSettings) string {
+	var parts []string
+	if settings.ChunkAverageBits != 0 {
+		parts = append(parts, "average "+units.BytesSize(float64(int64(1)<<uint(settings.ChunkAverageBits))))
+	}
+	if chunking := settings.Chunking; chunking != nil {
+		if chunking.MinSizeBytes != 0 {
+			parts = append(parts, "min "+units.BytesSize(float64(chunking.MinSizeBytes)))
+		}
+		if chunking.MaxSizeBytes != 0 {
+			parts = append(parts, "max "+units.BytesSize(float64(chunking.MaxSizeBytes)))
+		}
+		if chunking.WindowBytes != 0 {
+			parts = append(parts, fmt.Sprintf("window %dB", chunking.WindowBytes))
+		}
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func printLock(lock *pfs.RepoLock) string {
+	s := "until " + lock.Until.String()
+	if until, err := types.TimestampFromProto(lock.Until); err == nil {
+		s = "until " + until.Local().Format(time.RFC3339)
+	}
+	if lock.Reason != "" {
+		s += " (" + lock.Reason + ")"
+	}
+	if lock.BrokenAt != nil {
+		s += ", broken"
+		if lock.BrokenBy != "" {
+			s += " by " + lock.BrokenBy
+		}
+		if brokenAt, err := types.TimestampFromProto(lock.BrokenAt); err == nil {
+			s += " at " + brokenAt.Local().Format(time.RFC3339)
+		}
+		if lock.BreakReason != "" {
+			s += " (" + lock.BreakReason + ")"
+		}
+	}
+	return s
+}
+
 // PrintBranch pretty-prints a Branch.
 func PrintBranch(w io.Writer, branchInfo *pfs.BranchInfo) {
 	fmt.Fprintf(w, "%s\t", branchInfo.Branch.Name)
@@ -122,7 +240,13 @@ func PrintDetailedBranchInfo(branchInfo *pfs.BranchInfo) error {
 		`Name: {{.Branch.Repo.Name}}@{{.Branch.Name}}{{if .Head}}
 Head Commit: {{ .Head.Branch.Repo.Name}}@{{.Head.ID}} {{end}}{{if .Provenance}}
 Provenance: {{range .Provenance}} {{.Repo.Name}}@{{.Name}} {{end}} {{end}}{{if .Trigger}}
-Trigger: {{printTrigger .Trigger}} {{end}}
+Trigger: {{printTrigger .Trigger}} {{end}}{{with .StoragePolicy}}
+Storage Policy: {{printStoragePolicy .}} {{end}}{{with .Retention}}
+Retention: {{printRetention .}} {{end}}{{with .Stats}}
+Commits: {{.Commits}}{{if .LastCommit}}
+Last Commit: {{prettyAgo .LastCommit}}{{end}}
+Size: {{prettySize .SizeBytes}}{{with .Trigger}}
+Trigger Status: {{if .Pending}}pending{{else}}up to date{{end}}{{if .LastTriggered}}, last triggered {{prettyAgo .LastTriggered}}{{end}}{{end}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -134,6 +258,92 @@ Trigger: {{printTrigger .Trigger}} {{end}}
 	return nil
 }
 
+// PrintDetailedTriggerInfo pretty-prints the state of a branch's trigger.
+func PrintDetailedTriggerInfo(w io.Writer, info *pfs.TriggerInfo) {
+	fmt.Fprintf(w, "Branch: %s@%s\n", info.Branch.Repo, info.Branch.Name)
+	fmt.Fprintf(w, "Trigger: %s\n", printTrigger(info.Trigger))
+	if status := info.Status; status != nil {
+		if status.Head != nil {
+			fmt.Fprintf(w, "Trigger Head: %s\n", status.Head.ID)
+		}
+		fmt.Fprint(w, "Status: ")
+		if status.Pending {
+			fmt.Fprint(w, "pending")
+		} else {
+			fmt.Fprint(w, "up to date")
+		}
+		if status.LastTriggered != nil {
+			fmt.Fprintf(w, ", last triggered %s", pretty.Ago(status.LastTriggered))
+		}
+		fmt.Fprintln(w)
+	}
+	if !info.Status.GetPending() {
+		return
+	}
+	t := info.Trigger
+	if t.Size_ != "" {
+		fmt.Fprintf(w, "Size: %s of %s\n", units.BytesSize(float64(info.SizeBytes)), t.Size_)
+	}
+	if t.Commits != 0 {
+		fmt.Fprintf(w, "Commits: %d of %d\n", info.Commits, t.Commits)
+	}
+	if t.Files != 0 {
+		fmt.Fprintf(w, "Files: %d of %d\n", info.Files, t.Files)
+	}
+	if t.PathPattern != "" {
+		fmt.Fprintf(w, "Path Matched: %t\n", info.PathMatched)
+	}
+	if info.NextCron != nil {
+		fmt.Fprintf(w, "Next Cron: %s\n", types.TimestampString(info.NextCron))
+	}
+	fmt.Fprintf(w, "Ready: %t\n", info.Ready)
+}
+
+// PrintBranchStorageUsage pretty-prints the storage usage of a branch.
+func PrintBranchStorageUsage(w io.Writer, usage *pfs.BranchStorageUsage) {
+	fmt.Fprintf(w, "%s\t", usage.Branch.Repo)
+	fmt.Fprintf(w, "%s\t", usage.Branch.Name)
+	fmt.Fprintf(w, "%s\t", units.BytesSize(float64(usage.LogicalBytes)))
+	fmt.Fprintf(w, "%s\t", units.BytesSize(float64(usage.PhysicalBytes)))
+	fmt.Fprintf(w, "%d\t", usage.Chunks)
+	fmt.Fprintln(w)
+}
+
+// PrintCommitDedupReport pretty-prints a comparison of the chunks that two
+// commits reference.
+func PrintCommitDedupReport(w io.Writer, report *pfs.CommitDedupReport) {
+	fmt.Fprintf(w, "Logical size: %s in %s, %s in %s\n",
+		units.BytesSize(float64(report.ALogicalBytes)), report.A,
+		units.BytesSize(float64(report.BLogicalBytes)), report.B)
+	fmt.Fprintf(w, "Shared: %s in %d chunks\n", units.BytesSize(float64(report.Shared.Bytes)), report.Shared.Chunks)
+	fmt.Fprintf(w, "Only in %s: %s in %d chunks\n", report.A, units.BytesSize(float64(report.OnlyA.Bytes)), report.OnlyA.Chunks)
+	fmt.Fprintf(w, "Only in %s: %s in %d chunks\n", report.B, units.BytesSize(float64(report.OnlyB.Bytes)), report.OnlyB.Chunks)
+}
+
+// PrintGarbageCollectionRun pretty-prints what a garbage collection run did.
+func PrintGarbageCollectionRun(w io.Writer, run *pfs.GarbageCollectionRun) {
+	kind := "Scheduled"
+	if run.Manual {
+		kind = "Manual"
+	}
+	fmt.Fprintf(w, "%s run %s, took %s\n", kind, pretty.Ago(run.Started), pretty.TimeDifference(run.Started, run.Finished))
+	fmt.Fprintf(w, "Objects deleted: %d\n", run.ObjectsDeleted)
+	fmt.Fprintf(w, "Chunks deleted: %d\n", run.ChunksDeleted)
+	fmt.Fprintf(w, "Reclaimed: %s\n", pretty.Size(uint64(run.BytesReclaimed)))
+}
+
+// PrintGarbageCollectionStats pretty-prints garbage collection stats.
+func PrintGarbageCollectionStats(w io.Writer, stats *pfs.GarbageCollectionStats) {
+	fmt.Fprintf(w, "Reclaimable: %s in %d chunks\n", pretty.Size(uint64(stats.ReclaimableBytes)), stats.ReclaimableChunks)
+	fmt.Fprintf(w, "Reclaimed in the last day: %s\n", pretty.Size(uint64(stats.BytesReclaimedLastDay)))
+	if stats.LastRun == nil {
+		fmt.Fprintf(w, "Last run: none in the last day\n")
+		return
+	}
+	fmt.Fprintf(w, "Last run: ")
+	PrintGarbageCollectionRun(w, stats.LastRun)
+}
+
 // PrintCommitInfo pretty-prints commit info.
 func PrintCommitInfo(w io.Writer, commitInfo *pfs.CommitInfo, fullTimestamps bool) {
 	fmt.Fprintf(w, "%s\t", commitInfo.Commit.Branch.Repo)
@@ -157,6 +367,14 @@ func PrintCommitInfo(w io.Writer, commitInfo *pfs.CommitInfo, fullTimestamps boo
 	fmt.Fprintln(w)
 }
 
+// PrintLineageCommit pretty-prints a commit in another commit's lineage, in
+// direction (upstream or downstream) from it.
+func PrintLineageCommit(w io.Writer, direction string, lineageCommit *pfs.LineageCommit, fullTimestamps bool) {
+	fmt.Fprintf(w, "%s\t", direction)
+	fmt.Fprintf(w, "%d\t", lineageCommit.Depth)
+	PrintCommitInfo(w, lineageCommit.CommitInfo, fullTimestamps)
+}
+
 // PrintableCommitInfo is a wrapper around CommitInfo containing any formatting options
 // used within the template to conditionally print information.
 type PrintableCommitInfo struct {
@@ -171,18 +389,45 @@ func NewPrintableCommitInfo(ci *pfs.CommitInfo) *PrintableCommitInfo {
 	}
 }
 
+// PrintCommitEvent prints a single line for a commit from watch commits,
+// with the time the commit was started or finished.
+func PrintCommitEvent(w io.Writer, commitInfo *pfs.CommitInfo) {
+	event, at := "started", commitInfo.Started
+	if commitInfo.Finished != nil {
+		event, at = "finished", commitInfo.Finished
+	}
+	var when string
+	if t, err := types.TimestampFromProto(at); err == nil {
+		when = t.Local().Format(time.RFC3339)
+	}
+	fmt.Fprintf(w, "%s %s %s %s %s\n", when, commitInfo.Commit.Branch, commitInfo.Commit.ID, event, units.BytesSize(float64(commitInfo.SizeBytes)))
+}
+
 // PrintDetailedCommitInfo pretty-prints detailed commit info.
 func PrintDetailedCommitInfo(w io.Writer, commitInfo *PrintableCommitInfo) error {
 	template, err := template.New("CommitInfo").Funcs(funcMap).Parse(
 		`Commit: {{.Commit.Branch.Repo.Name}}@{{.Commit.ID}}
 Original Branch: {{.Commit.Branch.Name}}{{if .Description}}
-Description: {{.Description}}{{end}}{{if .ParentCommit}}
-Parent: {{.ParentCommit.ID}}{{end}}{{if .FullTimestamps}}
+Description: {{.Description}}{{end}}{{if .Labels}}
+Labels:{{range $k, $v := .Labels}} {{$k}}={{$v}}{{end}}{{end}}{{if .ParentCommit}}
+Parent: {{.ParentCommit.ID}}{{end}}{{with .MergeParent}}
+Merge Parent: {{.Branch.Name}}@{{.ID}}{{end}}{{if .FullTimestamps}}
 Started: {{.Started}}{{else}}
 Started: {{prettyAgo .Started}}{{end}}{{if .Finished}}{{if .FullTimestamps}}
 Finished: {{.Finished}}{{else}}
-Finished: {{prettyAgo .Finished}}{{end}}{{end}}
-Size: {{prettySize .SizeBytes}}
+Finished: {{prettyAgo .Finished}}{{end}}{{end}}{{if .Recalled}}{{if .FullTimestamps}}
+Recalled: {{.Recalled}}{{else}}
+Recalled: {{prettyAgo .Recalled}}{{end}}{{end}}{{if .Archived}}{{if .FullTimestamps}}
+Archived: {{.Archived}}{{else}}
+Archived: {{prettyAgo .Archived}}{{end}}{{end}}{{if .Locked}}
+Locked: true{{end}}
+Size: {{prettySize .SizeBytes}}{{with .Details}}
+Diff Filesets: {{range $i, $id := .DiffFilesetIds}}{{if $i}}, {{end}}{{$id}}{{else}}none{{end}}
+Total Fileset: {{if .TotalFilesetId}}{{.TotalFilesetId}}{{else}}not computed{{end}}
+Layers: {{.Layers}}
+Compacted: {{.Compacted}}
+Chunks: {{.ChunkCount}}
+Index Size: {{prettySize .IndexSizeBytes}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -217,6 +462,22 @@ func PrintFileInfo(w io.Writer, fileInfo *pfs.FileInfo, fullTimestamps, withComm
 	fmt.Fprintln(w)
 }
 
+// PrintDirectorySize pretty-prints the size of a directory from du.
+func PrintDirectorySize(w io.Writer, fileInfo *pfs.FileInfo) {
+	fmt.Fprintf(w, "%s\t", units.BytesSize(float64(fileInfo.SizeBytes)))
+	fmt.Fprintf(w, "%d\t", fileInfo.NumDescendants)
+	fmt.Fprintf(w, "%s\t", fileInfo.File.Path)
+	fmt.Fprintln(w)
+}
+
+// PrintTagInfo pretty-prints the files written with a tag.
+func PrintTagInfo(w io.Writer, tagInfo *pfs.TagInfo) {
+	fmt.Fprintf(w, "%s\t", tagInfo.Tag)
+	fmt.Fprintf(w, "%d\t", tagInfo.NumFiles)
+	fmt.Fprintf(w, "%s\t", units.BytesSize(float64(tagInfo.SizeBytes)))
+	fmt.Fprintln(w)
+}
+
 // PrintDiffFileInfo pretty-prints a file info from diff file.
 func PrintDiffFileInfo(w io.Writer, added bool, fileInfo *pfs.FileInfo, fullTimestamps bool) {
 	if added {
@@ -227,13 +488,49 @@ func PrintDiffFileInfo(w io.Writer, added bool, fileInfo *pfs.FileInfo, fullTime
 	PrintFileInfo(w, fileInfo, fullTimestamps, false)
 }
 
+// PrintDiffEntry pretty-prints a change from diff file --detect-renames.
+func PrintDiffEntry(w io.Writer, entry *pfs.DiffEntry) {
+	switch entry.Type {
+	case pfs.FileChangeType_ADDED:
+		fmt.Fprint(w, color.GreenString("added\t"))
+	case pfs.FileChangeType_DELETED:
+		fmt.Fprint(w, color.RedString("deleted\t"))
+	case pfs.FileChangeType_RENAMED:
+		fmt.Fprint(w, color.YellowString("renamed\t"))
+	default:
+		fmt.Fprint(w, "modified\t")
+	}
+	if entry.Type == pfs.FileChangeType_RENAMED {
+		fmt.Fprintf(w, "%s -> %s\t", entry.OldPath, entry.Path)
+	} else {
+		fmt.Fprintf(w, "%s\t", entry.Path)
+	}
+	if entry.SizeDelta < 0 {
+		fmt.Fprintf(w, "-%s\t", units.BytesSize(float64(-entry.SizeDelta)))
+	} else {
+		fmt.Fprintf(w, "+%s\t", units.BytesSize(float64(entry.SizeDelta)))
+	}
+	fmt.Fprintln(w)
+}
+
 // PrintDetailedFileInfo pretty-prints detailed file info.
 func PrintDetailedFileInfo(fileInfo *pfs.FileInfo) error {
 	template, err := template.New("FileInfo").Funcs(funcMap).Parse(
 		`Path: {{.File.Path}}
 Tag: {{.File.Tag}}
 Type: {{fileType .FileType}}
-Size: {{prettySize .SizeBytes}}
+Size: {{prettySize .SizeBytes}}{{if .Hash}}
+Hash: {{printf "%x" .Hash}}{{end}}{{if .LinkTarget}}
+Link Target: {{.LinkTarget}}{{end}}{{if .Metadata}}
+Metadata:{{range $k, $v := .Metadata}} {{$k}}={{$v}}{{end}}{{end}}{{with .CopiedFrom}}
+Copied From: {{.Commit}}:{{.Path}}{{end}}{{if .Mode}}
+Mode: {{fileMode .Mode}}{{end}}{{if .Mtime}}
+Modified: {{prettyAgo .Mtime}}{{end}}{{if .NumChildren}}
+Children: {{.NumChildren}}
+Descendants: {{.NumDescendants}}
+Files: {{.NumFiles}}
+Directories: {{.NumDirs}}{{if .NewestMtime}}
+Newest Modified: {{prettyAgo .NewestMtime}}{{end}}{{end}}
 `)
 	if err != nil {
 		return err
@@ -248,11 +545,22 @@ func fileType(fileType pfs.FileType) string {
 	return "dir"
 }
 
+func fileMode(mode uint32) string {
+	return os.FileMode(mode).Perm().String()
+}
+
 var funcMap = template.FuncMap{
-	"prettyAgo":    pretty.Ago,
-	"prettySize":   pretty.Size,
-	"fileType":     fileType,
-	"printTrigger": printTrigger,
+	"prettyAgo":          pretty.Ago,
+	"prettySize":         pretty.Size,
+	"prettyDuration":     pretty.Duration,
+	"fileType":           fileType,
+	"fileMode":           fileMode,
+	"printTrigger":       printTrigger,
+	"printStoragePolicy": printStoragePolicy,
+	"printRetention":     printRetention,
+	"printLock":          printLock,
+	"printCompression":   printCompression,
+	"printChunking":      printChunking,
 }
 
 // CompactPrintCommit renders 'c' as a compact string, e.g.
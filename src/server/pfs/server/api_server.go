@@ -2,19 +2,23 @@ package server
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/log"
 	"github.com/pachyderm/pachyderm/v2/src/internal/miscutil"
@@ -44,6 +48,9 @@ type apiServer struct {
 
 	// env generates clients for pachyderm's downstream services
 	env serviceenv.ServiceEnv
+
+	// uploads limits concurrent ModifyFile and CreateFileSet streams
+	uploads *uploadLimiter
 }
 
 func newAPIServer(env serviceenv.ServiceEnv, txnEnv *txnenv.TransactionEnv, etcdPrefix string) (*apiServer, error) {
@@ -56,6 +63,11 @@ func newAPIServer(env serviceenv.ServiceEnv, txnEnv *txnenv.TransactionEnv, etcd
 		driver: d,
 		env:    env,
 		txnEnv: txnEnv,
+		uploads: newUploadLimiter(
+			env.Config().UploadMaxStreams,
+			env.Config().UploadMaxStreamsPerUser,
+			env.Config().UploadMaxInFlightBytes,
+		),
 	}
 	return s, nil
 }
@@ -78,7 +90,10 @@ func (a *apiServer) CreateRepoInTransaction(txnCtx *txncontext.TransactionContex
 	if repo := request.GetRepo(); repo != nil && repo.Name == fileSetsRepo {
 		return errors.Errorf("%s is a reserved name", fileSetsRepo)
 	}
-	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update)
+	if request.Template != nil {
+		return a.driver.createRepoFromTemplate(txnCtx, request)
+	}
+	return a.driver.createRepo(txnCtx, request.Repo, request.Description, request.Update, request.Mirror, request.Settings, request.Labels)
 }
 
 // CreateRepo implements the protobuf pfs.CreateRepo RPC
@@ -125,13 +140,20 @@ func (a *apiServer) InspectRepo(ctx context.Context, request *pfs.InspectRepoReq
 func (a *apiServer) ListRepo(ctx context.Context, request *pfs.ListRepoRequest) (response *pfs.ListRepoResponse, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-	repoInfos, err := a.driver.listRepo(ctx, true, request.Type)
+	repoInfos, err := a.driver.listRepo(ctx, true, request)
 	return repoInfos, err
 }
 
 // DeleteRepoInTransaction is identical to DeleteRepo except that it can run
 // inside an existing postgres transaction.  This is not an RPC.
 func (a *apiServer) DeleteRepoInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.DeleteRepoRequest) error {
+	if len(request.Labels) > 0 {
+		if request.Repo != nil {
+			return errors.Errorf("repo cannot be combined with labels")
+		}
+		_, err := a.driver.deleteRepos(txnCtx, nil, request.Labels, request.Force)
+		return err
+	}
 	return a.driver.deleteRepo(txnCtx, request.Repo, request.Force)
 }
 
@@ -147,10 +169,118 @@ func (a *apiServer) DeleteRepo(ctx context.Context, request *pfs.DeleteRepoReque
 	return &types.Empty{}, nil
 }
 
+// BreakRepoLock implements the protobuf pfs.BreakRepoLock RPC
+func (a *apiServer) BreakRepoLock(ctx context.Context, request *pfs.BreakRepoLockRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.breakRepoLock(txnCtx, request.Repo, request.Reason)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// DeleteRepos implements the protobuf pfs.DeleteRepos RPC
+func (a *apiServer) DeleteRepos(ctx context.Context, request *pfs.DeleteReposRequest) (response *pfs.DeleteReposResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	withContext := a.txnEnv.WithWriteContext
+	if request.DryRun {
+		withContext = a.txnEnv.WithReadContext
+	}
+	response = &pfs.DeleteReposResponse{}
+	if err := withContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		response.Repos, err = a.driver.deleteRepos(txnCtx, request.Repos, request.Labels, request.Force)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ReservePath implements the protobuf pfs.ReservePath RPC
+func (a *apiServer) ReservePath(ctx context.Context, request *pfs.ReservePathRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.reservePath(txnCtx, request.Repo, request.Prefix, request.Owner)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// ReleasePath implements the protobuf pfs.ReleasePath RPC
+func (a *apiServer) ReleasePath(ctx context.Context, request *pfs.ReleasePathRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.releasePath(txnCtx, request.Repo, request.Prefix)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// CreateProject implements the protobuf pfs.CreateProject RPC
+func (a *apiServer) CreateProject(ctx context.Context, request *pfs.CreateProjectRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.createProject(txnCtx, request.Project, request.Description, request.Update, request.Defaults)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// InspectProject implements the protobuf pfs.InspectProject RPC
+func (a *apiServer) InspectProject(ctx context.Context, request *pfs.InspectProjectRequest) (response *pfs.ProjectInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	var info *pfs.ProjectInfo
+	if err := a.txnEnv.WithReadContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		info, err = a.driver.inspectProject(txnCtx, request.Project)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ListProject implements the protobuf pfs.ListProject RPC
+func (a *apiServer) ListProject(ctx context.Context, request *pfs.ListProjectRequest) (response *pfs.ListProjectResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithReadContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		response, err = a.driver.listProject(txnCtx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// DeleteProject implements the protobuf pfs.DeleteProject RPC
+func (a *apiServer) DeleteProject(ctx context.Context, request *pfs.DeleteProjectRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.deleteProject(txnCtx, request.Project, request.Force)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 // StartCommitInTransaction is identical to StartCommit except that it can run
 // inside an existing postgres transaction.  This is not an RPC.
 func (a *apiServer) StartCommitInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.StartCommitRequest) (*pfs.Commit, error) {
-	return a.driver.startCommit(txnCtx, request.Parent, request.Branch, request.Description)
+	return a.driver.startCommit(txnCtx, request.Parent, request.Branch, request.Description, request.Labels)
 }
 
 // StartCommit implements the protobuf pfs.StartCommit RPC
@@ -175,7 +305,7 @@ func (a *apiServer) FinishCommitInTransaction(txnCtx *txncontext.TransactionCont
 		if request.Empty {
 			request.Description += pfs.EmptyStr
 		}
-		return a.driver.finishCommit(txnCtx, request.Commit, request.Description)
+		return a.driver.finishCommit(txnCtx, request.Commit, request.Description, request.Labels)
 	})
 }
 
@@ -214,7 +344,29 @@ func (a *apiServer) InspectCommitInTransaction(txnCtx *txncontext.TransactionCon
 func (a *apiServer) InspectCommit(ctx context.Context, request *pfs.InspectCommitRequest) (response *pfs.CommitInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-	return a.driver.inspectCommit(ctx, request.Commit, request.Wait)
+	var err error
+	if request.WaitTimeout != nil {
+		var timeout time.Duration
+		if timeout, err = types.DurationFromProto(request.WaitTimeout); err != nil {
+			return nil, err
+		}
+		response, err = a.driver.inspectCommitWithTimeout(ctx, request.Commit, request.Wait, timeout)
+	} else {
+		response, err = a.driver.inspectCommit(ctx, request.Commit, request.Wait)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.Locked, err = a.driver.isCommitLocked(ctx, response); err != nil {
+		return nil, err
+	}
+	if !request.Details {
+		return response, nil
+	}
+	if response.Details, err = a.driver.commitDetails(ctx, response); err != nil {
+		return nil, err
+	}
+	return response, nil
 }
 
 // ListCommit implements the protobuf pfs.ListCommit RPC
@@ -224,10 +376,17 @@ func (a *apiServer) ListCommit(request *pfs.ListCommitRequest, respServer pfs.AP
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("stream containing %d commits", sent), retErr, time.Since(start))
 	}(time.Now())
-	return a.driver.listCommit(respServer.Context(), request.Repo, request.To, request.From, request.Number, request.Reverse, func(ci *pfs.CommitInfo) error {
+	pg := newPager(request.PageToken, request.PageSize, false)
+	if err := a.driver.listCommit(respServer.Context(), request.Repo, request.To, request.From, request.Number, request.Reverse, newCommitFilter(request), func(ci *pfs.CommitInfo) error {
+		if ok, err := pg.add(ci.Commit.String()); !ok {
+			return err
+		}
 		sent++
 		return respServer.Send(ci)
-	})
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return err
+	}
+	return pg.check()
 }
 
 // InspectCommitSetInTransaction performs the same job as InspectCommitSet
@@ -244,9 +403,25 @@ func (a *apiServer) InspectCommitSet(request *pfs.InspectCommitSetRequest, serve
 	return a.driver.inspectCommitSet(server.Context(), request.CommitSet, request.Wait, server.Send)
 }
 
+// CommitLineage implements the protobuf pfs.CommitLineage RPC
+func (a *apiServer) CommitLineage(ctx context.Context, request *pfs.CommitLineageRequest) (response *pfs.CommitLineageResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.commitLineage(ctx, request.Commit, request.UpstreamDepth, request.DownstreamDepth)
+}
+
 // SquashCommitSetInTransaction is identical to SquashCommitSet except that it can run
 // inside an existing postgres transaction.  This is not an RPC.
 func (a *apiServer) SquashCommitSetInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.SquashCommitSetRequest) error {
+	commitInfos, err := a.driver.inspectCommitSetImmediate(txnCtx, request.CommitSet)
+	if err != nil {
+		return err
+	}
+	for _, commitInfo := range commitInfos {
+		if err := a.driver.checkRepoWritable(txnCtx, commitInfo.Commit.Branch.Repo); err != nil {
+			return err
+		}
+	}
 	return a.driver.squashCommitSet(txnCtx, request.CommitSet)
 }
 
@@ -266,20 +441,76 @@ func (a *apiServer) SquashCommitSet(ctx context.Context, request *pfs.SquashComm
 func (a *apiServer) SubscribeCommit(request *pfs.SubscribeCommitRequest, stream pfs.API_SubscribeCommitServer) (retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
-	return a.driver.subscribeCommit(stream.Context(), request.Repo, request.Branch, request.From, request.State, stream.Send)
+	if len(request.Repos) > 0 && (request.Repo != nil || request.From != nil || request.Cursor != "") {
+		return errors.Errorf("repos cannot be combined with repo, from or cursor")
+	}
+	filter, err := newSubscribeFilter(request)
+	if err != nil {
+		return err
+	}
+	var interval time.Duration
+	if request.HeartbeatInterval != nil {
+		if interval, err = types.DurationFromProto(request.HeartbeatInterval); err != nil {
+			return err
+		}
+	}
+	heartbeat := func() error { return stream.Send(&pfs.CommitInfo{}) }
+	return grpcutil.Heartbeat(stream.Context(), interval, heartbeat, func(ctx context.Context, mu sync.Locker) error {
+		send := func(ci *pfs.CommitInfo) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return stream.Send(ci)
+		}
+		if len(request.Repos) > 0 {
+			return a.driver.subscribeCommits(ctx, request.Repos, filter, request.Cursors, request.State, send)
+		}
+		return a.driver.subscribeCommit(ctx, request.Repo, filter, request.From, request.Cursor, request.State, send)
+	})
+}
+
+// CherryPickCommit implements the protobuf pfs.CherryPickCommit RPC
+func (a *apiServer) CherryPickCommit(ctx context.Context, request *pfs.CherryPickCommitRequest) (response *pfs.Commit, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.cherryPickCommit(ctx, request.Commit, request.Branch, request.Description)
 }
 
 // ClearCommit deletes all data in the commit.
 func (a *apiServer) ClearCommit(ctx context.Context, request *pfs.ClearCommitRequest) (_ *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
-	return &types.Empty{}, a.driver.clearCommit(ctx, request.Commit)
+	return &types.Empty{}, a.driver.clearCommit(ctx, request.Commit, request.Paths)
+}
+
+// ArchiveCommit implements the protobuf pfs.ArchiveCommit RPC
+func (a *apiServer) ArchiveCommit(ctx context.Context, request *pfs.ArchiveCommitRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.archiveCommit(txnCtx, request.Commit, !request.Unarchive)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// RecallCommit implements the protobuf pfs.RecallCommit RPC
+func (a *apiServer) RecallCommit(ctx context.Context, request *pfs.RecallCommitRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.driver.recallCommit(ctx, request.Commit); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
 }
 
 // CreateBranchInTransaction is identical to CreateBranch except that it can run
 // inside an existing postgres transaction.  This is not an RPC.
 func (a *apiServer) CreateBranchInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.CreateBranchRequest) error {
-	return a.driver.createBranch(txnCtx, request.Branch, request.Head, request.Provenance, request.Trigger)
+	if err := a.driver.checkRepoWritable(txnCtx, request.Branch.Repo); err != nil {
+		return err
+	}
+	return a.driver.createBranch(txnCtx, request.Branch, request.Head, request.Provenance, request.Trigger, request.StoragePolicy, request.Retention)
 }
 
 // CreateBranch implements the protobuf pfs.CreateBranch RPC
@@ -318,13 +549,25 @@ func (a *apiServer) InspectBranch(ctx context.Context, request *pfs.InspectBranc
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 	branchInfo := &pfs.BranchInfo{}
+	var headInfo *pfs.CommitInfo
 	if err := a.txnEnv.WithReadContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
 		var err error
 		branchInfo, err = a.driver.inspectBranch(txnCtx, request.Branch)
+		if err != nil {
+			return err
+		}
+		branchInfo.Stats, headInfo, err = a.driver.branchStats(txnCtx, branchInfo)
 		return err
 	}); err != nil {
 		return nil, err
 	}
+	if headInfo != nil && headInfo.Finished != nil {
+		size, err := a.driver.sizeOfCommit(ctx, headInfo.Commit)
+		if err != nil {
+			return nil, err
+		}
+		branchInfo.Stats.SizeBytes = uint64(size)
+	}
 	return branchInfo, nil
 }
 
@@ -340,12 +583,32 @@ func (a *apiServer) ListBranch(ctx context.Context, request *pfs.ListBranchReque
 	if err != nil {
 		return nil, err
 	}
-	return &pfs.BranchInfos{BranchInfo: branches}, nil
+	if request.PageToken == "" && request.PageSize <= 0 {
+		return &pfs.BranchInfos{BranchInfo: branches}, nil
+	}
+	pg := newPager(request.PageToken, request.PageSize, false)
+	var page []*pfs.BranchInfo
+	for _, bi := range branches {
+		ok, err := pg.add(bi.Branch.String())
+		if err != nil {
+			break
+		}
+		if ok {
+			page = append(page, bi)
+		}
+	}
+	if err := pg.check(); err != nil {
+		return nil, err
+	}
+	return &pfs.BranchInfos{BranchInfo: page}, nil
 }
 
 // DeleteBranchInTransaction is identical to DeleteBranch except that it can run
 // inside an existing postgres transaction.  This is not an RPC.
 func (a *apiServer) DeleteBranchInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.DeleteBranchRequest) error {
+	if err := a.driver.checkRepoWritable(txnCtx, request.Branch.Repo); err != nil {
+		return err
+	}
 	return a.driver.deleteBranch(txnCtx, request.Branch, request.Force)
 }
 
@@ -361,6 +624,88 @@ func (a *apiServer) DeleteBranch(ctx context.Context, request *pfs.DeleteBranchR
 	return &types.Empty{}, nil
 }
 
+// PromoteBranchInTransaction is identical to PromoteBranch except that it can
+// run inside an existing postgres transaction.  This is not an RPC.
+func (a *apiServer) PromoteBranchInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.PromoteBranchRequest) error {
+	return a.driver.promoteBranch(txnCtx, request.From, request.To, request.ExpectedHead)
+}
+
+// PromoteBranch implements the protobuf pfs.PromoteBranch RPC
+func (a *apiServer) PromoteBranch(ctx context.Context, request *pfs.PromoteBranchRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithTransaction(ctx, func(txn txnenv.Transaction) error {
+		return txn.PromoteBranch(request)
+	}, func(txnCtx *txncontext.TransactionContext) (string, error) {
+		if request.NewCommitSet {
+			return "", nil
+		}
+		// As with CreateBranch, the promoted head is put in the CommitSet of
+		// the head it aliases, so that promoting a staging branch doesn't
+		// make a new CommitSet. The request is checked first, so that from
+		// isn't read unless the promotion is allowed.
+		if _, err := a.driver.checkPromoteBranch(txnCtx, request.From, request.To); err != nil {
+			return "", err
+		}
+		head, err := a.driver.promotedHead(txnCtx, request.From)
+		if err != nil {
+			return "", err
+		}
+		return head.Commit.ID, nil
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// MergeBranch implements the protobuf pfs.MergeBranch RPC
+func (a *apiServer) MergeBranch(ctx context.Context, request *pfs.MergeBranchRequest) (response *pfs.MergeBranchResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.mergeBranch(ctx, request.From, request.Into, request.Description)
+}
+
+// InspectTrigger implements the protobuf pfs.InspectTrigger RPC
+func (a *apiServer) InspectTrigger(ctx context.Context, request *pfs.InspectTriggerRequest) (response *pfs.TriggerInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithReadContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		response, _, err = a.driver.inspectTrigger(txnCtx, request.Branch)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RunTrigger implements the protobuf pfs.RunTrigger RPC
+func (a *apiServer) RunTrigger(ctx context.Context, request *pfs.RunTriggerRequest) (response *pfs.RunTriggerResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		response, err = a.driver.runTrigger(txnCtx, request.Branch, request.Force)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// WatchBranch implements the protobuf pfs.WatchBranch RPC
+func (a *apiServer) WatchBranch(request *pfs.WatchBranchRequest, server pfs.API_WatchBranchServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.watchBranch(server.Context(), request.Branch, func(change *pfs.BranchHeadChange) error {
+		sent++
+		return server.Send(change)
+	})
+}
+
 func (a *apiServer) ModifyFile(server pfs.API_ModifyFileServer) (retErr error) {
 	commit, err := readCommit(server)
 	if err != nil {
@@ -368,30 +713,79 @@ func (a *apiServer) ModifyFile(server pfs.API_ModifyFileServer) (retErr error) {
 	}
 	func() { a.Log(commit, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(commit, nil, retErr, time.Since(start)) }(time.Now())
+	release, err := a.startUpload(server.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+	bufferOpt, releaseBuffer := a.uploads.bufferOption()
+	defer releaseBuffer()
 	return metrics.ReportRequestWithThroughput(func() (int64, error) {
 		var bytesRead int64
 		if err := a.driver.modifyFile(server.Context(), commit, func(uw *fileset.UnorderedWriter) error {
-			n, err := a.modifyFile(server.Context(), uw, server)
+			n, err := a.modifyFile(server.Context(), uw, commit, server)
 			if err != nil {
 				return err
 			}
 			bytesRead += n
 			return nil
-		}); err != nil {
+		}, bufferOpt); err != nil {
 			return bytesRead, err
 		}
 		return bytesRead, server.SendAndClose(&types.Empty{})
 	})
 }
 
+// CopyFile implements the protobuf pfs.CopyFile RPC
+func (a *apiServer) CopyFile(ctx context.Context, request *pfs.CopyFileRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	cf := request.CopyFile
+	if err := a.driver.modifyFile(ctx, request.Commit, func(uw *fileset.UnorderedWriter) error {
+		return a.driver.copyFile(ctx, uw, cf.Dst, cf.Src, cf.OffsetBytes, cf.SizeBytes, cf.Append, cf.Tag)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 type modifyFileSource interface {
 	Recv() (*pfs.ModifyFileRequest, error)
 }
 
 // modifyFile reads from a modifyFileSource until io.EOF and writes changes to an UnorderedWriter.
-// SetCommit messages will result in an error.
-func (a *apiServer) modifyFile(ctx context.Context, uw *fileset.UnorderedWriter, server modifyFileSource) (int64, error) {
+// SetCommit messages will result in an error. Split files are numbered after
+// the files in commit, if it's set.
+func (a *apiServer) modifyFile(ctx context.Context, uw *fileset.UnorderedWriter, commit *pfs.Commit, server modifyFileSource) (int64, error) {
 	var bytesRead int64
+	indexes := newSplitIndexes(func(dir string) (int64, error) {
+		if commit == nil {
+			return 0, nil
+		}
+		var n int64
+		if err := a.driver.listFile(ctx, commit.NewFile(dir), false, nil, func(*pfs.FileInfo) error {
+			n++
+			return nil
+		}); err != nil && !errutil.IsNotFoundError(err) {
+			return 0, err
+		}
+		return n, nil
+	})
+	var split *splitUpload
+	closeSplit := func() error {
+		if split == nil {
+			return nil
+		}
+		err := split.close()
+		indexes.set(split.s.add.Path, split.s.next)
+		split = nil
+		return err
+	}
+	defer func() {
+		if split != nil {
+			split.abort()
+		}
+	}()
 	for {
 		msg, err := server.Recv()
 		if err != nil {
@@ -400,51 +794,130 @@ func (a *apiServer) modifyFile(ctx context.Context, uw *fileset.UnorderedWriter,
 			}
 			return bytesRead, err
 		}
+		if add, ok := msg.Body.(*pfs.ModifyFileRequest_AddFile); !ok || split == nil || !split.matches(add.AddFile) {
+			if err := closeSplit(); err != nil {
+				return bytesRead, err
+			}
+		}
 		switch mod := msg.Body.(type) {
 		case *pfs.ModifyFileRequest_AddFile:
 			var err error
 			var n int64
 			p := mod.AddFile.Path
 			t := mod.AddFile.Tag
+			mtime := mod.AddFile.Mtime
+			if mtime == nil {
+				mtime = types.TimestampNow()
+			}
+			opts := []fileset.FileOption{fileset.WithModTime(mtime)}
+			if mod.AddFile.Mode != 0 {
+				opts = append(opts, fileset.WithMode(mod.AddFile.Mode))
+			}
+			if mod.AddFile.LinkTarget != "" {
+				if len(mod.AddFile.GetRaw().GetValue()) > 0 || mod.AddFile.GetUrl() != nil || mod.AddFile.Split != nil {
+					return bytesRead, errors.Errorf("symlink %s cannot have content", p)
+				}
+				opts = append(opts, fileset.WithLinkTarget(mod.AddFile.LinkTarget))
+			}
+			if len(mod.AddFile.Metadata) > 0 {
+				opts = append(opts, fileset.WithMetadata(mod.AddFile.Metadata))
+			}
+			if mod.AddFile.Split != nil && mod.AddFile.Split.Delimiter != pfs.Delimiter_NONE {
+				if split == nil {
+					next, err := indexes.get(p)
+					if err != nil {
+						return bytesRead, err
+					}
+					split = newSplitUpload(uw, mod.AddFile, next, opts...)
+				}
+				n, err := a.splitFile(split, mod.AddFile)
+				if err != nil {
+					return bytesRead, err
+				}
+				bytesRead += n
+				continue
+			}
 			switch src := mod.AddFile.Source.(type) {
 			case *pfs.AddFile_Raw:
-				n, err = putFileRaw(uw, p, t, src.Raw)
+				if err := a.uploads.checkBytes(int64(len(src.Raw.GetValue()))); err != nil {
+					return bytesRead, err
+				}
+				n, err = putFileRaw(uw, p, t, src.Raw, opts...)
 			case *pfs.AddFile_Url:
-				n, err = putFileURL(ctx, uw, p, t, src.Url)
+				n, err = putFileURL(ctx, uw, p, t, src.Url, opts...)
 			default:
 				// need to write empty data to path
-				n, err = putFileRaw(uw, p, t, &types.BytesValue{})
+				n, err = putFileRaw(uw, p, t, &types.BytesValue{}, opts...)
 			}
 			if err != nil {
 				return bytesRead, err
 			}
 			bytesRead += n
 		case *pfs.ModifyFileRequest_DeleteFile:
-			if err := deleteFile(uw, mod.DeleteFile); err != nil {
+			if err := a.driver.deleteFile(ctx, uw, commit, mod.DeleteFile); err != nil {
+				return bytesRead, err
+			}
+			indexes.delete(mod.DeleteFile.Path)
+		case *pfs.ModifyFileRequest_DeleteTag:
+			if err := deleteTag(uw, mod.DeleteTag); err != nil {
 				return bytesRead, err
 			}
 		case *pfs.ModifyFileRequest_CopyFile:
 			cf := mod.CopyFile
-			if err := a.driver.copyFile(ctx, uw, cf.Dst, cf.Src, cf.Append, cf.Tag); err != nil {
+			if err := a.driver.copyFile(ctx, uw, cf.Dst, cf.Src, cf.OffsetBytes, cf.SizeBytes, cf.Append, cf.Tag); err != nil {
 				return bytesRead, err
 			}
+		case *pfs.ModifyFileRequest_MoveFile:
+			if commit == nil {
+				return bytesRead, errors.Errorf("cannot move files in a file set")
+			}
+			mf := mod.MoveFile
+			if err := a.driver.moveFile(ctx, uw, commit, mf.Src, mf.Dst); err != nil {
+				return bytesRead, err
+			}
+			indexes.delete(mf.Src)
+		case *pfs.ModifyFileRequest_AddFileSet:
+			n, err := a.driver.appendFileSet(ctx, uw, mod.AddFileSet)
+			if err != nil {
+				return bytesRead, err
+			}
+			bytesRead += n
 		case *pfs.ModifyFileRequest_SetCommit:
 			return bytesRead, errors.Errorf("cannot set commit")
 		default:
 			return bytesRead, errors.Errorf("unrecognized message type")
 		}
 	}
-	return bytesRead, nil
+	return bytesRead, closeSplit()
+}
+
+// splitFile writes the raw content of add to the split upload it's part of.
+func (a *apiServer) splitFile(split *splitUpload, add *pfs.AddFile) (int64, error) {
+	var data []byte
+	switch src := add.Source.(type) {
+	case *pfs.AddFile_Raw:
+		data = src.Raw.GetValue()
+	case nil:
+	default:
+		return 0, errors.Errorf("only raw content can be split")
+	}
+	if err := a.uploads.checkBytes(int64(len(data))); err != nil {
+		return 0, err
+	}
+	if err := split.write(data); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
 }
 
-func putFileRaw(uw *fileset.UnorderedWriter, path, tag string, src *types.BytesValue) (int64, error) {
-	if err := uw.Put(path, tag, true, bytes.NewReader(src.Value)); err != nil {
+func putFileRaw(uw *fileset.UnorderedWriter, path, tag string, src *types.BytesValue, opts ...fileset.FileOption) (int64, error) {
+	if err := uw.Put(path, tag, true, bytes.NewReader(src.Value), opts...); err != nil {
 		return 0, err
 	}
 	return int64(len(src.Value)), nil
 }
 
-func putFileURL(ctx context.Context, uw *fileset.UnorderedWriter, dstPath, tag string, src *pfs.AddFile_URLSource) (n int64, retErr error) {
+func putFileURL(ctx context.Context, uw *fileset.UnorderedWriter, dstPath, tag string, src *pfs.AddFile_URLSource, opts ...fileset.FileOption) (n int64, retErr error) {
 	url, err := url.Parse(src.URL)
 	if err != nil {
 		return 0, err
@@ -464,7 +937,7 @@ func putFileURL(ctx context.Context, uw *fileset.UnorderedWriter, dstPath, tag s
 				retErr = err
 			}
 		}()
-		return 0, uw.Put(dstPath, tag, true, resp.Body)
+		return 0, uw.Put(dstPath, tag, true, resp.Body, opts...)
 	default:
 		url, err := obj.ParseURL(src.URL)
 		if err != nil {
@@ -480,21 +953,27 @@ func putFileURL(ctx context.Context, uw *fileset.UnorderedWriter, dstPath, tag s
 				return miscutil.WithPipe(func(w io.Writer) error {
 					return objClient.Get(ctx, name, w)
 				}, func(r io.Reader) error {
-					return uw.Put(filepath.Join(dstPath, strings.TrimPrefix(name, path)), tag, true, r)
+					return uw.Put(filepath.Join(dstPath, strings.TrimPrefix(name, path)), tag, true, r, opts...)
 				})
 			})
 		}
 		return 0, miscutil.WithPipe(func(w io.Writer) error {
 			return objClient.Get(ctx, url.Object, w)
 		}, func(r io.Reader) error {
-			return uw.Put(dstPath, tag, true, r)
+			return uw.Put(dstPath, tag, true, r, opts...)
 		})
 	}
 }
 
-func deleteFile(uw *fileset.UnorderedWriter, request *pfs.DeleteFile) error {
-	uw.Delete(request.Path, request.Tag)
-	return nil
+func deleteTag(uw *fileset.UnorderedWriter, request *pfs.DeleteTag) error {
+	if request.Tag == "" {
+		return errors.Errorf("a tag must be given to delete files by tag")
+	}
+	p := request.Path
+	if p == "" {
+		p = "/"
+	}
+	return uw.Delete(p, request.Tag)
 }
 
 // GetFileTAR implements the protobuf pfs.GetFile RPC
@@ -503,18 +982,41 @@ func (a *apiServer) GetFileTAR(request *pfs.GetFileRequest, server pfs.API_GetFi
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
 	return metrics.ReportRequestWithThroughput(func() (int64, error) {
 		ctx := server.Context()
-		src, err := a.driver.getFile(ctx, request.File)
+		file := request.File
+		if request.CaseInsensitive {
+			var err error
+			if file, err = a.driver.resolveFileCase(ctx, file); err != nil {
+				return 0, err
+			}
+		}
+		src, err := a.driver.getFile(ctx, file)
 		if err != nil {
 			return 0, err
 		}
+		if request.OffsetBytes != 0 || request.SizeBytes != 0 {
+			if request.OffsetBytes < 0 || request.SizeBytes < 0 {
+				return 0, errors.Errorf("offset_bytes and size_bytes cannot be negative")
+			}
+			if request.URL != "" || request.Format != pfs.ArchiveFormat_TAR {
+				return 0, errors.Errorf("ranges of files can only be streamed as tar archives")
+			}
+			src = newRangeSource(src, a.driver.storage.ChunkStorage(), request.OffsetBytes, request.SizeBytes)
+		}
+		writeArchive := getFileTar
+		if request.Format == pfs.ArchiveFormat_ZIP {
+			writeArchive = getFileZip
+		}
 		if request.URL != "" {
+			if request.Format == pfs.ArchiveFormat_ZIP {
+				return getFileURLArchive(ctx, request.URL, src, writeArchive)
+			}
 			return getFileURL(ctx, request.URL, src)
 		}
 		var bytesWritten int64
 		err = grpcutil.WithStreamingBytesWriter(server, func(w io.Writer) error {
 			var err error
 			bytesWritten, err = withGetFileWriter(w, func(w io.Writer) error {
-				return getFileTar(ctx, w, src)
+				return writeArchive(ctx, w, src)
 			})
 			return err
 		})
@@ -551,6 +1053,29 @@ func getFileURL(ctx context.Context, URL string, src Source) (int64, error) {
 	return bytesWritten, err
 }
 
+// getFileURLArchive writes src as a single archive object at URL.
+func getFileURLArchive(ctx context.Context, URL string, src Source, writeArchive func(context.Context, io.Writer, Source) error) (int64, error) {
+	parsedURL, err := obj.ParseURL(URL)
+	if err != nil {
+		return 0, err
+	}
+	objClient, err := obj.NewClientFromURLAndSecret(parsedURL, false)
+	if err != nil {
+		return 0, err
+	}
+	var bytesWritten int64
+	err = miscutil.WithPipe(func(w io.Writer) error {
+		var err error
+		bytesWritten, err = withGetFileWriter(w, func(w io.Writer) error {
+			return writeArchive(ctx, w, src)
+		})
+		return err
+	}, func(r io.Reader) error {
+		return objClient.Put(ctx, parsedURL.Object, r)
+	})
+	return bytesWritten, err
+}
+
 func withGetFileWriter(w io.Writer, cb func(io.Writer) error) (int64, error) {
 	gfw := &getFileWriter{w: w}
 	err := cb(gfw)
@@ -578,6 +1103,9 @@ func getFileTar(ctx context.Context, w io.Writer, src Source) error {
 	// 	},
 	// }
 	if err := src.Iterate(ctx, func(fi *pfs.FileInfo, file fileset.File) error {
+		if fi.FileType == pfs.FileType_DIR {
+			return writeTarDir(w, fi)
+		}
 		return fileset.WriteTarEntry(w, file)
 	}); err != nil {
 		return err
@@ -585,11 +1113,91 @@ func getFileTar(ctx context.Context, w io.Writer, src Source) error {
 	return tar.NewWriter(w).Close()
 }
 
+// writeTarDir writes a directory entry for fi to w, typed as a directory so
+// that tar readers don't mistake it for an empty file.
+func writeTarDir(w io.Writer, fi *pfs.FileInfo) error {
+	tw := tar.NewWriter(w)
+	hdr := &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     fi.File.Path,
+		Mode:     0755,
+	}
+	if fi.Mtime != nil {
+		mtime, err := types.TimestampFromProto(fi.Mtime)
+		if err != nil {
+			return err
+		}
+		hdr.ModTime = mtime
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// getFileZip writes src to w as a zip archive. Each entry is written as it's
+// read, with its size and checksum after the content, so the archive is never
+// buffered. Paths are relative, as zip requires.
+func getFileZip(ctx context.Context, w io.Writer, src Source) error {
+	zw := zip.NewWriter(w)
+	if err := src.Iterate(ctx, func(fi *pfs.FileInfo, file fileset.File) error {
+		name := strings.TrimPrefix(fi.File.Path, "/")
+		if name == "" {
+			return nil
+		}
+		hdr := &zip.FileHeader{
+			Name:   name,
+			Method: zip.Deflate,
+		}
+		if fi.FileType == pfs.FileType_DIR {
+			// Zip marks directories with a trailing slash.
+			if !strings.HasSuffix(hdr.Name, "/") {
+				hdr.Name += "/"
+			}
+			hdr.Method = zip.Store
+		} else if fi.LinkTarget != "" {
+			hdr.SetMode(os.ModeSymlink | 0777)
+		} else if fi.Mode != 0 {
+			hdr.SetMode(os.FileMode(fi.Mode).Perm())
+		}
+		if fi.Mtime != nil {
+			mtime, err := types.TimestampFromProto(fi.Mtime)
+			if err != nil {
+				return errors.EnsureStack(err)
+			}
+			hdr.Modified = mtime
+		}
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		if fi.FileType == pfs.FileType_DIR {
+			return nil
+		}
+		if fi.LinkTarget != "" {
+			// Zip stores the target of a symlink as its content.
+			_, err := io.WriteString(fw, fi.LinkTarget)
+			return errors.EnsureStack(err)
+		}
+		return file.Content(fw)
+	}); err != nil {
+		return err
+	}
+	return errors.EnsureStack(zw.Close())
+}
+
 // InspectFile implements the protobuf pfs.InspectFile RPC
 func (a *apiServer) InspectFile(ctx context.Context, request *pfs.InspectFileRequest) (response *pfs.FileInfo, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
-	return a.driver.inspectFile(ctx, request.File)
+	file := request.File
+	if request.CaseInsensitive {
+		var err error
+		if file, err = a.driver.resolveFileCase(ctx, file); err != nil {
+			return nil, err
+		}
+	}
+	return a.driver.inspectFile(ctx, file)
 }
 
 // ListFile implements the protobuf pfs.ListFile RPC
@@ -599,10 +1207,22 @@ func (a *apiServer) ListFile(request *pfs.ListFileRequest, server pfs.API_ListFi
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
 	}(time.Now())
-	return a.driver.listFile(server.Context(), request.File, request.Full, func(fi *pfs.FileInfo) error {
+	// The page is cut where files are sent, so that files already buffered
+	// when it's full aren't counted.
+	pg := newPager(request.PageToken, request.PageSize, true)
+	send := func(fi *pfs.FileInfo) error {
+		if ok, err := pg.add(fi.File.Path); !ok {
+			return err
+		}
 		sent++
 		return server.Send(fi)
-	})
+	}
+	if err := sendFileInfos(server.Context(), a.env.Config().FileInfoStreamBuffer, send, func(ctx context.Context, cb func(*pfs.FileInfo) error) error {
+		return a.driver.listFileSelected(ctx, request, cb)
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return err
+	}
+	return nil
 }
 
 // WalkFile implements the protobuf pfs.WalkFile RPC
@@ -612,12 +1232,48 @@ func (a *apiServer) WalkFile(request *pfs.WalkFileRequest, server pfs.API_WalkFi
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
 	}(time.Now())
-	return a.driver.walkFile(server.Context(), request.File, func(fi *pfs.FileInfo) error {
+	send := func(fi *pfs.FileInfo) error {
+		sent++
+		return server.Send(fi)
+	}
+	return sendFileInfos(server.Context(), a.env.Config().FileInfoStreamBuffer, send, func(ctx context.Context, cb func(*pfs.FileInfo) error) error {
+		return a.driver.walkFile(ctx, request.File, cb)
+	})
+}
+
+// ListFileHistory implements the protobuf pfs.ListFileHistory RPC
+func (a *apiServer) ListFileHistory(request *pfs.ListFileHistoryRequest, server pfs.API_ListFileHistoryServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.listFileHistory(server.Context(), request.File, request.Limit, func(fi *pfs.FileInfo) error {
+		sent++
+		return server.Send(fi)
+	})
+}
+
+// DirectorySizes implements the protobuf pfs.DirectorySizes RPC
+func (a *apiServer) DirectorySizes(request *pfs.DirectorySizesRequest, server pfs.API_DirectorySizesServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.directorySizes(server.Context(), request.File, request.Depth, func(fi *pfs.FileInfo) error {
 		sent++
 		return server.Send(fi)
 	})
 }
 
+// ListTags implements the protobuf pfs.ListTags RPC
+func (a *apiServer) ListTags(ctx context.Context, request *pfs.ListTagsRequest) (response *pfs.ListTagsResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.listTags(ctx, request.File)
+}
+
 // GlobFile implements the protobuf pfs.GlobFile RPC
 func (a *apiServer) GlobFile(request *pfs.GlobFileRequest, respServer pfs.API_GlobFileServer) (retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -625,7 +1281,7 @@ func (a *apiServer) GlobFile(request *pfs.GlobFileRequest, respServer pfs.API_Gl
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
 	}(time.Now())
-	return a.driver.globFile(respServer.Context(), request.Commit, request.Pattern, func(fi *pfs.FileInfo) error {
+	return a.driver.globFile(respServer.Context(), request.Commit, request.Pattern, request.Tag, hiddenPrefixes(request.ExcludeHidden, request.HiddenPrefixes), func(fi *pfs.FileInfo) error {
 		sent++
 		return respServer.Send(fi)
 	})
@@ -638,6 +1294,18 @@ func (a *apiServer) DiffFile(request *pfs.DiffFileRequest, server pfs.API_DiffFi
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
 	}(time.Now())
+	if request.DetectRenames {
+		return diffEntries(func(cb func(oldFi, newFi *pfs.FileInfo) error) error {
+			return a.driver.diffFile(server.Context(), request.OldFile, request.NewFile, cb)
+		}, func(entry *pfs.DiffEntry, oldFi, newFi *pfs.FileInfo) error {
+			sent++
+			return server.Send(&pfs.DiffFileResponse{
+				OldFile: oldFi,
+				NewFile: newFi,
+				Entry:   entry,
+			})
+		})
+	}
 	return a.driver.diffFile(server.Context(), request.OldFile, request.NewFile, func(oldFi, newFi *pfs.FileInfo) error {
 		sent++
 		return server.Send(&pfs.DiffFileResponse{
@@ -647,6 +1315,58 @@ func (a *apiServer) DiffFile(request *pfs.DiffFileRequest, server pfs.API_DiffFi
 	})
 }
 
+// DiffFileContent implements the protobuf pfs.DiffFileContent RPC
+func (a *apiServer) DiffFileContent(request *pfs.DiffFileContentRequest, server pfs.API_DiffFileContentServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.diffFileContent(server.Context(), request.OldFile, request.NewFile, int(request.ContextLines), request.ByteRanges, func(resp *pfs.DiffFileContentResponse) error {
+		sent++
+		return server.Send(resp)
+	})
+}
+
+// ListFileChunks implements the protobuf pfs.ListFileChunks RPC
+func (a *apiServer) ListFileChunks(request *pfs.ListFileChunksRequest, server pfs.API_ListFileChunksServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.listFileChunks(server.Context(), request.File, func(chunk *pfs.FileChunk) error {
+		sent++
+		return server.Send(chunk)
+	})
+}
+
+// ChangeFeed implements the protobuf pfs.ChangeFeed RPC
+func (a *apiServer) ChangeFeed(request *pfs.ChangeFeedRequest, server pfs.API_ChangeFeedServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.changeFeed(server.Context(), request.Branch, request.Cursor, func(change *pfs.FileChange) error {
+		sent++
+		return server.Send(change)
+	})
+}
+
+// Watch implements the protobuf pfs.Watch RPC
+func (a *apiServer) Watch(request *pfs.WatchRequest, server pfs.API_WatchServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.watch(server.Context(), request.Token, request.Repos, func(ev *pfs.WatchEvent) error {
+		sent++
+		return server.Send(ev)
+	})
+}
+
 // DeleteAll implements the protobuf pfs.DeleteAll RPC
 func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -660,14 +1380,63 @@ func (a *apiServer) DeleteAll(ctx context.Context, request *types.Empty) (respon
 	return &types.Empty{}, nil
 }
 
-// Fsckimplements the protobuf pfs.Fsck RPC
+// GarbageCollect implements the protobuf pfs.GarbageCollect RPC
+func (a *apiServer) GarbageCollect(ctx context.Context, request *pfs.GarbageCollectRequest) (response *pfs.GarbageCollectResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	run, err := a.driver.garbageCollect(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.GarbageCollectResponse{Run: run}, nil
+}
+
+// InspectGarbageCollection implements the protobuf pfs.InspectGarbageCollection RPC
+func (a *apiServer) InspectGarbageCollection(ctx context.Context, request *pfs.InspectGarbageCollectionRequest) (response *pfs.GarbageCollectionStats, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.inspectGarbageCollection(ctx)
+}
+
+// StorageUsage implements the protobuf pfs.StorageUsage RPC
+func (a *apiServer) StorageUsage(ctx context.Context, request *pfs.StorageUsageRequest) (response *pfs.StorageUsageResponse, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.storageUsage(ctx, request.Repo)
+}
+
+// DedupReport implements the protobuf pfs.DedupReport RPC
+func (a *apiServer) DedupReport(ctx context.Context, request *pfs.DedupReportRequest) (response *pfs.CommitDedupReport, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.dedupReport(ctx, request.A, request.B)
+}
+
+// FindFilesByContent implements the protobuf pfs.FindFilesByContent RPC
+func (a *apiServer) FindFilesByContent(request *pfs.FindFilesByContentRequest, server pfs.API_FindFilesByContentServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	var sent int
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("response stream with %d objects", sent), retErr, time.Since(start))
+	}(time.Now())
+	return a.driver.findFilesByContent(server.Context(), request.Hash, request.ChunkId, request.Repo, func(fi *pfs.FileInfo) error {
+		sent++
+		return server.Send(fi)
+	})
+}
+
+// Fsck implements the protobuf pfs.Fsck RPC
 func (a *apiServer) Fsck(request *pfs.FsckRequest, fsckServer pfs.API_FsckServer) (retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
 	sent := 0
 	defer func(start time.Time) {
 		a.Log(request, fmt.Sprintf("stream containing %d messages", sent), retErr, time.Since(start))
 	}(time.Now())
-	if err := a.driver.fsck(fsckServer.Context(), request.Fix, func(resp *pfs.FsckResponse) error {
+	fixLevel := request.FixLevel
+	if request.Fix && fixLevel < pfs.FsckFixLevel_SAFE_FIX {
+		fixLevel = pfs.FsckFixLevel_SAFE_FIX
+	}
+	if err := a.driver.fsck(fsckServer.Context(), fixLevel, func(resp *pfs.FsckResponse) error {
 		sent++
 		return fsckServer.Send(resp)
 	}); err != nil {
@@ -680,10 +1449,17 @@ func (a *apiServer) Fsck(request *pfs.FsckRequest, fsckServer pfs.API_FsckServer
 func (a *apiServer) CreateFileSet(server pfs.API_CreateFileSetServer) (retErr error) {
 	func() { a.Log(nil, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(nil, nil, retErr, time.Since(start)) }(time.Now())
+	release, err := a.startUpload(server.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+	bufferOpt, releaseBuffer := a.uploads.bufferOption()
+	defer releaseBuffer()
 	fsID, err := a.driver.createFileSet(server.Context(), func(uw *fileset.UnorderedWriter) error {
-		_, err := a.modifyFile(server.Context(), uw, server)
+		_, err := a.modifyFile(server.Context(), uw, nil, server)
 		return err
-	})
+	}, bufferOpt)
 	if err != nil {
 		return err
 	}
@@ -703,9 +1479,9 @@ func (a *apiServer) GetFileSet(ctx context.Context, req *pfs.GetFileSetRequest)
 }
 
 func (a *apiServer) AddFileSet(ctx context.Context, req *pfs.AddFileSetRequest) (*types.Empty, error) {
-	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
-		return a.AddFileSetInTransaction(txnCtx, req)
-	}); err != nil {
+	if err := a.txnEnv.WithTransaction(ctx, func(txn txnenv.Transaction) error {
+		return txn.AddFileSet(req)
+	}, nil); err != nil {
 		return nil, err
 	}
 	return &types.Empty{}, nil
@@ -734,6 +1510,127 @@ func (a *apiServer) RenewFileSet(ctx context.Context, req *pfs.RenewFileSetReque
 	return &types.Empty{}, nil
 }
 
+// ComposeFileSet implements the pfs.ComposeFileSet RPC
+func (a *apiServer) ComposeFileSet(ctx context.Context, req *pfs.ComposeFileSetRequest) (*pfs.CreateFileSetResponse, error) {
+	var ids []fileset.ID
+	for _, id := range req.FileSetIds {
+		fsid, err := fileset.ParseID(id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, *fsid)
+	}
+	fsid, err := a.driver.composeFileSet(ctx, ids, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.CreateFileSetResponse{
+		FileSetId: fsid.HexString(),
+	}, nil
+}
+
+// FilterFileSet implements the pfs.FilterFileSet RPC
+func (a *apiServer) FilterFileSet(ctx context.Context, req *pfs.FilterFileSetRequest) (*pfs.CreateFileSetResponse, error) {
+	fsid, err := fileset.ParseID(req.FileSetId)
+	if err != nil {
+		return nil, err
+	}
+	fsid, err = a.driver.filterFileSet(ctx, *fsid, req.PathPrefix, req.Glob, req.Exclude, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.CreateFileSetResponse{
+		FileSetId: fsid.HexString(),
+	}, nil
+}
+
+// SubtractFileSet implements the pfs.SubtractFileSet RPC
+func (a *apiServer) SubtractFileSet(ctx context.Context, req *pfs.SubtractFileSetRequest) (*pfs.CreateFileSetResponse, error) {
+	fsid, err := fileset.ParseID(req.FileSetId)
+	if err != nil {
+		return nil, err
+	}
+	subtrahend, err := fileset.ParseID(req.SubtrahendId)
+	if err != nil {
+		return nil, err
+	}
+	fsid, err = a.driver.subtractFileSet(ctx, *fsid, *subtrahend, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.CreateFileSetResponse{
+		FileSetId: fsid.HexString(),
+	}, nil
+}
+
+// InspectFileSet implements the pfs.InspectFileSet RPC
+func (a *apiServer) InspectFileSet(ctx context.Context, req *pfs.InspectFileSetRequest) (*pfs.FileSetInfo, error) {
+	fsid, err := fileset.ParseID(req.FileSetId)
+	if err != nil {
+		return nil, err
+	}
+	return a.driver.inspectFileSet(ctx, *fsid)
+}
+
+// StartUpload implements the pfs.StartUpload RPC
+func (a *apiServer) StartUpload(ctx context.Context, request *pfs.StartUploadRequest) (response *pfs.UploadInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		response, err = a.driver.startUpload(txnCtx, request.UploadId, request.Commit)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// AddUploadPart implements the pfs.AddUploadPart RPC
+func (a *apiServer) AddUploadPart(ctx context.Context, request *pfs.AddUploadPartRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	fsid, err := fileset.ParseID(request.FileSetId)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.driver.addUploadPart(ctx, request.UploadId, request.Number, *fsid); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// InspectUpload implements the pfs.InspectUpload RPC
+func (a *apiServer) InspectUpload(ctx context.Context, request *pfs.InspectUploadRequest) (response *pfs.UploadInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	return a.driver.inspectUpload(ctx, request.UploadId)
+}
+
+// FinishUpload implements the pfs.FinishUpload RPC
+func (a *apiServer) FinishUpload(ctx context.Context, request *pfs.FinishUploadRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.finishUpload(txnCtx, request.UploadId)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// AbortUpload implements the pfs.AbortUpload RPC
+func (a *apiServer) AbortUpload(ctx context.Context, request *pfs.AbortUploadRequest) (response *types.Empty, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.abortUpload(txnCtx, request.UploadId)
+	}); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
 // RunLoadTest implements the pfs.RunLoadTest RPC
 func (a *apiServer) RunLoadTest(ctx context.Context, req *pfs.RunLoadTestRequest) (_ *pfs.RunLoadTestResponse, retErr error) {
 	func() { a.Log(req, nil, nil, 0) }()
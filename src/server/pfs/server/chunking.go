@@ -0,0 +1,22 @@
+package server
+
+import (
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+func validateChunking(settings *pfs.RepoSettings) error {
+	chunking := settings.GetChunking()
+	return chunk.ValidateChunking(int(settings.GetChunkAverageBits()), int(chunking.GetMinSizeBytes()), int(chunking.GetMaxSizeBytes()), int(chunking.GetWindowBytes()))
+}
+
+// chunkingWriterOption returns the option that chunks files the way a repo's
+// settings say to, or nil if they use the defaults.
+func chunkingWriterOption(settings *pfs.RepoSettings) fileset.WriterOption {
+	chunking := settings.GetChunking()
+	if settings.GetChunkAverageBits() == 0 && chunking == nil {
+		return nil
+	}
+	return fileset.WithChunking(int(settings.GetChunkAverageBits()), int(chunking.GetMinSizeBytes()), int(chunking.GetMaxSizeBytes()), int(chunking.GetWindowBytes()))
+}
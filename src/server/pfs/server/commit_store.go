@@ -29,10 +29,22 @@ type commitStore interface {
 	GetTotalFileSet(ctx context.Context, commit *pfs.Commit) (*fileset.ID, error)
 	// GetDiffFileSet returns the diff fileset for a commit
 	GetDiffFileSet(ctx context.Context, commit *pfs.Commit) (*fileset.ID, error)
+	// GetDiffFileSets returns the filesets that make up the diff for a commit, in order.
+	GetDiffFileSets(ctx context.Context, commit *pfs.Commit) ([]fileset.ID, error)
+	// ReplaceDiffFileSetsTx replaces the filesets old at the start of the diff with id,
+	// keeping any filesets that were added after them.
+	ReplaceDiffFileSetsTx(tx *sqlx.Tx, commit *pfs.Commit, old []fileset.ID, id fileset.ID) error
+	// DropTotalFileSetTx clears the total fileset for the commit, so that it's
+	// computed again from the diff.
+	DropTotalFileSetTx(tx *sqlx.Tx, commit *pfs.Commit) error
 	// DropFileSets clears the diff and total filesets for the commit.
 	DropFileSets(ctx context.Context, commit *pfs.Commit) error
 	// DropFileSetsTx is identical to DropFileSets except it runs in the provided transaction.
 	DropFileSetsTx(tx *sqlx.Tx, commit *pfs.Commit) error
+	// ExpireFileSetsTx is identical to DropFileSetsTx, except that the commit's filesets
+	// are also set to expire now, so that they're garbage collected on the next pass
+	// (if nothing else references them) instead of after their TTL.
+	ExpireFileSetsTx(tx *sqlx.Tx, commit *pfs.Commit) error
 }
 
 var _ commitStore = &postgresCommitStore{}
@@ -116,6 +128,52 @@ func (cs *postgresCommitStore) GetDiffFileSet(ctx context.Context, commit *pfs.C
 	return cs.s.Compose(ctx, ids, defaultTTL)
 }
 
+func (cs *postgresCommitStore) GetDiffFileSets(ctx context.Context, commit *pfs.Commit) ([]fileset.ID, error) {
+	var ids []fileset.ID
+	if err := dbutil.WithTx(ctx, cs.db, func(tx *sqlx.Tx) error {
+		var err error
+		ids, err = getDiff(tx, commit)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (cs *postgresCommitStore) ReplaceDiffFileSetsTx(tx *sqlx.Tx, commit *pfs.Commit, old []fileset.ID, id fileset.ID) error {
+	ids, err := getDiff(tx, commit)
+	if err != nil {
+		return err
+	}
+	if len(ids) < len(old) {
+		return errors.Errorf("diff of commit %v changed while it was being rewritten", commit.ID)
+	}
+	for i := range old {
+		if ids[i] != old[i] {
+			return errors.Errorf("diff of commit %v changed while it was being rewritten", commit.ID)
+		}
+	}
+	// The filesets added since are cloned before the diff is dropped, so that
+	// they aren't garbage collected in between.
+	var rest []fileset.ID
+	for _, id := range ids[len(old):] {
+		clone, err := cs.s.CloneTx(tx, id, defaultTTL)
+		if err != nil {
+			return err
+		}
+		rest = append(rest, *clone)
+	}
+	if err := cs.DropFileSetsTx(tx, commit); err != nil {
+		return err
+	}
+	for _, id := range append([]fileset.ID{id}, rest...) {
+		if err := cs.AddFileSetTx(tx, commit, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (cs *postgresCommitStore) SetTotalFileSet(ctx context.Context, commit *pfs.Commit, id fileset.ID) error {
 	return dbutil.WithTx(ctx, cs.db, func(tx *sqlx.Tx) error {
 		if err := dropTotal(tx, cs.tr, commit); err != nil {
@@ -125,6 +183,10 @@ func (cs *postgresCommitStore) SetTotalFileSet(ctx context.Context, commit *pfs.
 	})
 }
 
+func (cs *postgresCommitStore) DropTotalFileSetTx(tx *sqlx.Tx, commit *pfs.Commit) error {
+	return dropTotal(tx, cs.tr, commit)
+}
+
 func (cs *postgresCommitStore) DropFileSets(ctx context.Context, commit *pfs.Commit) error {
 	return dbutil.WithTx(ctx, cs.db, func(tx *sqlx.Tx) error {
 		return cs.DropFileSetsTx(tx, commit)
@@ -138,6 +200,29 @@ func (cs *postgresCommitStore) DropFileSetsTx(tx *sqlx.Tx, commit *pfs.Commit) e
 	return cs.dropDiff(tx, commit)
 }
 
+func (cs *postgresCommitStore) ExpireFileSetsTx(tx *sqlx.Tx, commit *pfs.Commit) error {
+	ids, err := getDiff(tx, commit)
+	if err != nil {
+		return err
+	}
+	total, err := getTotal(tx, commit)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if total != nil {
+		ids = append(ids, *total)
+	}
+	if err := cs.DropFileSetsTx(tx, commit); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := cs.s.DropTx(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (cs *postgresCommitStore) dropDiff(tx *sqlx.Tx, commit *pfs.Commit) error {
 	diffIDs, err := getDiff(tx, commit)
 	if err != nil {
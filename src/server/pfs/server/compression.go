@@ -0,0 +1,42 @@
+package server
+
+import (
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+var compressionAlgos = map[pfs.CompressionCodec]chunk.CompressionAlgo{
+	pfs.CompressionCodec_UNCOMPRESSED: chunk.CompressionAlgo_NONE,
+	pfs.CompressionCodec_GZIP:         chunk.CompressionAlgo_GZIP_BEST_SPEED,
+	pfs.CompressionCodec_ZSTD:         chunk.CompressionAlgo_ZSTD,
+	pfs.CompressionCodec_LZ4:          chunk.CompressionAlgo_LZ4,
+}
+
+func validateCompression(compression *pfs.ChunkCompression) error {
+	if compression == nil {
+		return nil
+	}
+	algo, ok := compressionAlgos[compression.Codec]
+	if !ok {
+		return errors.Errorf("unrecognized compression codec %v", compression.Codec)
+	}
+	return chunk.ValidateCompression(algo, int(compression.Level))
+}
+
+// repoWriterOptions returns the options that write files the way a repo's
+// settings say to.
+func repoWriterOptions(settings *pfs.RepoSettings) []fileset.UnorderedWriterOption {
+	var opts []fileset.WriterOption
+	if compression := settings.GetCompression(); compression != nil {
+		opts = append(opts, fileset.WithCompression(compressionAlgos[compression.Codec], int(compression.Level)))
+	}
+	if opt := chunkingWriterOption(settings); opt != nil {
+		opts = append(opts, opt)
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return []fileset.UnorderedWriterOption{fileset.WithWriterOptions(opts...)}
+}
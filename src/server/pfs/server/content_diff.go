@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// maxUnifiedDiffSize is the size above which files aren't diffed line by
+// line, since both versions are held in memory to do so.
+const maxUnifiedDiffSize = 16 * 1024 * 1024
+
+// diffFileContent calls cb with the differences between the content of
+// oldFile and newFile. If oldFile is nil, the same path in the parent of
+// newFile's commit is used. A file that doesn't exist is diffed as if it was
+// empty.
+func (d *driver) diffFileContent(ctx context.Context, oldFile, newFile *pfs.File, contextLines int, byteRanges bool, cb func(*pfs.DiffFileContentResponse) error) error {
+	newCommitInfo, err := d.inspectCommit(ctx, newFile.Commit, pfs.CommitState_STARTED)
+	if err != nil {
+		return err
+	}
+	newFile = &pfs.File{
+		Commit: newCommitInfo.Commit,
+		Path:   newFile.Path,
+		Tag:    newFile.Tag,
+	}
+	if oldFile == nil {
+		oldFile = &pfs.File{
+			Commit: newCommitInfo.ParentCommit,
+			Path:   newFile.Path,
+		}
+	}
+	newRefs, err := d.fileDataRefs(ctx, newFile)
+	if err != nil {
+		return err
+	}
+	var oldRefs []*chunk.DataRef
+	if oldFile.Commit != nil {
+		if oldRefs, err = d.fileDataRefs(ctx, oldFile); err != nil {
+			return err
+		}
+	}
+	if byteRanges {
+		return d.diffByteRanges(ctx, oldRefs, newRefs, func(delta *pfs.ByteRangeDelta) error {
+			return cb(&pfs.DiffFileContentResponse{Delta: delta})
+		})
+	}
+	if equalDataRefs(oldRefs, newRefs) {
+		return nil
+	}
+	oldData, err := d.readDataRefs(ctx, oldRefs)
+	if err != nil {
+		return err
+	}
+	newData, err := d.readDataRefs(ctx, newRefs)
+	if err != nil {
+		return err
+	}
+	w := &unifiedDiffWriter{cb: cb}
+	if !isText(oldData) || !isText(newData) {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", diffName(oldFile), diffName(newFile))
+		return errors.EnsureStack(err)
+	}
+	return errors.EnsureStack(difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(oldData)),
+		B:        difflib.SplitLines(string(newData)),
+		FromFile: diffName(oldFile),
+		ToFile:   diffName(newFile),
+		Context:  contextLines,
+	}))
+}
+
+// fileDataRefs returns the data refs of the content of the file at
+// file.Path, across all of its tags unless file.Tag is set. A file that
+// doesn't exist has no data refs.
+func (d *driver) fileDataRefs(ctx context.Context, file *pfs.File) ([]*chunk.DataRef, error) {
+	p := cleanPath(file.Path)
+	if p == "/" {
+		return nil, errors.Errorf("cannot diff the content of the root directory")
+	}
+	_, fs, err := d.openCommit(ctx, file.Commit, index.WithPrefix(p), index.WithTag(file.Tag))
+	if err != nil {
+		return nil, err
+	}
+	var dataRefs []*chunk.DataRef
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		idx := f.Index()
+		if strings.HasPrefix(idx.Path, p+"/") {
+			return errors.Errorf("cannot diff the content of %s, which is a directory", p)
+		}
+		if idx.Path == p {
+			dataRefs = append(dataRefs, idx.File.DataRefs...)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return dataRefs, nil
+}
+
+func (d *driver) readDataRefs(ctx context.Context, dataRefs []*chunk.DataRef) ([]byte, error) {
+	var size int64
+	for _, dataRef := range dataRefs {
+		size += dataRef.SizeBytes
+	}
+	if size > maxUnifiedDiffSize {
+		return nil, errors.Errorf("file is %d bytes, which is too large for a unified diff, diff its byte ranges instead", size)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	if err := d.storage.ChunkStorage().NewReader(ctx, dataRefs).Get(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// diffByteRanges calls cb with the ranges of the new content that replace
+// ranges of the old content. The contents are compared by their data refs,
+// which content defined chunking keeps the same around a change, so only the
+// data in the ranges that changed is read.
+func (d *driver) diffByteRanges(ctx context.Context, oldRefs, newRefs []*chunk.DataRef, cb func(*pfs.ByteRangeDelta) error) error {
+	oldOffsets, newOffsets := dataRefOffsets(oldRefs), dataRefOffsets(newRefs)
+	m := difflib.NewMatcher(dataRefKeys(oldRefs), dataRefKeys(newRefs))
+	for _, op := range m.GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+		delta := &pfs.ByteRangeDelta{
+			OldOffset: oldOffsets[op.I1],
+			OldLength: oldOffsets[op.I2] - oldOffsets[op.I1],
+			NewOffset: newOffsets[op.J1],
+		}
+		if op.J1 == op.J2 {
+			if err := cb(delta); err != nil {
+				return err
+			}
+			continue
+		}
+		for j := op.J1; j < op.J2; j++ {
+			var buf bytes.Buffer
+			if err := d.storage.ChunkStorage().NewReader(ctx, newRefs[j:j+1]).Get(&buf); err != nil {
+				return err
+			}
+			delta.Data = buf.Bytes()
+			if err := cb(delta); err != nil {
+				return err
+			}
+			delta = &pfs.ByteRangeDelta{
+				OldOffset: oldOffsets[op.I2],
+				NewOffset: newOffsets[j+1],
+			}
+		}
+	}
+	return nil
+}
+
+// dataRefOffsets returns the offset of each data ref in the content, followed
+// by the content's size.
+func dataRefOffsets(dataRefs []*chunk.DataRef) []int64 {
+	offsets := make([]int64, len(dataRefs)+1)
+	for i, dataRef := range dataRefs {
+		offsets[i+1] = offsets[i] + dataRef.SizeBytes
+	}
+	return offsets
+}
+
+// dataRefKeys returns a key for each data ref, which is the same for data
+// refs that reference the same data.
+func dataRefKeys(dataRefs []*chunk.DataRef) []string {
+	keys := make([]string, len(dataRefs))
+	for i, dataRef := range dataRefs {
+		if len(dataRef.Hash) > 0 {
+			keys[i] = hex.EncodeToString(dataRef.Hash)
+			continue
+		}
+		keys[i] = fmt.Sprintf("%x:%d:%d", dataRef.Ref.GetId(), dataRef.OffsetBytes, dataRef.SizeBytes)
+	}
+	return keys
+}
+
+func equalDataRefs(a, b []*chunk.DataRef) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	aKeys, bKeys := dataRefKeys(a), dataRefKeys(b)
+	for i := range aKeys {
+		if aKeys[i] != bKeys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isText returns true if data looks like text, the same way that pachctl
+// diff file decides.
+func isText(data []byte) bool {
+	prefix := data
+	if len(prefix) > 8000 {
+		prefix = prefix[:8000]
+	}
+	return bytes.IndexByte(prefix, 0) < 0 && utf8.Valid(data)
+}
+
+func diffName(file *pfs.File) string {
+	if file.Commit == nil {
+		return "/dev/null"
+	}
+	return fmt.Sprintf("%s@%s:%s", file.Commit.Branch.Repo.Name, file.Commit.ID, cleanPath(file.Path))
+}
+
+// unifiedDiffWriter sends what's written to it as the unified parts of
+// DiffFileContentResponses.
+type unifiedDiffWriter struct {
+	cb func(*pfs.DiffFileContentResponse) error
+}
+
+func (w *unifiedDiffWriter) Write(data []byte) (int, error) {
+	if err := w.cb(&pfs.DiffFileContentResponse{Unified: append([]byte{}, data...)}); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
@@ -94,3 +94,71 @@ func (d *Differ) Iterate(ctx context.Context, cb func(aFi, bFi *pfs.FileInfo) er
 func equalFileInfos(aFi, bFi *pfs.FileInfo) bool {
 	return bytes.Equal(aFi.Hash, bFi.Hash)
 }
+
+// diffEntries calls cb with a DiffEntry for each file changed in the diff
+// that iterate produces, in path order. Directories are left out, since their
+// changes follow from the changes to their files. A deleted file and a
+// non-empty added file with the same content are paired up into a RENAMED
+// entry, so the whole diff is collected before cb is called.
+func diffEntries(iterate func(cb func(oldFi, newFi *pfs.FileInfo) error) error, cb func(entry *pfs.DiffEntry, oldFi, newFi *pfs.FileInfo) error) error {
+	type change struct {
+		entry        *pfs.DiffEntry
+		oldFi, newFi *pfs.FileInfo
+	}
+	var changes []*change
+	var added []*change
+	// deleted holds the deleted files that can be renames, by hash, in path
+	// order.
+	deleted := make(map[string][]*change)
+	if err := iterate(func(oldFi, newFi *pfs.FileInfo) error {
+		if oldFi != nil && oldFi.FileType == pfs.FileType_DIR {
+			oldFi = nil
+		}
+		if newFi != nil && newFi.FileType == pfs.FileType_DIR {
+			newFi = nil
+		}
+		c := &change{oldFi: oldFi, newFi: newFi}
+		switch {
+		case oldFi == nil && newFi == nil:
+			return nil
+		case newFi == nil:
+			c.entry = &pfs.DiffEntry{Type: pfs.FileChangeType_DELETED, Path: oldFi.File.Path, SizeDelta: -int64(oldFi.SizeBytes)}
+			if oldFi.SizeBytes > 0 {
+				deleted[string(oldFi.Hash)] = append(deleted[string(oldFi.Hash)], c)
+			}
+		case oldFi == nil:
+			c.entry = &pfs.DiffEntry{Type: pfs.FileChangeType_ADDED, Path: newFi.File.Path, SizeDelta: int64(newFi.SizeBytes)}
+			if newFi.SizeBytes > 0 {
+				added = append(added, c)
+			}
+		default:
+			c.entry = &pfs.DiffEntry{Type: pfs.FileChangeType_MODIFIED, Path: newFi.File.Path, SizeDelta: int64(newFi.SizeBytes) - int64(oldFi.SizeBytes)}
+		}
+		changes = append(changes, c)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, c := range added {
+		candidates := deleted[string(c.newFi.Hash)]
+		if len(candidates) == 0 {
+			continue
+		}
+		d := candidates[0]
+		deleted[string(c.newFi.Hash)] = candidates[1:]
+		c.entry.Type = pfs.FileChangeType_RENAMED
+		c.entry.OldPath = d.entry.Path
+		c.entry.SizeDelta = int64(c.newFi.SizeBytes) - int64(d.oldFi.SizeBytes)
+		c.oldFi = d.oldFi
+		d.entry = nil
+	}
+	for _, c := range changes {
+		if c.entry == nil {
+			continue
+		}
+		if err := cb(c.entry, c.oldFi, c.newFi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"math"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pachyderm/pachyderm/v2/src/auth"
@@ -35,6 +37,7 @@ import (
 	"github.com/gogo/protobuf/types"
 	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -47,6 +50,9 @@ const (
 	fileSetsRepo         = client.FileSetsRepoName
 	defaultTTL           = client.DefaultTTL
 	maxTTL               = 30 * time.Minute
+	// uploadTTL is how long an upload and its parts are kept after it's
+	// started or a part is last added to it, if it isn't finished.
+	uploadTTL = 24 * time.Hour
 )
 
 // IsPermissionError returns true if a given error is a permission error.
@@ -74,6 +80,7 @@ type driver struct {
 	prefix     string
 
 	// collections
+	projects col.PostgresCollection
 	repos    col.PostgresCollection
 	commits  col.PostgresCollection
 	branches col.PostgresCollection
@@ -81,6 +88,7 @@ type driver struct {
 	storage     *fileset.Storage
 	commitStore commitStore
 	compactor   *compactor
+	pathPolicy  *pathPolicy
 }
 
 func newDriver(env serviceenv.ServiceEnv, txnEnv *txnenv.TransactionEnv, etcdPrefix string) (*driver, error) {
@@ -90,6 +98,19 @@ func newDriver(env serviceenv.ServiceEnv, txnEnv *txnenv.TransactionEnv, etcdPre
 	if err != nil {
 		return nil, err
 	}
+	objClient, err = obj.NewReplicaClientFromURLs(objClient, env.Config().StorageReadReplicas)
+	if err != nil {
+		return nil, err
+	}
+	objClient, err = obj.NewErasureClientFromConfig(objClient, env.Config().StorageErasureDataShards, env.Config().StorageErasureParityShards, env.Config().StorageErasureShardURLs)
+	if err != nil {
+		return nil, err
+	}
+	pathPolicy, err := newPathPolicy(env.Config().PathAllowedRanges, env.Config().PathForbiddenCharacters, env.Config().PathMaxComponentLength, env.Config().PathMaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	projects := pfsdb.Projects(env.GetDBClient(), env.GetPostgresListener())
 	repos := pfsdb.Repos(env.GetDBClient(), env.GetPostgresListener())
 	commits := pfsdb.Commits(env.GetDBClient(), env.GetPostgresListener())
 	branches := pfsdb.Branches(env.GetDBClient(), env.GetPostgresListener())
@@ -100,9 +121,11 @@ func newDriver(env serviceenv.ServiceEnv, txnEnv *txnenv.TransactionEnv, etcdPre
 		txnEnv:     txnEnv,
 		etcdClient: etcdClient,
 		prefix:     etcdPrefix,
+		projects:   projects,
 		repos:      repos,
 		commits:    commits,
 		branches:   branches,
+		pathPolicy: pathPolicy,
 		// TODO: set maxFanIn based on downward API.
 	}
 	// Setup tracker and chunk / fileset storage.
@@ -136,7 +159,7 @@ func (d *driver) activateAuth(txnCtx *txncontext.TransactionContext) error {
 	return d.repos.ReadOnly(txnCtx.ClientContext).List(repoInfo, col.DefaultOptions(), func(string) error {
 		err := d.env.AuthServer().CreateRoleBindingInTransaction(txnCtx, "", nil, &auth.Resource{
 			Type: auth.ResourceType_REPO,
-			Name: repoInfo.Repo.Name,
+			Name: repoInfo.Repo.QualifiedName(),
 		})
 		if err != nil && !col.IsErrExists(err) {
 			return err
@@ -145,7 +168,7 @@ func (d *driver) activateAuth(txnCtx *txncontext.TransactionContext) error {
 	})
 }
 
-func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Repo, description string, update bool) error {
+func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Repo, description string, update bool, mirror *pfs.RepoMirror, settings *pfs.RepoSettings, labels map[string]string) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
@@ -184,7 +207,8 @@ func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 			}
 		}
 
-		if existingRepoInfo.Description == description {
+		newLabels := mergeLabels(existingRepoInfo.Labels, labels)
+		if existingRepoInfo.Description == description && (settings == nil || proto.Equal(settings, existingRepoInfo.Settings)) && equalLabels(newLabels, existingRepoInfo.Labels) {
 			// Don't overwrite the stored proto with an identical value. This
 			// optimization is impactful because pps will frequently update the spec
 			// repo to make sure it exists.
@@ -197,15 +221,28 @@ func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 		// idempotent way to ensure that R exists. By permitting these calls when
 		// they don't actually change anything, even if the caller doesn't have
 		// WRITER access, we make the pattern more generally useful.
-		if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, repo.Name, auth.Permission_REPO_WRITE); err != nil {
+		if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
 			return errors.Wrapf(err, "could not update description of %q", repo)
 		}
 		existingRepoInfo.Description = description
+		existingRepoInfo.Labels = newLabels
+		if settings != nil {
+			if err := validateLockUpdate(repo, existingRepoInfo.Settings, settings, txnTime(txnCtx)); err != nil {
+				return err
+			}
+			if err := validateCompression(settings.Compression); err != nil {
+				return err
+			}
+			if err := validateChunking(settings); err != nil {
+				return err
+			}
+			existingRepoInfo.Settings = settings
+		}
 		return repos.Put(pfsdb.RepoKey(repo), &existingRepoInfo)
 	} else {
 		// if this is a system repo, make sure the corresponding user repo already exists
 		if repo.Type != pfs.UserRepoType {
-			baseRepo := client.NewRepo(repo.Name)
+			baseRepo := client.NewProjectRepo(repo.Project.GetName(), repo.Name)
 			err = repos.Get(pfsdb.RepoKey(baseRepo), &existingRepoInfo)
 			if err != nil && col.IsErrNotFound(err) {
 				return errors.Errorf("cannot create a system repo without a corresponding 'user' repo")
@@ -215,6 +252,25 @@ func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 		}
 
 		// New repo case
+		if err := validateMirror(mirror); err != nil {
+			return err
+		}
+		if err := validateLock(settings.GetLock()); err != nil {
+			return err
+		}
+		if err := validateCompression(settings.GetCompression()); err != nil {
+			return err
+		}
+		if err := validateChunking(settings); err != nil {
+			return err
+		}
+		defaultSettings, err := d.newRepoSettings(txnCtx, repo)
+		if err != nil {
+			return err
+		}
+		if settings == nil {
+			settings = defaultSettings
+		}
 		if authIsActivated {
 			// Create ACL for new repo. Make caller the sole owner. If this is a user repo,
 			// and the ACL already exists with a different owner, this will fail.
@@ -224,7 +280,7 @@ func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 				txnCtx,
 				whoAmI.Username,
 				[]string{auth.RepoOwnerRole},
-				&auth.Resource{Type: auth.ResourceType_REPO, Name: repo.Name},
+				&auth.Resource{Type: auth.ResourceType_REPO, Name: repo.QualifiedName()},
 			); err != nil && (!col.IsErrExists(err) || repo.Type == pfs.UserRepoType) {
 				return errors.Wrapf(grpcutil.ScrubGRPC(err), "could not create role binding for new repo %q", repo)
 			}
@@ -233,6 +289,9 @@ func (d *driver) createRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 			Repo:        repo,
 			Created:     txnCtx.Timestamp,
 			Description: description,
+			Settings:    settings,
+			Mirror:      mirror,
+			Labels:      mergeLabels(nil, labels),
 		})
 	}
 }
@@ -261,7 +320,7 @@ func (d *driver) inspectRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Re
 
 func (d *driver) getPermissions(ctx context.Context, repo *pfs.Repo) ([]auth.Permission, []string, error) {
 	resp, err := d.env.AuthServer().GetPermissions(ctx, &auth.GetPermissionsRequest{
-		Resource: &auth.Resource{Type: auth.ResourceType_REPO, Name: repo.Name},
+		Resource: &auth.Resource{Type: auth.ResourceType_REPO, Name: repo.QualifiedName()},
 	})
 	if err != nil {
 		return nil, nil, err
@@ -270,12 +329,21 @@ func (d *driver) getPermissions(ctx context.Context, repo *pfs.Repo) ([]auth.Per
 	return resp.Permissions, resp.Roles, nil
 }
 
-func (d *driver) listRepo(ctx context.Context, includeAuth bool, repoType string) (*pfs.ListRepoResponse, error) {
+// listRepo lists the repos selected by request, all repos are returned if
+// it's empty.
+func (d *driver) listRepo(ctx context.Context, includeAuth bool, request *pfs.ListRepoRequest) (*pfs.ListRepoResponse, error) {
+	filter, err := newRepoFilter(request)
+	if err != nil {
+		return nil, err
+	}
 	result := &pfs.ListRepoResponse{}
 	authSeemsActive := true
 	repoInfo := &pfs.RepoInfo{}
 
-	processFunc := func(string) error {
+	processFunc := func() error {
+		if !filter.matches(repoInfo) {
+			return nil
+		}
 		size, err := d.getRepoSize(ctx, repoInfo.Repo)
 		if err != nil {
 			return err
@@ -295,20 +363,97 @@ func (d *driver) listRepo(ctx context.Context, includeAuth bool, repoType string
 		return nil
 	}
 
-	var err error
-	if repoType == "" {
-		// blank type means return all
-		err = d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions(), processFunc)
-	} else {
-		err = d.repos.ReadOnly(ctx).GetByIndex(pfsdb.ReposTypeIndex, repoType, repoInfo, col.DefaultOptions(), processFunc)
-	}
-	if err != nil {
+	ascending := request.SortBy == pfs.RepoSortBy_BY_CREATION && request.Reverse
+	if err := pfsdb.ListRepos(ctx, d.env.GetDBClient(), filter.sqlFilter(), ascending, repoInfo, processFunc); err != nil {
 		return nil, err
 	}
-
+	sortRepoInfos(result.RepoInfo, request.SortBy, request.Reverse)
 	return result, nil
 }
 
+// repoFilter selects the repos listed by listRepo.
+type repoFilter struct {
+	repoType string
+	project  *pfs.Project
+	// name is set if the name glob is a literal name, otherwise nameGlob
+	// matches the names of the repos that are selected.
+	name     string
+	nameGlob func(string) bool
+	// namePrefix is the literal prefix of the name glob.
+	namePrefix   string
+	createdAfter *types.Timestamp
+	labels       map[string]string
+}
+
+func newRepoFilter(request *pfs.ListRepoRequest) (*repoFilter, error) {
+	f := &repoFilter{
+		repoType:     request.Type,
+		project:      request.Project,
+		createdAfter: request.CreatedAfter,
+		labels:       request.Labels,
+	}
+	if request.NameGlob != "" {
+		if !isGlob(request.NameGlob) {
+			f.name = request.NameGlob
+		} else {
+			g, err := compileGlob(request.NameGlob)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid repo name glob %q", request.NameGlob)
+			}
+			f.nameGlob = g.Match
+			f.namePrefix = globLiteralPrefix(request.NameGlob)
+		}
+	}
+	return f, nil
+}
+
+// sqlFilter returns the part of the filter that postgres applies. The rest,
+// the whole of a name glob, the creation time and the labels, is applied by
+// matches, since a repo's creation time and labels are only in its proto.
+func (f *repoFilter) sqlFilter() pfsdb.RepoListFilter {
+	return pfsdb.RepoListFilter{Type: f.repoType, Project: f.project, Name: f.name, NamePrefix: f.namePrefix}
+}
+
+// matches returns true if repoInfo is selected by the filter.
+func (f *repoFilter) matches(repoInfo *pfs.RepoInfo) bool {
+	if f.repoType != "" && repoInfo.Repo.Type != f.repoType {
+		return false
+	}
+	if f.project != nil && repoInfo.Repo.Project.GetName() != f.project.Name {
+		return false
+	}
+	if f.name != "" && repoInfo.Repo.Name != f.name {
+		return false
+	}
+	if f.nameGlob != nil && !f.nameGlob(repoInfo.Repo.Name) {
+		return false
+	}
+	if f.createdAfter != nil && repoInfo.Created.Compare(f.createdAfter) <= 0 {
+		return false
+	}
+	return matchesLabels(repoInfo.Labels, f.labels)
+}
+
+// sortRepoInfos sorts repoInfos, which are listed by creation, newest first,
+// or oldest first if reverse is set, by the other orders of sortBy.
+func sortRepoInfos(repoInfos []*pfs.RepoInfo, sortBy pfs.RepoSortBy, reverse bool) {
+	var less func(a, b *pfs.RepoInfo) bool
+	switch sortBy {
+	case pfs.RepoSortBy_BY_NAME:
+		less = func(a, b *pfs.RepoInfo) bool { return a.Repo.String() < b.Repo.String() }
+	case pfs.RepoSortBy_BY_SIZE:
+		less = func(a, b *pfs.RepoInfo) bool { return a.SizeBytes > b.SizeBytes }
+	default:
+		return
+	}
+	sort.SliceStable(repoInfos, func(i, j int) bool {
+		if reverse {
+			return less(repoInfos[j], repoInfos[i])
+		}
+		return less(repoInfos[i], repoInfos[j])
+	})
+}
+
 func (d *driver) deleteAllBranchesFromRepos(txnCtx *txncontext.TransactionContext, repos []pfs.RepoInfo, force bool) error {
 	var branchInfos []*pfs.BranchInfo
 	for _, repo := range repos {
@@ -349,7 +494,11 @@ func (d *driver) deleteRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 	}
 
 	// Check if the caller is authorized to delete this repo
-	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, repo.Name, auth.Permission_REPO_DELETE); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, repo.QualifiedName(), auth.Permission_REPO_DELETE); err != nil {
+		return err
+	}
+	// Locked repos can't be deleted, even with force
+	if err := d.checkRepoUnlocked(txnCtx, repo); err != nil {
 		return err
 	}
 
@@ -358,7 +507,7 @@ func (d *driver) deleteRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 		var dependentRepos []pfs.RepoInfo
 		var otherRepo pfs.RepoInfo
 		if err := repos.GetByIndex(pfsdb.ReposNameIndex, repo.Name, &otherRepo, col.DefaultOptions(), func(key string) error {
-			if otherRepo.Repo.Type != repo.Type {
+			if otherRepo.Repo.Type != repo.Type && otherRepo.Repo.Project.GetName() == repo.Project.GetName() {
 				dependentRepos = append(dependentRepos, otherRepo)
 			}
 			return nil
@@ -418,31 +567,131 @@ func (d *driver) deleteRepo(txnCtx *txncontext.TransactionContext, repo *pfs.Rep
 
 	// since system repos share a role binding, only delete it if this is the user repo, in which case the other repos will be deleted anyway
 	if repo.Type == pfs.UserRepoType {
-		if err := d.env.AuthServer().DeleteRoleBindingInTransaction(txnCtx, &auth.Resource{Type: auth.ResourceType_REPO, Name: repo.Name}); err != nil && !auth.IsErrNotActivated(err) {
+		if err := d.env.AuthServer().DeleteRoleBindingInTransaction(txnCtx, &auth.Resource{Type: auth.ResourceType_REPO, Name: repo.QualifiedName()}); err != nil && !auth.IsErrNotActivated(err) {
 			return grpcutil.ScrubGRPC(err)
 		}
 	}
 	return nil
 }
 
+// deleteRepos deletes repos and the repos with all of labels, if it's set,
+// along with the system repos of the user repos among them. It returns the
+// repos it deleted, in the order it deleted them: repos downstream of others,
+// through the provenance of their branches, go first, so that only repos
+// downstream of ones that aren't being deleted need force.
+func (d *driver) deleteRepos(txnCtx *txncontext.TransactionContext, repos []*pfs.Repo, labels map[string]string, force bool) ([]*pfs.Repo, error) {
+	var repoInfos []*pfs.RepoInfo
+	for _, repo := range repos {
+		repoInfo := &pfs.RepoInfo{}
+		if err := d.repos.ReadWrite(txnCtx.SqlTx).Get(pfsdb.RepoKey(repo), repoInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil, pfsserver.ErrRepoNotFound{Repo: repo}
+			}
+			return nil, err
+		}
+		repoInfos = append(repoInfos, repoInfo)
+	}
+	if len(labels) > 0 {
+		resp, err := d.listRepo(txnCtx.ClientContext, !includeAuth, &pfs.ListRepoRequest{Labels: labels})
+		if err != nil {
+			return nil, err
+		}
+		repoInfos = append(repoInfos, resp.RepoInfo...)
+	}
+	// A user repo's system repos are deleted with it, so they're grouped
+	// under it, and a group is downstream of another if any of its repos'
+	// branches are.
+	groups := make(map[string][]*pfs.RepoInfo)
+	groupOf := make(map[string]string)
+	add := func(group string, repoInfo *pfs.RepoInfo) {
+		if _, ok := groupOf[pfsdb.RepoKey(repoInfo.Repo)]; ok {
+			return
+		}
+		groupOf[pfsdb.RepoKey(repoInfo.Repo)] = group
+		groups[group] = append(groups[group], repoInfo)
+	}
+	for _, userInfo := range repoInfos {
+		user := userInfo.Repo
+		if user.Type != pfs.UserRepoType {
+			continue
+		}
+		group := pfsdb.RepoKey(user)
+		add(group, userInfo)
+		repoInfo := &pfs.RepoInfo{}
+		if err := d.repos.ReadWrite(txnCtx.SqlTx).GetByIndex(pfsdb.ReposNameIndex, user.Name, repoInfo, col.DefaultOptions(), func(string) error {
+			if repoInfo.Repo.Type != user.Type && repoInfo.Repo.Project.GetName() == user.Project.GetName() {
+				add(group, proto.Clone(repoInfo).(*pfs.RepoInfo))
+			}
+			return nil
+		}); err != nil && !col.IsErrNotFound(err) {
+			return nil, errors.Wrapf(err, "error finding dependent repos for %q", user)
+		}
+	}
+	for _, repoInfo := range repoInfos {
+		add(pfsdb.RepoKey(repoInfo.Repo), repoInfo)
+	}
+	// Provenance is transitive, so a group that's downstream of another is
+	// downstream of more groups than it, and deleting the groups downstream
+	// of the most groups first deletes them in order.
+	upstream := make(map[string]map[string]bool)
+	for group, members := range groups {
+		upstream[group] = make(map[string]bool)
+		for _, repoInfo := range members {
+			for _, branch := range repoInfo.Branches {
+				branchInfo, err := d.inspectBranch(txnCtx, branch)
+				if err != nil {
+					return nil, errors.Wrapf(err, "error inspecting branch %s", branch)
+				}
+				for _, prov := range branchInfo.Provenance {
+					if other, ok := groupOf[pfsdb.RepoKey(prov.Repo)]; ok && other != group {
+						upstream[group][other] = true
+					}
+				}
+			}
+		}
+	}
+	order := make([]string, 0, len(groups))
+	for group := range groups {
+		order = append(order, group)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if len(upstream[order[i]]) != len(upstream[order[j]]) {
+			return len(upstream[order[i]]) > len(upstream[order[j]])
+		}
+		return order[i] < order[j]
+	})
+	var deleted []*pfs.Repo
+	for _, group := range order {
+		repo := groups[group][0].Repo
+		if err := d.deleteRepo(txnCtx, repo, force); err != nil {
+			return nil, errors.Wrapf(err, "error deleting repo %q", repo)
+		}
+		deleted = append(deleted, repo)
+	}
+	return deleted, nil
+}
+
 // startCommit makes a new commit in 'branch', with the parent 'parent':
-// - 'parent' may be omitted, in which case the parent commit is inferred
-//   from 'branch'.
-// - If 'parent' is set, it determines the parent commit, but 'branch' is
-//   still moved to point at the new commit
+//   - 'parent' may be omitted, in which case the parent commit is inferred
+//     from 'branch'.
+//   - If 'parent' is set, it determines the parent commit, but 'branch' is
+//     still moved to point at the new commit
 func (d *driver) startCommit(
 	txnCtx *txncontext.TransactionContext,
 	parent *pfs.Commit,
 	branch *pfs.Branch,
 	description string,
+	labels map[string]string,
 ) (*pfs.Commit, error) {
 	// Validate arguments:
 	if branch == nil || branch.Name == "" {
 		return nil, errors.Errorf("branch must be specified")
 	}
-	// Check that caller is authorized
-	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.Name, auth.Permission_REPO_WRITE); err != nil {
-		return nil, err
+	// Check that caller is authorized, mirror syncs are done by PFS itself
+	if !isMirrorSync(txnCtx.ClientContext) {
+		if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
+			return nil, err
+		}
 	}
 
 	// New commit and commitInfo
@@ -456,6 +705,7 @@ func (d *driver) startCommit(
 		Description: description,
 		Started:     txnCtx.Timestamp,
 	}
+	setCommitLabels(newCommitInfo, labels)
 	if err := ancestry.ValidateName(branch.Name); err != nil {
 		return nil, err
 	}
@@ -468,6 +718,9 @@ func (d *driver) startCommit(
 		}
 		return nil, err
 	}
+	if repoInfo.Mirror != nil && !isMirrorSync(txnCtx.ClientContext) {
+		return nil, pfsserver.ErrRepoReadOnly{Repo: branch.Repo}
+	}
 
 	// update 'branch' (which must always be set) and set parent.ID (if 'parent'
 	// was not set)
@@ -486,7 +739,7 @@ func (d *driver) startCommit(
 			parent = branchInfo.Head
 		}
 		// Point 'branch' at the new commit
-		branchInfo.Head = newCommit
+		setBranchHead(txnCtx, branchInfo, newCommit, pfs.HeadChangeCause_NEW_COMMIT)
 		return nil
 	}); err != nil {
 		return nil, err
@@ -502,7 +755,7 @@ func (d *driver) startCommit(
 		specBranch := client.NewSystemRepo(spoutName, pfs.SpecRepoType).NewBranch("master")
 		specCommit := specBranch.NewCommit(spoutCommit)
 		log.Infof("Adding spout spec commit to current commitset: %s", specCommit)
-		if _, err := d.aliasCommit(txnCtx, specCommit, specBranch); err != nil {
+		if _, err := d.aliasCommit(txnCtx, specCommit, specBranch, pfs.HeadChangeCause_NEW_COMMIT); err != nil {
 			return nil, err
 		}
 	} else if len(branchInfo.Provenance) > 0 {
@@ -554,7 +807,7 @@ func (d *driver) startCommit(
 
 // TODO: Need to block operations on the commit before kicking off the compaction / finishing the commit.
 // We are going to want to move the compaction to the read side, and just mark the commit as finished here.
-func (d *driver) finishCommit(txnCtx *txncontext.TransactionContext, commit *pfs.Commit, description string) error {
+func (d *driver) finishCommit(txnCtx *txncontext.TransactionContext, commit *pfs.Commit, description string, labels map[string]string) error {
 	commitInfo, err := d.resolveCommit(txnCtx.SqlTx, commit)
 	if err != nil {
 		return err
@@ -567,9 +820,13 @@ func (d *driver) finishCommit(txnCtx *txncontext.TransactionContext, commit *pfs
 	if commitInfo.Origin.Kind == pfs.OriginKind_ALIAS {
 		return errors.Errorf("cannot finish an alias commit: %s", commitInfo.Commit)
 	}
+	if err := d.checkFinishCommit(txnCtx, commitInfo); err != nil {
+		return err
+	}
 	if description != "" {
 		commitInfo.Description = description
 	}
+	setCommitLabels(commitInfo, labels)
 	commitInfo.Finished = txnCtx.Timestamp
 	if err := d.commits.ReadWrite(txnCtx.SqlTx).Put(pfsdb.CommitKey(commitInfo.Commit), commitInfo); err != nil {
 		return err
@@ -583,6 +840,107 @@ func (d *driver) finishCommit(txnCtx *txncontext.TransactionContext, commit *pfs
 	return nil
 }
 
+// setCommitLabels sets the labels of commitInfo to those in labels, removing
+// the ones with empty values.
+func setCommitLabels(commitInfo *pfs.CommitInfo, labels map[string]string) {
+	commitInfo.Labels = mergeLabels(commitInfo.Labels, labels)
+}
+
+// mergeLabels returns existing with labels added to it, except for labels
+// with empty values, which are removed. existing isn't modified.
+func mergeLabels(existing, labels map[string]string) map[string]string {
+	var result map[string]string
+	for k, v := range existing {
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[k] = v
+	}
+	for k, v := range labels {
+		if v == "" {
+			delete(result, k)
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[k] = v
+	}
+	return result
+}
+
+func equalLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if actual, ok := b[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesLabels returns true if have has all of the labels in want. Labels
+// in want with empty values match any value.
+func matchesLabels(have, want map[string]string) bool {
+	for k, v := range want {
+		actual, ok := have[k]
+		if !ok || (v != "" && v != actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// commitFilter selects the commits listed by listCommit.
+type commitFilter struct {
+	includeArchived bool
+	// labels are the labels commits must have. Labels with empty values match
+	// any value.
+	labels         map[string]string
+	startedAfter   *types.Timestamp
+	finishedBefore *types.Timestamp
+	origin         *pfs.CommitOrigin
+}
+
+func newCommitFilter(request *pfs.ListCommitRequest) *commitFilter {
+	return &commitFilter{
+		includeArchived: request.IncludeArchived,
+		labels:          request.Labels,
+		startedAfter:    request.StartedAfter,
+		finishedBefore:  request.FinishedBefore,
+		origin:          request.Origin,
+	}
+}
+
+// matches returns true if commitInfo is selected by the filter.
+func (f *commitFilter) matches(commitInfo *pfs.CommitInfo) bool {
+	if commitInfo.Archived != nil && !f.includeArchived {
+		return false
+	}
+	if !matchesLabels(commitInfo.Labels, f.labels) {
+		return false
+	}
+	if f.startedAfter != nil && commitInfo.Started.Compare(f.startedAfter) <= 0 {
+		return false
+	}
+	if f.finishedBefore != nil && (commitInfo.Finished == nil || commitInfo.Finished.Compare(f.finishedBefore) >= 0) {
+		return false
+	}
+	if f.origin != nil && commitInfo.Origin.Kind != f.origin.Kind {
+		return false
+	}
+	return true
+}
+
+// tooOld returns true if commitInfo started too early to be selected by the
+// filter, in which case neither are the commits started before it, so
+// listing from newest to oldest can stop.
+func (f *commitFilter) tooOld(commitInfo *pfs.CommitInfo) bool {
+	return f.startedAfter != nil && commitInfo.Started.Compare(f.startedAfter) <= 0
+}
+
 // finishAliasChildren will traverse the given commit's children, finding all
 // continguous aliases and finishing them.
 func (d *driver) finishAliasDescendents(txnCtx *txncontext.TransactionContext, parentCommitInfo *pfs.CommitInfo) error {
@@ -610,7 +968,7 @@ func (d *driver) finishAliasDescendents(txnCtx *txncontext.TransactionContext, p
 	return nil
 }
 
-func (d *driver) aliasCommit(txnCtx *txncontext.TransactionContext, parent *pfs.Commit, branch *pfs.Branch) (*pfs.CommitInfo, error) {
+func (d *driver) aliasCommit(txnCtx *txncontext.TransactionContext, parent *pfs.Commit, branch *pfs.Branch, cause pfs.HeadChangeCause) (*pfs.CommitInfo, error) {
 	// It is considered an error if the CommitSet attempts to use two different
 	// commits from the same branch.  Therefore, if there is already a row for the
 	// given branch and it doesn't reference the same parent commit, we fail.  In
@@ -677,7 +1035,7 @@ func (d *driver) aliasCommit(txnCtx *txncontext.TransactionContext, parent *pfs.
 	}
 
 	// Update the branch head
-	branchInfo.Head = commit
+	setBranchHead(txnCtx, branchInfo, commit, cause)
 	if err := d.branches.ReadWrite(txnCtx.SqlTx).Put(pfsdb.BranchKey(branch), branchInfo); err != nil {
 		return nil, err
 	}
@@ -705,8 +1063,10 @@ func (d *driver) getRepoSize(ctx context.Context, repo *pfs.Repo) (int64, error)
 // propagateBranches selectively starts commits in or downstream of 'branches'
 // in order to restore the invariant that branch provenance matches HEAD commit
 // provenance:
-//   B.Head is provenant on A.Head <=>
-//   branch B is provenant on branch A
+//
+//	B.Head is provenant on A.Head <=>
+//	branch B is provenant on branch A
+//
 // The implementation assumes that the invariant already holds for all branches
 // upstream of 'branches', but not necessarily for each 'branch' itself. Despite
 // the name, 'branches' do not need a HEAD commit to propagate, though one may
@@ -798,7 +1158,7 @@ func (d *driver) propagateBranches(txnCtx *txncontext.TransactionContext, branch
 				return err
 			}
 			if provOfSubvBI.Head.ID != txnCtx.CommitSetID {
-				if _, err := d.aliasCommit(txnCtx, provOfSubvBI.Head, provOfSubvBI.Head.Branch); err != nil {
+				if _, err := d.aliasCommit(txnCtx, provOfSubvBI.Head, provOfSubvBI.Head.Branch, pfs.HeadChangeCause_PROPAGATION); err != nil {
 					return err
 				}
 				// Update the cached branch head
@@ -821,7 +1181,7 @@ func (d *driver) propagateBranches(txnCtx *txncontext.TransactionContext, branch
 
 			// Set 'newCommit's ParentCommit, 'branch.Head's ChildCommits and 'branch.Head'
 			newCommitInfo.ParentCommit = subvBI.Head
-			subvBI.Head = newCommit
+			setBranchHead(txnCtx, subvBI, newCommit, pfs.HeadChangeCause_PROPAGATION)
 			if newCommitInfo.ParentCommit != nil {
 				parentCommitInfo := &pfs.CommitInfo{}
 				if err := d.commits.ReadWrite(txnCtx.SqlTx).Update(pfsdb.CommitKey(newCommitInfo.ParentCommit), parentCommitInfo, func() error {
@@ -867,7 +1227,7 @@ func (d *driver) inspectCommit(ctx context.Context, commit *pfs.Commit, wait pfs
 	if commit == nil {
 		return nil, errors.Errorf("cannot inspect nil commit")
 	}
-	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, commit.Branch.Repo.Name, auth.Permission_REPO_INSPECT_COMMIT); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_INSPECT_COMMIT); err != nil {
 		return nil, err
 	}
 
@@ -928,6 +1288,32 @@ func (d *driver) inspectCommit(ctx context.Context, commit *pfs.Commit, wait pfs
 	return commitInfo, nil
 }
 
+// inspectCommitWithTimeout is inspectCommit, but gives up waiting for the
+// commit to reach 'wait' once 'timeout' elapses. In that case it returns an
+// ErrCommitWaitTimeout carrying the commit's current CommitInfo, so that
+// long-polling callers can tell how far the commit has progressed.
+func (d *driver) inspectCommitWithTimeout(ctx context.Context, commit *pfs.Commit, wait pfs.CommitState, timeout time.Duration) (*pfs.CommitInfo, error) {
+	if timeout <= 0 || wait == pfs.CommitState_STARTED {
+		return d.inspectCommit(ctx, commit, wait)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	commitInfo, err := d.inspectCommit(waitCtx, commit, wait)
+	if err == nil || ctx.Err() != nil || !errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+		return commitInfo, err
+	}
+	current, err := d.inspectCommit(ctx, commit, pfs.CommitState_STARTED)
+	if err != nil {
+		return nil, err
+	}
+	return nil, pfsserver.ErrCommitWaitTimeout{
+		Commit:     commit,
+		Wait:       wait,
+		Timeout:    timeout,
+		CommitInfo: current,
+	}
+}
+
 // resolveCommit contains the essential implementation of inspectCommit: it converts 'commit' (which may
 // be a commit ID or branch reference, plus '~' and/or '^') to a repo + commit
 // ID. It accepts a postgres transaction so that it can be used in a transaction
@@ -1056,13 +1442,13 @@ func (d *driver) getCommit(ctx context.Context, commit *pfs.Commit) (*pfs.Commit
 	return commitInfo, nil
 }
 
-func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, cb func(*pfs.CommitInfo) error) error {
+func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit, from *pfs.Commit, number uint64, reverse bool, filter *commitFilter, cb func(*pfs.CommitInfo) error) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
 	}
 
-	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.Name, auth.Permission_REPO_LIST_COMMIT); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.QualifiedName(), auth.Permission_REPO_LIST_COMMIT); err != nil {
 		return err
 	}
 	if from != nil && !proto.Equal(from.Branch.Repo, repo) || to != nil && !proto.Equal(to.Branch.Repo, repo) {
@@ -1103,6 +1489,8 @@ func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit,
 		var cis []*pfs.CommitInfo
 		// sendCis sorts cis and passes them to f
 		sendCis := func() error {
+			// The pending cis are never sent twice, even if cb stops partway.
+			defer func() { cis = nil }()
 			// We don't sort these because there is no provenance between commits
 			// within a repo, so there is no topological sort necessary.
 			for i, ci := range cis {
@@ -1118,21 +1506,24 @@ func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit,
 					return err
 				}
 			}
-			cis = nil
 			return nil
 		}
 		ci := &pfs.CommitInfo{}
 		lastRev := int64(-1)
 		listCallback := func(key string, createRev int64) error {
 			if createRev != lastRev {
+				// ErrBreak is passed on to stop listing.
 				if err := sendCis(); err != nil {
-					if errors.Is(err, errutil.ErrBreak) {
-						return nil
-					}
 					return err
 				}
 				lastRev = createRev
 			}
+			if !reverse && filter.tooOld(ci) {
+				return errutil.ErrBreak
+			}
+			if !filter.matches(ci) {
+				return nil
+			}
 			cis = append(cis, proto.Clone(ci).(*pfs.CommitInfo))
 			return nil
 		}
@@ -1168,13 +1559,19 @@ func (d *driver) listCommit(ctx context.Context, repo *pfs.Repo, to *pfs.Commit,
 			if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(cursor), &commitInfo); err != nil {
 				return err
 			}
+			cursor = commitInfo.ParentCommit
+			if filter.tooOld(&commitInfo) {
+				break
+			}
+			if !filter.matches(&commitInfo) {
+				continue
+			}
 			if err := cb(&commitInfo); err != nil {
 				if errors.Is(err, errutil.ErrBreak) {
 					return nil
 				}
 				return err
 			}
-			cursor = commitInfo.ParentCommit
 			number--
 		}
 	}
@@ -1287,6 +1684,15 @@ func (d *driver) squashCommitSet(txnCtx *txncontext.TransactionContext, commitse
 		return err
 	}
 
+	// Finished commits in locked repos can't be squashed
+	for _, commitInfo := range commitInfos {
+		if commitInfo.Finished != nil {
+			if err := d.checkRepoUnlocked(txnCtx, commitInfo.Commit.Branch.Repo); err != nil {
+				return err
+			}
+		}
+	}
+
 	// 2) Delete each commit in the CommitSet
 	affectedBranches := []*pfs.Branch{}
 	for _, commitInfo := range commitInfos {
@@ -1295,8 +1701,17 @@ func (d *driver) squashCommitSet(txnCtx *txncontext.TransactionContext, commitse
 			return err
 		}
 
-		// Delete the commit's filesets
-		if err := d.commitStore.DropFileSetsTx(txnCtx.SqlTx, commitInfo.Commit); err != nil {
+		// Delete the commit's filesets, expiring them right away if the
+		// commit's branch asks for its data to be garbage collected eagerly
+		dropFileSets := d.commitStore.DropFileSetsTx
+		commitBranchInfo := &pfs.BranchInfo{}
+		if err := d.branches.ReadWrite(txnCtx.SqlTx).Get(pfsdb.BranchKey(commitInfo.Commit.Branch), commitBranchInfo); err != nil && !col.IsErrNotFound(err) {
+			return err
+		}
+		if commitBranchInfo.StoragePolicy.GetEagerGc() {
+			dropFileSets = d.commitStore.ExpireFileSetsTx
+		}
+		if err := dropFileSets(txnCtx.SqlTx, commitInfo.Commit); err != nil {
 			return err
 		}
 
@@ -1306,13 +1721,13 @@ func (d *driver) squashCommitSet(txnCtx *txncontext.TransactionContext, commitse
 			if branchInfo.Head.ID == commitInfo.Commit.ID {
 				if commitInfo.ParentCommit == nil || !proto.Equal(commitInfo.ParentCommit.Branch, commitInfo.Commit.Branch) {
 					// Create a new empty commit for the branch head
-					var err error
-					branchInfo.Head, err = d.makeEmptyCommit(txnCtx, branchInfo)
+					head, err := d.makeEmptyCommit(txnCtx, branchInfo)
 					if err != nil {
 						return err
 					}
+					setBranchHead(txnCtx, branchInfo, head, pfs.HeadChangeCause_COMMIT_REMOVED)
 				} else {
-					branchInfo.Head = commitInfo.ParentCommit
+					setBranchHead(txnCtx, branchInfo, commitInfo.ParentCommit, pfs.HeadChangeCause_COMMIT_REMOVED)
 				}
 				affectedBranches = append(affectedBranches, commitInfo.Commit.Branch)
 			}
@@ -1414,7 +1829,10 @@ func (d *driver) squashCommitSet(txnCtx *txncontext.TransactionContext, commitse
 	return nil
 }
 
-func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch string, from *pfs.Commit, state pfs.CommitState, cb func(*pfs.CommitInfo) error) error {
+// subscribeCommit calls cb with each commit in repo, in the order they were
+// created, once it reaches state. If cursor is set, only the commits after the
+// commit it was returned with are included.
+func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, filter *subscribeFilter, from *pfs.Commit, cursor string, state pfs.CommitState, cb func(*pfs.CommitInfo) error) error {
 	// Validate arguments
 	if repo == nil {
 		return errors.New("repo cannot be nil")
@@ -1422,6 +1840,17 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 	if from != nil && !proto.Equal(from.Branch.Repo, repo) {
 		return errors.Errorf("the `from` commit needs to be from repo %s", repo)
 	}
+	var after *SubscribeCommitCursor
+	if cursor != "" {
+		var err error
+		if after, err = decodeSubscribeCommitCursor(cursor); err != nil {
+			return err
+		}
+		from = nil
+	}
+	if filter.pathPrefix != "" {
+		state = pfs.CommitState_FINISHED
+	}
 
 	// keep track of the commits that have been sent
 	seen := make(map[string]bool)
@@ -1436,8 +1865,22 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 			return errors.Wrapf(err, "unmarshal")
 		}
 
-		// if branch is provided, make sure the commit was created on that branch
-		if branch != "" && commitInfo.Commit.Branch.Name != branch {
+		// Commits are replayed in the order they were created, so everything
+		// up to the cursor's commit was sent to the earlier subscription. The
+		// cursor's commit may have been deleted since, so a commit started
+		// after it also ends the replayed part.
+		if after != nil {
+			if key == after.Commit {
+				after = nil
+				return nil
+			}
+			if !startedAfter(commitInfo, after) {
+				return nil
+			}
+			after = nil
+		}
+
+		if !filter.matches(commitInfo) {
 			return nil
 		}
 
@@ -1448,16 +1891,194 @@ func (d *driver) subscribeCommit(ctx context.Context, repo *pfs.Repo, branch str
 			if err != nil {
 				return err
 			}
+			seen[commitInfo.Commit.ID] = true
+			if ok, err := d.changedPathPrefix(ctx, commitInfo, filter.pathPrefix); err != nil || !ok {
+				return err
+			}
+			if commitInfo.Cursor, err = encodeSubscribeCommitCursor(key, commitInfo); err != nil {
+				return err
+			}
 			if err := cb(commitInfo); err != nil {
 				return err
 			}
-			seen[commitInfo.Commit.ID] = true
 		}
 		return nil
 	}, watch.WithSort(col.SortByCreateRevision, col.SortAscend), watch.IgnoreDelete)
 }
 
-func (d *driver) clearCommit(ctx context.Context, commit *pfs.Commit) error {
+// subscribeCommits is subscribeCommit over several repos at once. Each repo
+// is watched separately, and commits are passed to 'cb' one at a time, in the
+// order they become available. 'cursors' are matched to repos by the commit
+// key they were issued for.
+func (d *driver) subscribeCommits(ctx context.Context, repos []*pfs.Repo, filter *subscribeFilter, cursors []string, state pfs.CommitState, cb func(*pfs.CommitInfo) error) error {
+	repoCursors := make(map[string]string)
+	for _, repo := range repos {
+		if repo == nil {
+			return errors.New("repo cannot be nil")
+		}
+		repoCursors[pfsdb.RepoKey(repo)] = ""
+	}
+	for _, cursor := range cursors {
+		after, err := decodeSubscribeCommitCursor(cursor)
+		if err != nil {
+			return err
+		}
+		repoKey := strings.SplitN(after.Commit, "@", 2)[0]
+		if _, ok := repoCursors[repoKey]; !ok {
+			return errors.Errorf("cursor %q is not for any of the subscribed repos", cursor)
+		}
+		repoCursors[repoKey] = cursor
+	}
+	var mu sync.Mutex
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, repo := range repos {
+		repo := repo
+		cursor, ok := repoCursors[pfsdb.RepoKey(repo)]
+		if !ok {
+			continue // repo was listed more than once
+		}
+		delete(repoCursors, pfsdb.RepoKey(repo))
+		eg.Go(func() error {
+			return d.subscribeCommit(ctx, repo, filter, nil, cursor, state, func(ci *pfs.CommitInfo) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return cb(ci)
+			})
+		})
+	}
+	return errors.EnsureStack(eg.Wait())
+}
+
+// subscribeFilter selects the commits sent by subscribeCommit.
+type subscribeFilter struct {
+	branch string
+	// branchGlob, if set, matches the names of the branches whose commits
+	// are selected.
+	branchGlob func(string) bool
+	origin     *pfs.CommitOrigin
+	pathPrefix string
+}
+
+func newSubscribeFilter(request *pfs.SubscribeCommitRequest) (*subscribeFilter, error) {
+	f := &subscribeFilter{
+		branch: request.Branch,
+		origin: request.Origin,
+	}
+	if request.BranchGlob != "" {
+		if request.Branch != "" {
+			return nil, errors.Errorf("branch cannot be combined with branch_glob")
+		}
+		g, err := compileGlob(request.BranchGlob)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid branch glob %q", request.BranchGlob)
+		}
+		f.branchGlob = g.Match
+	}
+	if request.PathPrefix != "" {
+		f.pathPrefix = cleanPath(request.PathPrefix)
+	}
+	return f, nil
+}
+
+// matches returns true if commitInfo is selected by the filter, leaving out
+// the path prefix, which can only be checked once the commit is finished.
+func (f *subscribeFilter) matches(commitInfo *pfs.CommitInfo) bool {
+	if f.branch != "" && commitInfo.Commit.Branch.Name != f.branch {
+		return false
+	}
+	if f.branchGlob != nil && !f.branchGlob(commitInfo.Commit.Branch.Name) {
+		return false
+	}
+	if f.origin != nil && commitInfo.Origin.Kind != f.origin.Kind {
+		return false
+	}
+	return true
+}
+
+// changedPathPrefix returns true if the finished commit in commitInfo changed
+// a file at or under prefix, compared to its parent. Every commit changes the
+// empty prefix.
+func (d *driver) changedPathPrefix(ctx context.Context, commitInfo *pfs.CommitInfo, prefix string) (bool, error) {
+	if prefix == "" {
+		return true, nil
+	}
+	var changed bool
+	if err := d.diffFile(ctx, nil, commitInfo.Commit.NewFile(prefix), func(_, _ *pfs.FileInfo) error {
+		changed = true
+		return errutil.ErrBreak
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return false, err
+	}
+	return changed, nil
+}
+
+func encodeSubscribeCommitCursor(key string, commitInfo *pfs.CommitInfo) (string, error) {
+	data, err := proto.Marshal(&SubscribeCommitCursor{
+		Commit:  key,
+		Started: commitInfo.Started,
+	})
+	if err != nil {
+		return "", errors.EnsureStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSubscribeCommitCursor(cursor string) (*SubscribeCommitCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid subscribe commit cursor %q", cursor)
+	}
+	result := &SubscribeCommitCursor{}
+	if err := proto.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrapf(err, "invalid subscribe commit cursor %q", cursor)
+	}
+	return result, nil
+}
+
+// startedAfter determines if commitInfo was started after the commit in cursor.
+func startedAfter(commitInfo *pfs.CommitInfo, cursor *SubscribeCommitCursor) bool {
+	if commitInfo.Started == nil || cursor.Started == nil {
+		return false
+	}
+	started, err := types.TimestampFromProto(commitInfo.Started)
+	if err != nil {
+		return false
+	}
+	cursorStarted, err := types.TimestampFromProto(cursor.Started)
+	if err != nil {
+		return false
+	}
+	return started.After(cursorStarted)
+}
+
+// archiveCommit archives a finished commit, or unarchives it if archive is
+// false. An unarchived commit's data stays in cold storage until it's
+// recalled.
+func (d *driver) archiveCommit(txnCtx *txncontext.TransactionContext, commit *pfs.Commit, archive bool) error {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
+		return err
+	}
+	commitInfo, err := d.resolveCommit(txnCtx.SqlTx, commit)
+	if err != nil {
+		return err
+	}
+	if commitInfo.Finished == nil {
+		return errors.Errorf("cannot archive unfinished commit %v", commitInfo.Commit.ID)
+	}
+	return d.commits.ReadWrite(txnCtx.SqlTx).Update(pfsdb.CommitKey(commitInfo.Commit), commitInfo, func() error {
+		switch {
+		case archive && commitInfo.Archived == nil:
+			commitInfo.Archived = txnCtx.Timestamp
+		case !archive:
+			commitInfo.Archived = nil
+		}
+		return nil
+	})
+}
+
+// clearCommit drops the changes made in an open commit. If paths are given,
+// only the changes to files that match them are dropped.
+func (d *driver) clearCommit(ctx context.Context, commit *pfs.Commit, paths []string) error {
 	commitInfo, err := d.inspectCommit(ctx, commit, pfs.CommitState_STARTED)
 	if err != nil {
 		return err
@@ -1465,17 +2086,21 @@ func (d *driver) clearCommit(ctx context.Context, commit *pfs.Commit) error {
 	if commitInfo.Finished != nil {
 		return errors.Errorf("cannot clear finished commit")
 	}
-	return d.commitStore.DropFileSets(ctx, commit)
+	if len(paths) == 0 {
+		return d.commitStore.DropFileSets(ctx, commitInfo.Commit)
+	}
+	return d.clearCommitPaths(ctx, commitInfo.Commit, paths)
 }
 
 // createBranch creates a new branch or updates an existing branch (must be one
 // or the other). Most importantly, it sets 'branch.DirectProvenance' to
 // 'provenance' and then for all (downstream) branches, restores the invariant:
-//   ∀ b . b.Provenance = ∪ b'.Provenance (where b' ∈ b.DirectProvenance)
+//
+//	∀ b . b.Provenance = ∪ b'.Provenance (where b' ∈ b.DirectProvenance)
 //
 // This invariant is assumed to hold for all branches upstream of 'branch', but not
 // for 'branch' itself once 'b.Provenance' has been set.
-func (d *driver) createBranch(txnCtx *txncontext.TransactionContext, branch *pfs.Branch, commit *pfs.Commit, provenance []*pfs.Branch, trigger *pfs.Trigger) error {
+func (d *driver) createBranch(txnCtx *txncontext.TransactionContext, branch *pfs.Branch, commit *pfs.Commit, provenance []*pfs.Branch, trigger *pfs.Trigger, storagePolicy *pfs.BranchStoragePolicy, retention *pfs.BranchRetention) error {
 	// Validate arguments
 	if branch == nil {
 		return errors.New("branch cannot be nil")
@@ -1483,12 +2108,17 @@ func (d *driver) createBranch(txnCtx *txncontext.TransactionContext, branch *pfs
 	if branch.Repo == nil {
 		return errors.New("branch repo cannot be nil")
 	}
+	var err error
+	if trigger == nil {
+		if trigger, err = d.defaultBranchTrigger(txnCtx, branch); err != nil {
+			return err
+		}
+	}
 	if err := d.validateTrigger(txnCtx, branch, trigger); err != nil {
 		return err
 	}
 
-	var err error
-	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.Name, auth.Permission_REPO_CREATE_BRANCH); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.QualifiedName(), auth.Permission_REPO_CREATE_BRANCH); err != nil {
 		return err
 	}
 	// Validate request
@@ -1510,6 +2140,12 @@ func (d *driver) createBranch(txnCtx *txncontext.TransactionContext, branch *pfs
 		if trigger != nil && trigger.Branch != "" {
 			branchInfo.Trigger = trigger
 		}
+		if storagePolicy != nil {
+			branchInfo.StoragePolicy = storagePolicy
+		}
+		if retention != nil {
+			branchInfo.Retention = retention
+		}
 		return nil
 	}); err != nil {
 		return err
@@ -1526,7 +2162,7 @@ func (d *driver) createBranch(txnCtx *txncontext.TransactionContext, branch *pfs
 		// Verify the provenance of the new branch head and lock in its upstream commits
 		for _, provBranch := range provenance {
 			// Check that the CommitSet for the given commit has values for every branch in provenance and alias them
-			if _, err := d.aliasCommit(txnCtx, provBranch.NewCommit(ci.Commit.ID), provBranch); err != nil {
+			if _, err := d.aliasCommit(txnCtx, provBranch.NewCommit(ci.Commit.ID), provBranch, pfs.HeadChangeCause_SET_HEAD); err != nil {
 				if pfsserver.IsCommitNotFoundErr(err) {
 					return errors.Errorf("cannot create branch %s with commit %s as head because it does not have provenance in the %s branch", branch, ci.Commit, provBranch)
 				}
@@ -1535,26 +2171,27 @@ func (d *driver) createBranch(txnCtx *txncontext.TransactionContext, branch *pfs
 
 		if commit.ID == txnCtx.CommitSetID && proto.Equal(commit.Branch, branchInfo.Branch) {
 			// We can reuse the existing commit only if it is already on this branch
-			branchInfo.Head = commit
+			setBranchHead(txnCtx, branchInfo, commit, pfs.HeadChangeCause_SET_HEAD)
 		} else if branchInfo.Head == nil || branchInfo.Head.ID != commit.ID {
 			// Create an alias of the head commit onto this branch - this will move the
 			// head of the branch and update the repo size if necessary
-			aliasCommitInfo, err := d.aliasCommit(txnCtx, commit, branch)
+			aliasCommitInfo, err := d.aliasCommit(txnCtx, commit, branch, pfs.HeadChangeCause_SET_HEAD)
 			if err != nil {
 				return err
 			}
 			// Update the local branchInfo.Head
-			branchInfo.Head = aliasCommitInfo.Commit
+			setBranchHead(txnCtx, branchInfo, aliasCommitInfo.Commit, pfs.HeadChangeCause_SET_HEAD)
 		}
 	}
 
 	// If the branch still has no head, create an empty commit on it so that we
 	// can maintain an invariant that branches always have a head commit.
 	if branchInfo.Head == nil {
-		branchInfo.Head, err = d.makeEmptyCommit(txnCtx, branchInfo)
+		head, err := d.makeEmptyCommit(txnCtx, branchInfo)
 		if err != nil {
 			return err
 		}
+		setBranchHead(txnCtx, branchInfo, head, pfs.HeadChangeCause_BRANCH_CREATED)
 	}
 
 	// Update (or create)
@@ -1659,13 +2296,38 @@ func (d *driver) inspectBranch(txnCtx *txncontext.TransactionContext, branch *pf
 	return result, nil
 }
 
+// branchStats returns the statistics kept for branchInfo's branch, and its
+// head's info. The size of the head isn't set, since it's computed outside of
+// the transaction.
+func (d *driver) branchStats(txnCtx *txncontext.TransactionContext, branchInfo *pfs.BranchInfo) (*pfs.BranchStats, *pfs.CommitInfo, error) {
+	stats, err := pfsdb.GetBranchStatsTx(txnCtx.SqlTx, branchInfo.Branch)
+	if err != nil {
+		return nil, nil, err
+	}
+	var headInfo *pfs.CommitInfo
+	if branchInfo.Head != nil {
+		if headInfo, err = d.resolveCommit(txnCtx.SqlTx, proto.Clone(branchInfo.Head).(*pfs.Commit)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if branchInfo.Trigger != nil {
+		if stats.Trigger == nil {
+			stats.Trigger = &pfs.BranchTriggerStatus{}
+		}
+		if err := d.triggerStatus(txnCtx, branchInfo, headInfo, stats.Trigger); err != nil {
+			return nil, nil, err
+		}
+	}
+	return stats, headInfo, nil
+}
+
 func (d *driver) listBranch(ctx context.Context, repo *pfs.Repo, reverse bool) ([]*pfs.BranchInfo, error) {
 	// Validate arguments
 	if repo == nil {
 		return nil, errors.New("repo cannot be nil")
 	}
 
-	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.Name, auth.Permission_REPO_LIST_BRANCH); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.QualifiedName(), auth.Permission_REPO_LIST_BRANCH); err != nil {
 		return nil, err
 	}
 
@@ -1720,6 +2382,48 @@ func (d *driver) listBranch(ctx context.Context, repo *pfs.Repo, reverse bool) (
 	return result, nil
 }
 
+// watchBranch calls cb with each change to the head of branch, or of every
+// branch in its repo if it has no name, made after the watch starts.
+func (d *driver) watchBranch(ctx context.Context, branch *pfs.Branch, cb func(*pfs.BranchHeadChange) error) error {
+	// Validate arguments
+	if branch == nil {
+		return errors.New("branch cannot be nil")
+	}
+	if branch.Repo == nil {
+		return errors.New("branch repo cannot be nil")
+	}
+	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, branch.Repo.QualifiedName(), auth.Permission_REPO_LIST_BRANCH); err != nil {
+		return err
+	}
+
+	// The watch starts by replaying every branch, so keep track of the last
+	// change to each branch that's been seen, starting with the changes made
+	// before the watch, so that only new changes are sent.
+	last := make(map[string]*pfs.BranchHeadChange)
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadOnly(ctx).GetByIndex(pfsdb.BranchesRepoIndex, pfsdb.RepoKey(branch.Repo), branchInfo, col.DefaultOptions(), func(key string) error {
+		last[key] = branchInfo.HeadChange
+		return nil
+	}); err != nil {
+		return err
+	}
+	return d.branches.ReadOnly(ctx).WatchByIndexF(pfsdb.BranchesRepoIndex, pfsdb.RepoKey(branch.Repo), func(ev *watch.Event) error {
+		var key string
+		branchInfo := &pfs.BranchInfo{}
+		if err := ev.Unmarshal(&key, branchInfo); err != nil {
+			return errors.Wrapf(err, "unmarshal")
+		}
+		if branch.Name != "" && branchInfo.Branch.Name != branch.Name {
+			return nil
+		}
+		if branchInfo.HeadChange == nil || proto.Equal(branchInfo.HeadChange, last[key]) {
+			return nil
+		}
+		last[key] = branchInfo.HeadChange
+		return cb(branchInfo.HeadChange)
+	}, watch.IgnoreDelete)
+}
+
 func (d *driver) deleteBranch(txnCtx *txncontext.TransactionContext, branch *pfs.Branch, force bool) error {
 	// Validate arguments
 	if branch == nil {
@@ -1729,7 +2433,10 @@ func (d *driver) deleteBranch(txnCtx *txncontext.TransactionContext, branch *pfs
 		return errors.New("branch repo cannot be nil")
 	}
 
-	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.Name, auth.Permission_REPO_DELETE_BRANCH); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.QualifiedName(), auth.Permission_REPO_DELETE_BRANCH); err != nil {
+		return err
+	}
+	if err := d.checkRepoUnlocked(txnCtx, branch.Repo); err != nil {
 		return err
 	}
 
@@ -1765,7 +2472,7 @@ func (d *driver) deleteBranch(txnCtx *txncontext.TransactionContext, branch *pfs
 				return err
 			}
 			del(&subvBranchInfo.DirectProvenance, branch)
-			if err := d.createBranch(txnCtx, subvBranch, nil, subvBranchInfo.DirectProvenance, nil); err != nil {
+			if err := d.createBranch(txnCtx, subvBranch, nil, subvBranchInfo.DirectProvenance, nil, nil, nil); err != nil {
 				return err
 			}
 		}
@@ -1801,7 +2508,7 @@ func (d *driver) addBranchProvenance(txnCtx *txncontext.TransactionContext, bran
 			if err != nil {
 				return err
 			}
-			provBranchInfo.Head = head
+			setBranchHead(txnCtx, provBranchInfo, head, pfs.HeadChangeCause_BRANCH_CREATED)
 		}
 		add(&provBranchInfo.Subvenance, branchInfo.Branch)
 		return nil
@@ -1818,7 +2525,7 @@ func (d *driver) addBranchProvenance(txnCtx *txncontext.TransactionContext, bran
 func (d *driver) deleteAll(txnCtx *txncontext.TransactionContext) error {
 	// Note: d.listRepo() doesn't return the 'spec' repo, so it doesn't get
 	// deleted here. Instead, PPS is responsible for deleting and re-creating it
-	repoInfos, err := d.listRepo(txnCtx.ClientContext, !includeAuth, "")
+	repoInfos, err := d.listRepo(txnCtx.ClientContext, !includeAuth, &pfs.ListRepoRequest{})
 	if err != nil {
 		return err
 	}
@@ -1827,7 +2534,7 @@ func (d *driver) deleteAll(txnCtx *txncontext.TransactionContext) error {
 			return err
 		}
 	}
-	return nil
+	return d.projects.ReadWrite(txnCtx.SqlTx).DeleteAll()
 }
 
 func (d *driver) makeEmptyCommit(txnCtx *txncontext.TransactionContext, branchInfo *pfs.BranchInfo) (*pfs.Commit, error) {
@@ -1858,6 +2565,23 @@ func (d *driver) makeEmptyCommit(txnCtx *txncontext.TransactionContext, branchIn
 	return commit, nil
 }
 
+// setBranchHead points branchInfo at head, and records the change in
+// branchInfo.HeadChange for WatchBranch.
+func setBranchHead(txnCtx *txncontext.TransactionContext, branchInfo *pfs.BranchInfo, head *pfs.Commit, cause pfs.HeadChangeCause) {
+	if proto.Equal(branchInfo.Head, head) {
+		return
+	}
+	branchInfo.HeadChange = &pfs.BranchHeadChange{
+		Branch:    branchInfo.Branch,
+		OldHead:   branchInfo.Head,
+		NewHead:   head,
+		Cause:     cause,
+		CommitSet: client.NewCommitSet(txnCtx.CommitSetID),
+		Time:      txnCtx.Timestamp,
+	}
+	branchInfo.Head = head
+}
+
 // TODO: Is this really necessary?
 type branchSet []*pfs.Branch
 
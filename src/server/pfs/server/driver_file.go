@@ -1,26 +1,44 @@
 package server
 
 import (
+	"bytes"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/renew"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/track"
+	txnenv "github.com/pachyderm/pachyderm/v2/src/internal/transactionenv"
 	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
 	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/transaction"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
-func (d *driver) modifyFile(ctx context.Context, commit *pfs.Commit, cb func(*fileset.UnorderedWriter) error) error {
+func (d *driver) modifyFile(ctx context.Context, commit *pfs.Commit, cb func(*fileset.UnorderedWriter) error, uwOpts ...fileset.UnorderedWriterOption) error {
+	activeTxn, err := client.GetTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	if activeTxn != nil {
+		return d.modifyFileInTransaction(ctx, activeTxn, commit, cb, uwOpts...)
+	}
 	return d.storage.WithRenewer(ctx, defaultTTL, func(ctx context.Context, renewer *renew.StringSet) error {
 		// Store the originally-requested parameters because they will be overwritten by inspectCommit
 		branch := proto.Clone(commit.Branch).(*pfs.Branch)
@@ -29,12 +47,23 @@ func (d *driver) modifyFile(ctx context.Context, commit *pfs.Commit, cb func(*fi
 			branch.Name = commitID
 			commitID = ""
 		}
+		repoInfo, err := d.readRepoInfo(ctx, branch.Repo)
+		if err != nil {
+			return err
+		}
+		settings := repoInfo.Settings
+		validate, err := d.reservationValidator(ctx, repoInfo)
+		if err != nil {
+			return err
+		}
+		opts := append(repoWriterOptions(settings), fileset.WithValidator(validate))
+		opts = append(opts, uwOpts...)
 		commitInfo, err := d.inspectCommit(ctx, commit, pfs.CommitState_STARTED)
 		if err != nil {
 			if !errutil.IsNotFoundError(err) || branch.Name == "" {
 				return err
 			}
-			return d.oneOffModifyFile(ctx, renewer, branch, cb)
+			return d.oneOffModifyFile(ctx, renewer, branch, settings, nil, cb, opts...)
 		}
 		if commitInfo.Finished != nil {
 			// The commit is already finished - if the commit was explicitly specified,
@@ -47,45 +76,92 @@ func (d *driver) modifyFile(ctx context.Context, commit *pfs.Commit, cb func(*fi
 				return err
 			}
 			renewer.Add(parentID.HexString())
-			return d.oneOffModifyFile(ctx, renewer, branch, cb, fileset.WithParentID(parentID))
+			return d.oneOffModifyFile(ctx, renewer, branch, settings, parentID, cb, opts...)
 		}
-		return d.withCommitUnorderedWriter(ctx, renewer, commitInfo.Commit, cb)
+		return d.withCommitUnorderedWriter(ctx, renewer, commitInfo.Commit, settings, cb, opts...)
 	})
 }
 
-func (d *driver) oneOffModifyFile(ctx context.Context, renewer *renew.StringSet, branch *pfs.Branch, cb func(*fileset.UnorderedWriter) error, opts ...fileset.UnorderedWriterOption) error {
+func (d *driver) oneOffModifyFile(ctx context.Context, renewer *renew.StringSet, branch *pfs.Branch, settings *pfs.RepoSettings, parentID *fileset.ID, cb func(*fileset.UnorderedWriter) error, opts ...fileset.UnorderedWriterOption) error {
+	var ids []fileset.ID
+	if parentID != nil {
+		opts = append(opts, fileset.WithParentID(parentID))
+		ids = append(ids, *parentID)
+	}
 	id, err := d.withUnorderedWriter(ctx, renewer, false, cb, opts...)
 	if err != nil {
 		return err
 	}
+	ids = append(ids, *id)
+	if err := d.checkFileLimits(ctx, branch.NewCommit(branch.Name), settings, ids); err != nil {
+		return err
+	}
 	return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
-		commit, err := d.startCommit(txnCtx, nil, branch, "")
+		// The branch may have been modified concurrently since the file set
+		// was written. If a commit has been started on it since, the file set
+		// goes into that commit instead, like any other modification to an
+		// open commit. Otherwise the commit is finished as soon as it's made,
+		// which checks that it fits in the repo.
+		head, err := d.openBranchHead(txnCtx, branch)
+		if err != nil {
+			return err
+		}
+		if head != nil {
+			return d.commitStore.AddFileSetTx(txnCtx.SqlTx, head, *id)
+		}
+		commit, err := d.startCommit(txnCtx, nil, branch, "", nil)
 		if err != nil {
 			return err
 		}
 		if err := d.commitStore.AddFileSetTx(txnCtx.SqlTx, commit, *id); err != nil {
 			return err
 		}
-		return d.finishCommit(txnCtx, commit, "")
+		return d.finishCommit(txnCtx, commit, "", nil)
 	})
 }
 
+// openBranchHead returns the head of branch if it's an open commit, or nil if
+// the head is finished or the branch doesn't exist yet.
+func (d *driver) openBranchHead(txnCtx *txncontext.TransactionContext, branch *pfs.Branch) (*pfs.Commit, error) {
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadWrite(txnCtx.SqlTx).Get(pfsdb.BranchKey(branch), branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if branchInfo.Head == nil {
+		return nil, nil
+	}
+	headInfo, err := d.resolveCommit(txnCtx.SqlTx, proto.Clone(branchInfo.Head).(*pfs.Commit))
+	if err != nil {
+		return nil, err
+	}
+	if headInfo.Finished != nil {
+		return nil, nil
+	}
+	return headInfo.Commit, nil
+}
+
 // withCommitWriter calls cb with an unordered writer. All data written to cb is added to the commit, or an error is returned.
-func (d *driver) withCommitUnorderedWriter(ctx context.Context, renewer *renew.StringSet, commit *pfs.Commit, cb func(*fileset.UnorderedWriter) error) error {
+func (d *driver) withCommitUnorderedWriter(ctx context.Context, renewer *renew.StringSet, commit *pfs.Commit, settings *pfs.RepoSettings, cb func(*fileset.UnorderedWriter) error, opts ...fileset.UnorderedWriterOption) error {
 	parentID, err := d.getFileSet(ctx, commit)
 	if err != nil {
 		return err
 	}
 	renewer.Add(parentID.HexString())
-	id, err := d.withUnorderedWriter(ctx, renewer, false, cb, fileset.WithParentID(parentID))
+	id, err := d.withUnorderedWriter(ctx, renewer, false, cb, append(opts, fileset.WithParentID(parentID))...)
 	if err != nil {
 		return err
 	}
+	if err := d.checkFileLimits(ctx, commit, settings, []fileset.ID{*parentID, *id}); err != nil {
+		return err
+	}
 	return d.commitStore.AddFileSet(ctx, commit, *id)
 }
 
 func (d *driver) withUnorderedWriter(ctx context.Context, renewer *renew.StringSet, compact bool, cb func(*fileset.UnorderedWriter) error, opts ...fileset.UnorderedWriterOption) (*fileset.ID, error) {
-	opts = append([]fileset.UnorderedWriterOption{fileset.WithRenewal(defaultTTL, renewer), fileset.WithValidator(validate)}, opts...)
+	opts = append([]fileset.UnorderedWriterOption{fileset.WithRenewal(defaultTTL, renewer), fileset.WithValidator(d.pathPolicy.validate)}, opts...)
 	uw, err := d.storage.NewUnorderedWriter(ctx, opts...)
 	if err != nil {
 		return nil, err
@@ -121,7 +197,7 @@ func (d *driver) openCommit(ctx context.Context, commit *pfs.Commit, opts ...ind
 		}
 		return &pfs.CommitInfo{Commit: commit}, fs, nil
 	}
-	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, commit.Branch.Repo.Name, auth.Permission_REPO_READ); err != nil {
+	if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
 		return nil, nil, err
 	}
 	commitInfo, err := d.inspectCommit(ctx, commit, pfs.CommitState_STARTED)
@@ -139,12 +215,20 @@ func (d *driver) openCommit(ctx context.Context, commit *pfs.Commit, opts ...ind
 	return commitInfo, fs, nil
 }
 
-func (d *driver) copyFile(ctx context.Context, uw *fileset.UnorderedWriter, dst string, src *pfs.File, appendFile bool, tag string) (retErr error) {
+// copyFile copies src to dst. If offset or length is set, only that range of
+// the content of src, which must be a single file, is copied.
+func (d *driver) copyFile(ctx context.Context, uw *fileset.UnorderedWriter, dst string, src *pfs.File, offset, length int64, appendFile bool, tag string) (retErr error) {
 	srcCommitInfo, err := d.inspectCommit(ctx, src.Commit, pfs.CommitState_STARTED)
 	if err != nil {
 		return err
 	}
 	srcCommit := srcCommitInfo.Commit
+	if offset != 0 || length != 0 {
+		return d.copyFileRange(ctx, uw, dst, srcCommit.NewFile(src.Path), src.Tag, offset, length, appendFile, tag)
+	}
+	if isGlob(src.Path) {
+		return d.copyGlob(ctx, uw, dst, srcCommit, src, appendFile, tag)
+	}
 	srcPath := cleanPath(src.Path)
 	dstPath := cleanPath(dst)
 	pathTransform := func(x string) string {
@@ -164,11 +248,308 @@ func (d *driver) copyFile(ctx context.Context, uw *fileset.UnorderedWriter, dst
 	fs = fileset.NewIndexMapper(fs, func(idx *index.Index) *index.Index {
 		idx2 := *idx
 		idx2.Path = pathTransform(idx2.Path)
+		file := *idx.File
+		file.CopiedFrom = srcCommit.NewFile(idx.Path)
+		idx2.File = &file
 		return &idx2
 	})
 	return uw.Copy(ctx, fs, tag, appendFile)
 }
 
+// copyFileRange copies at most length bytes of the content of the file src,
+// starting at offset, to dst, or everything from offset if length is 0. Only
+// the content is copied, by reference to the chunks that hold it, not the
+// file's mode, modification time or metadata.
+func (d *driver) copyFileRange(ctx context.Context, uw *fileset.UnorderedWriter, dst string, src *pfs.File, srcTag string, offset, length int64, appendFile bool, tag string) error {
+	if offset < 0 || length < 0 {
+		return errors.Errorf("offset_bytes and size_bytes cannot be negative")
+	}
+	if isGlob(src.Path) {
+		return errors.Errorf("cannot copy a range of %s, because it's a glob pattern", src.Path)
+	}
+	srcPath := cleanPath(src.Path)
+	if srcPath == "/" {
+		return errors.Errorf("cannot copy a range of the root directory")
+	}
+	_, fs, err := d.openCommit(ctx, src.Commit, index.WithPrefix(srcPath), index.WithTag(srcTag))
+	if err != nil {
+		return err
+	}
+	var dataRefs []*chunk.DataRef
+	var found bool
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		idx := f.Index()
+		if strings.HasPrefix(idx.Path, srcPath+"/") {
+			return errors.Errorf("cannot copy a range of %s, because it's a directory", srcPath)
+		}
+		if idx.Path == srcPath {
+			found = true
+			dataRefs = append(dataRefs, idx.File.DataRefs...)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !found {
+		return pfsserver.ErrFileNotFound{File: src}
+	}
+	// The range of the file's parts is written as a single part.
+	var copied bool
+	fs = fileset.NewIndexFilter(fs, func(idx *index.Index) bool {
+		if idx.Path != srcPath || copied {
+			return false
+		}
+		copied = true
+		return true
+	})
+	fs = fileset.NewIndexMapper(fs, func(idx *index.Index) *index.Index {
+		return &index.Index{
+			Path: cleanPath(dst),
+			File: &index.File{
+				Tag:        idx.File.Tag,
+				DataRefs:   fileset.RangeDataRefs(dataRefs, offset, length),
+				CopiedFrom: src,
+			},
+		}
+	})
+	return uw.Copy(ctx, fs, tag, appendFile)
+}
+
+// listFileChunks calls cb with the chunks of the content of the files at or
+// under file.Path, in order of path and then of offset in the file.
+func (d *driver) listFileChunks(ctx context.Context, file *pfs.File, cb func(*pfs.FileChunk) error) error {
+	commitInfo, err := d.inspectCommit(ctx, file.Commit, pfs.CommitState_STARTED)
+	if err != nil {
+		return err
+	}
+	p := cleanPath(file.Path)
+	_, fs, err := d.openCommit(ctx, commitInfo.Commit, index.WithPrefix(p), index.WithTag(file.Tag))
+	if err != nil {
+		return err
+	}
+	var path string
+	var offset int64
+	return fs.Iterate(ctx, func(f fileset.File) error {
+		idx := f.Index()
+		if p != "/" && idx.Path != p && !strings.HasPrefix(idx.Path, p+"/") {
+			return nil
+		}
+		// A file's parts are iterated in order, so its offsets carry on from
+		// one part to the next.
+		if idx.Path != path {
+			path = idx.Path
+			offset = 0
+		}
+		for _, dataRef := range idx.File.DataRefs {
+			if err := cb(&pfs.FileChunk{
+				Path:        idx.Path,
+				OffsetBytes: offset,
+				SizeBytes:   dataRef.SizeBytes,
+				Hash:        dataRef.Hash,
+			}); err != nil {
+				return err
+			}
+			offset += dataRef.SizeBytes
+		}
+		return nil
+	})
+}
+
+// appendFileSet appends the files in the file set id to the files with the
+// same paths and tags, and returns the number of bytes appended.
+func (d *driver) appendFileSet(ctx context.Context, uw *fileset.UnorderedWriter, id string) (int64, error) {
+	fsid, err := fileset.ParseID(id)
+	if err != nil {
+		return 0, err
+	}
+	fs, err := d.storage.Open(ctx, []fileset.ID{*fsid})
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	fs = fileset.NewIndexMapper(fs, func(idx *index.Index) *index.Index {
+		n += index.SizeBytes(idx)
+		return idx
+	})
+	if err := uw.AppendTagged(ctx, fs); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// copyGlob copies the files and directories in srcCommit that match the glob
+// src.Path into the directory dst. Their paths relative to the directory the
+// glob starts in are kept, so copying /data/**/*.csv to /out copies
+// /data/a/b.csv to /out/a/b.csv.
+func (d *driver) copyGlob(ctx context.Context, uw *fileset.UnorderedWriter, dst string, srcCommit *pfs.Commit, src *pfs.File, appendFile bool, tag string) error {
+	glob := cleanPath(src.Path)
+	prefix := globLiteralPrefix(glob)
+	literal := prefix[:strings.LastIndex(prefix, "/")+1]
+	matches, err := d.globPaths(ctx, srcCommit, glob, src.Tag)
+	if err != nil {
+		return err
+	}
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, p := range matches {
+		if fileset.IsDir(p) {
+			dirs[p] = true
+		} else {
+			files[p] = true
+		}
+	}
+	dstPath := cleanPath(dst)
+	_, fs, err := d.openCommit(ctx, srcCommit, index.WithPrefix(literal), index.WithTag(src.Tag))
+	if err != nil {
+		return err
+	}
+	fs = fileset.NewIndexFilter(fs, func(idx *index.Index) bool {
+		if files[idx.Path] {
+			return true
+		}
+		for dir := path.Dir(idx.Path); dir != "/"; dir = path.Dir(dir) {
+			if dirs[dir+"/"] {
+				return true
+			}
+		}
+		return false
+	})
+	fs = fileset.NewIndexMapper(fs, func(idx *index.Index) *index.Index {
+		idx2 := *idx
+		idx2.Path = path.Join(dstPath, strings.TrimPrefix(idx.Path, literal))
+		file := *idx.File
+		file.CopiedFrom = srcCommit.NewFile(idx.Path)
+		idx2.File = &file
+		return &idx2
+	})
+	return uw.Copy(ctx, fs, tag, appendFile)
+}
+
+// deleteFile deletes the file or directory at request.Path. If the path is a
+// glob pattern, everything in commit that matches it is deleted instead. Like
+// copyFile, a pattern is matched against what's in the commit before the
+// current modifications.
+func (d *driver) deleteFile(ctx context.Context, uw *fileset.UnorderedWriter, commit *pfs.Commit, request *pfs.DeleteFile) error {
+	if !isGlob(request.Path) {
+		uw.Delete(request.Path, request.Tag)
+		return nil
+	}
+	if commit == nil {
+		return errors.Errorf("cannot delete files matching a glob pattern in a file set")
+	}
+	commitInfo, err := d.inspectCommit(ctx, proto.Clone(commit).(*pfs.Commit), pfs.CommitState_STARTED)
+	if err != nil {
+		return err
+	}
+	matches, err := d.globPaths(ctx, commitInfo.Commit, request.Path, request.Tag)
+	if err != nil {
+		return err
+	}
+	for _, p := range matches {
+		if err := uw.Delete(p, request.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// globPaths returns the paths of the files and directories in commit that
+// match glob, leaving out those inside of a matching directory.
+func (d *driver) globPaths(ctx context.Context, commit *pfs.Commit, glob, tag string) ([]string, error) {
+	var paths []string
+	if err := d.globFile(ctx, commit, glob, tag, nil, func(fi *pfs.FileInfo) error {
+		p := fi.File.Path
+		if n := len(paths); n > 0 && fileset.IsDir(paths[n-1]) && strings.HasPrefix(p, paths[n-1]) {
+			return nil
+		}
+		paths = append(paths, p)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// moveFile moves the file or directory at src in commit to dst, keeping the
+// tags of its files. Like copyFile, it moves what's in the commit before the
+// current modifications.
+func (d *driver) moveFile(ctx context.Context, uw *fileset.UnorderedWriter, commit *pfs.Commit, src, dst string) error {
+	srcPath := cleanPath(src)
+	dstPath := cleanPath(dst)
+	if srcPath == "/" {
+		return errors.Errorf("cannot move the root directory")
+	}
+	if dstPath == srcPath || strings.HasPrefix(dstPath, srcPath+"/") {
+		return errors.Errorf("cannot move %s to %s, which is inside of it", srcPath, dstPath)
+	}
+	commitInfo, err := d.inspectCommit(ctx, proto.Clone(commit).(*pfs.Commit), pfs.CommitState_STARTED)
+	if err != nil {
+		return err
+	}
+	_, fs, err := d.openCommit(ctx, commitInfo.Commit, index.WithPrefix(srcPath))
+	if err != nil {
+		return err
+	}
+	var found bool
+	fs = fileset.NewIndexFilter(fs, func(idx *index.Index) bool {
+		if idx.Path == srcPath || strings.HasPrefix(idx.Path, srcPath+"/") {
+			found = true
+			return true
+		}
+		return false
+	})
+	if err := uw.Move(ctx, fs, srcPath, dstPath); err != nil {
+		return err
+	}
+	if !found {
+		return pfsserver.ErrFileNotFound{File: commitInfo.Commit.NewFile(srcPath)}
+	}
+	return nil
+}
+
+// resolveFileCase returns file with its path replaced by the path of the file
+// or directory in its commit that matches it case-insensitively. An exact
+// match is preferred, more than one inexact match is an error, and file is
+// returned as is if nothing matches.
+func (d *driver) resolveFileCase(ctx context.Context, file *pfs.File) (*pfs.File, error) {
+	p := strings.TrimSuffix(cleanPath(file.Path), "/")
+	// Only the part of the path before its first letter is the same in every
+	// case, so that is all that can be used to narrow the search.
+	prefix := p
+	if i := strings.IndexFunc(p, unicode.IsLetter); i >= 0 {
+		prefix = p[:i]
+	}
+	_, fs, err := d.openCommit(ctx, file.Commit, index.WithPrefix(prefix), index.WithTag(file.Tag))
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	if err := fileset.NewDirInserter(fs).Iterate(ctx, func(f fileset.File) error {
+		candidate := strings.TrimSuffix(f.Index().Path, "/")
+		if !strings.EqualFold(candidate, p) {
+			return nil
+		}
+		if candidate == p {
+			matches = []string{candidate}
+			return errutil.ErrBreak
+		}
+		matches = append(matches, candidate)
+		return nil
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return file, nil
+	case 1:
+		resolved := proto.Clone(file).(*pfs.File)
+		resolved.Path = matches[0]
+		return resolved, nil
+	default:
+		return nil, pfsserver.ErrAmbiguousPath{File: file, Matches: matches}
+	}
+}
+
 func (d *driver) getFile(ctx context.Context, file *pfs.File) (Source, error) {
 	commit := file.Commit
 	glob := cleanPath(file.Path)
@@ -223,7 +604,60 @@ func (d *driver) inspectFile(ctx context.Context, file *pfs.File) (*pfs.FileInfo
 	return ret, nil
 }
 
-func (d *driver) listFile(ctx context.Context, file *pfs.File, full bool, cb func(*pfs.FileInfo) error) error {
+// listFileHistory calls cb with the info of file at each commit, going back
+// through the ancestors of file's commit, where it was created or changed,
+// until limit versions have been found (if limit is positive).
+func (d *driver) listFileHistory(ctx context.Context, file *pfs.File, limit int64, cb func(*pfs.FileInfo) error) error {
+	commitInfo, err := d.inspectCommit(ctx, file.Commit, pfs.CommitState_STARTED)
+	if err != nil {
+		return err
+	}
+	// Each version is only known to be a change once the version in the
+	// parent commit is known to differ from it.
+	var version *pfs.FileInfo
+	var sent int64
+	for {
+		fi, err := d.inspectFile(ctx, &pfs.File{
+			Commit: commitInfo.Commit,
+			Path:   file.Path,
+			Tag:    file.Tag,
+		})
+		if err != nil && !pfsserver.IsFileNotFoundErr(err) {
+			return err
+		}
+		if version != nil && !sameFileVersion(version, fi) {
+			if err := cb(version); err != nil {
+				return err
+			}
+			sent++
+			if limit > 0 && sent >= limit {
+				return nil
+			}
+		}
+		version = fi
+		if commitInfo.ParentCommit == nil {
+			break
+		}
+		if commitInfo, err = d.inspectCommit(ctx, commitInfo.ParentCommit, pfs.CommitState_STARTED); err != nil {
+			return err
+		}
+	}
+	if version != nil {
+		return cb(version)
+	}
+	return nil
+}
+
+func sameFileVersion(a, b *pfs.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.FileType == b.FileType && a.SizeBytes == b.SizeBytes && bytes.Equal(a.Hash, b.Hash)
+}
+
+// listFile calls cb with each file and directory in the directory at file,
+// leaving out those whose names start with one of hidden.
+func (d *driver) listFile(ctx context.Context, file *pfs.File, full bool, hidden []string, cb func(*pfs.FileInfo) error) error {
 	name := cleanPath(file.Path)
 	commitInfo, fs, err := d.openCommit(ctx, file.Commit, index.WithPrefix(name), index.WithTag(file.Tag))
 	if err != nil {
@@ -248,13 +682,117 @@ func (d *driver) listFile(ctx context.Context, file *pfs.File, full bool, cb fun
 	}
 	s := NewSource(commitInfo, fs, opts...)
 	return s.Iterate(ctx, func(fi *pfs.FileInfo, _ fileset.File) error {
-		if pathIsChild(name, cleanPath(fi.File.Path)) {
+		p := cleanPath(fi.File.Path)
+		if pathIsChild(name, p) && !isHidden(path.Base(p), hidden) {
 			return cb(fi)
 		}
 		return nil
 	})
 }
 
+// listFileSelected calls cb with the files that listFile lists for request
+// and its filters select, in the order of its sort_by.
+func (d *driver) listFileSelected(ctx context.Context, request *pfs.ListFileRequest, cb func(*pfs.FileInfo) error) error {
+	sorted := request.SortBy != pfs.FileSortBy_BY_PATH || request.Reverse
+	if sorted && request.PageSize > 0 {
+		return errors.Errorf("files can only be paged through in path order")
+	}
+	f, err := d.newFileFilter(ctx, request)
+	if err != nil {
+		return err
+	}
+	hidden := hiddenPrefixes(request.ExcludeHidden, request.HiddenPrefixes)
+	if !sorted {
+		return d.listFile(ctx, request.File, request.Full, hidden, func(fi *pfs.FileInfo) error {
+			if !f.matches(fi) {
+				return nil
+			}
+			return cb(fi)
+		})
+	}
+	var fileInfos []*pfs.FileInfo
+	if err := d.listFile(ctx, request.File, request.Full, hidden, func(fi *pfs.FileInfo) error {
+		if f.matches(fi) {
+			fileInfos = append(fileInfos, fi)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	sortFileInfos(fileInfos, request.SortBy, request.Reverse)
+	for _, fi := range fileInfos {
+		if err := cb(fi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileFilter selects the files listed by listFileSelected.
+type fileFilter struct {
+	minSize, maxSize int64
+	fileType         pfs.FileType
+	// changed, if it's set, holds the paths of the files that were changed in
+	// the commit, and of the directories that contain them.
+	changed map[string]bool
+}
+
+func (d *driver) newFileFilter(ctx context.Context, request *pfs.ListFileRequest) (*fileFilter, error) {
+	if request.MaxSizeBytes > 0 && request.MaxSizeBytes < request.MinSizeBytes {
+		return nil, errors.Errorf("max size (%d) must be at least min size (%d)", request.MaxSizeBytes, request.MinSizeBytes)
+	}
+	f := &fileFilter{
+		minSize:  request.MinSizeBytes,
+		maxSize:  request.MaxSizeBytes,
+		fileType: request.FileType,
+	}
+	if request.ChangedOnly {
+		f.changed = make(map[string]bool)
+		if err := d.diffFile(ctx, nil, request.File, func(oldFi, newFi *pfs.FileInfo) error {
+			p, ok := changedFilePath(oldFi, newFi)
+			if !ok {
+				return nil
+			}
+			for p = cleanPath(p); !f.changed[p]; p = parentPath(p) {
+				f.changed[p] = true
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// matches returns true if fi is selected by the filter.
+func (f *fileFilter) matches(fi *pfs.FileInfo) bool {
+	if int64(fi.SizeBytes) < f.minSize {
+		return false
+	}
+	if f.maxSize > 0 && int64(fi.SizeBytes) > f.maxSize {
+		return false
+	}
+	if f.fileType != pfs.FileType_RESERVED && fi.FileType != f.fileType {
+		return false
+	}
+	return f.changed == nil || f.changed[cleanPath(fi.File.Path)]
+}
+
+// sortFileInfos sorts fileInfos, which are listed in path order, by sortBy,
+// reversed if reverse is set.
+func sortFileInfos(fileInfos []*pfs.FileInfo, sortBy pfs.FileSortBy, reverse bool) {
+	less := func(a, b *pfs.FileInfo) bool { return a.File.Path < b.File.Path }
+	if sortBy == pfs.FileSortBy_BY_FILE_SIZE {
+		less = func(a, b *pfs.FileInfo) bool { return a.SizeBytes > b.SizeBytes }
+	}
+	sort.SliceStable(fileInfos, func(i, j int) bool {
+		if reverse {
+			return less(fileInfos[j], fileInfos[i])
+		}
+		return less(fileInfos[i], fileInfos[j])
+	})
+}
+
 func (d *driver) walkFile(ctx context.Context, file *pfs.File, cb func(*pfs.FileInfo) error) (retErr error) {
 	p := cleanPath(file.Path)
 	if p == "/" {
@@ -278,9 +816,140 @@ func (d *driver) walkFile(ctx context.Context, file *pfs.File, cb func(*pfs.File
 	})
 }
 
-func (d *driver) globFile(ctx context.Context, commit *pfs.Commit, glob string, cb func(*pfs.FileInfo) error) error {
+// directorySizes calls cb with the aggregate size of the directory at file,
+// and of each of its subdirectories down to depth, in path order. Only the
+// index is read, so the contents of the files are never fetched.
+func (d *driver) directorySizes(ctx context.Context, file *pfs.File, depth int64, cb func(*pfs.FileInfo) error) error {
+	p := cleanPath(file.Path)
+	if p == "/" {
+		p = ""
+	}
+	commitInfo, fs, err := d.openCommit(ctx, file.Commit, index.WithPrefix(p), index.WithTag(file.Tag))
+	if err != nil {
+		return err
+	}
+	target := fileset.Clean(p, true)
+	fs = fileset.NewDirInserter(fs)
+	dirs := make(map[string]*pfs.FileInfo)
+	dirInfo := func(dir string) *pfs.FileInfo {
+		fi, ok := dirs[dir]
+		if !ok {
+			fi = &pfs.FileInfo{
+				File:      commitInfo.Commit.NewFile(dir),
+				FileType:  pfs.FileType_DIR,
+				Committed: commitInfo.Finished,
+			}
+			dirs[dir] = fi
+		}
+		return fi
+	}
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		idx := f.Index()
+		if !strings.HasPrefix(idx.Path, target) {
+			return nil
+		}
+		dirInfo(target)
+		rel := strings.TrimSuffix(strings.TrimPrefix(idx.Path, target), "/")
+		if rel == "" {
+			return nil
+		}
+		var size uint64
+		if !fileset.IsDir(idx.Path) {
+			size = uint64(index.SizeBytes(idx))
+		}
+		parts := strings.Split(rel, "/")
+		// Each of the directories containing the entry, from target down to
+		// its parent, gets the entry counted towards its totals.
+		dir := target
+		for level := 0; level < len(parts); level++ {
+			if depth >= 0 && int64(level) > depth {
+				break
+			}
+			fi := dirInfo(dir)
+			fi.SizeBytes += size
+			fi.NumDescendants++
+			if level == len(parts)-1 {
+				fi.NumChildren++
+			}
+			if fileset.IsDir(idx.Path) {
+				fi.NumDirs++
+			} else {
+				fi.NumFiles++
+				if newerTimestamp(idx.File.Mtime, fi.NewestMtime) {
+					fi.NewestMtime = idx.File.Mtime
+				}
+			}
+			dir += parts[level] + "/"
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return &pfsserver.ErrFileNotFound{File: file}
+	}
+	paths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		paths = append(paths, dir)
+	}
+	sort.Strings(paths)
+	for _, dir := range paths {
+		if err := cb(dirs[dir]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listTags returns the tags of the files at or under file's path, with the
+// number and total size of the files written with each tag, sorted by tag.
+func (d *driver) listTags(ctx context.Context, file *pfs.File) (*pfs.ListTagsResponse, error) {
+	p := cleanPath(file.Path)
+	prefix := p
+	if p == "/" {
+		prefix = ""
+	}
+	_, fs, err := d.openCommit(ctx, file.Commit, index.WithPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	dir := fileset.Clean(p, true)
+	tags := make(map[string]*pfs.TagInfo)
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		idx := f.Index()
+		if idx.Path != p && !strings.HasPrefix(idx.Path, dir) {
+			return nil
+		}
+		ti, ok := tags[idx.File.Tag]
+		if !ok {
+			ti = &pfs.TagInfo{Tag: idx.File.Tag}
+			tags[idx.File.Tag] = ti
+		}
+		ti.NumFiles++
+		ti.SizeBytes += uint64(index.SizeBytes(idx))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	resp := &pfs.ListTagsResponse{}
+	for _, ti := range tags {
+		resp.Tags = append(resp.Tags, ti)
+	}
+	sort.Slice(resp.Tags, func(i, j int) bool { return resp.Tags[i].Tag < resp.Tags[j].Tag })
+	return resp, nil
+}
+
+// globFile calls cb with each file and directory matching glob, leaving out
+// those with an element that starts with one of hidden.
+func (d *driver) globFile(ctx context.Context, commit *pfs.Commit, glob, tag string, hidden []string, cb func(*pfs.FileInfo) error) error {
 	glob = cleanPath(glob)
-	commitInfo, fs, err := d.openCommit(ctx, commit, index.WithPrefix(globLiteralPrefix(glob)))
+	prefix := globLiteralPrefix(glob)
+	// Elements named in full by the pattern are never hidden.
+	literal := prefix
+	if prefix != glob {
+		literal = prefix[:strings.LastIndex(prefix, "/")+1]
+	}
+	commitInfo, fs, err := d.openCommit(ctx, commit, index.WithPrefix(prefix), index.WithTag(tag))
 	if err != nil {
 		return err
 	}
@@ -298,7 +967,7 @@ func (d *driver) globFile(ctx context.Context, commit *pfs.Commit, glob string,
 	}
 	s := NewSource(commitInfo, fs, opts...)
 	return s.Iterate(ctx, func(fi *pfs.FileInfo, _ fileset.File) error {
-		if mf(fi.File.Path) {
+		if mf(fi.File.Path) && !isHidden(strings.TrimPrefix(fi.File.Path, literal), hidden) {
 			return cb(fi)
 		}
 		return nil
@@ -322,12 +991,12 @@ func (d *driver) diffFile(ctx context.Context, oldFile, newFile *pfs.File, cb fu
 	}
 	// Do READER authorization check for both newFile and oldFile
 	if oldFile != nil && oldFile.Commit != nil {
-		if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, oldFile.Commit.Branch.Repo.Name, auth.Permission_REPO_READ); err != nil {
+		if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, oldFile.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
 			return err
 		}
 	}
 	if newFile != nil && newFile.Commit != nil {
-		if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, newFile.Commit.Branch.Repo.Name, auth.Permission_REPO_READ); err != nil {
+		if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, newFile.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
 			return err
 		}
 	}
@@ -384,12 +1053,55 @@ func (d *driver) diffFile(ctx context.Context, oldFile, newFile *pfs.File, cb fu
 	return diff.Iterate(ctx, cb)
 }
 
+// changeFeed calls cb with the files changed by each commit on branch,
+// followed by a FileChange with just the commit and its cursor, as the commits
+// are finished. If cursor is set, only the commits after it are included.
+func (d *driver) changeFeed(ctx context.Context, branch *pfs.Branch, cursor string, cb func(*pfs.FileChange) error) error {
+	if branch == nil {
+		return errors.New("branch cannot be nil")
+	}
+	return d.subscribeCommit(ctx, branch.Repo, &subscribeFilter{branch: branch.Name}, nil, cursor, pfs.CommitState_FINISHED, func(ci *pfs.CommitInfo) error {
+		if err := d.diffFile(ctx, nil, ci.Commit.NewFile("/"), func(oldFi, newFi *pfs.FileInfo) error {
+			change := fileChange(ci.Commit, oldFi, newFi)
+			if change == nil {
+				return nil
+			}
+			return cb(change)
+		}); err != nil {
+			return err
+		}
+		return cb(&pfs.FileChange{
+			Commit: ci.Commit,
+			Cursor: ci.Cursor,
+		})
+	})
+}
+
+// fileChange describes a difference reported by diffFile as a FileChange in
+// commit. It returns nil for directories, which change whenever a file in them
+// does, so they aren't reported.
+func fileChange(commit *pfs.Commit, oldFi, newFi *pfs.FileInfo) *pfs.FileChange {
+	change := &pfs.FileChange{Commit: commit}
+	switch {
+	case oldFi == nil:
+		change.Path, change.Type = newFi.File.Path, pfs.FileChangeType_ADDED
+	case newFi == nil:
+		change.Path, change.Type = oldFi.File.Path, pfs.FileChangeType_DELETED
+	default:
+		change.Path, change.Type = newFi.File.Path, pfs.FileChangeType_MODIFIED
+	}
+	if strings.HasSuffix(change.Path, "/") {
+		return nil
+	}
+	return change
+}
+
 // createFileSet creates a new temporary fileset and returns it.
-func (d *driver) createFileSet(ctx context.Context, cb func(*fileset.UnorderedWriter) error) (*fileset.ID, error) {
+func (d *driver) createFileSet(ctx context.Context, cb func(*fileset.UnorderedWriter) error, opts ...fileset.UnorderedWriterOption) (*fileset.ID, error) {
 	var id *fileset.ID
 	if err := d.storage.WithRenewer(ctx, defaultTTL, func(ctx context.Context, renewer *renew.StringSet) error {
 		var err error
-		id, err = d.withUnorderedWriter(ctx, renewer, false, cb)
+		id, err = d.withUnorderedWriter(ctx, renewer, false, cb, opts...)
 		return err
 	}); err != nil {
 		return nil, err
@@ -398,14 +1110,52 @@ func (d *driver) createFileSet(ctx context.Context, cb func(*fileset.UnorderedWr
 }
 
 func (d *driver) renewFileSet(ctx context.Context, id fileset.ID, ttl time.Duration) error {
+	if err := validateFileSetTTL(ttl); err != nil {
+		return err
+	}
+	_, err := d.storage.SetTTL(ctx, id, ttl)
+	return err
+}
+
+// composeFileSet composes the file sets at ids into a new file set, which
+// expires after ttl, or defaultTTL if it isn't set.
+func (d *driver) composeFileSet(ctx context.Context, ids []fileset.ID, ttl time.Duration) (*fileset.ID, error) {
+	if len(ids) == 0 {
+		return nil, errors.Errorf("must compose at least one file set")
+	}
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	if err := validateFileSetTTL(ttl); err != nil {
+		return nil, err
+	}
+	return d.storage.Compose(ctx, ids, ttl)
+}
+
+func (d *driver) inspectFileSet(ctx context.Context, id fileset.ID) (*pfs.FileSetInfo, error) {
+	size, err := d.storage.SizeOf(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := d.storage.Flatten(ctx, []fileset.ID{id})
+	if err != nil {
+		return nil, err
+	}
+	return &pfs.FileSetInfo{
+		FileSetId: id.HexString(),
+		SizeBytes: size,
+		Layers:    int64(len(layers)),
+	}, nil
+}
+
+func validateFileSetTTL(ttl time.Duration) error {
 	if ttl < time.Second {
 		return errors.Errorf("ttl (%d) must be at least one second", ttl)
 	}
 	if ttl > maxTTL {
 		return errors.Errorf("ttl (%d) exceeds max ttl (%d)", ttl, maxTTL)
 	}
-	_, err := d.storage.SetTTL(ctx, id, ttl)
-	return err
+	return nil
 }
 
 func (d *driver) addFileSet(txnCtx *txncontext.TransactionContext, commit *pfs.Commit, filesetID fileset.ID) error {
@@ -419,6 +1169,83 @@ func (d *driver) addFileSet(txnCtx *txncontext.TransactionContext, commit *pfs.C
 	return d.commitStore.AddFileSetTx(txnCtx.SqlTx, commitInfo.Commit, filesetID)
 }
 
+// modifyFileInTransaction writes the changes that cb makes to a file set, and
+// appends adding it to commit to the stored transaction activeTxn, so that they're
+// made when it's finished, atomically with the rest of it. The file set is held
+// for the transaction until then, or until it's deleted.
+func (d *driver) modifyFileInTransaction(ctx context.Context, activeTxn *transaction.Transaction, commit *pfs.Commit, cb func(*fileset.UnorderedWriter) error, uwOpts ...fileset.UnorderedWriterOption) error {
+	return d.storage.WithRenewer(ctx, defaultTTL, func(ctx context.Context, renewer *renew.StringSet) error {
+		// The repo may be created later in the transaction, in which case the
+		// files are written with the default settings.
+		var settings *pfs.RepoSettings
+		if repoInfo, err := d.readRepoInfo(ctx, commit.Branch.Repo); err == nil {
+			settings = repoInfo.Settings
+		} else if !pfsserver.IsRepoNotFoundErr(err) {
+			return err
+		}
+		opts := append(repoWriterOptions(settings), uwOpts...)
+		// Deleting a directory deletes the files in it as of the commit, or as of
+		// the head of its branch if the commit is started in the transaction.
+		parentID, err := d.getFileSet(ctx, commit)
+		if errutil.IsNotFoundError(err) && commit.GetBranch().GetName() != "" {
+			parentID, err = d.getFileSet(ctx, commit.Branch.NewCommit(""))
+		}
+		if err != nil && !errutil.IsNotFoundError(err) {
+			return err
+		}
+		if parentID != nil {
+			renewer.Add(parentID.HexString())
+			opts = append(opts, fileset.WithParentID(parentID))
+		}
+		id, err := d.withUnorderedWriter(ctx, renewer, false, cb, opts...)
+		if err != nil {
+			return err
+		}
+		// The file set is held for as long as the stored transaction exists,
+		// however long that is. The hold is released when the transaction is
+		// finished or deleted.
+		if err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+			return d.storage.HoldTx(txnCtx.SqlTx, transactionHolder(activeTxn.ID), *id, track.NoTTL)
+		}); err != nil {
+			return err
+		}
+		if err := d.txnEnv.WithTransaction(ctx, func(txn txnenv.Transaction) error {
+			return txn.AddFileSet(&pfs.AddFileSetRequest{
+				Commit:    commit,
+				FileSetId: id.HexString(),
+			})
+		}, nil); err != nil {
+			if err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+				return d.storage.ReleaseTx(txnCtx.SqlTx, transactionHolder(activeTxn.ID), *id)
+			}); err != nil {
+				log.Errorf("error releasing file set %v held for transaction %v: %v", id.HexString(), activeTxn.ID, err)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// releaseTransactionFileSets releases the file sets held for the stored
+// transaction txnID. File sets that aren't held for it, such as ones that were
+// added to it by ID, are left alone.
+func (d *driver) releaseTransactionFileSets(txnCtx *txncontext.TransactionContext, txnID string, fileSetIDs []string) error {
+	for _, fileSetID := range fileSetIDs {
+		id, err := fileset.ParseID(fileSetID)
+		if err != nil {
+			return err
+		}
+		if err := d.storage.ReleaseTx(txnCtx.SqlTx, transactionHolder(txnID), *id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transactionHolder(txnID string) string {
+	return "transaction/" + txnID
+}
+
 func (d *driver) getFileSet(ctx context.Context, commit *pfs.Commit) (*fileset.ID, error) {
 	commitInfo, err := d.getCommit(ctx, commit)
 	if err != nil {
@@ -491,3 +1318,105 @@ func (d *driver) sizeOfCommit(ctx context.Context, commit *pfs.Commit) (int64, e
 	}
 	return d.storage.SizeOf(ctx, *fsid)
 }
+
+// commitDetails describes the filesets that back a commit.
+func (d *driver) commitDetails(ctx context.Context, commitInfo *pfs.CommitInfo) (*pfs.CommitDetails, error) {
+	commit := commitInfo.Commit
+	details := &pfs.CommitDetails{}
+	diffIDs, err := d.commitStore.GetDiffFileSets(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range diffIDs {
+		details.DiffFilesetIds = append(details.DiffFilesetIds, id.HexString())
+	}
+	if commitInfo.Finished != nil {
+		totalID, err := d.commitStore.GetTotalFileSet(ctx, commit)
+		if err != nil && err != errNoTotalFileSet {
+			return nil, err
+		}
+		if totalID != nil {
+			details.TotalFilesetId = totalID.HexString()
+		}
+	}
+	id, err := d.getFileSet(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	prims, err := d.storage.Flatten(ctx, []fileset.ID{*id})
+	if err != nil {
+		return nil, err
+	}
+	details.Layers = int64(len(prims))
+	if details.Compacted, err = d.storage.IsCompacted(ctx, []fileset.ID{*id}); err != nil {
+		return nil, err
+	}
+	if err := d.storage.Chunks(ctx, *id, func(chunk.ID) error {
+		details.ChunkCount++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	fs, err := d.storage.Open(ctx, []fileset.ID{*id})
+	if err != nil {
+		return nil, err
+	}
+	for _, deletive := range []bool{false, true} {
+		if err := fs.Iterate(ctx, func(f fileset.File) error {
+			details.IndexSizeBytes += uint64(f.Index().Size())
+			return nil
+		}, deletive); err != nil {
+			return nil, err
+		}
+	}
+	return details, nil
+}
+
+// clearCommitPaths rewrites the diff of an open commit without the changes
+// to files that match paths.
+func (d *driver) clearCommitPaths(ctx context.Context, commit *pfs.Commit, paths []string) error {
+	var mfs []func(string) bool
+	for _, p := range paths {
+		mf, err := globMatchFunction(cleanPath(p))
+		if err != nil {
+			return err
+		}
+		mfs = append(mfs, mf)
+	}
+	// A file is cleared if it, or any directory above it, matches.
+	cleared := func(p string) bool {
+		for _, mf := range mfs {
+			for x := p; x != "/"; x = path.Dir(x) {
+				if mf(x) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	ids, err := d.commitStore.GetDiffFileSets(ctx, commit)
+	if err != nil {
+		return err
+	}
+	return d.storage.WithRenewer(ctx, defaultTTL, func(ctx context.Context, renewer *renew.StringSet) error {
+		fs, err := d.storage.Open(ctx, ids)
+		if err != nil {
+			return err
+		}
+		fs = fileset.NewIndexFilter(fs, func(idx *index.Index) bool {
+			return !cleared(idx.Path)
+		})
+		w := d.storage.NewWriter(ctx, fileset.WithTTL(defaultTTL))
+		if err := fileset.CopyFiles(ctx, w, fs, true); err != nil {
+			return err
+		}
+		id, err := w.Close()
+		if err != nil {
+			return err
+		}
+		renewer.Add(id.HexString())
+		return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+			return d.commitStore.ReplaceDiffFileSetsTx(txnCtx.SqlTx, commit, ids, *id)
+		})
+	})
+}
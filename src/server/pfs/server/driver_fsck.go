@@ -2,14 +2,21 @@ package server
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
 
 	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 )
 
@@ -81,14 +88,15 @@ func (e ErrBranchSubvenanceTransitivity) Error() string {
 	return fmt.Sprintf("consistency error: branch %s is missing branch %s in its subvenance\n", e.BranchInfo.Branch, e.MissingSubvenance)
 }
 
-// ErrBranchInfoNotFound Branch info could not be found. Typically because of an incomplete deletion of a branch.
-// This struct contains all the information that was used to demonstrate that this invariant is not being satisfied.
-type ErrBranchInfoNotFound struct {
-	Branch *pfs.Branch
+// ErrDanglingBranchProvenance A branch's provenance or subvenance includes a branch that could not be found.
+// Typically because of an incomplete deletion of a branch.
+type ErrDanglingBranchProvenance struct {
+	BranchInfo *pfs.BranchInfo
+	Missing    *pfs.Branch
 }
 
-func (e ErrBranchInfoNotFound) Error() string {
-	return fmt.Sprintf("consistency error: the branch %v on repo %v could not be found\n", e.Branch.Name, e.Branch.Repo)
+func (e ErrDanglingBranchProvenance) Error() string {
+	return fmt.Sprintf("consistency error: branch %s refers to branch %s in its provenance or subvenance, which could not be found\n", e.BranchInfo.Branch, e.Missing)
 }
 
 // ErrCommitInfoNotFound Commit info could not be found. Typically because of an incomplete deletion of a commit.
@@ -124,34 +132,79 @@ func (e ErrMissingBranchHead) Error() string {
 	return fmt.Sprintf("consistency error: branch %s does not have a head commit", e.Branch)
 }
 
+// ErrOrphanedCommit indicates that a commit's repo could not be found. Typically because of an incomplete
+// deletion of a repo.
+type ErrOrphanedCommit struct {
+	Commit *pfs.Commit
+}
+
+func (e ErrOrphanedCommit) Error() string {
+	return fmt.Sprintf("consistency error: the repo of commit %s could not be found", e.Commit)
+}
+
+// ErrMissingFileSet indicates that a commit references a fileset that could not be found. If only its total
+// fileset is missing, it can be computed again from the commit's diff.
+type ErrMissingFileSet struct {
+	Commit *pfs.Commit
+	Diff   bool
+}
+
+func (e ErrMissingFileSet) Error() string {
+	which := "total"
+	if e.Diff {
+		which = "diff"
+	}
+	return fmt.Sprintf("consistency error: a %s fileset of commit %s could not be found", which, e.Commit)
+}
+
 // fsck verifies that pfs satisfies the following invariants:
 // 1. Branch provenance is transitive
 // 2. Head commit provenance has heads of branch's branch provenance
-// If fix is true it will attempt to fix as many of these issues as it can.
-func (d *driver) fsck(ctx context.Context, fix bool, cb func(*pfs.FsckResponse) error) error {
-	onError := func(err error) error { return cb(&pfs.FsckResponse{Error: err.Error()}) }
-
-	// TODO(global ids): no fixable fsck issues?
-	// onFix := func(fix string) error { return cb(&pfs.FsckResponse{Fix: fix}) }
+// 3. Commits belong to repos that exist, and reference filesets that exist
+// Each issue it finds is passed to cb, after fixing it if fixLevel allows.
+func (d *driver) fsck(ctx context.Context, fixLevel pfs.FsckFixLevel, cb func(*pfs.FsckResponse) error) error {
+	return d.checkConsistency(ctx, func(err error) error {
+		issue := fsckIssue(err)
+		if issue.FixLevel != pfs.FsckFixLevel_REPORT_ONLY && issue.FixLevel <= fixLevel {
+			action, err := d.fixFsckIssue(ctx, err)
+			if err != nil {
+				return err
+			}
+			issue.Action = action
+		}
+		return cb(&pfs.FsckResponse{
+			Error: issue.Description,
+			Fix:   issue.Action,
+			Issue: issue,
+		})
+	})
+}
 
-	// collect all the info for the branches and commits in pfs
+// checkConsistency implements fsck, passing each violation it finds to
+// onError as one of the consistency error types above.
+func (d *driver) checkConsistency(ctx context.Context, onError func(error) error) error {
+	// collect all the info for the repos, branches and commits in pfs
+	repoInfos := make(map[string]*pfs.RepoInfo)
 	branchInfos := make(map[string]*pfs.BranchInfo)
 	commitInfos := make(map[string]*pfs.CommitInfo)
-	newCommitInfos := make(map[string]*pfs.CommitInfo)
 	repoInfo := &pfs.RepoInfo{}
 	if err := d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions(), func(string) error {
-		commitInfo := &pfs.CommitInfo{}
-		if err := d.commits.ReadOnly(ctx).GetByIndex(pfsdb.CommitsRepoIndex, pfsdb.RepoKey(repoInfo.Repo), commitInfo, col.DefaultOptions(), func(string) error {
-			commitInfos[pfsdb.CommitKey(commitInfo.Commit)] = proto.Clone(commitInfo).(*pfs.CommitInfo)
-			return nil
-		}); err != nil {
-			return err
-		}
-		branchInfo := &pfs.BranchInfo{}
-		return d.branches.ReadOnly(ctx).GetByIndex(pfsdb.BranchesRepoIndex, pfsdb.RepoKey(repoInfo.Repo), branchInfo, col.DefaultOptions(), func(string) error {
-			branchInfos[pfsdb.BranchKey(branchInfo.Branch)] = proto.Clone(branchInfo).(*pfs.BranchInfo)
-			return nil
-		})
+		repoInfos[pfsdb.RepoKey(repoInfo.Repo)] = proto.Clone(repoInfo).(*pfs.RepoInfo)
+		return nil
+	}); err != nil {
+		return err
+	}
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadOnly(ctx).List(branchInfo, col.DefaultOptions(), func(string) error {
+		branchInfos[pfsdb.BranchKey(branchInfo.Branch)] = proto.Clone(branchInfo).(*pfs.BranchInfo)
+		return nil
+	}); err != nil {
+		return err
+	}
+	commitInfo := &pfs.CommitInfo{}
+	if err := d.commits.ReadOnly(ctx).List(commitInfo, col.DefaultOptions(), func(string) error {
+		commitInfos[pfsdb.CommitKey(commitInfo.Commit)] = proto.Clone(commitInfo).(*pfs.CommitInfo)
+		return nil
 	}); err != nil {
 		return err
 	}
@@ -160,6 +213,25 @@ func (d *driver) fsck(ctx context.Context, fix bool, cb func(*pfs.FsckResponse)
 	for _, bi := range branchInfos {
 		// we expect the branch's provenance to equal the union of the provenances of the branch's direct provenances
 		// i.e. union(branch, branch.Provenance) = union(branch, branch.DirectProvenance, branch.DirectProvenance.Provenance)
+		dangling := false
+		for _, branches := range [][]*pfs.Branch{bi.DirectProvenance, bi.Provenance, bi.Subvenance} {
+			for _, branch := range branches {
+				if _, ok := branchInfos[pfsdb.BranchKey(branch)]; !ok {
+					dangling = true
+					if err := onError(ErrDanglingBranchProvenance{
+						BranchInfo: bi,
+						Missing:    branch,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if dangling {
+			// the other checks would report the same missing branches
+			continue
+		}
+
 		direct := bi.DirectProvenance
 		union := []*pfs.Branch{bi.Branch}
 		for _, directProvenance := range direct {
@@ -204,13 +276,7 @@ func (d *driver) fsck(ctx context.Context, fix bool, cb func(*pfs.FsckResponse)
 			// provBranch.Head != nil implies branch.Head.Provenance contains
 			// provBranch.Head
 			for _, provBranch := range bi.Provenance {
-				provBranchInfo, ok := branchInfos[pfsdb.BranchKey(provBranch)]
-				if !ok {
-					if err := onError(ErrBranchInfoNotFound{Branch: provBranch}); err != nil {
-						return err
-					}
-					continue
-				}
+				provBranchInfo := branchInfos[pfsdb.BranchKey(provBranch)]
 				if provBranchInfo.Head != nil {
 					// in this case, the headCommit Provenance should contain provBranch.Head
 					if _, ok := commitInfos[pfsdb.CommitKey(bi.Head)]; !ok {
@@ -228,6 +294,28 @@ func (d *driver) fsck(ctx context.Context, fix bool, cb func(*pfs.FsckResponse)
 
 	// For every commit
 	for _, commitInfo := range commitInfos {
+		// The commit's repo should exist
+		if _, ok := repoInfos[pfsdb.RepoKey(commitInfo.Commit.Branch.Repo)]; !ok {
+			if err := onError(ErrOrphanedCommit{Commit: commitInfo.Commit}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Every fileset that the commit references should exist
+		totalMissing, diffMissing, err := d.missingFileSets(ctx, commitInfo.Commit)
+		if err != nil {
+			return err
+		}
+		if totalMissing || diffMissing {
+			if err := onError(ErrMissingFileSet{
+				Commit: commitInfo.Commit,
+				Diff:   diffMissing,
+			}); err != nil {
+				return err
+			}
+		}
+
 		// Every parent commit info should exist and point to this as a child
 		if commitInfo.ParentCommit != nil {
 			parentCommitInfo, ok := commitInfos[pfsdb.CommitKey(commitInfo.ParentCommit)]
@@ -283,19 +371,226 @@ func (d *driver) fsck(ctx context.Context, fix bool, cb func(*pfs.FsckResponse)
 	}
 
 	// TODO(global ids): is there any verification we can do for commitsets?
+	return nil
+}
+
+// missingFileSets returns whether commit's total fileset, and any of its diff
+// filesets, don't exist.
+func (d *driver) missingFileSets(ctx context.Context, commit *pfs.Commit) (totalMissing, diffMissing bool, retErr error) {
+	var total *fileset.ID
+	var diff []fileset.ID
+	if err := dbutil.WithTx(ctx, d.env.GetDBClient(), func(tx *sqlx.Tx) error {
+		var err error
+		if diff, err = getDiff(tx, commit); err != nil {
+			return err
+		}
+		if total, err = getTotal(tx, commit); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return false, false, err
+	}
+	if total != nil {
+		exists, err := d.storage.Exists(ctx, *total)
+		if err != nil {
+			return false, false, err
+		}
+		totalMissing = !exists
+	}
+	for _, id := range diff {
+		exists, err := d.storage.Exists(ctx, id)
+		if err != nil {
+			return false, false, err
+		}
+		if !exists {
+			diffMissing = true
+			break
+		}
+	}
+	return totalMissing, diffMissing, nil
+}
 
-	if fix {
-		return col.NewSQLTx(ctx, d.env.GetDBClient(), func(sqlTx *sqlx.Tx) error {
-			for _, ci := range newCommitInfos {
-				// We've observed users getting ErrExists from this create,
-				// which doesn't make a lot of sense, but we insulate against
-				// it anyways so it doesn't prevent the command from working.
-				if err := d.commits.ReadWrite(sqlTx).Create(pfsdb.CommitKey(ci.Commit), ci); err != nil && !col.IsErrExists(err) {
+// fsckIssue returns the issue that a consistency error describes, and the
+// level that fixes it.
+func fsckIssue(err error) *pfs.FsckIssue {
+	issue := &pfs.FsckIssue{Description: err.Error()}
+	switch err := err.(type) {
+	case ErrBranchProvenanceTransitivity:
+		issue.Type = pfs.FsckIssueType_BRANCH_PROVENANCE_TRANSITIVITY
+		issue.Branch = err.BranchInfo.Branch
+	case ErrBranchSubvenanceTransitivity:
+		issue.Type = pfs.FsckIssueType_BRANCH_SUBVENANCE_TRANSITIVITY
+		issue.Branch = err.BranchInfo.Branch
+		issue.FixLevel = pfs.FsckFixLevel_SAFE_FIX
+	case ErrDanglingBranchProvenance:
+		issue.Type = pfs.FsckIssueType_DANGLING_BRANCH_PROVENANCE
+		issue.Branch = err.BranchInfo.Branch
+		issue.FixLevel = pfs.FsckFixLevel_SAFE_FIX
+	case ErrCommitInfoNotFound:
+		issue.Type = pfs.FsckIssueType_COMMIT_INFO_NOT_FOUND
+		issue.Commit = err.Commit
+	case ErrCommitAncestryBroken:
+		issue.Type = pfs.FsckIssueType_COMMIT_ANCESTRY_BROKEN
+		issue.Commit = err.Child
+	case ErrMissingBranchHead:
+		issue.Type = pfs.FsckIssueType_MISSING_BRANCH_HEAD
+		issue.Branch = err.Branch
+	case ErrOrphanedCommit:
+		issue.Type = pfs.FsckIssueType_ORPHANED_COMMIT
+		issue.Commit = err.Commit
+		issue.FixLevel = pfs.FsckFixLevel_AGGRESSIVE_FIX
+	case ErrMissingFileSet:
+		issue.Type = pfs.FsckIssueType_MISSING_FILESET
+		issue.Commit = err.Commit
+		issue.FixLevel = pfs.FsckFixLevel_SAFE_FIX
+		if err.Diff {
+			issue.FixLevel = pfs.FsckFixLevel_AGGRESSIVE_FIX
+		}
+	}
+	return issue
+}
+
+// fixFsckIssue fixes the issue that a consistency error describes, and
+// returns what it did.
+func (d *driver) fixFsckIssue(ctx context.Context, err error) (string, error) {
+	var action string
+	if err := col.NewSQLTx(ctx, d.env.GetDBClient(), func(sqlTx *sqlx.Tx) error {
+		switch err := err.(type) {
+		case ErrBranchSubvenanceTransitivity:
+			branchInfo := &pfs.BranchInfo{}
+			if err := d.branches.ReadWrite(sqlTx).Update(pfsdb.BranchKey(err.BranchInfo.Branch), branchInfo, func() error {
+				add(&branchInfo.Subvenance, err.MissingSubvenance)
+				return nil
+			}); err != nil {
+				return err
+			}
+			action = fmt.Sprintf("added branch %s to the subvenance of branch %s", err.MissingSubvenance, err.BranchInfo.Branch)
+		case ErrDanglingBranchProvenance:
+			branchInfo := &pfs.BranchInfo{}
+			if err := d.branches.ReadWrite(sqlTx).Update(pfsdb.BranchKey(err.BranchInfo.Branch), branchInfo, func() error {
+				del(&branchInfo.DirectProvenance, err.Missing)
+				del(&branchInfo.Provenance, err.Missing)
+				del(&branchInfo.Subvenance, err.Missing)
+				return nil
+			}); err != nil {
+				return err
+			}
+			action = fmt.Sprintf("removed branch %s from the provenance and subvenance of branch %s", err.Missing, err.BranchInfo.Branch)
+		case ErrOrphanedCommit:
+			if err := d.commits.ReadWrite(sqlTx).Delete(pfsdb.CommitKey(err.Commit)); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+			if err := d.commitStore.ExpireFileSetsTx(sqlTx, err.Commit); err != nil {
+				return err
+			}
+			action = fmt.Sprintf("deleted commit %s and its data", err.Commit)
+		case ErrMissingFileSet:
+			if !err.Diff {
+				if err := d.commitStore.DropTotalFileSetTx(sqlTx, err.Commit); err != nil {
 					return err
 				}
+				action = fmt.Sprintf("dropped the total fileset of commit %s, to be computed again from its diff", err.Commit)
+				return nil
+			}
+			if err := d.commitStore.DropFileSetsTx(sqlTx, err.Commit); err != nil {
+				return err
+			}
+			action = fmt.Sprintf("dropped the filesets of commit %s, which now has its parent's files", err.Commit)
+		default:
+			return errors.Errorf("fsck cannot fix %v", err)
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+var (
+	// fsckFindings is the number of consistency errors of each kind found by
+	// the last background fsck. Alerting on it being nonzero tells operators
+	// about inconsistencies without running pachctl fsck.
+	fsckFindings = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pachyderm",
+			Subsystem: "pfs",
+			Name:      "fsck_findings",
+			Help:      "Number of consistency errors found by the last background fsck, by kind",
+		},
+		[]string{"kind"},
+	)
+	// fsckLastRun is when the last background fsck finished.
+	fsckLastRun = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pachyderm",
+			Subsystem: "pfs",
+			Name:      "fsck_last_run_timestamp_seconds",
+			Help:      "Unix time at which the last background fsck finished",
+		},
+	)
+)
+
+// fsckFindingKinds are the kinds of consistency error that fsck reports.
+var fsckFindingKinds = []string{
+	"branch_provenance_transitivity",
+	"branch_subvenance_transitivity",
+	"dangling_branch_provenance",
+	"commit_info_not_found",
+	"commit_ancestry_broken",
+	"missing_branch_head",
+	"orphaned_commit",
+	"missing_fileset",
+	"unknown",
+}
+
+// fsckFindingKind returns the kind label for a consistency error, which is
+// the name of its issue type.
+func fsckFindingKind(err error) string {
+	t := fsckIssue(err).Type
+	if t == pfs.FsckIssueType_FSCK_ISSUE_UNKNOWN {
+		return "unknown"
+	}
+	return strings.ToLower(t.String())
+}
+
+// checkConsistencyForever runs fsck every interval, without fixing anything.
+// Each consistency error is logged as a warning, and the number of each kind
+// is published as a Prometheus metric.
+func (d *driver) checkConsistencyForever(ctx context.Context, intervalStr string) error {
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid fsck interval %q", intervalStr)
+	}
+	for _, metric := range []prometheus.Collector{fsckFindings, fsckLastRun} {
+		if err := prometheus.Register(metric); err != nil {
+			// metrics may be redundantly registered; ignore these errors
+			if !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+				log.Errorf("error registering prometheus metric: %v", err)
 			}
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		case <-ticker.C:
+		}
+		counts := make(map[string]int)
+		if err := d.checkConsistency(ctx, func(err error) error {
+			kind := fsckFindingKind(err)
+			counts[kind]++
+			log.WithField("kind", kind).Warnf("fsck: %v", err)
 			return nil
-		})
+		}); err != nil {
+			log.Errorf("error in background fsck: %v", err)
+			continue
+		}
+		for _, kind := range fsckFindingKinds {
+			fsckFindings.WithLabelValues(kind).Set(float64(counts[kind]))
+		}
+		fsckLastRun.SetToCurrentTime()
 	}
-	return nil
 }
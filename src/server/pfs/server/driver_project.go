@@ -0,0 +1,163 @@
+package server
+
+import (
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ancestry"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+func (d *driver) createProject(txnCtx *txncontext.TransactionContext, project *pfs.Project, description string, update bool, defaults *pfs.ProjectDefaults) error {
+	// Validate arguments
+	if project == nil {
+		return errors.New("project cannot be nil")
+	}
+	if err := ancestry.ValidateName(project.Name); err != nil {
+		return err
+	}
+	for branch, trigger := range defaults.GetBranchTriggers() {
+		if trigger.GetBranch() == "" {
+			return errors.Errorf("default trigger for branch %q must specify a branch to trigger on", branch)
+		}
+		if trigger.Branch == branch {
+			return errors.Errorf("default trigger for branch %q cannot trigger on itself", branch)
+		}
+	}
+	// Check that the user is logged in, if auth is active.
+	if _, err := d.env.AuthServer().WhoAmI(txnCtx.ClientContext, &auth.WhoAmIRequest{}); err != nil && !auth.IsErrNotActivated(err) {
+		return errors.Wrapf(grpcutil.ScrubGRPC(err), "error authenticating (must log in to create a project)")
+	}
+
+	projects := d.projects.ReadWrite(txnCtx.SqlTx)
+	var existingProjectInfo pfs.ProjectInfo
+	if err := projects.Get(pfsdb.ProjectKey(project), &existingProjectInfo); err != nil {
+		if !col.IsErrNotFound(err) {
+			return errors.Wrapf(err, "error checking whether %q exists", project)
+		}
+		return projects.Create(pfsdb.ProjectKey(project), &pfs.ProjectInfo{
+			Project:     project,
+			Created:     txnCtx.Timestamp,
+			Description: description,
+			Defaults:    defaults,
+		})
+	}
+	if !update {
+		return pfsserver.ErrProjectExists{Project: project}
+	}
+	if existingProjectInfo.Description == description && proto.Equal(existingProjectInfo.Defaults, defaults) {
+		return nil
+	}
+	existingProjectInfo.Description = description
+	existingProjectInfo.Defaults = defaults
+	return projects.Put(pfsdb.ProjectKey(project), &existingProjectInfo)
+}
+
+// newRepoSettings returns the settings that a new repo starts with, which are
+// the defaults of the project it is created in. It also enforces the
+// project's limit on the number of repos it may contain.
+func (d *driver) newRepoSettings(txnCtx *txncontext.TransactionContext, repo *pfs.Repo) (*pfs.RepoSettings, error) {
+	if repo.Project.GetName() == "" {
+		return nil, nil
+	}
+	projectInfo, err := d.inspectProject(txnCtx, repo.Project)
+	if err != nil {
+		return nil, err
+	}
+	if maxRepos := projectInfo.Defaults.GetMaxRepos(); maxRepos > 0 && repo.Type == pfs.UserRepoType {
+		// The repos are counted in the transaction, so that concurrent
+		// CreateRepos can't both pass the limit.
+		var count uint64
+		repoInfo := &pfs.RepoInfo{}
+		if err := d.repos.ReadWrite(txnCtx.SqlTx).GetByIndex(pfsdb.ReposTypeIndex, pfs.UserRepoType, repoInfo, col.DefaultOptions(), func(string) error {
+			if repoInfo.Repo.Project.GetName() == repo.Project.Name {
+				count++
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if count >= maxRepos {
+			return nil, errors.Errorf("project %q already contains the maximum number of repos (%d)", repo.Project, maxRepos)
+		}
+	}
+	if settings := projectInfo.Defaults.GetRepoSettings(); settings != nil {
+		return proto.Clone(settings).(*pfs.RepoSettings), nil
+	}
+	return nil, nil
+}
+
+// defaultBranchTrigger returns the trigger that a new branch gets from the
+// defaults of the project its repo is in, if any.
+func (d *driver) defaultBranchTrigger(txnCtx *txncontext.TransactionContext, branch *pfs.Branch) (*pfs.Trigger, error) {
+	if branch.Repo.Project.GetName() == "" || branch.Repo.Type != pfs.UserRepoType {
+		return nil, nil
+	}
+	projectInfo, err := d.inspectProject(txnCtx, branch.Repo.Project)
+	if err != nil {
+		return nil, err
+	}
+	trigger, ok := projectInfo.Defaults.GetBranchTriggers()[branch.Name]
+	if !ok {
+		return nil, nil
+	}
+	if err := d.branches.ReadWrite(txnCtx.SqlTx).Get(pfsdb.BranchKey(branch), &pfs.BranchInfo{}); err == nil {
+		// only branches that are being created get the default trigger
+		return nil, nil
+	} else if !col.IsErrNotFound(err) {
+		return nil, err
+	}
+	return proto.Clone(trigger).(*pfs.Trigger), nil
+}
+
+func (d *driver) inspectProject(txnCtx *txncontext.TransactionContext, project *pfs.Project) (*pfs.ProjectInfo, error) {
+	// Validate arguments
+	if project == nil {
+		return nil, errors.New("project cannot be nil")
+	}
+	result := &pfs.ProjectInfo{}
+	if err := d.projects.ReadWrite(txnCtx.SqlTx).Get(pfsdb.ProjectKey(project), result); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, pfsserver.ErrProjectNotFound{Project: project}
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *driver) listProject(txnCtx *txncontext.TransactionContext) (*pfs.ListProjectResponse, error) {
+	result := &pfs.ListProjectResponse{}
+	projectInfo := &pfs.ProjectInfo{}
+	if err := d.projects.ReadWrite(txnCtx.SqlTx).List(projectInfo, col.DefaultOptions(), func(string) error {
+		result.ProjectInfo = append(result.ProjectInfo, proto.Clone(projectInfo).(*pfs.ProjectInfo))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *driver) deleteProject(txnCtx *txncontext.TransactionContext, project *pfs.Project, force bool) error {
+	if _, err := d.inspectProject(txnCtx, project); err != nil {
+		return err
+	}
+	repoInfos, err := d.listRepo(txnCtx.ClientContext, !includeAuth, &pfs.ListRepoRequest{Type: pfs.UserRepoType, Project: project})
+	if err != nil {
+		return err
+	}
+	if len(repoInfos.RepoInfo) > 0 && !force {
+		return errors.Errorf("cannot delete project %q as it still contains %d repo(s)", project, len(repoInfos.RepoInfo))
+	}
+	for _, repoInfo := range repoInfos.RepoInfo {
+		if err := d.deleteRepo(txnCtx, repoInfo.Repo, force); err != nil {
+			return errors.Wrapf(err, "error deleting repo %q", repoInfo.Repo)
+		}
+	}
+	return d.projects.ReadWrite(txnCtx.SqlTx).Delete(pfsdb.ProjectKey(project))
+}
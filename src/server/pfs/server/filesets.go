@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
+)
+
+// filterFileSet makes a new file set with the files in the file set at id
+// whose paths start with prefix and match glob, or with the ones that don't
+// if exclude is true. A file matches glob if its path or the path of one of
+// its parent directories does, so "/logs" matches every file in /logs.
+func (d *driver) filterFileSet(ctx context.Context, id fileset.ID, prefix, glob string, exclude bool, ttl time.Duration) (*fileset.ID, error) {
+	if prefix == "" && glob == "" {
+		return nil, errors.Errorf("file set filter must have a path prefix or a glob pattern")
+	}
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	match := func(string) bool { return true }
+	if glob != "" {
+		mf, err := globMatchFunction(cleanPath(glob))
+		if err != nil {
+			return nil, err
+		}
+		match = func(p string) bool {
+			for ; p != "/"; p = parentPath(p) {
+				if mf(p) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	var opts []index.Option
+	if prefix != "" && !exclude {
+		opts = append(opts, index.WithPrefix(prefix))
+	}
+	return d.copyFileSet(ctx, id, ttl, func(f fileset.File) (bool, error) {
+		p := f.Index().Path
+		return (strings.HasPrefix(p, prefix) && match(p)) != exclude, nil
+	}, opts...)
+}
+
+// subtractFileSet makes a new file set with the files in the file set at id
+// whose paths aren't in the file set at subtrahend.
+func (d *driver) subtractFileSet(ctx context.Context, id, subtrahend fileset.ID, ttl time.Duration) (*fileset.ID, error) {
+	sub, err := d.storage.Open(ctx, []fileset.ID{subtrahend})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Both file sets are in path order, so the subtrahend is iterated
+	// alongside the file set, up to the path of each of its files.
+	it := fileset.NewIterator(ctx, sub)
+	return d.copyFileSet(ctx, id, ttl, func(f fileset.File) (bool, error) {
+		p := f.Index().Path
+		for {
+			subf, err := it.Peek()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return true, nil
+				}
+				return false, err
+			}
+			if subf.Index().Path >= p {
+				return subf.Index().Path != p, nil
+			}
+			if _, err := it.Next(); err != nil {
+				return false, err
+			}
+		}
+	})
+}
+
+// copyFileSet writes the files in the file set at id that keep returns true
+// for to a new file set, which expires after ttl, or defaultTTL if it isn't
+// set. The files are written by reference to their data, so no data is
+// copied.
+func (d *driver) copyFileSet(ctx context.Context, id fileset.ID, ttl time.Duration, keep func(fileset.File) (bool, error), opts ...index.Option) (*fileset.ID, error) {
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	if err := validateFileSetTTL(ttl); err != nil {
+		return nil, err
+	}
+	fs, err := d.storage.Open(ctx, []fileset.ID{id}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	w := d.storage.NewWriter(ctx, fileset.WithTTL(ttl))
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		ok, err := keep(f)
+		if err != nil || !ok {
+			return err
+		}
+		return w.Copy(f, f.Index().File.Tag)
+	}); err != nil {
+		return nil, err
+	}
+	return w.Close()
+}
+
+// parentPath returns the path of the directory that contains p, which is
+// clean, or "/" if it's at the top level.
+func parentPath(p string) string {
+	i := strings.LastIndex(p, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return p[:i]
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// findFilesByContent calls cb with the info of each file whose content has
+// hash, or whose data is in the chunk chunkID, in the finished commits that
+// aren't archived in repo, or in every repo that the caller can read if it's
+// nil. A file is found once for each commit that it's in.
+func (d *driver) findFilesByContent(ctx context.Context, hash, chunkID []byte, repo *pfs.Repo, cb func(*pfs.FileInfo) error) error {
+	if (len(hash) == 0) == (len(chunkID) == 0) {
+		return errors.Errorf("exactly one of a content hash and a chunk ID must be set")
+	}
+	var commitInfos []*pfs.CommitInfo
+	commitInfo := &pfs.CommitInfo{}
+	processFunc := func(string) error {
+		if commitInfo.Finished != nil && commitInfo.Archived == nil {
+			commitInfos = append(commitInfos, proto.Clone(commitInfo).(*pfs.CommitInfo))
+		}
+		return nil
+	}
+	if repo != nil {
+		if _, err := d.readRepoInfo(ctx, repo); err != nil {
+			return err
+		}
+		if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+			return err
+		}
+		if err := d.commits.ReadOnly(ctx).GetByIndex(pfsdb.CommitsRepoIndex, pfsdb.RepoKey(repo), commitInfo, col.DefaultOptions(), processFunc); err != nil {
+			return err
+		}
+	} else if err := d.commits.ReadOnly(ctx).List(commitInfo, col.DefaultOptions(), processFunc); err != nil {
+		return err
+	}
+	match := func(f fileset.File) (bool, error) {
+		for _, dataRef := range f.Index().File.DataRefs {
+			if bytes.Equal(dataRef.Ref.Id, chunkID) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if len(hash) > 0 {
+		match = func(f fileset.File) (bool, error) {
+			h, err := f.Hash()
+			if err != nil {
+				return false, err
+			}
+			return bytes.Equal(h, hash), nil
+		}
+	}
+	authorized := make(map[string]bool)
+	for _, commitInfo := range commitInfos {
+		if ok, err := d.canReadRepo(ctx, commitInfo.Commit.Branch.Repo, authorized); err != nil || !ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		_, fs, err := d.openCommit(ctx, commitInfo.Commit)
+		if err != nil {
+			return err
+		}
+		if err := fs.Iterate(ctx, func(f fileset.File) error {
+			ok, err := match(f)
+			if err != nil || !ok {
+				return err
+			}
+			idx := f.Index()
+			file := commitInfo.Commit.NewFile(idx.Path)
+			file.Tag = idx.File.Tag
+			fi := &pfs.FileInfo{
+				File:       file,
+				FileType:   pfs.FileType_FILE,
+				SizeBytes:  uint64(index.SizeBytes(idx)),
+				Committed:  commitInfo.Finished,
+				Mode:       idx.File.Mode,
+				Mtime:      idx.File.Mtime,
+				LinkTarget: idx.File.LinkTarget,
+				Metadata:   idx.File.Metadata,
+				CopiedFrom: idx.File.CopiedFrom,
+			}
+			if fi.Hash, err = f.Hash(); err != nil {
+				return err
+			}
+			return cb(fi)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/dlock"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+const (
+	gcLockPath = "pfs-gc-lock"
+	// defaultGCInterval is how often storage is garbage collected if
+	// STORAGE_GC_POLLING isn't set.
+	defaultGCInterval = 10 * time.Second
+)
+
+// collectGarbageForever garbage collects storage on the interval set by
+// STORAGE_GC_POLLING.
+func (d *driver) collectGarbageForever(ctx context.Context) error {
+	interval := defaultGCInterval
+	if intervalStr := d.env.Config().StorageGCPolling; intervalStr != "" {
+		// The interval is validated at startup.
+		var err error
+		if interval, err = time.ParseDuration(intervalStr); err != nil {
+			return errors.EnsureStack(err)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		case <-ticker.C:
+		}
+		if _, err := d.garbageCollect(ctx, false); err != nil {
+			log.Errorf("error garbage collecting storage: %v", err)
+		}
+	}
+}
+
+// garbageCollect deletes the filesets that nothing references, and then the
+// chunks that nothing references, and records the run. Chunks whose reference
+// count has dropped to zero are deleted right away, rather than once their
+// TTL has passed. Only one run happens at a time across the cluster.
+func (d *driver) garbageCollect(ctx context.Context, manual bool) (*pfs.GarbageCollectionRun, error) {
+	lock := dlock.NewDLock(d.etcdClient, path.Join(d.prefix, gcLockPath))
+	ctx, err := lock.Lock(ctx)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	defer lock.Unlock(ctx)
+	run := &pfs.GarbageCollectionRun{
+		Started: types.TimestampNow(),
+		Manual:  manual,
+	}
+	// Deleting filesets can release the chunks they referenced, so released
+	// chunks are expired until a pass deletes nothing.
+	for {
+		if err := dbutil.WithTx(ctx, d.env.GetDBClient(), func(tx *sqlx.Tx) error {
+			_, err := pfsdb.ExpireReleasedChunksTx(tx)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		objects, err := d.storage.CollectGarbage(ctx)
+		run.ObjectsDeleted += int64(objects)
+		if err != nil {
+			return nil, err
+		}
+		if objects == 0 {
+			break
+		}
+	}
+	run.ChunksDeleted, run.BytesReclaimed, err = chunk.NewGC(d.storage.ChunkStorage()).Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	run.Finished = types.TimestampNow()
+	if err := dbutil.WithTx(ctx, d.env.GetDBClient(), func(tx *sqlx.Tx) error {
+		return pfsdb.AddGCRunTx(tx, run)
+	}); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// inspectGarbageCollection returns the chunks that the next garbage
+// collection run will delete, and what the runs in the last day did.
+func (d *driver) inspectGarbageCollection(ctx context.Context) (*pfs.GarbageCollectionStats, error) {
+	stats := &pfs.GarbageCollectionStats{}
+	db := d.env.GetDBClient()
+	// A chunk is garbage once it's been tombstoned, or once nothing refers to
+	// its tracker object and that has expired or been released.
+	if err := db.QueryRowxContext(ctx, `
+		SELECT count(*), COALESCE(sum(c.size), 0)
+		FROM (
+			SELECT DISTINCT ON (chunk_id) chunk_id, size, tombstone
+			FROM storage.chunk_objects
+			ORDER BY chunk_id, gen DESC
+		) c
+		LEFT JOIN storage.tracker_objects t ON t.str_id = $1 || encode(c.chunk_id, 'hex')
+		LEFT JOIN pfs.chunk_refs cr ON cr.int_id = t.int_id
+		WHERE c.tombstone OR (
+			(t.expires_at IS NULL OR t.expires_at < CURRENT_TIMESTAMP OR cr.released_at IS NOT NULL)
+			AND NOT EXISTS (SELECT 1 FROM storage.tracker_refs r WHERE r.to_id = t.int_id)
+		)
+	`, chunk.TrackerPrefix).Scan(&stats.ReclaimableChunks, &stats.ReclaimableBytes); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if err := dbutil.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		var err error
+		stats.LastRun, stats.BytesReclaimedLastDay, err = pfsdb.GetGCStatsTx(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+const (
+	// finishCommitHookTimeout is how long a finish commit hook has to respond.
+	finishCommitHookTimeout = 30 * time.Second
+	// finishCommitHookMaxChanges is the number of changes a finish commit hook
+	// is sent, beyond which the list is truncated.
+	finishCommitHookMaxChanges = 10000
+	// finishCommitHookMaxMessage is the number of bytes of a rejection's body
+	// that are returned to the caller.
+	finishCommitHookMaxMessage = 4096
+)
+
+// checkFinishCommitHook calls the finish commit hook in settings, the
+// settings of the repo of the commit in commitInfo, if there is one, with the
+// files that the commit changes. It returns an ErrCommitRejected if the hook
+// rejects the commit. It runs in the transaction that finishes the commit, so
+// the hook is called with the commit's files as of it, and is called again if
+// the transaction is retried.
+func (d *driver) checkFinishCommitHook(txnCtx *txncontext.TransactionContext, commitInfo *pfs.CommitInfo, settings *pfs.RepoSettings) error {
+	hook := settings.GetFinishCommitHook()
+	if hook == "" {
+		return nil
+	}
+	ctx := txnCtx.ClientContext
+	request := &pfs.FinishCommitHookRequest{Commit: commitInfo.Commit}
+	if err := d.diffCommitTx(txnCtx, commitInfo, func(oldFi, newFi *pfs.FileInfo) error {
+		change := fileChange(commitInfo.Commit, oldFi, newFi)
+		if change == nil {
+			return nil
+		}
+		if len(request.Changes) == finishCommitHookMaxChanges {
+			request.Truncated = true
+			return errutil.ErrBreak
+		}
+		request.Changes = append(request.Changes, change)
+		return nil
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return err
+	}
+	body, err := (&jsonpb.Marshaler{}).MarshalToString(request)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, finishCommitHookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook, strings.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "invalid finish commit hook %q", hook)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "could not call finish commit hook of repo %v", commitInfo.Commit.Branch.Repo)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, err := ioutil.ReadAll(io.LimitReader(resp.Body, finishCommitHookMaxMessage))
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	message := string(bytes.TrimSpace(msg))
+	if message == "" {
+		message = resp.Status
+	}
+	return pfsserver.ErrCommitRejected{Commit: commitInfo.Commit, Message: message}
+}
+
+// diffCommitTx calls cb with the files that the commit in commitInfo changes,
+// like diffFile, as they're recorded in txnCtx's transaction.
+func (d *driver) diffCommitTx(txnCtx *txncontext.TransactionContext, commitInfo *pfs.CommitInfo, cb func(oldFi, newFi *pfs.FileInfo) error) error {
+	ctx := txnCtx.ClientContext
+	var old Source = emptySource{}
+	if commitInfo.ParentCommit != nil {
+		parentInfo, err := d.resolveCommit(txnCtx.SqlTx, proto.Clone(commitInfo.ParentCommit).(*pfs.Commit))
+		if err != nil {
+			return err
+		}
+		ids, err := d.commitFileSetsTx(txnCtx, parentInfo)
+		if err != nil {
+			return err
+		}
+		fs, err := d.storage.Open(ctx, ids)
+		if err != nil {
+			return err
+		}
+		old = NewSource(parentInfo, fs, WithFull())
+	}
+	ids, err := d.commitFileSetsTx(txnCtx, commitInfo)
+	if err != nil {
+		return err
+	}
+	fs, err := d.storage.Open(ctx, ids)
+	if err != nil {
+		return err
+	}
+	return NewDiffer(old, NewSource(commitInfo, fs, WithFull())).Iterate(ctx, cb)
+}
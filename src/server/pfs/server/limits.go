@@ -0,0 +1,194 @@
+package server
+
+import (
+	"database/sql"
+	"path"
+
+	"github.com/gogo/protobuf/proto"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+	"golang.org/x/net/context"
+)
+
+// readRepoInfo returns the stored info of repo, which holds the settings and
+// path reservations that modifications to the repo are checked against.
+func (d *driver) readRepoInfo(ctx context.Context, repo *pfs.Repo) (*pfs.RepoInfo, error) {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).Get(pfsdb.RepoKey(repo), repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, pfsserver.ErrRepoNotFound{Repo: repo}
+		}
+		return nil, err
+	}
+	return repoInfo, nil
+}
+
+// dirEntries tracks the number of entries in a directory. Files are iterated
+// in path order, so all of the files under an entry are visited
+// consecutively and an entry is new whenever its name changes.
+type dirEntries struct {
+	last  string
+	count uint64
+}
+
+// checkFileLimits checks that the files in the fileset made up of ids are
+// within the limits in settings. The check is skipped when the repo has no
+// limits, otherwise it reads the index of the whole fileset, whose size is
+// bounded by the limits themselves. commit is used to describe violations.
+func (d *driver) checkFileLimits(ctx context.Context, commit *pfs.Commit, settings *pfs.RepoSettings, ids []fileset.ID) error {
+	maxSize := settings.GetMaxFileSizeBytes()
+	maxFiles := settings.GetMaxFilesPerCommit()
+	maxEntries := settings.GetMaxDirectoryEntries()
+	if maxSize == 0 && maxFiles == 0 && maxEntries == 0 {
+		return nil
+	}
+	fs, err := d.storage.Open(ctx, ids)
+	if err != nil {
+		return err
+	}
+	var files, size uint64
+	var lastPath string
+	dirs := make(map[string]*dirEntries)
+	checkSize := func() error {
+		if maxSize > 0 && size > maxSize {
+			return pfsserver.ErrFileTooLarge{File: commit.NewFile(lastPath), SizeBytes: size, Limit: maxSize}
+		}
+		return nil
+	}
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		idx := f.Index()
+		if idx.Path == lastPath {
+			// Another tag of the same file.
+			size += uint64(index.SizeBytes(idx))
+			return nil
+		}
+		if err := checkSize(); err != nil {
+			return err
+		}
+		lastPath = idx.Path
+		size = uint64(index.SizeBytes(idx))
+		files++
+		if maxFiles > 0 && files > maxFiles {
+			return pfsserver.ErrTooManyFiles{Commit: commit, Limit: maxFiles}
+		}
+		if maxEntries > 0 {
+			for p := idx.Path; p != "/"; {
+				dir, name := path.Split(p)
+				entries, ok := dirs[dir]
+				if !ok {
+					entries = &dirEntries{}
+					dirs[dir] = entries
+				}
+				if entries.last == name {
+					break
+				}
+				entries.last = name
+				entries.count++
+				if entries.count > maxEntries {
+					return pfsserver.ErrTooManyDirectoryEntries{Dir: commit.NewFile(dir), Limit: maxEntries}
+				}
+				p = path.Clean(dir)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return checkSize()
+}
+
+// checkRepoSize checks that the fileset made up of ids is within the limit on
+// the size of the repo in settings. The sizes of the files are added up rather
+// than the sizes of the filesets, since the filesets of an open commit still
+// hold the data of files that were deleted or overwritten. commit is used to
+// describe violations.
+func (d *driver) checkRepoSize(ctx context.Context, commit *pfs.Commit, settings *pfs.RepoSettings, ids []fileset.ID) error {
+	limit := settings.GetMaxSizeBytes()
+	if limit == 0 {
+		return nil
+	}
+	fs, err := d.storage.Open(ctx, ids)
+	if err != nil {
+		return err
+	}
+	var size uint64
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		size += uint64(index.SizeBytes(f.Index()))
+		return nil
+	}); err != nil {
+		return err
+	}
+	if size > limit {
+		return pfsserver.ErrRepoTooLarge{Commit: commit, SizeBytes: size, Limit: limit}
+	}
+	return nil
+}
+
+// checkFinishCommitSize checks that finishing the commit in commitInfo won't
+// make its repo exceed the size limit in settings, the repo's settings. It runs in the transaction that
+// finishes the commit, so that the commit's files are counted as of it,
+// including the ones added earlier in the same transaction.
+func (d *driver) checkFinishCommitSize(txnCtx *txncontext.TransactionContext, commitInfo *pfs.CommitInfo, settings *pfs.RepoSettings) error {
+	if settings.GetMaxSizeBytes() == 0 {
+		return nil
+	}
+	ids, err := d.commitFileSetsTx(txnCtx, commitInfo)
+	if err != nil {
+		return err
+	}
+	return d.checkRepoSize(txnCtx.ClientContext, commitInfo.Commit, settings, ids)
+}
+
+// checkFinishCommit checks the commit in commitInfo against its repo's size
+// limit and finish commit hook before it's finished.
+func (d *driver) checkFinishCommit(txnCtx *txncontext.TransactionContext, commitInfo *pfs.CommitInfo) error {
+	repo := commitInfo.Commit.Branch.Repo
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadWrite(txnCtx.SqlTx).Get(pfsdb.RepoKey(repo), repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return pfsserver.ErrRepoNotFound{Repo: repo}
+		}
+		return err
+	}
+	if err := d.checkFinishCommitSize(txnCtx, commitInfo, repoInfo.Settings); err != nil {
+		return err
+	}
+	return d.checkFinishCommitHook(txnCtx, commitInfo, repoInfo.Settings)
+}
+
+// commitFileSetsTx returns the filesets that make up the files in the commit
+// in commitInfo, as they're recorded in txnCtx's transaction, unlike
+// getFileSet, which only sees commits and filesets that are already
+// committed.
+func (d *driver) commitFileSetsTx(txnCtx *txncontext.TransactionContext, commitInfo *pfs.CommitInfo) ([]fileset.ID, error) {
+	if commitInfo.Finished != nil {
+		id, err := getTotal(txnCtx.SqlTx, commitInfo.Commit)
+		if err == nil {
+			return []fileset.ID{*id}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.EnsureStack(err)
+		}
+	}
+	var ids []fileset.ID
+	if commitInfo.ParentCommit != nil {
+		parentInfo, err := d.resolveCommit(txnCtx.SqlTx, proto.Clone(commitInfo.ParentCommit).(*pfs.Commit))
+		if err != nil {
+			return nil, err
+		}
+		if ids, err = d.commitFileSetsTx(txnCtx, parentInfo); err != nil {
+			return nil, err
+		}
+	}
+	diff, err := getDiff(txnCtx.SqlTx, commitInfo.Commit)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	return append(ids, diff...), nil
+}
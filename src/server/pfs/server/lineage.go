@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// commitLineage returns the commits that commit was derived from and the
+// commits derived from it, following provenance up to upstreamDepth and
+// downstreamDepth levels away. Commits in repos that the caller can't read
+// are left out, along with the commits found through them.
+func (d *driver) commitLineage(ctx context.Context, commit *pfs.Commit, upstreamDepth, downstreamDepth int64) (*pfs.CommitLineageResponse, error) {
+	commitInfo, err := d.inspectCommit(ctx, commit, pfs.CommitState_STARTED)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pfs.CommitLineageResponse{CommitInfo: commitInfo}
+	authorized := map[string]bool{pfsdb.RepoKey(commitInfo.Commit.Branch.Repo): true}
+	if upstreamDepth >= 0 {
+		if resp.Upstream, err = d.walkLineage(ctx, commitInfo, upstreamDepth, authorized, d.upstreamCommits); err != nil {
+			return nil, err
+		}
+	}
+	if downstreamDepth >= 0 {
+		if resp.Downstream, err = d.walkLineage(ctx, commitInfo, downstreamDepth, authorized, d.downstreamCommits); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// walkLineage returns the commits that next finds from commitInfo, and then
+// from each of those, breadth first, up to depth levels away unless depth is
+// zero.
+func (d *driver) walkLineage(ctx context.Context, commitInfo *pfs.CommitInfo, depth int64, authorized map[string]bool, next func(context.Context, *pfs.CommitInfo) ([]*pfs.CommitInfo, error)) ([]*pfs.LineageCommit, error) {
+	visited := map[string]bool{pfsdb.CommitKey(commitInfo.Commit): true}
+	var result []*pfs.LineageCommit
+	level := []*pfs.CommitInfo{commitInfo}
+	for i := int64(1); len(level) > 0 && (depth == 0 || i <= depth); i++ {
+		var nextLevel []*pfs.CommitInfo
+		for _, via := range level {
+			commitInfos, err := next(ctx, via)
+			if err != nil {
+				return nil, err
+			}
+			for _, ci := range commitInfos {
+				key := pfsdb.CommitKey(ci.Commit)
+				if visited[key] {
+					continue
+				}
+				visited[key] = true
+				if ok, err := d.canReadRepo(ctx, ci.Commit.Branch.Repo, authorized); err != nil || !ok {
+					if err != nil {
+						return nil, err
+					}
+					continue
+				}
+				result = append(result, &pfs.LineageCommit{
+					CommitInfo: ci,
+					Depth:      i,
+					Via:        via.Commit,
+				})
+				nextLevel = append(nextLevel, ci)
+			}
+		}
+		level = nextLevel
+	}
+	return result, nil
+}
+
+// upstreamCommits returns the commits in commitInfo's direct provenance, which
+// are the ones in its commit set on the branches it's provenant on. Aliases
+// are replaced by the commits they alias, which hold the data.
+func (d *driver) upstreamCommits(ctx context.Context, commitInfo *pfs.CommitInfo) ([]*pfs.CommitInfo, error) {
+	var result []*pfs.CommitInfo
+	for _, branch := range commitInfo.DirectProvenance {
+		provInfo := &pfs.CommitInfo{}
+		if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(branch.NewCommit(commitInfo.Commit.ID)), provInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		for provInfo.Origin.Kind == pfs.OriginKind_ALIAS && provInfo.ParentCommit != nil {
+			parentInfo := &pfs.CommitInfo{}
+			if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(provInfo.ParentCommit), parentInfo); err != nil {
+				if col.IsErrNotFound(err) {
+					break
+				}
+				return nil, err
+			}
+			provInfo = parentInfo
+		}
+		result = append(result, provInfo)
+	}
+	return result, nil
+}
+
+// downstreamCommits returns the commits directly provenant on commitInfo,
+// which are the ones in its commit set, or in the commit set of one of its
+// aliases, that have its branch in their direct provenance.
+func (d *driver) downstreamCommits(ctx context.Context, commitInfo *pfs.CommitInfo) ([]*pfs.CommitInfo, error) {
+	ids := []string{commitInfo.Commit.ID}
+	queue := append([]*pfs.Commit{}, commitInfo.ChildCommits...)
+	for len(queue) > 0 {
+		var child *pfs.Commit
+		child, queue = queue[0], queue[1:]
+		childInfo := &pfs.CommitInfo{}
+		if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(child), childInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if childInfo.Origin.Kind == pfs.OriginKind_ALIAS {
+			ids = append(ids, childInfo.Commit.ID)
+			queue = append(queue, childInfo.ChildCommits...)
+		}
+	}
+	branchKey := pfsdb.BranchKey(commitInfo.Commit.Branch)
+	var result []*pfs.CommitInfo
+	for _, id := range ids {
+		ci := &pfs.CommitInfo{}
+		if err := d.commits.ReadOnly(ctx).GetByIndex(pfsdb.CommitsCommitSetIndex, id, ci, col.DefaultOptions(), func(string) error {
+			for _, branch := range ci.DirectProvenance {
+				if pfsdb.BranchKey(branch) == branchKey {
+					result = append(result, proto.Clone(ci).(*pfs.CommitInfo))
+					break
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+// isLocked returns true if settings hold a lock that hasn't expired or been
+// broken at now.
+func isLocked(settings *pfs.RepoSettings, now time.Time) bool {
+	if settings.GetLock().GetBrokenAt() != nil {
+		return false
+	}
+	until, err := types.TimestampFromProto(settings.GetLock().GetUntil())
+	if err != nil {
+		return false
+	}
+	return now.Before(until)
+}
+
+func validateLock(lock *pfs.RepoLock) error {
+	if lock == nil {
+		return nil
+	}
+	if lock.Until == nil {
+		return errors.Errorf("repo lock must specify when it expires")
+	}
+	if _, err := types.TimestampFromProto(lock.Until); err != nil {
+		return errors.Wrapf(err, "invalid repo lock expiration")
+	}
+	return nil
+}
+
+// validateLockUpdate checks that changing a repo's settings from old to new
+// doesn't remove or shorten a lock that's in effect at now, and that a lock is
+// only marked broken by breakRepoLock.
+func validateLockUpdate(repo *pfs.Repo, old, new *pfs.RepoSettings, now time.Time) error {
+	if err := validateLock(new.GetLock()); err != nil {
+		return err
+	}
+	if isBroken(new.GetLock()) && !proto.Equal(old.GetLock(), new.GetLock()) {
+		return errors.Errorf("a repo lock can only be broken with BreakRepoLock")
+	}
+	if !isLocked(old, now) {
+		return nil
+	}
+	oldUntil, _ := types.TimestampFromProto(old.Lock.Until)
+	if new.GetLock() == nil {
+		return errors.Wrapf(pfsserver.ErrRepoLocked{Repo: repo, Until: old.Lock.Until}, "cannot remove the lock")
+	}
+	newUntil, _ := types.TimestampFromProto(new.Lock.Until)
+	if newUntil.Before(oldUntil) {
+		return errors.Wrapf(pfsserver.ErrRepoLocked{Repo: repo, Until: old.Lock.Until}, "cannot shorten the lock")
+	}
+	return nil
+}
+
+func isBroken(lock *pfs.RepoLock) bool {
+	return lock.GetBrokenBy() != "" || lock.GetBrokenAt() != nil || lock.GetBreakReason() != ""
+}
+
+// breakRepoLock ends repo's lock before it expires, and records the caller,
+// the time and the reason in it. The caller's cluster permission to break
+// locks is checked by the auth interceptor.
+func (d *driver) breakRepoLock(txnCtx *txncontext.TransactionContext, repo *pfs.Repo, reason string) error {
+	var brokenBy string
+	whoAmI, err := d.env.AuthServer().WhoAmI(txnCtx.ClientContext, &auth.WhoAmIRequest{})
+	if err != nil {
+		if !auth.IsErrNotActivated(err) {
+			return errors.EnsureStack(err)
+		}
+	} else {
+		brokenBy = whoAmI.Username
+	}
+	repoInfo := &pfs.RepoInfo{}
+	return errors.EnsureStack(d.repos.ReadWrite(txnCtx.SqlTx).Update(pfsdb.RepoKey(repo), repoInfo, func() error {
+		if !isLocked(repoInfo.Settings, txnTime(txnCtx)) {
+			return errors.Errorf("repo %v is not locked", repo)
+		}
+		repoInfo.Settings.Lock.BrokenBy = brokenBy
+		repoInfo.Settings.Lock.BrokenAt = txnCtx.Timestamp
+		repoInfo.Settings.Lock.BreakReason = reason
+		return nil
+	}))
+}
+
+// checkRepoUnlocked returns ErrRepoLocked if repo is under a lock at the
+// transaction's timestamp. A repo that doesn't exist isn't locked.
+func (d *driver) checkRepoUnlocked(txnCtx *txncontext.TransactionContext, repo *pfs.Repo) error {
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadWrite(txnCtx.SqlTx).Get(pfsdb.RepoKey(repo), repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if isLocked(repoInfo.Settings, txnTime(txnCtx)) {
+		return pfsserver.ErrRepoLocked{Repo: repo, Until: repoInfo.Settings.Lock.Until}
+	}
+	return nil
+}
+
+// isCommitLocked returns true if commitInfo's commit is finished and its repo
+// is currently locked.
+func (d *driver) isCommitLocked(ctx context.Context, commitInfo *pfs.CommitInfo) (bool, error) {
+	if commitInfo.Finished == nil {
+		return false, nil
+	}
+	repoInfo, err := d.readRepoInfo(ctx, commitInfo.Commit.Branch.Repo)
+	if err != nil {
+		return false, err
+	}
+	return isLocked(repoInfo.Settings, time.Now()), nil
+}
+
+func txnTime(txnCtx *txncontext.TransactionContext) time.Time {
+	if now, err := types.TimestampFromProto(txnCtx.Timestamp); err == nil {
+		return now
+	}
+	return time.Now()
+}
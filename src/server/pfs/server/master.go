@@ -7,8 +7,9 @@ import (
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
 	"github.com/pachyderm/pachyderm/v2/src/internal/dlock"
-	_ "github.com/pachyderm/pachyderm/v2/src/internal/errors"
-	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
+	"github.com/pachyderm/pachyderm/v2/src/internal/snapshot"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -26,17 +27,61 @@ func (d *driver) master(ctx context.Context) {
 			return err
 		}
 		defer masterLock.Unlock(masterCtx)
+		// Each of the master's loops is retried on its own, so that one that
+		// keeps failing doesn't restart the others, and garbage collection in
+		// particular isn't starved by them.
 		eg, ctx := errgroup.WithContext(masterCtx)
-		eg.Go(func() error {
-			return d.storage.GC(ctx)
-		})
-		eg.Go(func() error {
-			gc := chunk.NewGC(d.storage.ChunkStorage())
-			return gc.RunForever(ctx)
-		})
+		loop := func(name string, f func(context.Context) error) {
+			eg.Go(func() error {
+				return backoff.RetryUntilCancel(ctx, func() error {
+					return f(ctx)
+				}, backoff.NewInfiniteBackOff(), func(err error, _ time.Duration) error {
+					log.Errorf("error in pfs master %s: %v", name, err)
+					return nil
+				})
+			})
+		}
+		loop("garbage collection", d.collectGarbageForever)
+		loop("mirror sync", d.syncMirrors)
+		loop("event sweep", d.sweepEventsForever)
+		if interval := d.env.Config().SnapshotInterval; interval != "" {
+			loop("snapshots", func(ctx context.Context) error {
+				return d.takeSnapshots(ctx, interval)
+			})
+		}
+		if interval := d.env.Config().FsckInterval; interval != "" {
+			loop("consistency check", func(ctx context.Context) error {
+				return d.checkConsistencyForever(ctx, interval)
+			})
+		}
+		if interval := d.env.Config().ColdTieringInterval; interval != "" {
+			loop("cold tiering", func(ctx context.Context) error {
+				return d.tierColdDataForever(ctx, interval)
+			})
+		}
+		if interval := d.env.Config().CommitRetentionInterval; interval != "" {
+			loop("commit retention", func(ctx context.Context) error {
+				return d.expireCommitsForever(ctx, interval)
+			})
+		}
 		return eg.Wait()
 	}, backoff.NewInfiniteBackOff(), func(err error, _ time.Duration) error {
 		log.Errorf("error in pfs master: %v", err)
 		return nil
 	})
 }
+
+// takeSnapshots periodically writes a snapshot of the cluster's metadata to
+// object storage.
+func (d *driver) takeSnapshots(ctx context.Context, intervalStr string) error {
+	// The interval is validated at startup.
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	objClient, err := obj.NewClient(d.env.Config().StorageBackend, d.env.Config().StorageRoot)
+	if err != nil {
+		return err
+	}
+	return snapshot.NewSnapshotter(d.env.GetDBClient(), objClient, d.env.Config().SnapshotRetention).Run(ctx, interval)
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"bytes"
+	"context"
+
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/renew"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// mergeBranch merges the changes made on from since the commit it last had
+// in common with into, its merge base, into a new commit on into. A file
+// changed on only one of the branches is taken from that branch, by
+// reference to its data. Files changed differently on both are conflicts,
+// and no commit is made if there are any.
+func (d *driver) mergeBranch(ctx context.Context, from, into *pfs.Branch, description string) (*pfs.MergeBranchResponse, error) {
+	if from.Repo.Name != into.Repo.Name || from.Repo.Type != into.Repo.Type {
+		return nil, errors.Errorf("cannot merge %v into %v, which is in a different repo", from, into)
+	}
+	if from.Name == into.Name {
+		return nil, errors.Errorf("cannot merge branch %v into itself", from)
+	}
+	theirsInfo, err := d.inspectCommit(ctx, from.NewCommit(""), pfs.CommitState_FINISHED)
+	if err != nil {
+		return nil, err
+	}
+	oursInfo, err := d.inspectCommit(ctx, into.NewCommit(""), pfs.CommitState_FINISHED)
+	if err != nil {
+		return nil, err
+	}
+	theirs, ours := theirsInfo.Commit, oursInfo.Commit
+	base, err := d.mergeBase(ctx, ours, theirs)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil && base.ID == theirs.ID {
+		// Everything on from is already on into.
+		return &pfs.MergeBranchResponse{Commit: ours}, nil
+	}
+	baseFile := &pfs.File{Path: "/"}
+	if base != nil {
+		baseFile.Commit = base
+	}
+	oursChanges := make(map[string]*pfs.FileInfo)
+	oursChanged := make(map[string]bool)
+	if err := d.diffFile(ctx, baseFile, ours.NewFile("/"), func(oldFi, newFi *pfs.FileInfo) error {
+		if p, ok := changedFilePath(oldFi, newFi); ok {
+			oursChanges[p] = fileOrNil(newFi)
+			oursChanged[p] = true
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	resp := &pfs.MergeBranchResponse{}
+	// take holds the paths whose version on from is merged, which may be a
+	// deletion.
+	var take []string
+	if err := d.diffFile(ctx, baseFile, theirs.NewFile("/"), func(oldFi, newFi *pfs.FileInfo) error {
+		p, ok := changedFilePath(oldFi, newFi)
+		if !ok {
+			return nil
+		}
+		newFi = fileOrNil(newFi)
+		if !oursChanged[p] {
+			take = append(take, p)
+			return nil
+		}
+		oursFi := oursChanges[p]
+		if oursFi == nil && newFi == nil {
+			return nil
+		}
+		if oursFi != nil && newFi != nil && bytes.Equal(oursFi.Hash, newFi.Hash) {
+			return nil
+		}
+		resp.Conflicts = append(resp.Conflicts, &pfs.MergeConflict{
+			Path:   p,
+			Ours:   oursFi,
+			Theirs: newFi,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(resp.Conflicts) > 0 {
+		return resp, nil
+	}
+	commit, err := d.commitOnHead(ctx, into, ours, description, func(uw *fileset.UnorderedWriter) error {
+		return d.takeFiles(ctx, uw, ours, theirs, take)
+	}, func(commitInfo *pfs.CommitInfo) {
+		commitInfo.MergeParent = theirs
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Commit = commit
+	return resp, nil
+}
+
+// cherryPickCommit applies the changes that commit made to its parent to a
+// new commit on branch, by reference to the data of the changed files.
+func (d *driver) cherryPickCommit(ctx context.Context, commit *pfs.Commit, branch *pfs.Branch, description string) (*pfs.Commit, error) {
+	commitInfo, err := d.inspectCommit(ctx, commit, pfs.CommitState_FINISHED)
+	if err != nil {
+		return nil, err
+	}
+	headInfo, err := d.inspectCommit(ctx, branch.NewCommit(""), pfs.CommitState_FINISHED)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	if err := d.diffFile(ctx, nil, commitInfo.Commit.NewFile("/"), func(oldFi, newFi *pfs.FileInfo) error {
+		if p, ok := changedFilePath(oldFi, newFi); ok {
+			changed = append(changed, p)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = commitInfo.Description
+	}
+	return d.commitOnHead(ctx, branch, headInfo.Commit, description, func(uw *fileset.UnorderedWriter) error {
+		return d.takeFiles(ctx, uw, headInfo.Commit, commitInfo.Commit, changed)
+	}, nil)
+}
+
+// commitOnHead makes a finished commit on branch, whose head must still be
+// head, with the changes that write makes to head's files. update, if it's
+// set, can change the new commit's info before it's finished.
+func (d *driver) commitOnHead(ctx context.Context, branch *pfs.Branch, head *pfs.Commit, description string, write func(*fileset.UnorderedWriter) error, update func(*pfs.CommitInfo)) (*pfs.Commit, error) {
+	var commit *pfs.Commit
+	if err := d.storage.WithRenewer(ctx, defaultTTL, func(ctx context.Context, renewer *renew.StringSet) error {
+		parentID, err := d.getFileSet(ctx, head)
+		if err != nil {
+			return err
+		}
+		renewer.Add(parentID.HexString())
+		id, err := d.withUnorderedWriter(ctx, renewer, false, write, fileset.WithParentID(parentID))
+		if err != nil {
+			return err
+		}
+		return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+			branchInfo := &pfs.BranchInfo{}
+			if err := d.branches.ReadWrite(txnCtx.SqlTx).Get(pfsdb.BranchKey(branch), branchInfo); err != nil {
+				return err
+			}
+			if branchInfo.Head.ID != head.ID {
+				return errors.Errorf("branch %v moved while a commit was being made on it, retry", branch)
+			}
+			commit, err = d.startCommit(txnCtx, head, branch, description, nil)
+			if err != nil {
+				return err
+			}
+			if update != nil {
+				commitInfo := &pfs.CommitInfo{}
+				if err := d.commits.ReadWrite(txnCtx.SqlTx).Update(pfsdb.CommitKey(commit), commitInfo, func() error {
+					update(commitInfo)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if err := d.commitStore.AddFileSetTx(txnCtx.SqlTx, commit, *id); err != nil {
+				return err
+			}
+			return d.finishCommit(txnCtx, commit, "", nil)
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// takeFiles replaces the files at paths in ours with their versions in
+// theirs, keeping their tags. A path with no file in theirs is deleted.
+func (d *driver) takeFiles(ctx context.Context, uw *fileset.UnorderedWriter, ours, theirs *pfs.Commit, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	isTaken := make(map[string]bool)
+	for _, p := range paths {
+		isTaken[p] = true
+	}
+	filter := func(fs fileset.FileSet) fileset.FileSet {
+		return fileset.NewIndexFilter(fs, func(idx *index.Index) bool {
+			return isTaken[idx.Path]
+		})
+	}
+	_, fs, err := d.openCommit(ctx, ours)
+	if err != nil {
+		return err
+	}
+	if err := filter(fs).Iterate(ctx, func(f fileset.File) error {
+		return uw.Delete(f.Index().Path, f.Index().File.Tag)
+	}); err != nil {
+		return err
+	}
+	_, fs, err = d.openCommit(ctx, theirs)
+	if err != nil {
+		return err
+	}
+	return uw.CopyTagged(ctx, filter(fs))
+}
+
+// mergeBase returns the closest commit that a and b both descend from,
+// following merge parents as well as parents, or nil if they have no
+// ancestors in common.
+func (d *driver) mergeBase(ctx context.Context, a, b *pfs.Commit) (*pfs.Commit, error) {
+	ancestors := make(map[string]bool)
+	if err := d.walkAncestors(ctx, a, func(commit *pfs.Commit) (bool, error) {
+		ancestors[commit.ID] = true
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+	var base *pfs.Commit
+	if err := d.walkAncestors(ctx, b, func(commit *pfs.Commit) (bool, error) {
+		if ancestors[commit.ID] {
+			base = commit
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// walkAncestors calls cb with commit and its ancestors, nearest first, until
+// cb returns false. Ancestors that were squashed or deleted are skipped.
+func (d *driver) walkAncestors(ctx context.Context, commit *pfs.Commit, cb func(*pfs.Commit) (bool, error)) error {
+	visited := make(map[string]bool)
+	queue := []*pfs.Commit{commit}
+	for len(queue) > 0 {
+		commit, queue = queue[0], queue[1:]
+		if visited[commit.ID] {
+			continue
+		}
+		visited[commit.ID] = true
+		commitInfo := &pfs.CommitInfo{}
+		if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(commit), commitInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if ok, err := cb(commitInfo.Commit); err != nil || !ok {
+			return err
+		}
+		if commitInfo.ParentCommit != nil {
+			queue = append(queue, commitInfo.ParentCommit)
+		}
+		if commitInfo.MergeParent != nil {
+			queue = append(queue, commitInfo.MergeParent)
+		}
+	}
+	return nil
+}
+
+// changedFilePath returns the path of the file that a diff of oldFi and newFi
+// is about, or false if it's about a directory.
+func changedFilePath(oldFi, newFi *pfs.FileInfo) (string, bool) {
+	if fi := fileOrNil(newFi); fi != nil {
+		return fi.File.Path, true
+	}
+	if fi := fileOrNil(oldFi); fi != nil {
+		return fi.File.Path, true
+	}
+	return "", false
+}
+
+func fileOrNil(fi *pfs.FileInfo) *pfs.FileInfo {
+	if fi == nil || fi.FileType == pfs.FileType_DIR {
+		return nil
+	}
+	return fi
+}
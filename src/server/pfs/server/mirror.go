@@ -0,0 +1,465 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/miscutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/obj"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pachhash"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/renew"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+const (
+	// mirrorPollInterval is how often the PFS master checks for mirror repos
+	// that are due to be synced.
+	mirrorPollInterval = 10 * time.Second
+	// defaultMirrorInterval is how often a mirror repo is synced if its mirror
+	// doesn't specify an interval.
+	defaultMirrorInterval = time.Minute
+	// mirrorOfLabel is the label on each commit written to a mirror repo that
+	// records the source commit, or the hash of the snapshot archive, that
+	// it's a copy of.
+	mirrorOfLabel = "pachyderm.io/mirror-of"
+	// mirrorAddressKey and mirrorAuthTokenKey are the keys of a mirror's
+	// secret that hold the address of the source cluster and the auth token
+	// for it.
+	mirrorAddressKey   = "pachd_address"
+	mirrorAuthTokenKey = "auth_token"
+	// mirrorSnapshotURLKey is the key of a snapshot mirror's secret that
+	// holds the URL of the snapshot its credentials are for. The credentials
+	// themselves are under the same keys as in pachd's storage secret.
+	mirrorSnapshotURLKey = "snapshot_url"
+)
+
+type mirrorSyncKey struct{}
+
+// isMirrorSync returns true if ctx belongs to PFS syncing a mirror repo. Such
+// contexts are created internally and are allowed to write to mirror repos.
+func isMirrorSync(ctx context.Context) bool {
+	return ctx.Value(mirrorSyncKey{}) != nil
+}
+
+func validateMirror(mirror *pfs.RepoMirror) error {
+	if mirror == nil {
+		return nil
+	}
+	if mirror.SnapshotURL != "" {
+		if mirror.Address != "" || mirror.Source != nil {
+			return errors.Errorf("a mirror of a snapshot can't also specify a source cluster or repo")
+		}
+		if mirror.AuthTokenSecret == "" {
+			return errors.Errorf("a mirror of a snapshot must specify a secret with the credentials to read it with")
+		}
+		if len(mirror.Branches) > 1 {
+			return errors.Errorf("a mirror of a snapshot has a single branch")
+		}
+		if !isGzipped(mirror.SnapshotURL) && !strings.HasSuffix(mirror.SnapshotURL, ".tar") {
+			return errors.Errorf("snapshot %q must be a .tar, .tar.gz or .tgz archive", mirror.SnapshotURL)
+		}
+		url, err := obj.ParseURL(mirror.SnapshotURL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid snapshot url")
+		}
+		// A local snapshot would be read from pachd's own filesystem.
+		if url.Store == "local" {
+			return errors.Errorf("snapshot %q must be in object storage", mirror.SnapshotURL)
+		}
+	} else {
+		if mirror.Address == "" {
+			return errors.Errorf("mirror must specify the address of the source cluster or a snapshot")
+		}
+		if mirror.Source == nil || mirror.Source.Name == "" {
+			return errors.Errorf("mirror must specify a source repo")
+		}
+	}
+	if mirror.Interval != nil {
+		interval, err := types.DurationFromProto(mirror.Interval)
+		if err != nil {
+			return errors.Wrapf(err, "invalid mirror interval")
+		}
+		if interval < mirrorPollInterval {
+			return errors.Errorf("mirror interval must be at least %v", mirrorPollInterval)
+		}
+	}
+	return nil
+}
+
+// syncMirrors periodically updates the branches of all mirror repos from
+// their source repos. It runs in the PFS master.
+func (d *driver) syncMirrors(ctx context.Context) error {
+	ctx = context.WithValue(ctx, mirrorSyncKey{}, true)
+	lastSync := make(map[string]time.Time)
+	ticker := time.NewTicker(mirrorPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		}
+		repoInfo := &pfs.RepoInfo{}
+		var mirrors []*pfs.RepoInfo
+		if err := d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions(), func(string) error {
+			if repoInfo.Mirror != nil {
+				mirrors = append(mirrors, proto.Clone(repoInfo).(*pfs.RepoInfo))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, ri := range mirrors {
+			interval := defaultMirrorInterval
+			if ri.Mirror.Interval != nil {
+				if i, err := types.DurationFromProto(ri.Mirror.Interval); err == nil {
+					interval = i
+				}
+			}
+			key := pfsdb.RepoKey(ri.Repo)
+			if time.Since(lastSync[key]) < interval {
+				continue
+			}
+			lastSync[key] = time.Now()
+			if err := d.syncMirror(ctx, ri); err != nil {
+				log.Errorf("error syncing mirror repo %v from %v: %v", ri.Repo, ri.Mirror.Source, err)
+			}
+		}
+	}
+}
+
+func (d *driver) syncMirror(ctx context.Context, repoInfo *pfs.RepoInfo) (retErr error) {
+	mirror := repoInfo.Mirror
+	if mirror.SnapshotURL != "" {
+		return d.syncSnapshotMirror(ctx, repoInfo)
+	}
+	remote, err := d.mirrorClient(mirror)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := remote.Close(); retErr == nil {
+			retErr = err
+		}
+	}()
+	remote = remote.WithCtx(ctx)
+	branches := mirror.Branches
+	if len(branches) == 0 {
+		bis, err := remote.PfsAPIClient.ListBranch(remote.Ctx(), &pfs.ListBranchRequest{Repo: mirror.Source})
+		if err != nil {
+			return err
+		}
+		for _, bi := range bis.BranchInfo {
+			branches = append(branches, bi.Branch.Name)
+		}
+	}
+	for _, branch := range branches {
+		if err := d.syncMirrorBranch(ctx, remote, mirror.Source.NewBranch(branch), repoInfo.Repo.NewBranch(branch)); err != nil {
+			return errors.Wrapf(err, "error syncing branch %v", branch)
+		}
+	}
+	return nil
+}
+
+// mirrorClient returns a client for the cluster that mirror's source repo is
+// in, which authenticates with the token in the mirror's secret, if it has
+// one.
+func (d *driver) mirrorClient(mirror *pfs.RepoMirror) (*client.APIClient, error) {
+	var token string
+	if mirror.AuthTokenSecret != "" {
+		// The secret names the cluster its token is for, so that whoever can
+		// create a mirror can't send the token to a cluster of their own.
+		data, err := d.mirrorSecret(mirror, mirrorAddressKey, mirror.Address)
+		if err != nil {
+			return nil, err
+		}
+		token = string(data[mirrorAuthTokenKey])
+		if token == "" {
+			return nil, errors.Errorf("secret %q doesn't have an %s", mirror.AuthTokenSecret, mirrorAuthTokenKey)
+		}
+	}
+	remote, err := client.NewFromURI(mirror.Address)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		remote.SetAuthToken(token)
+	}
+	return remote, nil
+}
+
+// mirrorSecret returns the data of the mirror's secret, which must hold value
+// under key.
+func (d *driver) mirrorSecret(mirror *pfs.RepoMirror, key, value string) (map[string][]byte, error) {
+	secret, err := d.env.GetKubeClient().CoreV1().Secrets(d.env.Config().Namespace).Get(mirror.AuthTokenSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading the mirror's secret %q", mirror.AuthTokenSecret)
+	}
+	if got := string(secret.Data[key]); got != value {
+		return nil, errors.Errorf("secret %q is for %q, not the mirror's %s %q", mirror.AuthTokenSecret, got, key, value)
+	}
+	return secret.Data, nil
+}
+
+// syncMirrorBranch makes the head of dst a copy of the last finished commit
+// on src, if that has changed since the last sync. Only the files that
+// changed since the commit that dst's head mirrors are copied, unless that
+// commit is gone from the source.
+func (d *driver) syncMirrorBranch(ctx context.Context, remote *client.APIClient, src, dst *pfs.Branch) error {
+	srcInfo, err := lastFinishedCommit(remote, src)
+	if err != nil || srcInfo == nil {
+		return err
+	}
+	head, mirrored, err := d.mirrorHead(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if mirrored == srcInfo.Commit.ID {
+		return nil
+	}
+	if mirrored != "" {
+		if _, err := remote.InspectCommit(src.Repo.Name, src.Name, mirrored); err != nil {
+			if !pfsserver.IsCommitNotFoundErr(err) {
+				return err
+			}
+			mirrored = ""
+		}
+	}
+	putFile := func(uw *fileset.UnorderedWriter, fi *pfs.FileInfo, appendFile bool) error {
+		return miscutil.WithPipe(func(w io.Writer) error {
+			return remote.GetFile(srcInfo.Commit, fi.File.Path, w)
+		}, func(r io.Reader) error {
+			return uw.Put(fi.File.Path, "", appendFile, r, fileset.WithMode(fi.Mode), fileset.WithModTime(fi.Mtime), fileset.WithLinkTarget(fi.LinkTarget), fileset.WithMetadata(fi.Metadata))
+		})
+	}
+	description := "mirror of " + srcInfo.Commit.String()
+	return d.writeMirrorCommit(ctx, dst, head, mirrored, description, srcInfo.Labels, func(uw *fileset.UnorderedWriter) (string, error) {
+		if mirrored == "" {
+			// Replace the mirror's contents entirely, so that files deleted
+			// from the source are deleted from the mirror as well.
+			if err := uw.Delete("/", ""); err != nil {
+				return "", err
+			}
+			return srcInfo.Commit.ID, remote.WalkFile(srcInfo.Commit, "/", func(fi *pfs.FileInfo) error {
+				if fi.FileType != pfs.FileType_FILE {
+					return nil
+				}
+				return putFile(uw, fi, true)
+			})
+		}
+		return srcInfo.Commit.ID, remote.DiffFile(srcInfo.Commit, "/", src.NewCommit(mirrored), "/", false, func(newFi, oldFi *pfs.FileInfo) error {
+			if oldFi != nil && oldFi.FileType == pfs.FileType_FILE && (newFi == nil || newFi.FileType != pfs.FileType_FILE) {
+				if err := uw.Delete(oldFi.File.Path, ""); err != nil {
+					return err
+				}
+			}
+			if newFi == nil || newFi.FileType != pfs.FileType_FILE {
+				return nil
+			}
+			return putFile(uw, newFi, false)
+		})
+	})
+}
+
+// lastFinishedCommit returns the newest finished commit on branch, or nil if
+// there isn't one. It doesn't wait for the branch's head to finish, which
+// could take arbitrarily long and would hold up every other mirror.
+func lastFinishedCommit(remote *client.APIClient, branch *pfs.Branch) (*pfs.CommitInfo, error) {
+	commit := branch.NewCommit("")
+	for commit != nil {
+		ci, err := remote.PfsAPIClient.InspectCommit(remote.Ctx(), &pfs.InspectCommitRequest{Commit: commit})
+		if err != nil {
+			return nil, err
+		}
+		if ci.Finished != nil {
+			return ci, nil
+		}
+		commit = ci.ParentCommit
+	}
+	return nil, nil
+}
+
+// syncSnapshotMirror makes the head of the mirror's branch a copy of the
+// files in its snapshot archive, if the archive has changed since the last
+// sync. The archive is hashed as it's read, since object storage doesn't
+// tell us whether it's changed.
+func (d *driver) syncSnapshotMirror(ctx context.Context, repoInfo *pfs.RepoInfo) error {
+	mirror := repoInfo.Mirror
+	branch := "master"
+	if len(mirror.Branches) > 0 {
+		branch = mirror.Branches[0]
+	}
+	dst := repoInfo.Repo.NewBranch(branch)
+	head, mirrored, err := d.mirrorHead(ctx, dst)
+	if err != nil {
+		return err
+	}
+	url, err := obj.ParseURL(mirror.SnapshotURL)
+	if err != nil {
+		return errors.Wrapf(err, "error parsing url %v", mirror.SnapshotURL)
+	}
+	// The snapshot is read with the credentials in the mirror's secret,
+	// never with pachd's own, which may be able to read objects that the
+	// mirror's creator can't. The secret names the snapshot its credentials
+	// are for, for the same reason as in mirrorClient.
+	data, err := d.mirrorSecret(mirror, mirrorSnapshotURLKey, mirror.SnapshotURL)
+	if err != nil {
+		return err
+	}
+	objClient, err := obj.NewClientFromURLAndSecretData(url, data)
+	if err != nil {
+		return err
+	}
+	description := "mirror of " + mirror.SnapshotURL
+	return d.writeMirrorCommit(ctx, dst, head, mirrored, description, nil, func(uw *fileset.UnorderedWriter) (string, error) {
+		if err := uw.Delete("/", ""); err != nil {
+			return "", err
+		}
+		hash := pachhash.New()
+		if err := miscutil.WithPipe(func(w io.Writer) error {
+			return objClient.Get(ctx, url.Object, w)
+		}, func(r io.Reader) error {
+			r = io.TeeReader(r, hash)
+			if err := putSnapshotFiles(uw, r, isGzipped(mirror.SnapshotURL)); err != nil {
+				return err
+			}
+			// Whatever follows the archive is hashed too.
+			_, err := io.Copy(ioutil.Discard, r)
+			return errors.EnsureStack(err)
+		}); err != nil {
+			return "", err
+		}
+		return "hash:" + pachhash.EncodeHash(hash.Sum(nil)), nil
+	})
+}
+
+// putSnapshotFiles writes the files in the tar archive in r, written by
+// `pachctl export repo`, to uw.
+func putSnapshotFiles(uw *fileset.UnorderedWriter, r io.Reader, gzipped bool) error {
+	if gzipped {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		r = gr
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.EnsureStack(err)
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		mtime, err := types.TimestampProto(hdr.ModTime)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		if err := uw.Put("/"+strings.TrimPrefix(hdr.Name, "/"), "", true, tr, fileset.WithMode(uint32(hdr.Mode)), fileset.WithModTime(mtime)); err != nil {
+			return err
+		}
+	}
+}
+
+func isGzipped(url string) bool {
+	return strings.HasSuffix(url, ".tar.gz") || strings.HasSuffix(url, ".tgz")
+}
+
+// mirrorHead returns the head of the mirror branch dst, and what it's a
+// mirror of. Both are empty if dst doesn't exist yet.
+func (d *driver) mirrorHead(ctx context.Context, dst *pfs.Branch) (*pfs.Commit, string, error) {
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadOnly(ctx).Get(pfsdb.BranchKey(dst), branchInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	headInfo := &pfs.CommitInfo{}
+	if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(branchInfo.Head), headInfo); err != nil {
+		return nil, "", err
+	}
+	return headInfo.Commit, headInfo.Labels[mirrorOfLabel], nil
+}
+
+// writeMirrorCommit writes a commit to dst on top of head, if it's set, with
+// the files written by cb. cb returns what the commit is a mirror of, which is
+// recorded in its labels, and no commit is written if that's what head,
+// which is a mirror of mirrored, already holds.
+func (d *driver) writeMirrorCommit(ctx context.Context, dst *pfs.Branch, head *pfs.Commit, mirrored, description string, labels map[string]string, cb func(*fileset.UnorderedWriter) (string, error)) error {
+	return d.storage.WithRenewer(ctx, defaultTTL, func(ctx context.Context, renewer *renew.StringSet) error {
+		var opts []fileset.UnorderedWriterOption
+		if head != nil {
+			parentID, err := d.getFileSet(ctx, head)
+			if err != nil {
+				return err
+			}
+			renewer.Add(parentID.HexString())
+			opts = append(opts, fileset.WithParentID(parentID))
+		}
+		var mirrorOf string
+		id, err := d.withUnorderedWriter(ctx, renewer, false, func(uw *fileset.UnorderedWriter) error {
+			var err error
+			mirrorOf, err = cb(uw)
+			return err
+		}, opts...)
+		if err != nil {
+			return err
+		}
+		if mirrorOf == mirrored {
+			return nil
+		}
+		labels = mergeLabels(labels, map[string]string{mirrorOfLabel: mirrorOf})
+		return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+			commit, err := d.startCommit(txnCtx, nil, dst, description, labels)
+			if err != nil {
+				return err
+			}
+			if err := d.commitStore.AddFileSetTx(txnCtx.SqlTx, commit, *id); err != nil {
+				return err
+			}
+			return d.finishCommit(txnCtx, commit, "", nil)
+		})
+	})
+}
+
+// checkRepoWritable returns ErrRepoReadOnly if repo is a mirror repo, unless
+// txnCtx belongs to PFS syncing it. A repo that doesn't exist is left for the
+// caller to report.
+func (d *driver) checkRepoWritable(txnCtx *txncontext.TransactionContext, repo *pfs.Repo) error {
+	if isMirrorSync(txnCtx.ClientContext) {
+		return nil
+	}
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadWrite(txnCtx.SqlTx).Get(pfsdb.RepoKey(repo), repoInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if repoInfo.Mirror != nil {
+		return pfsserver.ErrRepoReadOnly{Repo: repo}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
+)
+
+// pager limits a listing to one page of it: at most size items (or all of
+// them, if size isn't positive) after the item whose key is token (or from
+// the start, if token is empty).
+type pager struct {
+	token string
+	size  int64
+	// sorted is true if the listing is sorted by key, so the items on the
+	// page are those with greater keys, even if token's item is gone.
+	sorted bool
+	found  bool
+	added  int64
+}
+
+func newPager(token string, size int64, sorted bool) *pager {
+	return &pager{
+		token:  token,
+		size:   size,
+		sorted: sorted,
+		found:  token == "",
+	}
+}
+
+// add returns true if the item with key is on the page, and errutil.ErrBreak
+// once the page is full, so listing can stop.
+func (p *pager) add(key string) (bool, error) {
+	if p.size > 0 && p.added >= p.size {
+		return false, errutil.ErrBreak
+	}
+	if !p.found {
+		if p.sorted {
+			if key <= p.token {
+				return false, nil
+			}
+		} else {
+			p.found = key == p.token
+			return false, nil
+		}
+		p.found = true
+	}
+	p.added++
+	return true, nil
+}
+
+// check returns an error if the listing ended without reaching the item
+// whose key is token, which is only known for unsorted listings.
+func (p *pager) check() error {
+	if !p.found && !p.sorted {
+		return errors.Errorf("page token %q not found, it may have been deleted", p.token)
+	}
+	return nil
+}
@@ -3,14 +3,25 @@ package server
 import (
 	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	lru "github.com/hashicorp/golang-lru"
 	globlib "github.com/pachyderm/ohmyglob"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 )
 
 var globRegex = regexp.MustCompile(`[*?[\]{}!()@+^]`)
 
+// isGlob returns true if p is a glob pattern. Globbing characters aren't
+// allowed in paths, so any path with one in it is a pattern.
+func isGlob(p string) bool {
+	return globRegex.MatchString(p)
+}
+
 func globLiteralPrefix(glob string) string {
 	idx := globRegex.FindStringIndex(glob)
 	if idx == nil {
@@ -19,17 +30,52 @@ func globLiteralPrefix(glob string) string {
 	return glob[:idx[0]]
 }
 
-func globMatchFunction(glob string) (func(string) bool, error) {
+// globCacheSize is the number of compiled glob patterns kept by globCache.
+const globCacheSize = 1024
+
+// globCache holds recently compiled glob patterns, keyed by pattern, so that
+// repeated requests with the same pattern don't recompile it.
+var globCache = func() *lru.Cache {
+	c, err := lru.New(globCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+func compileGlob(glob string) (*globlib.Glob, error) {
+	if g, ok := globCache.Get(glob); ok {
+		return g.(*globlib.Glob), nil
+	}
 	g, err := globlib.Compile(glob, '/')
 	if err != nil {
 		return nil, err
 	}
+	globCache.Add(glob, g)
+	return g, nil
+}
+
+func globMatchFunction(glob string) (func(string) bool, error) {
+	g, err := compileGlob(glob)
+	if err != nil {
+		return nil, err
+	}
+	// Paths outside of the glob's literal prefix can't match, so they're
+	// rejected without evaluating the pattern. A prefix with an escape in it
+	// isn't literal, so it's not used.
+	prefix := globLiteralPrefix(glob)
+	if strings.Contains(prefix, `\`) {
+		prefix = ""
+	}
 	return func(path string) bool {
 		// TODO: This does not seem like a good approach for this edge case.
 		if path == "/" && glob == "/" {
 			return true
 		}
 		path = strings.TrimRight(path, "/")
+		if !strings.HasPrefix(path+"/", prefix) {
+			return false
+		}
 		return g.Match(path)
 	}, nil
 }
@@ -45,6 +91,37 @@ func pathIsChild(parent, child string) bool {
 	return !strings.Contains(rel, "/")
 }
 
+// hiddenPrefixes returns the name prefixes of hidden files: "." and extra,
+// or nil if hidden files aren't excluded.
+func hiddenPrefixes(exclude bool, extra []string) []string {
+	if !exclude {
+		return nil
+	}
+	prefixes := []string{"."}
+	for _, prefix := range extra {
+		if prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// isHidden determines if any element of the path p starts with one of
+// prefixes.
+func isHidden(p string, prefixes []string) bool {
+	for _, elem := range strings.Split(p, "/") {
+		if elem == "" {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(elem, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // cleanPath converts paths to a canonical form used in the driver
 // "" -> "/"
 // "abc" -> "/abc"
@@ -59,20 +136,88 @@ func cleanPath(p string) string {
 	return "/" + strings.Trim(p, "/")
 }
 
-var validRangeRegex = regexp.MustCompile("^[ -~]+$")
+// pathPolicy determines which paths may be written to PFS.
+type pathPolicy struct {
+	// allowed is the set of characters allowed in paths.
+	allowed *unicode.RangeTable
+	// forbidden are characters that aren't allowed in paths, in addition to
+	// glob characters, which are never allowed.
+	forbidden string
+	// maxComponentLength is the maximum length (in bytes) of each element of
+	// a path, if positive.
+	maxComponentLength int
+	// maxDepth is the maximum number of elements in a path, if positive.
+	maxDepth int
+}
+
+// defaultPathPolicy allows printable ASCII characters, and doesn't limit the
+// length or depth of paths.
+var defaultPathPolicy = &pathPolicy{
+	allowed: &unicode.RangeTable{R16: []unicode.Range16{{Lo: ' ', Hi: '~', Stride: 1}}},
+}
+
+// newPathPolicy creates the path policy described by config. allowedRanges is
+// a comma-separated list of ranges of unicode code points, such as
+// "0x20-0x7e,0xa0-0x10ffff". The default policy is used for any field that
+// isn't set.
+func newPathPolicy(allowedRanges, forbidden string, maxComponentLength, maxDepth int) (*pathPolicy, error) {
+	policy := &pathPolicy{
+		allowed:            defaultPathPolicy.allowed,
+		forbidden:          forbidden,
+		maxComponentLength: maxComponentLength,
+		maxDepth:           maxDepth,
+	}
+	if allowedRanges != "" {
+		table := &unicode.RangeTable{}
+		for _, r := range strings.Split(allowedRanges, ",") {
+			bounds := strings.SplitN(strings.TrimSpace(r), "-", 2)
+			lo, err := strconv.ParseUint(bounds[0], 0, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid path character range %q", r)
+			}
+			hi := lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.ParseUint(bounds[1], 0, 32); err != nil {
+					return nil, errors.Wrapf(err, "invalid path character range %q", r)
+				}
+			}
+			if hi < lo || hi > unicode.MaxRune {
+				return nil, errors.Errorf("invalid path character range %q", r)
+			}
+			table.R32 = append(table.R32, unicode.Range32{Lo: uint32(lo), Hi: uint32(hi), Stride: 1})
+		}
+		sort.Slice(table.R32, func(i, j int) bool { return table.R32[i].Lo < table.R32[j].Lo })
+		policy.allowed = table
+	}
+	return policy, nil
+}
 
-func validate(p string) error {
-	pBytes := []byte(p)
-	if !validRangeRegex.Match(pBytes) {
-		return errors.Errorf("path (%v) invalid: only printable ASCII characters allowed", p)
+func (policy *pathPolicy) validate(p string) error {
+	if p == "" {
+		return errors.Errorf("path invalid: empty paths are not allowed")
+	}
+	for _, r := range p {
+		if r == utf8.RuneError || !unicode.Is(policy.allowed, r) {
+			return errors.Errorf("path (%q) invalid: character %q is not allowed", p, r)
+		}
+		if strings.ContainsRune(policy.forbidden, r) {
+			return errors.Errorf("path (%q) invalid: character %q is forbidden", p, r)
+		}
 	}
-	if globRegex.Match(pBytes) {
+	if globRegex.MatchString(p) {
 		return errors.Errorf("path (%v) invalid: globbing character (%v) not allowed in path", p, globRegex.FindString(p))
 	}
-	for _, elem := range strings.Split(p, "/") {
+	elems := strings.Split(strings.Trim(p, "/"), "/")
+	if policy.maxDepth > 0 && len(elems) > policy.maxDepth {
+		return errors.Errorf("path (%v) invalid: path has %d elements, the maximum is %d", p, len(elems), policy.maxDepth)
+	}
+	for _, elem := range elems {
 		if elem == "." || elem == ".." {
 			return errors.Errorf("path (%v) invalid: relative file paths are not allowed", p)
 		}
+		if policy.maxComponentLength > 0 && len(elem) > policy.maxComponentLength {
+			return errors.Errorf("path (%v) invalid: element %q is longer than the maximum of %d bytes", p, elem, policy.maxComponentLength)
+		}
 	}
 	return nil
 }
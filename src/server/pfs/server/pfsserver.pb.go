@@ -6,6 +6,7 @@ package server
 import (
 	fmt "fmt"
 	proto "github.com/gogo/protobuf/proto"
+	types "github.com/gogo/protobuf/types"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -195,32 +196,95 @@ func (m *PathRange) GetUpper() string {
 	return ""
 }
 
+// SubscribeCommitCursor is the position of a commit in a SubscribeCommit
+// stream. It's returned to clients encoded in CommitInfo.cursor.
+type SubscribeCommitCursor struct {
+	// commit is the key of the commit in the commits collection.
+	Commit               string           `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"`
+	Started              *types.Timestamp `protobuf:"bytes,2,opt,name=started,proto3" json:"started,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *SubscribeCommitCursor) Reset()         { *m = SubscribeCommitCursor{} }
+func (m *SubscribeCommitCursor) String() string { return proto.CompactTextString(m) }
+func (*SubscribeCommitCursor) ProtoMessage()    {}
+func (*SubscribeCommitCursor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a5a92e512e703e9c, []int{3}
+}
+func (m *SubscribeCommitCursor) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubscribeCommitCursor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubscribeCommitCursor.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SubscribeCommitCursor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeCommitCursor.Merge(m, src)
+}
+func (m *SubscribeCommitCursor) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubscribeCommitCursor) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeCommitCursor.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeCommitCursor proto.InternalMessageInfo
+
+func (m *SubscribeCommitCursor) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+func (m *SubscribeCommitCursor) GetStarted() *types.Timestamp {
+	if m != nil {
+		return m.Started
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*CompactionTask)(nil), "pfsserver.CompactionTask")
 	proto.RegisterType((*CompactionTaskResult)(nil), "pfsserver.CompactionTaskResult")
 	proto.RegisterType((*PathRange)(nil), "pfsserver.PathRange")
+	proto.RegisterType((*SubscribeCommitCursor)(nil), "pfsserver.SubscribeCommitCursor")
 }
 
 func init() { proto.RegisterFile("server/pfs/server/pfsserver.proto", fileDescriptor_a5a92e512e703e9c) }
 
 var fileDescriptor_a5a92e512e703e9c = []byte{
-	// 247 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x2c, 0x4e, 0x2d, 0x2a,
-	0x4b, 0x2d, 0xd2, 0x2f, 0x48, 0x2b, 0xd6, 0x47, 0x30, 0x21, 0x2c, 0xbd, 0x82, 0xa2, 0xfc, 0x92,
-	0x7c, 0x21, 0x4e, 0xb8, 0x80, 0x52, 0x16, 0x17, 0x9f, 0x73, 0x7e, 0x6e, 0x41, 0x62, 0x72, 0x49,
-	0x66, 0x7e, 0x5e, 0x48, 0x62, 0x71, 0xb6, 0x90, 0x08, 0x17, 0x6b, 0x66, 0x5e, 0x4a, 0x6a, 0x85,
-	0x04, 0xa3, 0x02, 0xa3, 0x06, 0x73, 0x10, 0x84, 0x23, 0x24, 0xc6, 0xc5, 0x96, 0x99, 0x57, 0x50,
-	0x5a, 0x52, 0x2c, 0xc1, 0xa4, 0xc0, 0xac, 0xc1, 0x19, 0x04, 0xe5, 0x09, 0x69, 0x71, 0xb1, 0x16,
-	0x25, 0xe6, 0xa5, 0xa7, 0x4a, 0x30, 0x2b, 0x30, 0x6a, 0x70, 0x1b, 0x89, 0xe8, 0x21, 0xec, 0x0a,
-	0x48, 0x2c, 0xc9, 0x08, 0x02, 0xc9, 0x05, 0x41, 0x94, 0x28, 0xd9, 0x70, 0x89, 0xa0, 0xda, 0x15,
-	0x94, 0x5a, 0x5c, 0x9a, 0x53, 0x82, 0xc3, 0x46, 0x3e, 0x2e, 0xa6, 0xcc, 0x14, 0x09, 0x26, 0x05,
-	0x46, 0x0d, 0xce, 0x20, 0xa6, 0xcc, 0x14, 0x25, 0x73, 0x2e, 0x4e, 0xb8, 0x89, 0x20, 0x2d, 0x39,
-	0xf9, 0xe5, 0xa9, 0x45, 0x60, 0x2d, 0x9c, 0x41, 0x10, 0x0e, 0x48, 0xb4, 0xb4, 0xa0, 0x20, 0xb5,
-	0x08, 0xaa, 0x0b, 0xc2, 0x71, 0x72, 0x3d, 0xf1, 0x48, 0x8e, 0xf1, 0xc2, 0x23, 0x39, 0xc6, 0x07,
-	0x8f, 0xe4, 0x18, 0xa3, 0xcc, 0xd3, 0x33, 0x4b, 0x32, 0x4a, 0x93, 0xf4, 0x92, 0xf3, 0x73, 0xf5,
-	0x0b, 0x12, 0x93, 0x33, 0x2a, 0x53, 0x52, 0x8b, 0x90, 0x59, 0x65, 0x46, 0xfa, 0xc5, 0x45, 0xc9,
-	0xfa, 0x18, 0x81, 0x98, 0xc4, 0x06, 0x0e, 0x3b, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0x2d,
-	0xaf, 0x30, 0x7a, 0x60, 0x01, 0x00, 0x00,
+	// 317 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0x3f, 0x4f, 0xf3, 0x30,
+	0x10, 0xc6, 0xe5, 0x54, 0xed, 0xab, 0xb8, 0x52, 0x87, 0xa8, 0x2f, 0x8a, 0x3a, 0x94, 0x90, 0x29,
+	0x62, 0x88, 0xa5, 0x82, 0xd4, 0x85, 0x89, 0x8a, 0x1d, 0x99, 0x4e, 0x6c, 0x4e, 0x72, 0x4d, 0x0d,
+	0x4d, 0x6c, 0xf9, 0x4f, 0x81, 0x6f, 0xc8, 0xc8, 0x47, 0x40, 0xfd, 0x24, 0x28, 0x71, 0xd2, 0x82,
+	0x10, 0xdb, 0xfd, 0xee, 0xee, 0xb9, 0x27, 0x79, 0x8c, 0x2f, 0x34, 0xa8, 0x3d, 0x28, 0x22, 0x37,
+	0x9a, 0x9c, 0x4a, 0x57, 0xa5, 0x52, 0x09, 0x23, 0x02, 0xff, 0xd8, 0x98, 0x9d, 0x97, 0x42, 0x94,
+	0x3b, 0x20, 0xed, 0x20, 0xb3, 0x1b, 0x62, 0x78, 0x05, 0xda, 0xb0, 0x4a, 0xba, 0xdd, 0xf8, 0x09,
+	0x4f, 0x56, 0xa2, 0x92, 0x2c, 0x37, 0x5c, 0xd4, 0x6b, 0xa6, 0x9f, 0x83, 0x29, 0x1e, 0xf2, 0xba,
+	0x80, 0xd7, 0x10, 0x45, 0x28, 0x19, 0x50, 0x07, 0xc1, 0x19, 0x1e, 0xf1, 0x5a, 0x5a, 0xa3, 0x43,
+	0x2f, 0x1a, 0x24, 0x3e, 0xed, 0x28, 0xb8, 0xc4, 0x43, 0xc5, 0xea, 0x12, 0xc2, 0x41, 0x84, 0x92,
+	0xf1, 0x62, 0x9a, 0x9e, 0x3e, 0xe6, 0x9e, 0x99, 0x2d, 0x6d, 0x66, 0xd4, 0xad, 0xc4, 0x37, 0x78,
+	0xfa, 0xd3, 0x8b, 0x82, 0xb6, 0x3b, 0xf3, 0x87, 0xe3, 0x04, 0x7b, 0xbc, 0x08, 0xbd, 0x08, 0x25,
+	0x3e, 0xf5, 0x78, 0x11, 0x2f, 0xb1, 0x7f, 0xbc, 0xd8, 0x48, 0x76, 0xe2, 0x05, 0x54, 0x2b, 0xf1,
+	0xa9, 0x83, 0xa6, 0x6b, 0xa5, 0x04, 0xd5, 0xa9, 0x1c, 0xc4, 0x80, 0xff, 0x3f, 0xd8, 0x4c, 0xe7,
+	0x8a, 0x67, 0xb0, 0x12, 0x55, 0xc5, 0xcd, 0xca, 0x2a, 0x2d, 0x54, 0xf3, 0x4f, 0x79, 0xcb, 0xdd,
+	0x95, 0x8e, 0x82, 0x6b, 0xfc, 0x4f, 0x1b, 0xa6, 0x0c, 0x38, 0xfb, 0xf1, 0x62, 0x96, 0xba, 0x18,
+	0xd3, 0x3e, 0xc6, 0x74, 0xdd, 0xc7, 0x48, 0xfb, 0xd5, 0xdb, 0xbb, 0xf7, 0xc3, 0x1c, 0x7d, 0x1c,
+	0xe6, 0xe8, 0xf3, 0x30, 0x47, 0x8f, 0xcb, 0x92, 0x9b, 0xad, 0xcd, 0xd2, 0x5c, 0x54, 0x44, 0xb2,
+	0x7c, 0xfb, 0x56, 0x80, 0xfa, 0x5e, 0xed, 0x17, 0x44, 0xab, 0x9c, 0xfc, 0x7a, 0xcc, 0x6c, 0xd4,
+	0x7a, 0x5c, 0x7d, 0x0d, 0x00, 0xa0, 0x43, 0xed, 0x3d, 0xe8, 0x01, 0x00, 0x00,
 }
 
 func (m *CompactionTask) Marshal() (dAtA []byte, err error) {
@@ -356,6 +420,52 @@ func (m *PathRange) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *SubscribeCommitCursor) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscribeCommitCursor) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscribeCommitCursor) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Started != nil {
+		{
+			size, err := m.Started.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintPfsserver(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Commit) > 0 {
+		i -= len(m.Commit)
+		copy(dAtA[i:], m.Commit)
+		i = encodeVarintPfsserver(dAtA, i, uint64(len(m.Commit)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintPfsserver(dAtA []byte, offset int, v uint64) int {
 	offset -= sovPfsserver(v)
 	base := offset
@@ -431,6 +541,26 @@ func (m *PathRange) Size() (n int) {
 	return n
 }
 
+func (m *SubscribeCommitCursor) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Commit)
+	if l > 0 {
+		n += 1 + l + sovPfsserver(uint64(l))
+	}
+	if m.Started != nil {
+		l = m.Started.Size()
+		n += 1 + l + sovPfsserver(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovPfsserver(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -792,6 +922,125 @@ func (m *PathRange) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *SubscribeCommitCursor) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPfsserver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SubscribeCommitCursor: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SubscribeCommitCursor: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Commit", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPfsserver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPfsserver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPfsserver
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Commit = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Started", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPfsserver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPfsserver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPfsserver
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Started == nil {
+				m.Started = &types.Timestamp{}
+			}
+			if err := m.Started.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPfsserver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPfsserver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipPfsserver(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
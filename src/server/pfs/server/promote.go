@@ -0,0 +1,94 @@
+package server
+
+import (
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+// promoteBranch moves the head of to to the head of from, which must be in
+// the same repo, by aliasing it onto to. If expectedHead is set, to's head
+// must be it, so that a promotion that raced with another change to to fails
+// rather than undoing that change.
+func (d *driver) promoteBranch(txnCtx *txncontext.TransactionContext, from, to *pfs.Branch, expectedHead *pfs.Commit) error {
+	toInfo, err := d.checkPromoteBranch(txnCtx, from, to)
+	if err != nil {
+		return err
+	}
+	head, err := d.promotedHead(txnCtx, from)
+	if err != nil {
+		return err
+	}
+	if len(toInfo.DirectProvenance) > 0 {
+		return errors.Errorf("cannot promote to branch %s because it has provenance", to)
+	}
+	if expectedHead != nil && expectedHead.ID != toInfo.Head.ID {
+		return pfsserver.ErrUnexpectedBranchHead{Branch: to, Head: toInfo.Head, Expected: expectedHead}
+	}
+	// There's nothing to do if to's head is already from's head, or an alias
+	// of it.
+	if toInfo.Head.ID == head.Commit.ID {
+		return nil
+	}
+	toHead, err := d.resolveCommit(txnCtx.SqlTx, toInfo.Head)
+	if err != nil {
+		return err
+	}
+	if toHead.Origin.GetKind() == pfs.OriginKind_ALIAS && proto.Equal(toHead.ParentCommit, head.Commit) {
+		return nil
+	}
+	aliasCommitInfo, err := d.aliasCommit(txnCtx, head.Commit, to, pfs.HeadChangeCause_PROMOTED)
+	if err != nil {
+		return err
+	}
+	if err := txnCtx.PropagateBranch(to); err != nil {
+		return err
+	}
+	// Branches that trigger on to may fire in turn.
+	return d.triggerCommit(txnCtx, aliasCommitInfo.Commit)
+}
+
+// checkPromoteBranch checks that from can be promoted to to, and that the
+// caller is allowed to, and returns the info of to. from isn't read, so that
+// it's only read once it's known to be in to's repo, where the auth check on
+// to covers it.
+func (d *driver) checkPromoteBranch(txnCtx *txncontext.TransactionContext, from, to *pfs.Branch) (*pfs.BranchInfo, error) {
+	if from == nil || to == nil {
+		return nil, errors.Errorf("cannot promote a nil branch")
+	}
+	if !proto.Equal(from.Repo, to.Repo) {
+		return nil, errors.Errorf("cannot promote branch %s to %s because they're in different repos", from, to)
+	}
+	if from.Name == to.Name {
+		return nil, errors.Errorf("cannot promote branch %s to itself", from)
+	}
+	toInfo, err := d.inspectBranch(txnCtx, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, to.Repo.QualifiedName(), auth.Permission_REPO_CREATE_BRANCH); err != nil {
+		return nil, err
+	}
+	return toInfo, nil
+}
+
+// promotedHead returns the info of the head of from, which is what
+// promoting from moves another branch's head to. It must be finished.
+func (d *driver) promotedHead(txnCtx *txncontext.TransactionContext, from *pfs.Branch) (*pfs.CommitInfo, error) {
+	fromInfo, err := d.inspectBranch(txnCtx, from)
+	if err != nil {
+		return nil, err
+	}
+	head, err := d.resolveCommit(txnCtx.SqlTx, fromInfo.Head)
+	if err != nil {
+		return nil, err
+	}
+	if head.Finished == nil {
+		return nil, pfsserver.ErrCommitNotFinished{Commit: head.Commit}
+	}
+	return head, nil
+}
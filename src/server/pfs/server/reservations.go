@@ -0,0 +1,114 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+	"golang.org/x/net/context"
+)
+
+// reservationPrefix returns prefix as a clean directory path.
+func reservationPrefix(prefix string) string {
+	return fileset.Clean(prefix, true)
+}
+
+// reservationCovers returns true if p is the reserved directory or is under it.
+func reservationCovers(r *pfs.PathReservation, p string) bool {
+	return strings.HasPrefix(p, r.Prefix) || p == strings.TrimSuffix(r.Prefix, "/")
+}
+
+func (d *driver) reservePath(txnCtx *txncontext.TransactionContext, repo *pfs.Repo, prefix, owner string) error {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, repo.QualifiedName(), auth.Permission_REPO_MODIFY_BINDINGS); err != nil {
+		return errors.EnsureStack(err)
+	}
+	prefix = reservationPrefix(prefix)
+	if prefix == "/" {
+		return errors.Errorf("the root of a repo can't be reserved")
+	}
+	if owner == "" {
+		whoAmI, err := d.env.AuthServer().WhoAmI(txnCtx.ClientContext, &auth.WhoAmIRequest{})
+		if err != nil {
+			if auth.IsErrNotActivated(err) {
+				return errors.Errorf("an owner must be given when auth is not active")
+			}
+			return errors.EnsureStack(err)
+		}
+		owner = whoAmI.Username
+	}
+	repoInfo := &pfs.RepoInfo{}
+	return errors.EnsureStack(d.repos.ReadWrite(txnCtx.SqlTx).Update(pfsdb.RepoKey(repo), repoInfo, func() error {
+		for _, r := range repoInfo.PathReservations {
+			if r.Prefix == prefix && r.Owner == owner {
+				return nil
+			}
+			// Reservations can't overlap, so that exactly one owner is
+			// responsible for each path.
+			if strings.HasPrefix(prefix, r.Prefix) || strings.HasPrefix(r.Prefix, prefix) {
+				return errors.Errorf("prefix %v overlaps prefix %v, which is reserved for %v", prefix, r.Prefix, r.Owner)
+			}
+		}
+		repoInfo.PathReservations = append(repoInfo.PathReservations, &pfs.PathReservation{
+			Prefix: prefix,
+			Owner:  owner,
+		})
+		return nil
+	}))
+}
+
+func (d *driver) releasePath(txnCtx *txncontext.TransactionContext, repo *pfs.Repo, prefix string) error {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, repo.QualifiedName(), auth.Permission_REPO_MODIFY_BINDINGS); err != nil {
+		return errors.EnsureStack(err)
+	}
+	prefix = reservationPrefix(prefix)
+	repoInfo := &pfs.RepoInfo{}
+	return errors.EnsureStack(d.repos.ReadWrite(txnCtx.SqlTx).Update(pfsdb.RepoKey(repo), repoInfo, func() error {
+		for i, r := range repoInfo.PathReservations {
+			if r.Prefix == prefix {
+				repoInfo.PathReservations = append(repoInfo.PathReservations[:i], repoInfo.PathReservations[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Errorf("prefix %v is not reserved in repo %v", prefix, repo)
+	}))
+}
+
+// reservationValidator returns a path validator that rejects modifications
+// to the paths that repoInfo reserves for writers other than the caller, in
+// addition to the paths rejected by the path policy. Callers that can't be
+// identified, because auth is not active, only get to modify unreserved paths.
+func (d *driver) reservationValidator(ctx context.Context, repoInfo *pfs.RepoInfo) (func(string) error, error) {
+	if len(repoInfo.PathReservations) == 0 {
+		return d.pathPolicy.validate, nil
+	}
+	var caller string
+	whoAmI, err := d.env.AuthServer().WhoAmI(ctx, &auth.WhoAmIRequest{})
+	if err != nil && !auth.IsErrNotActivated(err) {
+		return nil, errors.EnsureStack(err)
+	}
+	if err == nil {
+		caller = whoAmI.Username
+	}
+	return func(p string) error {
+		if err := d.pathPolicy.validate(p); err != nil {
+			return err
+		}
+		p = fileset.Clean(p, fileset.IsDir(p))
+		for _, r := range repoInfo.PathReservations {
+			if r.Owner != caller && reservationCovers(r, p) {
+				return pfsserver.ErrPathReserved{
+					Repo:   repoInfo.Repo,
+					Path:   p,
+					Prefix: r.Prefix,
+					Owner:  r.Owner,
+				}
+			}
+		}
+		return nil
+	}, nil
+}
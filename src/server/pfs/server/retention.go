@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+// expireCommitsForever periodically squashes the commits that are beyond
+// their branch's retention policy, or their repo's retention setting.
+func (d *driver) expireCommitsForever(ctx context.Context, intervalStr string) error {
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid commit retention interval %q", intervalStr)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		case <-ticker.C:
+		}
+		if err := d.expireCommits(ctx); err != nil {
+			log.Errorf("error expiring commits: %v", err)
+		}
+	}
+}
+
+// expireCommits squashes the commit sets of expired commits. A commit set is
+// only squashed if all of its commits are finished and none of them is the
+// head of a branch, so branch heads, and the commits that the heads of
+// downstream branches are provenant on, are kept.
+func (d *driver) expireCommits(ctx context.Context) error {
+	now := time.Now()
+	repoRetention := make(map[string]time.Duration)
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions(), func(string) error {
+		if repoInfo.Settings.GetRetention() != nil {
+			retention, err := types.DurationFromProto(repoInfo.Settings.Retention)
+			if err != nil {
+				return errors.EnsureStack(err)
+			}
+			repoRetention[pfsdb.RepoKey(repoInfo.Repo)] = retention
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	heads := make(map[string]bool)
+	var branchInfos []*pfs.BranchInfo
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadOnly(ctx).List(branchInfo, col.DefaultOptions(), func(string) error {
+		if branchInfo.Head == nil {
+			return nil
+		}
+		heads[pfsdb.CommitKey(branchInfo.Head)] = true
+		branchInfos = append(branchInfos, proto.Clone(branchInfo).(*pfs.BranchInfo))
+		return nil
+	}); err != nil {
+		return err
+	}
+	expired := make(map[string]bool)
+	for _, branchInfo := range branchInfos {
+		maxCommits := branchInfo.Retention.GetMaxCommits()
+		maxAge := repoRetention[pfsdb.RepoKey(branchInfo.Branch.Repo)]
+		if branchInfo.Retention.GetMaxAge() != nil {
+			var err error
+			if maxAge, err = types.DurationFromProto(branchInfo.Retention.MaxAge); err != nil {
+				return errors.EnsureStack(err)
+			}
+		}
+		if maxCommits <= 0 && maxAge <= 0 {
+			continue
+		}
+		// The head is always kept, so the walk starts from its parent.
+		commitInfo := &pfs.CommitInfo{}
+		if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(branchInfo.Head), commitInfo); err != nil {
+			return err
+		}
+		for n := int64(2); commitInfo.ParentCommit != nil && proto.Equal(commitInfo.ParentCommit.Branch, branchInfo.Branch); n++ {
+			if err := d.commits.ReadOnly(ctx).Get(pfsdb.CommitKey(commitInfo.ParentCommit), commitInfo); err != nil {
+				return err
+			}
+			if isExpiredCommit(commitInfo, n, maxCommits, maxAge, now) {
+				expired[commitInfo.Commit.ID] = true
+			}
+		}
+	}
+	var squashed int
+	for id := range expired {
+		commitset := &pfs.CommitSet{ID: id}
+		var ok bool
+		if err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+			var err error
+			if ok, err = d.canExpireCommitSet(txnCtx, commitset, heads); err != nil || !ok {
+				return err
+			}
+			return d.squashCommitSet(txnCtx, commitset)
+		}); err != nil {
+			return errors.Wrapf(err, "error squashing expired commit set %v", id)
+		}
+		if ok {
+			squashed++
+		}
+	}
+	if squashed > 0 {
+		log.Infof("commit retention: squashed %d expired commit sets", squashed)
+	}
+	return nil
+}
+
+// isExpiredCommit returns true if a finished commit is beyond the retention
+// limits of its branch, where n is its position on the branch, starting at 1
+// for the head.
+func isExpiredCommit(commitInfo *pfs.CommitInfo, n, maxCommits int64, maxAge time.Duration, now time.Time) bool {
+	if commitInfo.Finished == nil {
+		return false
+	}
+	if maxCommits > 0 && n > maxCommits {
+		return true
+	}
+	if maxAge <= 0 {
+		return false
+	}
+	finished, err := types.TimestampFromProto(commitInfo.Finished)
+	if err != nil {
+		return false
+	}
+	return now.Sub(finished) > maxAge
+}
+
+// canExpireCommitSet returns true if all of the commits in commitset are
+// finished, none of them is the head of a branch, and none of them is in a
+// locked repo. heads are the heads of
+// all branches when the expired commits were found, and the head of each
+// commit's own branch is read again in case it has changed since.
+func (d *driver) canExpireCommitSet(txnCtx *txncontext.TransactionContext, commitset *pfs.CommitSet, heads map[string]bool) (bool, error) {
+	commitInfos, err := d.inspectCommitSetImmediate(txnCtx, commitset)
+	if err != nil {
+		if pfsserver.IsCommitSetNotFoundErr(err) {
+			// The commit set was squashed in the meantime.
+			return false, nil
+		}
+		return false, err
+	}
+	for _, commitInfo := range commitInfos {
+		if commitInfo.Finished == nil || heads[pfsdb.CommitKey(commitInfo.Commit)] {
+			return false, nil
+		}
+		if err := d.checkRepoUnlocked(txnCtx, commitInfo.Commit.Branch.Repo); err != nil {
+			if pfsserver.IsRepoLockedErr(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		branchInfo := &pfs.BranchInfo{}
+		if err := d.branches.ReadWrite(txnCtx.SqlTx).Get(pfsdb.BranchKey(commitInfo.Commit.Branch), branchInfo); err != nil {
+			if col.IsErrNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if branchInfo.Head != nil && branchInfo.Head.ID == commitInfo.Commit.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
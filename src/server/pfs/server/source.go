@@ -5,11 +5,16 @@ import (
 	"path"
 	"strings"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset/index"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	"golang.org/x/net/context"
+	"golang.org/x/sync/errgroup"
 )
 
 // Source iterates over FileInfos generated from a fileset.FileSet
@@ -60,6 +65,12 @@ func (s *source) Iterate(ctx context.Context, cb func(*pfs.FileInfo, fileset.Fil
 		}
 		if fileset.IsDir(idx.Path) {
 			fi.FileType = pfs.FileType_DIR
+		} else {
+			fi.Mode = idx.File.Mode
+			fi.Mtime = idx.File.Mtime
+			fi.LinkTarget = idx.File.LinkTarget
+			fi.Metadata = idx.File.Metadata
+			fi.CopiedFrom = idx.File.CopiedFrom
 		}
 		if s.full {
 			cachedFi, ok, err := s.checkFileInfoCache(ctx, cache, f)
@@ -69,6 +80,11 @@ func (s *source) Iterate(ctx context.Context, cb func(*pfs.FileInfo, fileset.Fil
 			if ok {
 				fi.SizeBytes = cachedFi.SizeBytes
 				fi.Hash = cachedFi.Hash
+				fi.NumChildren = cachedFi.NumChildren
+				fi.NumDescendants = cachedFi.NumDescendants
+				fi.NumFiles = cachedFi.NumFiles
+				fi.NumDirs = cachedFi.NumDirs
+				fi.NewestMtime = cachedFi.NewestMtime
 			} else {
 				computedFi, err := s.computeFileInfo(ctx, cache, iter, idx.Path)
 				if err != nil {
@@ -76,6 +92,11 @@ func (s *source) Iterate(ctx context.Context, cb func(*pfs.FileInfo, fileset.Fil
 				}
 				fi.SizeBytes = computedFi.SizeBytes
 				fi.Hash = computedFi.Hash
+				fi.NumChildren = computedFi.NumChildren
+				fi.NumDescendants = computedFi.NumDescendants
+				fi.NumFiles = computedFi.NumFiles
+				fi.NumDirs = computedFi.NumDirs
+				fi.NewestMtime = computedFi.NewestMtime
 			}
 		}
 		// TODO: Figure out how to remove directory infos from cache when they are no longer needed.
@@ -119,7 +140,7 @@ func (s *source) computeFileInfo(ctx context.Context, cache map[string]*pfs.File
 	if !fileset.IsDir(idx.Path) {
 		return s.computeRegularFileInfo(ctx, f)
 	}
-	var size uint64
+	fi := &pfs.FileInfo{FileType: pfs.FileType_DIR}
 	h := pfs.NewHash()
 	for {
 		f2, err := iter.Peek()
@@ -137,13 +158,10 @@ func (s *source) computeFileInfo(ctx context.Context, cache map[string]*pfs.File
 		if err != nil {
 			return nil, err
 		}
-		size += childFi.SizeBytes
 		h.Write(childFi.Hash)
+		addChildFileInfo(fi, childFi)
 	}
-	fi := &pfs.FileInfo{
-		SizeBytes: size,
-		Hash:      h.Sum(nil),
-	}
+	fi.Hash = h.Sum(nil)
 	cache[target] = fi
 	return fi, nil
 }
@@ -152,6 +170,7 @@ func (s *source) computeRegularFileInfo(ctx context.Context, f fileset.File) (*p
 	fi := &pfs.FileInfo{
 		FileType:  pfs.FileType_FILE,
 		SizeBytes: uint64(index.SizeBytes(f.Index())),
+		Mtime:     f.Index().File.Mtime,
 	}
 	var err error
 	fi.Hash, err = f.Hash()
@@ -161,6 +180,36 @@ func (s *source) computeRegularFileInfo(ctx context.Context, f fileset.File) (*p
 	return fi, nil
 }
 
+// addChildFileInfo adds the info of child, which is directly inside the
+// directory with info fi, to fi's totals.
+func addChildFileInfo(fi, child *pfs.FileInfo) {
+	fi.SizeBytes += child.SizeBytes
+	fi.NumChildren++
+	fi.NumDescendants += 1 + child.NumDescendants
+	mtime := child.Mtime
+	if child.FileType == pfs.FileType_DIR {
+		fi.NumDirs += 1 + child.NumDirs
+		fi.NumFiles += child.NumFiles
+		mtime = child.NewestMtime
+	} else {
+		fi.NumFiles++
+	}
+	if newerTimestamp(mtime, fi.NewestMtime) {
+		fi.NewestMtime = mtime
+	}
+}
+
+// newerTimestamp returns true if a is set and is later than b, or b isn't set.
+func newerTimestamp(a, b *types.Timestamp) bool {
+	if a == nil {
+		return false
+	}
+	if b == nil {
+		return true
+	}
+	return a.Seconds > b.Seconds || (a.Seconds == b.Seconds && a.Nanos > b.Nanos)
+}
+
 type errOnEmpty struct {
 	source Source
 	err    error
@@ -187,8 +236,77 @@ func (s *errOnEmpty) Iterate(ctx context.Context, cb func(*pfs.FileInfo, fileset
 	return nil
 }
 
+type rangeSource struct {
+	source         Source
+	chunks         *chunk.Storage
+	offset, length int64
+}
+
+// newRangeSource restricts the content of the file in s to at most length
+// bytes starting at offset (or everything from offset if length is 0). It's an
+// error for s to contain anything other than a single file.
+func newRangeSource(s Source, chunks *chunk.Storage, offset, length int64) Source {
+	return &rangeSource{source: s, chunks: chunks, offset: offset, length: length}
+}
+
+// Iterate calls cb with the file in the underlying Source, with its content
+// restricted to the range.
+func (s *rangeSource) Iterate(ctx context.Context, cb func(*pfs.FileInfo, fileset.File) error) error {
+	var first string
+	return s.source.Iterate(ctx, func(fi *pfs.FileInfo, f fileset.File) error {
+		if fi.FileType != pfs.FileType_FILE {
+			return errors.Errorf("cannot read a range of %s, because it's a directory", fi.File.Path)
+		}
+		if first != "" {
+			return errors.Errorf("cannot read a range of more than one file, but both %s and %s match", first, fi.File.Path)
+		}
+		first = fi.File.Path
+		rf := fileset.NewRangeFile(ctx, s.chunks, f, s.offset, s.length)
+		fi = proto.Clone(fi).(*pfs.FileInfo)
+		fi.SizeBytes = uint64(index.SizeBytes(rf.Index()))
+		return cb(fi, rf)
+	})
+}
+
 type emptySource struct{}
 
 func (emptySource) Iterate(ctx context.Context, cb func(*pfs.FileInfo, fileset.File) error) error {
 	return nil
 }
+
+// sendFileInfos calls iterate, and sends the FileInfos it produces with send
+// from a separate goroutine. At most bufSize FileInfos are queued between the
+// two. Once the queue is full, iterate blocks until send catches up, so a
+// client that reads slowly pauses iteration over the index rather than
+// growing the server's buffers. If bufSize is 0, FileInfos are sent from
+// iterate directly.
+func sendFileInfos(ctx context.Context, bufSize int, send func(*pfs.FileInfo) error, iterate func(context.Context, func(*pfs.FileInfo) error) error) error {
+	if bufSize <= 0 {
+		return iterate(ctx, send)
+	}
+	fis := make(chan *pfs.FileInfo, bufSize)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		defer close(fis)
+		return iterate(ctx, func(fi *pfs.FileInfo) error {
+			select {
+			case fis <- fi:
+				return nil
+			case <-ctx.Done():
+				return errors.EnsureStack(ctx.Err())
+			}
+		})
+	})
+	eg.Go(func() error {
+		for fi := range fis {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := send(fi); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.EnsureStack(eg.Wait())
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/sql"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// recordReader reads the records of split content.
+type recordReader interface {
+	// Read returns the next record, or io.EOF after the last one.
+	Read() ([]byte, error)
+	// Header and Footer return what goes at the start and end of every file,
+	// besides header records.
+	Header() []byte
+	Footer() []byte
+}
+
+func newRecordReader(delimiter pfs.Delimiter, r io.Reader) (recordReader, error) {
+	switch delimiter {
+	case pfs.Delimiter_LINE:
+		return &lineReader{r: bufio.NewReader(r)}, nil
+	case pfs.Delimiter_JSON:
+		return &jsonReader{d: json.NewDecoder(r)}, nil
+	case pfs.Delimiter_CSV:
+		return &csvReader{r: bufio.NewReader(r)}, nil
+	case pfs.Delimiter_SQL:
+		return &sqlReader{r: sql.NewPGDumpReader(bufio.NewReader(r))}, nil
+	default:
+		return nil, errors.Errorf("unrecognized delimiter %v", delimiter)
+	}
+}
+
+type noHeader struct{}
+
+func (noHeader) Header() []byte { return nil }
+func (noHeader) Footer() []byte { return nil }
+
+// lineReader reads newline-terminated records.
+type lineReader struct {
+	noHeader
+	r *bufio.Reader
+}
+
+func (lr *lineReader) Read() ([]byte, error) {
+	record, err := lr.r.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(record) > 0 {
+			return record, nil
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// jsonReader reads JSON values, dropping the whitespace between them.
+type jsonReader struct {
+	noHeader
+	d *json.Decoder
+}
+
+func (jr *jsonReader) Read() ([]byte, error) {
+	var record json.RawMessage
+	if err := jr.d.Decode(&record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// csvReader reads CSV records as they are, so a record can span several lines
+// if it has quoted newlines.
+type csvReader struct {
+	noHeader
+	r *bufio.Reader
+}
+
+func (cr *csvReader) Read() ([]byte, error) {
+	var record []byte
+	var quoted bool
+	for {
+		line, err := cr.r.ReadBytes('\n')
+		record = append(record, line...)
+		// Escaped quotes come in pairs, so only an odd number of quotes
+		// opens or closes a quoted field.
+		if bytes.Count(line, []byte{'"'})%2 == 1 {
+			quoted = !quoted
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) || len(record) == 0 {
+				return nil, err
+			}
+			if quoted {
+				return nil, errors.Errorf("invalid CSV: unterminated quoted field")
+			}
+			return record, nil
+		}
+		if !quoted {
+			return record, nil
+		}
+	}
+}
+
+// sqlReader reads the rows of a pgdump file.
+type sqlReader struct {
+	r *sql.PGDumpReader
+}
+
+func (sr *sqlReader) Read() ([]byte, error) {
+	return sr.r.ReadRow()
+}
+
+func (sr *sqlReader) Header() []byte { return sr.r.Header }
+func (sr *sqlReader) Footer() []byte { return sr.r.Footer }
+
+// splitter writes records to numbered files in a directory.
+type splitter struct {
+	uw    *fileset.UnorderedWriter
+	add   *pfs.AddFile
+	opts  []fileset.FileOption
+	first int64
+	next  int64
+}
+
+func (s *splitter) name(i int64) string {
+	return fmt.Sprintf("%s/%016x", strings.TrimSuffix(s.add.Path, "/"), i)
+}
+
+// split writes the records in r to files, grouped by the split's targets.
+func (s *splitter) split(r io.Reader) error {
+	split := s.add.Split
+	rr, err := newRecordReader(split.Delimiter, r)
+	if err != nil {
+		return err
+	}
+	var header []byte
+	headerRecords := split.HeaderRecords
+	var buf bytes.Buffer
+	var datums int64
+	flush := func() error {
+		if datums == 0 {
+			return nil
+		}
+		content := io.MultiReader(bytes.NewReader(rr.Header()), bytes.NewReader(header), &buf)
+		if err := s.uw.Put(s.name(s.next), s.add.Tag, false, content, s.opts...); err != nil {
+			return err
+		}
+		s.next++
+		buf.Reset()
+		datums = 0
+		return nil
+	}
+	for {
+		record, err := rr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return errors.Wrapf(err, "error splitting %v content", split.Delimiter)
+		}
+		if headerRecords > 0 {
+			header = append(header, record...)
+			headerRecords--
+			continue
+		}
+		buf.Write(record)
+		datums++
+		if (split.TargetFileDatums == 0 && split.TargetFileBytes == 0) ||
+			(split.TargetFileDatums > 0 && datums >= split.TargetFileDatums) ||
+			(split.TargetFileBytes > 0 && int64(buf.Len()) >= split.TargetFileBytes) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	// The footer is only known once all of the records are read, so it's
+	// appended to each file afterwards.
+	if footer := rr.Footer(); len(footer) > 0 {
+		for i := s.first; i < s.next; i++ {
+			if err := s.uw.Put(s.name(i), s.add.Tag, true, bytes.NewReader(footer), s.opts...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitUpload splits the raw content of consecutive AddFiles with the same
+// path, tag and split as a whole. The content is split in the background
+// while it's being received.
+type splitUpload struct {
+	s      *splitter
+	pw     *io.PipeWriter
+	done   chan error
+	closed bool
+	err    error
+}
+
+func newSplitUpload(uw *fileset.UnorderedWriter, add *pfs.AddFile, next int64, opts ...fileset.FileOption) *splitUpload {
+	pr, pw := io.Pipe()
+	su := &splitUpload{
+		s: &splitter{
+			uw:    uw,
+			add:   add,
+			opts:  opts,
+			first: next,
+			next:  next,
+		},
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := su.s.split(pr)
+		pr.CloseWithError(err)
+		su.done <- err
+	}()
+	return su
+}
+
+// matches returns true if add continues the content being split.
+func (su *splitUpload) matches(add *pfs.AddFile) bool {
+	return add.Path == su.s.add.Path && add.Tag == su.s.add.Tag && proto.Equal(add.Split, su.s.add.Split)
+}
+
+func (su *splitUpload) write(data []byte) error {
+	if _, err := su.pw.Write(data); err != nil {
+		// Writes only fail once splitting has failed.
+		return su.close()
+	}
+	return nil
+}
+
+// close waits for all of the content to be split, returning the error from
+// splitting it, if any.
+func (su *splitUpload) close() error {
+	return su.closeWithError(nil)
+}
+
+// abort stops splitting, without waiting for the rest of the content.
+func (su *splitUpload) abort() {
+	su.closeWithError(errors.Errorf("split upload aborted"))
+}
+
+func (su *splitUpload) closeWithError(err error) error {
+	if !su.closed {
+		su.pw.CloseWithError(err)
+		su.err = <-su.done
+		su.closed = true
+	}
+	return su.err
+}
+
+// splitIndexes tracks the number of the next file to split records into for
+// each directory written to by a ModifyFile stream.
+type splitIndexes struct {
+	// count returns the number of files that were already in a directory.
+	count   func(dir string) (int64, error)
+	next    map[string]int64
+	deleted []string
+}
+
+func newSplitIndexes(count func(string) (int64, error)) *splitIndexes {
+	return &splitIndexes{
+		count: count,
+		next:  make(map[string]int64),
+	}
+}
+
+func (si *splitIndexes) get(dir string) (int64, error) {
+	dir = cleanPath(dir)
+	if next, ok := si.next[dir]; ok {
+		return next, nil
+	}
+	for _, p := range si.deleted {
+		if dir == p || strings.HasPrefix(dir, strings.TrimSuffix(p, "/")+"/") {
+			return 0, nil
+		}
+	}
+	return si.count(dir)
+}
+
+func (si *splitIndexes) set(dir string, next int64) {
+	si.next[cleanPath(dir)] = next
+}
+
+// delete records that the files in p were deleted, so splitting into it
+// starts from the first file again.
+func (si *splitIndexes) delete(p string) {
+	p = cleanPath(p)
+	si.deleted = append(si.deleted, p)
+	for dir := range si.next {
+		if dir == p || strings.HasPrefix(dir, strings.TrimSuffix(p, "/")+"/") {
+			delete(si.next, dir)
+		}
+	}
+}
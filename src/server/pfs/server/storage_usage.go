@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/lib/pq"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// storageUsage returns the logical and physical size of the head of every
+// branch in repo, or in every repo if it's nil. Branches in repos that the
+// caller can't read are left out. The physical size is computed from the
+// chunks that the heads' files reference, so data shared by branches, or by
+// files within a branch, is only counted once.
+func (d *driver) storageUsage(ctx context.Context, repo *pfs.Repo) (*pfs.StorageUsageResponse, error) {
+	var branchInfos []*pfs.BranchInfo
+	branchInfo := &pfs.BranchInfo{}
+	processFunc := func(string) error {
+		branchInfos = append(branchInfos, proto.Clone(branchInfo).(*pfs.BranchInfo))
+		return nil
+	}
+	if repo != nil {
+		if _, err := d.readRepoInfo(ctx, repo); err != nil {
+			return nil, err
+		}
+		if err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+			return nil, err
+		}
+		if err := d.branches.ReadOnly(ctx).GetByIndex(pfsdb.BranchesRepoIndex, pfsdb.RepoKey(repo), branchInfo, col.DefaultOptions(), processFunc); err != nil {
+			return nil, err
+		}
+	} else if err := d.branches.ReadOnly(ctx).List(branchInfo, col.DefaultOptions(), processFunc); err != nil {
+		return nil, err
+	}
+	resp := &pfs.StorageUsageResponse{}
+	authorized := make(map[string]bool)
+	// branchChunks holds the chunks that each branch references.
+	var branchChunks []map[string]bool
+	allChunks := make(map[string]bool)
+	for _, branchInfo := range branchInfos {
+		if ok, err := d.canReadRepo(ctx, branchInfo.Branch.Repo, authorized); err != nil || !ok {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		usage := &pfs.BranchStorageUsage{Branch: branchInfo.Branch}
+		logical, chunks, err := d.commitDataChunks(ctx, branchInfo.Branch.NewCommit(""))
+		if err != nil {
+			return nil, err
+		}
+		for key := range chunks {
+			allChunks[key] = true
+		}
+		usage.LogicalBytes = logical
+		resp.LogicalBytes += usage.LogicalBytes
+		resp.Branches = append(resp.Branches, usage)
+		branchChunks = append(branchChunks, chunks)
+	}
+	sizes, err := d.chunkSizes(ctx, allChunks)
+	if err != nil {
+		return nil, err
+	}
+	for i, usage := range resp.Branches {
+		for key := range branchChunks[i] {
+			usage.PhysicalBytes += sizes[key]
+			usage.Chunks++
+		}
+	}
+	for key := range allChunks {
+		resp.PhysicalBytes += sizes[key]
+		resp.Chunks++
+	}
+	if resp.PhysicalBytes > 0 {
+		resp.DedupRatio = float64(resp.LogicalBytes) / float64(resp.PhysicalBytes)
+	}
+	return resp, nil
+}
+
+// dedupReport compares the chunks that the files in commits a and b
+// reference, and returns how many of them, and how many bytes, they share and
+// each have on their own.
+func (d *driver) dedupReport(ctx context.Context, a, b *pfs.Commit) (*pfs.CommitDedupReport, error) {
+	aInfo, err := d.inspectCommit(ctx, a, pfs.CommitState_STARTED)
+	if err != nil {
+		return nil, err
+	}
+	bInfo, err := d.inspectCommit(ctx, b, pfs.CommitState_STARTED)
+	if err != nil {
+		return nil, err
+	}
+	report := &pfs.CommitDedupReport{
+		A:      aInfo.Commit,
+		B:      bInfo.Commit,
+		Shared: &pfs.DedupStats{},
+		OnlyA:  &pfs.DedupStats{},
+		OnlyB:  &pfs.DedupStats{},
+	}
+	var aChunks, bChunks map[string]bool
+	if report.ALogicalBytes, aChunks, err = d.commitDataChunks(ctx, aInfo.Commit); err != nil {
+		return nil, err
+	}
+	if report.BLogicalBytes, bChunks, err = d.commitDataChunks(ctx, bInfo.Commit); err != nil {
+		return nil, err
+	}
+	allChunks := make(map[string]bool)
+	for key := range aChunks {
+		allChunks[key] = true
+	}
+	for key := range bChunks {
+		allChunks[key] = true
+	}
+	sizes, err := d.chunkSizes(ctx, allChunks)
+	if err != nil {
+		return nil, err
+	}
+	for key := range allChunks {
+		stats := report.Shared
+		switch {
+		case !bChunks[key]:
+			stats = report.OnlyA
+		case !aChunks[key]:
+			stats = report.OnlyB
+		}
+		stats.Chunks++
+		stats.Bytes += sizes[key]
+	}
+	return report, nil
+}
+
+// commitDataChunks returns the size of the files in commit, and the chunks
+// that their data is in, as chunk IDs as strings. Index chunks are left out.
+func (d *driver) commitDataChunks(ctx context.Context, commit *pfs.Commit) (int64, map[string]bool, error) {
+	_, fs, err := d.openCommit(ctx, commit)
+	if err != nil {
+		return 0, nil, err
+	}
+	var logical int64
+	chunks := make(map[string]bool)
+	if err := fs.Iterate(ctx, func(f fileset.File) error {
+		for _, dataRef := range f.Index().File.DataRefs {
+			logical += dataRef.SizeBytes
+			chunks[string(dataRef.Ref.Id)] = true
+		}
+		return nil
+	}); err != nil {
+		return 0, nil, err
+	}
+	return logical, chunks, nil
+}
+
+// chunkSizes returns the size in object storage of the latest uploaded
+// generation of each of chunks, which are chunk IDs as strings.
+func (d *driver) chunkSizes(ctx context.Context, chunks map[string]bool) (map[string]int64, error) {
+	const batchSize = 1000
+	sizes := make(map[string]int64)
+	ids := make([][]byte, 0, len(chunks))
+	for id := range chunks {
+		ids = append(ids, []byte(id))
+	}
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		ids = ids[len(batch):]
+		var rows []struct {
+			ChunkID []byte `db:"chunk_id"`
+			Size    int64  `db:"size"`
+		}
+		if err := d.env.GetDBClient().SelectContext(ctx, &rows, `
+			SELECT DISTINCT ON (chunk_id) chunk_id, size
+			FROM storage.chunk_objects
+			WHERE chunk_id = ANY($1) AND uploaded AND NOT tombstone
+			ORDER BY chunk_id, gen DESC
+		`, pq.ByteaArray(batch)); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		for _, row := range rows {
+			sizes[string(row.ChunkID)] = row.Size
+		}
+	}
+	return sizes, nil
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// createRepoFromTemplate creates request.Repo laid out like request.Template:
+// it gets the template's description and settings, unless they're set in the
+// request, and the template's branches, with their triggers, and path
+// reservations. None of the template's data is copied, each branch starts
+// with an empty commit.
+func (d *driver) createRepoFromTemplate(txnCtx *txncontext.TransactionContext, request *pfs.CreateRepoRequest) error {
+	if request.Update {
+		return errors.New("a template can only be used to create a new repo")
+	}
+	if request.Mirror != nil {
+		return errors.New("a mirror repo can't be created from a template")
+	}
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, request.Template.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+		return errors.EnsureStack(err)
+	}
+	templateInfo, err := d.inspectRepo(txnCtx, request.Template, !includeAuth)
+	if err != nil {
+		return errors.Wrapf(err, "could not inspect template %q", request.Template)
+	}
+	description, settings := request.Description, request.Settings
+	if description == "" {
+		description = templateInfo.Description
+	}
+	if settings == nil && templateInfo.Settings != nil {
+		settings = proto.Clone(templateInfo.Settings).(*pfs.RepoSettings)
+	}
+	if err := d.createRepo(txnCtx, request.Repo, description, false, nil, settings, request.Labels); err != nil {
+		return err
+	}
+	for _, branch := range templateInfo.Branches {
+		branchInfo, err := d.inspectBranch(txnCtx, branch)
+		if err != nil {
+			return err
+		}
+		var trigger *pfs.Trigger
+		if branchInfo.Trigger != nil {
+			trigger = proto.Clone(branchInfo.Trigger).(*pfs.Trigger)
+		}
+		if err := d.createBranch(txnCtx, request.Repo.NewBranch(branch.Name), nil, nil, trigger, nil, nil); err != nil {
+			return errors.Wrapf(err, "could not create branch %q", branch.Name)
+		}
+	}
+	for _, r := range templateInfo.PathReservations {
+		if err := d.reservePath(txnCtx, request.Repo, r.Prefix, r.Owner); err != nil {
+			return errors.Wrapf(err, "could not reserve %s", r.Prefix)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsload"
 	"github.com/pachyderm/pachyderm/v2/src/internal/require"
 	"github.com/pachyderm/pachyderm/v2/src/internal/testpachd"
 	tu "github.com/pachyderm/pachyderm/v2/src/internal/testutil"
@@ -22,6 +23,23 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestBench(t *testing.T) {
+	env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+	require.NoError(t, env.PachClient.CreateRepo("bench"))
+	spec := pfsload.DefaultBenchSpec()
+	spec.Count = 50
+	spec.Concurrency = 4
+	spec.SizeSpecs = []*pfsload.SizeSpec{{Min: 100, Max: 1000, Prob: 100}}
+	result, err := pfsload.Bench(env.PachClient, "bench", spec, 0)
+	require.NoError(t, err)
+	var count int
+	for _, op := range result.Operations {
+		count += op.Count
+		require.True(t, op.P50 <= op.P90 && op.P90 <= op.P99 && op.P99 <= op.Max)
+	}
+	require.Equal(t, spec.Count, count)
+}
+
 var loads = []string{`
 count: 5
 operations:
@@ -2,12 +2,16 @@ package testing
 
 import (
 	"archive/tar"
+	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -20,6 +24,7 @@ import (
 	"time"
 
 	units "github.com/docker/go-units"
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/types"
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/ancestry"
@@ -30,6 +35,7 @@ import (
 	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
 	"github.com/pachyderm/pachyderm/v2/src/internal/require"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/sql"
 	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
 	"github.com/pachyderm/pachyderm/v2/src/internal/tarutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/testpachd"
@@ -102,6 +108,261 @@ func TestPFS(suite *testing.T) {
 		require.YesError(t, env.PachClient.CreateRepo("lenny#"))
 	})
 
+	suite.Run("Projects", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.YesError(t, env.PachClient.CreateProject("proj/ect"))
+		require.NoError(t, env.PachClient.CreateProject("project"))
+		require.YesError(t, env.PachClient.CreateProject("project"))
+
+		createRepo := func(project, repo string) error {
+			_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+				Repo: client.NewProjectRepo(project, repo),
+			})
+			return err
+		}
+		require.True(t, pfsserver.IsProjectNotFoundErr(createRepo("missing", "repo")))
+		require.NoError(t, createRepo("project", "repo"))
+		require.NoError(t, createRepo("", "repo"))
+
+		// repos with the same name in different projects are distinct
+		commit, err := env.PachClient.StartCommit("repo", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.FinishCommit("repo", "master", commit.ID))
+		projectCommits, err := env.PachClient.ListCommit(client.NewProjectRepo("project", "repo"), nil, nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(projectCommits))
+
+		resp, err := env.PachClient.PfsAPIClient.ListRepo(env.PachClient.Ctx(), &pfs.ListRepoRequest{
+			Type:    pfs.UserRepoType,
+			Project: client.NewProject("project"),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(resp.RepoInfo))
+		require.Equal(t, "project/repo", resp.RepoInfo[0].Repo.String())
+
+		require.YesError(t, env.PachClient.DeleteProject("project", false))
+		require.NoError(t, env.PachClient.DeleteProject("project", true))
+		_, err = env.PachClient.InspectProject("project")
+		require.True(t, pfsserver.IsProjectNotFoundErr(err))
+		_, err = env.PachClient.InspectRepo("repo")
+		require.NoError(t, err)
+	})
+
+	suite.Run("ProjectDefaults", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		defaults := &pfs.ProjectDefaults{
+			RepoSettings:   &pfs.RepoSettings{MaxSizeBytes: 1024},
+			MaxRepos:       1,
+			BranchTriggers: map[string]*pfs.Trigger{"staging": {Branch: "master", Commits: 2}},
+		}
+		_, err := env.PachClient.PfsAPIClient.CreateProject(env.PachClient.Ctx(), &pfs.CreateProjectRequest{
+			Project:  client.NewProject("project"),
+			Defaults: defaults,
+		})
+		require.NoError(t, err)
+
+		repo := client.NewProjectRepo("project", "repo")
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{Repo: repo})
+		require.NoError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo: client.NewProjectRepo("project", "other"),
+		})
+		require.YesError(t, err)
+
+		repoInfo, err := env.PachClient.PfsAPIClient.InspectRepo(env.PachClient.Ctx(), &pfs.InspectRepoRequest{Repo: repo})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1024), repoInfo.Settings.MaxSizeBytes)
+
+		_, err = env.PachClient.PfsAPIClient.CreateBranch(env.PachClient.Ctx(), &pfs.CreateBranchRequest{Branch: repo.NewBranch("master")})
+		require.NoError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateBranch(env.PachClient.Ctx(), &pfs.CreateBranchRequest{Branch: repo.NewBranch("staging")})
+		require.NoError(t, err)
+		branchInfo, err := env.PachClient.PfsAPIClient.InspectBranch(env.PachClient.Ctx(), &pfs.InspectBranchRequest{Branch: repo.NewBranch("staging")})
+		require.NoError(t, err)
+		require.Equal(t, "master", branchInfo.Trigger.Branch)
+		require.Equal(t, int64(2), branchInfo.Trigger.Commits)
+	})
+
+	suite.Run("CreateRepoFromTemplate", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:        client.NewRepo("template"),
+			Description: "standard layout",
+			Settings:    &pfs.RepoSettings{MaxFileSizeBytes: 1024},
+		})
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("template", "master", ""), "file", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.CreateBranchTrigger("template", "staging", "", "", &pfs.Trigger{Branch: "master", Commits: 2}))
+		require.NoError(t, env.PachClient.ReservePath("template", "/ingest/", "robot:ingest"))
+
+		require.NoError(t, env.PachClient.CreateRepoFromTemplate("dataset", "template"))
+		repoInfo, err := env.PachClient.InspectRepo("dataset")
+		require.NoError(t, err)
+		require.Equal(t, "standard layout", repoInfo.Description)
+		require.Equal(t, uint64(1024), repoInfo.Settings.MaxFileSizeBytes)
+		require.Equal(t, 1, len(repoInfo.PathReservations))
+		require.Equal(t, "robot:ingest", repoInfo.PathReservations[0].Owner)
+		branchInfo, err := env.PachClient.InspectBranch("dataset", "staging")
+		require.NoError(t, err)
+		require.Equal(t, "master", branchInfo.Trigger.Branch)
+		require.Equal(t, int64(2), branchInfo.Trigger.Commits)
+
+		// None of the template's data is copied.
+		files, err := env.PachClient.ListFileAll(client.NewCommit("dataset", "master", ""), "/")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(files))
+
+		// Templates can't be used to update repos.
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo("dataset"),
+			Template: client.NewRepo("template"),
+			Update:   true,
+		})
+		require.YesError(t, err)
+		require.YesError(t, env.PachClient.CreateRepoFromTemplate("other", "missing"))
+	})
+
+	suite.Run("MirrorRepoIsReadOnly", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("mirror"),
+			Mirror: &pfs.RepoMirror{Address: "grpc://localhost:1650"},
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("mirror"),
+			Mirror: &pfs.RepoMirror{Address: "grpc://localhost:1650", Source: client.NewRepo("source")},
+		})
+		require.NoError(t, err)
+
+		repoInfo, err := env.PachClient.InspectRepo("mirror")
+		require.NoError(t, err)
+		require.Equal(t, "source", repoInfo.Mirror.Source.Name)
+
+		_, err = env.PachClient.StartCommit("mirror", "master")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoReadOnlyErr(err))
+		require.YesError(t, env.PachClient.PutFile(client.NewCommit("mirror", "master", ""), "file", strings.NewReader("foo")))
+		err = env.PachClient.CreateBranch("mirror", "other", "", "", nil)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoReadOnlyErr(err))
+		err = env.PachClient.DeleteBranch("mirror", "master", false)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoReadOnlyErr(err))
+
+		// A snapshot mirror names only the snapshot's URL and the secret
+		// with the credentials to read it with.
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("snapshot"),
+			Mirror: &pfs.RepoMirror{SnapshotURL: "s3://bucket/snapshot.zip", AuthTokenSecret: "creds"},
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("snapshot"),
+			Mirror: &pfs.RepoMirror{SnapshotURL: "s3://bucket/snapshot.tar", AuthTokenSecret: "creds", Address: "grpc://localhost:1650"},
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("snapshot"),
+			Mirror: &pfs.RepoMirror{SnapshotURL: "s3://bucket/snapshot.tar.gz"},
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("snapshot"),
+			Mirror: &pfs.RepoMirror{SnapshotURL: "local://bucket/snapshot.tar.gz", AuthTokenSecret: "creds"},
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:   client.NewRepo("snapshot"),
+			Mirror: &pfs.RepoMirror{SnapshotURL: "s3://bucket/snapshot.tar.gz", AuthTokenSecret: "creds"},
+		})
+		require.NoError(t, err)
+	})
+
+	suite.Run("MirrorRepoSync", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("source"))
+		commit := client.NewCommit("source", "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(commit, "b", strings.NewReader("bar")))
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo: client.NewRepo("mirror"),
+			Mirror: &pfs.RepoMirror{
+				Address:  "grpc://" + env.MockPachd.Addr.String(),
+				Source:   client.NewRepo("source"),
+				Interval: types.DurationProto(10 * time.Second),
+			},
+		})
+		require.NoError(t, err)
+		mirrored := client.NewCommit("mirror", "master", "")
+		checkFiles := func(expected map[string]string) error {
+			var files []string
+			if err := env.PachClient.ListFile(mirrored, "/", func(fi *pfs.FileInfo) error {
+				files = append(files, fi.File.Path)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if len(files) != len(expected) {
+				return errors.Errorf("expected %d files, got %v", len(expected), files)
+			}
+			for path, content := range expected {
+				var buf bytes.Buffer
+				if err := env.PachClient.GetFile(mirrored, path, &buf); err != nil {
+					return err
+				}
+				if buf.String() != content {
+					return errors.Errorf("expected %s to be %q, got %q", path, content, buf.String())
+				}
+			}
+			return nil
+		}
+		require.NoErrorWithinTRetry(t, time.Minute, func() error {
+			return checkFiles(map[string]string{"a": "foo", "b": "bar"})
+		})
+
+		// Only the changes since the last sync are copied, so files deleted
+		// from the source are deleted from the mirror.
+		require.NoError(t, env.PachClient.DeleteFile(commit, "a"))
+		require.NoError(t, env.PachClient.PutFile(commit, "c", strings.NewReader("baz")))
+		require.NoErrorWithinTRetry(t, time.Minute, func() error {
+			return checkFiles(map[string]string{"b": "bar", "c": "baz"})
+		})
+
+		// An open commit on the source isn't mirrored, and doesn't hold up
+		// the other mirrors.
+		_, err = env.PachClient.StartCommit("source", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "d", strings.NewReader("qux")))
+		require.NoError(t, env.PachClient.CreateRepo("other"))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("other", "master", ""), "e", strings.NewReader("quux")))
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo: client.NewRepo("other-mirror"),
+			Mirror: &pfs.RepoMirror{
+				Address:  "grpc://" + env.MockPachd.Addr.String(),
+				Source:   client.NewRepo("other"),
+				Interval: types.DurationProto(10 * time.Second),
+			},
+		})
+		require.NoError(t, err)
+		require.NoErrorWithinTRetry(t, time.Minute, func() error {
+			var buf bytes.Buffer
+			return env.PachClient.GetFile(client.NewCommit("other-mirror", "master", ""), "e", &buf)
+		})
+		require.NoError(t, checkFiles(map[string]string{"b": "bar", "c": "baz"}))
+	})
+
 	suite.Run("CreateSameRepoInParallel", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -200,6 +461,46 @@ func TestPFS(suite *testing.T) {
 		require.NotNil(t, commitInfo.ParentCommit)
 	})
 
+	suite.Run("BranchStats", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		putFile := func(i int) {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("file%d", i), strings.NewReader("data")))
+			require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		}
+		for i := 0; i < 3; i++ {
+			putFile(i)
+		}
+		branchInfo, err := env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), branchInfo.Stats.Commits)
+		require.NotNil(t, branchInfo.Stats.LastCommit)
+		require.Equal(t, uint64(12), branchInfo.Stats.SizeBytes)
+		require.Nil(t, branchInfo.Stats.Trigger)
+
+		// The trigger is pending until there are two new commits on master
+		// since prod was made.
+		require.NoError(t, env.PachClient.CreateBranchTrigger(repo, "prod", "", "", &pfs.Trigger{Branch: "master", Commits: 2}))
+		branchInfo, err = env.PachClient.InspectBranch(repo, "prod")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), branchInfo.Stats.Commits)
+		require.True(t, branchInfo.Stats.Trigger.Pending)
+		require.Nil(t, branchInfo.Stats.Trigger.LastTriggered)
+
+		putFile(3)
+		branchInfo, err = env.PachClient.InspectBranch(repo, "prod")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), branchInfo.Stats.Commits)
+		require.Equal(t, uint64(16), branchInfo.Stats.SizeBytes)
+		require.False(t, branchInfo.Stats.Trigger.Pending)
+		require.NotNil(t, branchInfo.Stats.Trigger.LastTriggered)
+	})
+
 	suite.Run("ToggleBranchProvenance", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -384,6 +685,127 @@ func TestPFS(suite *testing.T) {
 		require.ElementsEqualUnderFn(t, repoNames, repoInfos, RepoInfoToName)
 	})
 
+	suite.Run("ListRepoFilterAndSort", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("images-b"))
+		require.NoError(t, env.PachClient.CreateRepo("images-a"))
+		time.Sleep(10 * time.Millisecond)
+		since := time.Now()
+		require.NoError(t, env.PachClient.CreateRepo("logs"))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("images-a", "master", ""), "a", strings.NewReader("foobar")))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("logs", "master", ""), "a", strings.NewReader("foo")))
+
+		names := func(opts ...client.ListRepoOption) []string {
+			repoInfos, err := env.PachClient.ListRepoByType(pfs.UserRepoType, opts...)
+			require.NoError(t, err)
+			var names []string
+			for _, repoInfo := range repoInfos {
+				names = append(names, repoInfo.Repo.Name)
+			}
+			return names
+		}
+		require.Equal(t, []string{"logs", "images-a", "images-b"}, names())
+		require.Equal(t, []string{"images-b", "images-a", "logs"}, names(client.WithSortListRepo(pfs.RepoSortBy_BY_CREATION, true)))
+		require.Equal(t, []string{"images-a", "images-b", "logs"}, names(client.WithSortListRepo(pfs.RepoSortBy_BY_NAME, false)))
+		require.Equal(t, []string{"images-a", "logs", "images-b"}, names(client.WithSortListRepo(pfs.RepoSortBy_BY_SIZE, false)))
+		require.Equal(t, []string{"images-a", "images-b"}, names(client.WithNameGlobListRepo("images-*"), client.WithSortListRepo(pfs.RepoSortBy_BY_NAME, false)))
+		require.Equal(t, []string{"logs"}, names(client.WithNameGlobListRepo("logs")))
+		require.Equal(t, []string{"logs"}, names(client.WithCreatedAfterListRepo(since)))
+
+		// The literal prefix of a glob is matched by postgres, where _ is
+		// otherwise a wildcard.
+		require.NoError(t, env.PachClient.CreateRepo("images_c"))
+		require.Equal(t, []string{"images_c"}, names(client.WithNameGlobListRepo("images_*")))
+	})
+
+	suite.Run("RepoLabels", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		createRepo := func(name string, update bool, labels map[string]string) {
+			_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+				Repo:   client.NewRepo(name),
+				Update: update,
+				Labels: labels,
+			})
+			require.NoError(t, err)
+		}
+		createRepo("a", false, map[string]string{"team": "ml-experiments", "tier": "scratch"})
+		createRepo("b", false, map[string]string{"team": "ml-experiments"})
+		createRepo("c", false, map[string]string{"team": "data"})
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo: client.NewSystemRepo("a", pfs.MetaRepoType),
+		})
+		require.NoError(t, err)
+
+		// Updating a repo merges its labels, and empty values remove them.
+		createRepo("a", true, map[string]string{"tier": "", "owner": "alice"})
+		repoInfo, err := env.PachClient.InspectRepo("a")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"team": "ml-experiments", "owner": "alice"}, repoInfo.Labels)
+
+		names := func(labels map[string]string) []string {
+			repoInfos, err := env.PachClient.ListRepoByType(pfs.UserRepoType, client.WithLabelsListRepo(labels), client.WithSortListRepo(pfs.RepoSortBy_BY_NAME, false))
+			require.NoError(t, err)
+			var names []string
+			for _, repoInfo := range repoInfos {
+				names = append(names, repoInfo.Repo.Name)
+			}
+			return names
+		}
+		require.Equal(t, []string{"a", "b"}, names(map[string]string{"team": "ml-experiments"}))
+		require.Equal(t, []string{"a", "b", "c"}, names(map[string]string{"team": ""}))
+		require.Equal(t, []string{"a"}, names(map[string]string{"owner": "alice", "team": ""}))
+
+		require.NoError(t, env.PachClient.DeleteReposByLabels(map[string]string{"team": "ml-experiments"}, false))
+		repoInfos, err := env.PachClient.ListRepoByType("")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(repoInfos))
+		require.Equal(t, "c", repoInfos[0].Repo.Name)
+	})
+
+	suite.Run("DeleteRepos", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		// raw -> clean -> model, and raw -> other
+		for _, repo := range []string{"raw", "clean", "model", "other"} {
+			require.NoError(t, env.PachClient.CreateRepo(repo))
+		}
+		require.NoError(t, env.PachClient.CreateBranch("raw", "master", "", "", nil))
+		require.NoError(t, env.PachClient.CreateBranch("clean", "master", "", "", []*pfs.Branch{client.NewBranch("raw", "master")}))
+		require.NoError(t, env.PachClient.CreateBranch("model", "master", "", "", []*pfs.Branch{client.NewBranch("clean", "master")}))
+		require.NoError(t, env.PachClient.CreateBranch("other", "master", "", "", []*pfs.Branch{client.NewBranch("raw", "master")}))
+
+		names := func(repos []*pfs.Repo) []string {
+			var names []string
+			for _, repo := range repos {
+				names = append(names, repo.Name)
+			}
+			return names
+		}
+		repos := []*pfs.Repo{client.NewRepo("raw"), client.NewRepo("model"), client.NewRepo("clean")}
+		_, err := env.PachClient.DeleteRepos(repos, nil, false, true)
+		require.YesError(t, err)
+		require.True(t, strings.Contains(err.Error(), "raw"))
+		deleted, err := env.PachClient.DeleteRepos(repos[1:], nil, false, true)
+		require.NoError(t, err)
+		require.Equal(t, []string{"model", "clean"}, names(deleted))
+		repoInfos, err := env.PachClient.ListRepo()
+		require.NoError(t, err)
+		require.Equal(t, 4, len(repoInfos))
+
+		deleted, err = env.PachClient.DeleteRepos(repos, nil, true, false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"model", "clean", "raw"}, names(deleted))
+		repoInfos, err = env.PachClient.ListRepo()
+		require.NoError(t, err)
+		require.Equal(t, 1, len(repoInfos))
+		require.Equal(t, "other", repoInfos[0].Repo.Name)
+	})
+
 	// Make sure that artifacts of deleted repos do not resurface
 	suite.Run("CreateDeletedRepo", func(t *testing.T) {
 		t.Parallel()
@@ -735,6 +1157,38 @@ func TestPFS(suite *testing.T) {
 		require.True(t, finished.After(tFinished))
 	})
 
+	suite.Run("InspectCommitDetails", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(commit, "b", strings.NewReader("bar")))
+
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master", commit.ID)
+		require.NoError(t, err)
+		require.Nil(t, commitInfo.Details)
+
+		commitInfo, err = env.PachClient.InspectCommitDetails(repo, "master", commit.ID)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(commitInfo.Details.DiffFilesetIds))
+		require.Equal(t, "", commitInfo.Details.TotalFilesetId)
+		require.True(t, commitInfo.Details.ChunkCount > 0)
+		require.True(t, commitInfo.Details.IndexSizeBytes > 0)
+
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit.ID))
+		_, err = env.PachClient.WaitCommit(repo, "master", commit.ID)
+		require.NoError(t, err)
+		commitInfo, err = env.PachClient.InspectCommitDetails(repo, "master", commit.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, "", commitInfo.Details.TotalFilesetId)
+		require.True(t, commitInfo.Details.Layers > 0)
+		require.True(t, commitInfo.Details.Compacted)
+	})
+
 	suite.Run("InspectCommitWait", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -867,6 +1321,74 @@ func TestPFS(suite *testing.T) {
 		require.Equal(t, 0, int(repoInfo.SizeBytes))
 	})
 
+	suite.Run("CommitLineage", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("in"))
+		require.NoError(t, env.PachClient.CreateRepo("side"))
+		require.NoError(t, env.PachClient.CreateRepo("mid"))
+		require.NoError(t, env.PachClient.CreateRepo("out"))
+		require.NoError(t, env.PachClient.CreateBranch("in", "master", "", "", nil))
+		require.NoError(t, env.PachClient.CreateBranch("side", "master", "", "", nil))
+		require.NoError(t, env.PachClient.CreateBranch("mid", "master", "", "", []*pfs.Branch{client.NewBranch("in", "master"), client.NewBranch("side", "master")}))
+		require.NoError(t, env.PachClient.CreateBranch("out", "master", "", "", []*pfs.Branch{client.NewBranch("mid", "master")}))
+
+		putFile := func(repo string) *pfs.Commit {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("data")))
+			require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit.ID))
+			return commit
+		}
+		inCommit := putFile("in")
+		// The commit on side aliases in's commit in a new commit set, whose
+		// commits in mid and out are also derived from in's commit.
+		sideCommit := putFile("side")
+
+		byRepo := func(lineage []*pfs.LineageCommit) map[string][]*pfs.LineageCommit {
+			result := make(map[string][]*pfs.LineageCommit)
+			for _, lc := range lineage {
+				repo := lc.CommitInfo.Commit.Branch.Repo.Name
+				result[repo] = append(result[repo], lc)
+			}
+			return result
+		}
+
+		resp, err := env.PachClient.CommitLineage("out", "master", inCommit.ID, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(resp.Downstream))
+		upstream := byRepo(resp.Upstream)
+		require.Equal(t, 1, len(upstream["mid"]))
+		require.Equal(t, int64(1), upstream["mid"][0].Depth)
+		require.Equal(t, inCommit.ID, upstream["mid"][0].CommitInfo.Commit.ID)
+		require.Equal(t, 1, len(upstream["in"]))
+		require.Equal(t, int64(2), upstream["in"][0].Depth)
+		require.Equal(t, inCommit.ID, upstream["in"][0].CommitInfo.Commit.ID)
+		require.Equal(t, "mid", upstream["in"][0].Via.Branch.Repo.Name)
+		require.Equal(t, 1, len(upstream["side"]))
+		require.NotEqual(t, pfs.OriginKind_ALIAS, upstream["side"][0].CommitInfo.Origin.Kind)
+
+		resp, err = env.PachClient.CommitLineage("in", "master", inCommit.ID, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(resp.Upstream))
+		downstream := byRepo(resp.Downstream)
+		require.Equal(t, 2, len(downstream["mid"]))
+		require.Equal(t, 2, len(downstream["out"]))
+		ids := make(map[string]int64)
+		for _, lc := range downstream["out"] {
+			ids[lc.CommitInfo.Commit.ID] = lc.Depth
+		}
+		require.Equal(t, map[string]int64{inCommit.ID: 2, sideCommit.ID: 2}, ids)
+
+		resp, err = env.PachClient.CommitLineage("in", "master", inCommit.ID, -1, 1)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(resp.Upstream))
+		downstream = byRepo(resp.Downstream)
+		require.Equal(t, 2, len(downstream["mid"]))
+		require.Equal(t, 0, len(downstream["out"]))
+	})
+
 	suite.Run("BasicFile", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -1666,6 +2188,79 @@ func TestPFS(suite *testing.T) {
 		require.Equal(t, 2*numFiles, len(fis))
 	})
 
+	suite.Run("ListPaged", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		master := client.NewCommit(repo, "master", "")
+		numFiles := 25
+		for i := 0; i < numFiles; i++ {
+			require.NoError(t, env.PachClient.PutFile(master, fmt.Sprintf("dir/%02d", i), strings.NewReader("foo")))
+		}
+		for i := 0; i < 5; i++ {
+			require.NoError(t, env.PachClient.CreateBranch(repo, fmt.Sprintf("branch-%d", i), "master", "", nil))
+		}
+
+		// Files are listed in order, a page at a time.
+		it := env.PachClient.ListFilePaged(master, "dir", 7)
+		var paths []string
+		for it.Next() {
+			paths = append(paths, it.FileInfo().File.Path)
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, numFiles, len(paths))
+		for i, p := range paths {
+			require.Equal(t, fmt.Sprintf("/dir/%02d", i), p)
+		}
+
+		// A page starts after its token, even if that file is gone.
+		var page []string
+		require.NoError(t, env.PachClient.ListFile(master, "dir", func(fi *pfs.FileInfo) error {
+			page = append(page, fi.File.Path)
+			return nil
+		}, func(req *pfs.ListFileRequest) {
+			req.PageSize = 2
+			req.PageToken = "/dir/10.5"
+		}))
+		require.Equal(t, []string{"/dir/11", "/dir/12"}, page)
+
+		commits, err := env.PachClient.ListCommitByRepo(client.NewRepo(repo))
+		require.NoError(t, err)
+		commitIt := env.PachClient.ListCommitPaged(client.NewRepo(repo), 4)
+		var pagedCommits []string
+		for commitIt.Next() {
+			pagedCommits = append(pagedCommits, commitIt.CommitInfo().Commit.ID)
+		}
+		require.NoError(t, commitIt.Err())
+		require.Equal(t, len(commits), len(pagedCommits))
+		for i, ci := range commits {
+			require.Equal(t, ci.Commit.ID, pagedCommits[i])
+		}
+
+		branches, err := env.PachClient.ListBranch(repo)
+		require.NoError(t, err)
+		branchIt := env.PachClient.ListBranchPaged(repo, 2)
+		var pagedBranches []string
+		for branchIt.Next() {
+			pagedBranches = append(pagedBranches, branchIt.BranchInfo().Branch.Name)
+		}
+		require.NoError(t, branchIt.Err())
+		require.Equal(t, len(branches), len(pagedBranches))
+		for i, bi := range branches {
+			require.Equal(t, bi.Branch.Name, pagedBranches[i])
+		}
+
+		// Tokens of unsorted listings that don't exist are errors.
+		_, err = env.PachClient.PfsAPIClient.ListBranch(env.PachClient.Ctx(), &pfs.ListBranchRequest{
+			Repo:      client.NewRepo(repo),
+			PageSize:  2,
+			PageToken: "test@missing",
+		})
+		require.YesError(t, err)
+	})
+
 	suite.Run("ListFile", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -1841,6 +2436,41 @@ func TestPFS(suite *testing.T) {
 		require.Equal(t, 1, len(fileInfos))
 	})
 
+	suite.Run("ClearCommitPaths", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit1, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit1, "staging/batch-1/a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
+
+		commit2, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit2, "staging/batch-1/a", strings.NewReader("bar")))
+		require.NoError(t, env.PachClient.PutFile(commit2, "staging/batch-2/a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(commit2, "staging/batch-2/b", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(commit2, "c", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.DeleteFile(commit2, "staging/batch-1/a"))
+
+		// Clearing batch-1 undoes the delete, and clearing batch-2 drops its files.
+		require.NoError(t, env.PachClient.ClearCommit(repo, commit2.Branch.Name, commit2.ID, "/staging/batch-1/**", "/staging/batch-2"))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.Branch.Name, commit2.ID))
+		var paths []string
+		require.NoError(t, env.PachClient.WalkFile(commit2, "", func(fi *pfs.FileInfo) error {
+			if fi.FileType == pfs.FileType_FILE {
+				paths = append(paths, fi.File.Path)
+			}
+			return nil
+		}))
+		require.ElementsEqual(t, []string{"/c", "/staging/batch-1/a"}, paths)
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit2, "staging/batch-1/a", &buf))
+		require.Equal(t, "foo", buf.String())
+	})
+
 	suite.Run("DeleteFile", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -2143,28 +2773,37 @@ func TestPFS(suite *testing.T) {
 	})
 
 	suite.Run("OffsetRead", func(t *testing.T) {
-		// TODO(2.0 required): Decide on how to expose offset read.
-		t.Skip("Offset read exists (inefficient), just need to decide on how to expose it in V2")
-		//t.Parallel()
-		//env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		//repo := "test"
-		//require.NoError(t, env.PachClient.CreateRepo(repo))
-		//commit, err := env.PachClient.StartCommit(repo, "")
-		//require.NoError(t, err)
-		//fileData := "foo\n"
-		//require.NoError(t, env.PachClient.PutFile(commit, "foo", strings.NewReader(fileData)))
-		//require.NoError(t, env.PachClient.PutFile(commit, "foo", strings.NewReader(fileData)))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		fileData := "foo\n"
+		require.NoError(t, env.PachClient.PutFile(commit, "foo", strings.NewReader(fileData)))
+		require.NoError(t, env.PachClient.PutFile(commit, "foo", strings.NewReader(fileData), client.WithAppendPutFile()))
+
+		check := func() {
+			var buffer bytes.Buffer
+			require.NoError(t, env.PachClient.GetFileRange(commit, "foo", int64(len(fileData)*2)+1, 0, &buffer))
+			require.Equal(t, "", buffer.String())
 
-		//var buffer bytes.Buffer
-		//require.NoError(t, env.PachClient.GetFile(commit, "foo", int64(len(fileData)*2)+1, 0, &buffer))
-		//require.Equal(t, "", buffer.String())
+			buffer.Reset()
+			require.NoError(t, env.PachClient.GetFileRange(commit, "foo", 1, 0, &buffer))
+			require.Equal(t, "oo\nfoo\n", buffer.String())
 
-		//require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+			buffer.Reset()
+			require.NoError(t, env.PachClient.GetFileRange(commit, "foo", 2, 3, &buffer))
+			require.Equal(t, "o\nf", buffer.String())
+		}
+		check()
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		check()
 
-		//buffer.Reset()
-		//require.NoError(t, env.PachClient.GetFile(commit, "foo", int64(len(fileData)*2)+1, 0, &buffer))
-		//require.Equal(t, "", buffer.String())
+		var buffer bytes.Buffer
+		require.YesError(t, env.PachClient.GetFileRange(commit, "foo", -1, 0, &buffer))
+		require.YesError(t, env.PachClient.GetFileRange(commit, "/", 0, 1, &buffer))
 	})
 
 	suite.Run("Branch2", func(t *testing.T) {
@@ -2283,6 +2922,320 @@ func TestPFS(suite *testing.T) {
 		})
 	})
 
+	suite.Run("SubscribeCommitCursor", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		var commits []*pfs.Commit
+		for i := 0; i < 5; i++ {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+			commits = append(commits, commit)
+		}
+
+		// Read the first three commits, then resume from the last cursor.
+		var cursor string
+		var count int
+		require.NoError(t, env.PachClient.SubscribeCommit(client.NewRepo(repo), "master", "", pfs.CommitState_FINISHED, func(ci *pfs.CommitInfo) error {
+			require.Equal(t, commits[count], ci.Commit)
+			require.NotEqual(t, "", ci.Cursor)
+			cursor = ci.Cursor
+			count++
+			if count == 3 {
+				return errutil.ErrBreak
+			}
+			return nil
+		}))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		commits = append(commits, commit)
+		require.NoError(t, env.PachClient.SubscribeCommit(client.NewRepo(repo), "master", "", pfs.CommitState_FINISHED, func(ci *pfs.CommitInfo) error {
+			require.Equal(t, commits[count], ci.Commit)
+			count++
+			if count == len(commits) {
+				return errutil.ErrBreak
+			}
+			return nil
+		}, client.WithCursorSubscribeCommit(cursor)))
+
+		err = env.PachClient.SubscribeCommit(client.NewRepo(repo), "master", "", pfs.CommitState_FINISHED, func(ci *pfs.CommitInfo) error {
+			return nil
+		}, client.WithCursorSubscribeCommit("not a cursor"))
+		require.YesError(t, err)
+	})
+
+	suite.Run("SubscribeCommitFilter", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		put := func(branch, path string) *pfs.Commit {
+			require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, branch, ""), path, strings.NewReader("foo")))
+			commitInfo, err := env.PachClient.InspectCommit(repo, branch, "")
+			require.NoError(t, err)
+			return commitInfo.Commit
+		}
+		put("master", "a")
+		r1 := put("release-1", "config/x")
+		r2 := put("release-2", "data/y")
+		r3 := put("release-1", "config/z")
+
+		subscribe := func(n int, opts ...client.SubscribeCommitOption) []*pfs.Commit {
+			var commits []*pfs.Commit
+			require.NoError(t, env.PachClient.SubscribeCommit(client.NewRepo(repo), "", "", pfs.CommitState_STARTED, func(ci *pfs.CommitInfo) error {
+				commits = append(commits, ci.Commit)
+				if len(commits) == n {
+					return errutil.ErrBreak
+				}
+				return nil
+			}, opts...))
+			return commits
+		}
+		require.Equal(t, []*pfs.Commit{r1, r2, r3}, subscribe(3,
+			client.WithBranchGlobSubscribeCommit("release-*"),
+			client.WithOriginSubscribeCommit(pfs.OriginKind_USER),
+		))
+		require.Equal(t, []*pfs.Commit{r1, r3}, subscribe(2, client.WithPathPrefixSubscribeCommit("/config")))
+
+		err := env.PachClient.SubscribeCommit(client.NewRepo(repo), "master", "", pfs.CommitState_STARTED, func(ci *pfs.CommitInfo) error {
+			return nil
+		}, client.WithBranchGlobSubscribeCommit("release-*"))
+		require.YesError(t, err)
+	})
+
+	suite.Run("SubscribeCommitHeartbeat", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		// The subscription stays idle for several heartbeat intervals before
+		// the commit arrives; heartbeats keep it alive and never reach cb.
+		go func() {
+			time.Sleep(time.Second)
+			_, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+		}()
+		var commits int
+		require.NoError(t, env.PachClient.SubscribeCommit(client.NewRepo(repo), "master", "", pfs.CommitState_STARTED, func(ci *pfs.CommitInfo) error {
+			require.NotNil(t, ci.Commit)
+			commits++
+			return errutil.ErrBreak
+		}, client.WithHeartbeatSubscribeCommit(100*time.Millisecond)))
+		require.Equal(t, 1, commits)
+	})
+
+	suite.Run("SubscribeCommits", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repos := []*pfs.Repo{client.NewRepo("a"), client.NewRepo("b")}
+		for _, repo := range repos {
+			require.NoError(t, env.PachClient.CreateRepo(repo.Name))
+			for i := 0; i < 2; i++ {
+				commit, err := env.PachClient.StartCommit(repo.Name, "master")
+				require.NoError(t, err)
+				require.NoError(t, env.PachClient.FinishCommit(repo.Name, commit.Branch.Name, commit.ID))
+			}
+		}
+
+		// Read every commit once, remembering the first cursor for each repo.
+		cursors := make(map[string]string)
+		seen := make(map[string]int)
+		require.NoError(t, env.PachClient.SubscribeCommits(repos, "master", pfs.CommitState_FINISHED, func(ci *pfs.CommitInfo) error {
+			repo := ci.Commit.Branch.Repo.Name
+			if _, ok := cursors[repo]; !ok {
+				cursors[repo] = ci.Cursor
+			}
+			seen[repo]++
+			if seen["a"]+seen["b"] == 4 {
+				return errutil.ErrBreak
+			}
+			return nil
+		}))
+		require.Equal(t, map[string]int{"a": 2, "b": 2}, seen)
+
+		// Resuming repo a from its first commit replays only its second one,
+		// while repo b, with no cursor, is replayed in full.
+		seen = make(map[string]int)
+		require.NoError(t, env.PachClient.SubscribeCommits(repos, "master", pfs.CommitState_FINISHED, func(ci *pfs.CommitInfo) error {
+			seen[ci.Commit.Branch.Repo.Name]++
+			if seen["a"]+seen["b"] == 3 {
+				return errutil.ErrBreak
+			}
+			return nil
+		}, client.WithCursorsSubscribeCommit(cursors["a"])))
+		require.Equal(t, map[string]int{"a": 1, "b": 2}, seen)
+	})
+
+	suite.Run("WatchBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
+
+		ctx, cancel := context.WithCancel(env.PachClient.Ctx())
+		defer cancel()
+		changes := make(chan *pfs.BranchHeadChange)
+		go func() {
+			env.PachClient.WithCtx(ctx).WatchBranch(repo, "", func(change *pfs.BranchHeadChange) error {
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+				}
+				return nil
+			})
+		}()
+
+		// Only changes made after the watch starts are returned, so keep
+		// committing until one arrives.
+		var change *pfs.BranchHeadChange
+		for change == nil {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+			select {
+			case change = <-changes:
+			case <-time.After(time.Second):
+			}
+		}
+		require.Equal(t, pfs.HeadChangeCause_NEW_COMMIT, change.Cause)
+		require.Equal(t, "master", change.Branch.Name)
+		require.NotNil(t, change.OldHead)
+		require.Equal(t, change.CommitSet.ID, change.NewHead.ID)
+
+		require.NoError(t, env.PachClient.CreateBranch(repo, "other", "master", "", nil))
+		for change.Branch.Name != "other" {
+			select {
+			case change = <-changes:
+			case <-time.After(30 * time.Second):
+				t.Fatal("timed out waiting for the head of other to change")
+			}
+		}
+		require.Equal(t, pfs.HeadChangeCause_SET_HEAD, change.Cause)
+		require.Nil(t, change.OldHead)
+	})
+
+	suite.Run("ChangeFeed", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/a", strings.NewReader("1")))
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/a", strings.NewReader("2")))
+		require.NoError(t, env.PachClient.DeleteFile(commit, "dir/a"))
+
+		var changes []string
+		var cursors []string
+		require.NoError(t, env.PachClient.ChangeFeed(repo, "master", "", func(change *pfs.FileChange) error {
+			if change.Path == "" {
+				require.NotEqual(t, "", change.Cursor)
+				cursors = append(cursors, change.Cursor)
+				if len(changes) == 3 {
+					return errutil.ErrBreak
+				}
+				return nil
+			}
+			changes = append(changes, fmt.Sprintf("%v %s", change.Type, change.Path))
+			return nil
+		}))
+		require.Equal(t, []string{"ADDED /dir/a", "MODIFIED /dir/a", "DELETED /dir/a"}, changes)
+
+		// Resume after the second commit.
+		var change *pfs.FileChange
+		require.NoError(t, env.PachClient.ChangeFeed(repo, "master", cursors[len(cursors)-2], func(fc *pfs.FileChange) error {
+			change = fc
+			return errutil.ErrBreak
+		}))
+		require.Equal(t, pfs.FileChangeType_DELETED, change.Type)
+		require.Equal(t, "/dir/a", change.Path)
+	})
+
+	suite.Run("Watch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateRepo("other"))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("other", "master", ""), "a", strings.NewReader("foo")))
+
+		// The database is new, so "0" replays every event.
+		var events []*pfs.WatchEvent
+		require.NoError(t, env.PachClient.Watch("0", func(ev *pfs.WatchEvent) error {
+			events = append(events, ev)
+			if ev.Commit != nil && ev.Commit.Finished != nil {
+				return errutil.ErrBreak
+			}
+			return nil
+		}, client.NewRepo(repo)))
+		require.Equal(t, pfs.WatchEventType_CREATED, events[0].Type)
+		require.Equal(t, repo, events[0].Repo.Repo.Name)
+		var sawBranch bool
+		for _, ev := range events {
+			switch {
+			case ev.Repo != nil:
+				require.Equal(t, repo, ev.Repo.Repo.Name)
+			case ev.Branch != nil:
+				require.Equal(t, repo, ev.Branch.Branch.Repo.Name)
+				sawBranch = true
+			default:
+				require.Equal(t, repo, ev.Commit.Commit.Branch.Repo.Name)
+			}
+		}
+		require.True(t, sawBranch)
+
+		// Resuming from an event starts right after it.
+		require.NoError(t, env.PachClient.Watch(events[0].Token, func(ev *pfs.WatchEvent) error {
+			require.Equal(t, events[1].Token, ev.Token)
+			return errutil.ErrBreak
+		}, client.NewRepo(repo)))
+
+		token := events[len(events)-1].Token
+		require.NoError(t, env.PachClient.DeleteRepo(repo, false))
+		require.NoError(t, env.PachClient.Watch(token, func(ev *pfs.WatchEvent) error {
+			if ev.Repo != nil && ev.Type == pfs.WatchEventType_REMOVED {
+				return errutil.ErrBreak
+			}
+			return nil
+		}, client.NewRepo(repo)))
+
+		require.YesError(t, env.PachClient.Watch("not a token", func(*pfs.WatchEvent) error { return nil }))
+	})
+
+	suite.Run("WaitCommitTimeout", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+
+		ci, err := env.PachClient.WaitCommitTimeout(repo, "master", commit.ID, time.Second)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsCommitWaitTimeoutErr(err))
+		require.NotNil(t, ci)
+		require.Equal(t, commit.ID, ci.Commit.ID)
+		require.Nil(t, ci.Finished)
+
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit.ID))
+		ci, err = env.PachClient.WaitCommitTimeout(repo, "master", commit.ID, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, ci.Finished)
+	})
+
 	suite.Run("InspectRepoSimple", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
@@ -2469,992 +3422,1144 @@ func TestPFS(suite *testing.T) {
 		require.YesError(t, env.PachClient.PutFile(commit, "foobar*", strings.NewReader("foobar\n")))
 	})
 
-	suite.Run("BigListFile", func(t *testing.T) {
+	suite.Run("ListFileStreamBuffer", func(t *testing.T) {
 		t.Parallel()
-		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t), func(config *serviceenv.Configuration) {
+			config.FileInfoStreamBuffer = 1
+		})
+
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
 		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		var eg errgroup.Group
-		for i := 0; i < 25; i++ {
-			for j := 0; j < 25; j++ {
-				i := i
-				j := j
-				eg.Go(func() error {
-					return env.PachClient.PutFile(commit, fmt.Sprintf("dir%d/file%d", i, j), strings.NewReader("foo\n"))
-				})
-			}
+		var expected []string
+		for i := 0; i < 20; i++ {
+			p := fmt.Sprintf("/dir/%02d", i)
+			require.NoError(t, env.PachClient.PutFile(commit, p, strings.NewReader("foo")))
+			expected = append(expected, p)
 		}
-		require.NoError(t, eg.Wait())
 		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		for i := 0; i < 25; i++ {
-			files, err := env.PachClient.ListFileAll(commit, fmt.Sprintf("dir%d", i))
-			require.NoError(t, err)
-			require.Equal(t, 25, len(files))
-		}
+
+		// A slow reader still sees every file, in order.
+		var listed []string
+		require.NoError(t, env.PachClient.ListFile(commit, "/dir", func(fi *pfs.FileInfo) error {
+			time.Sleep(10 * time.Millisecond)
+			listed = append(listed, fi.File.Path)
+			return nil
+		}))
+		require.Equal(t, expected, listed)
+
+		var walked []string
+		require.NoError(t, env.PachClient.WalkFile(commit, "/dir", func(fi *pfs.FileInfo) error {
+			if fi.FileType == pfs.FileType_FILE {
+				walked = append(walked, fi.File.Path)
+			}
+			return nil
+		}))
+		require.Equal(t, expected, walked)
 	})
 
-	suite.Run("StartCommitLatestOnBranch", func(t *testing.T) {
+	suite.Run("PutFilePathPolicy", func(t *testing.T) {
 		t.Parallel()
-		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t), func(config *serviceenv.Configuration) {
+			config.PathAllowedRanges = "0x20-0x7e,0xa0-0x10ffff"
+			config.PathForbiddenCharacters = ":"
+			config.PathMaxComponentLength = 8
+			config.PathMaxDepth = 3
+		})
 
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
 
-		commit1, err := env.PachClient.StartCommit(repo, "master")
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
 
-		commit2, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, env.PachClient.PutFile(commit, "héllo", strings.NewReader("foobar\n")))
+		require.YesError(t, env.PachClient.PutFile(commit, "foo\x7fbar", strings.NewReader("foobar\n")))
+		require.YesError(t, env.PachClient.PutFile(commit, "foo:bar", strings.NewReader("foobar\n")))
+		require.YesError(t, env.PachClient.PutFile(commit, "foobarbaz", strings.NewReader("foobar\n")))
+		require.NoError(t, env.PachClient.PutFile(commit, "a/b/c", strings.NewReader("foobar\n")))
+		require.YesError(t, env.PachClient.PutFile(commit, "a/b/c/d", strings.NewReader("foobar\n")))
+	})
+
+	suite.Run("UploadStreamLimit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t), func(config *serviceenv.Configuration) {
+			config.UploadMaxStreams = 1
+		})
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
 
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.Branch.Name, commit2.ID))
+		require.NoError(t, env.PachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+			require.NoError(t, mf.PutFile("a", strings.NewReader("foo")))
+			// Once the open stream is being served, other uploads are rejected.
+			require.NoErrorWithinTRetry(t, 10*time.Second, func() error {
+				err := env.PachClient.PutFile(commit, "b", strings.NewReader("bar"))
+				if err == nil || !strings.Contains(err.Error(), "upload streams") {
+					return errors.Errorf("expected an upload limit error, got %v", err)
+				}
+				return nil
+			})
+			return nil
+		}))
+		require.NoError(t, env.PachClient.PutFile(commit, "b", strings.NewReader("bar")))
+	})
 
-		commit3, err := env.PachClient.StartCommit(repo, "master")
+	suite.Run("UploadBytesLimit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t), func(config *serviceenv.Configuration) {
+			config.UploadMaxInFlightBytes = 1000
+		})
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit3.Branch.Name, commit3.ID))
 
-		commitInfo, err := env.PachClient.InspectCommit(repo, "master", "")
-		require.NoError(t, err)
-		require.Equal(t, commit3.ID, commitInfo.Commit.ID)
+		require.NoError(t, env.PachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+			require.NoError(t, mf.PutFile("a", strings.NewReader(strings.Repeat("a", 800))))
+			// The content of the open stream is held until it's written
+			// out, so other uploads are rejected.
+			require.NoErrorWithinTRetry(t, 10*time.Second, func() error {
+				err := env.PachClient.PutFile(commit, "b", strings.NewReader("bar"))
+				if err == nil || !strings.Contains(err.Error(), "in-flight uploads") {
+					return errors.Errorf("expected an upload limit error, got %v", err)
+				}
+				return nil
+			})
+			return nil
+		}))
+		require.NoError(t, env.PachClient.PutFile(commit, "b", strings.NewReader("bar")))
 	})
 
-	suite.Run("CreateBranchTwice", func(t *testing.T) {
+	suite.Run("PutFileMode", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
 
-		commit1, err := env.PachClient.StartCommit(repo, "foo")
+		require.NoError(t, env.PachClient.PutFile(commit, "script.sh", strings.NewReader("#!/bin/sh\n"), client.WithModePutFile(0755)))
+		require.NoError(t, env.PachClient.PutFile(commit, "data", strings.NewReader("foo\n")))
+		fi, err := env.PachClient.InspectFile(commit, "script.sh")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
-		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", commit1.ID, nil))
-
-		commit2, err := env.PachClient.StartCommit(repo, "foo")
+		require.Equal(t, uint32(0755), fi.Mode)
+		fi, err = env.PachClient.InspectFile(commit, "data")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.Branch.Name, commit2.ID))
-		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", commit2.ID, nil))
+		require.Equal(t, uint32(0), fi.Mode)
 
-		branchInfos, err := env.PachClient.ListBranch(repo)
+		// Appending to a file keeps its mode.
+		require.NoError(t, env.PachClient.PutFile(commit, "script.sh", strings.NewReader("echo foo\n"), client.WithAppendPutFile()))
+		fi, err = env.PachClient.InspectFile(commit, "script.sh")
 		require.NoError(t, err)
+		require.Equal(t, uint32(0755), fi.Mode)
 
-		// branches should be returned newest-first
-		require.Equal(t, 2, len(branchInfos))
-		require.Equal(t, "master", branchInfos[0].Branch.Name)
-		require.Equal(t, commit2.ID, branchInfos[0].Head.ID) // aliased branch should have the same commit ID
-		require.Equal(t, "foo", branchInfos[1].Branch.Name)
-		require.Equal(t, commit2.ID, branchInfos[1].Head.ID) // original branch should remain unchanged
+		// Modes are read from and written to tar headers.
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "run.sh", Size: 3, Mode: 0700}))
+		_, err = tw.Write([]byte("foo"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, env.PachClient.PutFileTAR(commit, buf))
+		r, err := env.PachClient.GetFileTar(commit, "run.sh")
+		require.NoError(t, err)
+		hdr, err := tar.NewReader(r).Next()
+		require.NoError(t, err)
+		require.Equal(t, int64(0700), hdr.Mode)
 	})
 
-	suite.Run("WaitCommitSet", func(t *testing.T) {
+	suite.Run("PutFileSymlink", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "run.sh", strings.NewReader("echo foo\n"), client.WithModePutFile(0755)))
+		require.NoError(t, env.PachClient.PutFile(commit, "link", strings.NewReader(""), client.WithSymlinkPutFile("run.sh")))
+		fi, err := env.PachClient.InspectFile(commit, "link")
+		require.NoError(t, err)
+		require.Equal(t, "run.sh", fi.LinkTarget)
+		require.Equal(t, uint64(0), fi.SizeBytes)
+		fi, err = env.PachClient.InspectFile(commit, "run.sh")
+		require.NoError(t, err)
+		require.Equal(t, "", fi.LinkTarget)
+		require.Equal(t, uint32(0755), fi.Mode)
 
-		ACommit, err := env.PachClient.StartCommit("A", "master")
+		// Symlinks can't have content.
+		require.YesError(t, env.PachClient.PutFile(commit, "bad", strings.NewReader("foo"), client.WithSymlinkPutFile("run.sh")))
+
+		// Symlinks are read from and written to tar archives.
+		r, err := env.PachClient.GetFileTar(commit, "link")
 		require.NoError(t, err)
-		BCommit := client.NewCommit("B", "master", ACommit.ID)
-		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		hdr, err := tar.NewReader(r).Next()
+		require.NoError(t, err)
+		require.Equal(t, byte(tar.TypeSymlink), hdr.Typeflag)
+		require.Equal(t, "run.sh", hdr.Linkname)
 
-		commitInfos, err := env.PachClient.WaitCommitSetAll(ACommit.ID)
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "dir/link", Linkname: "../run.sh"}))
+		require.NoError(t, tw.Close())
+		require.NoError(t, env.PachClient.PutFileTAR(commit, buf))
+		fi, err = env.PachClient.InspectFile(commit, "dir/link")
 		require.NoError(t, err)
-		require.Equal(t, 2, len(commitInfos))
-		require.Equal(t, ACommit, commitInfos[0].Commit)
-		require.Equal(t, BCommit, commitInfos[1].Commit)
+		require.Equal(t, "../run.sh", fi.LinkTarget)
+
+		// Overwriting a symlink makes it a regular file.
+		require.NoError(t, env.PachClient.PutFile(commit, "link", strings.NewReader("foo")))
+		fi, err = env.PachClient.InspectFile(commit, "link")
+		require.NoError(t, err)
+		require.Equal(t, "", fi.LinkTarget)
 	})
 
-	// WaitCommitSet2 implements the following DAG:
-	// A ─▶ B ─▶ C ─▶ D
-	suite.Run("WaitCommitSet2", func(t *testing.T) {
+	suite.Run("PutFileMetadata", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-		require.NoError(t, env.PachClient.CreateRepo("D"))
-
-		// Create branches and finish the default head commits on the downstream branches
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("B", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-		require.NoError(t, env.PachClient.CreateBranch("D", "master", "", "", []*pfs.Branch{client.NewBranch("C", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("D", "master", ""))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "data.csv", strings.NewReader("a,b\n"),
+			client.WithMetadataPutFile(map[string]string{"schema": "v1", "content-type": "text/csv"})))
+		require.NoError(t, env.PachClient.PutFile(commit, "data.csv", strings.NewReader("1,2\n"), client.WithAppendPutFile(),
+			client.WithMetadataPutFile(map[string]string{"schema": "v2"})))
+		require.NoError(t, env.PachClient.PutFile(commit, "other", strings.NewReader("foo")))
 
-		ACommit, err := env.PachClient.StartCommit("A", "master")
+		expected := map[string]string{"schema": "v2", "content-type": "text/csv"}
+		fi, err := env.PachClient.InspectFile(commit, "data.csv")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
-
-		// do the other commits in a goro so we can block for them
-		go func() {
-			require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
-			require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-			require.NoError(t, env.PachClient.FinishCommit("D", "master", ""))
-		}()
+		require.Equal(t, expected, fi.Metadata)
+		fis, err := env.PachClient.ListFileAll(commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fis))
+		require.Equal(t, expected, fis[0].Metadata)
+		require.Equal(t, 0, len(fis[1].Metadata))
 
-		// Wait for the commits to finish
-		commitInfos, err := env.PachClient.WaitCommitSetAll(ACommit.ID)
+		// Overwriting a file replaces its metadata.
+		require.NoError(t, env.PachClient.PutFile(commit, "data.csv", strings.NewReader("a,b\n")))
+		fi, err = env.PachClient.InspectFile(commit, "data.csv")
 		require.NoError(t, err)
-		BCommit := client.NewCommit("B", "master", ACommit.ID)
-		CCommit := client.NewCommit("C", "master", ACommit.ID)
-		DCommit := client.NewCommit("D", "master", ACommit.ID)
-		require.Equal(t, 4, len(commitInfos))
-		require.Equal(t, ACommit, commitInfos[0].Commit)
-		require.Equal(t, BCommit, commitInfos[1].Commit)
-		require.Equal(t, CCommit, commitInfos[2].Commit)
-		require.Equal(t, DCommit, commitInfos[3].Commit)
+		require.Equal(t, 0, len(fi.Metadata))
 	})
 
-	// A
-	//  ╲
-	//   ◀
-	//    C
-	//   ◀
-	//  ╱
-	// B
-	suite.Run("WaitCommitSet3", func(t *testing.T) {
+	suite.Run("PutFileModTime", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("B", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
 
-		ACommit, err := env.PachClient.StartCommit("A", "master")
+		before := time.Now()
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("foo\n")))
+		fi, err := env.PachClient.InspectFile(commit, "file")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("A", ACommit.Branch.Name, ACommit.ID))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-		BCommit, err := env.PachClient.StartCommit("B", "master")
+		mtime, err := types.TimestampFromProto(fi.Mtime)
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("B", BCommit.Branch.Name, BCommit.ID))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		require.True(t, !mtime.Before(before.Truncate(time.Second)))
 
-		BCommit, err = env.PachClient.StartCommit("B", "master")
+		// Clients can provide the modification time.
+		provided := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		require.NoError(t, env.PachClient.PutFile(commit, "old", strings.NewReader("foo\n"), client.WithModTimePutFile(provided)))
+		fi, err = env.PachClient.InspectFile(commit, "old")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("B", BCommit.Branch.Name, BCommit.ID))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-
-		// The first two commits will be A and B, but they aren't deterministically sorted
-		commitInfos, err := env.PachClient.WaitCommitSetAll(ACommit.ID)
+		mtime, err = types.TimestampFromProto(fi.Mtime)
 		require.NoError(t, err)
-		require.Equal(t, 3, len(commitInfos))
-		expected := []*pfs.Commit{ACommit, client.NewCommit("B", "master", ACommit.ID)}
-		actual := []*pfs.Commit{commitInfos[0].Commit, commitInfos[1].Commit}
-		require.ImagesEqual(t, expected, actual, CommitToID)
-		require.Equal(t, client.NewCommit("C", "master", ACommit.ID), commitInfos[2].Commit)
+		require.True(t, provided.Equal(mtime))
 
-		commitInfos, err = env.PachClient.WaitCommitSetAll(BCommit.ID)
+		r, err := env.PachClient.GetFileTar(commit, "old")
 		require.NoError(t, err)
-		require.Equal(t, 3, len(commitInfos))
-		expected = []*pfs.Commit{client.NewCommit("A", "master", BCommit.ID), BCommit}
-		actual = []*pfs.Commit{commitInfos[0].Commit, commitInfos[1].Commit}
-		require.ImagesEqual(t, expected, actual, CommitToID)
-		require.Equal(t, client.NewCommit("C", "master", BCommit.ID), commitInfos[2].Commit)
+		hdr, err := tar.NewReader(r).Next()
+		require.NoError(t, err)
+		require.True(t, provided.Equal(hdr.ModTime))
 	})
 
-	suite.Run("WaitCommitSetWithNoDownstreamRepos", func(t *testing.T) {
+	suite.Run("GetFileTarDir", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit, err := env.PachClient.StartCommit(repo, "master")
+		commit := client.NewCommit(repo, "master", "")
+		mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		require.NoError(t, env.PachClient.PutFile(commit, "a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/b", strings.NewReader("bar"), client.WithModePutFile(0700), client.WithModTimePutFile(mtime)))
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/sub/c", strings.NewReader("baz")))
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/sub/d.csv", strings.NewReader("qux")))
+
+		getTar := func(path string) map[string]*tar.Header {
+			r, err := env.PachClient.GetFileTar(commit, path)
+			require.NoError(t, err)
+			tr := tar.NewReader(r)
+			hdrs := make(map[string]*tar.Header)
+			for {
+				hdr, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				hdrs[hdr.Name] = hdr
+			}
+			return hdrs
+		}
+
+		hdrs := getTar("dir")
+		require.Equal(t, 5, len(hdrs))
+		for _, dir := range []string{"/dir/", "/dir/sub/"} {
+			require.Equal(t, byte(tar.TypeDir), hdrs[dir].Typeflag)
+		}
+		b := hdrs["/dir/b"]
+		require.Equal(t, byte(tar.TypeReg), b.Typeflag)
+		require.Equal(t, int64(3), b.Size)
+		require.Equal(t, int64(0700), b.Mode)
+		require.True(t, mtime.Equal(b.ModTime))
+		require.NotNil(t, hdrs["/dir/sub/c"])
+
+		hdrs = getTar("dir/*/*.csv")
+		require.Equal(t, 1, len(hdrs))
+		require.NotNil(t, hdrs["/dir/sub/d.csv"])
+	})
+
+	suite.Run("GetFileZip", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		files := map[string]string{"a": "foo", "dir/b": "bar", "dir/sub/c": "baz"}
+		for p, data := range files {
+			require.NoError(t, env.PachClient.PutFile(commit, p, strings.NewReader(data)))
+		}
+
+		r, err := env.PachClient.GetFileZip(commit, "/")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		commitInfos, err := env.PachClient.WaitCommitSetAll(commit.ID)
+		data, err := ioutil.ReadAll(r)
 		require.NoError(t, err)
-		require.Equal(t, 1, len(commitInfos))
-		require.Equal(t, commit, commitInfos[0].Commit)
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		got := make(map[string]string)
+		for _, f := range zr.File {
+			if strings.HasSuffix(f.Name, "/") {
+				continue
+			}
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			got[f.Name] = string(content)
+		}
+		require.Equal(t, files, got)
 	})
 
-	suite.Run("WaitOpenCommit", func(t *testing.T) {
+	suite.Run("InspectFileDirCounts", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
-		commit, err := env.PachClient.StartCommit("A", "master")
-		require.NoError(t, err)
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		for _, p := range []string{"a", "dir/b", "dir/c", "dir/sub/d"} {
+			require.NoError(t, env.PachClient.PutFile(commit, p, strings.NewReader("foo")))
+		}
 
-		// do the other commits in a goro so we can block for them
-		eg, _ := errgroup.WithContext(context.Background())
-		eg.Go(func() error {
-			time.Sleep(3 * time.Second)
-			if err := env.PachClient.FinishCommit("A", "master", ""); err != nil {
-				return err
-			}
-			return env.PachClient.FinishCommit("B", "master", "")
-		})
+		check := func() {
+			fi, err := env.PachClient.InspectFile(commit, "/")
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), fi.NumChildren)
+			require.Equal(t, uint64(6), fi.NumDescendants)
+			fi, err = env.PachClient.InspectFile(commit, "dir")
+			require.NoError(t, err)
+			require.Equal(t, uint64(3), fi.NumChildren)
+			require.Equal(t, uint64(4), fi.NumDescendants)
+			fi, err = env.PachClient.InspectFile(commit, "a")
+			require.NoError(t, err)
+			require.Equal(t, uint64(0), fi.NumChildren)
+			require.Equal(t, uint64(0), fi.NumDescendants)
+			fis, err := env.PachClient.ListFileAll(commit, "/")
+			require.NoError(t, err)
+			require.Equal(t, 2, len(fis))
+			require.Equal(t, uint64(3), fis[1].NumChildren)
+		}
+		// Counts are the same for open and finished commits.
+		check()
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		check()
+	})
 
-		t.Cleanup(func() {
-			require.NoError(t, eg.Wait())
-		})
+	suite.Run("DirectorySizes", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Wait for the commit to finish
-		commitInfos, err := env.PachClient.WaitCommitSetAll(commit.ID)
-		require.NoError(t, err)
-		require.Equal(t, 2, len(commitInfos))
-		require.Equal(t, commit, commitInfos[0].Commit)
-		require.Equal(t, client.NewCommit("B", "master", commit.ID), commitInfos[1].Commit)
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		for p, data := range map[string]string{
+			"a":         "1",
+			"dir/b":     "22",
+			"dir/c":     "333",
+			"dir/sub/d": "4444",
+			"other/e":   "55555",
+		} {
+			require.NoError(t, env.PachClient.PutFile(commit, p, strings.NewReader(data)))
+		}
+
+		sizes := func(path string, depth int64) map[string]uint64 {
+			result := make(map[string]uint64)
+			require.NoError(t, env.PachClient.DirectorySizes(commit, path, depth, func(fi *pfs.FileInfo) error {
+				result[fi.File.Path] = fi.SizeBytes
+				return nil
+			}))
+			return result
+		}
+		require.Equal(t, map[string]uint64{"/": 15}, sizes("/", 0))
+		require.Equal(t, map[string]uint64{"/": 15, "/dir/": 9, "/other/": 5}, sizes("/", 1))
+		require.Equal(t, map[string]uint64{"/": 15, "/dir/": 9, "/dir/sub/": 4, "/other/": 5}, sizes("/", -1))
+		require.Equal(t, map[string]uint64{"/dir/": 9, "/dir/sub/": 4}, sizes("dir", 1))
+
+		var root *pfs.FileInfo
+		require.NoError(t, env.PachClient.DirectorySizes(commit, "/", 0, func(fi *pfs.FileInfo) error {
+			root = fi
+			return nil
+		}))
+		require.Equal(t, uint64(3), root.NumChildren)
+		require.Equal(t, uint64(8), root.NumDescendants)
+
+		err := env.PachClient.DirectorySizes(commit, "a", 0, func(*pfs.FileInfo) error { return nil })
+		require.YesError(t, err)
+		require.True(t, errutil.IsNotFoundError(err))
 	})
 
-	suite.Run("WaitUninvolvedBranch", func(t *testing.T) {
+	suite.Run("CaseInsensitivePaths", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", nil))
-		commit, err := env.PachClient.StartCommit("A", "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "Data/Images/Cat.JPG", strings.NewReader("cat")))
+		require.NoError(t, env.PachClient.PutFile(commit, "readme", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(commit, "README", strings.NewReader("bar")))
+
+		_, err := env.PachClient.InspectFile(commit, "data/images/cat.jpg")
+		require.YesError(t, err)
+		fi, err := env.PachClient.InspectFile(commit, "data/images/cat.jpg", client.WithCaseInsensitiveInspectFile())
+		require.NoError(t, err)
+		require.Equal(t, "/Data/Images/Cat.JPG", fi.File.Path)
+		fi, err = env.PachClient.InspectFile(commit, "/DATA/", client.WithCaseInsensitiveInspectFile())
 		require.NoError(t, err)
+		require.Equal(t, pfs.FileType_DIR, fi.FileType)
+		buf := &bytes.Buffer{}
+		require.NoError(t, env.PachClient.GetFile(commit, "DATA/images/CAT.jpg", buf, client.WithCaseInsensitiveGetFile()))
+		require.Equal(t, "cat", buf.String())
 
-		// Blocking on a commit that doesn't exist does not work
-		_, err = env.PachClient.WaitCommit("B", "master", commit.ID)
+		// Exact matches win, and otherwise several matches are an error.
+		buf.Reset()
+		require.NoError(t, env.PachClient.GetFile(commit, "README", buf, client.WithCaseInsensitiveGetFile()))
+		require.Equal(t, "bar", buf.String())
+		err = env.PachClient.GetFile(commit, "ReadMe", &bytes.Buffer{}, client.WithCaseInsensitiveGetFile())
 		require.YesError(t, err)
+		require.True(t, pfsserver.IsAmbiguousPathErr(err))
 	})
 
-	suite.Run("WaitNonExistentBranch", func(t *testing.T) {
+	suite.Run("PathReservations", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		commit, err := env.PachClient.StartCommit("A", "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "ingest/source-a/file", strings.NewReader("foo")))
+
+		// Without auth the caller can't be identified, so an owner is required
+		// and nobody can modify reserved paths.
+		require.YesError(t, env.PachClient.ReservePath(repo, "/ingest/source-a/", ""))
+		require.NoError(t, env.PachClient.ReservePath(repo, "/ingest/source-a/", "robot:source-a"))
+		require.YesError(t, env.PachClient.ReservePath(repo, "/ingest/", "robot:source-b"))
+		repoInfo, err := env.PachClient.InspectRepo(repo)
 		require.NoError(t, err)
+		require.Equal(t, 1, len(repoInfo.PathReservations))
+		require.Equal(t, "robot:source-a", repoInfo.PathReservations[0].Owner)
 
-		// Blocking on a branch that doesn't exist does not work
-		_, err = env.PachClient.WaitCommit("A", "foo", commit.ID)
+		err = env.PachClient.PutFile(commit, "ingest/source-a/other", strings.NewReader("bar"))
 		require.YesError(t, err)
-
-		_, err = env.PachClient.WaitCommit("A", "foo", "")
+		require.True(t, pfsserver.IsPathReservedErr(err))
+		err = env.PachClient.DeleteFile(commit, "ingest/")
 		require.YesError(t, err)
+		require.True(t, pfsserver.IsPathReservedErr(err))
+		require.NoError(t, env.PachClient.PutFile(commit, "ingest/source-b/file", strings.NewReader("bar")))
+
+		require.NoError(t, env.PachClient.ReleasePath(repo, "/ingest/source-a"))
+		require.NoError(t, env.PachClient.PutFile(commit, "ingest/source-a/other", strings.NewReader("bar")))
+		require.YesError(t, env.PachClient.ReleasePath(repo, "/ingest/source-a"))
 	})
 
-	suite.Run("EmptyWait", func(t *testing.T) {
+	suite.Run("ExcludeHiddenFiles", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		_, err := env.PachClient.WaitCommitSetAll("")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		for _, p := range []string{"out/part-0", "out/.part-0.crc", "out/_SUCCESS", ".git/config", "data/.cache/a", "data/b"} {
+			require.NoError(t, env.PachClient.PutFile(commit, p, strings.NewReader("foo")))
+		}
+		paths := func(fis []*pfs.FileInfo, err error) []string {
+			require.NoError(t, err)
+			var ps []string
+			for _, fi := range fis {
+				ps = append(ps, fi.File.Path)
+			}
+			return ps
+		}
+
+		require.ElementsEqual(t, []string{"/.git/", "/data/", "/out/"}, paths(env.PachClient.ListFileAll(commit, "/")))
+		require.ElementsEqual(t, []string{"/data/", "/out/"}, paths(env.PachClient.ListFileAll(commit, "/", client.WithExcludeHiddenListFile())))
+		require.ElementsEqual(t, []string{"/out/_SUCCESS", "/out/part-0"}, paths(env.PachClient.ListFileAll(commit, "/out", client.WithExcludeHiddenListFile())))
+		require.ElementsEqual(t, []string{"/out/part-0"}, paths(env.PachClient.ListFileAll(commit, "/out", client.WithExcludeHiddenListFile("_"))))
+
+		require.ElementsEqual(t, []string{"/data/", "/data/b", "/out/", "/out/_SUCCESS", "/out/part-0"}, paths(env.PachClient.GlobFileAll(commit, "**", client.WithExcludeHiddenGlobFile())))
+		// Hidden directories named in the pattern are still searched.
+		require.ElementsEqual(t, []string{"/data/.cache/a"}, paths(env.PachClient.GlobFileAll(commit, "/data/.cache/*", client.WithExcludeHiddenGlobFile())))
+	})
+
+	suite.Run("RepoFileLimits", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo: client.NewRepo(repo),
+			Settings: &pfs.RepoSettings{
+				MaxFileSizeBytes:    4,
+				MaxFilesPerCommit:   3,
+				MaxDirectoryEntries: 2,
+			},
+		})
+		require.NoError(t, err)
+		commit := client.NewCommit(repo, "master", "")
+
+		require.NoError(t, env.PachClient.PutFile(commit, "a", strings.NewReader("foo")))
+		err = env.PachClient.PutFile(commit, "b", strings.NewReader("foobar"))
 		require.YesError(t, err)
+		require.True(t, pfsserver.IsFileTooLargeErr(err))
+		// Appends count towards the limit.
+		err = env.PachClient.PutFile(commit, "a", strings.NewReader("foo"), client.WithAppendPutFile())
+		require.True(t, pfsserver.IsFileTooLargeErr(err))
+
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/a", strings.NewReader("foo")))
+		err = env.PachClient.PutFile(commit, "c", strings.NewReader("foo"))
+		require.True(t, pfsserver.IsTooManyDirectoryEntriesErr(err))
+
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/sub/c", strings.NewReader("foo")))
+		err = env.PachClient.PutFile(commit, "dir/sub/d", strings.NewReader("foo"))
+		require.True(t, pfsserver.IsTooManyFilesErr(err))
+
+		// Failed modifications aren't applied.
+		files, err := env.PachClient.ListFileAll(commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(files))
 	})
 
-	suite.Run("WaitNonExistentCommitSet", func(t *testing.T) {
+	suite.Run("RepoSizeLimit", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		_, err := env.PachClient.WaitCommitSetAll("fake-commitset")
+		repo := "test"
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo(repo),
+			Settings: &pfs.RepoSettings{MaxSizeBytes: 8},
+		})
+		require.NoError(t, err)
+
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit.ID))
+
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), repoInfo.SizeBytes)
+		require.Equal(t, uint64(8), repoInfo.Settings.MaxSizeBytes)
+
+		// The limit is only checked when the commit is finished, so the commit
+		// can go over it in the meantime.
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "b", strings.NewReader("foobar")))
+		err = env.PachClient.FinishCommit(repo, "master", commit.ID)
 		require.YesError(t, err)
-		require.True(t, pfsserver.IsCommitSetNotFoundErr(err))
+		require.True(t, pfsserver.IsRepoTooLargeErr(err))
+		// Commits finished in a transaction are checked too.
+		_, err = env.PachClient.ExecuteInTransaction(func(c *client.APIClient) error {
+			return c.FinishCommit(repo, "master", commit.ID)
+		})
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoTooLargeErr(err))
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master", commit.ID)
+		require.NoError(t, err)
+		require.Nil(t, commitInfo.Finished)
+		require.NoError(t, env.PachClient.DeleteFile(commit, "a"))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit.ID))
+
+		// Modifications without an open commit are finished right away.
+		err = env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "c", strings.NewReader("foo"))
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoTooLargeErr(err))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "c", strings.NewReader("f")))
 	})
 
-	suite.Run("PutFileSplit", func(t *testing.T) {
-		// TODO(2.0 optional): Implement put file split.
-		t.Skip("Put file split not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	if testing.Short() {
-		//		t.Skip("Skipping integration tests in short mode")
-		//	}
-		//
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//	commit, err := env.PachClient.StartCommit(repo, "master")
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "none", pfs.Delimiter_NONE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "line2", pfs.Delimiter_LINE, 2, 0, 0, false, strings.NewReader("foo\nbar\nbuz\nfiz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "line3", pfs.Delimiter_LINE, 0, 8, 0, false, strings.NewReader("foo\nbar\nbuz\nfiz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 0, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "json", pfs.Delimiter_JSON, 0, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "json2", pfs.Delimiter_JSON, 2, 0, 0, false, strings.NewReader("{}{}{}{}"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "json3", pfs.Delimiter_JSON, 0, 4, 0, false, strings.NewReader("{}{}{}{}"))
-		//	require.NoError(t, err)
-		//
-		//	files, err := env.PachClient.ListFileAll(repo, commit.ID, "line2")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 2, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(8), fileInfo.SizeBytes)
-		//	}
-		//
-		//	require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
-		//	commit2, err := env.PachClient.StartCommit(repo, "master")
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit2.ID, "line", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, commit2.ID, "json", pfs.Delimiter_JSON, 0, 0, 0, false, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"))
-		//	require.NoError(t, err)
-		//
-		//	files, err = env.PachClient.ListFileAll(repo, commit2.ID, "line")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 9, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-		//	}
-		//
-		//	require.NoError(t, env.PachClient.FinishCommit(repo, commit2.ID))
-		//	fileInfo, err := env.PachClient.InspectFile(repo, commit.ID, "none")
-		//	require.NoError(t, err)
-		//	require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
-		//	files, err = env.PachClient.ListFileAll(repo, commit.ID, "line")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 6, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit2.ID, "line")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 9, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit.ID, "line2")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 2, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(8), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit.ID, "line3")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 2, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(8), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit.ID, "json")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 20, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(2), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit2.ID, "json")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 30, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(2), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit.ID, "json2")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 2, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-		//	}
-		//	files, err = env.PachClient.ListFileAll(repo, commit.ID, "json3")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 2, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-		//	}
-	})
+	suite.Run("FinishCommitHook", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-	suite.Run("PutFileSplitBig", func(t *testing.T) {
-		// TODO(2.0 optional): Implement put file split.
-		t.Skip("Put file split not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	if testing.Short() {
-		//		t.Skip("Skipping integration tests in short mode")
-		//	}
-		//
-		//	// create repos
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//	commit, err := env.PachClient.StartCommit(repo, "master")
-		//	require.NoError(t, err)
-		//	w, err := env.PachClient.PutFileSplitWriter(repo, commit.ID, "line", pfs.Delimiter_LINE, 0, 0, 0, false)
-		//	require.NoError(t, err)
-		//	for i := 0; i < 1000; i++ {
-		//		_, err = w.Write([]byte("foo\n"))
-		//		require.NoError(t, err)
-		//	}
-		//	require.NoError(t, w.Close())
-		//	require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
-		//	files, err := env.PachClient.ListFileAll(repo, commit.ID, "line")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 1000, len(files))
-		//	for _, fileInfo := range files {
-		//		require.Equal(t, uint64(4), fileInfo.SizeBytes)
-		//	}
-	})
+		// The hook rejects commits that add files outside of /data.
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := &pfs.FinishCommitHookRequest{}
+			if err := jsonpb.Unmarshal(r.Body, request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, change := range request.Changes {
+				if !strings.HasPrefix(change.Path, "/data/") {
+					http.Error(w, fmt.Sprintf("%v is outside of /data", change.Path), http.StatusForbidden)
+					return
+				}
+			}
+		}))
+		defer hook.Close()
 
-	suite.Run("PutFileSplitCSV", func(t *testing.T) {
-		// TODO(2.0 optional): Implement put file split.
-		t.Skip("Put file split not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	// create repos
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//	_, err := env.PachClient.PutFileSplit(repo, "master", "data", pfs.Delimiter_CSV, 0, 0, 0, false,
-		//		// Weird, but this is actually two lines ("is\na" is quoted, so one cell)
-		//		strings.NewReader("this,is,a,test\n"+
-		//			"\"\"\"this\"\"\",\"is\nonly\",\"a,test\"\n"))
-		//	require.NoError(t, err)
-		//	fileInfos, err := env.PachClient.ListFileAll(repo, "master", "/data")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 2, len(fileInfos))
-		//	var contents bytes.Buffer
-		//	env.PachClient.GetFile(repo, "master", "/data/0000000000000000", &contents)
-		//	require.Equal(t, "this,is,a,test\n", contents.String())
-		//	contents.Reset()
-		//	env.PachClient.GetFile(repo, "master", "/data/0000000000000001", &contents)
-		//	require.Equal(t, "\"\"\"this\"\"\",\"is\nonly\",\"a,test\"\n", contents.String())
-	})
+		repo := "test"
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo(repo),
+			Settings: &pfs.RepoSettings{FinishCommitHook: hook.URL},
+		})
+		require.NoError(t, err)
 
-	suite.Run("PutFileSplitSQL", func(t *testing.T) {
-		// TODO(2.0 optional): Implement put file split.
-		t.Skip("Put file split not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	// create repos
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//
-		//	_, err := env.PachClient.PutFileSplit(repo, "master", "/sql", pfs.Delimiter_SQL, 0, 0, 0,
-		//		false, strings.NewReader(tu.TestPGDump))
-		//	require.NoError(t, err)
-		//	fileInfos, err := env.PachClient.ListFileAll(repo, "master", "/sql")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 5, len(fileInfos))
-		//
-		//	// Get one of the SQL records & validate it
-		//	var contents bytes.Buffer
-		//	env.PachClient.GetFile(repo, "master", "/sql/0000000000000000", &contents)
-		//	// Validate that the recieved pgdump file creates the cars table
-		//	require.Matches(t, "CREATE TABLE public\\.cars", contents.String())
-		//	// Validate the SQL header more generally by passing the output of GetFile
-		//	// back through the SQL library & confirm that it parses correctly but only
-		//	// has one row
-		//	pgReader := sql.NewPGDumpReader(bufio.NewReader(bytes.NewReader(contents.Bytes())))
-		//	record, err := pgReader.ReadRow()
-		//	require.NoError(t, err)
-		//	require.Equal(t, "Tesla\tRoadster\t2008\tliterally a rocket\n", string(record))
-		//	_, err = pgReader.ReadRow()
-		//	require.YesError(t, err)
-		//	require.True(t, errors.Is(err, io.EOF))
-		//
-		//	// Create a new commit that overwrites all existing data & puts it back with
-		//	// --header-records=1
-		//	commit, err := env.PachClient.StartCommit(repo, "master")
-		//	require.NoError(t, err)
-		//	require.NoError(t, env.PachClient.DeleteFile(repo, commit.ID, "/sql"))
-		//	_, err = env.PachClient.PutFileSplit(repo, commit.ID, "/sql", pfs.Delimiter_SQL, 0, 0, 1,
-		//		false, strings.NewReader(tu.TestPGDump))
-		//	require.NoError(t, err)
-		//	require.NoError(t, env.PachClient.FinishCommit(repo, commit.ID))
-		//	fileInfos, err = env.PachClient.ListFileAll(repo, "master", "/sql")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 4, len(fileInfos))
-		//
-		//	// Get one of the SQL records & validate it
-		//	contents.Reset()
-		//	env.PachClient.GetFile(repo, "master", "/sql/0000000000000003", &contents)
-		//	// Validate a that the recieved pgdump file creates the cars table
-		//	require.Matches(t, "CREATE TABLE public\\.cars", contents.String())
-		//	// Validate the SQL header more generally by passing the output of GetFile
-		//	// back through the SQL library & confirm that it parses correctly but only
-		//	// has one row
-		//	pgReader = sql.NewPGDumpReader(bufio.NewReader(strings.NewReader(contents.String())))
-		//	record, err = pgReader.ReadRow()
-		//	require.NoError(t, err)
-		//	require.Equal(t, "Tesla\tRoadster\t2008\tliterally a rocket\n", string(record))
-		//	record, err = pgReader.ReadRow()
-		//	require.NoError(t, err)
-		//	require.Equal(t, "Toyota\tCorolla\t2005\tgreatest car ever made\n", string(record))
-		//	_, err = pgReader.ReadRow()
-		//	require.YesError(t, err)
-		//	require.True(t, errors.Is(err, io.EOF))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "data/a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+
+		commit, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "b", strings.NewReader("foo")))
+		err = env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsCommitRejectedErr(err))
+		require.Matches(t, "/b is outside of /data", err.Error())
+
+		// Commits finished in a batch are checked too.
+		_, err = env.PachClient.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+			return builder.FinishCommit(repo, commit.Branch.Name, commit.ID)
+		})
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsCommitRejectedErr(err))
+
+		// The rejected commit stays open and can be fixed.
+		require.NoError(t, env.PachClient.DeleteFile(commit, "b"))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
 	})
 
-	suite.Run("DiffFile", func(t *testing.T) {
+	suite.Run("BranchStoragePolicy", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-
-		// Write foo
-		c1, err := env.PachClient.StartCommit(repo, "master")
+		policy := &pfs.BranchStoragePolicy{StorageClass: pfs.StorageClass_COLD, EagerGc: true}
+		require.NoError(t, env.PachClient.CreateBranchStoragePolicy(repo, "scratch", policy))
+		bi, err := env.PachClient.InspectBranch(repo, "scratch")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(c1, "foo", strings.NewReader("foo\n"), client.WithAppendPutFile()))
-		checks := func() {
-			newFis, oldFis, err := env.PachClient.DiffFileAll(c1, "", nil, "", false)
-			require.NoError(t, err)
-			require.Equal(t, 0, len(oldFis))
-			require.Equal(t, 2, len(newFis))
-			require.Equal(t, "/foo", newFis[1].File.Path)
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, c1.Branch.Name, c1.ID))
-		checks()
+		require.Equal(t, policy.StorageClass, bi.StoragePolicy.StorageClass)
+		require.Equal(t, policy.EagerGc, bi.StoragePolicy.EagerGc)
 
-		// Change the value of foo
-		c2, err := env.PachClient.StartCommit(repo, "master")
+		// Updating the branch without a policy keeps the existing one.
+		require.NoError(t, env.PachClient.CreateBranch(repo, "scratch", "", "", nil))
+		bi, err = env.PachClient.InspectBranch(repo, "scratch")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.DeleteFile(c2, "/foo"))
-		require.NoError(t, env.PachClient.PutFile(c2, "foo", strings.NewReader("not foo\n"), client.WithAppendPutFile()))
-		checks = func() {
-			newFis, oldFis, err := env.PachClient.DiffFileAll(c2, "", nil, "", false)
-			require.NoError(t, err)
-			require.Equal(t, 2, len(oldFis))
-			require.Equal(t, "/foo", oldFis[1].File.Path)
-			require.Equal(t, 2, len(newFis))
-			require.Equal(t, "/foo", newFis[1].File.Path)
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, c2.Branch.Name, c2.ID))
-		checks()
+		require.Equal(t, policy.StorageClass, bi.StoragePolicy.StorageClass)
+		require.Equal(t, policy.EagerGc, bi.StoragePolicy.EagerGc)
 
-		// Write bar
-		c3, err := env.PachClient.StartCommit(repo, "master")
+		// Commits on an eagerly garbage collected branch can be squashed.
+		commit, err := env.PachClient.StartCommit(repo, "scratch")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(c3, "/bar", strings.NewReader("bar\n"), client.WithAppendPutFile()))
-		checks = func() {
-			newFis, oldFis, err := env.PachClient.DiffFileAll(c3, "", nil, "", false)
-			require.NoError(t, err)
-			require.Equal(t, 1, len(oldFis))
-			require.Equal(t, 2, len(newFis))
-			require.Equal(t, "/bar", newFis[1].File.Path)
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, c3.Branch.Name, c3.ID))
-		checks()
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "scratch", commit.ID))
+		require.NoError(t, env.PachClient.SquashCommitSet(commit.ID))
+		_, err = env.PachClient.InspectCommit(repo, "scratch", commit.ID)
+		require.YesError(t, err)
+	})
 
-		// Delete bar
-		c4, err := env.PachClient.StartCommit(repo, "master")
+	suite.Run("MergeBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		master := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(master, "a", strings.NewReader("a\n")))
+		require.NoError(t, env.PachClient.PutFile(master, "b", strings.NewReader("b\n")))
+		require.NoError(t, env.PachClient.PutFile(master, "c", strings.NewReader("c\n")))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "feature", "master", "", nil))
+
+		feature := client.NewCommit(repo, "feature", "")
+		require.NoError(t, env.PachClient.PutFile(feature, "a", strings.NewReader("feature\n")))
+		require.NoError(t, env.PachClient.DeleteFile(feature, "b"))
+		require.NoError(t, env.PachClient.PutFile(feature, "d", strings.NewReader("d\n")))
+		require.NoError(t, env.PachClient.PutFile(master, "c", strings.NewReader("master\n")))
+
+		resp, err := env.PachClient.MergeBranch(repo, "feature", "master", "merge feature")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.DeleteFile(c4, "/bar"))
-		checks = func() {
-			newFis, oldFis, err := env.PachClient.DiffFileAll(c4, "", nil, "", false)
-			require.NoError(t, err)
-			require.Equal(t, 2, len(oldFis))
-			require.Equal(t, "/bar", oldFis[1].File.Path)
-			require.Equal(t, 1, len(newFis))
+		require.Equal(t, 0, len(resp.Conflicts))
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master", "")
+		require.NoError(t, err)
+		require.Equal(t, resp.Commit.ID, commitInfo.Commit.ID)
+		featureInfo, err := env.PachClient.InspectCommit(repo, "feature", "")
+		require.NoError(t, err)
+		require.Equal(t, featureInfo.Commit.ID, commitInfo.MergeParent.ID)
+		for p, content := range map[string]string{"a": "feature\n", "c": "master\n", "d": "d\n"} {
+			var buf bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(master, p, &buf))
+			require.Equal(t, content, buf.String())
 		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, c4.Branch.Name, c4.ID))
-		checks()
+		_, err = env.PachClient.InspectFile(master, "b")
+		require.YesError(t, err)
 
-		// Write dir/fizz and dir/buzz
-		c5, err := env.PachClient.StartCommit(repo, "master")
+		// Merging again has nothing to merge.
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", "")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(c5, "/dir/fizz", strings.NewReader("fizz\n"), client.WithAppendPutFile()))
-		require.NoError(t, env.PachClient.PutFile(c5, "/dir/buzz", strings.NewReader("buzz\n"), client.WithAppendPutFile()))
-		checks = func() {
-			newFis, oldFis, err := env.PachClient.DiffFileAll(c5, "", nil, "", false)
-			require.NoError(t, err)
-			require.Equal(t, 1, len(oldFis))
-			require.Equal(t, 4, len(newFis))
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, c5.Branch.Name, c5.ID))
-		checks()
+		require.Equal(t, commitInfo.Commit.ID, resp.Commit.ID)
 
-		// Modify dir/fizz
-		c6, err := env.PachClient.StartCommit(repo, "master")
+		// Files changed differently on both branches since the merge conflict.
+		require.NoError(t, env.PachClient.PutFile(feature, "c", strings.NewReader("feature\n")))
+		require.NoError(t, env.PachClient.PutFile(master, "c", strings.NewReader("master again\n")))
+		resp, err = env.PachClient.MergeBranch(repo, "feature", "master", "")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(c6, "/dir/fizz", strings.NewReader("fizz\n"), client.WithAppendPutFile()))
-		checks = func() {
-			newFis, oldFis, err := env.PachClient.DiffFileAll(c6, "", nil, "", false)
-			require.NoError(t, err)
-			require.Equal(t, 3, len(oldFis))
-			require.Equal(t, "/dir/fizz", oldFis[2].File.Path)
-			require.Equal(t, 3, len(newFis))
-			require.Equal(t, "/dir/fizz", newFis[2].File.Path)
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, c6.Branch.Name, c6.ID))
-		checks()
+		require.Nil(t, resp.Commit)
+		require.Equal(t, 1, len(resp.Conflicts))
+		require.Equal(t, "/c", resp.Conflicts[0].Path)
 	})
 
-	suite.Run("GlobFile", func(t *testing.T) {
+	suite.Run("CherryPickCommit", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		if testing.Short() {
-			t.Skip("Skipping integration tests in short mode")
-		}
-
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit := client.NewCommit(repo, "master", "")
+		master := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(master, "a", strings.NewReader("a\n")))
+		require.NoError(t, env.PachClient.PutFile(master, "b", strings.NewReader("b\n")))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "release", "master", "", nil))
+		require.NoError(t, env.PachClient.PutFile(master, "c", strings.NewReader("c\n")))
 
-		// Write foo
-		numFiles := 100
-		_, err := env.PachClient.StartCommit(repo, "master")
+		// The hotfix changes a, deletes b and adds d in one commit.
+		hotfix, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		for i := 0; i < numFiles; i++ {
-			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("file%d", i), strings.NewReader("1")))
-			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("dir1/file%d", i), strings.NewReader("2")))
-			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("dir2/dir3/file%d", i), strings.NewReader("3")))
-		}
-		checks := func() {
-			fileInfos, err := env.PachClient.GlobFileAll(commit, "*")
-			require.NoError(t, err)
-			require.Equal(t, numFiles+2, len(fileInfos))
-			fileInfos, err = env.PachClient.GlobFileAll(commit, "file*")
-			require.NoError(t, err)
-			require.Equal(t, numFiles, len(fileInfos))
-			fileInfos, err = env.PachClient.GlobFileAll(commit, "dir1/*")
-			require.NoError(t, err)
-			require.Equal(t, numFiles, len(fileInfos))
-			fileInfos, err = env.PachClient.GlobFileAll(commit, "dir2/dir3/*")
-			require.NoError(t, err)
-			require.Equal(t, numFiles, len(fileInfos))
-			fileInfos, err = env.PachClient.GlobFileAll(commit, "*/*")
-			require.NoError(t, err)
-			require.Equal(t, numFiles+1, len(fileInfos))
+		require.NoError(t, env.PachClient.PutFile(hotfix, "a", strings.NewReader("fixed\n")))
+		require.NoError(t, env.PachClient.DeleteFile(hotfix, "b"))
+		require.NoError(t, env.PachClient.PutFile(hotfix, "d", strings.NewReader("d\n")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", hotfix.ID, client.WithDescriptionFinishCommit("hotfix")))
 
-			var output strings.Builder
-			err = env.PachClient.GetFile(commit, "*", &output)
-			require.NoError(t, err)
-			require.Equal(t, numFiles*3, len(output.String()))
+		commit, err := env.PachClient.CherryPickCommit(repo, "master", hotfix.ID, "release", "")
+		require.NoError(t, err)
+		commitInfo, err := env.PachClient.InspectCommit(repo, "release", "")
+		require.NoError(t, err)
+		require.Equal(t, commit.ID, commitInfo.Commit.ID)
+		require.Equal(t, "hotfix", commitInfo.Description)
 
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "dir2/dir3/file1?", &output)
-			require.NoError(t, err)
-			require.Equal(t, 10, len(output.String()))
+		release := client.NewCommit(repo, "release", "")
+		var paths []string
+		require.NoError(t, env.PachClient.ListFile(release, "/", func(fi *pfs.FileInfo) error {
+			paths = append(paths, fi.File.Path)
+			return nil
+		}))
+		// c was added before the hotfix, so it isn't picked.
+		require.Equal(t, []string{"/a", "/d"}, paths)
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(release, "a", &buf))
+		require.Equal(t, "fixed\n", buf.String())
+	})
 
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "**file1?", &output)
-			require.NoError(t, err)
-			require.Equal(t, 30, len(output.String()))
+	suite.Run("RepoLock", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "**file1", &output)
-			require.NoError(t, err)
-			require.True(t, strings.Contains(output.String(), "1"))
-			require.True(t, strings.Contains(output.String(), "2"))
-			require.True(t, strings.Contains(output.String(), "3"))
-
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "**file1", &output)
-			require.NoError(t, err)
-			match, err := regexp.Match("[123]", []byte(output.String()))
-			require.NoError(t, err)
-			require.True(t, match)
-
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "dir?", &output)
-			require.NoError(t, err)
+		repo := "test"
+		until, err := types.TimestampProto(time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		lock := &pfs.RepoLock{Until: until, Reason: "litigation"}
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo(repo),
+			Settings: &pfs.RepoSettings{Lock: lock},
+		})
+		require.NoError(t, err)
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "a", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit.ID))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "b", strings.NewReader("bar")))
 
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "", &output)
-			require.NoError(t, err)
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master", commit.ID)
+		require.NoError(t, err)
+		require.True(t, commitInfo.Locked)
+		repoInfo, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.Equal(t, "litigation", repoInfo.Settings.Lock.Reason)
 
-			output = strings.Builder{}
-			err = env.PachClient.GetFile(commit, "garbage", &output)
-			require.YesError(t, err)
+		// Finished commits, branches and the repo can't be deleted.
+		err = env.PachClient.SquashCommitSet(commit.ID)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoLockedErr(err))
+		err = env.PachClient.DeleteBranch(repo, "master", true)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoLockedErr(err))
+		err = env.PachClient.DeleteRepo(repo, true)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoLockedErr(err))
+
+		// The lock can be extended, but not removed or shortened.
+		update := func(lock *pfs.RepoLock) error {
+			_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+				Repo:     client.NewRepo(repo),
+				Update:   true,
+				Settings: &pfs.RepoSettings{Lock: lock},
+			})
+			return err
 		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
-		checks()
+		err = update(nil)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoLockedErr(err))
+		err = update(&pfs.RepoLock{Until: types.TimestampNow()})
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsRepoLockedErr(err))
+		until.Seconds += 3600
+		require.NoError(t, update(&pfs.RepoLock{Until: until}))
 
-		_, err = env.PachClient.StartCommit(repo, "master")
+		// A lock can't be marked broken by updating it, only by breaking it,
+		// which records why and lets the repo be deleted again.
+		err = update(&pfs.RepoLock{Until: until, BrokenAt: types.TimestampNow()})
+		require.YesError(t, err)
+		require.Matches(t, "can only be broken", err.Error())
+		require.YesError(t, env.PachClient.BreakRepoLock("nonexistent", "settled"))
+		require.NoError(t, env.PachClient.BreakRepoLock(repo, "settled"))
+		require.YesError(t, env.PachClient.BreakRepoLock(repo, "settled"))
+		repoInfo, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		require.NotNil(t, repoInfo.Settings.Lock.BrokenAt)
+		require.Equal(t, "settled", repoInfo.Settings.Lock.BreakReason)
+		commitInfo, err = env.PachClient.InspectCommit(repo, "master", commit.ID)
 		require.NoError(t, err)
+		require.False(t, commitInfo.Locked)
+		require.NoError(t, env.PachClient.DeleteRepo(repo, false))
 
-		err = env.PachClient.DeleteFile(commit, "dir2/dir3/*")
+		// Once the lock expires, the repo can be deleted.
+		other := "other"
+		expired, err := types.TimestampProto(time.Now().Add(-time.Hour))
 		require.NoError(t, err)
-		err = env.PachClient.DeleteFile(commit, "dir?/*")
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo(other),
+			Settings: &pfs.RepoSettings{Lock: &pfs.RepoLock{Until: expired}},
+		})
 		require.NoError(t, err)
-		err = env.PachClient.DeleteFile(commit, "/")
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(other, "master", ""), "a", strings.NewReader("foo")))
+		commitInfo, err = env.PachClient.InspectCommit(other, "master", "")
 		require.NoError(t, err)
-		checks = func() {
-			fileInfos, err := env.PachClient.GlobFileAll(commit, "**")
-			require.NoError(t, err)
-			require.Equal(t, 0, len(fileInfos))
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
-		checks()
+		require.False(t, commitInfo.Locked)
+		require.NoError(t, env.PachClient.DeleteRepo(other, false))
 	})
 
-	suite.Run("GlobFile2", func(t *testing.T) {
+	suite.Run("BranchRetention", func(t *testing.T) {
 		t.Parallel()
-		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
-		if testing.Short() {
-			t.Skip("Skipping integration tests in short mode")
-		}
-
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t), func(config *serviceenv.Configuration) {
+			config.CommitRetentionInterval = "100ms"
+		})
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit := client.NewCommit(repo, "master", "")
-
-		_, err := env.PachClient.StartCommit(repo, "master")
+		retention := &pfs.BranchRetention{MaxCommits: 2}
+		require.NoError(t, env.PachClient.CreateBranchRetention(repo, "master", retention))
+		bi, err := env.PachClient.InspectBranch(repo, "master")
 		require.NoError(t, err)
-		expectedFileNames := []string{}
-		for i := 0; i < 100; i++ {
-			filename := fmt.Sprintf("/%d", i)
-			require.NoError(t, env.PachClient.PutFile(commit, filename, strings.NewReader(filename)))
+		require.Equal(t, int64(2), bi.Retention.MaxCommits)
 
-			if strings.HasPrefix(filename, "/1") {
-				expectedFileNames = append(expectedFileNames, filename)
-			}
+		// Commits on other branches aren't affected.
+		for i := 0; i < 3; i++ {
+			require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), fmt.Sprintf("file%d", i), strings.NewReader("foo")))
+			require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "other", ""), fmt.Sprintf("file%d", i), strings.NewReader("foo")))
 		}
-		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
-
-		actualFileNames := []string{}
-		require.NoError(t, env.PachClient.GlobFile(commit, "/1*", func(fileInfo *pfs.FileInfo) error {
-			actualFileNames = append(actualFileNames, fileInfo.File.Path)
+		require.NoErrorWithinTRetry(t, 10*time.Second, func() error {
+			commitInfos, err := env.PachClient.ListCommit(client.NewRepo(repo), client.NewCommit(repo, "master", ""), nil, 0)
+			if err != nil {
+				return err
+			}
+			if len(commitInfos) != 2 {
+				return errors.Errorf("expected 2 commits on master, got %d", len(commitInfos))
+			}
 			return nil
-		}))
+		})
+		commitInfos, err := env.PachClient.ListCommit(client.NewRepo(repo), client.NewCommit(repo, "other", ""), nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(commitInfos))
 
-		sort.Strings(expectedFileNames)
-		sort.Strings(actualFileNames)
-		require.Equal(t, expectedFileNames, actualFileNames)
+		// Squashing the older commits doesn't change the head.
+		files, err := env.PachClient.ListFileAll(client.NewCommit(repo, "master", ""), "/")
+		require.NoError(t, err)
+		require.Equal(t, 3, len(files))
 	})
 
-	suite.Run("GlobFile3", func(t *testing.T) {
+	suite.Run("ArchiveCommit", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit1, err := env.PachClient.StartCommit(repo, "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.1", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.2", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.1", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.2", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
-		globFile := func(pattern string) []string {
-			var fis []*pfs.FileInfo
-			require.NoError(t, env.PachClient.GlobFile(commit1, pattern, func(fi *pfs.FileInfo) error {
-				fis = append(fis, fi)
-				return nil
-			}))
-			return finfosToPaths(fis)
+		var commits []*pfs.Commit
+		for i := 0; i < 3; i++ {
+			commit, err := env.PachClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("file%d", i), strings.NewReader("foo")))
+			require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+			commits = append(commits, commit)
 		}
-		assert.ElementsMatch(t, []string{"/dir1/file1.2", "/dir2/file2.2"}, globFile("**.2"))
-		assert.ElementsMatch(t, []string{"/dir1/file1.1", "/dir1/file1.2"}, globFile("/dir1/*"))
-		assert.ElementsMatch(t, []string{"/dir1/", "/dir2/"}, globFile("/*"))
-		assert.ElementsMatch(t, []string{"/"}, globFile("/"))
-	})
 
-	// GetFileGlobOrder checks that GetFile(glob) streams data back in the
-	// right order. GetFile(glob) is supposed to return a stream of data of the
-	// form file1 + file2 + .. + fileN, where file1 is the lexicographically lowest
-	// file matching 'glob', file2 is the next lowest, etc.
-	suite.Run("GetFileGlobOrder", func(t *testing.T) {
-		t.Parallel()
-		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		// Open commits can't be archived.
+		open, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.YesError(t, env.PachClient.ArchiveCommit(repo, "master", open.ID))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", open.ID))
 
-		repo := "test"
-		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.ArchiveCommit(repo, "master", commits[0].ID))
+		cis, err := env.PachClient.ListCommit(client.NewRepo(repo), nil, nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(cis))
+		cis, err = env.PachClient.ListCommit(client.NewRepo(repo), client.NewCommit(repo, "master", ""), nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(cis))
+		cis, err = env.PachClient.ListCommit(client.NewRepo(repo), nil, nil, 0, client.WithArchivedListCommit())
+		require.NoError(t, err)
+		require.Equal(t, 4, len(cis))
 
-		var expected bytes.Buffer
-		commit, err := env.PachClient.StartCommit(repo, "master")
+		// Archived commits can still be read by ID.
+		ci, err := env.PachClient.InspectCommit(repo, "master", commits[0].ID)
 		require.NoError(t, err)
-		for i := 0; i < 25; i++ {
-			next := fmt.Sprintf("%d,%d,%d,%d\n", 4*i, (4*i)+1, (4*i)+2, (4*i)+3)
-			expected.WriteString(next)
-			env.PachClient.PutFile(commit, fmt.Sprintf("/data/%010d", i), strings.NewReader(next))
-		}
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		require.NotNil(t, ci.Archived)
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commits[0], "file0", &buf))
+		require.Equal(t, "foo", buf.String())
 
-		var output bytes.Buffer
-		require.NoError(t, env.PachClient.GetFile(commit, "/data/*", &output))
-		require.Equal(t, expected.String(), output.String())
+		require.NoError(t, env.PachClient.UnarchiveCommit(repo, "master", commits[0].ID))
+		ci, err = env.PachClient.InspectCommit(repo, "master", commits[0].ID)
+		require.NoError(t, err)
+		require.Nil(t, ci.Archived)
+		cis, err = env.PachClient.ListCommit(client.NewRepo(repo), nil, nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(cis))
 	})
 
-	suite.Run("ApplyWriteOrder", func(t *testing.T) {
+	suite.Run("CommitLabels", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
-		if testing.Short() {
-			t.Skip("Skipping integration tests in short mode")
-		}
-
 		repo := "test"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit := client.NewCommit(repo, "master", "")
 
-		// Test that fails when records are applied in lexicographic order
-		// rather than mod revision order.
-		_, err := env.PachClient.StartCommit(repo, "master")
+		commit1, err := env.PachClient.StartCommit(repo, "master",
+			client.WithDescriptionStartCommit("first"),
+			client.WithLabelsStartCommit(map[string]string{"stage": "dev", "owner": "alice"}))
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(commit, "/file", strings.NewReader("")))
-		err = env.PachClient.DeleteFile(commit, "/")
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit1.ID,
+			client.WithLabelsFinishCommit(map[string]string{"stage": "prod", "owner": ""})))
+		ci, err := env.PachClient.InspectCommit(repo, "master", commit1.ID)
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
-		fileInfos, err := env.PachClient.GlobFileAll(commit, "**")
+		require.Equal(t, "first", ci.Description)
+		require.Equal(t, map[string]string{"stage": "prod"}, ci.Labels)
+
+		commit2, err := env.PachClient.StartCommit(repo, "master",
+			client.WithLabelsStartCommit(map[string]string{"stage": "dev"}))
 		require.NoError(t, err)
-		require.Equal(t, 0, len(fileInfos))
-	})
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit2.ID,
+			client.WithDescriptionFinishCommit("second")))
+		ci, err = env.PachClient.InspectCommit(repo, "master", commit2.ID)
+		require.NoError(t, err)
+		require.Equal(t, "second", ci.Description)
+		require.Equal(t, map[string]string{"stage": "dev"}, ci.Labels)
 
-	suite.Run("Overwrite", func(t *testing.T) {
-		// TODO(2.0 optional): Implement put file split.
-		t.Skip("Put file split not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	if testing.Short() {
-		//		t.Skip("Skipping integration tests in short mode")
-		//	}
-		//
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//
-		//	// Write foo
-		//	_, err := env.PachClient.StartCommit(repo, "master")
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFile(repo, "master", "file1", strings.NewReader("foo"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, "master", "file2", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
-		//	require.NoError(t, err)
-		//	require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
-		//	_, err = env.PachClient.StartCommit(repo, "master")
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFile(repo, "master", "file1", strings.NewReader("bar"))
-		//	require.NoError(t, err)
-		//	require.NoError(t, env.PachClient.PutFile(repo, "master", "file2", strings.NewReader("buzz")))
-		//	require.NoError(t, err)
-		//	_, err = env.PachClient.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, 0, true, strings.NewReader("0\n1\n2\n"))
-		//	require.NoError(t, err)
-		//	require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
-		//	var buffer bytes.Buffer
-		//	require.NoError(t, env.PachClient.GetFile(repo, "master", "file1", &buffer))
-		//	require.Equal(t, "bar", buffer.String())
-		//	buffer.Reset()
-		//	require.NoError(t, env.PachClient.GetFile(repo, "master", "file2", &buffer))
-		//	require.Equal(t, "buzz", buffer.String())
-		//	fileInfos, err := env.PachClient.ListFileAll(repo, "master", "file3")
-		//	require.NoError(t, err)
-		//	require.Equal(t, 3, len(fileInfos))
-		//	for i := 0; i < 3; i++ {
-		//		buffer.Reset()
-		//		require.NoError(t, env.PachClient.GetFile(repo, "master", fmt.Sprintf("file3/%016x", i), &buffer))
-		//		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
-		//	}
+		_, err = env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+
+		listIDs := func(to *pfs.Commit, labels map[string]string) []string {
+			cis, err := env.PachClient.ListCommit(client.NewRepo(repo), to, nil, 0, client.WithLabelsListCommit(labels))
+			require.NoError(t, err)
+			var ids []string
+			for _, ci := range cis {
+				ids = append(ids, ci.Commit.ID)
+			}
+			return ids
+		}
+		for _, to := range []*pfs.Commit{nil, client.NewCommit(repo, "master", "")} {
+			require.Equal(t, 3, len(listIDs(to, nil)))
+			require.Equal(t, []string{commit1.ID}, listIDs(to, map[string]string{"stage": "prod"}))
+			require.Equal(t, []string{commit2.ID, commit1.ID}, listIDs(to, map[string]string{"stage": ""}))
+			require.Equal(t, 0, len(listIDs(to, map[string]string{"stage": "prod", "owner": ""})))
+		}
 	})
 
-	suite.Run("CopyFile", func(t *testing.T) {
+	suite.Run("ListCommitFilter", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		require.NoError(t, env.PachClient.CreateRepo("in"))
+		require.NoError(t, env.PachClient.CreateRepo("out"))
+		require.NoError(t, env.PachClient.CreateBranch("out", "master", "", "", []*pfs.Branch{client.NewBranch("in", "master")}))
 
-		repo := "test"
-		require.NoError(t, env.PachClient.CreateRepo(repo))
-
-		masterCommit, err := env.PachClient.StartCommit(repo, "master")
-		require.NoError(t, err)
-		numFiles := 5
-		for i := 0; i < numFiles; i++ {
-			require.NoError(t, env.PachClient.PutFile(masterCommit, fmt.Sprintf("files/%d", i), strings.NewReader(fmt.Sprintf("foo %d\n", i))))
+		commit := func() *pfs.Commit {
+			commit, err := env.PachClient.StartCommit("in", "master")
+			require.NoError(t, err)
+			require.NoError(t, env.PachClient.FinishCommit("in", "master", commit.ID))
+			return commit
 		}
-		require.NoError(t, env.PachClient.FinishCommit(repo, masterCommit.Branch.Name, masterCommit.ID))
-
-		for i := 0; i < numFiles; i++ {
-			_, err = env.PachClient.InspectFile(masterCommit, fmt.Sprintf("files/%d", i))
+		list := func(repo string, opts ...client.ListCommitOption) []*pfs.CommitInfo {
+			cis, err := env.PachClient.ListCommit(client.NewRepo(repo), nil, nil, 0, opts...)
 			require.NoError(t, err)
+			return cis
 		}
-
-		otherCommit, err := env.PachClient.StartCommit(repo, "other")
+		first := commit()
+		time.Sleep(10 * time.Millisecond)
+		between := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		commit()
+		last := commit()
+
+		after := list("in", client.WithTimeRangeListCommit(between, time.Time{}))
+		require.Equal(t, 2, len(after))
+		require.Equal(t, last.ID, after[0].Commit.ID)
+		before := list("in", client.WithTimeRangeListCommit(time.Time{}, between))
+		require.Equal(t, 1, len(before))
+		require.Equal(t, first.ID, before[0].Commit.ID)
+		cis, err := env.PachClient.ListCommit(client.NewRepo("in"), client.NewCommit("in", "master", ""), nil, 0, client.WithTimeRangeListCommit(between, time.Time{}))
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.CopyFile(otherCommit, "files", masterCommit, "files", client.WithAppendCopyFile()))
-		require.NoError(t, env.PachClient.CopyFile(otherCommit, "file0", masterCommit, "files/0", client.WithAppendCopyFile()))
-		require.NoError(t, env.PachClient.FinishCommit(repo, otherCommit.Branch.Name, otherCommit.ID))
+		require.Equal(t, 2, len(cis))
 
-		for i := 0; i < numFiles; i++ {
-			_, err = env.PachClient.InspectFile(otherCommit, fmt.Sprintf("files/%d", i))
-			require.NoError(t, err)
-		}
-		_, err = env.PachClient.InspectFile(otherCommit, "files/0")
+		// Open commits haven't finished before any time.
+		_, err = env.PachClient.StartCommit("in", "master")
 		require.NoError(t, err)
+		require.Equal(t, 3, len(list("in", client.WithTimeRangeListCommit(between, time.Time{}))))
+		require.Equal(t, 3, len(list("in", client.WithTimeRangeListCommit(time.Time{}, time.Now()))))
+		require.NoError(t, env.PachClient.FinishCommit("in", "master", ""))
+
+		require.Equal(t, 4, len(list("in", client.WithOriginListCommit(pfs.OriginKind_USER))))
+		require.Equal(t, 0, len(list("in", client.WithOriginListCommit(pfs.OriginKind_AUTO))))
+		outCommits := list("out")
+		require.Equal(t, len(outCommits), len(list("out", client.WithOriginListCommit(pfs.OriginKind_AUTO))))
+		require.Equal(t, 0, len(list("out", client.WithOriginListCommit(pfs.OriginKind_USER))))
 	})
 
-	suite.Run("PropagateBranch", func(t *testing.T) {
+	suite.Run("BigListFile", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
-		repo1 := "test1"
-		require.NoError(t, env.PachClient.CreateRepo(repo1))
-		repo2 := "test2"
-		require.NoError(t, env.PachClient.CreateRepo(repo2))
-		require.NoError(t, env.PachClient.CreateBranch(repo2, "master", "", "", []*pfs.Branch{client.NewBranch(repo1, "master")}))
-		commit, err := env.PachClient.StartCommit(repo1, "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit(repo1, commit.Branch.Name, commit.ID))
-		commits, err := env.PachClient.ListCommitByRepo(client.NewRepo(repo2))
+		var eg errgroup.Group
+		for i := 0; i < 25; i++ {
+			for j := 0; j < 25; j++ {
+				i := i
+				j := j
+				eg.Go(func() error {
+					return env.PachClient.PutFile(commit, fmt.Sprintf("dir%d/file%d", i, j), strings.NewReader("foo\n"))
+				})
+			}
+		}
+		require.NoError(t, eg.Wait())
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		for i := 0; i < 25; i++ {
+			files, err := env.PachClient.ListFileAll(commit, fmt.Sprintf("dir%d", i))
+			require.NoError(t, err)
+			require.Equal(t, 25, len(files))
+		}
+	})
+
+	suite.Run("StartCommitLatestOnBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		commit1, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.Equal(t, 2, len(commits))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
+
+		commit2, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.Branch.Name, commit2.ID))
+
+		commit3, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit3.Branch.Name, commit3.ID))
+
+		commitInfo, err := env.PachClient.InspectCommit(repo, "master", "")
+		require.NoError(t, err)
+		require.Equal(t, commit3.ID, commitInfo.Commit.ID)
 	})
 
-	// BackfillBranch implements the following DAG:
-	//
-	// A ──▶ C
-	//  ╲   ◀
-	//   ╲ ╱
-	//    ╳
-	//   ╱ ╲
-	// 	╱   ◀
-	// B ──▶ D
-	suite.Run("BackfillBranch", func(t *testing.T) {
+	suite.Run("CreateBranchTwice", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		commit1, err := env.PachClient.StartCommit(repo, "foo")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", commit1.ID, nil))
+
+		commit2, err := env.PachClient.StartCommit(repo, "foo")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.Branch.Name, commit2.ID))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", commit2.ID, nil))
+
+		branchInfos, err := env.PachClient.ListBranch(repo)
+		require.NoError(t, err)
+
+		// branches should be returned newest-first
+		require.Equal(t, 2, len(branchInfos))
+		require.Equal(t, "master", branchInfos[0].Branch.Name)
+		require.Equal(t, commit2.ID, branchInfos[0].Head.ID) // aliased branch should have the same commit ID
+		require.Equal(t, "foo", branchInfos[1].Branch.Name)
+		require.Equal(t, commit2.ID, branchInfos[1].Head.ID) // original branch should remain unchanged
+	})
+
+	suite.Run("WaitCommitSet", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		require.NoError(t, env.PachClient.CreateRepo("A"))
 		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-		require.NoError(t, env.PachClient.CreateRepo("D"))
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("B", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-		_, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+
+		ACommit, err := env.PachClient.StartCommit("A", "master")
 		require.NoError(t, err)
+		BCommit := client.NewCommit("B", "master", ACommit.ID)
 		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-		_, err = env.PachClient.StartCommit("B", "master")
-		require.NoError(t, err)
 		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-		commits, err := env.PachClient.ListCommitByRepo(client.NewRepo("C"))
-		require.NoError(t, err)
-		require.Equal(t, 3, len(commits))
 
-		// Create a branch in D, it should receive a single commit for the heads of `A` and `B`.
-		require.NoError(t, env.PachClient.CreateBranch("D", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("B", "master")}))
-		commits, err = env.PachClient.ListCommitByRepo(client.NewRepo("D"))
+		commitInfos, err := env.PachClient.WaitCommitSetAll(ACommit.ID)
 		require.NoError(t, err)
-		require.Equal(t, 1, len(commits))
+		require.Equal(t, 2, len(commitInfos))
+		require.Equal(t, ACommit, commitInfos[0].Commit)
+		require.Equal(t, BCommit, commitInfos[1].Commit)
 	})
 
-	// UpdateBranch tests the following DAG:
-	//
-	// A ─▶ B ─▶ C
-	//
-	// Then updates it to:
-	//
-	// A ─▶ B ─▶ C
-	//      ▲
-	// D ───╯
-	//
-	suite.Run("UpdateBranch", func(t *testing.T) {
+	// WaitCommitSet2 implements the following DAG:
+	// A ─▶ B ─▶ C ─▶ D
+	suite.Run("WaitCommitSet2", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
@@ -3462,1292 +4567,3119 @@ func TestPFS(suite *testing.T) {
 		require.NoError(t, env.PachClient.CreateRepo("B"))
 		require.NoError(t, env.PachClient.CreateRepo("C"))
 		require.NoError(t, env.PachClient.CreateRepo("D"))
+
+		// Create branches and finish the default head commits on the downstream branches
 		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
 		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
 		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("B", "master")}))
 		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		require.NoError(t, env.PachClient.CreateBranch("D", "master", "", "", []*pfs.Branch{client.NewBranch("C", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("D", "master", ""))
 
-		_, err := env.PachClient.StartCommit("A", "master")
+		ACommit, err := env.PachClient.StartCommit("A", "master")
 		require.NoError(t, err)
 		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+
+		// do the other commits in a goro so we can block for them
+		go func() {
+			require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+			require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+			require.NoError(t, env.PachClient.FinishCommit("D", "master", ""))
+		}()
+
+		// Wait for the commits to finish
+		commitInfos, err := env.PachClient.WaitCommitSetAll(ACommit.ID)
+		require.NoError(t, err)
+		BCommit := client.NewCommit("B", "master", ACommit.ID)
+		CCommit := client.NewCommit("C", "master", ACommit.ID)
+		DCommit := client.NewCommit("D", "master", ACommit.ID)
+		require.Equal(t, 4, len(commitInfos))
+		require.Equal(t, ACommit, commitInfos[0].Commit)
+		require.Equal(t, BCommit, commitInfos[1].Commit)
+		require.Equal(t, CCommit, commitInfos[2].Commit)
+		require.Equal(t, DCommit, commitInfos[3].Commit)
+	})
+
+	// A
+	//  ╲
+	//   ◀
+	//    C
+	//   ◀
+	//  ╱
+	// B
+	suite.Run("WaitCommitSet3", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("B", "master")}))
 		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
 
-		_, err = env.PachClient.StartCommit("D", "master")
+		ACommit, err := env.PachClient.StartCommit("A", "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("D", "master", ""))
+		require.NoError(t, env.PachClient.FinishCommit("A", ACommit.Branch.Name, ACommit.ID))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		BCommit, err := env.PachClient.StartCommit("B", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("B", BCommit.Branch.Name, BCommit.ID))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
 
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("D", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		BCommit, err = env.PachClient.StartCommit("B", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("B", BCommit.Branch.Name, BCommit.ID))
 		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-		cCommitInfo, err := env.PachClient.InspectCommit("C", "master", "")
+
+		// The first two commits will be A and B, but they aren't deterministically sorted
+		commitInfos, err := env.PachClient.WaitCommitSetAll(ACommit.ID)
 		require.NoError(t, err)
+		require.Equal(t, 3, len(commitInfos))
+		expected := []*pfs.Commit{ACommit, client.NewCommit("B", "master", ACommit.ID)}
+		actual := []*pfs.Commit{commitInfos[0].Commit, commitInfos[1].Commit}
+		require.ImagesEqual(t, expected, actual, CommitToID)
+		require.Equal(t, client.NewCommit("C", "master", ACommit.ID), commitInfos[2].Commit)
 
-		commitInfos, err := env.PachClient.InspectCommitSet(cCommitInfo.Commit.ID)
+		commitInfos, err = env.PachClient.WaitCommitSetAll(BCommit.ID)
 		require.NoError(t, err)
-		require.Equal(t, 4, len(commitInfos))
+		require.Equal(t, 3, len(commitInfos))
+		expected = []*pfs.Commit{client.NewCommit("A", "master", BCommit.ID), BCommit}
+		actual = []*pfs.Commit{commitInfos[0].Commit, commitInfos[1].Commit}
+		require.ImagesEqual(t, expected, actual, CommitToID)
+		require.Equal(t, client.NewCommit("C", "master", BCommit.ID), commitInfos[2].Commit)
 	})
 
-	suite.Run("BranchProvenance", func(t *testing.T) {
+	suite.Run("WaitCommitSetWithNoDownstreamRepos", func(t *testing.T) {
 		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		tests := [][]struct {
-			name       string
-			directProv []string
-			err        bool
-			expectProv map[string][]string
-			expectSubv map[string][]string
-		}{{
-			{name: "A"},
-			{name: "B", directProv: []string{"A"}},
-			{name: "C", directProv: []string{"B"}},
-			{name: "D", directProv: []string{"C", "A"},
-				expectProv: map[string][]string{"A": nil, "B": {"A"}, "C": {"B", "A"}, "D": {"A", "B", "C"}},
-				expectSubv: map[string][]string{"A": {"B", "C", "D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
-			// A ─▶ B ─▶ C ─▶ D
-			// ╰─────────────⬏
-			{name: "B",
-				expectProv: map[string][]string{"A": {}, "B": {}, "C": {"B"}, "D": {"A", "B", "C"}},
-				expectSubv: map[string][]string{"A": {"D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
-			// A    B ─▶ C ─▶ D
-			// ╰─────────────⬏
-		}, {
-			{name: "A"},
-			{name: "B", directProv: []string{"A"}},
-			{name: "C", directProv: []string{"A", "B"}},
-			{name: "D", directProv: []string{"C"},
-				expectProv: map[string][]string{"A": {}, "B": {"A"}, "C": {"A", "B"}, "D": {"A", "B", "C"}},
-				expectSubv: map[string][]string{"A": {"B", "C", "D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
-			// A ─▶ B ─▶ C ─▶ D
-			// ╰────────⬏
-			{name: "C", directProv: []string{"B"},
-				expectProv: map[string][]string{"A": {}, "B": {"A"}, "C": {"A", "B"}, "D": {"A", "B", "C"}},
-				expectSubv: map[string][]string{"A": {"B", "C", "D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
-			// A ─▶ B ─▶ C ─▶ D
-		}, {
-			{name: "A"},
-			{name: "B"},
-			{name: "C", directProv: []string{"A", "B"}},
-			{name: "D", directProv: []string{"C"}},
-			{name: "E", directProv: []string{"A", "D"},
-				expectProv: map[string][]string{"A": {}, "B": {}, "C": {"A", "B"}, "D": {"A", "B", "C"}, "E": {"A", "B", "C", "D"}},
-				expectSubv: map[string][]string{"A": {"C", "D", "E"}, "B": {"C", "D", "E"}, "C": {"D", "E"}, "D": {"E"}, "E": {}}},
-			// A    B ─▶ C ─▶ D ─▶ E
-			// ├────────⬏          ▲
-			// ╰───────────────────╯
-			{name: "C", directProv: []string{"B"},
-				expectProv: map[string][]string{"A": {}, "B": {}, "C": {"B"}, "D": {"B", "C"}, "E": {"A", "B", "C", "D"}},
-				expectSubv: map[string][]string{"A": {"E"}, "B": {"C", "D", "E"}, "C": {"D", "E"}, "D": {"E"}, "E": {}}},
-			// A    B ─▶ C ─▶ D ─▶ E
-			// ╰──────────────────⬏
-		}, {
-			{name: "A", directProv: []string{"A"}, err: true},
-			{name: "A"},
-			{name: "A", directProv: []string{"A"}, err: true},
-			{name: "B", directProv: []string{"A"}},
-			{name: "A", directProv: []string{"B"}, err: true},
-		},
-		}
-		for i, test := range tests {
-			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
-				t.Parallel()
-				env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
-				for _, repo := range []string{"A", "B", "C", "D", "E"} {
-					require.NoError(t, env.PachClient.CreateRepo(repo))
-				}
-				for iStep, step := range test {
-					var provenance []*pfs.Branch
-					for _, repo := range step.directProv {
-						provenance = append(provenance, client.NewBranch(repo, "master"))
-					}
-					err := env.PachClient.CreateBranch(step.name, "master", "", "", provenance)
-					if step.err {
-						require.YesError(t, err, "%d> CreateBranch(\"%s\", %v)", iStep, step.name, step.directProv)
-					} else {
-						require.NoError(t, err, "%d> CreateBranch(\"%s\", %v)", iStep, step.name, step.directProv)
-					}
-					require.NoError(t, env.PachClient.FsckFastExit())
-					for repo, expectedProv := range step.expectProv {
-						bi, err := env.PachClient.InspectBranch(repo, "master")
-						require.NoError(t, err)
-						sort.Strings(expectedProv)
-						require.Equal(t, len(expectedProv), len(bi.Provenance))
-						for _, b := range bi.Provenance {
-							i := sort.SearchStrings(expectedProv, b.Name)
-							if i >= len(expectedProv) || expectedProv[i] != b.Name {
-								t.Fatalf("provenance for %s contains: %s, but should only contain: %v", repo, b, expectedProv)
-							}
-						}
-					}
-					for repo, expectedSubv := range step.expectSubv {
-						bi, err := env.PachClient.InspectBranch(repo, "master")
-						require.NoError(t, err)
-						sort.Strings(expectedSubv)
-						require.Equal(t, len(expectedSubv), len(bi.Subvenance))
-						for _, b := range bi.Subvenance {
-							i := sort.SearchStrings(expectedSubv, b.Name)
-							if i >= len(expectedSubv) || expectedSubv[i] != b.Name {
-								t.Fatalf("subvenance for %s contains: %s, but should only contain: %v", repo, b, expectedSubv)
-							}
-						}
-					}
-				}
-			})
-		}
-
-		// t.Run("1", func(t *testing.T) {
-		// 	require.NoError(t, env.PachClient.CreateRepo("A"))
-		// 	require.NoError(t, env.PachClient.CreateRepo("B"))
-		// 	require.NoError(t, env.PachClient.CreateRepo("C"))
-		// 	require.NoError(t, env.PachClient.CreateRepo("D"))
-
-		// 	require.NoError(t, env.PachClient.CreateBranch("B", "master", "", []*pfs.Branch{client.NewBranch("A", "master")}))
-		// 	require.NoError(t, env.PachClient.CreateBranch("C", "master", "", []*pfs.Branch{client.NewBranch("B", "master")}))
-		// 	require.NoError(t, env.PachClient.CreateBranch("D", "master", "", []*pfs.Branch{client.NewBranch("C", "master"), client.NewBranch("A", "master")}))
-
-		// 	aMaster, err := env.PachClient.InspectBranch("A", "master")
-		// 	require.NoError(t, err)
-		// 	require.Equal(t, 3, len(aMaster.Subvenance))
-
-		// 	cMaster, err := env.PachClient.InspectBranch("C", "master")
-		// 	require.NoError(t, err)
-		// 	require.Equal(t, 2, len(cMaster.Provenance))
-
-		// 	dMaster, err := env.PachClient.InspectBranch("D", "master")
-		// 	require.NoError(t, err)
-		// 	require.Equal(t, 3, len(dMaster.Provenance))
-
-		// 	require.NoError(t, env.PachClient.CreateBranch("B", "master", "", nil))
-
-		// 	aMaster, err = env.PachClient.InspectBranch("A", "master")
-		// 	require.NoError(t, err)
-		// 	require.Equal(t, 1, len(aMaster.Subvenance))
-
-		// 	cMaster, err = env.PachClient.InspectBranch("C", "master")
-		// 	require.NoError(t, err)
-		// 	require.Equal(t, 1, len(cMaster.Provenance))
-
-		// 	dMaster, err = env.PachClient.InspectBranch("D", "master")
-		// 	require.NoError(t, err)
-		// 	require.Equal(t, 3, len(dMaster.Provenance))
-		// })
-		// t.Run("2", func(t *testing.T) {
-		// 	require.NoError(t, env.PachClient.CreateRepo("A"))
-		// 	require.NoError(t, env.PachClient.CreateRepo("B"))
-		// 	require.NoError(t, env.PachClient.CreateRepo("C"))
-		// 	require.NoError(t, env.PachClient.CreateRepo("D"))
-
-		// 	require.NoError(t, env.PachClient.CreateBranch("B", "master", "", []*pfs.Branch{client.NewBranch("A", "master")}))
-		// 	require.NoError(t, env.PachClient.CreateBranch("C", "master", "", []*pfs.Branch{client.NewBranch("B", "master"), client.NewBranch("A", "master")}))
-		// 	require.NoError(t, env.PachClient.CreateBranch("D", "master", "", []*pfs.Branch{client.NewBranch("C", "master")}))
-		// })
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		commitInfos, err := env.PachClient.WaitCommitSetAll(commit.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commitInfos))
+		require.Equal(t, commit, commitInfos[0].Commit)
 	})
 
-	suite.Run("ChildCommits", func(t *testing.T) {
+	suite.Run("WaitOpenCommit", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateBranch("A", "master", "", "", nil))
-		aRepo := client.NewRepo("A")
-
-		// Small helper function wrapping env.PachClient.InspectCommit, because it's called a lot
-		inspect := func(repo, branch, commit string) *pfs.CommitInfo {
-			commitInfo, err := env.PachClient.InspectCommit(repo, branch, commit)
-			require.NoError(t, err)
-			return commitInfo
-		}
-
-		commit1, err := env.PachClient.StartCommit("A", "master")
-		require.NoError(t, err)
-		commits, err := env.PachClient.ListCommit(aRepo, aRepo.NewCommit("master", ""), nil, 0)
-		require.NoError(t, err)
-		t.Logf("%v", commits)
-		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
-
-		commit2, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		commit, err := env.PachClient.StartCommit("A", "master")
 		require.NoError(t, err)
 
-		// Inspect commit 1 and 2
-		commit1Info, commit2Info := inspect("A", commit1.Branch.Name, commit1.ID), inspect("A", commit2.Branch.Name, commit2.ID)
-		require.Equal(t, commit1.ID, commit2Info.ParentCommit.ID)
-		require.ImagesEqual(t, []*pfs.Commit{commit2}, commit1Info.ChildCommits, CommitToID)
-
-		// Delete commit 2 and make sure it's removed from commit1.ChildCommits
-		require.NoError(t, env.PachClient.SquashCommitSet(commit2.ID))
-		commit1Info = inspect("A", commit1.Branch.Name, commit1.ID)
-		require.ElementsEqualUnderFn(t, nil, commit1Info.ChildCommits, CommitToID)
+		// do the other commits in a goro so we can block for them
+		eg, _ := errgroup.WithContext(context.Background())
+		eg.Go(func() error {
+			time.Sleep(3 * time.Second)
+			if err := env.PachClient.FinishCommit("A", "master", ""); err != nil {
+				return err
+			}
+			return env.PachClient.FinishCommit("B", "master", "")
+		})
 
-		// Re-create commit2, and create a third commit also extending from commit1.
-		// Make sure both appear in commit1.children
-		commit2, err = env.PachClient.StartCommit("A", "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("A", commit2.Branch.Name, commit2.ID))
-		commit3, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
-			Branch: client.NewBranch("A", "foo"),
-			Parent: commit1,
+		t.Cleanup(func() {
+			require.NoError(t, eg.Wait())
 		})
+
+		// Wait for the commit to finish
+		commitInfos, err := env.PachClient.WaitCommitSetAll(commit.ID)
 		require.NoError(t, err)
-		commit1Info = inspect("A", commit1.Branch.Name, commit1.ID)
-		require.ImagesEqual(t, []*pfs.Commit{commit2, commit3}, commit1Info.ChildCommits, CommitToID)
+		require.Equal(t, 2, len(commitInfos))
+		require.Equal(t, commit, commitInfos[0].Commit)
+		require.Equal(t, client.NewCommit("B", "master", commit.ID), commitInfos[1].Commit)
+	})
 
-		// Delete commit3 and make sure commit1 has the right children
-		require.NoError(t, env.PachClient.SquashCommitSet(commit3.ID))
-		commit1Info = inspect("A", commit1.Branch.Name, commit1.ID)
-		require.ImagesEqual(t, []*pfs.Commit{commit2}, commit1Info.ChildCommits, CommitToID)
+	suite.Run("WaitUninvolvedBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Create a downstream branch in a different repo, then commit to "A" and
-		// make sure the new HEAD commit is in the parent's children (i.e. test
-		// propagateBranches)
+		require.NoError(t, env.PachClient.CreateRepo("A"))
 		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{
-			client.NewBranch("A", "master"),
-		}))
-		bCommit1 := inspect("B", "master", "")
-		commit3, err = env.PachClient.StartCommit("A", "master")
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", nil))
+		commit, err := env.PachClient.StartCommit("A", "master")
 		require.NoError(t, err)
-		env.PachClient.FinishCommit("A", commit3.Branch.Name, commit3.ID)
-		// Re-inspect bCommit1, which has been updated by StartCommit
-		bCommit1, bCommit2 := inspect("B", bCommit1.Commit.Branch.Name, bCommit1.Commit.ID), inspect("B", "master", "")
-		require.Equal(t, bCommit1.Commit.ID, bCommit2.ParentCommit.ID)
-		require.ImagesEqual(t, []*pfs.Commit{bCommit2.Commit}, bCommit1.ChildCommits, CommitToID)
 
-		// create a new branch in a different repo, then update it so that two commits
-		// are generated. Make sure the second commit is in the parent's children
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{
-			client.NewBranch("A", "master"),
-		}))
-		cCommit1 := inspect("C", "master", "") // Get new commit's ID
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{
-			client.NewBranch("A", "master"),
-			client.NewBranch("B", "master"),
-		}))
-		// Re-inspect cCommit1, which has been updated by CreateBranch
-		cCommit1, cCommit2 := inspect("C", cCommit1.Commit.Branch.Name, cCommit1.Commit.ID), inspect("C", "master", "")
-		require.Equal(t, cCommit1.Commit.ID, cCommit2.ParentCommit.ID)
-		require.ImagesEqual(t, []*pfs.Commit{cCommit2.Commit}, cCommit1.ChildCommits, CommitToID)
+		// Blocking on a commit that doesn't exist does not work
+		_, err = env.PachClient.WaitCommit("B", "master", commit.ID)
+		require.YesError(t, err)
 	})
 
-	suite.Run("StartCommitFork", func(t *testing.T) {
+	suite.Run("WaitNonExistentBranch", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		require.NoError(t, env.PachClient.CreateRepo("A"))
 		commit, err := env.PachClient.StartCommit("A", "master")
 		require.NoError(t, err)
-		env.PachClient.FinishCommit("A", commit.Branch.Name, commit.ID)
-		commit2, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
-			Branch: client.NewBranch("A", "master2"),
-			Parent: client.NewCommit("A", "master", ""),
-		})
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("A", commit2.Branch.Name, commit2.ID))
 
-		aRepo := client.NewRepo("A")
-		commitInfos, err := env.PachClient.ListCommit(aRepo, nil, nil, 0)
-		require.NoError(t, err)
-		commits := []*pfs.Commit{}
-		for _, ci := range commitInfos {
-			commits = append(commits, ci.Commit)
-		}
-		require.ImagesEqual(t, []*pfs.Commit{commit, commit2}, commits, CommitToID)
+		// Blocking on a branch that doesn't exist does not work
+		_, err = env.PachClient.WaitCommit("A", "foo", commit.ID)
+		require.YesError(t, err)
+
+		_, err = env.PachClient.WaitCommit("A", "foo", "")
+		require.YesError(t, err)
 	})
 
-	// UpdateBranchNewOutputCommit tests the following corner case:
-	// A ──▶ C
-	// B
-	//
-	// Becomes:
-	//
-	// A  ╭▶ C
-	// B ─╯
-	//
-	// C should create a new output commit to process its unprocessed inputs in B
-	suite.Run("UpdateBranchNewOutputCommit", func(t *testing.T) {
+	suite.Run("EmptyWait", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-		require.NoError(t, env.PachClient.CreateBranch("A", "master", "", "", nil))
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", nil))
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "",
-			[]*pfs.Branch{client.NewBranch("A", "master")}))
+		_, err := env.PachClient.WaitCommitSetAll("")
+		require.YesError(t, err)
+	})
 
-		// Create commits in A and B
-		commit, err := env.PachClient.StartCommit("A", "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("A", commit.Branch.Name, commit.ID))
-		commit, err = env.PachClient.StartCommit("B", "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("B", commit.Branch.Name, commit.ID))
-
-		// Check for first output commit in C (plus the old empty head commit)
-		cRepo := client.NewRepo("C")
-		commits, err := env.PachClient.ListCommit(cRepo, cRepo.NewCommit("master", ""), nil, 0)
-		require.NoError(t, err)
-		require.Equal(t, 2, len(commits))
+	suite.Run("WaitNonExistentCommitSet", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Update the provenance of C/master and make sure it creates a new commit
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "",
-			[]*pfs.Branch{client.NewBranch("B", "master")}))
-		commits, err = env.PachClient.ListCommit(cRepo, cRepo.NewCommit("master", ""), nil, 0)
-		require.NoError(t, err)
-		require.Equal(t, 3, len(commits))
+		_, err := env.PachClient.WaitCommitSetAll("fake-commitset")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsCommitSetNotFoundErr(err))
 	})
 
-	// SquashCommitSetMultipleChildrenSingleCommit tests that when you have the
-	// following commit graph in a repo:
-	// c   d
-	//  ↘ ↙
-	//   b
-	//   ↓
-	//   a
-	//
-	// and you delete commit 'b', what you end up with is:
-	//
-	// c   d
-	//  ↘ ↙
-	//   a
-	suite.Run("SquashCommitSetMultipleChildrenSingleCommit", func(t *testing.T) {
+	suite.Run("PutFileSplit", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("repo"))
-		require.NoError(t, env.PachClient.CreateBranch("repo", "master", "", "", nil))
-
-		// Create commits 'a' and 'b'
-		a, err := env.PachClient.StartCommit("repo", "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("repo", a.Branch.Name, a.ID))
-		b, err := env.PachClient.StartCommit("repo", "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("repo", b.Branch.Name, b.ID))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "none", pfs.Delimiter_NONE, 0, 0, 0, strings.NewReader("foo\nbar\nbuz\n")))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "line", pfs.Delimiter_LINE, 0, 0, 0, strings.NewReader("foo\nbar\nbuz\n")))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "line", pfs.Delimiter_LINE, 0, 0, 0, strings.NewReader("foo\nbar\nbuz\n"), client.WithAppendPutFile()))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "line2", pfs.Delimiter_LINE, 2, 0, 0, strings.NewReader("foo\nbar\nbuz\nfiz\n")))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "line3", pfs.Delimiter_LINE, 0, 8, 0, strings.NewReader("foo\nbar\nbuz\nfiz\n")))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "json", pfs.Delimiter_JSON, 0, 0, 0, strings.NewReader("{}{}{}{}{}{}{}{}{}{}")))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "json", pfs.Delimiter_JSON, 0, 0, 0, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"), client.WithAppendPutFile()))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "json2", pfs.Delimiter_JSON, 2, 0, 0, strings.NewReader("{}{}{}{}")))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "json3", pfs.Delimiter_JSON, 0, 4, 0, strings.NewReader("{}{}{}{}")))
+
+		checkFiles := func(commit *pfs.Commit, path string, n int, size uint64) {
+			files, err := env.PachClient.ListFileAll(commit, path)
+			require.NoError(t, err)
+			require.Equal(t, n, len(files))
+			for _, fileInfo := range files {
+				require.Equal(t, size, fileInfo.SizeBytes)
+			}
+		}
+		checkFiles(commit, "line2", 2, 8)
 
-		// Create 'd' by aliasing 'b' into another branch (force a new CommitSet rather than extending 'b')
-		_, err = env.PachClient.PfsAPIClient.CreateBranch(env.PachClient.Ctx(), &pfs.CreateBranchRequest{
-			Branch:       client.NewBranch("repo", "master2"),
-			Head:         client.NewCommit("repo", "master", ""),
-			NewCommitSet: true,
-		})
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		commit2, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFileSplit(commit2, "line", pfs.Delimiter_LINE, 0, 0, 0, strings.NewReader("foo\nbar\nbuz\n"), client.WithAppendPutFile()))
+		require.NoError(t, env.PachClient.PutFileSplit(commit2, "json", pfs.Delimiter_JSON, 0, 0, 0, strings.NewReader("{}{}{}{}{}{}{}{}{}{}"), client.WithAppendPutFile()))
+		checkFiles(commit2, "line", 9, 4)
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit2.Branch.Name, commit2.ID))
 
-		// Create 'c'
-		c, err := env.PachClient.StartCommit("repo", "master")
+		fileInfo, err := env.PachClient.InspectFile(commit, "none")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("repo", c.Branch.Name, c.ID))
+		require.Equal(t, pfs.FileType_FILE, fileInfo.FileType)
+		checkFiles(commit, "line", 6, 4)
+		checkFiles(commit2, "line", 9, 4)
+		checkFiles(commit, "line2", 2, 8)
+		checkFiles(commit, "line3", 2, 8)
+		checkFiles(commit, "json", 20, 2)
+		checkFiles(commit2, "json", 30, 2)
+		checkFiles(commit, "json2", 2, 4)
+		checkFiles(commit, "json3", 2, 4)
+
+		// Putting without appending replaces the files.
+		commit3, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFileSplit(commit3, "line", pfs.Delimiter_LINE, 0, 0, 0, strings.NewReader("foo\nbar\n")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit3.Branch.Name, commit3.ID))
+		checkFiles(commit3, "line", 2, 4)
+	})
 
-		// Collect info re: a, b, c, and d, and make sure that the parent/child
-		// relationships are all correct
-		aInfo, err := env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+	suite.Run("PutFileSplitBig", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		bInfo, err := env.PachClient.InspectCommit("repo", b.Branch.Name, b.ID)
+		r := strings.NewReader(strings.Repeat("foo\n", 1000))
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "line", pfs.Delimiter_LINE, 0, 0, 0, r))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		files, err := env.PachClient.ListFileAll(commit, "line")
 		require.NoError(t, err)
-		cInfo, err := env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		require.Equal(t, 1000, len(files))
+		for _, fileInfo := range files {
+			require.Equal(t, uint64(4), fileInfo.SizeBytes)
+		}
+	})
+
+	suite.Run("PutFileSplitCSV", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "data", pfs.Delimiter_CSV, 0, 0, 0,
+			// Weird, but this is actually two lines ("is\na" is quoted, so one cell)
+			strings.NewReader("this,is,a,test\n"+
+				"\"\"\"this\"\"\",\"is\nonly\",\"a,test\"\n")))
+		fileInfos, err := env.PachClient.ListFileAll(commit, "/data")
 		require.NoError(t, err)
-		dInfo, err := env.PachClient.InspectCommit("repo", "master2", "")
+		require.Equal(t, 2, len(fileInfos))
+		var contents bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "/data/0000000000000000", &contents))
+		require.Equal(t, "this,is,a,test\n", contents.String())
+		contents.Reset()
+		require.NoError(t, env.PachClient.GetFile(commit, "/data/0000000000000001", &contents))
+		require.Equal(t, "\"\"\"this\"\"\",\"is\nonly\",\"a,test\"\n", contents.String())
+
+		// With a header record, the column names start every file.
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "header", pfs.Delimiter_CSV, 0, 0, 1,
+			strings.NewReader("a,b\n1,2\n3,4\n")))
+		fileInfos, err = env.PachClient.ListFileAll(commit, "/header")
 		require.NoError(t, err)
-		d := dInfo.Commit
+		require.Equal(t, 2, len(fileInfos))
+		contents.Reset()
+		require.NoError(t, env.PachClient.GetFile(commit, "/header/0000000000000001", &contents))
+		require.Equal(t, "a,b\n3,4\n", contents.String())
+	})
 
-		require.NotNil(t, aInfo.ParentCommit) // this should be the empty default head
-		require.ImagesEqual(t, []*pfs.Commit{b}, aInfo.ChildCommits, CommitToID)
+	suite.Run("PutFileSplitSQL", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.Equal(t, a.ID, bInfo.ParentCommit.ID)
-		require.ImagesEqual(t, []*pfs.Commit{c, d}, bInfo.ChildCommits, CommitToID)
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		master := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFileSplit(master, "/sql", pfs.Delimiter_SQL, 0, 0, 0, strings.NewReader(tu.TestPGDump)))
+		fileInfos, err := env.PachClient.ListFileAll(master, "/sql")
+		require.NoError(t, err)
+		require.Equal(t, 5, len(fileInfos))
+
+		// Get one of the SQL records & validate it
+		var contents bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(master, "/sql/0000000000000000", &contents))
+		// Validate that the recieved pgdump file creates the cars table
+		require.Matches(t, "CREATE TABLE public\\.cars", contents.String())
+		// Validate the SQL header more generally by passing the output of GetFile
+		// back through the SQL library & confirm that it parses correctly but only
+		// has one row
+		pgReader := sql.NewPGDumpReader(bufio.NewReader(bytes.NewReader(contents.Bytes())))
+		record, err := pgReader.ReadRow()
+		require.NoError(t, err)
+		require.Equal(t, "Tesla\tRoadster\t2008\tliterally a rocket\n", string(record))
+		_, err = pgReader.ReadRow()
+		require.YesError(t, err)
+		require.True(t, errors.Is(err, io.EOF))
 
-		require.Equal(t, b.ID, cInfo.ParentCommit.ID)
-		require.Equal(t, 0, len(cInfo.ChildCommits))
+		// Create a new commit that overwrites all existing data & puts it back with
+		// --header-records=1
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFileSplit(commit, "/sql", pfs.Delimiter_SQL, 0, 0, 1, strings.NewReader(tu.TestPGDump)))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		fileInfos, err = env.PachClient.ListFileAll(master, "/sql")
+		require.NoError(t, err)
+		require.Equal(t, 4, len(fileInfos))
+
+		// Get one of the SQL records & validate it
+		contents.Reset()
+		require.NoError(t, env.PachClient.GetFile(master, "/sql/0000000000000003", &contents))
+		// Validate that the recieved pgdump file creates the cars table
+		require.Matches(t, "CREATE TABLE public\\.cars", contents.String())
+		// Validate the SQL header more generally by passing the output of GetFile
+		// back through the SQL library & confirm that it parses correctly and
+		// has the header row followed by one row
+		pgReader = sql.NewPGDumpReader(bufio.NewReader(strings.NewReader(contents.String())))
+		record, err = pgReader.ReadRow()
+		require.NoError(t, err)
+		require.Equal(t, "Tesla\tRoadster\t2008\tliterally a rocket\n", string(record))
+		record, err = pgReader.ReadRow()
+		require.NoError(t, err)
+		require.Equal(t, "Toyota\tCorolla\t2005\tgreatest car ever made\n", string(record))
+		_, err = pgReader.ReadRow()
+		require.YesError(t, err)
+		require.True(t, errors.Is(err, io.EOF))
+	})
 
-		require.Equal(t, b.ID, dInfo.ParentCommit.ID)
-		require.Equal(t, 0, len(dInfo.ChildCommits))
+	suite.Run("DiffFile", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Delete commit 'b'
-		env.PachClient.SquashCommitSet(b.ID)
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
 
-		// Collect info re: a, c, and d, and make sure that the parent/child
-		// relationships are still correct
-		aInfo, err = env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+		// Write foo
+		c1, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		cInfo, err = env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		require.NoError(t, env.PachClient.PutFile(c1, "foo", strings.NewReader("foo\n"), client.WithAppendPutFile()))
+		checks := func() {
+			newFis, oldFis, err := env.PachClient.DiffFileAll(c1, "", nil, "", false)
+			require.NoError(t, err)
+			require.Equal(t, 0, len(oldFis))
+			require.Equal(t, 2, len(newFis))
+			require.Equal(t, "/foo", newFis[1].File.Path)
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, c1.Branch.Name, c1.ID))
+		checks()
+
+		// Change the value of foo
+		c2, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		dInfo, err = env.PachClient.InspectCommit("repo", d.Branch.Name, d.ID)
+		require.NoError(t, env.PachClient.DeleteFile(c2, "/foo"))
+		require.NoError(t, env.PachClient.PutFile(c2, "foo", strings.NewReader("not foo\n"), client.WithAppendPutFile()))
+		checks = func() {
+			newFis, oldFis, err := env.PachClient.DiffFileAll(c2, "", nil, "", false)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(oldFis))
+			require.Equal(t, "/foo", oldFis[1].File.Path)
+			require.Equal(t, 2, len(newFis))
+			require.Equal(t, "/foo", newFis[1].File.Path)
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, c2.Branch.Name, c2.ID))
+		checks()
+
+		// Write bar
+		c3, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(c3, "/bar", strings.NewReader("bar\n"), client.WithAppendPutFile()))
+		checks = func() {
+			newFis, oldFis, err := env.PachClient.DiffFileAll(c3, "", nil, "", false)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(oldFis))
+			require.Equal(t, 2, len(newFis))
+			require.Equal(t, "/bar", newFis[1].File.Path)
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, c3.Branch.Name, c3.ID))
+		checks()
 
-		require.NotNil(t, aInfo.ParentCommit)
-		require.ImagesEqual(t, []*pfs.Commit{c, d}, aInfo.ChildCommits, CommitToID)
+		// Delete bar
+		c4, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.DeleteFile(c4, "/bar"))
+		checks = func() {
+			newFis, oldFis, err := env.PachClient.DiffFileAll(c4, "", nil, "", false)
+			require.NoError(t, err)
+			require.Equal(t, 2, len(oldFis))
+			require.Equal(t, "/bar", oldFis[1].File.Path)
+			require.Equal(t, 1, len(newFis))
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, c4.Branch.Name, c4.ID))
+		checks()
 
-		require.Equal(t, a.ID, cInfo.ParentCommit.ID)
-		require.Equal(t, 0, len(cInfo.ChildCommits))
+		// Write dir/fizz and dir/buzz
+		c5, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(c5, "/dir/fizz", strings.NewReader("fizz\n"), client.WithAppendPutFile()))
+		require.NoError(t, env.PachClient.PutFile(c5, "/dir/buzz", strings.NewReader("buzz\n"), client.WithAppendPutFile()))
+		checks = func() {
+			newFis, oldFis, err := env.PachClient.DiffFileAll(c5, "", nil, "", false)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(oldFis))
+			require.Equal(t, 4, len(newFis))
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, c5.Branch.Name, c5.ID))
+		checks()
 
-		require.Equal(t, a.ID, dInfo.ParentCommit.ID)
-		require.Equal(t, 0, len(dInfo.ChildCommits))
+		// Modify dir/fizz
+		c6, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(c6, "/dir/fizz", strings.NewReader("fizz\n"), client.WithAppendPutFile()))
+		checks = func() {
+			newFis, oldFis, err := env.PachClient.DiffFileAll(c6, "", nil, "", false)
+			require.NoError(t, err)
+			require.Equal(t, 3, len(oldFis))
+			require.Equal(t, "/dir/fizz", oldFis[2].File.Path)
+			require.Equal(t, 3, len(newFis))
+			require.Equal(t, "/dir/fizz", newFis[2].File.Path)
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, c6.Branch.Name, c6.ID))
+		checks()
 	})
 
-	// Tests that when you have the following commit graph in a *downstream* repo:
-	//
-	//    ↙f
-	//   c
-	//   ↓↙e
-	//   b
-	//   ↓↙d
-	//   a
-	//
-	// and you delete commits 'b' and 'c' (in a single call), what you end up with
-	// is:
-	//     f
-	//     ↓
-	// d e c
-	//  ↘↓↙
-	//   a
-	// This makes sure that multiple live children are re-pointed at a live parent
-	// if appropriate
-	suite.Run("SquashCommitSetMultiLevelChildren", func(t *testing.T) {
+	suite.Run("DiffFileDetectRenames", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("upstream1"))
-		require.NoError(t, env.PachClient.CreateRepo("upstream2"))
-		// commit to both inputs
-		_, err := env.PachClient.StartCommit("upstream1", "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit1, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("upstream1", "master", ""))
-		_, err = env.PachClient.StartCommit("upstream2", "master")
+		require.NoError(t, env.PachClient.PutFile(commit1, "dir/a", strings.NewReader("foo\n")))
+		require.NoError(t, env.PachClient.PutFile(commit1, "dir/b", strings.NewReader("bar\n")))
+		require.NoError(t, env.PachClient.PutFile(commit1, "c", strings.NewReader("baz\n")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit1.ID))
+
+		commit2, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("upstream2", "master", ""))
+		require.NoError(t, env.PachClient.MoveFile(commit2, "dir/a", "moved/a"))
+		require.NoError(t, env.PachClient.PutFile(commit2, "dir/b", strings.NewReader("bar\nbar\n")))
+		require.NoError(t, env.PachClient.DeleteFile(commit2, "c"))
+		require.NoError(t, env.PachClient.PutFile(commit2, "d", strings.NewReader("new\n")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit2.ID))
 
-		// Create main repo (will have the commit graphs above)
-		require.NoError(t, env.PachClient.CreateRepo("repo"))
-		require.NoError(t, env.PachClient.CreateBranch("repo", "master", "", "", []*pfs.Branch{
-			client.NewBranch("upstream1", "master"),
-			client.NewBranch("upstream2", "master"),
-		}))
-		repoProto := client.NewRepo("repo")
-
-		// Create commit 'a'
-		aInfo, err := env.PachClient.InspectCommit("repo", "master", "")
+		entries, err := env.PachClient.DiffFileEntries(commit2, "/", nil, "")
 		require.NoError(t, err)
-		a := aInfo.Commit
-		require.NoError(t, env.PachClient.FinishCommit("repo", a.Branch.Name, a.ID))
+		var changes []string
+		for _, entry := range entries {
+			changes = append(changes, fmt.Sprintf("%v %s %s %d", entry.Type, entry.OldPath, entry.Path, entry.SizeDelta))
+		}
+		require.Equal(t, []string{
+			"DELETED  /c -4",
+			"ADDED  /d 4",
+			"MODIFIED  /dir/b 4",
+			"RENAMED /dir/a /moved/a 0",
+		}, changes)
+	})
 
-		// Create 'd'
-		resp, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
-			Branch: client.NewBranch("repo", "fod"),
-			Parent: a,
-		})
-		require.NoError(t, err)
-		d := client.NewCommit("repo", resp.Branch.Name, resp.ID)
-		require.NoError(t, env.PachClient.FinishCommit("repo", resp.Branch.Name, resp.ID))
+	suite.Run("DiffFileContent", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Create 'b'
-		// (a & b have same prov commit in upstream2, so this is the commit that will
-		// be deleted, as both b and c are provenant on it)
-		squashMeCommit, err := env.PachClient.StartCommit("upstream1", "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit1, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("upstream1", "master", ""))
-		bInfo, err := env.PachClient.InspectCommit("repo", "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit1, "file", strings.NewReader("a\nb\nc\n")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit1.ID))
+		commit2, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		b := bInfo.Commit
-		require.NoError(t, env.PachClient.FinishCommit("repo", b.Branch.Name, b.ID))
-		require.Equal(t, b.ID, squashMeCommit.ID)
+		require.NoError(t, env.PachClient.PutFile(commit2, "file", strings.NewReader("a\nB\nc\n")))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", commit2.ID))
 
-		// Create 'e'
-		resp, err = env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
-			Branch: client.NewBranch("repo", "foe"),
-			Parent: b,
-		})
-		require.NoError(t, err)
-		e := client.NewCommit("repo", resp.Branch.Name, resp.ID)
-		require.NoError(t, env.PachClient.FinishCommit("repo", resp.Branch.Name, resp.ID))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.DiffFileContent(commit2, "file", nil, "", 1, &buf))
+		require.True(t, strings.Contains(buf.String(), "-b\n+B\n"), buf.String())
 
-		// Create 'c'
-		_, err = env.PachClient.StartCommit("upstream2", "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.FinishCommit("upstream2", "master", ""))
-		cInfo, err := env.PachClient.InspectCommit("repo", "master", "")
-		require.NoError(t, err)
-		c := cInfo.Commit
-		require.NoError(t, env.PachClient.FinishCommit("repo", c.Branch.Name, c.ID))
+		// Diffing a file against itself finds no differences.
+		buf.Reset()
+		require.NoError(t, env.PachClient.DiffFileContent(commit2, "file", commit2, "file", 1, &buf))
+		require.Equal(t, "", buf.String())
+
+		// Applying the byte range deltas to the old content gives the new content.
+		old := []byte("a\nb\nc\n")
+		var deltas []*pfs.ByteRangeDelta
+		require.NoError(t, env.PachClient.DiffFileContentRanges(commit2, "file", nil, "", func(delta *pfs.ByteRangeDelta) error {
+			deltas = append(deltas, delta)
+			return nil
+		}))
+		require.True(t, len(deltas) > 0)
+		var result []byte
+		var offset int64
+		for _, delta := range deltas {
+			result = append(result, old[offset:delta.OldOffset]...)
+			result = append(result, delta.Data...)
+			offset = delta.OldOffset + delta.OldLength
+		}
+		result = append(result, old[offset:]...)
+		require.Equal(t, "a\nB\nc\n", string(result))
 
-		// Create 'f'
-		resp, err = env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
-			Branch: client.NewBranch("repo", "fof"),
-			Parent: c,
-		})
-		require.NoError(t, err)
-		f := client.NewCommit("repo", resp.Branch.Name, resp.ID)
-		require.NoError(t, env.PachClient.FinishCommit("repo", resp.Branch.Name, resp.ID))
+		require.YesError(t, env.PachClient.DiffFileContent(commit2, "/", nil, "", 1, &buf))
+	})
 
-		// Make sure child/parent relationships are as shown in first diagram
-		commits, err := env.PachClient.ListCommit(repoProto, nil, nil, 0)
-		require.NoError(t, err)
-		require.Equal(t, 6, len(commits))
-		aInfo, err = env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
-		require.NoError(t, err)
-		bInfo, err = env.PachClient.InspectCommit("repo", b.Branch.Name, b.ID)
-		require.NoError(t, err)
-		cInfo, err = env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
-		require.NoError(t, err)
-		dInfo, err := env.PachClient.InspectCommit("repo", d.Branch.Name, d.ID)
-		require.NoError(t, err)
-		eInfo, err := env.PachClient.InspectCommit("repo", e.Branch.Name, e.ID)
-		require.NoError(t, err)
-		fInfo, err := env.PachClient.InspectCommit("repo", f.Branch.Name, f.ID)
-		require.NoError(t, err)
+	suite.Run("GlobFile", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.Nil(t, aInfo.ParentCommit)
-		require.Equal(t, a.ID, bInfo.ParentCommit.ID)
-		require.Equal(t, a.ID, dInfo.ParentCommit.ID)
-		require.Equal(t, b.ID, cInfo.ParentCommit.ID)
-		require.Equal(t, b.ID, eInfo.ParentCommit.ID)
-		require.Equal(t, c.ID, fInfo.ParentCommit.ID)
-		require.ImagesEqual(t, []*pfs.Commit{b, d}, aInfo.ChildCommits, CommitToID)
-		require.ImagesEqual(t, []*pfs.Commit{c, e}, bInfo.ChildCommits, CommitToID)
-		require.ImagesEqual(t, []*pfs.Commit{f}, cInfo.ChildCommits, CommitToID)
-		require.Nil(t, dInfo.ChildCommits)
-		require.Nil(t, eInfo.ChildCommits)
-		require.Nil(t, fInfo.ChildCommits)
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
 
-		// Delete second commit in upstream2, which deletes b
-		require.NoError(t, env.PachClient.SquashCommitSet(squashMeCommit.ID))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
 
-		// Re-read commit info to get new parents/children
-		aInfo, err = env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+		// Write foo
+		numFiles := 100
+		_, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		cInfo, err = env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		for i := 0; i < numFiles; i++ {
+			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("file%d", i), strings.NewReader("1")))
+			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("dir1/file%d", i), strings.NewReader("2")))
+			require.NoError(t, env.PachClient.PutFile(commit, fmt.Sprintf("dir2/dir3/file%d", i), strings.NewReader("3")))
+		}
+		checks := func() {
+			fileInfos, err := env.PachClient.GlobFileAll(commit, "*")
+			require.NoError(t, err)
+			require.Equal(t, numFiles+2, len(fileInfos))
+			fileInfos, err = env.PachClient.GlobFileAll(commit, "file*")
+			require.NoError(t, err)
+			require.Equal(t, numFiles, len(fileInfos))
+			fileInfos, err = env.PachClient.GlobFileAll(commit, "dir1/*")
+			require.NoError(t, err)
+			require.Equal(t, numFiles, len(fileInfos))
+			fileInfos, err = env.PachClient.GlobFileAll(commit, "dir2/dir3/*")
+			require.NoError(t, err)
+			require.Equal(t, numFiles, len(fileInfos))
+			fileInfos, err = env.PachClient.GlobFileAll(commit, "*/*")
+			require.NoError(t, err)
+			require.Equal(t, numFiles+1, len(fileInfos))
+
+			var output strings.Builder
+			err = env.PachClient.GetFile(commit, "*", &output)
+			require.NoError(t, err)
+			require.Equal(t, numFiles*3, len(output.String()))
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "dir2/dir3/file1?", &output)
+			require.NoError(t, err)
+			require.Equal(t, 10, len(output.String()))
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "**file1?", &output)
+			require.NoError(t, err)
+			require.Equal(t, 30, len(output.String()))
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "**file1", &output)
+			require.NoError(t, err)
+			require.True(t, strings.Contains(output.String(), "1"))
+			require.True(t, strings.Contains(output.String(), "2"))
+			require.True(t, strings.Contains(output.String(), "3"))
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "**file1", &output)
+			require.NoError(t, err)
+			match, err := regexp.Match("[123]", []byte(output.String()))
+			require.NoError(t, err)
+			require.True(t, match)
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "dir?", &output)
+			require.NoError(t, err)
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "", &output)
+			require.NoError(t, err)
+
+			output = strings.Builder{}
+			err = env.PachClient.GetFile(commit, "garbage", &output)
+			require.YesError(t, err)
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		checks()
+
+		_, err = env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		dInfo, err = env.PachClient.InspectCommit("repo", d.Branch.Name, d.ID)
+
+		err = env.PachClient.DeleteFile(commit, "dir2/dir3/*")
 		require.NoError(t, err)
-		eInfo, err = env.PachClient.InspectCommit("repo", e.Branch.Name, e.ID)
+		err = env.PachClient.DeleteFile(commit, "dir?/*")
 		require.NoError(t, err)
-		fInfo, err = env.PachClient.InspectCommit("repo", f.Branch.Name, f.ID)
+		err = env.PachClient.DeleteFile(commit, "/")
 		require.NoError(t, err)
+		checks = func() {
+			fileInfos, err := env.PachClient.GlobFileAll(commit, "**")
+			require.NoError(t, err)
+			require.Equal(t, 0, len(fileInfos))
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		checks()
+	})
 
-		// The head of master should be 'c'
+	suite.Run("GlobFile2", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Make sure child/parent relationships are as shown in second diagram. Note
-		// that after 'b' is deleted, SquashCommitSet does not create a new commit (c has
-		// an alias for the deleted commit in upstream1)
-		commits, err = env.PachClient.ListCommit(client.NewRepo("repo"), nil, nil, 0)
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+
+		_, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.Equal(t, 5, len(commits))
-		require.Nil(t, aInfo.ParentCommit)
-		require.Equal(t, a.ID, cInfo.ParentCommit.ID)
-		require.Equal(t, a.ID, dInfo.ParentCommit.ID)
-		require.Equal(t, a.ID, eInfo.ParentCommit.ID)
-		require.Equal(t, c.ID, fInfo.ParentCommit.ID)
-		require.ImagesEqual(t, []*pfs.Commit{d, e, c}, aInfo.ChildCommits, CommitToID)
-		require.ImagesEqual(t, []*pfs.Commit{f}, cInfo.ChildCommits, CommitToID)
-		require.Nil(t, dInfo.ChildCommits)
-		require.Nil(t, eInfo.ChildCommits)
-		require.Nil(t, fInfo.ChildCommits)
+		expectedFileNames := []string{}
+		for i := 0; i < 100; i++ {
+			filename := fmt.Sprintf("/%d", i)
+			require.NoError(t, env.PachClient.PutFile(commit, filename, strings.NewReader(filename)))
 
-		masterInfo, err := env.PachClient.InspectBranch("repo", "master")
+			if strings.HasPrefix(filename, "/1") {
+				expectedFileNames = append(expectedFileNames, filename)
+			}
+		}
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+
+		actualFileNames := []string{}
+		require.NoError(t, env.PachClient.GlobFile(commit, "/1*", func(fileInfo *pfs.FileInfo) error {
+			actualFileNames = append(actualFileNames, fileInfo.File.Path)
+			return nil
+		}))
+
+		sort.Strings(expectedFileNames)
+		sort.Strings(actualFileNames)
+		require.Equal(t, expectedFileNames, actualFileNames)
+	})
+
+	suite.Run("GlobFile3", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit1, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.1", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.2", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.1", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.2", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
+		globFile := func(pattern string) []string {
+			var fis []*pfs.FileInfo
+			require.NoError(t, env.PachClient.GlobFile(commit1, pattern, func(fi *pfs.FileInfo) error {
+				fis = append(fis, fi)
+				return nil
+			}))
+			return finfosToPaths(fis)
+		}
+		assert.ElementsMatch(t, []string{"/dir1/file1.2", "/dir2/file2.2"}, globFile("**.2"))
+		assert.ElementsMatch(t, []string{"/dir1/file1.1", "/dir1/file1.2"}, globFile("/dir1/*"))
+		assert.ElementsMatch(t, []string{"/dir1/", "/dir2/"}, globFile("/*"))
+		assert.ElementsMatch(t, []string{"/"}, globFile("/"))
+	})
+
+	// GetFileGlobOrder checks that GetFile(glob) streams data back in the
+	// right order. GetFile(glob) is supposed to return a stream of data of the
+	// form file1 + file2 + .. + fileN, where file1 is the lexicographically lowest
+	// file matching 'glob', file2 is the next lowest, etc.
+	suite.Run("GetFileGlobOrder", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		var expected bytes.Buffer
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		for i := 0; i < 25; i++ {
+			next := fmt.Sprintf("%d,%d,%d,%d\n", 4*i, (4*i)+1, (4*i)+2, (4*i)+3)
+			expected.WriteString(next)
+			env.PachClient.PutFile(commit, fmt.Sprintf("/data/%010d", i), strings.NewReader(next))
+		}
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+
+		var output bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "/data/*", &output))
+		require.Equal(t, expected.String(), output.String())
+	})
+
+	suite.Run("ApplyWriteOrder", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+
+		// Test that fails when records are applied in lexicographic order
+		// rather than mod revision order.
+		_, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "/file", strings.NewReader("")))
+		err = env.PachClient.DeleteFile(commit, "/")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		fileInfos, err := env.PachClient.GlobFileAll(commit, "**")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(fileInfos))
+	})
+
+	suite.Run("Overwrite", func(t *testing.T) {
+		// TODO(2.0 optional): Implement put file split.
+		t.Skip("Put file split not implemented in V2")
+		//	t.Parallel()
+		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		//
+		//	if testing.Short() {
+		//		t.Skip("Skipping integration tests in short mode")
+		//	}
+		//
+		//	repo := "test"
+		//	require.NoError(t, env.PachClient.CreateRepo(repo))
+		//
+		//	// Write foo
+		//	_, err := env.PachClient.StartCommit(repo, "master")
+		//	require.NoError(t, err)
+		//	_, err = env.PachClient.PutFile(repo, "master", "file1", strings.NewReader("foo"))
+		//	require.NoError(t, err)
+		//	_, err = env.PachClient.PutFileSplit(repo, "master", "file2", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+		//	require.NoError(t, err)
+		//	_, err = env.PachClient.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, 0, false, strings.NewReader("foo\nbar\nbuz\n"))
+		//	require.NoError(t, err)
+		//	require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
+		//	_, err = env.PachClient.StartCommit(repo, "master")
+		//	require.NoError(t, err)
+		//	_, err = env.PachClient.PutFile(repo, "master", "file1", strings.NewReader("bar"))
+		//	require.NoError(t, err)
+		//	require.NoError(t, env.PachClient.PutFile(repo, "master", "file2", strings.NewReader("buzz")))
+		//	require.NoError(t, err)
+		//	_, err = env.PachClient.PutFileSplit(repo, "master", "file3", pfs.Delimiter_LINE, 0, 0, 0, true, strings.NewReader("0\n1\n2\n"))
+		//	require.NoError(t, err)
+		//	require.NoError(t, env.PachClient.FinishCommit(repo, "master"))
+		//	var buffer bytes.Buffer
+		//	require.NoError(t, env.PachClient.GetFile(repo, "master", "file1", &buffer))
+		//	require.Equal(t, "bar", buffer.String())
+		//	buffer.Reset()
+		//	require.NoError(t, env.PachClient.GetFile(repo, "master", "file2", &buffer))
+		//	require.Equal(t, "buzz", buffer.String())
+		//	fileInfos, err := env.PachClient.ListFileAll(repo, "master", "file3")
+		//	require.NoError(t, err)
+		//	require.Equal(t, 3, len(fileInfos))
+		//	for i := 0; i < 3; i++ {
+		//		buffer.Reset()
+		//		require.NoError(t, env.PachClient.GetFile(repo, "master", fmt.Sprintf("file3/%016x", i), &buffer))
+		//		require.Equal(t, fmt.Sprintf("%d\n", i), buffer.String())
+		//	}
+	})
+
+	suite.Run("CopyFile", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+
+		masterCommit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		numFiles := 5
+		for i := 0; i < numFiles; i++ {
+			require.NoError(t, env.PachClient.PutFile(masterCommit, fmt.Sprintf("files/%d", i), strings.NewReader(fmt.Sprintf("foo %d\n", i))))
+		}
+		require.NoError(t, env.PachClient.FinishCommit(repo, masterCommit.Branch.Name, masterCommit.ID))
+
+		for i := 0; i < numFiles; i++ {
+			_, err = env.PachClient.InspectFile(masterCommit, fmt.Sprintf("files/%d", i))
+			require.NoError(t, err)
+		}
+
+		otherCommit, err := env.PachClient.StartCommit(repo, "other")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.CopyFile(otherCommit, "files", masterCommit, "files", client.WithAppendCopyFile()))
+		require.NoError(t, env.PachClient.CopyFile(otherCommit, "file0", masterCommit, "files/0", client.WithAppendCopyFile()))
+		require.NoError(t, env.PachClient.FinishCommit(repo, otherCommit.Branch.Name, otherCommit.ID))
+
+		for i := 0; i < numFiles; i++ {
+			_, err = env.PachClient.InspectFile(otherCommit, fmt.Sprintf("files/%d", i))
+			require.NoError(t, err)
+		}
+		_, err = env.PachClient.InspectFile(otherCommit, "files/0")
+		require.NoError(t, err)
+	})
+
+	suite.Run("CopyFileAcrossRepos", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("src"))
+		require.NoError(t, env.PachClient.CreateRepo("dst"))
+		srcCommit := client.NewCommit("src", "master", "")
+		require.NoError(t, env.PachClient.PutFile(srcCommit, "file", strings.NewReader("foo\n")))
+		require.NoError(t, env.PachClient.PutFile(srcCommit, "dir/a", strings.NewReader("bar\n")))
+		srcInfo, err := env.PachClient.InspectCommit("src", "master", "")
+		require.NoError(t, err)
+
+		// Copying to a branch makes a commit, like PutFile.
+		dstCommit := client.NewCommit("dst", "master", "")
+		require.NoError(t, env.PachClient.CopyFile(dstCommit, "copy", srcCommit, "file"))
+		require.NoError(t, env.PachClient.CopyFile(dstCommit, "dir", srcCommit, "dir"))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(dstCommit, "copy", &buf))
+		require.Equal(t, "foo\n", buf.String())
+
+		fi, err := env.PachClient.InspectFile(dstCommit, "copy")
+		require.NoError(t, err)
+		require.Equal(t, "src", fi.CopiedFrom.Commit.Branch.Repo.Name)
+		require.Equal(t, srcInfo.Commit.ID, fi.CopiedFrom.Commit.ID)
+		require.Equal(t, "/file", fi.CopiedFrom.Path)
+		fi, err = env.PachClient.InspectFile(dstCommit, "dir/a")
+		require.NoError(t, err)
+		require.Equal(t, "/dir/a", fi.CopiedFrom.Path)
+
+		// Overwriting a copy forgets where it came from.
+		require.NoError(t, env.PachClient.PutFile(dstCommit, "copy", strings.NewReader("baz\n")))
+		fi, err = env.PachClient.InspectFile(dstCommit, "copy")
+		require.NoError(t, err)
+		require.Nil(t, fi.CopiedFrom)
+	})
+
+	suite.Run("CopyFileRange", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("repo"))
+		commit := client.NewCommit("repo", "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("foobarbaz")))
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/a", strings.NewReader("foo")))
+
+		var size int64
+		require.NoError(t, env.PachClient.ListFileChunks(commit, "file", func(chunk *pfs.FileChunk) error {
+			require.Equal(t, "/file", chunk.Path)
+			require.Equal(t, size, chunk.OffsetBytes)
+			size += chunk.SizeBytes
+			return nil
+		}))
+		require.Equal(t, int64(9), size)
+
+		require.NoError(t, env.PachClient.CopyFile(commit, "copy", commit, "file", client.WithRangeCopyFile(3, 3)))
+		require.NoError(t, env.PachClient.CopyFile(commit, "copy", commit, "file", client.WithAppendCopyFile(), client.WithRangeCopyFile(6, 0)))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "copy", &buf))
+		require.Equal(t, "barbaz", buf.String())
+
+		require.YesError(t, env.PachClient.CopyFile(commit, "copy", commit, "dir", client.WithRangeCopyFile(0, 1)))
+		require.YesError(t, env.PachClient.CopyFile(commit, "copy", commit, "missing", client.WithRangeCopyFile(0, 1)))
+	})
+
+	suite.Run("PushCommit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		// Small chunks, so that the big file is split into many of them.
+		settings := &pfs.RepoSettings{
+			ChunkAverageBits: 16,
+			Chunking:         &pfs.ChunkingParams{MinSizeBytes: 16 * units.KB, MaxSizeBytes: units.MB},
+		}
+		for _, repo := range []string{"local", "remote"} {
+			_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+				Repo:     client.NewRepo(repo),
+				Settings: settings,
+			})
+			require.NoError(t, err)
+		}
+		big := random.String(2 * units.MB)
+		local := client.NewCommit("local", "master", "")
+		require.NoError(t, env.PachClient.PutFile(local, "big", strings.NewReader(big)))
+		require.NoError(t, env.PachClient.PutFile(local, "small", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.PutFile(local, "exe", strings.NewReader("bar"), client.WithModePutFile(0755)))
+
+		remote := client.NewCommit("remote", "master", "")
+		checkFiles := func(expected map[string]string) {
+			fileInfos, err := env.PachClient.ListFileAll(remote, "/")
+			require.NoError(t, err)
+			require.Equal(t, len(expected), len(fileInfos))
+			for path, content := range expected {
+				var buf bytes.Buffer
+				require.NoError(t, env.PachClient.GetFile(remote, path, &buf))
+				require.Equal(t, content, buf.String())
+			}
+		}
+		pushed, err := env.PachClient.PushCommit(local, env.PachClient, remote.Branch)
+		require.NoError(t, err)
+		checkFiles(map[string]string{"big": big, "small": "foo", "exe": "bar"})
+		fi, err := env.PachClient.InspectFile(remote, "exe")
+		require.NoError(t, err)
+		require.Equal(t, uint32(0755), fi.Mode)
+
+		// The chunks of big that the remote has are copied from its own
+		// commit, rather than being sent again.
+		require.NoError(t, env.PachClient.PutFile(local, "big", strings.NewReader("more"), client.WithAppendPutFile()))
+		require.NoError(t, env.PachClient.DeleteFile(local, "small"))
+		require.NoError(t, env.PachClient.PutFile(local, "new", strings.NewReader("baz")))
+		_, err = env.PachClient.PushCommit(local, env.PachClient, remote.Branch)
+		require.NoError(t, err)
+		checkFiles(map[string]string{"big": big + "more", "exe": "bar", "new": "baz"})
+		fi, err = env.PachClient.InspectFile(remote, "big")
+		require.NoError(t, err)
+		require.NotNil(t, fi.CopiedFrom)
+		require.Equal(t, pushed.ID, fi.CopiedFrom.Commit.ID)
+
+		// A file whose content is unchanged is pushed again if its metadata
+		// changed.
+		require.NoError(t, env.PachClient.PutFile(local, "exe", &bytes.Buffer{}, client.WithAppendPutFile(), client.WithMetadataPutFile(map[string]string{"key": "value"})))
+		_, err = env.PachClient.PushCommit(local, env.PachClient, remote.Branch)
+		require.NoError(t, err)
+		checkFiles(map[string]string{"big": big + "more", "exe": "bar", "new": "baz"})
+		fi, err = env.PachClient.InspectFile(remote, "exe")
+		require.NoError(t, err)
+		require.Equal(t, "value", fi.Metadata["key"])
+
+		// A push that fails doesn't leave the remote branch with an open
+		// commit.
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo("limited"),
+			Settings: &pfs.RepoSettings{MaxFileSizeBytes: 1},
+		})
+		require.NoError(t, err)
+		_, err = env.PachClient.PushCommit(local, env.PachClient, client.NewBranch("limited", "master"))
+		require.YesError(t, err)
+		commitInfo, err := env.PachClient.InspectCommit("limited", "master", "")
+		require.NoError(t, err)
+		require.NotNil(t, commitInfo.Finished)
+		fileInfos, err := env.PachClient.ListFileAll(commitInfo.Commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(fileInfos))
+	})
+
+	suite.Run("CopyAndDeleteFileGlob", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+			for _, p := range []string{"data/a.csv", "data/b.txt", "data/x/c.csv", "data/x/y/d.csv", "data/x/y/e.txt"} {
+				if err := mf.PutFile(p, strings.NewReader(p)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		listPaths := func(glob string) []string {
+			var paths []string
+			require.NoError(t, env.PachClient.GlobFile(commit, glob, func(fi *pfs.FileInfo) error {
+				if fi.FileType == pfs.FileType_FILE {
+					paths = append(paths, fi.File.Path)
+				}
+				return nil
+			}))
+			return paths
+		}
+
+		// Matches keep their paths relative to the directory the glob starts in.
+		require.NoError(t, env.PachClient.CopyFile(commit, "out", commit, "data/**/*.csv"))
+		require.ElementsEqual(t, []string{"/out/a.csv", "/out/x/c.csv", "/out/x/y/d.csv"}, listPaths("out/**"))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "out/x/y/d.csv", &buf))
+		require.Equal(t, "data/x/y/d.csv", buf.String())
+		fi, err := env.PachClient.InspectFile(commit, "out/x/c.csv")
+		require.NoError(t, err)
+		require.Equal(t, "/data/x/c.csv", fi.CopiedFrom.Path)
+
+		// Matching directories are copied whole.
+		require.NoError(t, env.PachClient.CopyFile(commit, "dirs", commit, "data/?"))
+		require.ElementsEqual(t, []string{"/dirs/x/c.csv", "/dirs/x/y/d.csv", "/dirs/x/y/e.txt"}, listPaths("dirs/**"))
+
+		require.NoError(t, env.PachClient.DeleteFile(commit, "data/**/*.csv"))
+		require.ElementsEqual(t, []string{"/data/b.txt", "/data/x/y/e.txt"}, listPaths("data/**"))
+
+		// A glob that matches nothing deletes nothing.
+		require.NoError(t, env.PachClient.DeleteFile(commit, "data/**/*.json"))
+		require.ElementsEqual(t, []string{"/data/b.txt", "/data/x/y/e.txt"}, listPaths("data/**"))
+	})
+
+	suite.Run("MoveFile", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+			if err := mf.PutFile("a", strings.NewReader("foo\n")); err != nil {
+				return err
+			}
+			if err := mf.PutFile("dir/x", strings.NewReader("bar\n"), client.WithTagPutFile("t1")); err != nil {
+				return err
+			}
+			return mf.PutFile("dir/x", strings.NewReader("baz\n"), client.WithAppendPutFile(), client.WithTagPutFile("t2"))
+		}))
+
+		require.NoError(t, env.PachClient.MoveFile(commit, "dir", "new/dir"))
+		require.NoError(t, env.PachClient.MoveFile(commit, "a", "b"))
+		_, err := env.PachClient.InspectFile(commit, "dir/x")
+		require.YesError(t, err)
+		_, err = env.PachClient.InspectFile(commit, "a")
+		require.YesError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "new/dir/x", &buf))
+		require.Equal(t, "bar\nbaz\n", buf.String())
+		buf.Reset()
+		require.NoError(t, env.PachClient.GetFile(commit, "b", &buf))
+		require.Equal(t, "foo\n", buf.String())
+		// The tags of the moved files are kept.
+		require.NoError(t, env.PachClient.DeleteTag(commit, "/", "t1"))
+		buf.Reset()
+		require.NoError(t, env.PachClient.GetFile(commit, "new/dir/x", &buf))
+		require.Equal(t, "baz\n", buf.String())
+
+		err = env.PachClient.MoveFile(commit, "missing", "other")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsFileNotFoundErr(err))
+		require.YesError(t, env.PachClient.MoveFile(commit, "new", "new/sub"))
+	})
+
+	suite.Run("PropagateBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo1 := "test1"
+		require.NoError(t, env.PachClient.CreateRepo(repo1))
+		repo2 := "test2"
+		require.NoError(t, env.PachClient.CreateRepo(repo2))
+		require.NoError(t, env.PachClient.CreateBranch(repo2, "master", "", "", []*pfs.Branch{client.NewBranch(repo1, "master")}))
+		commit, err := env.PachClient.StartCommit(repo1, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit(repo1, commit.Branch.Name, commit.ID))
+		commits, err := env.PachClient.ListCommitByRepo(client.NewRepo(repo2))
+		require.NoError(t, err)
+		require.Equal(t, 2, len(commits))
+	})
+
+	// BackfillBranch implements the following DAG:
+	//
+	// A ──▶ C
+	//  ╲   ◀
+	//   ╲ ╱
+	//    ╳
+	//   ╱ ╲
+	// 	╱   ◀
+	// B ──▶ D
+	suite.Run("BackfillBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+		require.NoError(t, env.PachClient.CreateRepo("D"))
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("B", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		_, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		_, err = env.PachClient.StartCommit("B", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		commits, err := env.PachClient.ListCommitByRepo(client.NewRepo("C"))
+		require.NoError(t, err)
+		require.Equal(t, 3, len(commits))
+
+		// Create a branch in D, it should receive a single commit for the heads of `A` and `B`.
+		require.NoError(t, env.PachClient.CreateBranch("D", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("B", "master")}))
+		commits, err = env.PachClient.ListCommitByRepo(client.NewRepo("D"))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commits))
+	})
+
+	// UpdateBranch tests the following DAG:
+	//
+	// A ─▶ B ─▶ C
+	//
+	// Then updates it to:
+	//
+	// A ─▶ B ─▶ C
+	//      ▲
+	// D ───╯
+	//
+	suite.Run("UpdateBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+		require.NoError(t, env.PachClient.CreateRepo("D"))
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("B", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+
+		_, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+
+		_, err = env.PachClient.StartCommit("D", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("D", "master", ""))
+
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master"), client.NewBranch("D", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+		cCommitInfo, err := env.PachClient.InspectCommit("C", "master", "")
+		require.NoError(t, err)
+
+		commitInfos, err := env.PachClient.InspectCommitSet(cCommitInfo.Commit.ID)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(commitInfos))
+	})
+
+	suite.Run("BranchProvenance", func(t *testing.T) {
+		t.Parallel()
+
+		tests := [][]struct {
+			name       string
+			directProv []string
+			err        bool
+			expectProv map[string][]string
+			expectSubv map[string][]string
+		}{{
+			{name: "A"},
+			{name: "B", directProv: []string{"A"}},
+			{name: "C", directProv: []string{"B"}},
+			{name: "D", directProv: []string{"C", "A"},
+				expectProv: map[string][]string{"A": nil, "B": {"A"}, "C": {"B", "A"}, "D": {"A", "B", "C"}},
+				expectSubv: map[string][]string{"A": {"B", "C", "D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
+			// A ─▶ B ─▶ C ─▶ D
+			// ╰─────────────⬏
+			{name: "B",
+				expectProv: map[string][]string{"A": {}, "B": {}, "C": {"B"}, "D": {"A", "B", "C"}},
+				expectSubv: map[string][]string{"A": {"D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
+			// A    B ─▶ C ─▶ D
+			// ╰─────────────⬏
+		}, {
+			{name: "A"},
+			{name: "B", directProv: []string{"A"}},
+			{name: "C", directProv: []string{"A", "B"}},
+			{name: "D", directProv: []string{"C"},
+				expectProv: map[string][]string{"A": {}, "B": {"A"}, "C": {"A", "B"}, "D": {"A", "B", "C"}},
+				expectSubv: map[string][]string{"A": {"B", "C", "D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
+			// A ─▶ B ─▶ C ─▶ D
+			// ╰────────⬏
+			{name: "C", directProv: []string{"B"},
+				expectProv: map[string][]string{"A": {}, "B": {"A"}, "C": {"A", "B"}, "D": {"A", "B", "C"}},
+				expectSubv: map[string][]string{"A": {"B", "C", "D"}, "B": {"C", "D"}, "C": {"D"}, "D": {}}},
+			// A ─▶ B ─▶ C ─▶ D
+		}, {
+			{name: "A"},
+			{name: "B"},
+			{name: "C", directProv: []string{"A", "B"}},
+			{name: "D", directProv: []string{"C"}},
+			{name: "E", directProv: []string{"A", "D"},
+				expectProv: map[string][]string{"A": {}, "B": {}, "C": {"A", "B"}, "D": {"A", "B", "C"}, "E": {"A", "B", "C", "D"}},
+				expectSubv: map[string][]string{"A": {"C", "D", "E"}, "B": {"C", "D", "E"}, "C": {"D", "E"}, "D": {"E"}, "E": {}}},
+			// A    B ─▶ C ─▶ D ─▶ E
+			// ├────────⬏          ▲
+			// ╰───────────────────╯
+			{name: "C", directProv: []string{"B"},
+				expectProv: map[string][]string{"A": {}, "B": {}, "C": {"B"}, "D": {"B", "C"}, "E": {"A", "B", "C", "D"}},
+				expectSubv: map[string][]string{"A": {"E"}, "B": {"C", "D", "E"}, "C": {"D", "E"}, "D": {"E"}, "E": {}}},
+			// A    B ─▶ C ─▶ D ─▶ E
+			// ╰──────────────────⬏
+		}, {
+			{name: "A", directProv: []string{"A"}, err: true},
+			{name: "A"},
+			{name: "A", directProv: []string{"A"}, err: true},
+			{name: "B", directProv: []string{"A"}},
+			{name: "A", directProv: []string{"B"}, err: true},
+		},
+		}
+		for i, test := range tests {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				t.Parallel()
+				env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+				for _, repo := range []string{"A", "B", "C", "D", "E"} {
+					require.NoError(t, env.PachClient.CreateRepo(repo))
+				}
+				for iStep, step := range test {
+					var provenance []*pfs.Branch
+					for _, repo := range step.directProv {
+						provenance = append(provenance, client.NewBranch(repo, "master"))
+					}
+					err := env.PachClient.CreateBranch(step.name, "master", "", "", provenance)
+					if step.err {
+						require.YesError(t, err, "%d> CreateBranch(\"%s\", %v)", iStep, step.name, step.directProv)
+					} else {
+						require.NoError(t, err, "%d> CreateBranch(\"%s\", %v)", iStep, step.name, step.directProv)
+					}
+					require.NoError(t, env.PachClient.FsckFastExit())
+					for repo, expectedProv := range step.expectProv {
+						bi, err := env.PachClient.InspectBranch(repo, "master")
+						require.NoError(t, err)
+						sort.Strings(expectedProv)
+						require.Equal(t, len(expectedProv), len(bi.Provenance))
+						for _, b := range bi.Provenance {
+							i := sort.SearchStrings(expectedProv, b.Name)
+							if i >= len(expectedProv) || expectedProv[i] != b.Name {
+								t.Fatalf("provenance for %s contains: %s, but should only contain: %v", repo, b, expectedProv)
+							}
+						}
+					}
+					for repo, expectedSubv := range step.expectSubv {
+						bi, err := env.PachClient.InspectBranch(repo, "master")
+						require.NoError(t, err)
+						sort.Strings(expectedSubv)
+						require.Equal(t, len(expectedSubv), len(bi.Subvenance))
+						for _, b := range bi.Subvenance {
+							i := sort.SearchStrings(expectedSubv, b.Name)
+							if i >= len(expectedSubv) || expectedSubv[i] != b.Name {
+								t.Fatalf("subvenance for %s contains: %s, but should only contain: %v", repo, b, expectedSubv)
+							}
+						}
+					}
+				}
+			})
+		}
+
+		// t.Run("1", func(t *testing.T) {
+		// 	require.NoError(t, env.PachClient.CreateRepo("A"))
+		// 	require.NoError(t, env.PachClient.CreateRepo("B"))
+		// 	require.NoError(t, env.PachClient.CreateRepo("C"))
+		// 	require.NoError(t, env.PachClient.CreateRepo("D"))
+
+		// 	require.NoError(t, env.PachClient.CreateBranch("B", "master", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		// 	require.NoError(t, env.PachClient.CreateBranch("C", "master", "", []*pfs.Branch{client.NewBranch("B", "master")}))
+		// 	require.NoError(t, env.PachClient.CreateBranch("D", "master", "", []*pfs.Branch{client.NewBranch("C", "master"), client.NewBranch("A", "master")}))
+
+		// 	aMaster, err := env.PachClient.InspectBranch("A", "master")
+		// 	require.NoError(t, err)
+		// 	require.Equal(t, 3, len(aMaster.Subvenance))
+
+		// 	cMaster, err := env.PachClient.InspectBranch("C", "master")
+		// 	require.NoError(t, err)
+		// 	require.Equal(t, 2, len(cMaster.Provenance))
+
+		// 	dMaster, err := env.PachClient.InspectBranch("D", "master")
+		// 	require.NoError(t, err)
+		// 	require.Equal(t, 3, len(dMaster.Provenance))
+
+		// 	require.NoError(t, env.PachClient.CreateBranch("B", "master", "", nil))
+
+		// 	aMaster, err = env.PachClient.InspectBranch("A", "master")
+		// 	require.NoError(t, err)
+		// 	require.Equal(t, 1, len(aMaster.Subvenance))
+
+		// 	cMaster, err = env.PachClient.InspectBranch("C", "master")
+		// 	require.NoError(t, err)
+		// 	require.Equal(t, 1, len(cMaster.Provenance))
+
+		// 	dMaster, err = env.PachClient.InspectBranch("D", "master")
+		// 	require.NoError(t, err)
+		// 	require.Equal(t, 3, len(dMaster.Provenance))
+		// })
+		// t.Run("2", func(t *testing.T) {
+		// 	require.NoError(t, env.PachClient.CreateRepo("A"))
+		// 	require.NoError(t, env.PachClient.CreateRepo("B"))
+		// 	require.NoError(t, env.PachClient.CreateRepo("C"))
+		// 	require.NoError(t, env.PachClient.CreateRepo("D"))
+
+		// 	require.NoError(t, env.PachClient.CreateBranch("B", "master", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		// 	require.NoError(t, env.PachClient.CreateBranch("C", "master", "", []*pfs.Branch{client.NewBranch("B", "master"), client.NewBranch("A", "master")}))
+		// 	require.NoError(t, env.PachClient.CreateBranch("D", "master", "", []*pfs.Branch{client.NewBranch("C", "master")}))
+		// })
+	})
+
+	suite.Run("ChildCommits", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateBranch("A", "master", "", "", nil))
+		aRepo := client.NewRepo("A")
+
+		// Small helper function wrapping env.PachClient.InspectCommit, because it's called a lot
+		inspect := func(repo, branch, commit string) *pfs.CommitInfo {
+			commitInfo, err := env.PachClient.InspectCommit(repo, branch, commit)
+			require.NoError(t, err)
+			return commitInfo
+		}
+
+		commit1, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		commits, err := env.PachClient.ListCommit(aRepo, aRepo.NewCommit("master", ""), nil, 0)
+		require.NoError(t, err)
+		t.Logf("%v", commits)
+		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
+
+		commit2, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+
+		// Inspect commit 1 and 2
+		commit1Info, commit2Info := inspect("A", commit1.Branch.Name, commit1.ID), inspect("A", commit2.Branch.Name, commit2.ID)
+		require.Equal(t, commit1.ID, commit2Info.ParentCommit.ID)
+		require.ImagesEqual(t, []*pfs.Commit{commit2}, commit1Info.ChildCommits, CommitToID)
+
+		// Delete commit 2 and make sure it's removed from commit1.ChildCommits
+		require.NoError(t, env.PachClient.SquashCommitSet(commit2.ID))
+		commit1Info = inspect("A", commit1.Branch.Name, commit1.ID)
+		require.ElementsEqualUnderFn(t, nil, commit1Info.ChildCommits, CommitToID)
+
+		// Re-create commit2, and create a third commit also extending from commit1.
+		// Make sure both appear in commit1.children
+		commit2, err = env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("A", commit2.Branch.Name, commit2.ID))
+		commit3, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Branch: client.NewBranch("A", "foo"),
+			Parent: commit1,
+		})
+		require.NoError(t, err)
+		commit1Info = inspect("A", commit1.Branch.Name, commit1.ID)
+		require.ImagesEqual(t, []*pfs.Commit{commit2, commit3}, commit1Info.ChildCommits, CommitToID)
+
+		// Delete commit3 and make sure commit1 has the right children
+		require.NoError(t, env.PachClient.SquashCommitSet(commit3.ID))
+		commit1Info = inspect("A", commit1.Branch.Name, commit1.ID)
+		require.ImagesEqual(t, []*pfs.Commit{commit2}, commit1Info.ChildCommits, CommitToID)
+
+		// Create a downstream branch in a different repo, then commit to "A" and
+		// make sure the new HEAD commit is in the parent's children (i.e. test
+		// propagateBranches)
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{
+			client.NewBranch("A", "master"),
+		}))
+		bCommit1 := inspect("B", "master", "")
+		commit3, err = env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		env.PachClient.FinishCommit("A", commit3.Branch.Name, commit3.ID)
+		// Re-inspect bCommit1, which has been updated by StartCommit
+		bCommit1, bCommit2 := inspect("B", bCommit1.Commit.Branch.Name, bCommit1.Commit.ID), inspect("B", "master", "")
+		require.Equal(t, bCommit1.Commit.ID, bCommit2.ParentCommit.ID)
+		require.ImagesEqual(t, []*pfs.Commit{bCommit2.Commit}, bCommit1.ChildCommits, CommitToID)
+
+		// create a new branch in a different repo, then update it so that two commits
+		// are generated. Make sure the second commit is in the parent's children
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{
+			client.NewBranch("A", "master"),
+		}))
+		cCommit1 := inspect("C", "master", "") // Get new commit's ID
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{
+			client.NewBranch("A", "master"),
+			client.NewBranch("B", "master"),
+		}))
+		// Re-inspect cCommit1, which has been updated by CreateBranch
+		cCommit1, cCommit2 := inspect("C", cCommit1.Commit.Branch.Name, cCommit1.Commit.ID), inspect("C", "master", "")
+		require.Equal(t, cCommit1.Commit.ID, cCommit2.ParentCommit.ID)
+		require.ImagesEqual(t, []*pfs.Commit{cCommit2.Commit}, cCommit1.ChildCommits, CommitToID)
+	})
+
+	suite.Run("StartCommitFork", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		commit, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		env.PachClient.FinishCommit("A", commit.Branch.Name, commit.ID)
+		commit2, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Branch: client.NewBranch("A", "master2"),
+			Parent: client.NewCommit("A", "master", ""),
+		})
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("A", commit2.Branch.Name, commit2.ID))
+
+		aRepo := client.NewRepo("A")
+		commitInfos, err := env.PachClient.ListCommit(aRepo, nil, nil, 0)
+		require.NoError(t, err)
+		commits := []*pfs.Commit{}
+		for _, ci := range commitInfos {
+			commits = append(commits, ci.Commit)
+		}
+		require.ImagesEqual(t, []*pfs.Commit{commit, commit2}, commits, CommitToID)
+	})
+
+	// UpdateBranchNewOutputCommit tests the following corner case:
+	// A ──▶ C
+	// B
+	//
+	// Becomes:
+	//
+	// A  ╭▶ C
+	// B ─╯
+	//
+	// C should create a new output commit to process its unprocessed inputs in B
+	suite.Run("UpdateBranchNewOutputCommit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+		require.NoError(t, env.PachClient.CreateBranch("A", "master", "", "", nil))
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", nil))
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "",
+			[]*pfs.Branch{client.NewBranch("A", "master")}))
+
+		// Create commits in A and B
+		commit, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("A", commit.Branch.Name, commit.ID))
+		commit, err = env.PachClient.StartCommit("B", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("B", commit.Branch.Name, commit.ID))
+
+		// Check for first output commit in C (plus the old empty head commit)
+		cRepo := client.NewRepo("C")
+		commits, err := env.PachClient.ListCommit(cRepo, cRepo.NewCommit("master", ""), nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(commits))
+
+		// Update the provenance of C/master and make sure it creates a new commit
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "",
+			[]*pfs.Branch{client.NewBranch("B", "master")}))
+		commits, err = env.PachClient.ListCommit(cRepo, cRepo.NewCommit("master", ""), nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(commits))
+	})
+
+	// SquashCommitSetMultipleChildrenSingleCommit tests that when you have the
+	// following commit graph in a repo:
+	// c   d
+	//  ↘ ↙
+	//   b
+	//   ↓
+	//   a
+	//
+	// and you delete commit 'b', what you end up with is:
+	//
+	// c   d
+	//  ↘ ↙
+	//   a
+	suite.Run("SquashCommitSetMultipleChildrenSingleCommit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("repo"))
+		require.NoError(t, env.PachClient.CreateBranch("repo", "master", "", "", nil))
+
+		// Create commits 'a' and 'b'
+		a, err := env.PachClient.StartCommit("repo", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("repo", a.Branch.Name, a.ID))
+		b, err := env.PachClient.StartCommit("repo", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("repo", b.Branch.Name, b.ID))
+
+		// Create 'd' by aliasing 'b' into another branch (force a new CommitSet rather than extending 'b')
+		_, err = env.PachClient.PfsAPIClient.CreateBranch(env.PachClient.Ctx(), &pfs.CreateBranchRequest{
+			Branch:       client.NewBranch("repo", "master2"),
+			Head:         client.NewCommit("repo", "master", ""),
+			NewCommitSet: true,
+		})
+		require.NoError(t, err)
+
+		// Create 'c'
+		c, err := env.PachClient.StartCommit("repo", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("repo", c.Branch.Name, c.ID))
+
+		// Collect info re: a, b, c, and d, and make sure that the parent/child
+		// relationships are all correct
+		aInfo, err := env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+		require.NoError(t, err)
+		bInfo, err := env.PachClient.InspectCommit("repo", b.Branch.Name, b.ID)
+		require.NoError(t, err)
+		cInfo, err := env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		require.NoError(t, err)
+		dInfo, err := env.PachClient.InspectCommit("repo", "master2", "")
+		require.NoError(t, err)
+		d := dInfo.Commit
+
+		require.NotNil(t, aInfo.ParentCommit) // this should be the empty default head
+		require.ImagesEqual(t, []*pfs.Commit{b}, aInfo.ChildCommits, CommitToID)
+
+		require.Equal(t, a.ID, bInfo.ParentCommit.ID)
+		require.ImagesEqual(t, []*pfs.Commit{c, d}, bInfo.ChildCommits, CommitToID)
+
+		require.Equal(t, b.ID, cInfo.ParentCommit.ID)
+		require.Equal(t, 0, len(cInfo.ChildCommits))
+
+		require.Equal(t, b.ID, dInfo.ParentCommit.ID)
+		require.Equal(t, 0, len(dInfo.ChildCommits))
+
+		// Delete commit 'b'
+		env.PachClient.SquashCommitSet(b.ID)
+
+		// Collect info re: a, c, and d, and make sure that the parent/child
+		// relationships are still correct
+		aInfo, err = env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+		require.NoError(t, err)
+		cInfo, err = env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		require.NoError(t, err)
+		dInfo, err = env.PachClient.InspectCommit("repo", d.Branch.Name, d.ID)
+		require.NoError(t, err)
+
+		require.NotNil(t, aInfo.ParentCommit)
+		require.ImagesEqual(t, []*pfs.Commit{c, d}, aInfo.ChildCommits, CommitToID)
+
+		require.Equal(t, a.ID, cInfo.ParentCommit.ID)
+		require.Equal(t, 0, len(cInfo.ChildCommits))
+
+		require.Equal(t, a.ID, dInfo.ParentCommit.ID)
+		require.Equal(t, 0, len(dInfo.ChildCommits))
+	})
+
+	// Tests that when you have the following commit graph in a *downstream* repo:
+	//
+	//    ↙f
+	//   c
+	//   ↓↙e
+	//   b
+	//   ↓↙d
+	//   a
+	//
+	// and you delete commits 'b' and 'c' (in a single call), what you end up with
+	// is:
+	//     f
+	//     ↓
+	// d e c
+	//  ↘↓↙
+	//   a
+	// This makes sure that multiple live children are re-pointed at a live parent
+	// if appropriate
+	suite.Run("SquashCommitSetMultiLevelChildren", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("upstream1"))
+		require.NoError(t, env.PachClient.CreateRepo("upstream2"))
+		// commit to both inputs
+		_, err := env.PachClient.StartCommit("upstream1", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("upstream1", "master", ""))
+		_, err = env.PachClient.StartCommit("upstream2", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("upstream2", "master", ""))
+
+		// Create main repo (will have the commit graphs above)
+		require.NoError(t, env.PachClient.CreateRepo("repo"))
+		require.NoError(t, env.PachClient.CreateBranch("repo", "master", "", "", []*pfs.Branch{
+			client.NewBranch("upstream1", "master"),
+			client.NewBranch("upstream2", "master"),
+		}))
+		repoProto := client.NewRepo("repo")
+
+		// Create commit 'a'
+		aInfo, err := env.PachClient.InspectCommit("repo", "master", "")
+		require.NoError(t, err)
+		a := aInfo.Commit
+		require.NoError(t, env.PachClient.FinishCommit("repo", a.Branch.Name, a.ID))
+
+		// Create 'd'
+		resp, err := env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Branch: client.NewBranch("repo", "fod"),
+			Parent: a,
+		})
+		require.NoError(t, err)
+		d := client.NewCommit("repo", resp.Branch.Name, resp.ID)
+		require.NoError(t, env.PachClient.FinishCommit("repo", resp.Branch.Name, resp.ID))
+
+		// Create 'b'
+		// (a & b have same prov commit in upstream2, so this is the commit that will
+		// be deleted, as both b and c are provenant on it)
+		squashMeCommit, err := env.PachClient.StartCommit("upstream1", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("upstream1", "master", ""))
+		bInfo, err := env.PachClient.InspectCommit("repo", "master", "")
+		require.NoError(t, err)
+		b := bInfo.Commit
+		require.NoError(t, env.PachClient.FinishCommit("repo", b.Branch.Name, b.ID))
+		require.Equal(t, b.ID, squashMeCommit.ID)
+
+		// Create 'e'
+		resp, err = env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Branch: client.NewBranch("repo", "foe"),
+			Parent: b,
+		})
+		require.NoError(t, err)
+		e := client.NewCommit("repo", resp.Branch.Name, resp.ID)
+		require.NoError(t, env.PachClient.FinishCommit("repo", resp.Branch.Name, resp.ID))
+
+		// Create 'c'
+		_, err = env.PachClient.StartCommit("upstream2", "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.FinishCommit("upstream2", "master", ""))
+		cInfo, err := env.PachClient.InspectCommit("repo", "master", "")
+		require.NoError(t, err)
+		c := cInfo.Commit
+		require.NoError(t, env.PachClient.FinishCommit("repo", c.Branch.Name, c.ID))
+
+		// Create 'f'
+		resp, err = env.PachClient.PfsAPIClient.StartCommit(env.PachClient.Ctx(), &pfs.StartCommitRequest{
+			Branch: client.NewBranch("repo", "fof"),
+			Parent: c,
+		})
+		require.NoError(t, err)
+		f := client.NewCommit("repo", resp.Branch.Name, resp.ID)
+		require.NoError(t, env.PachClient.FinishCommit("repo", resp.Branch.Name, resp.ID))
+
+		// Make sure child/parent relationships are as shown in first diagram
+		commits, err := env.PachClient.ListCommit(repoProto, nil, nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 6, len(commits))
+		aInfo, err = env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+		require.NoError(t, err)
+		bInfo, err = env.PachClient.InspectCommit("repo", b.Branch.Name, b.ID)
+		require.NoError(t, err)
+		cInfo, err = env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		require.NoError(t, err)
+		dInfo, err := env.PachClient.InspectCommit("repo", d.Branch.Name, d.ID)
+		require.NoError(t, err)
+		eInfo, err := env.PachClient.InspectCommit("repo", e.Branch.Name, e.ID)
+		require.NoError(t, err)
+		fInfo, err := env.PachClient.InspectCommit("repo", f.Branch.Name, f.ID)
+		require.NoError(t, err)
+
+		require.Nil(t, aInfo.ParentCommit)
+		require.Equal(t, a.ID, bInfo.ParentCommit.ID)
+		require.Equal(t, a.ID, dInfo.ParentCommit.ID)
+		require.Equal(t, b.ID, cInfo.ParentCommit.ID)
+		require.Equal(t, b.ID, eInfo.ParentCommit.ID)
+		require.Equal(t, c.ID, fInfo.ParentCommit.ID)
+		require.ImagesEqual(t, []*pfs.Commit{b, d}, aInfo.ChildCommits, CommitToID)
+		require.ImagesEqual(t, []*pfs.Commit{c, e}, bInfo.ChildCommits, CommitToID)
+		require.ImagesEqual(t, []*pfs.Commit{f}, cInfo.ChildCommits, CommitToID)
+		require.Nil(t, dInfo.ChildCommits)
+		require.Nil(t, eInfo.ChildCommits)
+		require.Nil(t, fInfo.ChildCommits)
+
+		// Delete second commit in upstream2, which deletes b
+		require.NoError(t, env.PachClient.SquashCommitSet(squashMeCommit.ID))
+
+		// Re-read commit info to get new parents/children
+		aInfo, err = env.PachClient.InspectCommit("repo", a.Branch.Name, a.ID)
+		require.NoError(t, err)
+		cInfo, err = env.PachClient.InspectCommit("repo", c.Branch.Name, c.ID)
+		require.NoError(t, err)
+		dInfo, err = env.PachClient.InspectCommit("repo", d.Branch.Name, d.ID)
+		require.NoError(t, err)
+		eInfo, err = env.PachClient.InspectCommit("repo", e.Branch.Name, e.ID)
+		require.NoError(t, err)
+		fInfo, err = env.PachClient.InspectCommit("repo", f.Branch.Name, f.ID)
+		require.NoError(t, err)
+
+		// The head of master should be 'c'
+
+		// Make sure child/parent relationships are as shown in second diagram. Note
+		// that after 'b' is deleted, SquashCommitSet does not create a new commit (c has
+		// an alias for the deleted commit in upstream1)
+		commits, err = env.PachClient.ListCommit(client.NewRepo("repo"), nil, nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, 5, len(commits))
+		require.Nil(t, aInfo.ParentCommit)
+		require.Equal(t, a.ID, cInfo.ParentCommit.ID)
+		require.Equal(t, a.ID, dInfo.ParentCommit.ID)
+		require.Equal(t, a.ID, eInfo.ParentCommit.ID)
+		require.Equal(t, c.ID, fInfo.ParentCommit.ID)
+		require.ImagesEqual(t, []*pfs.Commit{d, e, c}, aInfo.ChildCommits, CommitToID)
+		require.ImagesEqual(t, []*pfs.Commit{f}, cInfo.ChildCommits, CommitToID)
+		require.Nil(t, dInfo.ChildCommits)
+		require.Nil(t, eInfo.ChildCommits)
+		require.Nil(t, fInfo.ChildCommits)
+
+		masterInfo, err := env.PachClient.InspectBranch("repo", "master")
+		require.NoError(t, err)
+		require.Equal(t, c.ID, masterInfo.Head.ID)
+	})
+
+	suite.Run("CommitState", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		// two input repos, one with many commits (logs), and one with few (schema)
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+
+		// Start a commit on A/master, this will create a non-ready commit on B/master.
+		_, err := env.PachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		_, err = env.PachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+			Commit: client.NewCommit("B", "master", ""),
+			Wait:   pfs.CommitState_READY,
+		})
+		require.YesError(t, err)
+
+		// Finish the commit on A/master, that will make the B/master ready.
+		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		_, err = env.PachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+			Commit: client.NewCommit("B", "master", ""),
+			Wait:   pfs.CommitState_READY,
+		})
+		require.NoError(t, err)
+
+		// Create a new branch C/master with A/master as provenance. It should start out ready.
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		_, err = env.PachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
+			Commit: client.NewCommit("C", "master", ""),
+			Wait:   pfs.CommitState_READY,
+		})
+		require.NoError(t, err)
+	})
+
+	suite.Run("SubscribeStates", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("A"))
+		require.NoError(t, env.PachClient.CreateRepo("B"))
+		require.NoError(t, env.PachClient.CreateRepo("C"))
+
+		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
+		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("B", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
+
+		ctx, cancel := context.WithCancel(env.PachClient.Ctx())
+		defer cancel()
+		pachClient := env.PachClient.WithCtx(ctx)
+
+		var readyCommits int64
+		go func() {
+			pachClient.SubscribeCommit(client.NewRepo("B"), "master", "", pfs.CommitState_READY, func(ci *pfs.CommitInfo) error {
+				atomic.AddInt64(&readyCommits, 1)
+				return nil
+			})
+		}()
+		go func() {
+			pachClient.SubscribeCommit(client.NewRepo("C"), "master", "", pfs.CommitState_READY, func(ci *pfs.CommitInfo) error {
+				atomic.AddInt64(&readyCommits, 1)
+				return nil
+			})
+		}()
+		_, err := pachClient.StartCommit("A", "master")
+		require.NoError(t, err)
+		require.NoError(t, pachClient.FinishCommit("A", "master", ""))
+
+		require.NoErrorWithinTRetry(t, time.Second*10, func() error {
+			if atomic.LoadInt64(&readyCommits) != 1 {
+				return errors.Errorf("wrong number of ready commits")
+			}
+			return nil
+		})
+
+		require.NoError(t, pachClient.FinishCommit("B", "master", ""))
+
+		require.NoErrorWithinTRetry(t, time.Second*10, func() error {
+			if atomic.LoadInt64(&readyCommits) != 2 {
+				return errors.Errorf("wrong number of ready commits")
+			}
+			return nil
+		})
+	})
+
+	suite.Run("PutFileCommit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		numFiles := 25
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+
+		var eg errgroup.Group
+		for i := 0; i < numFiles; i++ {
+			i := i
+			eg.Go(func() error {
+				return env.PachClient.PutFile(commit, fmt.Sprintf("%d", i), strings.NewReader(fmt.Sprintf("%d", i)))
+			})
+		}
+		require.NoError(t, eg.Wait())
+
+		for i := 0; i < numFiles; i++ {
+			var b bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(commit, fmt.Sprintf("%d", i), &b))
+			require.Equal(t, fmt.Sprintf("%d", i), b.String())
+		}
+
+		bi, err := env.PachClient.InspectBranch(repo, "master")
+		require.NoError(t, err)
+
+		eg = errgroup.Group{}
+		for i := 0; i < numFiles; i++ {
+			i := i
+			eg.Go(func() error {
+				return env.PachClient.CopyFile(commit, fmt.Sprintf("%d", (i+1)%numFiles), bi.Head, fmt.Sprintf("%d", i))
+			})
+		}
+		require.NoError(t, eg.Wait())
+
+		for i := 0; i < numFiles; i++ {
+			var b bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(commit, fmt.Sprintf("%d", (i+1)%numFiles), &b))
+			require.Equal(t, fmt.Sprintf("%d", i), b.String())
+		}
+
+		eg = errgroup.Group{}
+		for i := 0; i < numFiles; i++ {
+			i := i
+			eg.Go(func() error {
+				return env.PachClient.DeleteFile(commit, fmt.Sprintf("%d", i))
+			})
+		}
+		require.NoError(t, eg.Wait())
+
+		fileInfos, err := env.PachClient.ListFileAll(commit, "")
+		require.NoError(t, err)
+		require.Equal(t, 0, len(fileInfos))
+	})
+
+	suite.Run("PutFileCommitConcurrentStart", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		numFiles := 25
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		master := client.NewCommit(repo, "master", "")
+
+		// Commits started on the branch while files are being put go into
+		// the open commit, rather than failing the put.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var commitEg errgroup.Group
+		commitEg.Go(func() error {
+			for ctx.Err() == nil {
+				commit, err := env.PachClient.StartCommit(repo, "master")
+				if err != nil {
+					return err
+				}
+				time.Sleep(10 * time.Millisecond)
+				if err := env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		var eg errgroup.Group
+		for i := 0; i < numFiles; i++ {
+			i := i
+			eg.Go(func() error {
+				return env.PachClient.PutFile(master, fmt.Sprintf("%d", i), strings.NewReader(fmt.Sprintf("%d", i)))
+			})
+		}
+		require.NoError(t, eg.Wait())
+		cancel()
+		require.NoError(t, commitEg.Wait())
+
+		_, err := env.PachClient.WaitCommit(repo, "master", "")
+		require.NoError(t, err)
+		for i := 0; i < numFiles; i++ {
+			var b bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(master, fmt.Sprintf("%d", i), &b))
+			require.Equal(t, fmt.Sprintf("%d", i), b.String())
+		}
+	})
+
+	suite.Run("PutFileCommitNilBranch", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
+		commit := client.NewCommit(repo, "master", "")
+
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("file")))
+	})
+
+	suite.Run("PutFileCommitOverwrite", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		numFiles := 5
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+
+		for i := 0; i < numFiles; i++ {
+			require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader(fmt.Sprintf("%d", i))))
+		}
+
+		var b bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "file", &b))
+		require.Equal(t, fmt.Sprintf("%d", numFiles-1), b.String())
+	})
+
+	suite.Run("WalkFile", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/bar", strings.NewReader("bar")))
+		require.NoError(t, env.PachClient.PutFile(commit, "dir/dir2/buzz", strings.NewReader("buzz")))
+		require.NoError(t, env.PachClient.PutFile(commit, "foo", strings.NewReader("foo")))
+
+		expectedPaths := []string{"/", "/dir/", "/dir/bar", "/dir/dir2/", "/dir/dir2/buzz", "/foo"}
+		checks := func() {
+			i := 0
+			require.NoError(t, env.PachClient.WalkFile(commit, "", func(fi *pfs.FileInfo) error {
+				require.Equal(t, expectedPaths[i], fi.File.Path)
+				i++
+				return nil
+			}))
+			require.Equal(t, len(expectedPaths), i)
+		}
+		checks()
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		checks()
+	})
+
+	suite.Run("WalkFile2", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "WalkFile2"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit1, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.1", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.2", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.1", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.2", &bytes.Buffer{}))
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
+		walkFile := func(path string) []string {
+			var fis []*pfs.FileInfo
+			require.NoError(t, env.PachClient.WalkFile(commit1, path, func(fi *pfs.FileInfo) error {
+				fis = append(fis, fi)
+				return nil
+			}))
+			return finfosToPaths(fis)
+		}
+		assert.ElementsMatch(t, []string{"/dir1/", "/dir1/file1.1", "/dir1/file1.2"}, walkFile("/dir1"))
+		assert.ElementsMatch(t, []string{"/dir1/file1.1"}, walkFile("/dir1/file1.1"))
+		assert.Len(t, walkFile("/"), 7)
+	})
+
+	suite.Run("WalkFileEmpty", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		latestCommit := client.NewCommit(repo, "master", "")
+		checks := func() {
+			cb := func(fi *pfs.FileInfo) error {
+				if assert.Equal(t, fi.FileType, pfs.FileType_DIR) && assert.Equal(t, fi.File.Path, "/") {
+					return nil
+				}
+				return errors.New("should not have returned any file results for an empty commit")
+			}
+			checkNotFound := func(path string) {
+				err := env.PachClient.WalkFile(latestCommit, path, cb)
+				require.YesError(t, err)
+				require.Matches(t, "file .* not found in repo", err.Error())
+			}
+			require.NoError(t, env.PachClient.WalkFile(latestCommit, "", cb))
+			require.NoError(t, env.PachClient.WalkFile(latestCommit, "/", cb))
+			checkNotFound("foo")
+			checkNotFound("/foo")
+			checkNotFound("foo/bar")
+			checkNotFound("/foo/bar")
+		}
+
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		// TODO(global ids): uncomment this code once global ids land and branches have default heads
+		//require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
+		//checks() // Test the default empty head commit
+
+		_, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		checks() // Test an empty open commit
+
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		checks() // Test an empty closed commit
+	})
+
+	suite.Run("ReadSizeLimited", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		data := make([]byte, 20*units.MB)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		require.NoError(t, env.PachClient.PutFile(commit, "file", bytes.NewReader(data)))
+
+		var b bytes.Buffer
+		require.NoError(t, env.PachClient.GetFileRange(commit, "file", 0, 2*units.MB, &b))
+		require.Equal(t, 2*units.MB, b.Len())
+		require.True(t, bytes.Equal(data[:2*units.MB], b.Bytes()))
+
+		b.Reset()
+		require.NoError(t, env.PachClient.GetFileRange(commit, "file", 2*units.MB+1, 2*units.MB, &b))
+		require.Equal(t, 2*units.MB, b.Len())
+		require.True(t, bytes.Equal(data[2*units.MB+1:4*units.MB+1], b.Bytes()))
+
+		b.Reset()
+		require.NoError(t, env.PachClient.GetFileRange(commit, "file", 19*units.MB, 2*units.MB, &b))
+		require.Equal(t, units.MB, b.Len())
+		require.True(t, bytes.Equal(data[19*units.MB:], b.Bytes()))
+	})
+
+	suite.Run("GetFileParallel", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		data := make([]byte, 20*units.MB)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		require.NoError(t, env.PachClient.PutFile(commit, "file", bytes.NewReader(data)))
+
+		get := func(parallelism, partSize int, opts ...client.GetFileOption) []byte {
+			f, err := ioutil.TempFile(t.TempDir(), "file")
+			require.NoError(t, err)
+			defer f.Close()
+			require.NoError(t, env.PachClient.GetFileParallel(commit, "file", f, parallelism, partSize, opts...))
+			content, err := ioutil.ReadFile(f.Name())
+			require.NoError(t, err)
+			return content
+		}
+		// The last of the parts is shorter than the others.
+		require.True(t, bytes.Equal(data, get(4, 3*units.MB)))
+		// A range is downloaded in parts too, and written from the start.
+		require.True(t, bytes.Equal(data[units.MB+1:11*units.MB+1], get(4, 3*units.MB, client.WithRangeGetFile(units.MB+1, 10*units.MB))))
+		// A file that fits in a single part is downloaded whole.
+		require.True(t, bytes.Equal(data, get(4, 0)))
+		// So is a file in an open commit.
+		_, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(data, get(4, units.MB)))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+
+		f, err := ioutil.TempFile(t.TempDir(), "dir")
+		require.NoError(t, err)
+		defer f.Close()
+		require.YesError(t, env.PachClient.GetFileParallel(commit, "/", f, 4, units.MB))
+	})
+
+	suite.Run("PutFileURL", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		if testing.Short() {
+			t.Skip("Skipping integration tests in short mode")
+		}
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFileURL(commit, "readme", "https://raw.githubusercontent.com/pachyderm/pachyderm/master/README.md", false))
+		check := func() {
+			fileInfo, err := env.PachClient.InspectFile(commit, "readme")
+			require.NoError(t, err)
+			require.True(t, fileInfo.SizeBytes > 0)
+		}
+		check()
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		check()
+	})
+
+	suite.Run("PutFilesURL", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "repo"
+		repoProto := client.NewRepo(repo)
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		paths := []string{"README.md", "CHANGELOG.md", "CONTRIBUTING.md"}
+		for _, path := range paths {
+			url := fmt.Sprintf("https://raw.githubusercontent.com/pachyderm/pachyderm/master/%s", path)
+			require.NoError(t, env.PachClient.PutFileURL(commit, path, url, false))
+		}
+		check := func() {
+			cis, err := env.PachClient.ListCommit(repoProto, nil, nil, 0)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(cis))
+
+			for _, path := range paths {
+				fileInfo, err := env.PachClient.InspectFile(repoProto.NewCommit("master", ""), path)
+				require.NoError(t, err)
+				require.True(t, fileInfo.SizeBytes > 0)
+			}
+		}
+		check()
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		check()
+	})
+
+	suite.Run("PutFilesObjURL", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		masterCommit := client.NewCommit(repo, "master", "")
+		require.NoError(t, err)
+		objC, bucket := obj.NewTestClient(t)
+		paths := []string{"files/foo", "files/bar", "files/fizz"}
+		for _, path := range paths {
+			writeObj(t, objC, path, path)
+		}
+		for _, path := range paths {
+			url := fmt.Sprintf("local://%s/%s", bucket, path)
+			require.NoError(t, env.PachClient.PutFileURL(commit, path, url, false))
+		}
+		url := fmt.Sprintf("local://%s/files", bucket)
+		require.NoError(t, env.PachClient.PutFileURL(commit, "recursive", url, true))
+		check := func() {
+			cis, err := env.PachClient.ListCommit(client.NewRepo(repo), nil, nil, 0)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(cis))
+
+			for _, path := range paths {
+				var b bytes.Buffer
+				require.NoError(t, env.PachClient.GetFile(masterCommit, path, &b))
+				require.Equal(t, path, b.String())
+				b.Reset()
+				require.NoError(t, env.PachClient.GetFile(masterCommit, filepath.Join("recursive", filepath.Base(path)), &b))
+				require.Equal(t, path, b.String())
+			}
+		}
+		check()
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		check()
+	})
+
+	suite.Run("GetFilesObjURL", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.Equal(t, c.ID, masterInfo.Head.ID)
+		paths := []string{"files/foo", "files/bar", "files/fizz"}
+		for _, path := range paths {
+			require.NoError(t, env.PachClient.PutFile(commit, path, strings.NewReader(path)))
+		}
+		check := func() {
+			objC, bucket := tu.NewObjectClient(t)
+			for _, path := range paths {
+				url := fmt.Sprintf("local://%s/", bucket)
+				require.NoError(t, env.PachClient.GetFileURL(commit, path, url))
+			}
+			for _, path := range paths {
+				buf := &bytes.Buffer{}
+				err := objC.Get(context.Background(), path, buf)
+				require.NoError(t, err)
+				require.True(t, bytes.Equal([]byte(path), buf.Bytes()))
+			}
+		}
+		check()
+		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
+		check()
 	})
 
-	suite.Run("CommitState", func(t *testing.T) {
+	suite.Run("PutFileOutputRepo", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// two input repos, one with many commits (logs), and one with few (schema)
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
+		inputRepo, outputRepo := "input", "output"
+		require.NoError(t, env.PachClient.CreateRepo(inputRepo))
+		require.NoError(t, env.PachClient.CreateRepo(outputRepo))
+		inCommit := client.NewCommit(inputRepo, "master", "")
+		outCommit := client.NewCommit(outputRepo, "master", "")
+		require.NoError(t, env.PachClient.CreateBranch(outputRepo, "master", "", "", []*pfs.Branch{client.NewBranch(inputRepo, "master")}))
+		require.NoError(t, env.PachClient.FinishCommit(outputRepo, "master", ""))
+		require.NoError(t, env.PachClient.PutFile(inCommit, "foo", strings.NewReader("foo\n")))
+		require.NoError(t, env.PachClient.PutFile(outCommit, "bar", strings.NewReader("bar\n")))
+		require.NoError(t, env.PachClient.FinishCommit(outputRepo, "master", ""))
+		fileInfos, err := env.PachClient.ListFileAll(outCommit, "")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(fileInfos))
+		buf := &bytes.Buffer{}
+		require.NoError(t, env.PachClient.GetFile(outCommit, "bar", buf))
+		require.Equal(t, "bar\n", buf.String())
+	})
 
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+	suite.Run("FileHistory", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		// Start a commit on A/master, this will create a non-ready commit on B/master.
-		_, err := env.PachClient.StartCommit("A", "master")
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		master := client.NewCommit(repo, "master", "")
+		numCommits := 10
+		for i := 0; i < numCommits; i++ {
+			require.NoError(t, env.PachClient.PutFile(master, "file", strings.NewReader(fmt.Sprintf("foo%d\n", i))))
+		}
+		fileInfos, err := env.PachClient.ListFileHistory(master, "file", -1)
 		require.NoError(t, err)
+		require.Equal(t, numCommits, len(fileInfos))
+		// Versions are returned newest first.
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(fileInfos[0].File.Commit, "file", &buf))
+		require.Equal(t, fmt.Sprintf("foo%d\n", numCommits-1), buf.String())
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-		defer cancel()
-		_, err = env.PachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
-			Commit: client.NewCommit("B", "master", ""),
-			Wait:   pfs.CommitState_READY,
-		})
-		require.YesError(t, err)
-
-		// Finish the commit on A/master, that will make the B/master ready.
-		require.NoError(t, env.PachClient.FinishCommit("A", "master", ""))
+		for i := 1; i < numCommits; i++ {
+			fileInfos, err := env.PachClient.ListFileHistory(master, "file", int64(i))
+			require.NoError(t, err)
+			require.Equal(t, i, len(fileInfos))
+		}
 
-		ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
-		defer cancel()
-		_, err = env.PachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
-			Commit: client.NewCommit("B", "master", ""),
-			Wait:   pfs.CommitState_READY,
-		})
+		// Commits that don't change the file aren't versions of it.
+		require.NoError(t, env.PachClient.DeleteFile(master, "file"))
+		for i := 0; i < numCommits; i++ {
+			require.NoError(t, env.PachClient.PutFile(master, "file", strings.NewReader(fmt.Sprintf("bar%d\n", i))))
+			require.NoError(t, env.PachClient.PutFile(master, "unrelated", strings.NewReader(fmt.Sprintf("bar%d\n", i))))
+		}
+		fileInfos, err = env.PachClient.ListFileHistory(master, "file", -1)
 		require.NoError(t, err)
+		require.Equal(t, 2*numCommits, len(fileInfos))
 
-		// Create a new branch C/master with A/master as provenance. It should start out ready.
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
+		for i := 1; i < numCommits; i++ {
+			fileInfos, err := env.PachClient.ListFileHistory(master, "file", int64(i))
+			require.NoError(t, err)
+			require.Equal(t, i, len(fileInfos))
+		}
 
-		ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
-		defer cancel()
-		_, err = env.PachClient.PfsAPIClient.InspectCommit(ctx, &pfs.InspectCommitRequest{
-			Commit: client.NewCommit("C", "master", ""),
-			Wait:   pfs.CommitState_READY,
-		})
+		fileInfos, err = env.PachClient.ListFileHistory(master, "missing", -1)
 		require.NoError(t, err)
+		require.Equal(t, 0, len(fileInfos))
 	})
 
-	suite.Run("SubscribeStates", func(t *testing.T) {
+	suite.Run("UpdateRepo", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("A"))
-		require.NoError(t, env.PachClient.CreateRepo("B"))
-		require.NoError(t, env.PachClient.CreateRepo("C"))
-
-		require.NoError(t, env.PachClient.CreateBranch("B", "master", "", "", []*pfs.Branch{client.NewBranch("A", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("B", "master", ""))
-		require.NoError(t, env.PachClient.CreateBranch("C", "master", "", "", []*pfs.Branch{client.NewBranch("B", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("C", "master", ""))
-
-		ctx, cancel := context.WithCancel(env.PachClient.Ctx())
-		defer cancel()
-		pachClient := env.PachClient.WithCtx(ctx)
-
-		var readyCommits int64
-		go func() {
-			pachClient.SubscribeCommit(client.NewRepo("B"), "master", "", pfs.CommitState_READY, func(ci *pfs.CommitInfo) error {
-				atomic.AddInt64(&readyCommits, 1)
-				return nil
-			})
-		}()
-		go func() {
-			pachClient.SubscribeCommit(client.NewRepo("C"), "master", "", pfs.CommitState_READY, func(ci *pfs.CommitInfo) error {
-				atomic.AddInt64(&readyCommits, 1)
-				return nil
-			})
-		}()
-		_, err := pachClient.StartCommit("A", "master")
+		var err error
+		repo := "test"
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(
+			env.PachClient.Ctx(),
+			&pfs.CreateRepoRequest{
+				Repo:   client.NewRepo(repo),
+				Update: true,
+			},
+		)
 		require.NoError(t, err)
-		require.NoError(t, pachClient.FinishCommit("A", "master", ""))
-
-		require.NoErrorWithinTRetry(t, time.Second*10, func() error {
-			if atomic.LoadInt64(&readyCommits) != 1 {
-				return errors.Errorf("wrong number of ready commits")
-			}
-			return nil
-		})
-
-		require.NoError(t, pachClient.FinishCommit("B", "master", ""))
-
-		require.NoErrorWithinTRetry(t, time.Second*10, func() error {
-			if atomic.LoadInt64(&readyCommits) != 2 {
-				return errors.Errorf("wrong number of ready commits")
-			}
-			return nil
-		})
+		ri, err := env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		created, err := types.TimestampFromProto(ri.Created)
+		require.NoError(t, err)
+		desc := "foo"
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(
+			env.PachClient.Ctx(),
+			&pfs.CreateRepoRequest{
+				Repo:        client.NewRepo(repo),
+				Update:      true,
+				Description: desc,
+			},
+		)
+		require.NoError(t, err)
+		ri, err = env.PachClient.InspectRepo(repo)
+		require.NoError(t, err)
+		newCreated, err := types.TimestampFromProto(ri.Created)
+		require.NoError(t, err)
+		require.Equal(t, created, newCreated)
+		require.Equal(t, desc, ri.Description)
 	})
 
-	suite.Run("PutFileCommit", func(t *testing.T) {
+	suite.Run("DeferredProcessing", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		numFiles := 25
-		repo := "repo"
-		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.CreateRepo("input"))
+		require.NoError(t, env.PachClient.CreateRepo("output1"))
+		require.NoError(t, env.PachClient.CreateRepo("output2"))
+		require.NoError(t, env.PachClient.CreateBranch("output1", "staging", "", "", []*pfs.Branch{client.NewBranch("input", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("output1", "staging", ""))
+		require.NoError(t, env.PachClient.CreateBranch("output2", "staging", "", "", []*pfs.Branch{client.NewBranch("output1", "master")}))
+		require.NoError(t, env.PachClient.FinishCommit("output2", "staging", ""))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("input", "staging", ""), "file", strings.NewReader("foo")))
+		commitInfoA, err := env.PachClient.InspectCommit("input", "staging", "")
+		require.NoError(t, err)
+		commitsetID := commitInfoA.Commit.ID
 
-		var eg errgroup.Group
-		for i := 0; i < numFiles; i++ {
-			i := i
-			eg.Go(func() error {
-				return env.PachClient.PutFile(commit, fmt.Sprintf("%d", i), strings.NewReader(fmt.Sprintf("%d", i)))
-			})
-		}
-		require.NoError(t, eg.Wait())
+		commitInfos, err := env.PachClient.WaitCommitSetAll(commitsetID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(commitInfos))
+		require.Equal(t, commitInfoA.Commit, commitInfos[0].Commit)
 
-		for i := 0; i < numFiles; i++ {
-			var b bytes.Buffer
-			require.NoError(t, env.PachClient.GetFile(commit, fmt.Sprintf("%d", i), &b))
-			require.Equal(t, fmt.Sprintf("%d", i), b.String())
-		}
+		require.NoError(t, env.PachClient.CreateBranch("input", "master", "staging", "", nil))
+		require.NoError(t, env.PachClient.FinishCommit("output1", "staging", ""))
+		commitInfoB, err := env.PachClient.InspectCommit("input", "master", "")
+		require.NoError(t, err)
+		require.Equal(t, commitsetID, commitInfoB.Commit.ID)
 
-		bi, err := env.PachClient.InspectBranch(repo, "master")
+		commitInfos, err = env.PachClient.WaitCommitSetAll(commitsetID)
 		require.NoError(t, err)
+		require.Equal(t, 3, len(commitInfos))
 
-		eg = errgroup.Group{}
-		for i := 0; i < numFiles; i++ {
-			i := i
-			eg.Go(func() error {
-				return env.PachClient.CopyFile(commit, fmt.Sprintf("%d", (i+1)%numFiles), bi.Head, fmt.Sprintf("%d", i))
-			})
+		// The results _should_ be topologically sorted, but there are several
+		// branches with equivalent topological depth
+		expectedCommits := []string{
+			pfsdb.CommitKey(client.NewCommit("input", "staging", commitsetID)),
+			pfsdb.CommitKey(client.NewCommit("input", "master", commitsetID)),
+			pfsdb.CommitKey(client.NewCommit("output1", "staging", commitsetID)),
 		}
-		require.NoError(t, eg.Wait())
+		require.ElementsEqualUnderFn(t, expectedCommits, commitInfos, CommitInfoToID)
 
-		for i := 0; i < numFiles; i++ {
-			var b bytes.Buffer
-			require.NoError(t, env.PachClient.GetFile(commit, fmt.Sprintf("%d", (i+1)%numFiles), &b))
-			require.Equal(t, fmt.Sprintf("%d", i), b.String())
-		}
+		require.NoError(t, env.PachClient.CreateBranch("output1", "master", "staging", "", nil))
+		require.NoError(t, env.PachClient.FinishCommit("output2", "staging", ""))
+		commitInfoC, err := env.PachClient.InspectCommit("output1", "master", "")
+		require.NoError(t, err)
+		require.Equal(t, commitsetID, commitInfoC.Commit.ID)
 
-		eg = errgroup.Group{}
-		for i := 0; i < numFiles; i++ {
-			i := i
-			eg.Go(func() error {
-				return env.PachClient.DeleteFile(commit, fmt.Sprintf("%d", i))
-			})
-		}
-		require.NoError(t, eg.Wait())
+		commitInfos, err = env.PachClient.WaitCommitSetAll(commitsetID)
+		require.NoError(t, err)
+		require.Equal(t, 5, len(commitInfos))
+		expectedCommits = append(expectedCommits, []string{
+			pfsdb.CommitKey(client.NewCommit("output1", "master", commitsetID)),
+			pfsdb.CommitKey(client.NewCommit("output2", "staging", commitsetID)),
+		}...)
+		require.ElementsEqualUnderFn(t, expectedCommits, commitInfos, CommitInfoToID)
+	})
+
+	suite.Run("ListAll", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		require.NoError(t, env.PachClient.CreateRepo("repo1"))
+		require.NoError(t, env.PachClient.CreateRepo("repo2"))
+		commit1 := client.NewCommit("repo1", "master", "")
+		commit2 := client.NewCommit("repo2", "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit1, "file1", strings.NewReader("1")))
+		require.NoError(t, env.PachClient.PutFile(commit2, "file2", strings.NewReader("2")))
+		require.NoError(t, env.PachClient.PutFile(commit1, "file3", strings.NewReader("3")))
+		require.NoError(t, env.PachClient.PutFile(commit2, "file4", strings.NewReader("4")))
 
-		fileInfos, err := env.PachClient.ListFileAll(commit, "")
+		cis, err := env.PachClient.ListCommitByRepo(client.NewRepo(""))
 		require.NoError(t, err)
-		require.Equal(t, 0, len(fileInfos))
+		require.Equal(t, 4, len(cis))
+
+		bis, err := env.PachClient.ListBranch("")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(bis))
 	})
 
-	suite.Run("PutFileCommitNilBranch", func(t *testing.T) {
-		t.Parallel()
+	suite.Run("MonkeyObjectStorage", func(t *testing.T) {
+		// This test cannot be done in parallel because the monkey object client
+		// modifies global state.
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-
-		repo := "repo"
-		require.NoError(t, env.PachClient.CreateRepo(repo))
-		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
-		commit := client.NewCommit(repo, "master", "")
-
-		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("file")))
+		seedStr := func(seed int64) string {
+			return fmt.Sprint("seed: ", strconv.FormatInt(seed, 10))
+		}
+		monkeyRetry := func(t *testing.T, f func() error, errMsg string) {
+			backoff.Retry(func() error {
+				err := f()
+				if err != nil {
+					require.True(t, obj.IsMonkeyError(err), "Expected monkey error (%s), %s", err.Error(), errMsg)
+				}
+				return err
+			}, backoff.NewInfiniteBackOff())
+		}
+		seed := time.Now().UTC().UnixNano()
+		obj.InitMonkeyTest(seed)
+		iterations := 25
+		repo := "input"
+		require.NoError(t, env.PachClient.CreateRepo(repo), seedStr(seed))
+		filePrefix := "file"
+		dataPrefix := "data"
+		var commit *pfs.Commit
+		var err error
+		buf := &bytes.Buffer{}
+		obj.EnableMonkeyTest()
+		defer obj.DisableMonkeyTest()
+		for i := 0; i < iterations; i++ {
+			file := filePrefix + strconv.Itoa(i)
+			data := dataPrefix + strconv.Itoa(i)
+			// Retry start commit until it eventually succeeds.
+			monkeyRetry(t, func() error {
+				commit, err = env.PachClient.StartCommit(repo, "master")
+				return err
+			}, seedStr(seed))
+			// Retry put file until it eventually succeeds.
+			monkeyRetry(t, func() error {
+				if err := env.PachClient.PutFile(commit, file, strings.NewReader(data)); err != nil {
+					// Verify that the file does not exist if an error occurred.
+					obj.DisableMonkeyTest()
+					defer obj.EnableMonkeyTest()
+					buf.Reset()
+					err := env.PachClient.GetFile(commit, file, buf)
+					require.True(t, errutil.IsNotFoundError(err), seedStr(seed))
+				}
+				return err
+			}, seedStr(seed))
+			// Retry get file until it eventually succeeds (before commit is finished).
+			monkeyRetry(t, func() error {
+				buf.Reset()
+				if err = env.PachClient.GetFile(commit, file, buf); err != nil {
+					return err
+				}
+				require.Equal(t, data, buf.String(), seedStr(seed))
+				return nil
+			}, seedStr(seed))
+			// Retry finish commit until it eventually succeeds.
+			monkeyRetry(t, func() error {
+				return env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID)
+			}, seedStr(seed))
+			// Retry get file until it eventually succeeds (after commit is finished).
+			monkeyRetry(t, func() error {
+				buf.Reset()
+				if err = env.PachClient.GetFile(commit, file, buf); err != nil {
+					return err
+				}
+				require.Equal(t, data, buf.String(), seedStr(seed))
+				return nil
+			}, seedStr(seed))
+		}
 	})
 
-	suite.Run("PutFileCommitOverwrite", func(t *testing.T) {
+	suite.Run("FsckFix", func(t *testing.T) {
+		// TODO(optional 2.0): force-deleting the repo no longer creates dangling references
+		t.Skip("this test no longer creates invalid metadata")
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		numFiles := 5
-		repo := "repo"
-		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit := client.NewCommit(repo, "master", "")
-
-		for i := 0; i < numFiles; i++ {
-			require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader(fmt.Sprintf("%d", i))))
+		input := "input"
+		output1 := "output1"
+		output2 := "output2"
+		require.NoError(t, env.PachClient.CreateRepo(input))
+		require.NoError(t, env.PachClient.CreateRepo(output1))
+		require.NoError(t, env.PachClient.CreateRepo(output2))
+		require.NoError(t, env.PachClient.CreateBranch(output1, "master", "", "", []*pfs.Branch{client.NewBranch(input, "master")}))
+		require.NoError(t, env.PachClient.CreateBranch(output2, "master", "", "", []*pfs.Branch{client.NewBranch(output1, "master")}))
+		numCommits := 10
+		for i := 0; i < numCommits; i++ {
+			require.NoError(t, env.PachClient.PutFile(client.NewCommit(input, "master", ""), "file", strings.NewReader("1")))
 		}
+		require.NoError(t, env.PachClient.DeleteRepo(input, true))
+		require.NoError(t, env.PachClient.CreateRepo(input))
+		require.NoError(t, env.PachClient.CreateBranch(input, "master", "", "", nil))
 
-		var b bytes.Buffer
-		require.NoError(t, env.PachClient.GetFile(commit, "file", &b))
-		require.Equal(t, fmt.Sprintf("%d", numFiles-1), b.String())
-	})
-
-	suite.Run("WalkFile", func(t *testing.T) {
-		t.Parallel()
-		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		// Fsck should fail because ???
+		require.YesError(t, env.PachClient.FsckFastExit())
 
-		repo := "test"
-		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit, err := env.PachClient.StartCommit(repo, "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(commit, "dir/bar", strings.NewReader("bar")))
-		require.NoError(t, env.PachClient.PutFile(commit, "dir/dir2/buzz", strings.NewReader("buzz")))
-		require.NoError(t, env.PachClient.PutFile(commit, "foo", strings.NewReader("foo")))
+		// Deleting output1 should fail because output2 is provenant on it
+		require.YesError(t, env.PachClient.DeleteRepo(output1, false))
 
-		expectedPaths := []string{"/", "/dir/", "/dir/bar", "/dir/dir2/", "/dir/dir2/buzz", "/foo"}
-		checks := func() {
-			i := 0
-			require.NoError(t, env.PachClient.WalkFile(commit, "", func(fi *pfs.FileInfo) error {
-				require.Equal(t, expectedPaths[i], fi.File.Path)
-				i++
-				return nil
-			}))
-			require.Equal(t, len(expectedPaths), i)
-		}
-		checks()
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		checks()
+		// Deleting should now work due to fixing, must delete 2 before 1 though.
+		require.NoError(t, env.PachClient.DeleteRepo(output2, false))
+		require.NoError(t, env.PachClient.DeleteRepo(output1, false))
 	})
 
-	suite.Run("WalkFile2", func(t *testing.T) {
+	suite.Run("FsckFixLevel", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		repo := "WalkFile2"
+		repo := "repo"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit1, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "file", strings.NewReader("1")))
+		// Deleting the repo's row, as an incomplete deletion would, orphans
+		// its commits.
+		_, err := env.ServiceEnv.GetDBClient().Exec(`DELETE FROM collections.repos WHERE key = $1`, pfsdb.RepoKey(client.NewRepo(repo)))
 		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.1", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir1/file1.2", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.1", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.PutFile(commit1, "/dir2/file2.2", &bytes.Buffer{}))
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit1.Branch.Name, commit1.ID))
-		walkFile := func(path string) []string {
-			var fis []*pfs.FileInfo
-			require.NoError(t, env.PachClient.WalkFile(commit1, path, func(fi *pfs.FileInfo) error {
-				fis = append(fis, fi)
+
+		fsck := func(fixLevel pfs.FsckFixLevel) []*pfs.FsckIssue {
+			var issues []*pfs.FsckIssue
+			require.NoError(t, env.PachClient.FsckWithFixLevel(fixLevel, func(resp *pfs.FsckResponse) error {
+				require.Equal(t, resp.Error, resp.Issue.Description)
+				issues = append(issues, resp.Issue)
 				return nil
 			}))
-			return finfosToPaths(fis)
+			return issues
 		}
-		assert.ElementsMatch(t, []string{"/dir1/", "/dir1/file1.1", "/dir1/file1.2"}, walkFile("/dir1"))
-		assert.ElementsMatch(t, []string{"/dir1/file1.1"}, walkFile("/dir1/file1.1"))
-		assert.Len(t, walkFile("/"), 7)
+		// Deleting orphaned commits loses their data, so only aggressive
+		// fixes do it.
+		for _, fixLevel := range []pfs.FsckFixLevel{pfs.FsckFixLevel_REPORT_ONLY, pfs.FsckFixLevel_SAFE_FIX} {
+			issues := fsck(fixLevel)
+			require.True(t, len(issues) > 0)
+			for _, issue := range issues {
+				require.Equal(t, pfs.FsckIssueType_ORPHANED_COMMIT, issue.Type)
+				require.Equal(t, repo, issue.Commit.Branch.Repo.Name)
+				require.Equal(t, pfs.FsckFixLevel_AGGRESSIVE_FIX, issue.FixLevel)
+				require.Equal(t, "", issue.Action)
+			}
+		}
+		issues := fsck(pfs.FsckFixLevel_AGGRESSIVE_FIX)
+		require.True(t, len(issues) > 0)
+		for _, issue := range issues {
+			require.Equal(t, pfs.FsckIssueType_ORPHANED_COMMIT, issue.Type)
+			require.NotEqual(t, "", issue.Action)
+		}
+		require.Equal(t, 0, len(fsck(pfs.FsckFixLevel_REPORT_ONLY)))
 	})
 
-	suite.Run("WalkFileEmpty", func(t *testing.T) {
+	suite.Run("GarbageCollect", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		repo := "test"
-		latestCommit := client.NewCommit(repo, "master", "")
-		checks := func() {
-			cb := func(fi *pfs.FileInfo) error {
-				if assert.Equal(t, fi.FileType, pfs.FileType_DIR) && assert.Equal(t, fi.File.Path, "/") {
-					return nil
-				}
-				return errors.New("should not have returned any file results for an empty commit")
-			}
-			checkNotFound := func(path string) {
-				err := env.PachClient.WalkFile(latestCommit, path, cb)
-				require.YesError(t, err)
-				require.Matches(t, "file .* not found in repo", err.Error())
-			}
-			require.NoError(t, env.PachClient.WalkFile(latestCommit, "", cb))
-			require.NoError(t, env.PachClient.WalkFile(latestCommit, "/", cb))
-			checkNotFound("foo")
-			checkNotFound("/foo")
-			checkNotFound("foo/bar")
-			checkNotFound("/foo/bar")
-		}
-
+		repo := "repo"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		// TODO(global ids): uncomment this code once global ids land and branches have default heads
-		//require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
-		//checks() // Test the default empty head commit
-
-		_, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "file", strings.NewReader("foo")))
+		require.NoError(t, env.PachClient.DeleteRepo(repo, false))
+		// The repo's filesets are kept until their TTLs pass, which is
+		// simulated here. The chunks' own TTLs haven't passed, so they're
+		// only deleted because nothing references them anymore.
+		_, err := env.ServiceEnv.GetDBClient().Exec(`
+			UPDATE storage.tracker_objects SET expires_at = CURRENT_TIMESTAMP
+			WHERE str_id NOT LIKE 'chunk/%'
+		`)
 		require.NoError(t, err)
-		checks() // Test an empty open commit
 
-		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
-		checks() // Test an empty closed commit
-	})
+		run, err := env.PachClient.GarbageCollect()
+		require.NoError(t, err)
+		require.True(t, run.Manual)
+		require.NotNil(t, run.Started)
+		require.NotNil(t, run.Finished)
+		require.True(t, run.ChunksDeleted > 0)
+		require.True(t, run.BytesReclaimed > 0)
 
-	suite.Run("ReadSizeLimited", func(t *testing.T) {
-		// TODO(2.0 required): Decide on how to expose offset read.
-		t.Skip("Offset read exists (inefficient), just need to decide on how to expose it in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	require.NoError(t, env.PachClient.CreateRepo("test"))
-		//	require.NoError(t, env.PachClient.PutFile("test", "master", "", "file", strings.NewReader(strings.Repeat("a", 100*units.MB))))
-		//
-		//	var b bytes.Buffer
-		//	require.NoError(t, env.PachClient.GetFile("test", "master", "", "file", 0, 2*units.MB, &b))
-		//	require.Equal(t, 2*units.MB, b.Len())
-		//
-		//	b.Reset()
-		//	require.NoError(t, env.PachClient.GetFile("test", "master", "", "file", 2*units.MB, 2*units.MB, &b))
-		//	require.Equal(t, 2*units.MB, b.Len())
+		stats, err := env.PachClient.InspectGarbageCollection()
+		require.NoError(t, err)
+		require.NotNil(t, stats.LastRun)
+		require.True(t, stats.BytesReclaimedLastDay >= run.BytesReclaimed)
 	})
 
-	suite.Run("PutFileURL", func(t *testing.T) {
+	suite.Run("StorageUsage", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		if testing.Short() {
-			t.Skip("Skipping integration tests in short mode")
-		}
-
-		repo := "test"
+		repo := "repo"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit, err := env.PachClient.StartCommit(repo, "master")
-		require.NoError(t, err)
-		require.NoError(t, env.PachClient.PutFileURL(commit, "readme", "https://raw.githubusercontent.com/pachyderm/pachyderm/master/README.md", false))
-		check := func() {
-			fileInfo, err := env.PachClient.InspectFile(commit, "readme")
-			require.NoError(t, err)
-			require.True(t, fileInfo.SizeBytes > 0)
+		data := strings.Repeat("foo", 1000)
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "master", ""), "file", strings.NewReader(data)))
+		// copy has the same head as master, so its data is all shared.
+		require.NoError(t, env.PachClient.CreateBranch(repo, "copy", "master", "", nil))
+
+		usage, err := env.PachClient.StorageUsage(repo)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(usage.Branches))
+		for _, branchUsage := range usage.Branches {
+			require.Equal(t, int64(len(data)), branchUsage.LogicalBytes)
+			require.True(t, branchUsage.PhysicalBytes > 0)
+			require.Equal(t, usage.PhysicalBytes, branchUsage.PhysicalBytes)
+			require.Equal(t, usage.Chunks, branchUsage.Chunks)
 		}
-		check()
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		check()
+		require.Equal(t, int64(2*len(data)), usage.LogicalBytes)
+		require.Equal(t, float64(usage.LogicalBytes)/float64(usage.PhysicalBytes), usage.DedupRatio)
 	})
 
-	suite.Run("PutFilesURL", func(t *testing.T) {
+	suite.Run("ListFileFilters", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		repo := "repo"
-		repoProto := client.NewRepo(repo)
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit, err := env.PachClient.StartCommit(repo, "master")
-		require.NoError(t, err)
-		paths := []string{"README.md", "CHANGELOG.md", "CONTRIBUTING.md"}
-		for _, path := range paths {
-			url := fmt.Sprintf("https://raw.githubusercontent.com/pachyderm/pachyderm/master/%s", path)
-			require.NoError(t, env.PachClient.PutFileURL(commit, path, url, false))
-		}
-		check := func() {
-			cis, err := env.PachClient.ListCommit(repoProto, nil, nil, 0)
+		master := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.WithModifyFileClient(master, func(mf client.ModifyFile) error {
+			for p, size := range map[string]int{"/small": 10, "/medium": 100, "/large": 1000, "/dir/a": 50, "/old/a": 5} {
+				if err := mf.PutFile(p, strings.NewReader(strings.Repeat("x", size))); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		require.NoError(t, env.PachClient.PutFile(master, "/dir/b", strings.NewReader("changed")))
+		paths := func(opts ...client.ListFileOption) []string {
+			fis, err := env.PachClient.ListFileAll(master, "/", opts...)
 			require.NoError(t, err)
-			require.Equal(t, 1, len(cis))
-
-			for _, path := range paths {
-				fileInfo, err := env.PachClient.InspectFile(repoProto.NewCommit("master", ""), path)
-				require.NoError(t, err)
-				require.True(t, fileInfo.SizeBytes > 0)
+			var ps []string
+			for _, fi := range fis {
+				ps = append(ps, fi.File.Path)
 			}
+			return ps
 		}
-		check()
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		check()
+
+		require.Equal(t, []string{"/dir/", "/large", "/medium", "/old/", "/small"}, paths())
+		require.Equal(t, []string{"/dir/", "/medium"}, paths(client.WithSizeListFile(50, 100)))
+		require.Equal(t, []string{"/large", "/medium", "/small"}, paths(client.WithTypeListFile(pfs.FileType_FILE)))
+		require.Equal(t, []string{"/dir/", "/old/"}, paths(client.WithTypeListFile(pfs.FileType_DIR)))
+		require.Equal(t, []string{"/large", "/medium", "/dir/", "/small", "/old/"}, paths(client.WithSortListFile(pfs.FileSortBy_BY_FILE_SIZE, false)))
+		require.Equal(t, []string{"/small", "/medium"}, paths(client.WithTypeListFile(pfs.FileType_FILE), client.WithSizeListFile(0, 100), client.WithSortListFile(pfs.FileSortBy_BY_PATH, true)))
+		require.Equal(t, []string{"/dir/"}, paths(client.WithChangedListFile()))
+		fis, err := env.PachClient.ListFileAll(master, "/dir", client.WithChangedListFile())
+		require.NoError(t, err)
+		require.Equal(t, 1, len(fis))
+		require.Equal(t, "/dir/b", fis[0].File.Path)
+
+		_, err = env.PachClient.ListFileAll(master, "/", client.WithSizeListFile(100, 50))
+		require.YesError(t, err)
+		it := env.PachClient.ListFilePaged(master, "/", 2, client.WithSortListFile(pfs.FileSortBy_BY_FILE_SIZE, false))
+		require.False(t, it.Next())
+		require.YesError(t, it.Err())
 	})
 
-	suite.Run("PutFilesObjURL", func(t *testing.T) {
+	suite.Run("InspectFileDirStats", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		repo := "repo"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit, err := env.PachClient.StartCommit(repo, "master")
-		masterCommit := client.NewCommit(repo, "master", "")
-		require.NoError(t, err)
-		objC, bucket := obj.NewTestClient(t)
-		paths := []string{"files/foo", "files/bar", "files/fizz"}
-		for _, path := range paths {
-			writeObj(t, objC, path, path)
-		}
-		for _, path := range paths {
-			url := fmt.Sprintf("local://%s/%s", bucket, path)
-			require.NoError(t, env.PachClient.PutFileURL(commit, path, url, false))
+		commit := client.NewCommit(repo, "master", "")
+		base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, p := range []string{"a", "dir/b", "dir/sub/c", "dir/sub/deeper/d", "other/e"} {
+			mtime := base.Add(time.Duration(i) * time.Hour)
+			require.NoError(t, env.PachClient.PutFile(commit, p, strings.NewReader("foo"), client.WithModTimePutFile(mtime)))
 		}
-		url := fmt.Sprintf("local://%s/files", bucket)
-		require.NoError(t, env.PachClient.PutFileURL(commit, "recursive", url, true))
-		check := func() {
-			cis, err := env.PachClient.ListCommit(client.NewRepo(repo), nil, nil, 0)
+		newest := func(fi *pfs.FileInfo) time.Time {
+			mtime, err := types.TimestampFromProto(fi.NewestMtime)
 			require.NoError(t, err)
-			require.Equal(t, 1, len(cis))
-
-			for _, path := range paths {
-				var b bytes.Buffer
-				require.NoError(t, env.PachClient.GetFile(masterCommit, path, &b))
-				require.Equal(t, path, b.String())
-				b.Reset()
-				require.NoError(t, env.PachClient.GetFile(masterCommit, filepath.Join("recursive", filepath.Base(path)), &b))
-				require.Equal(t, path, b.String())
-			}
+			return mtime
 		}
-		check()
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		check()
+
+		fi, err := env.PachClient.InspectFile(commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), fi.NumFiles)
+		require.Equal(t, uint64(4), fi.NumDirs)
+		require.Equal(t, base.Add(4*time.Hour), newest(fi))
+		fi, err = env.PachClient.InspectFile(commit, "dir")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), fi.NumFiles)
+		require.Equal(t, uint64(2), fi.NumDirs)
+		require.Equal(t, base.Add(3*time.Hour), newest(fi))
+		fi, err = env.PachClient.InspectFile(commit, "dir/sub/deeper")
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), fi.NumFiles)
+		require.Equal(t, uint64(0), fi.NumDirs)
+		fi, err = env.PachClient.InspectFile(commit, "a")
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), fi.NumFiles)
+		require.Nil(t, fi.NewestMtime)
 	})
 
-	suite.Run("GetFilesObjURL", func(t *testing.T) {
+	suite.Run("DedupReport", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
 		repo := "repo"
 		require.NoError(t, env.PachClient.CreateRepo(repo))
-		commit, err := env.PachClient.StartCommit(repo, "master")
+		master := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(master, "a", strings.NewReader(random.String(10000))))
+		first, err := env.PachClient.InspectCommit(repo, "master", "")
+		require.NoError(t, err)
+		require.NoError(t, env.PachClient.PutFile(master, "b", strings.NewReader(random.String(10000))))
+		second, err := env.PachClient.InspectCommit(repo, "master", "")
 		require.NoError(t, err)
-		paths := []string{"files/foo", "files/bar", "files/fizz"}
-		for _, path := range paths {
-			require.NoError(t, env.PachClient.PutFile(commit, path, strings.NewReader(path)))
-		}
-		check := func() {
-			objC, bucket := tu.NewObjectClient(t)
-			for _, path := range paths {
-				url := fmt.Sprintf("local://%s/", bucket)
-				require.NoError(t, env.PachClient.GetFileURL(commit, path, url))
-			}
-			for _, path := range paths {
-				buf := &bytes.Buffer{}
-				err := objC.Get(context.Background(), path, buf)
-				require.NoError(t, err)
-				require.True(t, bytes.Equal([]byte(path), buf.Bytes()))
-			}
-		}
-		check()
-		require.NoError(t, env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID))
-		check()
-	})
-
-	suite.Run("PutFileOutputRepo", func(t *testing.T) {
-		t.Parallel()
-		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		inputRepo, outputRepo := "input", "output"
-		require.NoError(t, env.PachClient.CreateRepo(inputRepo))
-		require.NoError(t, env.PachClient.CreateRepo(outputRepo))
-		inCommit := client.NewCommit(inputRepo, "master", "")
-		outCommit := client.NewCommit(outputRepo, "master", "")
-		require.NoError(t, env.PachClient.CreateBranch(outputRepo, "master", "", "", []*pfs.Branch{client.NewBranch(inputRepo, "master")}))
-		require.NoError(t, env.PachClient.FinishCommit(outputRepo, "master", ""))
-		require.NoError(t, env.PachClient.PutFile(inCommit, "foo", strings.NewReader("foo\n")))
-		require.NoError(t, env.PachClient.PutFile(outCommit, "bar", strings.NewReader("bar\n")))
-		require.NoError(t, env.PachClient.FinishCommit(outputRepo, "master", ""))
-		fileInfos, err := env.PachClient.ListFileAll(outCommit, "")
+		report, err := env.PachClient.DedupReport(first.Commit, second.Commit)
 		require.NoError(t, err)
-		require.Equal(t, 1, len(fileInfos))
-		buf := &bytes.Buffer{}
-		require.NoError(t, env.PachClient.GetFile(outCommit, "bar", buf))
-		require.Equal(t, "bar\n", buf.String())
-	})
+		require.Equal(t, first.Commit.ID, report.A.ID)
+		require.Equal(t, int64(10000), report.ALogicalBytes)
+		require.Equal(t, int64(20000), report.BLogicalBytes)
+		// a is in both commits, and b only in the second.
+		require.True(t, report.Shared.Chunks > 0)
+		require.True(t, report.Shared.Bytes > 0)
+		require.Equal(t, int64(0), report.OnlyA.Chunks)
+		require.True(t, report.OnlyB.Chunks > 0)
 
-	suite.Run("FileHistory", func(t *testing.T) {
-		// TODO: There is no notion of file history in V2. We could potentially implement this, but
-		// we would need to spend some time thinking about the performance characteristics.
-		t.Skip("File history is not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	var err error
-		//
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//	numCommits := 10
-		//	for i := 0; i < numCommits; i++ {
-		//		_, err = env.PachClient.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
-		//		require.NoError(t, err)
-		//	}
-		//	fileInfos, err := env.PachClient.ListFileHistory(repo, "master", "file", -1)
-		//	require.NoError(t, err)
-		//	require.Equal(t, numCommits, len(fileInfos))
-		//
-		//	for i := 1; i < numCommits; i++ {
-		//		fileInfos, err := env.PachClient.ListFileHistory(repo, "master", "file", int64(i))
-		//		require.NoError(t, err)
-		//		require.Equal(t, i, len(fileInfos))
-		//	}
-		//
-		//	require.NoError(t, env.PachClient.DeleteFile(repo, "master", "file"))
-		//	for i := 0; i < numCommits; i++ {
-		//		_, err = env.PachClient.PutFile(repo, "master", "file", strings.NewReader("foo\n"))
-		//		require.NoError(t, err)
-		//		_, err = env.PachClient.PutFile(repo, "master", "unrelated", strings.NewReader("foo\n"))
-		//		require.NoError(t, err)
-		//	}
-		//	fileInfos, err = env.PachClient.ListFileHistory(repo, "master", "file", -1)
-		//	require.NoError(t, err)
-		//	require.Equal(t, numCommits, len(fileInfos))
-		//
-		//	for i := 1; i < numCommits; i++ {
-		//		fileInfos, err := env.PachClient.ListFileHistory(repo, "master", "file", int64(i))
-		//		require.NoError(t, err)
-		//		require.Equal(t, i, len(fileInfos))
-		//	}
+		report, err = env.PachClient.DedupReport(second.Commit, second.Commit)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), report.OnlyA.Chunks+report.OnlyB.Chunks)
 	})
 
-	suite.Run("UpdateRepo", func(t *testing.T) {
+	suite.Run("PromoteBranch", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		var err error
-		repo := "test"
-		_, err = env.PachClient.PfsAPIClient.CreateRepo(
-			env.PachClient.Ctx(),
-			&pfs.CreateRepoRequest{
-				Repo:   client.NewRepo(repo),
-				Update: true,
-			},
-		)
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "staging", ""), "file", strings.NewReader("foo")))
+		stagingInfo, err := env.PachClient.InspectBranch(repo, "staging")
 		require.NoError(t, err)
-		ri, err := env.PachClient.InspectRepo(repo)
+		masterInfo, err := env.PachClient.InspectBranch(repo, "master")
 		require.NoError(t, err)
-		created, err := types.TimestampFromProto(ri.Created)
+
+		// master isn't moved unless its head is the expected one.
+		err = env.PachClient.PromoteBranch(repo, "staging", "master", stagingInfo.Head.ID)
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsUnexpectedBranchHeadErr(err))
+		info, err := env.PachClient.InspectBranch(repo, "master")
 		require.NoError(t, err)
-		desc := "foo"
-		_, err = env.PachClient.PfsAPIClient.CreateRepo(
-			env.PachClient.Ctx(),
-			&pfs.CreateRepoRequest{
-				Repo:        client.NewRepo(repo),
-				Update:      true,
-				Description: desc,
-			},
-		)
+		require.Equal(t, masterInfo.Head.ID, info.Head.ID)
+
+		// The promoted head is in the same commit set as staging's head.
+		require.NoError(t, env.PachClient.PromoteBranch(repo, "staging", "master", masterInfo.Head.ID))
+		info, err = env.PachClient.InspectBranch(repo, "master")
 		require.NoError(t, err)
-		ri, err = env.PachClient.InspectRepo(repo)
+		require.Equal(t, stagingInfo.Head.ID, info.Head.ID)
+		require.Equal(t, pfs.HeadChangeCause_PROMOTED, info.HeadChange.Cause)
+		var b bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(client.NewCommit(repo, "master", ""), "file", &b))
+		require.Equal(t, "foo", b.String())
+
+		// Promoting the same head again doesn't move master.
+		require.NoError(t, env.PachClient.PromoteBranch(repo, "staging", "master", ""))
+		info2, err := env.PachClient.InspectBranch(repo, "master")
 		require.NoError(t, err)
-		newCreated, err := types.TimestampFromProto(ri.Created)
+		require.Equal(t, info.Head.ID, info2.Head.ID)
+
+		// An open head can't be promoted.
+		_, err = env.PachClient.StartCommit(repo, "staging")
 		require.NoError(t, err)
-		require.Equal(t, created, newCreated)
-		require.Equal(t, desc, ri.Description)
+		err = env.PachClient.PromoteBranch(repo, "staging", "master", "")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsCommitNotFinishedErr(err))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "staging", ""))
+
+		// Branches in other repos, and branches with provenance, can't be
+		// promoted to.
+		require.NoError(t, env.PachClient.CreateRepo("other"))
+		require.NoError(t, env.PachClient.CreateBranch("other", "master", "", "", nil))
+		_, err = env.PachClient.PfsAPIClient.PromoteBranch(env.PachClient.Ctx(), &pfs.PromoteBranchRequest{
+			From: client.NewBranch(repo, "staging"),
+			To:   client.NewBranch("other", "master"),
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.PromoteBranch(env.PachClient.Ctx(), &pfs.PromoteBranchRequest{
+			From: client.NewBranch(repo, "staging"),
+		})
+		require.YesError(t, err)
+		_, err = env.PachClient.PfsAPIClient.PromoteBranch(env.PachClient.Ctx(), &pfs.PromoteBranchRequest{
+			To: client.NewBranch(repo, "master"),
+		})
+		require.YesError(t, err)
+		// The branch promoted from isn't read before the request is checked.
+		_, err = env.PachClient.PfsAPIClient.PromoteBranch(env.PachClient.Ctx(), &pfs.PromoteBranchRequest{
+			From: client.NewBranch("missing", "staging"),
+			To:   client.NewBranch("other", "master"),
+		})
+		require.YesError(t, err)
+		require.Matches(t, "different repos", err.Error())
+		require.NoError(t, env.PachClient.CreateBranch(repo, "downstream", "", "", []*pfs.Branch{client.NewBranch("other", "master")}))
+		require.YesError(t, env.PachClient.PromoteBranch(repo, "staging", "downstream", ""))
+
+		// Promotions can be made in a transaction, where the expected head is
+		// checked when the transaction is finished.
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit(repo, "staging", ""), "file", strings.NewReader("bar")))
+		_, err = env.PachClient.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+			return builder.PromoteBranch(repo, "staging", "master", info.Head.ID)
+		})
+		require.NoError(t, err)
+		b.Reset()
+		require.NoError(t, env.PachClient.GetFile(client.NewCommit(repo, "master", ""), "file", &b))
+		require.Equal(t, "bar", b.String())
+		_, err = env.PachClient.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+			return builder.PromoteBranch(repo, "staging", "master", info.Head.ID)
+		})
+		require.YesError(t, err)
 	})
 
-	suite.Run("DeferredProcessing", func(t *testing.T) {
+	suite.Run("FindFilesByContent", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("input"))
-		require.NoError(t, env.PachClient.CreateRepo("output1"))
-		require.NoError(t, env.PachClient.CreateRepo("output2"))
-		require.NoError(t, env.PachClient.CreateBranch("output1", "staging", "", "", []*pfs.Branch{client.NewBranch("input", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("output1", "staging", ""))
-		require.NoError(t, env.PachClient.CreateBranch("output2", "staging", "", "", []*pfs.Branch{client.NewBranch("output1", "master")}))
-		require.NoError(t, env.PachClient.FinishCommit("output2", "staging", ""))
-		require.NoError(t, env.PachClient.PutFile(client.NewCommit("input", "staging", ""), "file", strings.NewReader("foo")))
-		commitInfoA, err := env.PachClient.InspectCommit("input", "staging", "")
+		data := random.String(10000)
+		require.NoError(t, env.PachClient.CreateRepo("a"))
+		require.NoError(t, env.PachClient.CreateRepo("b"))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("a", "master", ""), "artifact", strings.NewReader(data)))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("b", "master", ""), "copy", strings.NewReader(data)))
+		require.NoError(t, env.PachClient.PutFile(client.NewCommit("b", "master", ""), "other", strings.NewReader(random.String(10000))))
+		fi, err := env.PachClient.InspectFile(client.NewCommit("a", "master", ""), "artifact")
 		require.NoError(t, err)
-		commitsetID := commitInfoA.Commit.ID
 
-		commitInfos, err := env.PachClient.WaitCommitSetAll(commitsetID)
-		require.NoError(t, err)
-		require.Equal(t, 1, len(commitInfos))
-		require.Equal(t, commitInfoA.Commit, commitInfos[0].Commit)
+		find := func(repo *pfs.Repo, hash, chunkID []byte) []string {
+			var found []string
+			cb := func(fi *pfs.FileInfo) error {
+				found = append(found, fi.File.Commit.Branch.Repo.Name+":"+fi.File.Path)
+				return nil
+			}
+			if hash != nil {
+				require.NoError(t, env.PachClient.FindFilesByHash(repo, hash, cb))
+			} else {
+				require.NoError(t, env.PachClient.FindFilesByChunk(repo, chunkID, cb))
+			}
+			sort.Strings(found)
+			return found
+		}
+		// The copy in b is found in both of b's commits.
+		require.Equal(t, []string{"a:/artifact", "b:/copy", "b:/copy"}, find(nil, fi.Hash, nil))
+		require.Equal(t, []string{"a:/artifact"}, find(client.NewRepo("a"), fi.Hash, nil))
+		require.Equal(t, 0, len(find(nil, []byte("no such hash"), nil)))
+
+		// One of the chunks holds the data, the others are index chunks or
+		// hold the other file's data.
+		var chunkIDs [][]byte
+		require.NoError(t, env.ServiceEnv.GetDBClient().Select(&chunkIDs, `SELECT DISTINCT chunk_id FROM storage.chunk_objects`))
+		var foundByChunk bool
+		for _, chunkID := range chunkIDs {
+			if found := find(nil, nil, chunkID); len(found) == 3 {
+				require.Equal(t, []string{"a:/artifact", "b:/copy", "b:/copy"}, found)
+				foundByChunk = true
+			}
+		}
+		require.True(t, foundByChunk)
+		require.YesError(t, env.PachClient.FindFilesByHash(nil, nil, func(*pfs.FileInfo) error { return nil }))
+	})
 
-		require.NoError(t, env.PachClient.CreateBranch("input", "master", "staging", "", nil))
-		require.NoError(t, env.PachClient.FinishCommit("output1", "staging", ""))
-		commitInfoB, err := env.PachClient.InspectCommit("input", "master", "")
+	suite.Run("RepoCompression", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo("invalid"),
+			Settings: &pfs.RepoSettings{Compression: &pfs.ChunkCompression{Codec: pfs.CompressionCodec_ZSTD, Level: 23}},
+		})
+		require.YesError(t, err)
+
+		repo := "repo"
+		_, err = env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo(repo),
+			Settings: &pfs.RepoSettings{Compression: &pfs.ChunkCompression{Codec: pfs.CompressionCodec_LZ4, Level: 9}},
+		})
 		require.NoError(t, err)
-		require.Equal(t, commitsetID, commitInfoB.Commit.ID)
+		data := strings.Repeat("compressible text ", 10000)
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader(data)))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "file", &buf))
+		require.Equal(t, data, buf.String())
 
-		commitInfos, err = env.PachClient.WaitCommitSetAll(commitsetID)
+		usage, err := env.PachClient.StorageUsage(repo)
 		require.NoError(t, err)
-		require.Equal(t, 3, len(commitInfos))
+		require.Equal(t, int64(len(data)), usage.LogicalBytes)
+		require.True(t, usage.PhysicalBytes < usage.LogicalBytes)
+	})
 
-		// The results _should_ be topologically sorted, but there are several
-		// branches with equivalent topological depth
-		expectedCommits := []string{
-			pfsdb.CommitKey(client.NewCommit("input", "staging", commitsetID)),
-			pfsdb.CommitKey(client.NewCommit("input", "master", commitsetID)),
-			pfsdb.CommitKey(client.NewCommit("output1", "staging", commitsetID)),
-		}
-		require.ElementsEqualUnderFn(t, expectedCommits, commitInfos, CommitInfoToID)
+	suite.Run("RepoChunking", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateBranch("output1", "master", "staging", "", nil))
-		require.NoError(t, env.PachClient.FinishCommit("output2", "staging", ""))
-		commitInfoC, err := env.PachClient.InspectCommit("output1", "master", "")
-		require.NoError(t, err)
-		require.Equal(t, commitsetID, commitInfoC.Commit.ID)
+		_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+			Repo:     client.NewRepo("invalid"),
+			Settings: &pfs.RepoSettings{ChunkAverageBits: 12},
+		})
+		require.YesError(t, err)
 
-		commitInfos, err = env.PachClient.WaitCommitSetAll(commitsetID)
+		for repo, settings := range map[string]*pfs.RepoSettings{
+			"default": nil,
+			"small": {
+				ChunkAverageBits: 12,
+				Chunking:         &pfs.ChunkingParams{MinSizeBytes: 1024, MaxSizeBytes: 16 * 1024, WindowBytes: 32},
+			},
+		} {
+			_, err := env.PachClient.PfsAPIClient.CreateRepo(env.PachClient.Ctx(), &pfs.CreateRepoRequest{
+				Repo:     client.NewRepo(repo),
+				Settings: settings,
+			})
+			require.NoError(t, err)
+		}
+		data := random.String(1024 * 1024)
+		for _, repo := range []string{"default", "small"} {
+			commit := client.NewCommit(repo, "master", "")
+			require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader(data)))
+			var buf bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(commit, "file", &buf))
+			require.Equal(t, data, buf.String())
+		}
+		usage, err := env.PachClient.StorageUsage("default")
 		require.NoError(t, err)
-		require.Equal(t, 5, len(commitInfos))
-		expectedCommits = append(expectedCommits, []string{
-			pfsdb.CommitKey(client.NewCommit("output1", "master", commitsetID)),
-			pfsdb.CommitKey(client.NewCommit("output2", "staging", commitsetID)),
-		}...)
-		require.ElementsEqualUnderFn(t, expectedCommits, commitInfos, CommitInfoToID)
+		require.Equal(t, int64(1), usage.Chunks)
+		usage, err = env.PachClient.StorageUsage("small")
+		require.NoError(t, err)
+		require.True(t, usage.Chunks >= 64)
 	})
 
-	suite.Run("ListAll", func(t *testing.T) {
+	suite.Run("ParallelPutFile", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		require.NoError(t, env.PachClient.CreateRepo("repo1"))
-		require.NoError(t, env.PachClient.CreateRepo("repo2"))
-		commit1 := client.NewCommit("repo1", "master", "")
-		commit2 := client.NewCommit("repo2", "master", "")
-		require.NoError(t, env.PachClient.PutFile(commit1, "file1", strings.NewReader("1")))
-		require.NoError(t, env.PachClient.PutFile(commit2, "file2", strings.NewReader("2")))
-		require.NoError(t, env.PachClient.PutFile(commit1, "file3", strings.NewReader("3")))
-		require.NoError(t, env.PachClient.PutFile(commit2, "file4", strings.NewReader("4")))
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		data := random.String(10*1024 + 100)
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader("old content")))
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader(data), client.WithParallelPutFile(3, 1024)))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "file", &buf))
+		require.Equal(t, data, buf.String())
 
-		cis, err := env.PachClient.ListCommitByRepo(client.NewRepo(""))
+		// Parts are appended to the file's existing content with its tag.
+		require.NoError(t, env.PachClient.PutFile(commit, "file", strings.NewReader(data), client.WithParallelPutFile(3, 1024), client.WithAppendPutFile()))
+		require.NoError(t, env.PachClient.PutFile(commit, "tagged", strings.NewReader(data), client.WithParallelPutFile(3, 1024), client.WithTagPutFile("tag")))
+		buf.Reset()
+		require.NoError(t, env.PachClient.GetFile(commit, "file", &buf))
+		require.Equal(t, data+data, buf.String())
+		tags, err := env.PachClient.ListTags(commit, "/tagged")
 		require.NoError(t, err)
-		require.Equal(t, 4, len(cis))
+		require.Equal(t, 1, len(tags))
+		require.Equal(t, "tag", tags[0].Tag)
+	})
+
+	suite.Run("FileSetAPI", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		c := env.PachClient
+		id1, err := c.CreateFileSet(func(mf client.ModifyFile) error {
+			if err := mf.PutFile("a", strings.NewReader("a")); err != nil {
+				return err
+			}
+			return mf.PutFile("b", strings.NewReader("b"))
+		})
+		require.NoError(t, err)
+		id2, err := c.CreateFileSet(func(mf client.ModifyFile) error {
+			if err := mf.PutFile("b", strings.NewReader("c"), client.WithAppendPutFile()); err != nil {
+				return err
+			}
+			return mf.DeleteFile("a")
+		})
+		require.NoError(t, err)
+		id, err := c.ComposeFileSet([]string{id1, id2}, time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, c.RenewFileSet(id, 2*time.Minute))
+		info, err := c.InspectFileSet(id)
+		require.NoError(t, err)
+		require.Equal(t, id, info.FileSetId)
+		require.True(t, info.Layers >= 2)
+		_, err = c.ComposeFileSet([]string{id1}, time.Hour)
+		require.YesError(t, err)
+
+		// The composed file set can be read without adding it to a commit.
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(client.NewFileSetCommit(id), "b", &buf))
+		require.Equal(t, "bc", buf.String())
+		_, err = c.InspectFile(client.NewFileSetCommit(id), "a")
+		require.YesError(t, err)
 
-		bis, err := env.PachClient.ListBranch("")
+		repo := "repo"
+		require.NoError(t, c.CreateRepo(repo))
+		commit, err := c.StartCommit(repo, "master")
 		require.NoError(t, err)
-		require.Equal(t, 2, len(bis))
+		require.NoError(t, c.AddFileSet(repo, "master", commit.ID, id))
+		require.NoError(t, c.FinishCommit(repo, "master", commit.ID))
+		buf.Reset()
+		require.NoError(t, c.GetFile(commit, "b", &buf))
+		require.Equal(t, "bc", buf.String())
+		total, err := c.GetFileSet(repo, "master", commit.ID)
+		require.NoError(t, err)
+		info, err = c.InspectFileSet(total)
+		require.NoError(t, err)
+		require.True(t, info.SizeBytes > 0)
 	})
 
-	suite.Run("MonkeyObjectStorage", func(t *testing.T) {
-		// This test cannot be done in parallel because the monkey object client
-		// modifies global state.
+	suite.Run("FileSetAlgebra", func(t *testing.T) {
+		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		seedStr := func(seed int64) string {
-			return fmt.Sprint("seed: ", strconv.FormatInt(seed, 10))
-		}
-		monkeyRetry := func(t *testing.T, f func() error, errMsg string) {
-			backoff.Retry(func() error {
-				err := f()
-				if err != nil {
-					require.True(t, obj.IsMonkeyError(err), "Expected monkey error (%s), %s", err.Error(), errMsg)
-				}
-				return err
-			}, backoff.NewInfiniteBackOff())
-		}
-		seed := time.Now().UTC().UnixNano()
-		obj.InitMonkeyTest(seed)
-		iterations := 25
-		repo := "input"
-		require.NoError(t, env.PachClient.CreateRepo(repo), seedStr(seed))
-		filePrefix := "file"
-		dataPrefix := "data"
-		var commit *pfs.Commit
-		var err error
-		buf := &bytes.Buffer{}
-		obj.EnableMonkeyTest()
-		defer obj.DisableMonkeyTest()
-		for i := 0; i < iterations; i++ {
-			file := filePrefix + strconv.Itoa(i)
-			data := dataPrefix + strconv.Itoa(i)
-			// Retry start commit until it eventually succeeds.
-			monkeyRetry(t, func() error {
-				commit, err = env.PachClient.StartCommit(repo, "master")
-				return err
-			}, seedStr(seed))
-			// Retry put file until it eventually succeeds.
-			monkeyRetry(t, func() error {
-				if err := env.PachClient.PutFile(commit, file, strings.NewReader(data)); err != nil {
-					// Verify that the file does not exist if an error occurred.
-					obj.DisableMonkeyTest()
-					defer obj.EnableMonkeyTest()
-					buf.Reset()
-					err := env.PachClient.GetFile(commit, file, buf)
-					require.True(t, errutil.IsNotFoundError(err), seedStr(seed))
-				}
-				return err
-			}, seedStr(seed))
-			// Retry get file until it eventually succeeds (before commit is finished).
-			monkeyRetry(t, func() error {
-				buf.Reset()
-				if err = env.PachClient.GetFile(commit, file, buf); err != nil {
+
+		c := env.PachClient
+		id, err := c.CreateFileSet(func(mf client.ModifyFile) error {
+			for _, p := range []string{"/a", "/logs/1", "/logs/2", "/data/logs", "/data/x"} {
+				if err := mf.PutFile(p, strings.NewReader(p)); err != nil {
 					return err
 				}
-				require.Equal(t, data, buf.String(), seedStr(seed))
-				return nil
-			}, seedStr(seed))
-			// Retry finish commit until it eventually succeeds.
-			monkeyRetry(t, func() error {
-				return env.PachClient.FinishCommit(repo, commit.Branch.Name, commit.ID)
-			}, seedStr(seed))
-			// Retry get file until it eventually succeeds (after commit is finished).
-			monkeyRetry(t, func() error {
-				buf.Reset()
-				if err = env.PachClient.GetFile(commit, file, buf); err != nil {
-					return err
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		globPaths := func(id string) []string {
+			var ps []string
+			require.NoError(t, c.GlobFile(client.NewFileSetCommit(id), "/**", func(fi *pfs.FileInfo) error {
+				if fi.FileType == pfs.FileType_FILE {
+					ps = append(ps, fi.File.Path)
 				}
-				require.Equal(t, data, buf.String(), seedStr(seed))
 				return nil
-			}, seedStr(seed))
+			}))
+			return ps
 		}
+
+		logs, err := c.FilterFileSet(id, "/logs/", "", false, 0)
+		require.NoError(t, err)
+		require.ElementsEqual(t, []string{"/logs/1", "/logs/2"}, globPaths(logs))
+		noLogs, err := c.FilterFileSet(id, "", "/logs", true, 0)
+		require.NoError(t, err)
+		require.ElementsEqual(t, []string{"/a", "/data/logs", "/data/x"}, globPaths(noLogs))
+		anyLogs, err := c.FilterFileSet(id, "", "/*/logs", false, 0)
+		require.NoError(t, err)
+		require.ElementsEqual(t, []string{"/data/logs"}, globPaths(anyLogs))
+		_, err = c.FilterFileSet(id, "", "", false, 0)
+		require.YesError(t, err)
+
+		rest, err := c.SubtractFileSet(id, logs, 0)
+		require.NoError(t, err)
+		require.ElementsEqual(t, globPaths(noLogs), globPaths(rest))
+		union, err := c.ComposeFileSet([]string{rest, logs}, 0)
+		require.NoError(t, err)
+		require.ElementsEqual(t, globPaths(id), globPaths(union))
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(client.NewFileSetCommit(rest), "/data/x", &buf))
+		require.Equal(t, "/data/x", buf.String())
 	})
 
-	suite.Run("FsckFix", func(t *testing.T) {
-		// TODO(optional 2.0): force-deleting the repo no longer creates dangling references
-		t.Skip("this test no longer creates invalid metadata")
+	suite.Run("ResumableUpload", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
 
-		input := "input"
-		output1 := "output1"
-		output2 := "output2"
-		require.NoError(t, env.PachClient.CreateRepo(input))
-		require.NoError(t, env.PachClient.CreateRepo(output1))
-		require.NoError(t, env.PachClient.CreateRepo(output2))
-		require.NoError(t, env.PachClient.CreateBranch(output1, "master", "", "", []*pfs.Branch{client.NewBranch(input, "master")}))
-		require.NoError(t, env.PachClient.CreateBranch(output2, "master", "", "", []*pfs.Branch{client.NewBranch(output1, "master")}))
-		numCommits := 10
-		for i := 0; i < numCommits; i++ {
-			require.NoError(t, env.PachClient.PutFile(client.NewCommit(input, "master", ""), "file", strings.NewReader("1")))
+		repo := "repo"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit, err := env.PachClient.StartCommit(repo, "master")
+		require.NoError(t, err)
+		upload, err := env.PachClient.StartUpload(commit, "upload")
+		require.NoError(t, err)
+		putPart := func(upload *client.Upload, number int64, content string) {
+			require.NoError(t, upload.PutPart(number, func(mf client.ModifyFile) error {
+				return mf.PutFile("file", strings.NewReader(content), client.WithAppendPutFile())
+			}))
 		}
-		require.NoError(t, env.PachClient.DeleteRepo(input, true))
-		require.NoError(t, env.PachClient.CreateRepo(input))
-		require.NoError(t, env.PachClient.CreateBranch(input, "master", "", "", nil))
+		putPart(upload, 1, "b")
+		putPart(upload, 0, "x")
 
-		// Fsck should fail because ???
-		require.YesError(t, env.PachClient.FsckFastExit())
+		// Starting the upload again resumes it, with the parts added so far.
+		upload, err = env.PachClient.StartUpload(commit, "upload")
+		require.NoError(t, err)
+		require.True(t, upload.HasPart(0))
+		require.True(t, upload.HasPart(1))
+		require.False(t, upload.HasPart(2))
+		// Parts can be put again, and are appended in order of their numbers.
+		putPart(upload, 0, "a")
+		putPart(upload, 2, "c")
+		info, err := env.PachClient.InspectUpload("upload")
+		require.NoError(t, err)
+		require.Equal(t, 3, len(info.Parts))
+		require.Equal(t, commit.ID, info.Commit.ID)
 
-		// Deleting output1 should fail because output2 is provenant on it
-		require.YesError(t, env.PachClient.DeleteRepo(output1, false))
+		// The upload can't be started into another commit.
+		other, err := env.PachClient.StartCommit(repo, "other")
+		require.NoError(t, err)
+		_, err = env.PachClient.StartUpload(other, "upload")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsUploadExistsErr(err))
 
-		// Deleting should now work due to fixing, must delete 2 before 1 though.
-		require.NoError(t, env.PachClient.DeleteRepo(output2, false))
-		require.NoError(t, env.PachClient.DeleteRepo(output1, false))
+		require.NoError(t, upload.Close())
+		_, err = env.PachClient.InspectUpload("upload")
+		require.YesError(t, err)
+		require.True(t, pfsserver.IsUploadNotFoundErr(err))
+		require.NoError(t, env.PachClient.FinishCommit(repo, "master", ""))
+		var buf bytes.Buffer
+		require.NoError(t, env.PachClient.GetFile(commit, "file", &buf))
+		require.Equal(t, "abc", buf.String())
+
+		// An aborted upload adds nothing to its commit.
+		upload, err = env.PachClient.StartUpload(other, "upload")
+		require.NoError(t, err)
+		putPart(upload, 0, "a")
+		require.NoError(t, upload.Abort())
+		require.YesError(t, upload.Close())
+		require.NoError(t, env.PachClient.FinishCommit(repo, "other", ""))
+		_, err = env.PachClient.InspectFile(other, "file")
+		require.YesError(t, err)
 	})
 
 	suite.Run("PutFileAtomic", func(t *testing.T) {
@@ -4976,60 +7908,61 @@ func TestPFS(suite *testing.T) {
 	// its history. This checks for a regression where the repo would sometimes
 	// lock.
 	suite.Run("AtomicHistory", func(t *testing.T) {
-		// TODO: There is no notion of file history in V2. We could potentially implement this, but
-		// we would need to spend some time thinking about the performance characteristics.
-		t.Skip("File history is not implemented in V2")
-		//	t.Parallel()
-		//  env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
-		//
-		//	repo := "test"
-		//	require.NoError(t, env.PachClient.CreateRepo(repo))
-		//	require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", nil))
-		//	aSize := 1 * 1024 * 1024
-		//	bSize := aSize + 1024
-		//
-		//	for i := 0; i < 10; i++ {
-		//		// create a file of all A's
-		//		a := strings.Repeat("A", aSize)
-		//		_, err := env.PachClient.PutFile(repo, "master", "/file", strings.NewReader(a))
-		//		require.NoError(t, err)
-		//
-		//		// sllowwwllly replace it with all B's
-		//		ctx, cancel := context.WithCancel(context.Background())
-		//		eg, ctx := errgroup.WithContext(ctx)
-		//		eg.Go(func() error {
-		//			b := strings.Repeat("B", bSize)
-		//			r := SlowReader{underlying: strings.NewReader(b)}
-		//			_, err := env.PachClient.PutFile(repo, "master", "/file", &r)
-		//			cancel()
-		//			return err
-		//		})
-		//
-		//		// should pull /file when it's all A's
-		//		eg.Go(func() error {
-		//			for {
-		//				fileInfos, err := env.PachClient.ListFileHistory(repo, "master", "/file", 1)
-		//				require.NoError(t, err)
-		//				require.Equal(t, len(fileInfos), 1)
-		//
-		//				// stop once B's have been written
-		//				select {
-		//				case <-ctx.Done():
-		//					return nil
-		//				default:
-		//					time.Sleep(1 * time.Millisecond)
-		//				}
-		//			}
-		//		})
-		//
-		//		require.NoError(t, eg.Wait())
-		//
-		//		// should pull /file when it's all B's
-		//		fileInfos, err := env.PachClient.ListFileHistory(repo, "master", "/file", 1)
-		//		require.NoError(t, err)
-		//		require.Equal(t, 1, len(fileInfos))
-		//		require.Equal(t, bSize, int(fileInfos[0].SizeBytes))
-		//	}
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		require.NoError(t, env.PachClient.CreateBranch(repo, "master", "", "", nil))
+		master := client.NewCommit(repo, "master", "")
+		aSize := 1 * 1024 * 1024
+		bSize := aSize + 1024
+
+		for i := 0; i < 10; i++ {
+			// create a file of all A's
+			a := strings.Repeat("A", aSize)
+			require.NoError(t, env.PachClient.PutFile(master, "/file", strings.NewReader(a)))
+
+			// sllowwwllly replace it with all B's
+			ctx, cancel := context.WithCancel(context.Background())
+			eg, ctx := errgroup.WithContext(ctx)
+			eg.Go(func() error {
+				b := strings.Repeat("B", bSize)
+				r := SlowReader{underlying: strings.NewReader(b)}
+				err := env.PachClient.PutFile(master, "/file", &r)
+				cancel()
+				return err
+			})
+
+			// should pull /file when it's all A's
+			eg.Go(func() error {
+				for {
+					fileInfos, err := env.PachClient.ListFileHistory(master, "/file", 1)
+					if err != nil {
+						return err
+					}
+					if len(fileInfos) != 1 {
+						return errors.Errorf("expected 1 version of /file, got %d", len(fileInfos))
+					}
+
+					// stop once B's have been written
+					select {
+					case <-ctx.Done():
+						return nil
+					default:
+						time.Sleep(1 * time.Millisecond)
+					}
+				}
+			})
+
+			require.NoError(t, eg.Wait())
+
+			// should pull /file when it's all B's
+			fileInfos, err := env.PachClient.ListFileHistory(master, "/file", 1)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(fileInfos))
+			require.Equal(t, bSize, int(fileInfos[0].SizeBytes))
+		}
 	})
 
 	// TestTrigger tests branch triggers
@@ -5400,6 +8333,123 @@ func TestPFS(suite *testing.T) {
 			require.NotNil(t, bi.Head)
 			require.Equal(t, cHead, bi.Head.ID)
 		})
+
+		t.Run("Files", func(t *testing.T) {
+			require.NoError(t, c.CreateRepo("files"))
+			require.NoError(t, c.CreateBranchTrigger("files", "trigger", "", "", &pfs.Trigger{
+				Branch: "master",
+				Files:  3,
+			}))
+			bi, err := c.InspectBranch("files", "trigger")
+			require.NoError(t, err)
+			head := bi.Head
+			triggered := func() bool {
+				bi, err := c.InspectBranch("files", "trigger")
+				require.NoError(t, err)
+				if head.GetID() == bi.Head.GetID() {
+					return false
+				}
+				head = bi.Head
+				return true
+			}
+
+			masterHead := client.NewCommit("files", "master", "")
+			require.NoError(t, c.PutFile(masterHead, "file1", strings.NewReader("foo")))
+			require.False(t, triggered())
+			// Changing a file again doesn't count it twice.
+			require.NoError(t, c.PutFile(masterHead, "file1", strings.NewReader("changed")))
+			require.False(t, triggered())
+			require.NoError(t, c.PutFile(masterHead, "file2", strings.NewReader("bar")))
+			require.False(t, triggered())
+			require.NoError(t, c.PutFile(masterHead, "file3", strings.NewReader("baz")))
+			require.True(t, triggered())
+
+			// Deleting a file counts as a change.
+			require.NoError(t, c.DeleteFile(masterHead, "file1"))
+			require.False(t, triggered())
+			require.NoError(t, c.PutFile(masterHead, "file2", strings.NewReader("changed")))
+			require.False(t, triggered())
+			require.NoError(t, c.PutFile(masterHead, "file4", strings.NewReader("buzz")))
+			require.True(t, triggered())
+		})
+
+		t.Run("PathPattern", func(t *testing.T) {
+			require.NoError(t, c.CreateRepo("pattern"))
+			require.NoError(t, c.CreateBranchTrigger("pattern", "trigger", "", "", &pfs.Trigger{
+				Branch:      "master",
+				PathPattern: "/**/_SUCCESS",
+			}))
+			bi, err := c.InspectBranch("pattern", "trigger")
+			require.NoError(t, err)
+			head := bi.Head
+
+			masterHead := client.NewCommit("pattern", "master", "")
+			require.NoError(t, c.PutFile(masterHead, "output/part-0", strings.NewReader("foo")))
+			require.NoError(t, c.PutFile(masterHead, "output/part-1", strings.NewReader("bar")))
+			bi, err = c.InspectBranch("pattern", "trigger")
+			require.NoError(t, err)
+			require.Equal(t, head, bi.Head)
+
+			require.NoError(t, c.PutFile(masterHead, "output/_SUCCESS", strings.NewReader("")))
+			bi, err = c.InspectBranch("pattern", "trigger")
+			require.NoError(t, err)
+			require.NotEqual(t, head, bi.Head)
+			head = bi.Head
+			bi, err = c.InspectBranch("pattern", "master")
+			require.NoError(t, err)
+			require.Equal(t, head.ID, bi.Head.ID)
+
+			// The marker is unchanged since the last trigger.
+			require.NoError(t, c.PutFile(masterHead, "output/part-2", strings.NewReader("baz")))
+			bi, err = c.InspectBranch("pattern", "trigger")
+			require.NoError(t, err)
+			require.Equal(t, head, bi.Head)
+		})
+
+		t.Run("InspectAndRun", func(t *testing.T) {
+			require.NoError(t, c.CreateRepo("run"))
+			require.NoError(t, c.CreateBranchTrigger("run", "trigger", "", "", &pfs.Trigger{
+				Branch:   "master",
+				Commits:  3,
+				CronSpec: "0 0 1 1 *",
+			}))
+			info, err := c.InspectTrigger("run", "trigger")
+			require.NoError(t, err)
+			require.False(t, info.Status.Pending)
+			require.False(t, info.Ready)
+			_, err = c.InspectTrigger("run", "master")
+			require.YesError(t, err)
+
+			require.NoError(t, c.PutFile(client.NewCommit("run", "master", ""), "file", strings.NewReader("foo")))
+			info, err = c.InspectTrigger("run", "trigger")
+			require.NoError(t, err)
+			require.True(t, info.Status.Pending)
+			require.Equal(t, int64(1), info.Commits)
+			require.NotNil(t, info.NextCron)
+			require.False(t, info.Ready)
+
+			resp, err := c.RunTrigger("run", "trigger", false)
+			require.NoError(t, err)
+			require.False(t, resp.Fired)
+			require.True(t, resp.Trigger.Status.Pending)
+
+			resp, err = c.RunTrigger("run", "trigger", true)
+			require.NoError(t, err)
+			require.True(t, resp.Fired)
+			require.False(t, resp.Trigger.Status.Pending)
+			require.NotNil(t, resp.Trigger.Status.LastTriggered)
+			// The trigger's head is an alias of master's head.
+			master, err := c.InspectBranch("run", "master")
+			require.NoError(t, err)
+			trigger, err := c.InspectCommit("run", "trigger", "")
+			require.NoError(t, err)
+			require.Equal(t, master.Head.ID, trigger.ParentCommit.ID)
+
+			// There's nothing left to move the branch to.
+			resp, err = c.RunTrigger("run", "trigger", true)
+			require.NoError(t, err)
+			require.False(t, resp.Fired)
+		})
 	})
 
 	// TriggerValidation tests branch trigger validation
@@ -5429,6 +8479,16 @@ func TestPFS(suite *testing.T) {
 			Branch:  "master",
 			Commits: -1,
 		}))
+		// Can't have negative file count
+		require.YesError(t, c.CreateBranchTrigger("repo", "trigger", "", "", &pfs.Trigger{
+			Branch: "master",
+			Files:  -1,
+		}))
+		// Path pattern doesn't parse
+		require.YesError(t, c.CreateBranchTrigger("repo", "trigger", "", "", &pfs.Trigger{
+			Branch:      "master",
+			PathPattern: "[",
+		}))
 
 		// a -> b (valid, sets up the next test)
 		require.NoError(t, c.CreateBranchTrigger("repo", "b", "", "", &pfs.Trigger{
@@ -5667,6 +8727,91 @@ func TestPFS(suite *testing.T) {
 		}))
 		require.Equal(t, 0, len(expected))
 	})
+
+	suite.Run("ListTagsAndDeleteTag", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+			require.NoError(t, mf.PutFile("dir/a", strings.NewReader("foo\n"), client.WithTagPutFile("tag1")))
+			require.NoError(t, mf.PutFile("dir/a", strings.NewReader("bar\n"), client.WithTagPutFile("tag2")))
+			require.NoError(t, mf.PutFile("dir/b", strings.NewReader("baz\n"), client.WithTagPutFile("tag1")))
+			require.NoError(t, mf.PutFile("c", strings.NewReader("qux\n"), client.WithTagPutFile("tag1")))
+			return nil
+		}))
+
+		tagInfos, err := env.PachClient.ListTags(commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(tagInfos))
+		require.Equal(t, "tag1", tagInfos[0].Tag)
+		require.Equal(t, uint64(3), tagInfos[0].NumFiles)
+		require.Equal(t, uint64(12), tagInfos[0].SizeBytes)
+		require.Equal(t, "tag2", tagInfos[1].Tag)
+		require.Equal(t, uint64(1), tagInfos[1].NumFiles)
+		tagInfos, err = env.PachClient.ListTags(commit, "dir/b")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(tagInfos))
+		require.Equal(t, "tag1", tagInfos[0].Tag)
+
+		// Deleting a tag leaves the files written with other tags in place.
+		require.NoError(t, env.PachClient.DeleteTag(commit, "dir", "tag1"))
+		tagInfos, err = env.PachClient.ListTags(commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(tagInfos))
+		require.Equal(t, uint64(1), tagInfos[0].NumFiles)
+		buf := &bytes.Buffer{}
+		require.NoError(t, env.PachClient.GetFile(commit, "dir/a", buf))
+		require.Equal(t, "bar\n", buf.String())
+
+		require.NoError(t, env.PachClient.DeleteTag(commit, "", "tag1"))
+		tagInfos, err = env.PachClient.ListTags(commit, "/")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(tagInfos))
+		require.Equal(t, "tag2", tagInfos[0].Tag)
+		require.YesError(t, env.PachClient.DeleteTag(commit, "", ""))
+	})
+
+	suite.Run("ListFileTag", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, tu.NewTestDBConfig(t))
+		repo := "test"
+		require.NoError(t, env.PachClient.CreateRepo(repo))
+		commit := client.NewCommit(repo, "master", "")
+		require.NoError(t, env.PachClient.WithModifyFileClient(commit, func(mf client.ModifyFile) error {
+			require.NoError(t, mf.PutFile("dir/a", strings.NewReader("foo\n"), client.WithTagPutFile("tag1")))
+			require.NoError(t, mf.PutFile("dir/a", strings.NewReader("bar\n"), client.WithTagPutFile("tag2")))
+			require.NoError(t, mf.PutFile("dir/b", strings.NewReader("baz\n"), client.WithTagPutFile("tag2")))
+			require.NoError(t, mf.PutFile("c", strings.NewReader("qux\n"), client.WithTagPutFile("tag1")))
+			return nil
+		}))
+
+		fileInfos, err := env.PachClient.ListFileAll(commit, "dir", client.WithTagListFile("tag2"))
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fileInfos))
+		for _, fi := range fileInfos {
+			require.Equal(t, "tag2", fi.File.Tag)
+		}
+		fileInfos, err = env.PachClient.ListFileAll(commit, "/", client.WithTagListFile("tag1"))
+		require.NoError(t, err)
+		require.Equal(t, 2, len(fileInfos))
+		require.Equal(t, "/c", fileInfos[0].File.Path)
+		require.Equal(t, "/dir/", fileInfos[1].File.Path)
+
+		fileInfos, err = env.PachClient.GlobFileAll(commit, "/**", client.WithTagGlobFile("tag1"))
+		require.NoError(t, err)
+		var paths []string
+		for _, fi := range fileInfos {
+			if fi.FileType == pfs.FileType_FILE {
+				paths = append(paths, fi.File.Path)
+			}
+		}
+		require.ElementsEqual(t, []string{"/c", "/dir/a"}, paths)
+		fileInfos, err = env.PachClient.GlobFileAll(commit, "/**", client.WithTagGlobFile("missing"))
+		require.NoError(t, err)
+		require.Equal(t, 0, len(fileInfos))
+	})
 }
 
 var (
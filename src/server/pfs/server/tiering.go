@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/chunk"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+// tierColdDataForever periodically moves the data that is only referenced by
+// commits older than their repo's cold_storage_after setting, or by commits on
+// branches with the COLD storage class, to cold storage.
+func (d *driver) tierColdDataForever(ctx context.Context, intervalStr string) error {
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid cold tiering interval %q", intervalStr)
+	}
+	if !d.storage.ChunkStorage().HasColdStorage() {
+		return errors.Errorf("cold tiering is enabled, but no cold storage is configured")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		case <-ticker.C:
+		}
+		if err := d.tierColdData(ctx); err != nil {
+			log.Errorf("error moving data to cold storage: %v", err)
+		}
+	}
+}
+
+// tierColdData moves the chunks that are referenced by cold commits, and not
+// by any other commit, to cold storage.
+func (d *driver) tierColdData(ctx context.Context) error {
+	now := time.Now()
+	coldBranches := make(map[string]bool)
+	branchInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadOnly(ctx).List(branchInfo, col.DefaultOptions(), func(string) error {
+		if branchInfo.StoragePolicy.GetStorageClass() == pfs.StorageClass_COLD {
+			coldBranches[pfsdb.BranchKey(branchInfo.Branch)] = true
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var hotCommits, coldCommits []*pfs.Commit
+	repoInfo := &pfs.RepoInfo{}
+	if err := d.repos.ReadOnly(ctx).List(repoInfo, col.DefaultOptions(), func(string) error {
+		var after time.Duration
+		if repoInfo.Settings.GetColdStorageAfter() != nil {
+			var err error
+			if after, err = types.DurationFromProto(repoInfo.Settings.ColdStorageAfter); err != nil {
+				return errors.EnsureStack(err)
+			}
+		}
+		commitInfo := &pfs.CommitInfo{}
+		return d.commits.ReadOnly(ctx).GetByIndex(pfsdb.CommitsRepoIndex, pfsdb.RepoKey(repoInfo.Repo), commitInfo, col.DefaultOptions(), func(string) error {
+			commit := proto.Clone(commitInfo.Commit).(*pfs.Commit)
+			if isColdCommit(commitInfo, after, coldBranches[pfsdb.BranchKey(commit.Branch)], now) {
+				coldCommits = append(coldCommits, commit)
+			} else {
+				hotCommits = append(hotCommits, commit)
+			}
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	if len(coldCommits) == 0 {
+		return nil
+	}
+	hot, err := d.commitChunks(ctx, hotCommits)
+	if err != nil {
+		return err
+	}
+	cold, err := d.commitChunks(ctx, coldCommits)
+	if err != nil {
+		return err
+	}
+	chunks := d.storage.ChunkStorage()
+	var moved int
+	for id := range cold {
+		if _, ok := hot[id]; ok {
+			continue
+		}
+		if err := chunks.MoveToCold(ctx, chunk.ID(id)); err != nil {
+			return err
+		}
+		moved++
+	}
+	log.Infof("cold tiering: moved %d chunks of %d commits to cold storage", moved, len(coldCommits))
+	return nil
+}
+
+// isColdCommit returns true if the commit's data belongs in cold storage,
+// because it was archived and hasn't been recalled since, because it's on a
+// cold branch and hasn't been recalled since it was finished, or because it
+// was finished (or recalled) more than after ago.
+func isColdCommit(commitInfo *pfs.CommitInfo, after time.Duration, coldBranch bool, now time.Time) bool {
+	if commitInfo.Finished == nil {
+		return false
+	}
+	var recalled time.Time
+	if commitInfo.Recalled != nil {
+		recalled, _ = types.TimestampFromProto(commitInfo.Recalled)
+	}
+	if commitInfo.Archived != nil {
+		archived, err := types.TimestampFromProto(commitInfo.Archived)
+		if err == nil && !recalled.After(archived) {
+			return true
+		}
+	}
+	since, err := types.TimestampFromProto(commitInfo.Finished)
+	if err != nil {
+		return false
+	}
+	if coldBranch && !recalled.After(since) {
+		return true
+	}
+	if after <= 0 {
+		return false
+	}
+	if recalled.After(since) {
+		since = recalled
+	}
+	return now.Sub(since) > after
+}
+
+// commitChunks returns the set of chunks referenced by commits.
+func (d *driver) commitChunks(ctx context.Context, commits []*pfs.Commit) (map[string]struct{}, error) {
+	chunks := make(map[string]struct{})
+	for _, commit := range commits {
+		id, err := d.getFileSet(ctx, commit)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.storage.Chunks(ctx, *id, func(id chunk.ID) error {
+			chunks[string(id)] = struct{}{}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return chunks, nil
+}
+
+// recallCommit moves the chunks of a commit back to hot storage, and records
+// when it did so that they aren't moved to cold storage again until the
+// commit's repo's cold_storage_after setting elapses.
+func (d *driver) recallCommit(ctx context.Context, commit *pfs.Commit) error {
+	chunks := d.storage.ChunkStorage()
+	if !chunks.HasColdStorage() {
+		return errors.Errorf("no cold storage is configured")
+	}
+	commitInfo, err := d.getCommit(ctx, commit)
+	if err != nil {
+		return err
+	}
+	// The commit is marked as recalled first, so that background tiering
+	// doesn't move its chunks back while they're being recalled.
+	if err := d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		ci := &pfs.CommitInfo{}
+		return d.commits.ReadWrite(txnCtx.SqlTx).Update(pfsdb.CommitKey(commitInfo.Commit), ci, func() error {
+			ci.Recalled = types.TimestampNow()
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	id, err := d.getFileSet(ctx, commitInfo.Commit)
+	if err != nil {
+		return err
+	}
+	return d.storage.Chunks(ctx, *id, func(id chunk.ID) error {
+		return chunks.MoveToHot(ctx, id)
+	})
+}
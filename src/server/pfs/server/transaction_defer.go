@@ -16,6 +16,8 @@ type Propagater struct {
 
 	// Branches that were modified (new commits or head commit was moved to an old commit)
 	branches map[string]*pfs.Branch
+	// File sets held for stored transactions, by transaction ID, to release
+	fileSets map[string][]string
 }
 
 func (a *apiServer) NewPropagater(txnCtx *txncontext.TransactionContext) txncontext.PfsPropagater {
@@ -23,6 +25,7 @@ func (a *apiServer) NewPropagater(txnCtx *txncontext.TransactionContext) txncont
 		d:        a.driver,
 		txnCtx:   txnCtx,
 		branches: map[string]*pfs.Branch{},
+		fileSets: map[string][]string{},
 	}
 }
 
@@ -36,9 +39,21 @@ func (t *Propagater) PropagateBranch(branch *pfs.Branch) error {
 	return nil
 }
 
+// ReleaseFileSets marks the file sets held for the stored transaction txnID
+// as needing to be released once the transaction successfully ends.  This will
+// be performed by the Run function.
+func (t *Propagater) ReleaseFileSets(txnID string, fileSetIDs []string) {
+	t.fileSets[txnID] = append(t.fileSets[txnID], fileSetIDs...)
+}
+
 // Run performs any final tasks and cleanup tasks in the transaction, such as
-// propagating branches
+// propagating branches and releasing file sets
 func (t *Propagater) Run() error {
+	for txnID, fileSetIDs := range t.fileSets {
+		if err := t.d.releaseTransactionFileSets(t.txnCtx, txnID, fileSetIDs); err != nil {
+			return err
+		}
+	}
 	branches := make([]*pfs.Branch, 0, len(t.branches))
 	for _, branch := range t.branches {
 		branches = append(branches, branch)
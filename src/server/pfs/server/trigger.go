@@ -4,9 +4,11 @@ import (
 	"time"
 
 	units "github.com/docker/go-units"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/robfig/cron"
 
+	"github.com/pachyderm/pachyderm/v2/src/auth"
 	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
@@ -63,14 +65,11 @@ func (d *driver) triggerCommit(
 				}
 
 				if triggered {
-					aliasCommit, err := d.aliasCommit(txnCtx, newHead.Commit, bi.Branch)
+					aliasCommit, err := d.fireTrigger(txnCtx, bi.Branch, newHead)
 					if err != nil {
 						return nil, err
 					}
 					triggeredBranches[branch.Name] = aliasCommit
-					if err := txnCtx.PropagateBranch(bi.Branch); err != nil {
-						return nil, err
-					}
 				}
 			}
 		}
@@ -84,9 +83,40 @@ func (d *driver) triggerCommit(
 	return nil
 }
 
+// fireTrigger moves the head of branch to an alias of newHead, the head of the
+// branch that branch's trigger is on.
+func (d *driver) fireTrigger(txnCtx *txncontext.TransactionContext, branch *pfs.Branch, newHead *pfs.CommitInfo) (*pfs.CommitInfo, error) {
+	aliasCommit, err := d.aliasCommit(txnCtx, newHead.Commit, branch, pfs.HeadChangeCause_TRIGGER)
+	if err != nil {
+		return nil, err
+	}
+	if err := pfsdb.SetBranchTriggeredTx(txnCtx.SqlTx, branch, txnTime(txnCtx)); err != nil {
+		return nil, err
+	}
+	if err := txnCtx.PropagateBranch(branch); err != nil {
+		return nil, err
+	}
+	return aliasCommit, nil
+}
+
 // isTriggered checks to see if a branch should be updated from oldHead to
 // newHead based on a trigger.
 func (d *driver) isTriggered(txnCtx *txncontext.TransactionContext, t *pfs.Trigger, oldHead, newHead *pfs.CommitInfo) (bool, error) {
+	var now time.Time
+	if newHead.Finished != nil {
+		var err error
+		if now, err = types.TimestampFromProto(newHead.Finished); err != nil {
+			return false, errors.EnsureStack(err)
+		}
+	}
+	return d.evalTrigger(txnCtx, t, oldHead, newHead, now, t.Commits, &pfs.TriggerInfo{})
+}
+
+// evalTrigger sets the progress of a trigger from oldHead to newHead towards
+// each of its conditions in info, and returns whether they're met. The cron
+// spec is evaluated at now, and commits are counted up to maxCommits, or all
+// of them if it isn't positive.
+func (d *driver) evalTrigger(txnCtx *txncontext.TransactionContext, t *pfs.Trigger, oldHead, newHead *pfs.CommitInfo, now time.Time, maxCommits int64, info *pfs.TriggerInfo) (bool, error) {
 	result := t.All
 	merge := func(cond bool) {
 		if t.All {
@@ -105,7 +135,8 @@ func (d *driver) isTriggered(txnCtx *txncontext.TransactionContext, t *pfs.Trigg
 		if oldHead != nil {
 			oldSize = oldHead.SizeBytes
 		}
-		merge(int64(newHead.SizeBytes-oldSize) >= size)
+		info.SizeBytes = int64(newHead.SizeBytes - oldSize)
+		merge(info.SizeBytes >= size)
 	}
 	if t.CronSpec != "" {
 		// Shouldn't be possible to error here since we validate on ingress
@@ -114,27 +145,24 @@ func (d *driver) isTriggered(txnCtx *txncontext.TransactionContext, t *pfs.Trigg
 			// Shouldn't be possible to error here since we validate on ingress
 			return false, errors.EnsureStack(err)
 		}
-		var oldTime, newTime time.Time
+		var oldTime time.Time
 		if oldHead != nil && oldHead.Finished != nil {
 			oldTime, err = types.TimestampFromProto(oldHead.Finished)
 			if err != nil {
 				return false, errors.EnsureStack(err)
 			}
 		}
-		if newHead.Finished != nil {
-			newTime, err = types.TimestampFromProto(newHead.Finished)
-			if err != nil {
-				return false, errors.EnsureStack(err)
-			}
+		next := schedule.Next(oldTime)
+		if info.NextCron, err = types.TimestampProto(next); err != nil {
+			return false, errors.EnsureStack(err)
 		}
-		merge(schedule.Next(oldTime).Before(newTime))
+		merge(next.Before(now))
 	}
 	if t.Commits != 0 {
 		ci := newHead
-		var commits int64
-		for commits < t.Commits {
-			commits++
-			if ci.ParentCommit != nil && oldHead.Commit.ID != ci.ParentCommit.ID {
+		for maxCommits <= 0 || info.Commits < maxCommits {
+			info.Commits++
+			if ci.ParentCommit != nil && (oldHead == nil || oldHead.Commit.ID != ci.ParentCommit.ID) {
 				var err error
 				ci, err = d.resolveCommit(txnCtx.SqlTx, ci.ParentCommit)
 				if err != nil {
@@ -144,11 +172,168 @@ func (d *driver) isTriggered(txnCtx *txncontext.TransactionContext, t *pfs.Trigg
 				break
 			}
 		}
-		merge(commits == t.Commits)
+		merge(info.Commits >= t.Commits)
+	}
+	if t.Files != 0 || t.PathPattern != "" {
+		var err error
+		info.Files, info.PathMatched, err = d.triggerChangedFiles(txnCtx, t, oldHead, newHead)
+		if err != nil {
+			return false, err
+		}
+		if t.Files != 0 {
+			merge(info.Files >= t.Files)
+		}
+		if t.PathPattern != "" {
+			merge(info.PathMatched)
+		}
 	}
 	return result, nil
 }
 
+// inspectTrigger returns the state of the trigger on branch, and the commit
+// that it would move the branch's head to, if it's pending.
+func (d *driver) inspectTrigger(txnCtx *txncontext.TransactionContext, branch *pfs.Branch) (*pfs.TriggerInfo, *pfs.CommitInfo, error) {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+		return nil, nil, err
+	}
+	branchInfo, err := d.inspectBranch(txnCtx, branch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if branchInfo.Trigger == nil {
+		return nil, nil, errors.Errorf("branch %s doesn't have a trigger", branchInfo.Branch)
+	}
+	info := &pfs.TriggerInfo{
+		Branch:  branchInfo.Branch,
+		Trigger: branchInfo.Trigger,
+	}
+	stats, headInfo, err := d.branchStats(txnCtx, branchInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	info.Status = stats.Trigger
+	if !info.Status.Pending {
+		return info, nil, nil
+	}
+	// A trigger only moves the branch to finished commits, so the newest one
+	// on the branch that the trigger is on is what it would move it to.
+	newHead, err := d.resolveCommit(txnCtx.SqlTx, proto.Clone(info.Status.Head).(*pfs.Commit))
+	if err != nil {
+		return nil, nil, err
+	}
+	for newHead.Finished == nil {
+		if newHead.ParentCommit == nil || (headInfo != nil && newHead.ParentCommit.ID == headInfo.Commit.ID) {
+			return info, nil, nil
+		}
+		if newHead, err = d.resolveCommit(txnCtx.SqlTx, newHead.ParentCommit); err != nil {
+			return nil, nil, err
+		}
+	}
+	if info.Ready, err = d.evalTrigger(txnCtx, info.Trigger, headInfo, newHead, txnTime(txnCtx), 0, info); err != nil {
+		return nil, nil, err
+	}
+	return info, newHead, nil
+}
+
+// runTrigger evaluates the trigger on branch, and fires it if it's ready or
+// force is set. It returns whether it fired, and the state of the trigger.
+func (d *driver) runTrigger(txnCtx *txncontext.TransactionContext, branch *pfs.Branch, force bool) (*pfs.RunTriggerResponse, error) {
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, branch.Repo.QualifiedName(), auth.Permission_REPO_CREATE_BRANCH); err != nil {
+		return nil, err
+	}
+	info, newHead, err := d.inspectTrigger(txnCtx, branch)
+	if err != nil {
+		return nil, err
+	}
+	if newHead == nil || !(info.Ready || force) {
+		return &pfs.RunTriggerResponse{Trigger: info}, nil
+	}
+	aliasCommit, err := d.fireTrigger(txnCtx, info.Branch, newHead)
+	if err != nil {
+		return nil, err
+	}
+	// Branches that trigger on this one may fire in turn.
+	if err := d.triggerCommit(txnCtx, aliasCommit.Commit); err != nil {
+		return nil, err
+	}
+	if info, _, err = d.inspectTrigger(txnCtx, branch); err != nil {
+		return nil, err
+	}
+	return &pfs.RunTriggerResponse{Fired: true, Trigger: info}, nil
+}
+
+// triggerChangedFiles returns the number of files that were added, changed
+// or deleted between oldHead and newHead, and whether any of their paths
+// match t's path pattern.
+func (d *driver) triggerChangedFiles(txnCtx *txncontext.TransactionContext, t *pfs.Trigger, oldHead, newHead *pfs.CommitInfo) (int64, bool, error) {
+	match := func(string) bool { return false }
+	if t.PathPattern != "" {
+		var err error
+		if match, err = globMatchFunction(cleanPath(t.PathPattern)); err != nil {
+			// Shouldn't be possible to error here since we validate on ingress
+			return 0, false, err
+		}
+	}
+	// Alias commits, such as the heads of triggered branches, have the same
+	// files as the commits they alias, which, unlike an alias made by this
+	// transaction, can be read outside of it.
+	contentCommit := func(ci *pfs.CommitInfo) (*pfs.Commit, error) {
+		for ci.Origin.GetKind() == pfs.OriginKind_ALIAS && ci.ParentCommit != nil {
+			var err error
+			if ci, err = d.resolveCommit(txnCtx.SqlTx, ci.ParentCommit); err != nil {
+				return nil, err
+			}
+		}
+		return ci.Commit, nil
+	}
+	// Without an old head, everything in newHead is a change.
+	oldFile := &pfs.File{Path: "/"}
+	if oldHead != nil {
+		var err error
+		if oldFile.Commit, err = contentCommit(oldHead); err != nil {
+			return 0, false, err
+		}
+	}
+	newCommit, err := contentCommit(newHead)
+	if err != nil {
+		return 0, false, err
+	}
+	var files int64
+	var matched bool
+	if err := d.diffFile(txnCtx.ClientContext, oldFile, newCommit.NewFile("/"), func(oldFi, newFi *pfs.FileInfo) error {
+		p, ok := changedFilePath(oldFi, newFi)
+		if !ok {
+			return nil
+		}
+		files++
+		matched = matched || match(cleanPath(p))
+		return nil
+	}); err != nil {
+		return 0, false, err
+	}
+	return files, matched, nil
+}
+
+// triggerStatus sets the head of the branch that branchInfo's trigger is on in
+// status, and whether it's pending, which it is unless the branch's head,
+// headInfo, is that commit or an alias of it.
+func (d *driver) triggerStatus(txnCtx *txncontext.TransactionContext, branchInfo *pfs.BranchInfo, headInfo *pfs.CommitInfo, status *pfs.BranchTriggerStatus) error {
+	triggerInfo := &pfs.BranchInfo{}
+	if err := d.branches.ReadWrite(txnCtx.SqlTx).Get(pfsdb.BranchKey(branchInfo.Branch.Repo.NewBranch(branchInfo.Trigger.Branch)), triggerInfo); err != nil {
+		if col.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	status.Head = triggerInfo.Head
+	if status.Head == nil {
+		return nil
+	}
+	status.Pending = headInfo == nil ||
+		(headInfo.Commit.ID != status.Head.ID && headInfo.ParentCommit.GetID() != status.Head.ID)
+	return nil
+}
+
 // validateTrigger returns an error if a trigger is invalid
 func (d *driver) validateTrigger(txnCtx *txncontext.TransactionContext, branch *pfs.Branch, trigger *pfs.Trigger) error {
 	if trigger == nil {
@@ -166,6 +351,14 @@ func (d *driver) validateTrigger(txnCtx *txncontext.TransactionContext, branch *
 	if trigger.Commits < 0 {
 		return errors.Errorf("can't trigger on a negative number of commits")
 	}
+	if trigger.Files < 0 {
+		return errors.Errorf("can't trigger on a negative number of files")
+	}
+	if trigger.PathPattern != "" {
+		if _, err := globMatchFunction(cleanPath(trigger.PathPattern)); err != nil {
+			return errors.Wrapf(err, "invalid trigger path pattern")
+		}
+	}
 	bis, err := d.listBranch(txnCtx.ClientContext, branch.Repo, false)
 	if err != nil {
 		return err
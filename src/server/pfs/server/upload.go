@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+// startUpload starts the upload id into commit, which must be open. Starting
+// an upload that was already started into the same commit returns it as it
+// is, so that a client can resume it.
+func (d *driver) startUpload(txnCtx *txncontext.TransactionContext, id string, commit *pfs.Commit) (*pfs.UploadInfo, error) {
+	if id == "" {
+		return nil, errors.Errorf("upload ID must be set")
+	}
+	commitInfo, err := d.resolveCommit(txnCtx.SqlTx, commit)
+	if err != nil {
+		return nil, err
+	}
+	info, err := pfsdb.GetUploadTx(txnCtx.SqlTx, id)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		if pfsdb.CommitKey(info.Commit) != pfsdb.CommitKey(commitInfo.Commit) {
+			return nil, pfsserver.ErrUploadExists{UploadID: id, Commit: info.Commit}
+		}
+		return info, nil
+	}
+	if commitInfo.Finished != nil {
+		return nil, pfsserver.ErrCommitFinished{Commit: commitInfo.Commit}
+	}
+	if err := pfsdb.CreateUploadTx(txnCtx.SqlTx, id, commitInfo.Commit, txnTime(txnCtx).Add(uploadTTL)); err != nil {
+		return nil, err
+	}
+	return pfsdb.GetUploadTx(txnCtx.SqlTx, id)
+}
+
+// addUploadPart adds the file set fileSetID to the upload id as its part
+// number. The file set is held for the upload, and the holds on its other
+// parts are extended along with it.
+func (d *driver) addUploadPart(ctx context.Context, id string, number int64, fileSetID fileset.ID) error {
+	if number < 0 {
+		return errors.Errorf("upload part number (%d) must not be negative", number)
+	}
+	size, err := d.storage.SizeOf(ctx, fileSetID)
+	if err != nil {
+		return err
+	}
+	return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		info, err := d.getUpload(txnCtx, id, auth.Permission_REPO_WRITE)
+		if err != nil {
+			return err
+		}
+		// A part that's replaced is released, unless its file set is also
+		// another part.
+		held := make(map[string]bool)
+		for _, part := range info.Parts {
+			if part.Number != number {
+				held[part.FileSetId] = true
+			}
+		}
+		for _, part := range info.Parts {
+			if part.Number == number && part.FileSetId != fileSetID.HexString() {
+				if held[part.FileSetId] {
+					continue
+				}
+				if err := d.releaseUploadPart(txnCtx, id, part); err != nil {
+					return err
+				}
+				continue
+			}
+			partID, err := fileset.ParseID(part.FileSetId)
+			if err != nil {
+				return err
+			}
+			if err := d.storage.HoldTx(txnCtx.SqlTx, uploadHolder(id), *partID, uploadTTL); err != nil {
+				return err
+			}
+		}
+		if err := d.storage.HoldTx(txnCtx.SqlTx, uploadHolder(id), fileSetID, uploadTTL); err != nil {
+			return err
+		}
+		return pfsdb.PutUploadPartTx(txnCtx.SqlTx, id, &pfs.UploadPart{
+			Number:    number,
+			FileSetId: fileSetID.HexString(),
+			SizeBytes: size,
+		}, txnTime(txnCtx).Add(uploadTTL))
+	})
+}
+
+func (d *driver) inspectUpload(ctx context.Context, id string) (*pfs.UploadInfo, error) {
+	var info *pfs.UploadInfo
+	if err := d.txnEnv.WithReadContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		var err error
+		info, err = d.getUpload(txnCtx, id, auth.Permission_REPO_READ)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// finishUpload appends the parts of the upload id to its commit, in order of
+// their numbers, and deletes the upload.
+func (d *driver) finishUpload(txnCtx *txncontext.TransactionContext, id string) error {
+	info, err := d.getUpload(txnCtx, id, auth.Permission_REPO_WRITE)
+	if err != nil {
+		return err
+	}
+	for _, part := range info.Parts {
+		partID, err := fileset.ParseID(part.FileSetId)
+		if err != nil {
+			return err
+		}
+		if err := d.addFileSet(txnCtx, info.Commit, *partID); err != nil {
+			return err
+		}
+	}
+	return d.deleteUpload(txnCtx, info)
+}
+
+// abortUpload deletes the upload id, and releases its parts.
+func (d *driver) abortUpload(txnCtx *txncontext.TransactionContext, id string) error {
+	info, err := d.getUpload(txnCtx, id, auth.Permission_REPO_WRITE)
+	if err != nil {
+		return err
+	}
+	return d.deleteUpload(txnCtx, info)
+}
+
+// getUpload returns the upload id, if the caller has permission on the repo
+// of its commit.
+func (d *driver) getUpload(txnCtx *txncontext.TransactionContext, id string, permission auth.Permission) (*pfs.UploadInfo, error) {
+	info, err := pfsdb.GetUploadTx(txnCtx.SqlTx, id)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, pfsserver.ErrUploadNotFound{UploadID: id}
+	}
+	if err := d.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, info.Commit.Branch.Repo.QualifiedName(), permission); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (d *driver) deleteUpload(txnCtx *txncontext.TransactionContext, info *pfs.UploadInfo) error {
+	for _, part := range info.Parts {
+		if err := d.releaseUploadPart(txnCtx, info.UploadId, part); err != nil {
+			return err
+		}
+	}
+	return pfsdb.DeleteUploadTx(txnCtx.SqlTx, info.UploadId)
+}
+
+func (d *driver) releaseUploadPart(txnCtx *txncontext.TransactionContext, id string, part *pfs.UploadPart) error {
+	partID, err := fileset.ParseID(part.FileSetId)
+	if err != nil {
+		return err
+	}
+	return d.storage.ReleaseTx(txnCtx.SqlTx, uploadHolder(id), *partID)
+}
+
+func uploadHolder(id string) string {
+	return "upload/" + id
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/golang/protobuf/ptypes"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/storage/fileset"
+)
+
+// uploadRetryDelay is how long clients are told to wait before retrying an
+// upload that was rejected by an uploadLimiter.
+const uploadRetryDelay = 5 * time.Second
+
+// uploadBufferReservation is how many bytes an upload stream reserves at a
+// time for the content it buffers. It's small, since a stream holds what it
+// reserved until its buffer is written out, even if it buffers less.
+const uploadBufferReservation = 64 * units.KiB
+
+// uploadLimiter limits the number of upload streams (ModifyFile and
+// CreateFileSet) that pachd serves at once, overall and for each user, and
+// the number of bytes of file content that they buffer in memory at once,
+// until it's written out, so
+// that a burst of uploaders can't exhaust pachd's memory or starve
+// compaction. Uploads over a limit fail with ResourceExhausted, rather than
+// waiting, and the error tells the client when to retry.
+type uploadLimiter struct {
+	maxStreams        int
+	maxStreamsPerUser int
+	bytes             *semaphore.Weighted // nil if unlimited
+	maxBytes          int64
+
+	mu          sync.Mutex
+	streams     int
+	userStreams map[string]int
+}
+
+// newUploadLimiter returns an uploadLimiter with the given limits, each of
+// which is disabled if it's 0.
+func newUploadLimiter(maxStreams, maxStreamsPerUser int, maxBytes int64) *uploadLimiter {
+	l := &uploadLimiter{
+		maxStreams:        maxStreams,
+		maxStreamsPerUser: maxStreamsPerUser,
+		maxBytes:          maxBytes,
+		userStreams:       make(map[string]int),
+	}
+	if maxBytes > 0 {
+		l.bytes = semaphore.NewWeighted(maxBytes)
+	}
+	return l
+}
+
+// startStream reserves a stream for user, who is ignored by the per-user
+// limit if empty. The returned function releases it.
+func (l *uploadLimiter) startStream(user string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxStreams > 0 && l.streams >= l.maxStreams {
+		return nil, uploadLimitError(fmt.Sprintf("pachd is already serving the maximum of %d upload streams", l.maxStreams))
+	}
+	if user != "" && l.maxStreamsPerUser > 0 && l.userStreams[user] >= l.maxStreamsPerUser {
+		return nil, uploadLimitError(fmt.Sprintf("%s already has the maximum of %d upload streams open", user, l.maxStreamsPerUser))
+	}
+	l.streams++
+	if user != "" {
+		l.userStreams[user]++
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.streams--
+			if user != "" {
+				if l.userStreams[user]--; l.userStreams[user] == 0 {
+					delete(l.userStreams, user)
+				}
+			}
+		})
+	}, nil
+}
+
+// checkBytes returns an error if there isn't room for an upload message with
+// n bytes of content. The content itself is counted by the buffer
+// reservation of the stream's writer, see bufferOption, as it's buffered.
+func (l *uploadLimiter) checkBytes(n int64) error {
+	if l.bytes == nil || n == 0 {
+		return nil
+	}
+	if n > l.maxBytes {
+		return errors.Errorf("upload message of %d bytes is larger than the limit of %d in-flight upload bytes", n, l.maxBytes)
+	}
+	if !l.bytes.TryAcquire(n) {
+		return uploadLimitError(fmt.Sprintf("pachd is already holding the maximum of %d bytes of in-flight uploads", l.maxBytes))
+	}
+	l.bytes.Release(n)
+	return nil
+}
+
+// bufferOption returns an UnorderedWriterOption that reserves the bytes of
+// file content that an upload stream buffers, whether they were sent in the
+// stream or read from a URL. The returned function gives back what's still
+// reserved when the stream ends, in case its writer wasn't closed.
+func (l *uploadLimiter) bufferOption() (fileset.UnorderedWriterOption, func()) {
+	if l.bytes == nil {
+		return func(*fileset.UnorderedWriter) {}, func() {}
+	}
+	var mu sync.Mutex
+	var held int64
+	var done bool
+	reserve := func(n int64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return errors.Errorf("upload stream has ended")
+		}
+		if !l.bytes.TryAcquire(n) {
+			return uploadLimitError(fmt.Sprintf("pachd is already holding the maximum of %d bytes of in-flight uploads", l.maxBytes))
+		}
+		held += n
+		return nil
+	}
+	release := func(n int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		if n > held {
+			n = held
+		}
+		held -= n
+		l.bytes.Release(n)
+	}
+	size := int64(uploadBufferReservation)
+	if size > l.maxBytes {
+		size = l.maxBytes
+	}
+	return fileset.WithBufferReservation(size, reserve, release), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		l.bytes.Release(held)
+		held = 0
+		done = true
+	}
+}
+
+// uploadLimitError returns a ResourceExhausted error, with a hint to retry
+// after uploadRetryDelay.
+func uploadLimitError(msg string) error {
+	s := status.New(codes.ResourceExhausted, msg+", retry later")
+	if withRetry, err := s.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: ptypes.DurationProto(uploadRetryDelay),
+	}); err == nil {
+		s = withRetry
+	}
+	return s.Err()
+}
+
+// startUpload reserves an upload stream for the caller of ctx.
+func (a *apiServer) startUpload(ctx context.Context) (func(), error) {
+	var user string
+	if a.uploads.maxStreamsPerUser > 0 {
+		whoAmI, err := a.env.AuthServer().WhoAmI(ctx, &auth.WhoAmIRequest{})
+		if err != nil && !auth.IsErrNotActivated(err) {
+			return nil, errors.EnsureStack(err)
+		}
+		if err == nil {
+			user = whoAmI.Username
+		}
+	}
+	return a.uploads.startStream(user)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
 	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
+	"github.com/pachyderm/pachyderm/v2/src/internal/validation"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	"golang.org/x/net/context"
 )
@@ -27,8 +28,8 @@ func newValidatedAPIServer(embeddedServer *apiServer, env serviceenv.ServiceEnv)
 // DeleteRepoInTransaction is identical to DeleteRepo except that it can run
 // inside an existing etcd STM transaction.  This is not an RPC.
 func (a *validatedAPIServer) DeleteRepoInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.DeleteRepoRequest) error {
-	if request.Repo == nil {
-		return errors.New("must specify repo")
+	if err := validation.PFSRules.Validate(request); err != nil {
+		return err
 	}
 	return a.apiServer.DeleteRepoInTransaction(txnCtx, request)
 }
@@ -36,18 +37,10 @@ func (a *validatedAPIServer) DeleteRepoInTransaction(txnCtx *txncontext.Transact
 // FinishCommitInTransaction is identical to FinishCommit except that it can run
 // inside an existing etcd STM transaction.  This is not an RPC.
 func (a *validatedAPIServer) FinishCommitInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.FinishCommitRequest) error {
-	userCommit := request.Commit
-	// Validate arguments
-	if userCommit == nil {
-		return errors.New("commit cannot be nil")
-	}
-	if userCommit.Branch == nil {
-		return errors.New("commit branch cannot be nil")
-	}
-	if userCommit.Branch.Repo == nil {
-		return errors.New("commit repo cannot be nil")
+	if err := validation.PFSRules.Validate(request); err != nil {
+		return err
 	}
-	if err := a.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, userCommit.Branch.Repo.Name, auth.Permission_REPO_WRITE); err != nil {
+	if err := a.env.AuthServer().CheckRepoIsAuthorizedInTransaction(txnCtx, request.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
 		return err
 	}
 	return a.apiServer.FinishCommitInTransaction(txnCtx, request)
@@ -55,10 +48,7 @@ func (a *validatedAPIServer) FinishCommitInTransaction(txnCtx *txncontext.Transa
 
 // InspectFile implements the protobuf pfs.InspectFile RPC
 func (a *validatedAPIServer) InspectFile(ctx context.Context, request *pfs.InspectFileRequest) (response *pfs.FileInfo, retErr error) {
-	if err := validateFile(request.File); err != nil {
-		return nil, err
-	}
-	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, request.File.Commit.Branch.Repo.Name, auth.Permission_REPO_INSPECT_FILE); err != nil {
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, request.File.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_INSPECT_FILE); err != nil {
 		return nil, err
 	}
 	return a.apiServer.InspectFile(ctx, request)
@@ -66,10 +56,7 @@ func (a *validatedAPIServer) InspectFile(ctx context.Context, request *pfs.Inspe
 
 // ListFile implements the protobuf pfs.ListFile RPC
 func (a *validatedAPIServer) ListFile(request *pfs.ListFileRequest, server pfs.API_ListFileServer) (retErr error) {
-	if err := validateFile(request.File); err != nil {
-		return err
-	}
-	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), request.File.Commit.Branch.Repo.Name, auth.Permission_REPO_LIST_FILE); err != nil {
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), request.File.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_LIST_FILE); err != nil {
 		return err
 	}
 	return a.apiServer.ListFile(request, server)
@@ -78,101 +65,124 @@ func (a *validatedAPIServer) ListFile(request *pfs.ListFileRequest, server pfs.A
 // WalkFile implements the protobuf pfs.WalkFile RPC
 func (a *validatedAPIServer) WalkFile(request *pfs.WalkFileRequest, server pfs.API_WalkFileServer) (retErr error) {
 	file := request.File
-	// Validate arguments
-	if file == nil {
-		return errors.New("file cannot be nil")
-	}
-	if file.Commit == nil {
-		return errors.New("file commit cannot be nil")
-	}
-	if file.Commit.Branch == nil {
-		return errors.New("file branch cannot be nil")
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), file.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
+		return err
 	}
-	if file.Commit.Branch.Repo == nil {
-		return errors.New("file commit repo cannot be nil")
+	return a.apiServer.WalkFile(request, server)
+}
+
+// ListFileHistory implements the protobuf pfs.ListFileHistory RPC
+func (a *validatedAPIServer) ListFileHistory(request *pfs.ListFileHistoryRequest, server pfs.API_ListFileHistoryServer) (retErr error) {
+	file := request.File
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), file.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
+		return err
 	}
-	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), file.Commit.Branch.Repo.Name, auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
+	return a.apiServer.ListFileHistory(request, server)
+}
+
+// DirectorySizes implements the protobuf pfs.DirectorySizes RPC
+func (a *validatedAPIServer) DirectorySizes(request *pfs.DirectorySizesRequest, server pfs.API_DirectorySizesServer) (retErr error) {
+	file := request.File
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), file.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
 		return err
 	}
-	return a.apiServer.WalkFile(request, server)
+	return a.apiServer.DirectorySizes(request, server)
+}
+
+// ListTags implements the protobuf pfs.ListTags RPC
+func (a *validatedAPIServer) ListTags(ctx context.Context, request *pfs.ListTagsRequest) (*pfs.ListTagsResponse, error) {
+	file := request.File
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, file.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
+		return nil, err
+	}
+	return a.apiServer.ListTags(ctx, request)
 }
 
 // GlobFile implements the protobuf pfs.GlobFile RPC
 func (a *validatedAPIServer) GlobFile(request *pfs.GlobFileRequest, server pfs.API_GlobFileServer) (retErr error) {
 	commit := request.Commit
-	// Validate arguments
-	if commit == nil {
-		return errors.New("commit cannot be nil")
-	}
-	if commit.Branch == nil {
-		return errors.New("commit branch cannot be nil")
-	}
-	if commit.Branch.Repo == nil {
-		return errors.New("commit repo cannot be nil")
-	}
-	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), commit.Branch.Repo.Name, auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(server.Context(), commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ, auth.Permission_REPO_LIST_FILE); err != nil {
 		return err
 	}
 	return a.apiServer.GlobFile(request, server)
 }
 
 func (a *validatedAPIServer) ClearCommit(ctx context.Context, req *pfs.ClearCommitRequest) (*types.Empty, error) {
-	if req.Commit == nil {
-		return nil, errors.Errorf("commit cannot be nil")
-	}
-	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.Commit.Branch.Repo.Name, auth.Permission_REPO_WRITE); err != nil {
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
 		return nil, err
 	}
 	return a.apiServer.ClearCommit(ctx, req)
 }
 
-func (a *validatedAPIServer) InspectCommit(ctx context.Context, req *pfs.InspectCommitRequest) (response *pfs.CommitInfo, retErr error) {
-	if req.Commit == nil {
-		return nil, errors.New("commit cannot be nil")
+// CopyFile checks that the caller can write to the destination repo and read
+// the source repo, which can be different.
+func (a *validatedAPIServer) CopyFile(ctx context.Context, req *pfs.CopyFileRequest) (*types.Empty, error) {
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
+		return nil, err
 	}
-	return a.apiServer.InspectCommit(ctx, req)
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.CopyFile.Src.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+		return nil, err
+	}
+	return a.apiServer.CopyFile(ctx, req)
 }
 
-func (a *validatedAPIServer) InspectCommitSet(request *pfs.InspectCommitSetRequest, server pfs.API_InspectCommitSetServer) error {
-	if request.CommitSet == nil {
-		return errors.New("commitset cannot be nil")
+func (a *validatedAPIServer) RecallCommit(ctx context.Context, req *pfs.RecallCommitRequest) (*types.Empty, error) {
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
+		return nil, err
 	}
-	return a.apiServer.InspectCommitSet(request, server)
+	return a.apiServer.RecallCommit(ctx, req)
 }
 
-func (a *validatedAPIServer) SquashCommitSet(ctx context.Context, request *pfs.SquashCommitSetRequest) (*types.Empty, error) {
-	if request.CommitSet == nil {
-		return nil, errors.New("commitset cannot be nil")
+// DedupReport checks that the caller can read the repos of both commits,
+// which can be different.
+func (a *validatedAPIServer) DedupReport(ctx context.Context, req *pfs.DedupReportRequest) (*pfs.CommitDedupReport, error) {
+	if req.A == nil || req.B == nil {
+		return nil, errors.Errorf("both commits must be set")
+	}
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.A.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+		return nil, err
+	}
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.B.Branch.Repo.QualifiedName(), auth.Permission_REPO_READ); err != nil {
+		return nil, err
 	}
-	return a.apiServer.SquashCommitSet(ctx, request)
+	return a.apiServer.DedupReport(ctx, req)
 }
 
-func (a *validatedAPIServer) GetFileTAR(request *pfs.GetFileRequest, server pfs.API_GetFileTARServer) error {
-	if request.File == nil {
-		return errors.New("file cannot be nil")
+// InspectTrigger implements the protobuf pfs.InspectTrigger RPC
+func (a *validatedAPIServer) InspectTrigger(ctx context.Context, req *pfs.InspectTriggerRequest) (*pfs.TriggerInfo, error) {
+	if req.Branch == nil {
+		return nil, errors.Errorf("branch cannot be nil")
 	}
-	return a.apiServer.GetFileTAR(request, server)
+	return a.apiServer.InspectTrigger(ctx, req)
 }
 
-func (a *validatedAPIServer) CreateBranchInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.CreateBranchRequest) error {
-	if request.Head != nil && request.Branch.Repo.Name != request.Head.Branch.Repo.Name {
-		return errors.New("branch and head commit must belong to the same repo")
+// RunTrigger implements the protobuf pfs.RunTrigger RPC
+func (a *validatedAPIServer) RunTrigger(ctx context.Context, req *pfs.RunTriggerRequest) (*pfs.RunTriggerResponse, error) {
+	if req.Branch == nil {
+		return nil, errors.Errorf("branch cannot be nil")
 	}
-	return a.apiServer.CreateBranchInTransaction(txnCtx, request)
+	return a.apiServer.RunTrigger(ctx, req)
 }
 
-func validateFile(file *pfs.File) error {
-	if file == nil {
-		return errors.New("file cannot be nil")
+// StartUpload checks that the caller can write to the repo of the upload's
+// commit. The other upload RPCs check the commit that the upload was started
+// into.
+func (a *validatedAPIServer) StartUpload(ctx context.Context, req *pfs.StartUploadRequest) (*pfs.UploadInfo, error) {
+	if req.Commit == nil {
+		return nil, errors.Errorf("commit cannot be nil")
 	}
-	if file.Commit == nil {
-		return errors.New("file commit cannot be nil")
+	if err := a.env.AuthServer().CheckRepoIsAuthorized(ctx, req.Commit.Branch.Repo.QualifiedName(), auth.Permission_REPO_WRITE); err != nil {
+		return nil, err
 	}
-	if file.Commit.Branch == nil {
-		return errors.New("file branch cannot be nil")
+	return a.apiServer.StartUpload(ctx, req)
+}
+
+func (a *validatedAPIServer) CreateBranchInTransaction(txnCtx *txncontext.TransactionContext, request *pfs.CreateBranchRequest) error {
+	if err := validation.PFSRules.Validate(request); err != nil {
+		return err
 	}
-	if file.Commit.Branch.Repo == nil {
-		return errors.New("file commit repo cannot be nil")
+	if request.Head != nil && request.Branch.Repo.QualifiedName() != request.Head.Branch.Repo.QualifiedName() {
+		return errors.New("branch and head commit must belong to the same repo")
 	}
-	return nil
+	return a.apiServer.CreateBranchInTransaction(txnCtx, request)
 }
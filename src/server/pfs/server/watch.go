@@ -0,0 +1,386 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/v2/src/auth"
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfsdb"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+const (
+	watchPollInterval = 250 * time.Millisecond
+	watchBatchSize    = 1000
+	// eventsRetention is how long events are kept for, and so how long a
+	// watch token can be resumed from.
+	eventsRetention     = 24 * time.Hour
+	eventsSweepInterval = time.Hour
+)
+
+// SetupPostgresEventsV0 runs SQL to setup the events table that Watch reads,
+// which is written by triggers on the repo, branch and commit collections.
+// Events are kept for a day. SetupPostgresEventsV1 replaces its sweep
+// trigger.
+func SetupPostgresEventsV0(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		CREATE TABLE pfs.events (
+			seq BIGSERIAL PRIMARY KEY,
+			resource TEXT NOT NULL,
+			op TEXT NOT NULL,
+			proto BYTEA NOT NULL,
+			createdat TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX ON pfs.events (createdat);
+
+		CREATE FUNCTION pfs.events_trigger_fn() RETURNS TRIGGER AS $$
+		BEGIN
+			IF tg_op = 'DELETE' THEN
+				INSERT INTO pfs.events (resource, op, proto) VALUES (tg_argv[0], tg_op, old.proto);
+				RETURN old;
+			END IF;
+			IF tg_op = 'UPDATE' AND old.proto = new.proto THEN
+				RETURN new;
+			END IF;
+			INSERT INTO pfs.events (resource, op, proto) VALUES (tg_argv[0], tg_op, new.proto);
+			RETURN new;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE FUNCTION pfs.events_sweep_trigger_fn() RETURNS TRIGGER AS $$
+		BEGIN
+			DELETE FROM pfs.events WHERE createdat < now() - interval '1 day';
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER events_sweep AFTER INSERT ON pfs.events
+			FOR EACH STATEMENT EXECUTE PROCEDURE pfs.events_sweep_trigger_fn();
+
+		CREATE TRIGGER events AFTER INSERT OR UPDATE OR DELETE ON collections.repos
+			FOR EACH ROW EXECUTE PROCEDURE pfs.events_trigger_fn('repo');
+		CREATE TRIGGER events AFTER INSERT OR UPDATE OR DELETE ON collections.branches
+			FOR EACH ROW EXECUTE PROCEDURE pfs.events_trigger_fn('branch');
+		CREATE TRIGGER events AFTER INSERT OR UPDATE OR DELETE ON collections.commits
+			FOR EACH ROW EXECUTE PROCEDURE pfs.events_trigger_fn('commit');
+	`)
+	return errors.EnsureStack(err)
+}
+
+// SetupPostgresEventsV1 runs SQL to record the transaction that wrote each
+// event, which is what Watch orders events by, and the role binding of the
+// repo that a removed object was in, which is what Watch authorizes removals
+// with once the repo's own role binding is gone. It also drops the trigger
+// that swept old events on every insert; they're swept by the PFS master
+// instead, which records the last event swept in pfs.events_swept.
+//
+// Events written before this migration are ordered before all others, by
+// their sequence number, so tokens from before it still resume where they
+// left off.
+func SetupPostgresEventsV1(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		DROP TRIGGER events_sweep ON pfs.events;
+		DROP FUNCTION pfs.events_sweep_trigger_fn();
+
+		ALTER TABLE pfs.events ADD COLUMN txid BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE pfs.events ALTER COLUMN txid SET DEFAULT txid_current();
+		ALTER TABLE pfs.events ADD COLUMN role_binding BYTEA;
+		CREATE INDEX ON pfs.events (txid, seq);
+
+		CREATE TABLE pfs.events_swept (
+			txid BIGINT NOT NULL,
+			seq BIGINT NOT NULL
+		);
+		INSERT INTO pfs.events_swept (txid, seq)
+			SELECT 0, COALESCE(MIN(seq) - 1, 0) FROM pfs.events;
+
+		CREATE OR REPLACE FUNCTION pfs.events_trigger_fn() RETURNS TRIGGER AS $$
+		BEGIN
+			IF tg_op = 'DELETE' THEN
+				-- Keys start with the repo's qualified name and type, as in
+				-- "name.type@branch=id", and repos' role bindings are keyed by
+				-- their qualified name.
+				INSERT INTO pfs.events (resource, op, proto, role_binding) VALUES (tg_argv[0], tg_op, old.proto,
+					(SELECT proto FROM collections.role_bindings WHERE key = 'REPO:' || split_part(split_part(old.key, '@', 1), '.', 1)));
+				RETURN old;
+			END IF;
+			IF tg_op = 'UPDATE' AND old.proto = new.proto THEN
+				RETURN new;
+			END IF;
+			INSERT INTO pfs.events (resource, op, proto) VALUES (tg_argv[0], tg_op, new.proto);
+			RETURN new;
+		END;
+		$$ LANGUAGE plpgsql;
+	`)
+	return errors.EnsureStack(err)
+}
+
+type eventRow struct {
+	TxID        int64     `db:"txid"`
+	Seq         int64     `db:"seq"`
+	Resource    string    `db:"resource"`
+	Op          string    `db:"op"`
+	Proto       []byte    `db:"proto"`
+	RoleBinding []byte    `db:"role_binding"`
+	CreatedAt   time.Time `db:"createdat"`
+}
+
+// eventPos is the position of an event in the stream of all events. Events
+// are ordered by the transaction that wrote them, and then by the order they
+// were written in. Only the events of transactions older than every running
+// transaction are read, so no event is ever written before a position that a
+// watch has already read past.
+type eventPos struct {
+	TxID int64 `db:"txid"`
+	Seq  int64 `db:"seq"`
+}
+
+func (p eventPos) less(other eventPos) bool {
+	return p.TxID < other.TxID || (p.TxID == other.TxID && p.Seq < other.Seq)
+}
+
+// String returns p as a watch token. Events from before SetupPostgresEventsV1
+// have no transaction, and their tokens are just their sequence number.
+func (p eventPos) String() string {
+	if p.TxID == 0 {
+		return strconv.FormatInt(p.Seq, 10)
+	}
+	return fmt.Sprintf("%d-%d", p.TxID, p.Seq)
+}
+
+func parseEventPos(token string) (eventPos, error) {
+	var pos eventPos
+	parts := strings.SplitN(token, "-", 2)
+	var err error
+	if len(parts) == 2 {
+		if pos.TxID, err = strconv.ParseInt(parts[0], 10, 64); err != nil || pos.TxID <= 0 {
+			return eventPos{}, errors.Errorf("invalid watch token %q", token)
+		}
+	}
+	if pos.Seq, err = strconv.ParseInt(parts[len(parts)-1], 10, 64); err != nil || pos.Seq < 0 {
+		return eventPos{}, errors.Errorf("invalid watch token %q", token)
+	}
+	return pos, nil
+}
+
+// watch calls cb with the events after token, in order, and then with new
+// events as they're written, until ctx is done. Events for repos that the
+// caller can't read, or that aren't in repos if it's set, are left out.
+func (d *driver) watch(ctx context.Context, token string, repos []*pfs.Repo, cb func(*pfs.WatchEvent) error) error {
+	after, err := d.watchStart(ctx, token)
+	if err != nil {
+		return err
+	}
+	var selected map[string]bool
+	if len(repos) > 0 {
+		selected = make(map[string]bool)
+		for _, repo := range repos {
+			selected[pfsdb.RepoKey(repo)] = true
+		}
+	}
+	authorized := make(map[string]bool)
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		var rows []eventRow
+		if err := d.env.GetDBClient().SelectContext(ctx, &rows, `
+			SELECT txid, seq, resource, op, proto, role_binding, createdat FROM pfs.events
+			WHERE (txid, seq) > ($1, $2) AND txid < txid_snapshot_xmin(txid_current_snapshot())
+			ORDER BY txid, seq LIMIT $3
+		`, after.TxID, after.Seq, watchBatchSize); err != nil {
+			return errors.EnsureStack(err)
+		}
+		for _, row := range rows {
+			after = eventPos{TxID: row.TxID, Seq: row.Seq}
+			ev, repo, err := newWatchEvent(row)
+			if err != nil {
+				return err
+			}
+			if selected != nil && !selected[pfsdb.RepoKey(repo)] {
+				continue
+			}
+			if ok, err := d.canReadEventRepo(ctx, repo, row.RoleBinding, authorized); err != nil || !ok {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := cb(ev); err != nil {
+				return err
+			}
+		}
+		if len(rows) == watchBatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchStart returns the position that a watch from token starts after. If
+// token isn't set, it's the position after every event that can be read yet,
+// so the events of transactions that are still running are included.
+func (d *driver) watchStart(ctx context.Context, token string) (eventPos, error) {
+	db := d.env.GetDBClient()
+	if token == "" {
+		var xmin int64
+		if err := db.GetContext(ctx, &xmin, `SELECT txid_snapshot_xmin(txid_current_snapshot())`); err != nil {
+			return eventPos{}, errors.EnsureStack(err)
+		}
+		return eventPos{TxID: xmin - 1, Seq: math.MaxInt64}, nil
+	}
+	after, err := parseEventPos(token)
+	if err != nil {
+		return eventPos{}, err
+	}
+	var swept eventPos
+	if err := db.GetContext(ctx, &swept, `SELECT txid, seq FROM pfs.events_swept`); err != nil {
+		return eventPos{}, errors.EnsureStack(err)
+	}
+	if after.less(swept) {
+		return eventPos{}, errors.Errorf("watch token %q has expired, the events after it are no longer kept", token)
+	}
+	return after, nil
+}
+
+// canReadRepo returns true if the caller can read repo. Results are cached
+// in authorized, except for errors other than the caller not being
+// authorized, such as the repo having been deleted, for which it returns
+// false.
+func (d *driver) canReadRepo(ctx context.Context, repo *pfs.Repo, authorized map[string]bool) (bool, error) {
+	return d.canReadEventRepo(ctx, repo, nil, authorized)
+}
+
+// canReadEventRepo is like canReadRepo, except that if repo has been
+// deleted, along with its role binding, the caller is authorized under
+// roleBinding instead, which is the role binding that repo had when the event
+// was written. Only removals have one. The events of deleted repos without
+// one are only returned to those who can read every repo.
+func (d *driver) canReadEventRepo(ctx context.Context, repo *pfs.Repo, roleBinding []byte, authorized map[string]bool) (bool, error) {
+	key := pfsdb.RepoKey(repo)
+	if ok, cached := authorized[key]; cached {
+		return ok, nil
+	}
+	err := d.env.AuthServer().CheckRepoIsAuthorized(ctx, repo.QualifiedName(), auth.Permission_REPO_READ)
+	if ctx.Err() != nil {
+		return false, errors.EnsureStack(ctx.Err())
+	}
+	if err == nil || auth.IsErrNotAuthorized(err) {
+		authorized[key] = err == nil
+		return err == nil, nil
+	}
+	if !auth.IsErrNoRoleBinding(err) || roleBinding == nil {
+		return false, nil
+	}
+	binding := &auth.RoleBinding{}
+	if err := proto.Unmarshal(roleBinding, binding); err != nil {
+		return false, errors.EnsureStack(err)
+	}
+	err = d.env.AuthServer().CheckRoleBindingIsAuthorized(ctx, binding, &auth.Resource{Type: auth.ResourceType_REPO, Name: repo.QualifiedName()}, auth.Permission_REPO_READ)
+	if ctx.Err() != nil {
+		return false, errors.EnsureStack(ctx.Err())
+	}
+	return err == nil, nil
+}
+
+// newWatchEvent returns the event stored in row, and the repo it's about.
+func newWatchEvent(row eventRow) (*pfs.WatchEvent, *pfs.Repo, error) {
+	ev := &pfs.WatchEvent{Token: eventPos{TxID: row.TxID, Seq: row.Seq}.String()}
+	switch row.Op {
+	case "INSERT":
+		ev.Type = pfs.WatchEventType_CREATED
+	case "UPDATE":
+		ev.Type = pfs.WatchEventType_UPDATED
+	case "DELETE":
+		ev.Type = pfs.WatchEventType_REMOVED
+	default:
+		return nil, nil, errors.Errorf("unrecognized event operation %q", row.Op)
+	}
+	var err error
+	if ev.Time, err = types.TimestampProto(row.CreatedAt); err != nil {
+		return nil, nil, errors.EnsureStack(err)
+	}
+	var info proto.Message
+	var repo *pfs.Repo
+	switch row.Resource {
+	case "repo":
+		ev.Repo = &pfs.RepoInfo{}
+		info = ev.Repo
+	case "branch":
+		ev.Branch = &pfs.BranchInfo{}
+		info = ev.Branch
+	case "commit":
+		ev.Commit = &pfs.CommitInfo{}
+		info = ev.Commit
+	default:
+		return nil, nil, errors.Errorf("unrecognized event resource %q", row.Resource)
+	}
+	if err := proto.Unmarshal(row.Proto, info); err != nil {
+		return nil, nil, errors.EnsureStack(err)
+	}
+	switch {
+	case ev.Repo != nil:
+		repo = ev.Repo.Repo
+	case ev.Branch != nil:
+		repo = ev.Branch.Branch.Repo
+	default:
+		repo = ev.Commit.Commit.Branch.Repo
+	}
+	return ev, repo, nil
+}
+
+// sweepEventsForever periodically deletes the events older than
+// eventsRetention.
+func (d *driver) sweepEventsForever(ctx context.Context) error {
+	ticker := time.NewTicker(eventsSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.EnsureStack(ctx.Err())
+		case <-ticker.C:
+		}
+		if err := d.sweepEvents(ctx); err != nil {
+			log.Errorf("error sweeping events: %v", err)
+		}
+	}
+}
+
+// sweepEvents deletes the events up to the latest one that is older than
+// eventsRetention, and records it as the last event swept, so that watches
+// from before it fail rather than skip events.
+func (d *driver) sweepEvents(ctx context.Context) error {
+	return dbutil.WithTx(ctx, d.env.GetDBClient(), func(tx *sqlx.Tx) error {
+		var last []eventPos
+		if err := tx.SelectContext(ctx, &last, `
+			SELECT txid, seq FROM pfs.events
+			WHERE createdat < $1 AND txid < txid_snapshot_xmin(txid_current_snapshot())
+			ORDER BY txid DESC, seq DESC LIMIT 1
+		`, time.Now().Add(-eventsRetention)); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if len(last) == 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM pfs.events WHERE (txid, seq) <= ($1, $2)`, last[0].TxID, last[0].Seq); err != nil {
+			return errors.EnsureStack(err)
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE pfs.events_swept SET txid = $1, seq = $2`, last[0].TxID, last[0].Seq)
+		return errors.EnsureStack(err)
+	})
+}
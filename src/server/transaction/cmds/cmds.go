@@ -43,13 +43,21 @@ The following pachctl commands are supported in transactions:
   delete commit
   create branch
   delete branch
+  put file
+  copy file
+  delete file
   create pipeline
   update pipeline
 
 A transaction can be started with 'start transaction', after which the above
 commands will be stored in the transaction rather than immediately executed.
 The stored commands can be executed as a single operation with 'finish
-transaction' or cancelled with 'delete transaction'.`,
+transaction' or cancelled with 'delete transaction'.
+
+Files put, copied or deleted in a transaction are uploaded right away, but
+only written to their commits when the transaction is finished, so the
+commits must be open then, such as ones started in the transaction. Uploaded
+files are kept for a day if the transaction is neither finished nor deleted.`,
 	}
 	commands = append(commands, cmdutil.CreateDocsAlias(transactionDocs, "transaction", " transaction$"))
 
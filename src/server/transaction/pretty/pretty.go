@@ -87,6 +87,10 @@ func sprintSquashCommitSet(request *pfs.SquashCommitSetRequest) string {
 	return fmt.Sprintf("squash commitset %s", request.CommitSet.ID)
 }
 
+func sprintAddFileSet(request *pfs.AddFileSetRequest) string {
+	return fmt.Sprintf("add fileset %s to commit %s", request.FileSetId, pfspretty.CompactPrintCommit(request.Commit))
+}
+
 func sprintCreateBranch(request *pfs.CreateBranchRequest) string {
 	provenance := ""
 	for _, p := range request.Provenance {
@@ -104,6 +108,14 @@ func sprintDeleteBranch(request *pfs.DeleteBranchRequest) string {
 	return fmt.Sprintf("delete branch %s%s", request.Branch, force)
 }
 
+func sprintPromoteBranch(request *pfs.PromoteBranchRequest) string {
+	expected := ""
+	if request.ExpectedHead != nil {
+		expected = fmt.Sprintf(" --expected-head %s", request.ExpectedHead.ID)
+	}
+	return fmt.Sprintf("promote branch %s %s%s", request.From, request.To.Name, expected)
+}
+
 func sprintUpdateJobState(request *pps.UpdateJobStateRequest) string {
 	state := func() string {
 		switch request.State {
@@ -160,10 +172,14 @@ func transactionRequests(
 			line = sprintFinishCommit(request.FinishCommit)
 		} else if request.SquashCommitSet != nil {
 			line = sprintSquashCommitSet(request.SquashCommitSet)
+		} else if request.AddFileSet != nil {
+			line = sprintAddFileSet(request.AddFileSet)
 		} else if request.CreateBranch != nil {
 			line = sprintCreateBranch(request.CreateBranch)
 		} else if request.DeleteBranch != nil {
 			line = sprintDeleteBranch(request.DeleteBranch)
+		} else if request.PromoteBranch != nil {
+			line = sprintPromoteBranch(request.PromoteBranch)
 		} else if request.UpdateJobState != nil {
 			line = sprintUpdateJobState(request.UpdateJobState)
 		} else if request.CreatePipeline != nil {
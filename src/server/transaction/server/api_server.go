@@ -4,13 +4,12 @@ import (
 	"time"
 
 	"github.com/gogo/protobuf/types"
-	"github.com/jmoiron/sqlx"
 	"golang.org/x/net/context"
 
-	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
 	"github.com/pachyderm/pachyderm/v2/src/internal/log"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
 	txnenv "github.com/pachyderm/pachyderm/v2/src/internal/transactionenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/transactionenv/txncontext"
 	"github.com/pachyderm/pachyderm/v2/src/transaction"
 )
 
@@ -89,8 +88,8 @@ func (a *apiServer) DeleteAll(ctx context.Context, request *transaction.DeleteAl
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
 
-	if err := col.NewSQLTx(ctx, a.driver.db, func(sqlTx *sqlx.Tx) error {
-		return a.driver.deleteAll(ctx, sqlTx, nil)
+	if err := a.driver.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
+		return a.driver.deleteAll(txnCtx, nil)
 	}); err != nil {
 		return nil, err
 	}
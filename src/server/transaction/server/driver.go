@@ -100,10 +100,28 @@ func (d *driver) inspectTransaction(ctx context.Context, txn *transaction.Transa
 
 func (d *driver) deleteTransaction(ctx context.Context, txn *transaction.Transaction) error {
 	return d.txnEnv.WithWriteContext(ctx, func(txnCtx *txncontext.TransactionContext) error {
-		return d.transactions.ReadWrite(txnCtx.SqlTx).Delete(txn.ID)
+		transactions := d.transactions.ReadWrite(txnCtx.SqlTx)
+		info := &transaction.TransactionInfo{}
+		if err := transactions.Get(txn.ID, info); err != nil {
+			return err
+		}
+		txnCtx.ReleaseFileSets(txn.ID, addedFileSets(info))
+		return transactions.Delete(txn.ID)
 	})
 }
 
+// addedFileSets returns the IDs of the file sets that the requests in info add
+// to commits.
+func addedFileSets(info *transaction.TransactionInfo) []string {
+	var ids []string
+	for _, request := range info.Requests {
+		if request.AddFileSet != nil {
+			ids = append(ids, request.AddFileSet.FileSetId)
+		}
+	}
+	return ids
+}
+
 func (d *driver) listTransaction(ctx context.Context) ([]*transaction.TransactionInfo, error) {
 	var result []*transaction.TransactionInfo
 	transactionInfo := &transaction.TransactionInfo{}
@@ -118,20 +136,21 @@ func (d *driver) listTransaction(ctx context.Context) ([]*transaction.Transactio
 }
 
 // deleteAll deletes all transactions from etcd except the currently running
-// transaction (if any).
-func (d *driver) deleteAll(ctx context.Context, sqlTx *sqlx.Tx, running *transaction.Transaction) error {
-	txns, err := d.listTransaction(ctx)
+// transaction (if any), and releases the file sets held for them.
+func (d *driver) deleteAll(txnCtx *txncontext.TransactionContext, running *transaction.Transaction) error {
+	txns, err := d.listTransaction(txnCtx.ClientContext)
 	if err != nil {
 		return err
 	}
 
-	transactions := d.transactions.ReadWrite(sqlTx)
+	transactions := d.transactions.ReadWrite(txnCtx.SqlTx)
 	for _, info := range txns {
 		if running == nil || info.Transaction.ID != running.ID {
 			err := transactions.Delete(info.Transaction.ID)
 			if err != nil {
 				return err
 			}
+			txnCtx.ReleaseFileSets(info.Transaction.ID, addedFileSets(info))
 		}
 	}
 	return nil
@@ -162,10 +181,14 @@ func (d *driver) runTransaction(txnCtx *txncontext.TransactionContext, info *tra
 			err = directTxn.FinishCommit(request.FinishCommit)
 		} else if request.SquashCommitSet != nil {
 			err = directTxn.SquashCommitSet(request.SquashCommitSet)
+		} else if request.AddFileSet != nil {
+			err = directTxn.AddFileSet(request.AddFileSet)
 		} else if request.CreateBranch != nil {
 			err = directTxn.CreateBranch(request.CreateBranch)
 		} else if request.DeleteBranch != nil {
 			err = directTxn.DeleteBranch(request.DeleteBranch)
+		} else if request.PromoteBranch != nil {
+			err = directTxn.PromoteBranch(request.PromoteBranch)
 		} else if request.UpdateJobState != nil {
 			err = directTxn.UpdateJobState(request.UpdateJobState)
 		} else if request.DeleteAll != nil {
@@ -173,7 +196,7 @@ func (d *driver) runTransaction(txnCtx *txncontext.TransactionContext, info *tra
 			// update the client DeleteAll call to use only this, then remove unused
 			// RPCs.  This is not currently feasible because it does an orderly
 			// deletion that generates a very large transaction.
-			err = d.deleteAll(txnCtx.ClientContext, txnCtx.SqlTx, info.Transaction)
+			err = d.deleteAll(txnCtx, info.Transaction)
 		} else if request.CreatePipeline != nil {
 			if response.CreatePipelineResponse == nil {
 				response.CreatePipelineResponse = &transaction.CreatePipelineTransactionResponse{}
@@ -206,6 +229,9 @@ func (d *driver) finishTransaction(ctx context.Context, txn *transaction.Transac
 		if err := d.transactions.ReadWrite(txnCtx.SqlTx).Delete(txn.ID); err != nil {
 			return info, err
 		}
+		// the file sets written for the transaction are now referenced by its
+		// commits, so they no longer need to be held
+		txnCtx.ReleaseFileSets(txn.ID, addedFileSets(info))
 		// no need to update the transaction, since it's gone
 		// because the transaction info was read in the same sql transaction as the delete,
 		// we don't have to worry about checking for additional transaction changes
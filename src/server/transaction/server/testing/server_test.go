@@ -210,9 +210,14 @@ func TestTransactions(suite *testing.T) {
 	suite.Run("TestDeleteAllTransactions", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, testutil.NewTestDBConfig(t))
+		require.NoError(t, env.PachClient.CreateRepo("repo"))
 
-		_, err := env.PachClient.StartTransaction()
+		txn, err := env.PachClient.StartTransaction()
+		require.NoError(t, err)
+		txnClient := env.PachClient.WithTransaction(txn)
+		commit, err := txnClient.StartCommit("repo", "master")
 		require.NoError(t, err)
+		require.NoError(t, txnClient.PutFile(commit, "file", strings.NewReader("foo")))
 
 		_, err = env.PachClient.StartTransaction()
 		require.NoError(t, err)
@@ -221,12 +226,22 @@ func TestTransactions(suite *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, 2, len(txns))
 
+		// The file set written in the transaction is held for as long as
+		// the transaction exists.
+		heldFileSets := func(query string) int {
+			var n int
+			require.NoError(t, env.ServiceEnv.GetDBClient().Get(&n, query))
+			return n
+		}
+		require.Equal(t, 1, heldFileSets(`SELECT count(*) FROM storage.tracker_objects WHERE str_id LIKE 'hold/transaction/%' AND expires_at IS NULL`))
+
 		_, err = env.PachClient.TransactionAPIClient.DeleteAll(env.Context, &transaction.DeleteAllRequest{})
 		require.NoError(t, err)
 
 		txns, err = env.PachClient.ListTransaction()
 		require.NoError(t, err)
 		require.Equal(t, 0, len(txns))
+		require.Equal(t, 0, heldFileSets(`SELECT count(*) FROM storage.tracker_objects WHERE str_id LIKE 'hold/transaction/%'`))
 	})
 
 	suite.Run("TestMultiCommit", func(t *testing.T) {
@@ -391,6 +406,108 @@ func TestTransactions(suite *testing.T) {
 		require.Equal(t, txn.ID, commitInfos[0].Commit.ID)
 	})
 
+	suite.Run("TestAtomicMultiRepoCommit", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, testutil.NewTestDBConfig(t))
+		require.NoError(t, env.PachClient.CreateRepo("raw"))
+		require.NoError(t, env.PachClient.CreateRepo("meta"))
+		createFileSet := func(path, content string) string {
+			resp, err := env.PachClient.WithCreateFileSetClient(func(mf client.ModifyFile) error {
+				return mf.PutFile(path, strings.NewReader(content))
+			})
+			require.NoError(t, err)
+			return resp.FileSetId
+		}
+		rawID := createFileSet("data", "raw data")
+		metaID := createFileSet("data.json", "{}")
+
+		info, err := env.PachClient.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+			for repo, id := range map[string]string{"raw": rawID, "meta": metaID} {
+				if _, err := builder.StartCommit(repo, "master"); err != nil {
+					return err
+				}
+				if err := builder.AddFileSet(repo, "master", "", id); err != nil {
+					return err
+				}
+				if err := builder.FinishCommit(repo, "master", ""); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		for repo, file := range map[string]string{"raw": "data", "meta": "data.json"} {
+			commitInfo, err := env.PachClient.InspectCommit(repo, "master", "")
+			require.NoError(t, err)
+			require.Equal(t, info.Transaction.ID, commitInfo.Commit.ID)
+			require.NotNil(t, commitInfo.Finished)
+			_, err = env.PachClient.InspectFile(commitInfo.Commit, file)
+			require.NoError(t, err)
+		}
+
+		// If any part of the transaction fails, neither repo advances.
+		rawID = createFileSet("data2", "more raw data")
+		_, err = env.PachClient.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+			if _, err := builder.StartCommit("raw", "master"); err != nil {
+				return err
+			}
+			if err := builder.AddFileSet("raw", "master", "", rawID); err != nil {
+				return err
+			}
+			if _, err := builder.StartCommit("meta", "master"); err != nil {
+				return err
+			}
+			return builder.AddFileSet("meta", "master", "", "not-a-fileset")
+		})
+		require.YesError(t, err)
+		for _, repo := range []string{"raw", "meta"} {
+			commitInfo, err := env.PachClient.InspectCommit(repo, "master", "")
+			require.NoError(t, err)
+			require.Equal(t, info.Transaction.ID, commitInfo.Commit.ID)
+		}
+	})
+
+	suite.Run("TestModifyFileInTransaction", func(t *testing.T) {
+		t.Parallel()
+		env := testpachd.NewRealEnv(t, testutil.NewTestDBConfig(t))
+		require.NoError(t, env.PachClient.CreateRepo("raw"))
+		require.NoError(t, env.PachClient.CreateRepo("meta"))
+
+		txn, err := env.PachClient.StartTransaction()
+		require.NoError(t, err)
+		txnClient := env.PachClient.WithTransaction(txn)
+		for repo, file := range map[string]string{"raw": "data", "meta": "data.json"} {
+			commit, err := txnClient.StartCommit(repo, "master")
+			require.NoError(t, err)
+			require.NoError(t, txnClient.PutFile(commit, file, strings.NewReader(repo)))
+			require.NoError(t, txnClient.FinishCommit(repo, "master", ""))
+		}
+		// Nothing is written until the transaction is finished.
+		for _, repo := range []string{"raw", "meta"} {
+			commitInfos, err := env.PachClient.ListCommitByRepo(client.NewRepo(repo))
+			require.NoError(t, err)
+			require.Equal(t, 0, len(commitInfos))
+		}
+
+		_, err = env.PachClient.FinishTransaction(txn)
+		require.NoError(t, err)
+		for repo, file := range map[string]string{"raw": "data", "meta": "data.json"} {
+			commitInfo, err := env.PachClient.InspectCommit(repo, "master", "")
+			require.NoError(t, err)
+			require.Equal(t, txn.ID, commitInfo.Commit.ID)
+			var buf bytes.Buffer
+			require.NoError(t, env.PachClient.GetFile(commitInfo.Commit, file, &buf))
+			require.Equal(t, repo, buf.String())
+		}
+
+		// A file can't be written to a finished commit in a transaction.
+		txn, err = env.PachClient.StartTransaction()
+		require.NoError(t, err)
+		txnClient = env.PachClient.WithTransaction(txn)
+		require.YesError(t, txnClient.PutFile(client.NewCommit("raw", "master", ""), "more", strings.NewReader("more")))
+		require.NoError(t, env.PachClient.DeleteTransaction(txn))
+	})
+
 	suite.Run("TestBatchTransaction", func(t *testing.T) {
 		t.Parallel()
 		env := testpachd.NewRealEnv(t, testutil.NewTestDBConfig(t))
@@ -187,7 +187,7 @@ func NewCommitIterator(pachClient *client.APIClient, commit *pfs.Commit) Iterato
 func NewFileSetIterator(pachClient *client.APIClient, fsID string) Iterator {
 	return &fileSetIterator{
 		pachClient: pachClient,
-		commit:     client.NewRepo(client.FileSetsRepoName).NewCommit("", fsID),
+		commit:     client.NewFileSetCommit(fsID),
 	}
 }
 
@@ -71,20 +71,25 @@ var xxx_messageInfo_DeleteAllRequest proto.InternalMessageInfo
 
 type TransactionRequest struct {
 	// Exactly one of these fields should be set
-	CreateRepo           *pfs.CreateRepoRequest      `protobuf:"bytes,1,opt,name=create_repo,json=createRepo,proto3" json:"create_repo,omitempty"`
-	DeleteRepo           *pfs.DeleteRepoRequest      `protobuf:"bytes,2,opt,name=delete_repo,json=deleteRepo,proto3" json:"delete_repo,omitempty"`
-	StartCommit          *pfs.StartCommitRequest     `protobuf:"bytes,3,opt,name=start_commit,json=startCommit,proto3" json:"start_commit,omitempty"`
-	FinishCommit         *pfs.FinishCommitRequest    `protobuf:"bytes,4,opt,name=finish_commit,json=finishCommit,proto3" json:"finish_commit,omitempty"`
-	SquashCommitSet      *pfs.SquashCommitSetRequest `protobuf:"bytes,5,opt,name=squash_commit_set,json=squashCommitSet,proto3" json:"squash_commit_set,omitempty"`
-	CreateBranch         *pfs.CreateBranchRequest    `protobuf:"bytes,6,opt,name=create_branch,json=createBranch,proto3" json:"create_branch,omitempty"`
-	DeleteBranch         *pfs.DeleteBranchRequest    `protobuf:"bytes,7,opt,name=delete_branch,json=deleteBranch,proto3" json:"delete_branch,omitempty"`
-	UpdateJobState       *pps.UpdateJobStateRequest  `protobuf:"bytes,8,opt,name=update_job_state,json=updateJobState,proto3" json:"update_job_state,omitempty"`
-	CreatePipeline       *pps.CreatePipelineRequest  `protobuf:"bytes,9,opt,name=create_pipeline,json=createPipeline,proto3" json:"create_pipeline,omitempty"`
-	StopJob              *pps.StopJobRequest         `protobuf:"bytes,10,opt,name=stop_job,json=stopJob,proto3" json:"stop_job,omitempty"`
-	DeleteAll            *DeleteAllRequest           `protobuf:"bytes,11,opt,name=delete_all,json=deleteAll,proto3" json:"delete_all,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
-	XXX_unrecognized     []byte                      `json:"-"`
-	XXX_sizecache        int32                       `json:"-"`
+	CreateRepo      *pfs.CreateRepoRequest      `protobuf:"bytes,1,opt,name=create_repo,json=createRepo,proto3" json:"create_repo,omitempty"`
+	DeleteRepo      *pfs.DeleteRepoRequest      `protobuf:"bytes,2,opt,name=delete_repo,json=deleteRepo,proto3" json:"delete_repo,omitempty"`
+	StartCommit     *pfs.StartCommitRequest     `protobuf:"bytes,3,opt,name=start_commit,json=startCommit,proto3" json:"start_commit,omitempty"`
+	FinishCommit    *pfs.FinishCommitRequest    `protobuf:"bytes,4,opt,name=finish_commit,json=finishCommit,proto3" json:"finish_commit,omitempty"`
+	SquashCommitSet *pfs.SquashCommitSetRequest `protobuf:"bytes,5,opt,name=squash_commit_set,json=squashCommitSet,proto3" json:"squash_commit_set,omitempty"`
+	CreateBranch    *pfs.CreateBranchRequest    `protobuf:"bytes,6,opt,name=create_branch,json=createBranch,proto3" json:"create_branch,omitempty"`
+	DeleteBranch    *pfs.DeleteBranchRequest    `protobuf:"bytes,7,opt,name=delete_branch,json=deleteBranch,proto3" json:"delete_branch,omitempty"`
+	UpdateJobState  *pps.UpdateJobStateRequest  `protobuf:"bytes,8,opt,name=update_job_state,json=updateJobState,proto3" json:"update_job_state,omitempty"`
+	CreatePipeline  *pps.CreatePipelineRequest  `protobuf:"bytes,9,opt,name=create_pipeline,json=createPipeline,proto3" json:"create_pipeline,omitempty"`
+	StopJob         *pps.StopJobRequest         `protobuf:"bytes,10,opt,name=stop_job,json=stopJob,proto3" json:"stop_job,omitempty"`
+	DeleteAll       *DeleteAllRequest           `protobuf:"bytes,11,opt,name=delete_all,json=deleteAll,proto3" json:"delete_all,omitempty"`
+	// add_file_set adds a fileset to a commit, which is usually started by an
+	// earlier request in the same transaction. The fileset must not expire
+	// before the transaction is finished.
+	AddFileSet           *pfs.AddFileSetRequest    `protobuf:"bytes,12,opt,name=add_file_set,json=addFileSet,proto3" json:"add_file_set,omitempty"`
+	PromoteBranch        *pfs.PromoteBranchRequest `protobuf:"bytes,13,opt,name=promote_branch,json=promoteBranch,proto3" json:"promote_branch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
 }
 
 func (m *TransactionRequest) Reset()         { *m = TransactionRequest{} }
@@ -197,6 +202,20 @@ func (m *TransactionRequest) GetDeleteAll() *DeleteAllRequest {
 	return nil
 }
 
+func (m *TransactionRequest) GetAddFileSet() *pfs.AddFileSetRequest {
+	if m != nil {
+		return m.AddFileSet
+	}
+	return nil
+}
+
+func (m *TransactionRequest) GetPromoteBranch() *pfs.PromoteBranchRequest {
+	if m != nil {
+		return m.PromoteBranch
+	}
+	return nil
+}
+
 type TransactionResponse struct {
 	// At most, one of these fields should be set (most responses are empty)
 	Commit                 *pfs.Commit                        `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"`
@@ -766,65 +785,68 @@ func init() {
 func init() { proto.RegisterFile("transaction/transaction.proto", fileDescriptor_284c03442be38d9f) }
 
 var fileDescriptor_284c03442be38d9f = []byte{
-	// 928 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdd, 0x72, 0xdb, 0x44,
-	0x14, 0x8e, 0x9d, 0xe6, 0xc7, 0xc7, 0x6d, 0xec, 0x9c, 0x82, 0xab, 0x38, 0x53, 0x27, 0x88, 0xa1,
-	0x84, 0x1b, 0x79, 0x62, 0xb8, 0x62, 0x06, 0x4a, 0xd2, 0xd2, 0x8e, 0x33, 0x5c, 0x74, 0xe4, 0x02,
-	0x93, 0xcc, 0x50, 0x21, 0x4b, 0x2b, 0x5b, 0x60, 0x6b, 0xb7, 0xda, 0xb5, 0x99, 0xbe, 0x01, 0xef,
-	0xc1, 0x1d, 0x4f, 0xc2, 0x25, 0x4f, 0xc0, 0x30, 0x7e, 0x12, 0x46, 0xbb, 0x2b, 0x45, 0x92, 0xed,
-	0xa4, 0x4c, 0x7b, 0x27, 0x7d, 0x67, 0xbf, 0x6f, 0xbf, 0x3d, 0xe7, 0xec, 0x91, 0xe0, 0xa1, 0x88,
-	0xdd, 0x88, 0xbb, 0x9e, 0x08, 0x69, 0xd4, 0xcd, 0x3d, 0x5b, 0x2c, 0xa6, 0x82, 0xe2, 0x5e, 0x0e,
-	0x72, 0xe6, 0xbd, 0xf6, 0xe1, 0x88, 0xd2, 0xd1, 0x84, 0x74, 0x65, 0x74, 0x38, 0x0b, 0xba, 0x64,
-	0xca, 0xc4, 0x1b, 0xb5, 0xb8, 0x7d, 0x54, 0x0e, 0x8a, 0x70, 0x4a, 0xb8, 0x70, 0xa7, 0x4c, 0x2f,
-	0xf8, 0x60, 0x44, 0x47, 0x54, 0x3e, 0x76, 0x93, 0x27, 0x8d, 0xde, 0x63, 0x01, 0xef, 0xb2, 0x80,
-	0x67, 0xaf, 0x8c, 0x77, 0x19, 0xd3, 0xaf, 0x26, 0x42, 0xf3, 0x29, 0x99, 0x10, 0x41, 0xce, 0x26,
-	0x13, 0x9b, 0xbc, 0x9e, 0x11, 0x2e, 0xcc, 0xc5, 0x16, 0xe0, 0xcb, 0x6b, 0x63, 0x1a, 0xc6, 0x2f,
-	0xa1, 0xee, 0xc5, 0xc4, 0x15, 0xc4, 0x89, 0x09, 0xa3, 0x46, 0xe5, 0xb8, 0x72, 0x52, 0xef, 0x1d,
-	0x58, 0x2c, 0xe0, 0xce, 0xbc, 0x67, 0x3d, 0x91, 0x21, 0x9b, 0x30, 0xaa, 0xd7, 0xdb, 0xe0, 0x65,
-	0x50, 0xc2, 0xf5, 0xe5, 0x36, 0x8a, 0x5b, 0x2d, 0x72, 0x95, 0x83, 0x02, 0xd7, 0xcf, 0x20, 0xfc,
-	0x0a, 0xee, 0x72, 0xe1, 0xc6, 0xc2, 0xf1, 0xe8, 0x74, 0x1a, 0x0a, 0x63, 0x53, 0x92, 0xdb, 0x29,
-	0x79, 0x90, 0xc4, 0x9e, 0xc8, 0x50, 0xca, 0xae, 0xf3, 0x6b, 0x0c, 0xbf, 0x81, 0x7b, 0x41, 0x18,
-	0x85, 0x7c, 0x9c, 0xf2, 0xef, 0x48, 0xfe, 0x61, 0xca, 0x7f, 0x26, 0x83, 0x45, 0x81, 0xbb, 0x41,
-	0x0e, 0xc4, 0x0b, 0xd8, 0xe7, 0xaf, 0x67, 0x6e, 0xa6, 0xe0, 0x70, 0x22, 0x8c, 0x2d, 0xa9, 0xd2,
-	0xc9, 0x5c, 0xc8, 0x05, 0x8a, 0x30, 0x20, 0x99, 0x50, 0x83, 0x17, 0xf1, 0xc4, 0x8d, 0x4e, 0xe2,
-	0x30, 0x76, 0x23, 0x6f, 0x6c, 0x6c, 0x17, 0xdd, 0xa8, 0x34, 0x9e, 0xcb, 0x58, 0xe6, 0xc6, 0xcb,
-	0x81, 0x89, 0x82, 0x4e, 0xa5, 0x56, 0xd8, 0x29, 0x2a, 0xa8, 0x64, 0x96, 0x14, 0xfc, 0x1c, 0x88,
-	0xcf, 0xa1, 0x39, 0x63, 0x7e, 0xe2, 0xe1, 0x17, 0x3a, 0x74, 0xb8, 0x70, 0x05, 0x31, 0x76, 0xa5,
-	0xc8, 0x43, 0x8b, 0x31, 0x29, 0xf2, 0xbd, 0x8c, 0x5f, 0xd0, 0xe1, 0x40, 0xc8, 0x12, 0x2a, 0x99,
-	0xbd, 0x59, 0x01, 0xc6, 0x67, 0xd0, 0xd0, 0x87, 0x61, 0x21, 0x23, 0x93, 0x30, 0x22, 0x46, 0xad,
-	0xa8, 0xa3, 0x8e, 0xf3, 0x42, 0x47, 0x33, 0x1d, 0xaf, 0x00, 0xe3, 0x29, 0xec, 0x72, 0x41, 0x59,
-	0x62, 0xc7, 0x00, 0x29, 0xd0, 0x4a, 0x05, 0x06, 0x82, 0xb2, 0x0b, 0x3a, 0x4c, 0x99, 0x3b, 0x5c,
-	0xbd, 0xe3, 0x63, 0xd0, 0x2d, 0xe2, 0xb8, 0x93, 0x89, 0x51, 0x97, 0xa4, 0x63, 0xab, 0x78, 0x9d,
-	0xac, 0x72, 0x67, 0xdb, 0x35, 0x3f, 0x45, 0xcc, 0x3f, 0x2b, 0x70, 0xbf, 0xd0, 0xe4, 0x9c, 0xd1,
-	0x88, 0x13, 0x7c, 0x04, 0xdb, 0xba, 0x4f, 0x54, 0x83, 0xef, 0x65, 0x95, 0x51, 0x1d, 0xa2, 0xa3,
-	0xf8, 0x2b, 0x18, 0xa5, 0xb3, 0x3b, 0xb1, 0xd6, 0xd0, 0xed, 0x7d, 0x5a, 0xb6, 0x53, 0x4c, 0xc6,
-	0x8a, 0xcd, 0xed, 0x96, 0x57, 0xca, 0x97, 0xc2, 0xcd, 0xdf, 0xe0, 0xa3, 0x5b, 0xc9, 0xd8, 0x81,
-	0x7a, 0x10, 0x4e, 0x48, 0xd2, 0x9d, 0x4e, 0xe8, 0x4b, 0xfb, 0x35, 0xbb, 0x96, 0x40, 0x03, 0x22,
-	0xfa, 0x3e, 0xf6, 0xe0, 0x43, 0x16, 0x93, 0xf9, 0xb5, 0xdf, 0x39, 0x89, 0x79, 0x48, 0x23, 0x69,
-	0xf7, 0x8e, 0x7d, 0x3f, 0x09, 0xa6, 0xfa, 0x3f, 0xa8, 0x90, 0xf9, 0x09, 0xd4, 0x73, 0x5b, 0x61,
-	0x0b, 0xaa, 0xa9, 0xf2, 0xf9, 0xf6, 0xe2, 0x9f, 0xa3, 0x6a, 0xff, 0xa9, 0x5d, 0x0d, 0x7d, 0xf3,
-	0x8f, 0x2a, 0x34, 0x72, 0xeb, 0xfa, 0x51, 0x90, 0x5c, 0xdb, 0x7a, 0xee, 0xfc, 0x3a, 0x9b, 0x87,
-	0xe5, 0x9c, 0xe4, 0x0f, 0x92, 0x5f, 0x8f, 0x5f, 0xc3, 0x6e, 0xac, 0xaa, 0xc6, 0x8d, 0xea, 0xf1,
-	0xe6, 0x49, 0xbd, 0x67, 0xde, 0xc4, 0xd5, 0x05, 0xce, 0x38, 0x78, 0x06, 0xb5, 0xb4, 0x1e, 0xdc,
-	0xd8, 0x94, 0x02, 0x1f, 0xdf, 0x28, 0xa0, 0x4b, 0x70, 0xcd, 0xc2, 0x2f, 0x60, 0x47, 0x0e, 0x12,
-	0xe2, 0xeb, 0x99, 0xd1, 0xb6, 0xd4, 0x08, 0xb6, 0xd2, 0x11, 0x6c, 0xbd, 0x4c, 0x47, 0xb0, 0x9d,
-	0x2e, 0x45, 0x03, 0x76, 0xd2, 0xc4, 0x6e, 0xc9, 0xc4, 0xa6, 0xaf, 0xe6, 0x2b, 0x68, 0x96, 0x92,
-	0xc4, 0xf1, 0x02, 0x9a, 0x79, 0x53, 0x61, 0x14, 0x24, 0x93, 0x35, 0x71, 0x7b, 0x74, 0x83, 0xdb,
-	0x84, 0x6b, 0x37, 0x44, 0x11, 0x30, 0x2f, 0xe1, 0xc1, 0xb9, 0x2b, 0xbc, 0xf1, 0x8a, 0xd9, 0x9d,
-	0xcf, 0x66, 0xe5, 0xff, 0x67, 0xd3, 0x3c, 0x80, 0x07, 0x72, 0xce, 0x2e, 0x2f, 0x32, 0xaf, 0xe0,
-	0xa0, 0x1f, 0x71, 0x46, 0xbc, 0x15, 0xc1, 0x77, 0x6c, 0x02, 0xf3, 0x12, 0x0c, 0x75, 0x87, 0xdf,
-	0xbf, 0xb4, 0x01, 0xad, 0xef, 0x42, 0xbe, 0xea, 0x40, 0x97, 0x60, 0xa8, 0x6f, 0xc2, 0x7b, 0xdf,
-	0xb4, 0xf7, 0xfb, 0x16, 0x6c, 0x9e, 0xbd, 0xe8, 0xe3, 0x2b, 0x68, 0x96, 0x2b, 0x85, 0x9f, 0x96,
-	0x55, 0xd6, 0xd4, 0xb2, 0x7d, 0x5b, 0x63, 0x98, 0x1b, 0x78, 0x05, 0xcd, 0x72, 0xb9, 0x96, 0xf5,
-	0xd7, 0x14, 0xb4, 0x7d, 0xd3, 0x71, 0xcc, 0x0d, 0x1c, 0x02, 0x2e, 0xd7, 0x1b, 0x3f, 0x2b, 0x93,
-	0xd6, 0xf6, 0xc4, 0xdb, 0xf8, 0xff, 0x11, 0xf6, 0x97, 0xea, 0x8e, 0x27, 0xab, 0xc7, 0xfb, 0x8a,
-	0x1d, 0x5a, 0x4b, 0xf7, 0xf4, 0xdb, 0xe4, 0x3f, 0xca, 0xdc, 0xc0, 0x9f, 0xa0, 0x51, 0xaa, 0x3a,
-	0x3e, 0x2a, 0xcb, 0xae, 0x6e, 0x8b, 0xf6, 0xf1, 0x2d, 0xb6, 0xb9, 0xb9, 0x81, 0x3f, 0xc3, 0xfe,
-	0x52, 0xeb, 0x2c, 0xfb, 0x5e, 0xd7, 0x5d, 0x6f, 0x93, 0x99, 0xe7, 0x50, 0xcb, 0xbe, 0x6a, 0x78,
-	0xeb, 0x07, 0x6f, 0x7d, 0x26, 0xce, 0x1f, 0xff, 0xb5, 0xe8, 0x54, 0xfe, 0x5e, 0x74, 0x2a, 0xff,
-	0x2e, 0x3a, 0x95, 0xab, 0xd3, 0x51, 0x28, 0xc6, 0xb3, 0xa1, 0xe5, 0xd1, 0x69, 0x97, 0xb9, 0xde,
-	0xf8, 0x8d, 0x4f, 0xe2, 0xfc, 0xd3, 0xbc, 0xd7, 0xe5, 0xb1, 0x97, 0xff, 0x83, 0x1d, 0x6e, 0x4b,
-	0xc9, 0xcf, 0xff, 0x0b, 0x00, 0x00, 0xff, 0xff, 0xcc, 0x29, 0xc6, 0xe3, 0xe3, 0x0a, 0x00, 0x00,
+	// 970 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdd, 0x6e, 0xe3, 0x44,
+	0x14, 0x6e, 0xd2, 0xed, 0x4f, 0x4e, 0xda, 0x26, 0x9d, 0x85, 0xac, 0x9b, 0xb2, 0x69, 0x31, 0x62,
+	0x29, 0x37, 0x8e, 0x1a, 0xb8, 0x02, 0xc1, 0xd2, 0x76, 0xe9, 0x2a, 0x15, 0x17, 0x95, 0xbb, 0x80,
+	0x5a, 0x89, 0x35, 0x8e, 0x3d, 0x4e, 0x0c, 0x8e, 0x67, 0xd6, 0x33, 0x09, 0xda, 0x37, 0xe0, 0x3d,
+	0xb8, 0xe3, 0x49, 0xb8, 0xe4, 0x09, 0x10, 0xaa, 0x78, 0x10, 0xe4, 0xf9, 0x71, 0x6c, 0x27, 0x69,
+	0x17, 0xb1, 0x77, 0x9e, 0xef, 0xcc, 0xf7, 0xcd, 0x99, 0xf3, 0x9d, 0x99, 0x31, 0x3c, 0xe6, 0x89,
+	0x1b, 0x33, 0xd7, 0xe3, 0x21, 0x89, 0xbb, 0xb9, 0x6f, 0x8b, 0x26, 0x84, 0x13, 0xb4, 0x93, 0x83,
+	0x9c, 0x69, 0xaf, 0xbd, 0x3f, 0x24, 0x64, 0x18, 0xe1, 0xae, 0x88, 0x0e, 0x26, 0x41, 0x17, 0x8f,
+	0x29, 0x7f, 0x2d, 0x27, 0xb7, 0x0f, 0xca, 0x41, 0x1e, 0x8e, 0x31, 0xe3, 0xee, 0x98, 0xaa, 0x09,
+	0xef, 0x0c, 0xc9, 0x90, 0x88, 0xcf, 0x6e, 0xfa, 0xa5, 0xd0, 0x6d, 0x1a, 0xb0, 0x2e, 0x0d, 0x58,
+	0x36, 0xa4, 0xac, 0x4b, 0xa9, 0x1a, 0x9a, 0x08, 0x9a, 0xcf, 0x70, 0x84, 0x39, 0x3e, 0x89, 0x22,
+	0x1b, 0xbf, 0x9a, 0x60, 0xc6, 0xcd, 0x7f, 0xd6, 0x01, 0xbd, 0x98, 0x25, 0xa6, 0x60, 0xf4, 0x19,
+	0xd4, 0xbd, 0x04, 0xbb, 0x1c, 0x3b, 0x09, 0xa6, 0xc4, 0xa8, 0x1c, 0x56, 0x8e, 0xea, 0xbd, 0x3d,
+	0x8b, 0x06, 0xcc, 0x99, 0xf6, 0xac, 0x33, 0x11, 0xb2, 0x31, 0x25, 0x6a, 0xbe, 0x0d, 0x5e, 0x06,
+	0xa5, 0x5c, 0x5f, 0x2c, 0x23, 0xb9, 0xd5, 0x22, 0x57, 0x66, 0x50, 0xe0, 0xfa, 0x19, 0x84, 0xbe,
+	0x80, 0x2d, 0xc6, 0xdd, 0x84, 0x3b, 0x1e, 0x19, 0x8f, 0x43, 0x6e, 0xac, 0x0a, 0x72, 0x5b, 0x93,
+	0xaf, 0xd2, 0xd8, 0x99, 0x08, 0x69, 0x76, 0x9d, 0xcd, 0x30, 0xf4, 0x15, 0x6c, 0x07, 0x61, 0x1c,
+	0xb2, 0x91, 0xe6, 0x3f, 0x10, 0xfc, 0x7d, 0xcd, 0x3f, 0x17, 0xc1, 0xa2, 0xc0, 0x56, 0x90, 0x03,
+	0xd1, 0x05, 0xec, 0xb2, 0x57, 0x13, 0x37, 0x53, 0x70, 0x18, 0xe6, 0xc6, 0x9a, 0x50, 0xe9, 0x64,
+	0x59, 0x88, 0x09, 0x92, 0x70, 0x85, 0x33, 0xa1, 0x06, 0x2b, 0xe2, 0x69, 0x36, 0xaa, 0x88, 0x83,
+	0xc4, 0x8d, 0xbd, 0x91, 0xb1, 0x5e, 0xcc, 0x46, 0x96, 0xf1, 0x54, 0xc4, 0xb2, 0x6c, 0xbc, 0x1c,
+	0x98, 0x2a, 0xa8, 0x52, 0x2a, 0x85, 0x8d, 0xa2, 0x82, 0x2c, 0x66, 0x49, 0xc1, 0xcf, 0x81, 0xe8,
+	0x39, 0x34, 0x27, 0xd4, 0x4f, 0x73, 0xf8, 0x89, 0x0c, 0x1c, 0xc6, 0x5d, 0x8e, 0x8d, 0x4d, 0x21,
+	0xf2, 0xd8, 0xa2, 0x54, 0x88, 0x7c, 0x2b, 0xe2, 0x17, 0x64, 0x70, 0xc5, 0x85, 0x85, 0x52, 0x66,
+	0x67, 0x52, 0x80, 0xd1, 0x39, 0x34, 0xd4, 0x66, 0x68, 0x48, 0x71, 0x14, 0xc6, 0xd8, 0xa8, 0x15,
+	0x75, 0xe4, 0x76, 0x2e, 0x55, 0x34, 0xd3, 0xf1, 0x0a, 0x30, 0x3a, 0x86, 0x4d, 0xc6, 0x09, 0x4d,
+	0xd3, 0x31, 0x40, 0x08, 0xb4, 0xb4, 0xc0, 0x15, 0x27, 0xf4, 0x82, 0x0c, 0x34, 0x73, 0x83, 0xc9,
+	0x31, 0x7a, 0x0a, 0xaa, 0x45, 0x1c, 0x37, 0x8a, 0x8c, 0xba, 0x20, 0x1d, 0x5a, 0xc5, 0xe3, 0x64,
+	0x95, 0x3b, 0xdb, 0xae, 0xf9, 0x1a, 0x41, 0x9f, 0xc3, 0x96, 0xeb, 0xfb, 0x4e, 0x10, 0x46, 0x58,
+	0xf8, 0xb9, 0x55, 0x6c, 0xc9, 0x13, 0xdf, 0x3f, 0x0f, 0x23, 0x9c, 0xb3, 0x12, 0xdc, 0x0c, 0x42,
+	0x67, 0xb0, 0x43, 0x13, 0x32, 0x26, 0x33, 0x13, 0xb6, 0x05, 0xfd, 0x3d, 0x4d, 0xbf, 0x94, 0xd1,
+	0xa2, 0x0b, 0xdb, 0x34, 0x8f, 0x9a, 0xbf, 0x57, 0xe0, 0x61, 0xe1, 0x98, 0x31, 0x4a, 0x62, 0x86,
+	0xd1, 0x13, 0x58, 0x57, 0x9d, 0x2a, 0x8f, 0xd8, 0x4e, 0xd6, 0x1b, 0x02, 0xb5, 0x55, 0x14, 0xfd,
+	0x0c, 0x46, 0xa9, 0xfa, 0x4e, 0xa2, 0x34, 0xd4, 0x01, 0x3b, 0x2e, 0x17, 0xa4, 0x68, 0xc7, 0x82,
+	0xc5, 0xed, 0x96, 0x57, 0x72, 0x4c, 0xe2, 0xe6, 0x2f, 0xf0, 0xfe, 0xbd, 0x64, 0xd4, 0x81, 0xba,
+	0xae, 0xa7, 0x13, 0xfa, 0x22, 0xfd, 0x9a, 0x5d, 0x0b, 0x64, 0xd1, 0xfa, 0x3e, 0xea, 0xc1, 0xbb,
+	0x34, 0xc1, 0xd3, 0x59, 0xbe, 0x53, 0x9c, 0xb0, 0x90, 0xc4, 0x22, 0xdd, 0x07, 0xf6, 0xc3, 0x34,
+	0xa8, 0xf5, 0xbf, 0x93, 0x21, 0xf3, 0x43, 0xa8, 0xe7, 0x96, 0x42, 0x2d, 0xa8, 0x6a, 0xe5, 0xd3,
+	0xf5, 0xdb, 0xbf, 0x0e, 0xaa, 0xfd, 0x67, 0x76, 0x35, 0xf4, 0xcd, 0xdf, 0xaa, 0xd0, 0xc8, 0xcd,
+	0xeb, 0xc7, 0x41, 0x7a, 0x71, 0xd4, 0x73, 0xfb, 0x57, 0xd5, 0xdc, 0x2f, 0xd7, 0x24, 0xbf, 0x91,
+	0xfc, 0x7c, 0xf4, 0x25, 0x6c, 0x26, 0xd2, 0x39, 0x66, 0x54, 0x0f, 0x57, 0x8f, 0xea, 0x3d, 0xf3,
+	0x2e, 0xae, 0x32, 0x39, 0xe3, 0xa0, 0x13, 0xa8, 0x69, 0x3f, 0x98, 0xb1, 0x2a, 0x04, 0x3e, 0xb8,
+	0x53, 0x40, 0x59, 0x30, 0x63, 0xa1, 0x4f, 0x61, 0x43, 0x5c, 0x65, 0xd8, 0x57, 0xb7, 0x56, 0xdb,
+	0x92, 0x8f, 0x80, 0xa5, 0x1f, 0x01, 0xeb, 0x85, 0x7e, 0x04, 0x6c, 0x3d, 0x15, 0x19, 0xb0, 0xa1,
+	0x0b, 0xbb, 0x26, 0x0a, 0xab, 0x87, 0xe6, 0x4b, 0x68, 0x96, 0x8a, 0xc4, 0xd0, 0x05, 0x34, 0xf3,
+	0x49, 0x85, 0x71, 0x90, 0xde, 0xed, 0x69, 0xb6, 0x07, 0x77, 0x64, 0x9b, 0x72, 0xed, 0x06, 0x2f,
+	0x02, 0xe6, 0x35, 0x3c, 0x3a, 0x75, 0xb9, 0x37, 0x5a, 0xf0, 0x7a, 0xe4, 0xab, 0x59, 0xf9, 0xef,
+	0xd5, 0x34, 0xf7, 0xe0, 0x91, 0xb8, 0xe9, 0xe7, 0x27, 0x99, 0x37, 0xb0, 0xd7, 0x8f, 0x19, 0xc5,
+	0xde, 0x82, 0xe0, 0xff, 0x6c, 0x02, 0xf3, 0x1a, 0x0c, 0x79, 0x8b, 0xbc, 0x7d, 0x69, 0x03, 0x5a,
+	0xdf, 0x84, 0x6c, 0xd1, 0x86, 0xae, 0xc1, 0x90, 0xaf, 0xd2, 0x5b, 0x5f, 0xb4, 0xf7, 0xeb, 0x1a,
+	0xac, 0x9e, 0x5c, 0xf6, 0xd1, 0x4b, 0x68, 0x96, 0x9d, 0x42, 0x1f, 0x95, 0x55, 0x96, 0x78, 0xd9,
+	0xbe, 0xaf, 0x31, 0xcc, 0x15, 0x74, 0x03, 0xcd, 0xb2, 0x5d, 0xf3, 0xfa, 0x4b, 0x0c, 0x6d, 0xdf,
+	0xb5, 0x1d, 0x73, 0x05, 0x0d, 0x00, 0xcd, 0xfb, 0x8d, 0x3e, 0x2e, 0x93, 0x96, 0xf6, 0xc4, 0x9b,
+	0xe4, 0xff, 0x3d, 0xec, 0xce, 0xf9, 0x8e, 0x8e, 0x16, 0x3f, 0x30, 0x0b, 0x56, 0x68, 0xcd, 0x9d,
+	0xd3, 0xaf, 0xd3, 0x3f, 0x39, 0x73, 0x05, 0xfd, 0x00, 0x8d, 0x92, 0xeb, 0xe8, 0x49, 0x59, 0x76,
+	0x71, 0x5b, 0xb4, 0x0f, 0xef, 0x49, 0x9b, 0x99, 0x2b, 0xe8, 0x47, 0xd8, 0x9d, 0x6b, 0x9d, 0xf9,
+	0xbc, 0x97, 0x75, 0xd7, 0x9b, 0x54, 0xe6, 0x39, 0xd4, 0xb2, 0x77, 0x15, 0xdd, 0xfb, 0xe4, 0x2e,
+	0xaf, 0xc4, 0xe9, 0xd3, 0x3f, 0x6e, 0x3b, 0x95, 0x3f, 0x6f, 0x3b, 0x95, 0xbf, 0x6f, 0x3b, 0x95,
+	0x9b, 0xe3, 0x61, 0xc8, 0x47, 0x93, 0x81, 0xe5, 0x91, 0x71, 0x97, 0xba, 0xde, 0xe8, 0xb5, 0x8f,
+	0x93, 0xfc, 0xd7, 0xb4, 0xd7, 0x65, 0x89, 0x97, 0xff, 0x87, 0x1e, 0xac, 0x0b, 0xc9, 0x4f, 0xfe,
+	0x1d, 0x00, 0x00, 0x31, 0xb3, 0xb1, 0x65, 0x0b, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1176,6 +1198,30 @@ func (m *TransactionRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.PromoteBranch != nil {
+		{
+			size, err := m.PromoteBranch.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTransaction(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x6a
+	}
+	if m.AddFileSet != nil {
+		{
+			size, err := m.AddFileSet.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTransaction(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
 	if m.DeleteAll != nil {
 		{
 			size, err := m.DeleteAll.MarshalToSizedBuffer(dAtA[:i])
@@ -1845,6 +1891,14 @@ func (m *TransactionRequest) Size() (n int) {
 		l = m.DeleteAll.Size()
 		n += 1 + l + sovTransaction(uint64(l))
 	}
+	if m.AddFileSet != nil {
+		l = m.AddFileSet.Size()
+		n += 1 + l + sovTransaction(uint64(l))
+	}
+	if m.PromoteBranch != nil {
+		l = m.PromoteBranch.Size()
+		n += 1 + l + sovTransaction(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -2531,6 +2585,78 @@ func (m *TransactionRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddFileSet", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransaction
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTransaction
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTransaction
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AddFileSet == nil {
+				m.AddFileSet = &pfs.AddFileSetRequest{}
+			}
+			if err := m.AddFileSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PromoteBranch", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransaction
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTransaction
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTransaction
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.PromoteBranch == nil {
+				m.PromoteBranch = &pfs.PromoteBranchRequest{}
+			}
+			if err := m.PromoteBranch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTransaction(dAtA[iNdEx:])